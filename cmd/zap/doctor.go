@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/blackcoderx/zap/pkg/tui"
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the ZAP setup and print fixes for any problems",
+	Long: `Validate .zap/config.json, check that the LLM provider is reachable,
+verify the .zap folders and manifest, and check file permissions.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// API keys may come from .env, same as in interactive mode
+		_ = godotenv.Load()
+
+		checks := core.DiagnoseZapFolder(core.ZapFolderName)
+		if _, err := os.Stat(core.ZapFolderName); err == nil {
+			checks = append(checks, checkLLMConnection())
+		}
+
+		failed, warned := 0, 0
+		for _, check := range checks {
+			printDoctorCheck(check)
+			switch check.Status {
+			case core.DoctorFail:
+				failed++
+			case core.DoctorWarn:
+				warned++
+			}
+		}
+
+		fmt.Println()
+		if failed > 0 {
+			fmt.Printf("%d problem(s), %d warning(s)\n", failed, warned)
+			os.Exit(1)
+		}
+		if warned > 0 {
+			fmt.Printf("No problems, %d warning(s)\n", warned)
+			return
+		}
+		fmt.Println("Everything looks good")
+	},
+}
+
+// checkLLMConnection builds the configured LLM client and calls CheckConnection.
+func checkLLMConnection() core.DoctorCheck {
+	client, err := tui.NewLLMClient()
+	if err != nil {
+		return core.DoctorCheck{
+			Name:   "LLM provider",
+			Status: core.DoctorFail,
+			Detail: err.Error(),
+			Fix:    "Fix the provider settings in .zap/config.json",
+		}
+	}
+
+	info := client.ModelInfo()
+	name := fmt.Sprintf("LLM provider (%s, %s)", info.Provider, info.Model)
+	if err := client.CheckConnection(); err != nil {
+		fix := "Check your network connection and API key"
+		if info.Provider == "ollama" || info.Provider == "" {
+			fix = "Start Ollama with `ollama serve` or check ollama.url in .zap/config.json"
+		}
+		return core.DoctorCheck{Name: name, Status: core.DoctorFail, Detail: err.Error(), Fix: fix}
+	}
+	return core.DoctorCheck{Name: name, Status: core.DoctorOK, Detail: "reachable"}
+}
+
+// printDoctorCheck prints one check result with its fix, if any.
+func printDoctorCheck(check core.DoctorCheck) {
+	marks := map[core.DoctorStatus]string{
+		core.DoctorOK:   "[ok]  ",
+		core.DoctorWarn: "[warn]",
+		core.DoctorFail: "[fail]",
+	}
+	fmt.Printf("%s %s: %s\n", marks[check.Status], check.Name, check.Detail)
+	if check.Fix != "" {
+		fmt.Printf("       Fix: %s\n", check.Fix)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/blackcoderx/zap/pkg/core/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var exportOpenAPIParams tools.GenerateOpenAPIParams
+
+func init() {
+	flags := exportOpenAPICmd.Flags()
+	flags.StringVarP(&exportOpenAPIParams.Output, "output", "o", tools.DefaultOpenAPIDraftPath, "File to write the spec to")
+	flags.StringVar(&exportOpenAPIParams.Title, "title", "", "API title (info.title)")
+	flags.StringVar(&exportOpenAPIParams.Version, "version", "", "API version (info.version)")
+	flags.StringVar(&exportOpenAPIParams.Server, "server", "", "Only use recorded calls whose URL contains this")
+	flags.StringVar(&exportOpenAPIParams.Source, "source", "both", "Build from 'code' routes, 'traffic' in history, or 'both'")
+	flags.StringVarP(&exportOpenAPIParams.Framework, "framework", "f", "", "Route patterns to scan for (default: the configured framework)")
+	flags.BoolVar(&exportOpenAPIParams.Overwrite, "overwrite", false, "Replace an existing spec file")
+	exportCmd.AddCommand(exportOpenAPICmd)
+	rootCmd.AddCommand(exportCmd)
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export an API description built from the project",
+}
+
+var exportOpenAPICmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Generate a draft OpenAPI spec from routes in code and recorded calls",
+	Long: `Generate a draft OpenAPI 3.0 spec to hand-edit.
+
+Paths and methods come from the routes declared in the code (as list_routes
+finds them) and from the calls recorded in .zap/history/. Calls are matched
+to routes; their query parameters, request bodies and responses give the
+parameter types and the schemas, inferred from every body seen. Routes that
+were never called get a placeholder response.
+
+The spec is written to .zap/openapi.yaml unless --output says otherwise. An
+existing file is left alone without --overwrite.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workDir, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		params := exportOpenAPIParams
+		if params.Framework == "" {
+			params.Framework = viper.GetString("framework")
+		}
+
+		result, err := tools.BuildOpenAPIDraft(workDir, tools.NewResponseHistory(core.ZapFolderName), params)
+		if err != nil {
+			return err
+		}
+		if err := tools.WriteOpenAPIDraft(params.Output, result.YAML, params.Overwrite); err != nil {
+			return err
+		}
+		fmt.Print(result.Format(params.Output))
+		return nil
+	},
+}
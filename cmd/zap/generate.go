@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/blackcoderx/zap/pkg/core/tools"
+	"github.com/spf13/cobra"
+)
+
+var (
+	generateFrom    string
+	generateOptions tools.SuiteGenOptions
+)
+
+func init() {
+	flags := generateTestsCmd.Flags()
+	flags.StringVar(&generateFrom, "from", "", "OpenAPI 3.x or Swagger 2.0 spec (YAML or JSON)")
+	flags.StringVarP(&generateOptions.Env, "env", "e", "dev", "Environment to write BASE_URL and path parameters to")
+	flags.StringVar(&generateOptions.Filter, "filter", "", "Only operations whose id, path or tags contain this")
+	flags.BoolVar(&generateOptions.Single, "single", false, "Write one suite instead of one per tag")
+	flags.BoolVar(&generateOptions.HappyOnly, "happy-only", false, "Leave out the negative tests")
+	flags.BoolVar(&generateOptions.Overwrite, "overwrite", false, "Replace existing suites")
+	_ = generateTestsCmd.MarkFlagRequired("from")
+	generateCmd.AddCommand(generateTestsCmd)
+	rootCmd.AddCommand(generateCmd)
+}
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate project files from an API description",
+}
+
+var generateTestsCmd = &cobra.Command{
+	Use:   "tests --from <spec>",
+	Short: "Generate test suites with happy-path and negative tests from an OpenAPI spec",
+	Long: `Generate test suites from an OpenAPI 3.x or Swagger 2.0 spec.
+
+Operations are grouped by their first tag (or the first word of their path)
+into .zap/suites/<title>-<tag>.yaml. Each operation gets a happy-path test
+asserting its documented success status, content type and required response
+fields, plus negative tests: a request without credentials for secured
+operations, missing required body fields and query parameters, a wrong type
+and a value outside an enum, a malformed path parameter and an unknown
+resource. A negative test expects the 4xx the spec documents (400, 401, 404,
+422), or any 4xx when it documents none.
+
+BASE_URL and path parameter examples go into the environment given by --env,
+without replacing variables already set. DELETE happy-path tests are marked
+skip until their path variables point at something safe to delete. Run the
+suites with zap run.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureZapFolder(); err != nil {
+			return err
+		}
+
+		spec, err := tools.LoadOpenAPISpec(generateFrom)
+		if err != nil {
+			return err
+		}
+		result, err := tools.GenerateSuites(core.ZapFolderName, spec, generateOptions)
+		if result != nil {
+			fmt.Print(result.Format(core.ZapFolderName))
+		}
+		return err
+	},
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/blackcoderx/zap/pkg/core/tools"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyLimit  int
+	historyFilter string
+	historyEnv    string
+	historyIgnore []string
+)
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of calls to list")
+	historyCmd.Flags().StringVarP(&historyFilter, "filter", "f", "", "Only calls whose method or URL contain this")
+	historyRerunCmd.Flags().StringVarP(&historyEnv, "env", "e", "", "Environment to re-run in")
+	historyRerunCmd.Flags().StringSliceVar(&historyIgnore, "ignore", nil, "Body fields to leave out of the comparison")
+	historyDiffCmd.Flags().StringSliceVar(&historyIgnore, "ignore", nil, "Body fields to leave out of the comparison")
+
+	historyCmd.AddCommand(historyShowCmd, historyRerunCmd, historyDiffCmd)
+	rootCmd.AddCommand(historyCmd)
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List past HTTP calls recorded in .zap/history",
+	Long: `Every HTTP request ZAP sends is recorded in .zap/history/, with the
+request, the response and its timing. Secrets are masked.
+
+A call is referred to by its id, or by its position: 1 is the most recent
+call, 2 the one before.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHistory(nil, tools.HistoryParams{Action: "list", Limit: historyLimit, Filter: historyFilter})
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show [id]",
+	Short: "Show the request and response of a past call",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHistory(nil, tools.HistoryParams{Action: "show", ID: firstArg(args)})
+	},
+}
+
+var historyRerunCmd = &cobra.Command{
+	Use:   "rerun [id]",
+	Short: "Send a past call again and compare the responses",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		zapDir := core.ZapFolderName
+		varStore := tools.NewVariableStore(zapDir)
+		httpTool := newHTTPTool(zapDir, tools.NewResponseManager(), varStore)
+		if historyEnv != "" {
+			persistence := tools.NewPersistenceTool(zapDir)
+			persistence.SetHTTPTool(httpTool)
+			if err := persistence.SetEnvironment(historyEnv); err != nil {
+				return fmt.Errorf("failed to load environment '%s': %w", historyEnv, err)
+			}
+			// Fill the recorded request's {{VAR}} placeholders from the environment
+			for name, value := range persistence.GetEnvironment() {
+				varStore.Set(name, value)
+			}
+		}
+		return runHistory(httpTool, tools.HistoryParams{Action: "rerun", ID: firstArg(args), IgnoreFields: historyIgnore})
+	},
+}
+
+var historyDiffCmd = &cobra.Command{
+	Use:   "diff [id] [other]",
+	Short: "Compare two past calls",
+	Long: `Compare the status, headers and body of two past calls. With one id,
+the call is compared with the previous call to the same method and URL;
+with none, the most recent call is.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		params := tools.HistoryParams{Action: "diff", ID: firstArg(args), IgnoreFields: historyIgnore}
+		if len(args) == 2 {
+			params.Other = args[1]
+		}
+		return runHistory(nil, params)
+	},
+}
+
+// runHistory runs an action of the history tool and prints its output
+func runHistory(httpTool *tools.HTTPTool, params tools.HistoryParams) error {
+	history := tools.NewResponseHistory(core.ZapFolderName)
+	args, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	output, err := tools.NewHistoryTool(history, httpTool).Execute(string(args))
+	if err != nil {
+		return err
+	}
+	fmt.Println(strings.TrimSuffix(output, "\n"))
+	return nil
+}
+
+// firstArg returns args[0], or "" when there are no arguments
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/blackcoderx/zap/pkg/core/tools"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importEnv       string
+	importSuite     bool
+	importOverwrite bool
+)
+
+func init() {
+	importOpenAPICmd.Flags().StringVarP(&importEnv, "env", "e", "dev", "Environment to write BASE_URL and path parameters to")
+	importOpenAPICmd.Flags().BoolVar(&importSuite, "suite", false, "Also generate a smoke-test suite asserting documented status codes")
+	importOpenAPICmd.Flags().BoolVar(&importOverwrite, "overwrite", false, "Replace existing saved requests and environment variables")
+	importInsomniaCmd.Flags().BoolVar(&importOverwrite, "overwrite", false, "Replace existing saved requests and environment variables")
+	importBrunoCmd.Flags().BoolVar(&importOverwrite, "overwrite", false, "Replace existing saved requests and environment variables")
+	importCmd.AddCommand(importOpenAPICmd)
+	importCmd.AddCommand(importInsomniaCmd)
+	importCmd.AddCommand(importBrunoCmd)
+	rootCmd.AddCommand(importCmd)
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import requests from an API description or another API client",
+}
+
+var importOpenAPICmd = &cobra.Command{
+	Use:   "openapi <spec>",
+	Short: "Scaffold saved requests, an environment and a smoke-test suite from an OpenAPI/Swagger spec",
+	Long: `Import an OpenAPI 3.x or Swagger 2.0 spec (YAML or JSON).
+
+Every operation becomes a saved request in .zap/requests/ named after its
+operationId, with example query parameters and a body generated from its
+schema. The server URL and path parameter examples go into the environment
+given by --env as BASE_URL and {{param}} variables. With --suite, GET
+operations are collected into .zap/suites/<title>-smoke.yaml, asserting
+each documented success status; run it with the test_suite tool.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureZapFolder(); err != nil {
+			return err
+		}
+
+		spec, err := tools.LoadOpenAPISpec(args[0])
+		if err != nil {
+			return err
+		}
+		result, err := tools.ImportOpenAPI(core.ZapFolderName, spec, tools.OpenAPIImportOptions{
+			Env:       importEnv,
+			Suite:     importSuite,
+			Overwrite: importOverwrite,
+		})
+		if result != nil {
+			fmt.Print(result.Format())
+		}
+		return err
+	},
+}
+
+var importInsomniaCmd = &cobra.Command{
+	Use:   "insomnia <export.json>",
+	Short: "Import requests and environments from an Insomnia export",
+	Long: `Import an Insomnia export (Application > Preferences > Data > Export
+Data, format v4, JSON or YAML).
+
+Every HTTP request becomes a saved request in .zap/requests/ and every sub
+environment an environment in .zap/environments/. Insomnia variables like
+{{ _.base_url }} become {{base_url}}. Credentials written out in requests
+or environments are moved into encrypted secret variables.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		collection, err := tools.LoadInsomniaExport(args[0])
+		if err != nil {
+			return err
+		}
+		return importCollection(collection)
+	},
+}
+
+var importBrunoCmd = &cobra.Command{
+	Use:   "bruno <collection-folder>",
+	Short: "Import requests and environments from a Bruno collection folder",
+	Long: `Import a Bruno collection: the folder holding bruno.json.
+
+Every .bru request, in subfolders too, becomes a saved request in
+.zap/requests/, and every environments/*.bru an environment in
+.zap/environments/. Headers and auth set in collection.bru or folder.bru
+are applied to the requests that inherit them. Credentials written out in
+requests are moved into encrypted secret variables; secret variables Bruno
+keeps locally are listed so you can set them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		collection, err := tools.LoadBrunoCollection(args[0])
+		if err != nil {
+			return err
+		}
+		return importCollection(collection)
+	},
+}
+
+// importCollection writes an imported collection to .zap and prints a summary
+func importCollection(collection *tools.ImportedCollection) error {
+	if err := ensureZapFolder(); err != nil {
+		return err
+	}
+	result, err := tools.ImportCollection(core.ZapFolderName, tools.NewVariableStore(core.ZapFolderName), collection, importOverwrite)
+	if result != nil {
+		fmt.Print(result.Format())
+	}
+	return err
+}
+
+// ensureZapFolder runs first-time setup when there is no .zap folder yet
+func ensureZapFolder() error {
+	if _, err := os.Stat(core.ZapFolderName); os.IsNotExist(err) {
+		if err := core.InitializeZapFolder(""); err != nil {
+			return fmt.Errorf("failed to initialize .zap folder: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	keyringCmd.AddCommand(keyringMigrateCmd)
+	keyringCmd.AddCommand(keyringSetCmd)
+	rootCmd.AddCommand(keyringCmd)
+}
+
+var keyringCmd = &cobra.Command{
+	Use:   "keyring",
+	Short: "Keep API keys in the OS keyring instead of .zap/config.json",
+	Long: `Store provider API keys in the OS keyring (macOS Keychain, Windows
+Credential Manager, or the Secret Service on Linux via secret-tool).
+
+config.json keeps a "keyring:..." reference in place of the key. Without a
+keyring, keys stay in config.json as before.`,
+}
+
+var keyringMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move plaintext API keys from .zap/config.json into the OS keyring",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		moved, err := core.MigrateConfigSecrets(core.ZapFolderName)
+		for _, key := range moved {
+			fmt.Printf("Moved %s to the OS keyring\n", key)
+		}
+		if err != nil {
+			return err
+		}
+		if len(moved) == 0 {
+			fmt.Println("No plaintext API keys found in .zap/config.json")
+		}
+		return nil
+	},
+}
+
+var keyringSetCmd = &cobra.Command{
+	Use:   "set <provider>",
+	Short: "Store a provider's API key in the OS keyring",
+	Long:  "Prompt for the API key of a provider (" + strings.Join(core.KeyringProviders, ", ") + ") and store it in the OS keyring.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provider := args[0]
+
+		var key string
+		err := huh.NewInput().
+			Title(fmt.Sprintf("%s API Key", provider)).
+			Placeholder("Enter your API key...").
+			EchoMode(huh.EchoModePassword).
+			Value(&key).
+			WithTheme(huh.ThemeDracula()).
+			Run()
+		if err != nil {
+			return fmt.Errorf("input cancelled: %w", err)
+		}
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("no API key entered")
+		}
+
+		if err := core.SetConfigSecret(core.ZapFolderName, provider, strings.TrimSpace(key)); err != nil {
+			return err
+		}
+		fmt.Printf("Saved %s API key to the OS keyring\n", provider)
+		return nil
+	},
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logLimit int
+	logFull  bool
+)
+
+func init() {
+	logCmd.Flags().IntVarP(&logLimit, "number", "n", 10, "Number of recent entries to show (0 = all)")
+	logCmd.Flags().BoolVar(&logFull, "full", false, "Show every message of each prompt, not just the last one")
+	rootCmd.AddCommand(logCmd)
+}
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show recent LLM requests and responses from the audit log",
+	Long: `Show recent entries from .zap/llm-log/.
+
+The audit log is opt-in; enable it with "llm_log": true in .zap/config.json.
+Secrets are masked before entries are written.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := core.ReadLLMLog(core.ZapFolderName, logLimit)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println(`No LLM log entries found. Enable logging with "llm_log": true in .zap/config.json.`)
+			return nil
+		}
+
+		for i, entry := range entries {
+			if i > 0 {
+				fmt.Println(strings.Repeat("─", 60))
+			}
+			printLogEntry(entry)
+		}
+		return nil
+	},
+}
+
+// printLogEntry prints one audit log entry. Without --full only the last
+// prompt message is shown, since the system prompt repeats in every entry.
+func printLogEntry(entry core.LLMLogEntry) {
+	fmt.Printf("%s  %s/%s  %dms  (%s)\n", entry.Timestamp, entry.Provider, entry.Model, entry.DurationMs, entry.Session)
+
+	messages := entry.Messages
+	if !logFull && len(messages) > 1 {
+		fmt.Printf("  ... %d earlier messages (use --full)\n", len(messages)-1)
+		messages = messages[len(messages)-1:]
+	}
+	for _, msg := range messages {
+		fmt.Printf("  [%s] %s\n", msg.Role, indentLog(msg.Content))
+	}
+
+	if entry.Response != "" {
+		fmt.Printf("  [response] %s\n", indentLog(entry.Response))
+	}
+	if entry.ToolCall != nil {
+		fmt.Printf("  [tool_call] %s(%s)\n", entry.ToolCall.Name, entry.ToolCall.Arguments)
+	}
+	if entry.Error != "" {
+		fmt.Printf("  [error] %s\n", entry.Error)
+	}
+}
+
+// indentLog indents continuation lines so multi-line content stays grouped.
+func indentLog(s string) string {
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n    ")
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/blackcoderx/zap/pkg/core"
 	"github.com/blackcoderx/zap/pkg/core/tools"
@@ -23,6 +25,8 @@ var (
 	requestFile string
 	envName     string
 	framework   string
+	exportCurl  bool
+	keepVars    bool
 	rootCmd     = &cobra.Command{
 		Use:   "zap",
 		Short: "ZAP - AI-powered API testing in your terminal",
@@ -44,10 +48,15 @@ agent that understands your code and can interact with your APIs naturally.`,
 			// Re-read config after initialization (first run creates config.json
 			// after Viper's initial read, so values would be stale without this)
 			_ = viper.ReadInConfig()
+			applyUserDefaults()
 
 			// CLI Mode: Execute saved request
 			if requestFile != "" {
-				if err := runCLI(requestFile, envName); err != nil {
+				run := runCLI
+				if exportCurl {
+					run = runExportCurl
+				}
+				if err := run(requestFile, envName); err != nil {
 					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 					os.Exit(1)
 				}
@@ -70,8 +79,16 @@ func init() {
 	// CLI Flags
 	rootCmd.Flags().StringVarP(&requestFile, "request", "r", "", "Execute a saved request file (YAML)")
 	rootCmd.Flags().StringVarP(&envName, "env", "e", "dev", "Environment to use for variable substitution")
+	rootCmd.Flags().BoolVar(&exportCurl, "export-curl", false, "Print the saved request (-r) as a curl command instead of running it")
+	rootCmd.Flags().BoolVar(&keepVars, "keep-vars", false, "With --export-curl, keep {{VAR}} placeholders instead of resolving them")
 	rootCmd.Flags().StringVarP(&framework, "framework", "f", "", "API framework (gin, fastapi, express, etc.)")
 
+	// LLM provider overrides (take precedence over .zap/config.json)
+	rootCmd.PersistentFlags().String("provider", "", "LLM provider to use (ollama, gemini, openai, anthropic)")
+	rootCmd.PersistentFlags().String("model", "", "LLM model to use (overrides default_model)")
+	_ = viper.BindPFlag("provider", rootCmd.PersistentFlags().Lookup("provider"))
+	_ = viper.BindPFlag("default_model", rootCmd.PersistentFlags().Lookup("model"))
+
 	// Version command
 	rootCmd.AddCommand(&cobra.Command{
 		Use:   "version",
@@ -95,6 +112,24 @@ func initConfig() {
 
 	viper.AutomaticEnv()
 	_ = viper.ReadInConfig()
+	applyUserDefaults()
+}
+
+// applyUserDefaults makes ~/.zap/config.json the fallback for every setting
+// the project config leaves out, e.g. provider and model
+func applyUserDefaults() {
+	dir := core.UserZapDir()
+	if dir == "" {
+		return
+	}
+	user := viper.New()
+	user.SetConfigFile(filepath.Join(dir, "config.json"))
+	if err := user.ReadInConfig(); err != nil {
+		return
+	}
+	for _, key := range user.AllKeys() {
+		viper.SetDefault(key, user.Get(key))
+	}
 }
 
 func runCLI(requestName, env string) error {
@@ -105,7 +140,10 @@ func runCLI(requestName, env string) error {
 	varStore := tools.NewVariableStore(zapDir)
 
 	// Initialize tools
+	httpTool := newHTTPTool(zapDir, responseManager, varStore)
 	persistence := tools.NewPersistenceTool(zapDir)
+	persistence.SetHTTPTool(httpTool)
+	persistence.SetLibraryDir(core.UserZapDir())
 
 	// Set environment if specified
 	if env != "" {
@@ -124,7 +162,6 @@ func runCLI(requestName, env string) error {
 	}
 
 	// Execute request
-	httpTool := tools.NewHTTPTool(responseManager, varStore)
 	resp, err := httpTool.Execute(reqArgs)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
@@ -150,6 +187,44 @@ func runCLI(requestName, env string) error {
 	return nil
 }
 
+// newHTTPTool creates the HTTP tool for commands that send requests, with
+// the request ID settings from config and the response history
+func newHTTPTool(zapDir string, responseManager *tools.ResponseManager, varStore *tools.VariableStore) *tools.HTTPTool {
+	httpTool := tools.NewHTTPTool(responseManager, varStore)
+	httpTool.SetRequestIDs(tools.RequestIDConfig{
+		Enabled:        viper.GetBool("request_ids.enabled"),
+		IdempotencyKey: viper.GetString("request_ids.idempotency_key"),
+		RequestID:      viper.GetString("request_ids.request_id"),
+	})
+	httpTool.SetHistory(tools.NewResponseHistory(zapDir))
+	return httpTool
+}
+
+// runExportCurl prints a saved request as a curl command, with variables
+// resolved from the environment unless --keep-vars is set.
+func runExportCurl(requestName, env string) error {
+	zapDir := core.ZapFolderName
+	varStore := tools.NewVariableStore(zapDir)
+	persistence := tools.NewPersistenceTool(zapDir)
+	persistence.SetLibraryDir(core.UserZapDir())
+
+	if env != "" && !keepVars {
+		if err := persistence.SetEnvironment(env); err != nil {
+			return fmt.Errorf("failed to load environment '%s': %w", env, err)
+		}
+	}
+
+	exportTool := tools.NewCurlExportTool(nil, persistence, varStore)
+	args, _ := json.Marshal(tools.CurlExportParams{Name: requestName, Resolve: !keepVars})
+	command, err := exportTool.Execute(string(args))
+	if err != nil {
+		return fmt.Errorf("failed to export request '%s': %w", requestName, err)
+	}
+
+	fmt.Println(command)
+	return nil
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
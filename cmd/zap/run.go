@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/blackcoderx/zap/pkg/core/tools"
+	"github.com/blackcoderx/zap/pkg/core/tools/auth"
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runEnv         string
+	runOnFailure   string
+	runSaveResults bool
+	runReport      string
+	runReportFile  string
+	runUpdateSnaps bool
+)
+
+func init() {
+	runCmd.Flags().StringVarP(&runEnv, "env", "e", "dev", "Environment to use for variable substitution")
+	runCmd.Flags().StringVar(&runOnFailure, "on-failure", "", "stop or continue after a failed test (default: the suite's setting)")
+	runCmd.Flags().BoolVar(&runSaveResults, "save-results", false, "Save the results to .zap/test-results/")
+	runCmd.Flags().StringVar(&runReport, "report", "", "Report format for CI: "+strings.Join(tools.ReportFormats, ", "))
+	runCmd.Flags().BoolVar(&runUpdateSnaps, "update-snapshots", false, "Rewrite snapshots that don't match instead of failing")
+	runCmd.Flags().StringVarP(&runReportFile, "report-file", "o", "", "Write the report to this file instead of stdout")
+	rootCmd.AddCommand(runCmd)
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run [suite]",
+	Short: "Run a saved test suite without the agent",
+	Long: `Run a test suite saved in .zap/suites/<suite>.yaml, the same way the
+test_suite tool does, but without an LLM: the result only depends on the
+suite and the API, so it can gate CI. The exit code is 1 when a test fails.
+
+With --report junit, json or tap, a report for CI systems is printed instead
+of the summary, or written to --report-file with the summary still printed.
+
+Tests with a snapshot fail when the response differs from the one saved in
+.zap/snapshots/; --update-snapshots rewrites those snapshots instead.
+
+Save a suite by asking the agent to run test_suite with "save_as", or with
+zap import openapi --suite. Without a suite name, the saved suites are listed.`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true, // main prints the error
+	RunE: func(cmd *cobra.Command, args []string) error {
+		zapDir := core.ZapFolderName
+		if len(args) == 0 {
+			names, err := tools.ListSuites(zapDir)
+			if err != nil {
+				return err
+			}
+			if len(names) == 0 {
+				fmt.Println("No saved suites in .zap/suites/.")
+				return nil
+			}
+			fmt.Println("Saved suites:\n  " + strings.Join(names, "\n  "))
+			return nil
+		}
+
+		if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to load .env file: %v\n", err)
+		}
+
+		responseManager := tools.NewResponseManager()
+		varStore := tools.NewVariableStore(zapDir)
+		httpTool := newHTTPTool(zapDir, responseManager, varStore)
+		persistence := tools.NewPersistenceTool(zapDir)
+		persistence.SetHTTPTool(httpTool)
+		if err := persistence.SetEnvironment(runEnv); err != nil {
+			// The default environment is optional; one asked for is not
+			if cmd.Flags().Changed("env") || !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to load environment '%s': %w", runEnv, err)
+			}
+		}
+
+		if runReport == "" && runReportFile != "" {
+			return fmt.Errorf("--report-file needs --report")
+		}
+		if runReport != "" && !slices.Contains(tools.ReportFormats, strings.ToLower(runReport)) {
+			return fmt.Errorf("unknown report format '%s' (use %s)", runReport, strings.Join(tools.ReportFormats, ", "))
+		}
+
+		assertTool := tools.NewAssertTool(responseManager)
+		extractTool := tools.NewExtractTool(responseManager, varStore)
+		suiteTool := tools.NewTestSuiteTool(httpTool, assertTool, extractTool, responseManager, varStore, zapDir)
+		suiteTool.SetPersistence(persistence)
+		suiteTool.SetUpdateSnapshots(runUpdateSnaps)
+		// Tools that hooks may call; the ones needing an LLM aren't here
+		suiteTool.SetToolExecutor(tools.NewToolSet(
+			httpTool, assertTool, extractTool,
+			tools.NewVariableTool(varStore),
+			tools.NewWaitTool(),
+			tools.NewWaitForServiceTool(httpTool, responseManager, varStore),
+			auth.NewBearerTool(varStore),
+			auth.NewBasicTool(varStore),
+			auth.NewLoginFlowTool(httpTool, varStore, zapDir),
+			tools.NewIterateTool(suiteTool),
+		))
+		result, output, err := suiteTool.Run(tools.TestSuiteParams{
+			Suite:       args[0],
+			OnFailure:   runOnFailure,
+			SaveResults: runSaveResults,
+		})
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			fmt.Print(output)
+			return fmt.Errorf("login failed")
+		}
+
+		if runReport == "" || runReportFile != "" {
+			fmt.Print(output)
+		}
+		if runReport != "" {
+			report, err := tools.FormatSuiteReport(*result, runReport)
+			if err != nil {
+				return err
+			}
+			if runReportFile == "" {
+				os.Stdout.Write(report)
+			} else if err := os.WriteFile(runReportFile, report, 0644); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			} else {
+				fmt.Printf("Report written to %s\n", runReportFile)
+			}
+		}
+		if result.Aborted != "" {
+			return fmt.Errorf("setup failed: %s", result.Aborted)
+		}
+		if result.Failed > 0 {
+			return fmt.Errorf("%d of %d test(s) failed", result.Failed, result.TotalTests)
+		}
+		return nil
+	},
+}
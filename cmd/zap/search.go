@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/blackcoderx/zap/pkg/core/tools"
+	"github.com/spf13/cobra"
+)
+
+var searchTags []string
+
+func init() {
+	searchCmd.Flags().StringSliceVarP(&searchTags, "tag", "t", nil, "Only requests with this tag (repeat or comma-separate for several)")
+	rootCmd.AddCommand(searchCmd)
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search saved requests by name, URL, tags and body",
+	Long: `Search the saved requests in .zap/requests/, including collections and
+.http files, and the shared library in ~/.zap/requests. Every word of the
+query must appear in the request's name, URL, tags or body
+(case-insensitive). With --tag, only requests carrying all of the given
+tags are shown.
+
+Run a result with: zap -r <name>`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := strings.Join(args, " ")
+		if query == "" && len(searchTags) == 0 {
+			return fmt.Errorf("give a query or --tag")
+		}
+
+		persistence := tools.NewPersistenceTool(core.ZapFolderName)
+		persistence.SetLibraryDir(core.UserZapDir())
+		matches, err := persistence.SearchRequests(query, searchTags)
+		if err != nil {
+			return err
+		}
+		fmt.Println(strings.TrimSuffix(tools.FormatRequestMatches(matches), "\n"))
+		return nil
+	},
+}
@@ -28,13 +28,39 @@ type Agent struct {
 	totalCalls   int            // current total tool calls in session
 
 	// History management
-	maxHistory int // maximum number of messages to keep in history (0 = unlimited)
+	maxHistory         int    // maximum number of messages to keep in history (0 = unlimited)
+	historyTokenBudget int    // estimated token budget before older turns are summarized (0 = disabled)
+	historySummary     string // compact summary of turns removed from history
 
 	// User's API framework (gin, fastapi, express, etc.)
 	framework string
 
 	// Persistent memory across sessions
 	memoryStore *MemoryStore
+
+	// Native tool calling
+	nativeTools      bool // user preference (on by default)
+	toolsUnsupported bool // set when the current model rejects native tools
+
+	// Clients tried in order when llmClient fails a request
+	fallbackClients []llm.LLMClient
+
+	// Retry behavior for transient LLM failures
+	retryPolicy llm.RetryPolicy
+
+	// Opt-in audit log of LLM requests and responses
+	llmLog *LLMLog
+
+	// Session token/cost budget
+	budget  Budget
+	usage   BudgetUsage
+	usageMu sync.Mutex // Protects budget and usage
+
+	// Project-specific instructions from ZAP.md
+	projectInstructions string
+
+	// Per-section system prompt overrides from .zap/prompts/
+	promptOverrides map[string]PromptOverride
 }
 
 // Default limits for tool calls and history management.
@@ -61,6 +87,10 @@ func NewAgent(llmClient llm.LLMClient) *Agent {
 		totalLimit:   DefaultTotalLimit,
 		totalCalls:   0,
 		maxHistory:   DefaultMaxHistory,
+		nativeTools:  true,
+
+		historyTokenBudget: DefaultHistoryTokenBudget,
+		retryPolicy:        llm.DefaultRetryPolicy,
 	}
 }
 
@@ -84,6 +114,24 @@ func (a *Agent) ExecuteTool(toolName string, args string) (string, error) {
 	return tool.Execute(args)
 }
 
+// Shutdown stops what tools left running (mock servers, proxies, webhook
+// listeners, background commands) by calling their Cleanup method.
+// Call it once the agent is no longer used.
+func (a *Agent) Shutdown() {
+	a.toolsMu.RLock()
+	var cleanups []func()
+	for _, tool := range a.tools {
+		if c, ok := tool.(interface{ Cleanup() }); ok {
+			cleanups = append(cleanups, c.Cleanup)
+		}
+	}
+	a.toolsMu.RUnlock()
+
+	for _, cleanup := range cleanups {
+		cleanup()
+	}
+}
+
 // SetLastResponse stores the last response from a tool for chaining.
 func (a *Agent) SetLastResponse(response interface{}) {
 	a.lastResponse = response
@@ -116,6 +164,34 @@ func (a *Agent) GetFramework() string {
 	return a.framework
 }
 
+// SetLLMClient replaces the LLM client used for subsequent requests.
+// Conversation history is kept, so the new model continues the session.
+func (a *Agent) SetLLMClient(client llm.LLMClient) {
+	a.llmClient = client
+	a.toolsUnsupported = false
+}
+
+// SetModel switches the current client to a different model of the same provider.
+func (a *Agent) SetModel(model string) {
+	a.llmClient.SetModel(model)
+	a.toolsUnsupported = false
+}
+
+// GetModelInfo returns the provider and model currently in use.
+func (a *Agent) GetModelInfo() llm.ModelInfo {
+	return a.llmClient.ModelInfo()
+}
+
+// ListModels returns the models available from the current provider.
+// Returns an error if the provider can't list its models.
+func (a *Agent) ListModels() ([]string, error) {
+	lister, ok := a.llmClient.(llm.ModelLister)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support listing models", a.llmClient.ModelInfo().Provider)
+	}
+	return lister.ListModels()
+}
+
 // SetMemoryStore sets the persistent memory store for the agent.
 func (a *Agent) SetMemoryStore(store *MemoryStore) {
 	a.memoryStore = store
@@ -0,0 +1,404 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/storage"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// BaseURLDetection is the local address a project's server listens on and
+// what gave it away
+type BaseURLDetection struct {
+	Port     int
+	BasePath string // Global route prefix, e.g. "/api" (empty for none)
+	File     string // Where the port was found, relative to the project (empty for a default)
+	Evidence string // The setting, flag or call that matched
+}
+
+// URL is the base URL to reach the server from this machine
+func (d *BaseURLDetection) URL() string {
+	return fmt.Sprintf("http://localhost:%d%s", d.Port, d.BasePath)
+}
+
+// String describes the detection, e.g. "http://localhost:8080 (PORT=8080 in .env)"
+func (d *BaseURLDetection) String() string {
+	if d.File == "" {
+		return fmt.Sprintf("%s (%s)", d.URL(), d.Evidence)
+	}
+	return fmt.Sprintf("%s (%s in %s)", d.URL(), d.Evidence, d.File)
+}
+
+// baseURLEnvFiles are the dotenv files read for a port, most specific first
+var baseURLEnvFiles = []string{".env.local", ".env.development", ".env"}
+
+// baseURLPortVars and baseURLPathVars are the dotenv variables that hold
+// the listen port and a global route prefix
+var (
+	baseURLPortVars = []string{"PORT", "APP_PORT", "SERVER_PORT", "HTTP_PORT", "API_PORT"}
+	baseURLPathVars = []string{"API_PREFIX", "BASE_PATH", "API_BASE_PATH", "ROOT_PATH", "CONTEXT_PATH"}
+	baseURLURLVars  = []string{"APP_URL", "API_URL", "BASE_URL"}
+)
+
+// listenPatterns find a port in source files, run commands and config files.
+// The first submatch is the port, or an address ending in one.
+var listenPatterns = []*regexp.Regexp{
+	// Go: http.ListenAndServe(":8080"), r.Run(":8080"), e.Start(":8080"), app.Listen(":3000")
+	regexp.MustCompile(`(?:ListenAndServe(?:TLS)?|\.Run|\.Start|\.Listen)\(\s*"([\w.\-]*:\d+)"`),
+	// Go flags: flag.Int("port", 8080, ...), flag.String("addr", ":8080", ...)
+	regexp.MustCompile(`(?i)\.(?:Int|String)(?:Var)?P?\(\s*(?:&\w+,\s*)?"(?:port|addr|address|listen|http-port|http-addr)"\s*,\s*(?:"\w*",\s*)?"?([\w.\-]*:?\d+)"?`),
+	// Node: process.env.PORT || 3000; Go: port := ":8080" (os.Getenv("PORT") fallbacks)
+	regexp.MustCompile(`process\.env\.PORT\s*(?:\|\||\?\?)\s*['"]?(\d+)`),
+	regexp.MustCompile(`(?:port|addr)\s*:?=\s*"(:\d+)"`),
+	// Node: app.listen(3000), server.listen(8080)
+	regexp.MustCompile(`\.listen\(\s*(\d{2,5})\b`),
+	// Python: uvicorn.run(app, port=8000), app.run(port=5000)
+	regexp.MustCompile(`(?:uvicorn\.run|\.run)\([^)]*\bport\s*=\s*(\d+)`),
+	// Commands: uvicorn --port 8000, flask run -p 5000, gunicorn -b 0.0.0.0:8000
+	regexp.MustCompile(`(?:uvicorn|hypercorn|fastapi (?:dev|run)|flask run|rails s(?:erver)?|php artisan serve|next dev|nest start)\b[^\n]*?(?:--port[= ]|-p )(\d+)`),
+	regexp.MustCompile(`(?:gunicorn|hypercorn)\b[^\n]*?(?:--bind[= ]|-b )["']?([\w.\-]*:\d+)`),
+	regexp.MustCompile(`manage\.py runserver(?: [\w.\-]*:)?\s*(\d+)`),
+	// Spring: server.port=8081 (application.properties) or server:\n  port: 8081 (application.yml)
+	regexp.MustCompile(`(?m)^\s*server\.port\s*[=:]\s*(\d+)`),
+	regexp.MustCompile(`(?m)^server:\s*\n(?:\s+.*\n)*?\s+port:\s*(\d+)`),
+	// Rust: .bind(("127.0.0.1", 8080)), TcpListener::bind("0.0.0.0:3000")
+	regexp.MustCompile(`bind\(\s*\(\s*"[^"]*"\s*,\s*(\d+)\s*\)`),
+	regexp.MustCompile(`bind\(\s*"([\w.\-]*:\d+)"`),
+	// Dockerfile: EXPOSE 8080
+	regexp.MustCompile(`(?m)^\s*EXPOSE\s+(\d+)`),
+}
+
+// basePathPatterns find a global route prefix that route declarations don't show
+var basePathPatterns = []*regexp.Regexp{
+	// NestJS: app.setGlobalPrefix('api')
+	regexp.MustCompile(`setGlobalPrefix\(\s*['"]([^'"]+)['"]`),
+	// Spring: server.servlet.context-path=/api
+	regexp.MustCompile(`(?m)^\s*server\.servlet\.context-path\s*[=:]\s*(/\S*)`),
+	regexp.MustCompile(`(?m)^\s+context-path:\s*["']?(/[^\s"']*)`),
+	// FastAPI: FastAPI(root_path="/api"), uvicorn --root-path /api
+	regexp.MustCompile(`root_path\s*=\s*["'](/[^"']*)["']`),
+	regexp.MustCompile(`--root-path[= ](/\S+)`),
+}
+
+// baseURLCommandFiles hold run commands and settings rather than code
+var baseURLCommandFiles = map[string]bool{
+	"Procfile": true, "Makefile": true, "Dockerfile": true, "package.json": true,
+	"application.properties": true, "application.yml": true, "application.yaml": true,
+}
+
+// baseURLSourceExts are the source files read for listen calls
+var baseURLSourceExts = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".mjs": true, ".ts": true,
+	".java": true, ".kt": true, ".rs": true, ".sh": true,
+}
+
+// frameworkDefaultPorts are the ports a framework's dev server uses when
+// the project doesn't set one
+var frameworkDefaultPorts = map[string]int{
+	"gin": 8080, "fastapi": 8000, "django": 8000, "flask": 5000, "rails": 3000,
+	"laravel": 8000, "spring": 8080, "nestjs": 3000,
+}
+
+// nonHTTPPorts are the usual ports of databases, caches and brokers, which
+// docker-compose files publish next to the API
+var nonHTTPPorts = map[int]bool{
+	1433: true, 2181: true, 3306: true, 5432: true, 5672: true, 6379: true,
+	9092: true, 9200: true, 11211: true, 15672: true, 27017: true,
+}
+
+// DetectBaseURL looks for the port and base path the project in dir serves
+// on: dotenv files first (they override what the code defaults to), then
+// listen calls, flags, run commands and config files, then the ports
+// docker-compose publishes, and last the default port of framework. It
+// returns nil when no port is found.
+func DetectBaseURL(dir, framework string) *BaseURLDetection {
+	d := detectBaseURLFromEnv(dir)
+	if d == nil {
+		d = detectBaseURLFromFiles(dir)
+	}
+	if d == nil {
+		d = detectBaseURLFromCompose(dir)
+	}
+	if d == nil {
+		if port, ok := frameworkDefaultPorts[framework]; ok {
+			d = &BaseURLDetection{Port: port, Evidence: "default port of " + framework}
+		}
+	}
+	if d != nil && d.BasePath == "" {
+		d.BasePath = detectBasePath(dir)
+	}
+	return d
+}
+
+// detectBaseURLFromEnv reads the port from the project's dotenv files
+func detectBaseURLFromEnv(dir string) *BaseURLDetection {
+	for _, file := range baseURLEnvFiles {
+		vars, err := godotenv.Read(filepath.Join(dir, file))
+		if err != nil {
+			continue
+		}
+		basePath := ""
+		for _, name := range baseURLPathVars {
+			if v := vars[name]; strings.HasPrefix(v, "/") {
+				basePath = strings.TrimRight(v, "/")
+				break
+			}
+		}
+		for _, name := range baseURLPortVars {
+			if port := parsePort(vars[name]); port > 0 {
+				return &BaseURLDetection{Port: port, BasePath: basePath, File: file, Evidence: name + "=" + vars[name]}
+			}
+		}
+		// APP_URL=http://localhost:8000, when it points at this machine
+		for _, name := range baseURLURLVars {
+			if port := localURLPort(vars[name]); port > 0 {
+				return &BaseURLDetection{Port: port, BasePath: basePath, File: file, Evidence: name + "=" + vars[name]}
+			}
+		}
+	}
+	return nil
+}
+
+// detectBaseURLFromFiles scans run commands, config files and source code
+// for listen calls; command files come first, then files nearest the
+// project root (main.go before internal/x/y.go)
+func detectBaseURLFromFiles(dir string) *BaseURLDetection {
+	var files []string
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if path != dir && skipDetectDir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := entry.Name()
+		if !baseURLCommandFiles[name] && !baseURLSourceExts[filepath.Ext(name)] {
+			return nil
+		}
+		if isTestFileName(name) {
+			return nil
+		}
+		if len(files) >= maxDetectFiles {
+			return filepath.SkipAll
+		}
+		files = append(files, path)
+		return nil
+	})
+	sort.SliceStable(files, func(i, j int) bool {
+		ci, cj := baseURLCommandFiles[filepath.Base(files[i])], baseURLCommandFiles[filepath.Base(files[j])]
+		if ci != cj {
+			return ci
+		}
+		return strings.Count(files[i], string(filepath.Separator)) < strings.Count(files[j], string(filepath.Separator))
+	})
+
+	for _, path := range files {
+		if info, err := os.Stat(path); err != nil || info.Size() > maxDetectFileSize {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, pattern := range listenPatterns {
+			m := pattern.FindSubmatch(data)
+			if m == nil {
+				continue
+			}
+			if port := parsePort(string(m[1])); port > 0 {
+				rel, _ := filepath.Rel(dir, path)
+				return &BaseURLDetection{Port: port, File: filepath.ToSlash(rel), Evidence: strings.Join(strings.Fields(string(m[0])), " ")}
+			}
+		}
+	}
+	return nil
+}
+
+// composeFiles are the docker-compose file names, in the order docker looks
+// for them
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// detectBaseURLFromCompose takes the host port a docker-compose service
+// publishes. Services built from the project come before images, and
+// database and broker ports are skipped.
+func detectBaseURLFromCompose(dir string) *BaseURLDetection {
+	for _, file := range composeFiles {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			continue
+		}
+		var compose struct {
+			Services map[string]struct {
+				Build interface{}   `yaml:"build"`
+				Ports []interface{} `yaml:"ports"`
+			} `yaml:"services"`
+		}
+		if err := yaml.Unmarshal(data, &compose); err != nil {
+			continue
+		}
+
+		var found *BaseURLDetection
+		for _, name := range slices.Sorted(maps.Keys(compose.Services)) {
+			service := compose.Services[name]
+			for _, p := range service.Ports {
+				port := composeHostPort(p)
+				if port == 0 || nonHTTPPorts[port] {
+					continue
+				}
+				d := &BaseURLDetection{Port: port, File: file, Evidence: fmt.Sprintf("services.%s.ports %v", name, p)}
+				if service.Build != nil {
+					return d
+				}
+				if found == nil {
+					found = d
+				}
+				break
+			}
+		}
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// composeHostPort returns the host side of a compose port mapping:
+// "8080:3000", "127.0.0.1:8080:3000", "3000", 3000 or {published: 8080}
+func composeHostPort(p interface{}) int {
+	switch v := p.(type) {
+	case int:
+		return v
+	case string:
+		v = strings.SplitN(v, "/", 2)[0]
+		parts := strings.Split(v, ":")
+		if len(parts) >= 2 {
+			return parsePort(parts[len(parts)-2])
+		}
+		return parsePort(parts[0])
+	case map[string]interface{}:
+		switch published := v["published"].(type) {
+		case int:
+			return published
+		case string:
+			return parsePort(published)
+		}
+		if target, ok := v["target"].(int); ok {
+			return target
+		}
+	}
+	return 0
+}
+
+// detectBasePath looks for a global route prefix in dotenv files, config
+// files and source code
+func detectBasePath(dir string) string {
+	prefix := ""
+	files := 0
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || prefix != "" {
+			return nil
+		}
+		if entry.IsDir() {
+			if path != dir && skipDetectDir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := entry.Name()
+		if !baseURLCommandFiles[name] && !baseURLSourceExts[filepath.Ext(name)] || isTestFileName(name) {
+			return nil
+		}
+		if files++; files > maxDetectFiles {
+			return filepath.SkipAll
+		}
+		if info, err := entry.Info(); err != nil || info.Size() > maxDetectFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, pattern := range basePathPatterns {
+			if m := pattern.FindSubmatch(data); m != nil {
+				prefix = "/" + strings.Trim(string(m[1]), "/")
+				if prefix == "/" {
+					prefix = ""
+				}
+				return filepath.SkipAll
+			}
+		}
+		return nil
+	})
+	return prefix
+}
+
+// parsePort reads a port from "8080", ":8080" or "0.0.0.0:8080"
+func parsePort(s string) int {
+	s = strings.TrimSpace(strings.Trim(s, `"'`))
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		s = s[i+1:]
+	}
+	port, err := strconv.Atoi(s)
+	if err != nil || port <= 0 || port > 65535 {
+		return 0
+	}
+	return port
+}
+
+// localURLPort returns the port of a URL on this machine, e.g.
+// http://localhost:8000, or 0 for other hosts
+func localURLPort(url string) int {
+	rest, ok := strings.CutPrefix(url, "http://")
+	if !ok {
+		return 0
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	name, port, ok := strings.Cut(host, ":")
+	if !ok {
+		return 0
+	}
+	switch name {
+	case "localhost", "127.0.0.1", "0.0.0.0":
+		return parsePort(port)
+	}
+	return 0
+}
+
+// isTestFileName reports whether a file holds tests, whose ports are
+// usually test servers
+func isTestFileName(name string) bool {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.HasSuffix(base, "_test") || strings.HasPrefix(base, "test_") ||
+		strings.HasSuffix(base, ".test") || strings.HasSuffix(base, ".spec")
+}
+
+// seedBaseURL sets BASE_URL in the dev environment from DetectBaseURL when
+// it isn't set yet. It returns the detection when BASE_URL was written.
+func seedBaseURL(framework string) (*BaseURLDetection, error) {
+	envPath := filepath.Join(storage.GetEnvironmentsDir(ZapFolderName), "dev.yaml")
+	if env, err := storage.LoadEnvironment(envPath); err == nil && env["BASE_URL"] != "" {
+		return nil, nil
+	}
+	d := DetectBaseURL(".", framework)
+	if d == nil {
+		return nil, nil
+	}
+	written, err := storage.SetEnvironmentVariables(envPath, map[string]string{"BASE_URL": d.URL()}, false)
+	if err != nil || len(written) == 0 {
+		return nil, err
+	}
+	return d, nil
+}
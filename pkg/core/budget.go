@@ -0,0 +1,119 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/llm"
+)
+
+// Budget caps how much LLM usage a session may consume. Zero values disable
+// the corresponding limit. Token counts are estimates (see EstimateTokens),
+// and costs are only computed when prices are set.
+type Budget struct {
+	MaxTokens          int     // Max estimated input+output tokens per session
+	MaxCostUSD         float64 // Max estimated cost per session in USD
+	InputPricePerMTok  float64 // USD per million input tokens
+	OutputPricePerMTok float64 // USD per million output tokens
+}
+
+// BudgetUsage is the estimated LLM usage of the current session.
+type BudgetUsage struct {
+	Requests     int     // Successful LLM requests
+	InputTokens  int     // Estimated prompt tokens
+	OutputTokens int     // Estimated completion tokens
+	CostUSD      float64 // Estimated cost (0 if no prices are configured)
+}
+
+// TotalTokens returns input plus output tokens.
+func (u BudgetUsage) TotalTokens() int {
+	return u.InputTokens + u.OutputTokens
+}
+
+// SetBudget sets the session token/cost budget. When it is exhausted the
+// agent stops before the next LLM request with a "budget_exceeded" event.
+func (a *Agent) SetBudget(budget Budget) {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+	a.budget = budget
+}
+
+// GetBudgetUsage returns the estimated LLM usage so far in this session.
+func (a *Agent) GetBudgetUsage() BudgetUsage {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+	return a.usage
+}
+
+// addUsage records the estimated tokens and cost of an LLM request. The
+// native tool definitions sent with it are input as well, and a native tool
+// call is output: its name and JSON arguments are counted with the text
+// response. A failed request counts only if it streamed output first, as
+// providers bill what they generated; one refused before answering is not
+// billed. For a failed request response is the text streamed before it failed.
+func (a *Agent) addUsage(messages []llm.Message, tools []llm.ToolDefinition, response string, call *llm.ToolCall, err error) {
+	if err != nil && response == "" {
+		return
+	}
+
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+
+	in := estimateMessagesTokens(messages) + estimateToolTokens(tools)
+	out := EstimateTokens(response)
+	if call != nil {
+		out += EstimateTokens(call.Name + call.Arguments)
+	}
+	if err == nil {
+		a.usage.Requests++
+	}
+	a.usage.InputTokens += in
+	a.usage.OutputTokens += out
+	a.usage.CostUSD += float64(in)*a.budget.InputPricePerMTok/1e6 + float64(out)*a.budget.OutputPricePerMTok/1e6
+}
+
+// estimateToolTokens estimates the size of native tool definitions as they
+// are sent: each tool's name, description and JSON Schema.
+func estimateToolTokens(tools []llm.ToolDefinition) int {
+	total := 0
+	for _, tool := range tools {
+		schema, _ := json.Marshal(tool.Parameters)
+		total += EstimateTokens(tool.Name + tool.Description + string(schema))
+	}
+	return total
+}
+
+// budgetExceeded returns a description of the exhausted limit, or an empty
+// string if the session is still within budget.
+func (a *Agent) budgetExceeded() string {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+
+	if a.budget.MaxTokens > 0 && a.usage.TotalTokens() >= a.budget.MaxTokens {
+		return fmt.Sprintf("token budget of %d reached", a.budget.MaxTokens)
+	}
+	if a.budget.MaxCostUSD > 0 && a.usage.CostUSD >= a.budget.MaxCostUSD {
+		return fmt.Sprintf("cost budget of $%.2f reached", a.budget.MaxCostUSD)
+	}
+	return ""
+}
+
+// budgetExceededMessage builds the message shown when the budget is exhausted,
+// including a summary of what the session spent.
+func (a *Agent) budgetExceededMessage(reason string) string {
+	usage := a.GetBudgetUsage()
+	totalCalls, _ := a.GetTotalUsage()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Stopping: %s.\n\n", reason))
+	sb.WriteString("Session usage (estimated):\n")
+	sb.WriteString(fmt.Sprintf("- LLM requests: %d\n", usage.Requests))
+	sb.WriteString(fmt.Sprintf("- Tokens: %d (%d in / %d out)\n", usage.TotalTokens(), usage.InputTokens, usage.OutputTokens))
+	if usage.CostUSD > 0 {
+		sb.WriteString(fmt.Sprintf("- Cost: $%.4f\n", usage.CostUSD))
+	}
+	sb.WriteString(fmt.Sprintf("- Tool calls this turn: %d\n", totalCalls))
+	sb.WriteString("\nRaise budget.max_tokens / budget.max_cost_usd in .zap/config.json and restart ZAP to continue.")
+	return sb.String()
+}
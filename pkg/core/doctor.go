@@ -0,0 +1,318 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DoctorStatus is the outcome of a health check.
+type DoctorStatus string
+
+// Health check outcomes, from best to worst.
+const (
+	DoctorOK   DoctorStatus = "ok"
+	DoctorWarn DoctorStatus = "warn"
+	DoctorFail DoctorStatus = "fail"
+)
+
+// DoctorCheck is the result of one `zap doctor` check.
+type DoctorCheck struct {
+	Name   string       // What was checked
+	Status DoctorStatus // ok, warn or fail
+	Detail string       // What was found
+	Fix    string       // Actionable fix (empty when ok)
+}
+
+// requiredZapDirs are the .zap subfolders ZAP expects.
+var requiredZapDirs = []string{"requests", "environments", "baselines"}
+
+// supportedProviders lists the valid values of Config.Provider.
+var supportedProviders = []string{"ollama", "gemini", "openai", "anthropic"}
+
+// providerKeyEnvVars maps providers that need an API key to the environment
+// variable ZAP falls back to.
+var providerKeyEnvVars = map[string]string{
+	"gemini":    "GEMINI_API_KEY",
+	"anthropic": "ANTHROPIC_API_KEY",
+}
+
+// DiagnoseZapFolder checks the .zap folder: config.json schema, expected
+// subfolders, the manifest, memory file and write permissions.
+// LLM connectivity is checked separately by the caller.
+func DiagnoseZapFolder(zapDir string) []DoctorCheck {
+	info, err := os.Stat(zapDir)
+	if err != nil || !info.IsDir() {
+		return []DoctorCheck{{
+			Name:   zapDir + " folder",
+			Status: DoctorFail,
+			Detail: "not found in the current directory",
+			Fix:    "Run `zap` in your project root to create it with the setup wizard",
+		}}
+	}
+
+	checks := diagnoseConfig(zapDir)
+
+	for _, dir := range requiredZapDirs {
+		path := filepath.Join(zapDir, dir)
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			checks = append(checks, DoctorCheck{
+				Name:   path,
+				Status: DoctorFail,
+				Detail: "missing",
+				Fix:    fmt.Sprintf("Run `zap` once (missing folders are recreated) or `mkdir %s`", path),
+			})
+		} else {
+			checks = append(checks, DoctorCheck{Name: path, Status: DoctorOK, Detail: "present"})
+		}
+	}
+
+	checks = append(checks, diagnoseManifest(zapDir))
+	checks = append(checks, diagnoseJSONFile(filepath.Join(zapDir, "memory.json"), "Delete it to start with empty memory (saved facts will be lost)"))
+	checks = append(checks, diagnoseWritable(zapDir))
+	return checks
+}
+
+// diagnoseConfig validates config.json against the Config schema.
+func diagnoseConfig(zapDir string) []DoctorCheck {
+	path := filepath.Join(zapDir, "config.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []DoctorCheck{{
+			Name:   path,
+			Status: DoctorFail,
+			Detail: err.Error(),
+			Fix:    fmt.Sprintf("Remove %s and run `zap` to recreate the config with the setup wizard", zapDir),
+		}}
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return []DoctorCheck{{
+			Name:   path,
+			Status: DoctorFail,
+			Detail: describeJSONError(data, err),
+			Fix:    "Fix the JSON syntax or field type at the reported position",
+		}}
+	}
+
+	checks := []DoctorCheck{{Name: path, Status: DoctorOK, Detail: "valid JSON"}}
+
+	// Unknown keys are usually typos that silently do nothing
+	var raw map[string]json.RawMessage
+	_ = json.Unmarshal(data, &raw)
+	known := jsonFieldNames(reflect.TypeOf(Config{}))
+	var unknown []string
+	for key := range raw {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		checks = append(checks, DoctorCheck{
+			Name:   "config keys",
+			Status: DoctorWarn,
+			Detail: "unknown keys: " + strings.Join(unknown, ", "),
+			Fix:    "Check the spelling or remove them; unknown keys are ignored",
+		})
+	}
+
+	// Settings left out of the project config come from ~/.zap/config.json
+	userConfig, err := LoadUserConfig()
+	if err != nil {
+		checks = append(checks, DoctorCheck{
+			Name:   "user config",
+			Status: DoctorFail,
+			Detail: err.Error(),
+			Fix:    "Fix the JSON syntax, or delete it and run the setup wizard in a new project",
+		})
+	} else if userConfig != nil {
+		config = *userConfig
+		_ = json.Unmarshal(data, &config)
+		checks = append(checks, DoctorCheck{Name: filepath.Join(UserZapDir(), "config.json"), Status: DoctorOK, Detail: "user defaults loaded"})
+	}
+
+	checks = append(checks, diagnoseProvider("provider", config.Provider, &config))
+	for i, fb := range config.FallbackProviders {
+		checks = append(checks, diagnoseProvider(fmt.Sprintf("fallback_providers[%d]", i), fb.Provider, &config))
+	}
+
+	if config.Framework != "" && !slices.Contains(SupportedFrameworks, config.Framework) {
+		checks = append(checks, DoctorCheck{
+			Name:   "framework",
+			Status: DoctorWarn,
+			Detail: fmt.Sprintf("%q is not a known framework", config.Framework),
+			Fix:    fmt.Sprintf("Use one of: %s (or run `zap --framework <name>`)", strings.Join(SupportedFrameworks, ", ")),
+		})
+	}
+
+	if config.ToolLimits.DefaultLimit < 0 || config.ToolLimits.TotalLimit < 0 {
+		checks = append(checks, DoctorCheck{
+			Name:   "tool_limits",
+			Status: DoctorFail,
+			Detail: "limits must not be negative",
+			Fix:    "Set tool_limits.default_limit and tool_limits.total_limit to positive numbers",
+		})
+	}
+
+	return checks
+}
+
+// diagnoseProvider checks that a provider name is supported and, for
+// providers that need one, that an API key is configured.
+func diagnoseProvider(name, provider string, config *Config) DoctorCheck {
+	if provider == "" {
+		if name == "provider" {
+			return DoctorCheck{Name: name, Status: DoctorWarn, Detail: "not set (legacy config, using Ollama)", Fix: `Add "provider": "ollama" (or gemini, openai, anthropic) to config.json`}
+		}
+		return DoctorCheck{Name: name, Status: DoctorFail, Detail: "provider is empty", Fix: "Set provider on the fallback entry"}
+	}
+	if !slices.Contains(supportedProviders, provider) {
+		return DoctorCheck{
+			Name:   name,
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("unknown provider %q", provider),
+			Fix:    "Use one of: " + strings.Join(supportedProviders, ", "),
+		}
+	}
+
+	envVar, needsKey := providerKeyEnvVars[provider]
+	key := providerAPIKey(provider, config)
+	if IsKeyringRef(key) {
+		if _, err := ResolveSecret(key); err != nil {
+			return DoctorCheck{
+				Name:   name,
+				Status: DoctorFail,
+				Detail: err.Error(),
+				Fix:    fmt.Sprintf("Run `zap keyring set %s` to store the key again", provider),
+			}
+		}
+	}
+	if needsKey && key == "" && os.Getenv(envVar) == "" {
+		return DoctorCheck{
+			Name:   name,
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("%s has no API key", provider),
+			Fix:    fmt.Sprintf("Set %s.api_key in config.json or %s in your environment/.env", provider, envVar),
+		}
+	}
+
+	return DoctorCheck{Name: name, Status: DoctorOK, Detail: provider}
+}
+
+// providerAPIKey returns the API key configured for provider, if any.
+func providerAPIKey(provider string, config *Config) string {
+	switch provider {
+	case "gemini":
+		if config.GeminiConfig != nil {
+			return config.GeminiConfig.APIKey
+		}
+	case "anthropic":
+		if config.AnthropicConfig != nil {
+			return config.AnthropicConfig.APIKey
+		}
+	}
+	return ""
+}
+
+// diagnoseManifest checks that manifest.json exists and parses.
+func diagnoseManifest(zapDir string) DoctorCheck {
+	name := filepath.Join(zapDir, ManifestFilename)
+	manifest, err := LoadManifest(zapDir)
+	if err != nil {
+		return DoctorCheck{Name: name, Status: DoctorFail, Detail: err.Error(), Fix: "Delete it; ZAP recreates the manifest on start"}
+	}
+	if manifest == nil {
+		return DoctorCheck{Name: name, Status: DoctorWarn, Detail: "missing", Fix: "Run `zap` once to recreate it"}
+	}
+	return DoctorCheck{Name: name, Status: DoctorOK, Detail: fmt.Sprintf("version %d", manifest.Version)}
+}
+
+// diagnoseJSONFile checks that an optional JSON file parses.
+func diagnoseJSONFile(path, fix string) DoctorCheck {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DoctorCheck{Name: path, Status: DoctorOK, Detail: "not created yet"}
+	}
+	if err != nil {
+		return DoctorCheck{Name: path, Status: DoctorFail, Detail: err.Error(), Fix: "Check the file permissions"}
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return DoctorCheck{Name: path, Status: DoctorFail, Detail: describeJSONError(data, err), Fix: fix}
+	}
+	return DoctorCheck{Name: path, Status: DoctorOK, Detail: "valid JSON"}
+}
+
+// diagnoseWritable checks that ZAP can create files in zapDir and read the
+// working directory (needed by the codebase tools).
+func diagnoseWritable(zapDir string) DoctorCheck {
+	probe, err := os.CreateTemp(zapDir, ".doctor-*")
+	if err != nil {
+		return DoctorCheck{
+			Name:   "permissions",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("cannot write to %s: %v", zapDir, err),
+			Fix:    fmt.Sprintf("Make %s writable by your user (e.g. `chmod -R u+w %s`)", zapDir, zapDir),
+		}
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	if _, err := os.ReadDir("."); err != nil {
+		return DoctorCheck{
+			Name:   "permissions",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("cannot read the working directory: %v", err),
+			Fix:    "Run zap from a project directory you can read",
+		}
+	}
+
+	return DoctorCheck{Name: "permissions", Status: DoctorOK, Detail: zapDir + " is writable, working directory is readable"}
+}
+
+// describeJSONError turns a JSON error into a message with line and column.
+func describeJSONError(data []byte, err error) string {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err.Error()
+	}
+
+	line, col := 1, 1
+	for i := int64(0); i < offset && i < int64(len(data)); i++ {
+		if data[i] == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return fmt.Sprintf("%v (line %d, column %d)", err, line, col)
+}
+
+// jsonFieldNames returns the JSON keys of a struct type's fields.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag != "" && tag != "-" {
+			names[tag] = true
+		}
+	}
+	return names
+}
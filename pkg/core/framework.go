@@ -0,0 +1,237 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FrameworkDetection is a framework found in a project and what gave it away
+type FrameworkDetection struct {
+	Framework string
+	File      string // Where it was found, relative to the project
+	Evidence  string // The dependency or import that matched
+}
+
+// String describes the detection, e.g. "gin (github.com/gin-gonic/gin in go.mod)"
+func (d *FrameworkDetection) String() string {
+	return fmt.Sprintf("%s (%s in %s)", d.Framework, d.Evidence, d.File)
+}
+
+// frameworkDependency is a dependency name that identifies a framework
+type frameworkDependency struct {
+	framework string
+	name      string
+}
+
+// frameworkManifest lists the dependencies looked for in a manifest file.
+// When several match, the first one listed wins (NestJS apps also depend
+// on Express).
+type frameworkManifest struct {
+	files []string
+	deps  []frameworkDependency
+}
+
+var frameworkManifests = []frameworkManifest{
+	{files: []string{"go.mod"}, deps: []frameworkDependency{
+		{"gin", "github.com/gin-gonic/gin"},
+		{"echo", "github.com/labstack/echo"},
+		{"fiber", "github.com/gofiber/fiber"},
+		{"chi", "github.com/go-chi/chi"},
+	}},
+	{files: []string{"package.json"}, deps: []frameworkDependency{
+		{"nestjs", "@nestjs/core"},
+		{"hono", "hono"},
+		{"express", "express"},
+	}},
+	{files: []string{"requirements.txt", "pyproject.toml", "Pipfile", "setup.py"}, deps: []frameworkDependency{
+		{"fastapi", "fastapi"},
+		{"django", "django"},
+		{"flask", "flask"},
+	}},
+	{files: []string{"pom.xml", "build.gradle", "build.gradle.kts"}, deps: []frameworkDependency{
+		{"spring", "spring-boot"},
+	}},
+	{files: []string{"composer.json"}, deps: []frameworkDependency{
+		{"laravel", "laravel/framework"},
+	}},
+	{files: []string{"Gemfile"}, deps: []frameworkDependency{
+		{"rails", "rails"},
+	}},
+	{files: []string{"Cargo.toml"}, deps: []frameworkDependency{
+		{"actix", "actix-web"},
+		{"axum", "axum"},
+	}},
+}
+
+// frameworkSourcePatterns identify frameworks by imports and route
+// declarations in source files, for projects without a manifest ZAP knows
+var frameworkSourcePatterns = []struct {
+	framework string
+	pattern   *regexp.Regexp
+}{
+	{"gin", regexp.MustCompile(`"github\.com/gin-gonic/gin"`)},
+	{"echo", regexp.MustCompile(`"github\.com/labstack/echo`)},
+	{"fiber", regexp.MustCompile(`"github\.com/gofiber/fiber`)},
+	{"chi", regexp.MustCompile(`"github\.com/go-chi/chi`)},
+	{"fastapi", regexp.MustCompile(`(?m)^\s*(from fastapi import|import fastapi)`)},
+	{"django", regexp.MustCompile(`(?m)^\s*from django\.`)},
+	{"flask", regexp.MustCompile(`(?m)^\s*(from flask import|import flask)`)},
+	{"nestjs", regexp.MustCompile(`from ['"]@nestjs/`)},
+	{"hono", regexp.MustCompile(`from ['"]hono['"/]`)},
+	{"express", regexp.MustCompile(`require\(['"]express['"]\)|from ['"]express['"]`)},
+	{"spring", regexp.MustCompile(`import org\.springframework\.`)},
+	{"laravel", regexp.MustCompile(`use Illuminate\\`)},
+	{"rails", regexp.MustCompile(`Rails\.application\.routes\.draw`)},
+	{"actix", regexp.MustCompile(`use actix_web`)},
+	{"axum", regexp.MustCompile(`use axum`)},
+}
+
+// frameworkSourceExts are the source files scanned for frameworkSourcePatterns
+var frameworkSourceExts = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".mjs": true, ".ts": true,
+	".java": true, ".kt": true, ".php": true, ".rb": true, ".rs": true,
+}
+
+const (
+	// maxDetectFiles caps the source files read when no manifest matches
+	maxDetectFiles = 500
+	// maxDetectFileSize skips larger (likely generated) files
+	maxDetectFileSize = 256 * 1024
+)
+
+// DetectFramework guesses the API framework of the project in dir from its
+// manifests (go.mod, package.json, requirements.txt, pom.xml, ...), looking
+// in dir and then its direct subdirectories, and failing that from the
+// imports and route declarations in its source files. It returns nil when
+// nothing matches.
+func DetectFramework(dir string) *FrameworkDetection {
+	if d := detectFromManifests(dir, ""); d != nil {
+		return d
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !skipDetectDir(entry.Name()) {
+			if d := detectFromManifests(filepath.Join(dir, entry.Name()), entry.Name()); d != nil {
+				return d
+			}
+		}
+	}
+	return detectFromSources(dir)
+}
+
+// detectFromManifests checks the manifests in dir; rel is dir relative to
+// the project
+func detectFromManifests(dir, rel string) *FrameworkDetection {
+	for _, manifest := range frameworkManifests {
+		for _, file := range manifest.files {
+			data, err := os.ReadFile(filepath.Join(dir, file))
+			if err != nil {
+				continue
+			}
+			for _, dep := range manifest.deps {
+				if manifestHasDependency(file, data, dep.name) {
+					return &FrameworkDetection{Framework: dep.framework, File: filepath.ToSlash(filepath.Join(rel, file)), Evidence: dep.name}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// manifestHasDependency reports whether a manifest lists name
+func manifestHasDependency(file string, data []byte, name string) bool {
+	switch file {
+	case "package.json", "composer.json":
+		var manifest map[string]json.RawMessage
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return false
+		}
+		for _, key := range []string{"dependencies", "devDependencies", "require", "require-dev"} {
+			var deps map[string]interface{}
+			if err := json.Unmarshal(manifest[key], &deps); err == nil {
+				if _, ok := deps[name]; ok {
+					return true
+				}
+			}
+		}
+		return false
+	case "go.mod":
+		return strings.Contains(string(data), name)
+	case "Gemfile":
+		return regexp.MustCompile(`(?m)^\s*gem\s+['"]` + regexp.QuoteMeta(name) + `['"]`).Match(data)
+	}
+	// A package name, not part of a longer one (django, not django-environ
+	// alone; flask-cors still means Flask)
+	return regexp.MustCompile(`(?im)(^|[\s"'\[,:>])` + regexp.QuoteMeta(name) + `($|[\s"'\]=<>~!;\[,:.-])`).Match(data)
+}
+
+// detectFromSources counts framework imports in dir's source files and
+// returns the framework with the most
+func detectFromSources(dir string) *FrameworkDetection {
+	counts := make(map[string]int)
+	first := make(map[string]*FrameworkDetection)
+	files := 0
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if path != dir && skipDetectDir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !frameworkSourceExts[filepath.Ext(path)] {
+			return nil
+		}
+		if files++; files > maxDetectFiles {
+			return filepath.SkipAll
+		}
+		if info, err := entry.Info(); err != nil || info.Size() > maxDetectFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, sig := range frameworkSourcePatterns {
+			if m := sig.pattern.Find(data); m != nil {
+				counts[sig.framework]++
+				if first[sig.framework] == nil {
+					rel, _ := filepath.Rel(dir, path)
+					first[sig.framework] = &FrameworkDetection{Framework: sig.framework, File: filepath.ToSlash(rel), Evidence: strings.TrimSpace(string(m))}
+				}
+			}
+		}
+		return nil
+	})
+
+	var best *FrameworkDetection
+	for _, sig := range frameworkSourcePatterns {
+		if d := first[sig.framework]; d != nil && (best == nil || counts[sig.framework] > counts[best.Framework]) {
+			best = d
+		}
+	}
+	return best
+}
+
+// skipDetectDir reports whether a directory holds dependencies, builds or
+// tooling rather than the project's own code
+func skipDetectDir(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	switch name {
+	case "node_modules", "vendor", "venv", "env", "__pycache__", "target", "build", "dist", "bin", "obj":
+		return true
+	}
+	return false
+}
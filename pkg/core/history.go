@@ -0,0 +1,214 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/llm"
+)
+
+// DefaultHistoryTokenBudget is the default estimated token budget for the
+// conversation history sent to the LLM. Small local models commonly have
+// 8k context windows, and the system prompt needs room too.
+const DefaultHistoryTokenBudget = 6000
+
+// Limits for the fallback (non-LLM) summary.
+const (
+	maxSummaryLineLen = 160 // characters per line
+	maxSummaryLines   = 60  // oldest lines are dropped beyond this
+)
+
+// summaryPrompt instructs the LLM how to compact older turns.
+const summaryPrompt = `Summarize the earlier part of this API debugging session so it can replace the original messages.
+Keep: endpoints and methods used, status codes and errors seen, variable names and IDs, root causes found, fixes applied, and anything left unfinished.
+Drop: full response bodies, repeated attempts, and pleasantries.
+Write at most 15 short bullet points. Output only the bullets.`
+
+// EstimateTokens returns a rough token count for text.
+// Uses the common ~4 characters per token heuristic, which is close enough
+// for budgeting across the tokenizers of the supported providers.
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return len(text)/4 + 1
+}
+
+// estimateMessagesTokens sums EstimateTokens over message contents.
+func estimateMessagesTokens(messages []llm.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += EstimateTokens(msg.Content)
+	}
+	return total
+}
+
+// SetHistoryTokenBudget sets the estimated token budget for conversation history.
+// When the history (plus any existing summary) exceeds the budget, older turns
+// are summarized into a compact system note. Set to 0 to disable summarization.
+func (a *Agent) SetHistoryTokenBudget(tokens int) {
+	a.historyTokenBudget = tokens
+}
+
+// GetHistorySummary returns the compact summary of turns that were removed
+// from the history, or an empty string if nothing has been summarized yet.
+func (a *Agent) GetHistorySummary() string {
+	a.historyMu.RLock()
+	defer a.historyMu.RUnlock()
+	return a.historySummary
+}
+
+// buildMessages assembles the messages for an LLM call: the system prompt,
+// the summary of older turns (if any) and the recent history.
+// History is compacted first if it exceeds the token budget.
+func (a *Agent) buildMessages() []llm.Message {
+	a.compactHistory()
+
+	messages := []llm.Message{{Role: "system", Content: a.buildSystemPrompt()}}
+	if summary := a.GetHistorySummary(); summary != "" {
+		messages = append(messages, llm.Message{
+			Role:    "system",
+			Content: "Summary of earlier conversation (older messages were compacted):\n" + summary,
+		})
+	}
+	return append(messages, a.getHistorySnapshot()...)
+}
+
+// compactHistory summarizes the oldest turns when the history exceeds the
+// token budget. The most recent turns (about half the budget) are kept
+// verbatim; everything before them is folded into historySummary.
+// Returns true if the history was compacted.
+func (a *Agent) compactHistory() bool {
+	if a.historyTokenBudget <= 0 {
+		return false
+	}
+
+	history := a.getHistorySnapshot()
+	summary := a.GetHistorySummary()
+	if EstimateTokens(summary)+estimateMessagesTokens(history) <= a.historyTokenBudget {
+		return false
+	}
+
+	split := historySplitPoint(history, a.historyTokenBudget/2)
+	if split <= 0 {
+		return false
+	}
+
+	older := history[:split]
+	newSummary := a.summarizeMessages(summary, older)
+
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+	a.historySummary = newSummary
+	// Messages may have been appended while summarizing; drop only the
+	// ones that were folded into the summary.
+	if len(a.history) >= split {
+		a.history = a.history[split:]
+	}
+	return true
+}
+
+// historySplitPoint returns the index of the first message to keep so that
+// the kept messages fit in keepTokens. The split always lands on a user
+// message that starts a turn (not an "Observation:"), so tool calls and their
+// observations stay together. At least the last turn is always kept.
+func historySplitPoint(history []llm.Message, keepTokens int) int {
+	kept := 0
+	split := len(history)
+	for i := len(history) - 1; i >= 0; i-- {
+		kept += EstimateTokens(history[i].Content)
+		if !isTurnStart(history[i]) {
+			continue
+		}
+		if kept > keepTokens && split < len(history) {
+			break
+		}
+		split = i
+	}
+	if split == len(history) {
+		return 0
+	}
+	return split
+}
+
+// isTurnStart reports whether msg is a user request (as opposed to a tool observation).
+func isTurnStart(msg llm.Message) bool {
+	return msg.Role == "user" && !strings.HasPrefix(msg.Content, "Observation:")
+}
+
+// summarizeMessages folds messages into the existing summary.
+// The LLM is asked for a concise summary; if that fails (or there is no
+// client), a deterministic digest of requests, tool calls and answers is used.
+func (a *Agent) summarizeMessages(existing string, messages []llm.Message) string {
+	var transcript strings.Builder
+	if existing != "" {
+		transcript.WriteString("Previous summary:\n")
+		transcript.WriteString(existing)
+		transcript.WriteString("\n\n")
+	}
+	for _, msg := range messages {
+		fmt.Fprintf(&transcript, "[%s] %s\n", msg.Role, truncateForSummary(msg.Content, 2000))
+	}
+
+	if a.llmClient != nil {
+		request := []llm.Message{
+			{Role: "system", Content: summaryPrompt},
+			{Role: "user", Content: transcript.String()},
+		}
+		start := time.Now()
+		summary, err := a.llmClient.Chat(request)
+		a.logLLMCall(a.llmClient, request, summary, nil, err, start)
+		a.addUsage(request, nil, summary, nil, err)
+		if err == nil && strings.TrimSpace(summary) != "" {
+			return strings.TrimSpace(summary)
+		}
+	}
+
+	return a.digestMessages(existing, messages)
+}
+
+// digestMessages builds an extractive summary without calling the LLM:
+// one line per user request, tool call and final answer.
+func (a *Agent) digestMessages(existing string, messages []llm.Message) string {
+	var lines []string
+	if existing != "" {
+		lines = append(lines, existing)
+	}
+
+	for _, msg := range messages {
+		switch {
+		case isTurnStart(msg):
+			lines = append(lines, "- User asked: "+truncateForSummary(msg.Content, maxSummaryLineLen))
+		case msg.Role == "user":
+			// Observation: keep only the first line (usually the status)
+			first := strings.SplitN(strings.TrimPrefix(msg.Content, "Observation:"), "\n", 2)[0]
+			lines = append(lines, "  result: "+truncateForSummary(strings.TrimSpace(first), maxSummaryLineLen))
+		case msg.Role == "assistant":
+			_, toolName, toolArgs, finalAnswer := a.parseResponse(msg.Content)
+			if toolName != "" {
+				lines = append(lines, fmt.Sprintf("- Called %s(%s)", toolName, truncateForSummary(toolArgs, maxSummaryLineLen)))
+			} else {
+				lines = append(lines, "- Answered: "+truncateForSummary(finalAnswer, maxSummaryLineLen))
+			}
+		}
+	}
+
+	// Earlier digests are folded in as a single entry; split so the cap
+	// applies to individual lines.
+	lines = strings.Split(strings.Join(lines, "\n"), "\n")
+	if len(lines) > maxSummaryLines {
+		lines = lines[len(lines)-maxSummaryLines:]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// truncateForSummary collapses whitespace and shortens s to max characters.
+func truncateForSummary(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > max {
+		return s[:max] + "..."
+	}
+	return s
+}
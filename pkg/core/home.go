@@ -0,0 +1,98 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// UserZapDir returns the user-level ~/.zap directory (or $ZAP_HOME). Its
+// config.json holds defaults for every project and its requests/ folder a
+// request library shared between projects. It returns "" when there is no
+// home directory, or when it is the current project's own .zap folder.
+func UserZapDir() string {
+	dir := os.Getenv("ZAP_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil || home == "" {
+			return ""
+		}
+		dir = filepath.Join(home, ZapFolderName)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	if project, err := filepath.Abs(ZapFolderName); err == nil && project == abs {
+		return ""
+	}
+	return abs
+}
+
+// LoadUserConfig reads ~/.zap/config.json. It returns nil when there is none.
+func LoadUserConfig() (*Config, error) {
+	dir := UserZapDir()
+	if dir == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Join(dir, "config.json"), err)
+	}
+	return &config, nil
+}
+
+// saveUserDefaults writes the provider settings, model and theme of config to
+// ~/.zap/config.json, so setting up the next project skips those questions.
+func saveUserDefaults(config Config) (string, error) {
+	dir := UserZapDir()
+	if dir == "" {
+		return "", fmt.Errorf("no home directory to save defaults to")
+	}
+
+	// Keep anything else already in the file
+	path := filepath.Join(dir, "config.json")
+	defaults := make(map[string]interface{})
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &defaults)
+	}
+
+	defaults["provider"] = config.Provider
+	defaults["default_model"] = config.DefaultModel
+	defaults["theme"] = config.Theme
+	if config.OllamaConfig != nil {
+		defaults["ollama"] = config.OllamaConfig
+	}
+	if config.GeminiConfig != nil {
+		defaults["gemini"] = config.GeminiConfig
+	}
+	if config.OpenAIConfig != nil {
+		defaults["openai"] = config.OpenAIConfig
+	}
+	if config.AnthropicConfig != nil {
+		defaults["anthropic"] = config.AnthropicConfig
+	}
+
+	data, err := json.MarshalIndent(defaults, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal user config: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	// May hold API keys when there is no OS keyring, so only the user can read it
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write user config: %w", err)
+	}
+	return path, nil
+}
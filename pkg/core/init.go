@@ -30,15 +30,122 @@ type GeminiConfig struct {
 	APIKey string `json:"api_key"` // Gemini API key
 }
 
+// OpenAIConfig holds configuration for OpenAI-compatible APIs
+// (OpenAI, OpenRouter, LM Studio, vLLM, ...)
+type OpenAIConfig struct {
+	BaseURL string `json:"base_url"` // API root including version, e.g. https://api.openai.com/v1
+	APIKey  string `json:"api_key"`  // API key (may be empty for local servers)
+}
+
+// AnthropicConfig holds Anthropic (Claude) configuration
+type AnthropicConfig struct {
+	APIKey string `json:"api_key"` // Anthropic API key
+}
+
+// LLMRetryConfig holds retry settings for LLM API calls
+type LLMRetryConfig struct {
+	MaxAttempts int `json:"max_attempts"`  // Total attempts including the first (1 = no retries)
+	BaseDelayMs int `json:"base_delay_ms"` // Delay before the first retry, doubled each attempt
+	MaxDelayMs  int `json:"max_delay_ms"`  // Upper bound for a single delay
+}
+
+// GenerationConfig holds model sampling parameters. Unset fields use the
+// provider's defaults.
+type GenerationConfig struct {
+	Temperature *float64 `json:"temperature,omitempty"` // Lower = more deterministic tool calls
+	TopP        *float64 `json:"top_p,omitempty"`       // Nucleus sampling cutoff
+	NumCtx      int      `json:"num_ctx,omitempty"`     // Context window size (Ollama only)
+}
+
+// MemoryConfig holds settings for the persistent agent memory
+type MemoryConfig struct {
+	Embeddings        bool   `json:"embeddings"`                   // Enable semantic recall via embeddings
+	EmbeddingProvider string `json:"embedding_provider,omitempty"` // "ollama", "gemini" or "openai" (default: provider)
+	EmbeddingModel    string `json:"embedding_model,omitempty"`    // Embedding model (provider default if empty)
+}
+
+// BudgetConfig caps estimated LLM usage per session (0 = no limit)
+type BudgetConfig struct {
+	MaxTokens          int     `json:"max_tokens,omitempty"`            // Max estimated tokens (input + output)
+	MaxCostUSD         float64 `json:"max_cost_usd,omitempty"`          // Max estimated cost in USD
+	InputPricePerMTok  float64 `json:"input_price_per_mtok,omitempty"`  // USD per million input tokens
+	OutputPricePerMTok float64 `json:"output_price_per_mtok,omitempty"` // USD per million output tokens
+}
+
+// RequestIDsConfig controls automatic Idempotency-Key / X-Request-Id headers.
+// Templates may use {uuid}, {timestamp}, {unix_ms}, {counter} and {random}.
+type RequestIDsConfig struct {
+	Enabled        bool   `json:"enabled"`                   // Add the headers to every request
+	IdempotencyKey string `json:"idempotency_key,omitempty"` // Template for POST/PATCH (default "{uuid}", "off" disables)
+	RequestID      string `json:"request_id,omitempty"`      // Template for all requests (default "{uuid}", "off" disables)
+}
+
+// LogsConfig says where tail_logs reads the server's logs from
+type LogsConfig struct {
+	File      string `json:"file,omitempty"`      // Log file, relative to the project or absolute
+	Container string `json:"container,omitempty"` // Docker container name or ID
+}
+
+// CommandsConfig lists the commands run_command may run, e.g. "go test" or
+// "npm run dev". A command is allowed when it starts with one of them.
+type CommandsConfig struct {
+	Allow   []string `json:"allow"`             // Allowed command prefixes (empty disables run_command)
+	Timeout int      `json:"timeout,omitempty"` // Seconds before a command is stopped (default 300)
+}
+
+// FallbackProviderConfig names a provider/model to use when the primary fails.
+// Credentials and URLs come from that provider's own config block.
+type FallbackProviderConfig struct {
+	Provider string `json:"provider"` // "ollama", "gemini", "openai" or "anthropic"
+	Model    string `json:"model"`    // Model name (provider default if empty)
+}
+
 // Config represents the user's ZAP configuration
 type Config struct {
-	Provider     string           `json:"provider"` // "ollama" or "gemini"
-	OllamaConfig *OllamaConfig    `json:"ollama,omitempty"`
-	GeminiConfig *GeminiConfig    `json:"gemini,omitempty"`
-	DefaultModel string           `json:"default_model"`
-	Theme        string           `json:"theme"`
-	Framework    string           `json:"framework"` // API framework (e.g., gin, fastapi, express)
-	ToolLimits   ToolLimitsConfig `json:"tool_limits"`
+	Provider        string           `json:"provider,omitempty"` // "ollama", "gemini", "openai" or "anthropic"; from ~/.zap/config.json if empty
+	OllamaConfig    *OllamaConfig    `json:"ollama,omitempty"`
+	GeminiConfig    *GeminiConfig    `json:"gemini,omitempty"`
+	OpenAIConfig    *OpenAIConfig    `json:"openai,omitempty"`
+	AnthropicConfig *AnthropicConfig `json:"anthropic,omitempty"`
+	DefaultModel    string           `json:"default_model,omitempty"`
+	Theme           string           `json:"theme,omitempty"`
+	Framework       string           `json:"framework"` // API framework (e.g., gin, fastapi, express)
+	ToolLimits      ToolLimitsConfig `json:"tool_limits"`
+
+	// Generation sets temperature, top_p and num_ctx for LLM requests
+	Generation *GenerationConfig `json:"generation,omitempty"`
+
+	// NativeTools enables native (structured) tool calling when the model
+	// supports it (default true)
+	NativeTools *bool `json:"native_tools,omitempty"`
+
+	// LLMRetry controls retries of transient LLM failures (5xx, 429, timeouts)
+	LLMRetry *LLMRetryConfig `json:"llm_retry,omitempty"`
+
+	// FallbackProviders are tried in order when the primary provider fails
+	FallbackProviders []FallbackProviderConfig `json:"fallback_providers,omitempty"`
+
+	// HistoryTokenBudget is the estimated token budget for conversation history
+	// before older turns are summarized (0 = use the default)
+	HistoryTokenBudget int `json:"history_token_budget,omitempty"`
+
+	// Budget stops the agent once a session's token/cost budget is spent
+	Budget *BudgetConfig `json:"budget,omitempty"`
+
+	// Memory configures semantic recall for the memory tool
+	Memory *MemoryConfig `json:"memory,omitempty"`
+
+	// LLMLog enables the .zap/llm-log/ audit log of prompts and completions
+	LLMLog bool `json:"llm_log,omitempty"`
+
+	// RequestIDs adds generated Idempotency-Key / X-Request-Id headers
+	RequestIDs *RequestIDsConfig `json:"request_ids,omitempty"`
+
+	// Logs is the server log file or container read by tail_logs
+	Logs *LogsConfig `json:"logs,omitempty"`
+
+	// Commands is the allowlist of shell commands for run_command
+	Commands *CommandsConfig `json:"commands,omitempty"`
 
 	// Legacy fields for backward compatibility (deprecated)
 	OllamaURL    string `json:"ollama_url,omitempty"`
@@ -67,13 +174,19 @@ var SupportedFrameworks = []string{
 
 // SetupResult holds the collected values from the first-run setup wizard.
 type SetupResult struct {
-	Framework   string
-	Provider    string // "ollama" or "gemini"
-	OllamaMode  string // "local" or "cloud" (for Ollama only)
-	OllamaURL   string // Ollama API URL
-	GeminiKey   string // Gemini API key
-	OllamaKey   string // Ollama API key (for cloud mode)
-	Model       string
+	Framework    string
+	Provider     string // "ollama", "gemini", "openai" or "anthropic"
+	OllamaMode   string // "local" or "cloud" (for Ollama only)
+	OllamaURL    string // Ollama API URL
+	GeminiKey    string // Gemini API key
+	OllamaKey    string // Ollama API key (for cloud mode)
+	OpenAIURL    string // OpenAI-compatible base URL
+	OpenAIKey    string // OpenAI-compatible API key
+	AnthropicKey string // Anthropic API key
+	Model        string
+
+	// SaveAsDefault also writes the provider settings to ~/.zap/config.json
+	SaveAsDefault bool
 }
 
 // frameworkGroup organizes frameworks by language for the setup wizard.
@@ -95,8 +208,9 @@ var frameworkGroups = []frameworkGroup{
 }
 
 // buildFrameworkOptions creates huh.Option entries for all supported frameworks,
-// labeled by language (e.g., "gin (Go)").
-func buildFrameworkOptions() []huh.Option[string] {
+// labeled by language (e.g., "gin (Go)"). The detected framework, if any, is
+// marked and preselected.
+func buildFrameworkOptions(detected string) []huh.Option[string] {
 	var options []huh.Option[string]
 	for _, group := range frameworkGroups {
 		for _, fw := range group.Frameworks {
@@ -104,17 +218,42 @@ func buildFrameworkOptions() []huh.Option[string] {
 			if fw == "other" {
 				label = "other (custom/unlisted)"
 			}
+			if fw == detected {
+				options = append(options, huh.NewOption(label+" - detected", fw).Selected(true))
+				continue
+			}
 			options = append(options, huh.NewOption(label, fw))
 		}
 	}
 	return options
 }
 
+// frameworkSelect asks for the API framework. When one is detected in the
+// current directory it is preselected, so the prompt is only a confirmation.
+func frameworkSelect(value *string) *huh.Select[string] {
+	title := "Select your API framework"
+	description := "ZAP uses this to provide framework-specific debugging hints."
+	detected := ""
+	if d := DetectFramework("."); d != nil {
+		detected = d.Framework
+		title = "Confirm your API framework"
+		description = fmt.Sprintf("Detected %s. Press enter to confirm or pick another.", d)
+	}
+	return huh.NewSelect[string]().
+		Title(title).
+		Description(description).
+		Options(buildFrameworkOptions(detected)...).
+		Value(value).
+		Height(10)
+}
+
 // providerOptions returns the available LLM provider options for the setup wizard.
 func providerOptions() []huh.Option[string] {
 	return []huh.Option[string]{
 		huh.NewOption("Ollama (local or cloud)", "ollama"),
 		huh.NewOption("Gemini (Google AI)", "gemini"),
+		huh.NewOption("OpenAI-compatible (OpenAI, OpenRouter, LM Studio)", "openai"),
+		huh.NewOption("Claude (Anthropic)", "anthropic"),
 	}
 }
 
@@ -138,6 +277,9 @@ func runSetupWizard(frameworkFlag string) (*SetupResult, error) {
 		ollamaURL         string
 		ollamaKey         string
 		geminiKey         string
+		openaiURL         string
+		openaiKey         string
+		anthropicKey      string
 		modelName         string
 	)
 
@@ -151,12 +293,7 @@ func runSetupWizard(frameworkFlag string) (*SetupResult, error) {
 	if frameworkFlag == "" {
 		configGroups = append(configGroups,
 			huh.NewGroup(
-				huh.NewSelect[string]().
-					Title("Select your API framework").
-					Description("ZAP uses this to provide framework-specific debugging hints.").
-					Options(buildFrameworkOptions()...).
-					Value(&selectedFramework).
-					Height(10),
+				frameworkSelect(&selectedFramework),
 			),
 		)
 	}
@@ -273,6 +410,75 @@ func runSetupWizard(frameworkFlag string) (*SetupResult, error) {
 			result.Model = modelName
 		}
 
+	} else if selectedProvider == "openai" {
+		// OpenAI-compatible configuration
+		openaiForm := huh.NewForm(
+			huh.NewGroup(
+				huh.NewInput().
+					Title("Base URL").
+					Description("API root including version (default: https://api.openai.com/v1).\nOpenRouter: https://openrouter.ai/api/v1, LM Studio: http://localhost:1234/v1").
+					Placeholder("https://api.openai.com/v1").
+					Value(&openaiURL),
+				huh.NewInput().
+					Title("Model name").
+					Description("The model to use (default: gpt-4o-mini).").
+					Placeholder("gpt-4o-mini").
+					Value(&modelName),
+				huh.NewInput().
+					Title("API Key").
+					Description("Leave empty for local servers that don't require one.").
+					Placeholder("Enter your API key...").
+					EchoMode(huh.EchoModePassword).
+					Value(&openaiKey),
+			),
+		).WithTheme(huh.ThemeDracula())
+
+		if err := openaiForm.Run(); err != nil {
+			return nil, fmt.Errorf("setup cancelled: %w", err)
+		}
+
+		// Set defaults for OpenAI
+		if openaiURL == "" {
+			openaiURL = "https://api.openai.com/v1"
+		}
+		if modelName == "" {
+			modelName = "gpt-4o-mini"
+		}
+
+		result.OpenAIURL = openaiURL
+		result.OpenAIKey = openaiKey
+		result.Model = modelName
+
+	} else if selectedProvider == "anthropic" {
+		// Anthropic configuration
+		anthropicForm := huh.NewForm(
+			huh.NewGroup(
+				huh.NewInput().
+					Title("Anthropic API Key").
+					Description("Get your API key from console.anthropic.com.").
+					Placeholder("Enter your Anthropic API key...").
+					EchoMode(huh.EchoModePassword).
+					Value(&anthropicKey),
+				huh.NewInput().
+					Title("Model name").
+					Description("The Claude model to use (default: claude-sonnet-4-5).").
+					Placeholder("claude-sonnet-4-5").
+					Value(&modelName),
+			),
+		).WithTheme(huh.ThemeDracula())
+
+		if err := anthropicForm.Run(); err != nil {
+			return nil, fmt.Errorf("setup cancelled: %w", err)
+		}
+
+		// Set defaults for Anthropic
+		if modelName == "" {
+			modelName = "claude-sonnet-4-5"
+		}
+
+		result.AnthropicKey = anthropicKey
+		result.Model = modelName
+
 	} else {
 		// Gemini configuration
 		geminiForm := huh.NewForm(
@@ -323,6 +529,21 @@ func runSetupWizard(frameworkFlag string) (*SetupResult, error) {
 				maskAPIKey(result.OllamaKey),
 			)
 		}
+	} else if result.Provider == "openai" {
+		confirmDescription = fmt.Sprintf(
+			"Provider:  OpenAI-compatible\nFramework: %s\nURL:       %s\nModel:     %s\nAPI Key:   %s",
+			result.Framework,
+			result.OpenAIURL,
+			result.Model,
+			maskAPIKey(result.OpenAIKey),
+		)
+	} else if result.Provider == "anthropic" {
+		confirmDescription = fmt.Sprintf(
+			"Provider:  Claude (Anthropic)\nFramework: %s\nModel:     %s\nAPI Key:   %s",
+			result.Framework,
+			result.Model,
+			maskAPIKey(result.AnthropicKey),
+		)
 	} else {
 		confirmDescription = fmt.Sprintf(
 			"Provider:  Gemini\nFramework: %s\nModel:     %s\nAPI Key:   %s",
@@ -333,16 +554,26 @@ func runSetupWizard(frameworkFlag string) (*SetupResult, error) {
 	}
 
 	var confirmed bool
-	confirmForm := huh.NewForm(
-		huh.NewGroup(
+	confirmFields := []huh.Field{
+		huh.NewConfirm().
+			Title("Create configuration with these settings?").
+			Description(confirmDescription).
+			Affirmative("Yes, create config").
+			Negative("No, cancel").
+			Value(&confirmed),
+	}
+	if userDir := UserZapDir(); userDir != "" {
+		result.SaveAsDefault = true
+		confirmFields = append(confirmFields,
 			huh.NewConfirm().
-				Title("Create configuration with these settings?").
-				Description(confirmDescription).
-				Affirmative("Yes, create config").
-				Negative("No, cancel").
-				Value(&confirmed),
-		),
-	).WithTheme(huh.ThemeDracula())
+				Title("Use these settings for new projects too?").
+				Description(fmt.Sprintf("Saved to %s, so other projects only ask for the framework.", filepath.Join(userDir, "config.json"))).
+				Affirmative("Yes").
+				Negative("No").
+				Value(&result.SaveAsDefault),
+		)
+	}
+	confirmForm := huh.NewForm(huh.NewGroup(confirmFields...)).WithTheme(huh.ThemeDracula())
 
 	if err := confirmForm.Run(); err != nil {
 		return nil, fmt.Errorf("confirmation cancelled: %w", err)
@@ -355,6 +586,27 @@ func runSetupWizard(frameworkFlag string) (*SetupResult, error) {
 	return result, nil
 }
 
+// runFrameworkSetup is the setup for a new project when ~/.zap/config.json
+// already has the provider settings: only the framework is asked for.
+func runFrameworkSetup(frameworkFlag string, defaults *Config) (*SetupResult, error) {
+	selectedFramework := frameworkFlag
+	if selectedFramework == "" {
+		fmt.Println()
+		fmt.Printf("  Using %s (%s) from your user config.\n", defaults.Provider, defaults.DefaultModel)
+		fmt.Println()
+
+		form := huh.NewForm(
+			huh.NewGroup(
+				frameworkSelect(&selectedFramework),
+			),
+		).WithTheme(huh.ThemeDracula())
+		if err := form.Run(); err != nil {
+			return nil, fmt.Errorf("setup cancelled: %w", err)
+		}
+	}
+	return &SetupResult{Framework: selectedFramework}, nil
+}
+
 // maskAPIKey returns a masked version of the API key for display.
 func maskAPIKey(key string) string {
 	if key == "" {
@@ -371,8 +623,18 @@ func maskAPIKey(key string) string {
 func InitializeZapFolder(framework string) error {
 	// Check if .zap exists
 	if _, err := os.Stat(ZapFolderName); os.IsNotExist(err) {
-		// Run interactive setup wizard on first run
-		setup, err := runSetupWizard(framework)
+		// Run interactive setup wizard on first run. With provider settings
+		// in ~/.zap/config.json only the framework is needed.
+		userConfig, err := LoadUserConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		var setup *SetupResult
+		if userConfig != nil && userConfig.Provider != "" {
+			setup, err = runFrameworkSetup(framework, userConfig)
+		} else {
+			setup, err = runSetupWizard(framework)
+		}
 		if err != nil {
 			return fmt.Errorf("setup failed: %w", err)
 		}
@@ -436,6 +698,13 @@ func InitializeZapFolder(framework string) error {
 		CreateManifest(ZapFolderName)
 	}
 
+	// Point BASE_URL at the local server when the dev environment has none
+	if detected, err := seedBaseURL(GetConfigFramework()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to set BASE_URL: %v\n", err)
+	} else if detected != nil {
+		fmt.Printf("Set BASE_URL in the dev environment to %s\n", detected)
+	}
+
 	return nil
 }
 
@@ -505,12 +774,13 @@ func createDefaultEnvironment() error {
 	return nil
 }
 
-// createDefaultConfig creates a default configuration file with the setup wizard results.
+// createDefaultConfig creates a default configuration file with the setup
+// wizard results. Without a provider in setup, the provider settings, model
+// and theme are left to ~/.zap/config.json.
 func createDefaultConfig(setup *SetupResult) error {
 	config := Config{
 		Provider:     setup.Provider,
 		DefaultModel: setup.Model,
-		Theme:        "dark",
 		Framework:    setup.Framework,
 		ToolLimits: ToolLimitsConfig{
 			DefaultLimit: 50,  // Default: 50 calls per tool
@@ -519,14 +789,29 @@ func createDefaultConfig(setup *SetupResult) error {
 				// High-risk tools (external I/O)
 				"http_request":     25,
 				"performance_test": 5,
+				"fuzz_endpoint":    5,
+				"security_scan":    5,
 				"webhook_listener": 10,
+				"chaos_proxy":      10,
 				"auth_oauth2":      10,
+				"db_query":         20,
+				"run_command":      10, // Every run requires confirmation
 				// Medium-risk tools (file system)
-				"read_file":    50,
-				"list_files":   50,
-				"search_code":  30,
-				"save_request": 20,
-				"load_request": 30,
+				"read_file":        50,
+				"list_files":       50,
+				"search_code":      30,
+				"list_routes":      10,
+				"repo_map":         20,
+				"generate_openapi": 5,
+				"generate_tests":   5,
+				"save_request":     20,
+				"load_request":     30,
+				"move_request":     20,
+				"search_requests":  30,
+				"tail_logs":        30,
+				"docker":           20,
+				"git":              20,
+				"env_inspect":      10,
 				// Low-risk tools (in-memory)
 				"variable":             100,
 				"assert_response":      100,
@@ -535,28 +820,47 @@ func createDefaultConfig(setup *SetupResult) error {
 				"auth_basic":           50,
 				"auth_helper":          50,
 				"validate_json_schema": 50,
+				"validate_openapi":     50,
 				"compare_responses":    30,
+				"history":              30,
+				"snapshot":             30,
 				// Special tools
 				"retry":      15,
 				"wait":       20,
 				"test_suite": 10,
+				"iterate":    10,
 				// Memory tool
 				"memory": 50,
 			},
 		},
 	}
 
+	if setup.Provider != "" {
+		config.Theme = "dark"
+	}
+
 	// Set provider-specific config (only for the selected provider)
-	if setup.Provider == "ollama" {
+	if setup.Provider == "" {
+		// Inherited from ~/.zap/config.json
+	} else if setup.Provider == "ollama" {
 		config.OllamaConfig = &OllamaConfig{
 			Mode:   setup.OllamaMode,
 			URL:    setup.OllamaURL,
-			APIKey: setup.OllamaKey,
+			APIKey: storeAPIKey("ollama", setup.OllamaKey),
 		}
 		// Don't set GeminiConfig - it will be omitted from JSON
+	} else if setup.Provider == "openai" {
+		config.OpenAIConfig = &OpenAIConfig{
+			BaseURL: setup.OpenAIURL,
+			APIKey:  storeAPIKey("openai", setup.OpenAIKey),
+		}
+	} else if setup.Provider == "anthropic" {
+		config.AnthropicConfig = &AnthropicConfig{
+			APIKey: storeAPIKey("anthropic", setup.AnthropicKey),
+		}
 	} else {
 		config.GeminiConfig = &GeminiConfig{
-			APIKey: setup.GeminiKey,
+			APIKey: storeAPIKey("gemini", setup.GeminiKey),
 		}
 		// Don't set OllamaConfig - it will be omitted from JSON
 	}
@@ -571,9 +875,32 @@ func createDefaultConfig(setup *SetupResult) error {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
+	if setup.SaveAsDefault {
+		if path, err := saveUserDefaults(config); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			fmt.Printf("Saved provider settings as defaults for new projects in %s\n", path)
+		}
+	}
+
 	return nil
 }
 
+// storeAPIKey saves an API key in the OS keyring and returns what to write to
+// config.json: a keyring reference, or the key itself when no keyring is available.
+func storeAPIKey(provider, key string) string {
+	if key == "" {
+		return ""
+	}
+	ref, stored := StoreSecret(KeyringAccount(provider+".api_key"), key)
+	if stored {
+		fmt.Println("API key saved to the OS keyring")
+	} else {
+		fmt.Println("No OS keyring available, API key saved to .zap/config.json")
+	}
+	return ref
+}
+
 // createMemoryFile creates a memory.json file with versioned format
 func createMemoryFile() error {
 	memory := map[string]interface{}{
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// KeyringService is the service name ZAP's secrets are stored under in the
+// OS keyring (macOS Keychain, Windows Credential Manager, Secret Service).
+const KeyringService = "zap"
+
+// KeyringPrefix marks a config value that lives in the OS keyring, e.g.
+// "keyring:gemini.api_key (/home/me/project)".
+const KeyringPrefix = "keyring:"
+
+var (
+	// ErrKeyringUnavailable is returned when the platform has no usable keyring
+	ErrKeyringUnavailable = errors.New("no OS keyring available")
+	// ErrKeyringNotFound is returned when the keyring has no such secret
+	ErrKeyringNotFound = errors.New("secret not found in OS keyring")
+)
+
+// KeyringSet stores secret under account in the OS keyring.
+func KeyringSet(account, secret string) error {
+	return keyringSet(account, secret)
+}
+
+// KeyringGet reads the secret stored under account.
+func KeyringGet(account string) (string, error) {
+	return keyringGet(account)
+}
+
+// KeyringDelete removes the secret stored under account.
+func KeyringDelete(account string) error {
+	return keyringDelete(account)
+}
+
+// IsKeyringRef reports whether a config value refers to the OS keyring.
+func IsKeyringRef(value string) bool {
+	return strings.HasPrefix(value, KeyringPrefix)
+}
+
+// KeyringAccount returns the keyring account for a config key of the project
+// in the current directory, so projects with different keys don't collide.
+func KeyringAccount(key string) string {
+	dir, err := filepath.Abs(".")
+	if err != nil {
+		return key
+	}
+	return fmt.Sprintf("%s (%s)", key, dir)
+}
+
+// StoreSecret stores secret in the OS keyring and returns the reference to
+// write to the config file instead. When no keyring is available it returns
+// the secret itself, so the value falls back to plaintext file storage.
+func StoreSecret(account, secret string) (string, bool) {
+	if secret == "" || IsKeyringRef(secret) {
+		return secret, false
+	}
+	if err := KeyringSet(account, secret); err != nil {
+		return secret, false
+	}
+	return KeyringPrefix + account, true
+}
+
+// ResolveSecret returns value, reading it from the OS keyring when it is a
+// keyring reference.
+func ResolveSecret(value string) (string, error) {
+	if !IsKeyringRef(value) {
+		return value, nil
+	}
+	account := strings.TrimPrefix(value, KeyringPrefix)
+	secret, err := KeyringGet(account)
+	if err != nil {
+		return "", fmt.Errorf("failed to read '%s' from the OS keyring: %w", account, err)
+	}
+	return secret, nil
+}
+
+// LoadOrCreateKey returns the random 32-byte key stored in the OS keyring
+// under account, creating it on first use. Without a keyring the key is kept
+// in fallbackFile (mode 0600) instead.
+func LoadOrCreateKey(account, fallbackFile string) ([]byte, error) {
+	key, err := LoadKey(account, fallbackFile)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, ErrKeyringNotFound) && !errors.Is(err, ErrKeyringUnavailable) {
+		// Don't replace a key that exists but can't be read right now
+		return nil, err
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(key)
+	if err := KeyringSet(account, encoded); err != nil {
+		if err := os.WriteFile(fallbackFile, []byte(encoded+"\n"), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write key file: %w", err)
+		}
+	}
+	return key, nil
+}
+
+// LoadKey returns a key saved by LoadOrCreateKey without creating one.
+func LoadKey(account, fallbackFile string) ([]byte, error) {
+	if data, err := os.ReadFile(fallbackFile); err == nil {
+		return decodeKey(string(data))
+	}
+	encoded, err := KeyringGet(account)
+	if err != nil {
+		return nil, err
+	}
+	return decodeKey(encoded)
+}
+
+// decodeKey decodes a base64 key saved by LoadOrCreateKey
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid encryption key")
+	}
+	return key, nil
+}
+
+// KeyringProviders are the config.json blocks with an api_key
+var KeyringProviders = []string{"ollama", "gemini", "openai", "anthropic"}
+
+// MigrateConfigSecrets moves the plaintext API keys of config.json into the
+// OS keyring, leaving keyring references behind. It returns the moved keys.
+func MigrateConfigSecrets(zapDir string) ([]string, error) {
+	config, err := readRawConfig(zapDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var moved []string
+	migrate := func(key, value string, set func(string)) error {
+		if value == "" || IsKeyringRef(value) {
+			return nil
+		}
+		account := KeyringAccount(key)
+		if err := KeyringSet(account, value); err != nil {
+			return fmt.Errorf("failed to store %s in the OS keyring: %w", key, err)
+		}
+		set(KeyringPrefix + account)
+		moved = append(moved, key)
+		return nil
+	}
+
+	for _, provider := range KeyringProviders {
+		block, ok := config[provider].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, _ := block["api_key"].(string)
+		if err := migrate(provider+".api_key", value, func(ref string) { block["api_key"] = ref }); err != nil {
+			return moved, err
+		}
+	}
+	// Legacy top-level Ollama key
+	value, _ := config["ollama_api_key"].(string)
+	if err := migrate("ollama_api_key", value, func(ref string) { config["ollama_api_key"] = ref }); err != nil {
+		return moved, err
+	}
+
+	if len(moved) == 0 {
+		return nil, nil
+	}
+	return moved, writeRawConfig(zapDir, config)
+}
+
+// SetConfigSecret stores the API key of provider in the OS keyring and points
+// config.json at it.
+func SetConfigSecret(zapDir, provider, key string) error {
+	if !slices.Contains(KeyringProviders, provider) {
+		return fmt.Errorf("unknown provider '%s' (use %s)", provider, strings.Join(KeyringProviders, ", "))
+	}
+	config, err := readRawConfig(zapDir)
+	if err != nil {
+		return err
+	}
+
+	account := KeyringAccount(provider + ".api_key")
+	if err := KeyringSet(account, key); err != nil {
+		return fmt.Errorf("failed to store %s.api_key in the OS keyring: %w", provider, err)
+	}
+	block, ok := config[provider].(map[string]interface{})
+	if !ok {
+		block = make(map[string]interface{})
+		config[provider] = block
+	}
+	block["api_key"] = KeyringPrefix + account
+	return writeRawConfig(zapDir, config)
+}
+
+// readRawConfig reads config.json as a map, so rewriting it keeps every field
+func readRawConfig(zapDir string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(filepath.Join(zapDir, "config.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var config map[string]interface{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return config, nil
+}
+
+// writeRawConfig writes config.json back
+func writeRawConfig(zapDir string, config map[string]interface{}) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(zapDir, "config.json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// keyringDebug reports keyring command failures when ZAP_DEBUG is set
+func keyringDebug(format string, args ...interface{}) {
+	if os.Getenv("ZAP_DEBUG") != "" {
+		fmt.Fprintf(os.Stderr, "keyring: "+format+"\n", args...)
+	}
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// macOS: the login Keychain, through the security command. Secrets are
+// written through `security -i` on stdin so they never show up in argv.
+
+// securityItemNotFound is security's exit code for a missing item
+const securityItemNotFound = 44
+
+func keyringSet(account, secret string) error {
+	if _, err := exec.LookPath("security"); err != nil {
+		return ErrKeyringUnavailable
+	}
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		securityQuote(KeyringService), securityQuote(account), securityQuote(secret))
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(command)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil || stderr.Len() > 0 {
+		keyringDebug("security add-generic-password: %v %s", err, stderr.String())
+		return fmt.Errorf("failed to store secret in the Keychain: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func keyringGet(account string) (string, error) {
+	if _, err := exec.LookPath("security"); err != nil {
+		return "", ErrKeyringUnavailable
+	}
+	out, err := exec.Command("security", "find-generic-password", "-s", KeyringService, "-a", account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == securityItemNotFound {
+			return "", ErrKeyringNotFound
+		}
+		return "", fmt.Errorf("failed to read secret from the Keychain: %w", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func keyringDelete(account string) error {
+	if _, err := exec.LookPath("security"); err != nil {
+		return ErrKeyringUnavailable
+	}
+	err := exec.Command("security", "delete-generic-password", "-s", KeyringService, "-a", account).Run()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == securityItemNotFound {
+			return ErrKeyringNotFound
+		}
+		return fmt.Errorf("failed to delete secret from the Keychain: %w", err)
+	}
+	return nil
+}
+
+// securityQuote quotes an argument for security's interactive mode
+func securityQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,62 @@
+//go:build !darwin && !windows
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Linux and the BSDs: the Secret Service (GNOME Keyring, KWallet) through
+// secret-tool. The secret is passed on stdin so it never shows up in argv.
+
+func keyringSet(account, secret string) error {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return ErrKeyringUnavailable
+	}
+	cmd := exec.Command("secret-tool", "store", "--label", "ZAP: "+account,
+		"service", KeyringService, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// Usually no Secret Service is running (headless machines, CI)
+		keyringDebug("secret-tool store: %v %s", err, stderr.String())
+		return ErrKeyringUnavailable
+	}
+	return nil
+}
+
+func keyringGet(account string) (string, error) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return "", ErrKeyringUnavailable
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", KeyringService, "account", account)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if stdout.Len() > 0 {
+		return stdout.String(), nil
+	}
+	if err != nil && stderr.Len() > 0 {
+		return "", fmt.Errorf("failed to read secret from the Secret Service: %s", strings.TrimSpace(stderr.String()))
+	}
+	// secret-tool exits 1 without output when nothing matches
+	return "", ErrKeyringNotFound
+}
+
+func keyringDelete(account string) error {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return ErrKeyringUnavailable
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "clear", "service", KeyringService, "account", account)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil && stderr.Len() > 0 {
+		return fmt.Errorf("failed to delete secret from the Secret Service: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
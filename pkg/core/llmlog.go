@@ -0,0 +1,185 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/llm"
+)
+
+// LLMLogDir is the folder (inside .zap) holding the LLM audit log.
+const LLMLogDir = "llm-log"
+
+// LLMLogEntry is one LLM request/response pair in the audit log.
+// All text is masked with maskLogText before it is written.
+type LLMLogEntry struct {
+	Timestamp  string        `json:"timestamp"` // RFC3339
+	Session    string        `json:"session"`
+	Provider   string        `json:"provider"`
+	Model      string        `json:"model"`
+	Messages   []llm.Message `json:"messages"`
+	Response   string        `json:"response,omitempty"`
+	ToolCall   *llm.ToolCall `json:"tool_call,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	DurationMs int64         `json:"duration_ms"`
+}
+
+// LLMLog appends every prompt and completion to .zap/llm-log/<date>.jsonl.
+// It is opt-in (config "llm_log": true) and meant for debugging why the
+// agent chose a tool or reproducing a bad run.
+type LLMLog struct {
+	dir       string
+	sessionID string
+	mu        sync.Mutex
+}
+
+// NewLLMLog creates an audit log writing under zapDir/llm-log.
+func NewLLMLog(zapDir string) *LLMLog {
+	return &LLMLog{
+		dir:       filepath.Join(zapDir, LLMLogDir),
+		sessionID: fmt.Sprintf("session_%s", time.Now().Format("20060102_150405")),
+	}
+}
+
+// Record masks secrets in entry and appends it to today's log file.
+func (l *LLMLog) Record(entry LLMLogEntry) error {
+	entry.Session = l.sessionID
+	if entry.Timestamp == "" {
+		entry.Timestamp = time.Now().Format(time.RFC3339)
+	}
+
+	masked := make([]llm.Message, len(entry.Messages))
+	for i, msg := range entry.Messages {
+		masked[i] = llm.Message{Role: msg.Role, Content: maskLogText(msg.Content)}
+	}
+	entry.Messages = masked
+	entry.Response = maskLogText(entry.Response)
+	entry.Error = maskLogText(entry.Error)
+	if entry.ToolCall != nil {
+		entry.ToolCall = &llm.ToolCall{
+			Name:      entry.ToolCall.Name,
+			Arguments: maskLogText(entry.ToolCall.Arguments),
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create log folder: %w", err)
+	}
+
+	path := filepath.Join(l.dir, time.Now().Format("2006-01-02")+".jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write log entry: %w", err)
+	}
+	return nil
+}
+
+// ReadLLMLog returns the most recent limit entries from the audit log,
+// oldest first. limit <= 0 returns all entries.
+func ReadLLMLog(zapDir string, limit int) ([]LLMLogEntry, error) {
+	files, err := filepath.Glob(filepath.Join(zapDir, LLMLogDir, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log files: %w", err)
+	}
+	// Files are named by date, so lexical order is chronological
+	sort.Strings(files)
+
+	var entries []LLMLogEntry
+	for i := len(files) - 1; i >= 0; i-- {
+		fileEntries, err := readLLMLogFile(files[i])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(fileEntries, entries...)
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// readLLMLogFile parses one JSONL log file, skipping malformed lines.
+func readLLMLogFile(path string) ([]LLMLogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []LLMLogEntry
+	scanner := bufio.NewScanner(f)
+	// Prompts include the full system prompt, so lines can be large
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry LLMLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return entries, nil
+}
+
+// maskLogText masks secrets in logged text: everything RedactSecrets masks,
+// plus anything else that looks like a secret.
+func maskLogText(text string) string {
+	return MaskSecretsInText(RedactSecrets(text))
+}
+
+// SetLLMLog enables the LLM audit log. Pass nil to disable it.
+func (a *Agent) SetLLMLog(log *LLMLog) {
+	a.llmLog = log
+}
+
+// logLLMCall records a request to client in the audit log, if enabled.
+// Logging failures are ignored so they never break a chat.
+func (a *Agent) logLLMCall(client llm.LLMClient, messages []llm.Message, response string, call *llm.ToolCall, err error, start time.Time) {
+	if a.llmLog == nil {
+		return
+	}
+
+	info := client.ModelInfo()
+	entry := LLMLogEntry{
+		Provider:   info.Provider,
+		Model:      info.Model,
+		Messages:   messages,
+		Response:   response,
+		ToolCall:   call,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	_ = a.llmLog.Record(entry)
+}
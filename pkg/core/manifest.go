@@ -75,7 +75,7 @@ func UpdateManifestCounts(zapDir string) error {
 
 	// Count requests
 	requestsDir := filepath.Join(zapDir, "requests")
-	manifest.Counts["requests"] = countYAMLFiles(requestsDir)
+	manifest.Counts["requests"] = countRequestFiles(requestsDir)
 
 	// Count environments
 	environmentsDir := filepath.Join(zapDir, "environments")
@@ -138,6 +138,21 @@ func countYAMLFiles(dir string) int {
 	return count
 }
 
+// countRequestFiles counts .yaml and .yml files in a directory and its
+// collections (subdirectories)
+func countRequestFiles(dir string) int {
+	count := 0
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+				count++
+			}
+		}
+		return nil
+	})
+	return count
+}
+
 // countJSONFiles counts .json files in a directory
 func countJSONFiles(dir string) int {
 	count := 0
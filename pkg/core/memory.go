@@ -49,6 +49,7 @@ type MemoryStore struct {
 	topics    map[string]bool
 	toolsUsed map[string]bool
 	turnCount int
+	embedder  llm.Embedder // enables SemanticRecall (nil = substring recall only)
 }
 
 // NewMemoryStore creates a MemoryStore, loads existing memory, and generates a session ID.
@@ -227,13 +228,14 @@ func (ms *MemoryStore) SaveSessionSummary(history []llm.Message) {
 		return // Nothing happened in this session
 	}
 
-	// Build summary deterministically from first user message + topics + tools
-	summary := ms.buildSessionSummary(history)
+	// Build summary deterministically from first user message + topics + tools,
+	// masking any secrets the user pasted
+	summary := RedactSecrets(ms.buildSessionSummary(history))
 
 	// Collect topics
 	topics := make([]string, 0, len(ms.topics))
 	for t := range ms.topics {
-		topics = append(topics, t)
+		topics = append(topics, RedactSecrets(t))
 	}
 
 	// Collect tools used
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/llm"
+)
+
+// Semantic recall tuning.
+const (
+	memoryIndexFile     = "memory_index.json"
+	maxIndexedSessions  = 200  // most recent sessions included in semantic recall
+	minRecallSimilarity = 0.35 // matches below this cosine similarity are dropped
+)
+
+// MemoryMatch is a semantic recall result: either a saved fact or a past
+// session summary, with its similarity to the query.
+type MemoryMatch struct {
+	Entry   *MemoryEntry  // Set for saved facts
+	Session *SessionEntry // Set for past sessions
+	Score   float64       // Cosine similarity to the query (higher is closer)
+}
+
+// memoryVector is a cached embedding keyed by a hash of the embedded text,
+// so edited facts are re-embedded.
+type memoryVector struct {
+	Hash   string    `json:"hash"`
+	Vector []float64 `json:"vector"`
+}
+
+// memoryIndex is the on-disk format of memory_index.json.
+type memoryIndex struct {
+	Model   string                  `json:"model"`
+	Vectors map[string]memoryVector `json:"vectors"`
+}
+
+// SetEmbedder enables semantic recall using the given embedding model.
+// Embeddings are cached in .zap/memory_index.json.
+func (ms *MemoryStore) SetEmbedder(embedder llm.Embedder) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.embedder = embedder
+}
+
+// HasEmbedder reports whether semantic recall is available.
+func (ms *MemoryStore) HasEmbedder() bool {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.embedder != nil
+}
+
+// SemanticRecall returns the saved facts and past sessions most similar to
+// query, best first. New or changed items are embedded (in one batch) and
+// cached before searching.
+func (ms *MemoryStore) SemanticRecall(query string, limit int) ([]MemoryMatch, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.embedder == nil {
+		return nil, fmt.Errorf("semantic recall is not configured")
+	}
+
+	// Collect everything searchable: facts and past session summaries
+	type indexItem struct {
+		id    string
+		text  string
+		match MemoryMatch
+	}
+	var items []indexItem
+	for i := range ms.entries {
+		e := ms.entries[i]
+		items = append(items, indexItem{
+			id:    "fact:" + e.Key,
+			text:  fmt.Sprintf("[%s] %s: %s", e.Category, e.Key, e.Value),
+			match: MemoryMatch{Entry: &e},
+		})
+	}
+	for _, s := range ms.getRecentSessionsUnlocked(maxIndexedSessions) {
+		s := s
+		items = append(items, indexItem{
+			id:    "session:" + s.SessionID,
+			text:  s.Summary + " " + strings.Join(s.Topics, " "),
+			match: MemoryMatch{Session: &s},
+		})
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	index := ms.loadMemoryIndex()
+	model := ms.embedder.GetModel()
+	if index.Model != model {
+		// Vectors from different models aren't comparable
+		index = memoryIndex{Model: model, Vectors: make(map[string]memoryVector)}
+	}
+
+	// Embed items that are new or whose text changed
+	fresh := make(map[string]memoryVector, len(items))
+	var missingIdx []int
+	var missingText []string
+	for i, item := range items {
+		hash := hashMemoryText(item.text)
+		if v, ok := index.Vectors[item.id]; ok && v.Hash == hash {
+			fresh[item.id] = v
+			continue
+		}
+		missingIdx = append(missingIdx, i)
+		missingText = append(missingText, item.text)
+	}
+
+	if len(missingText) > 0 {
+		vectors, err := ms.embedder.Embed(missingText)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed memories: %w", err)
+		}
+		for j, i := range missingIdx {
+			fresh[items[i].id] = memoryVector{Hash: hashMemoryText(items[i].text), Vector: vectors[j]}
+		}
+	}
+
+	// Forgotten facts drop out because only current items are kept
+	if len(missingText) > 0 || len(fresh) != len(index.Vectors) {
+		index.Vectors = fresh
+		if err := ms.saveMemoryIndex(index); err != nil {
+			return nil, err
+		}
+	}
+
+	queryVectors, err := ms.embedder.Embed([]string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	var matches []MemoryMatch
+	for _, item := range items {
+		score := llm.CosineSimilarity(queryVectors[0], fresh[item.id].Vector)
+		if score < minRecallSimilarity {
+			continue
+		}
+		match := item.match
+		match.Score = score
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// hashMemoryText returns a short content hash used to detect changed items.
+func hashMemoryText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:8])
+}
+
+// loadMemoryIndex reads memory_index.json, returning an empty index if it
+// is missing or unreadable (it is only a cache).
+func (ms *MemoryStore) loadMemoryIndex() memoryIndex {
+	index := memoryIndex{Vectors: make(map[string]memoryVector)}
+	data, err := os.ReadFile(filepath.Join(ms.zapDir, memoryIndexFile))
+	if err != nil {
+		return index
+	}
+	if err := json.Unmarshal(data, &index); err != nil || index.Vectors == nil {
+		return memoryIndex{Vectors: make(map[string]memoryVector)}
+	}
+	return index
+}
+
+// saveMemoryIndex writes memory_index.json (must be called with lock held).
+func (ms *MemoryStore) saveMemoryIndex(index memoryIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(ms.zapDir, memoryIndexFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write memory index: %w", err)
+	}
+	return nil
+}
@@ -7,37 +7,12 @@ import (
 
 // buildSystemPrompt constructs the complete system prompt for the LLM.
 // It includes identity, scope, guardrails, behavioral rules, and tool descriptions.
+// Sections can be replaced or extended via .zap/prompts/ (see SetPromptOverrides).
 func (a *Agent) buildSystemPrompt() string {
 	var sb strings.Builder
-
-	// Core behavioral sections (order matters - most important first)
-	sb.WriteString(a.buildIdentitySection())
-	sb.WriteString(a.buildScopeSection())
-	sb.WriteString(a.buildGuardrailsSection())
-	sb.WriteString(a.buildBehavioralRulesSection())
-	sb.WriteString(a.buildAutonomousWorkflow())
-	sb.WriteString(a.buildZapFolderSync())
-	sb.WriteString(a.buildSecretsHandling())
-	sb.WriteString(a.buildToolUsageRules())
-
-	// Context and memory
-	sb.WriteString(a.buildMemorySection())
-	sb.WriteString(a.buildToolsSection())
-
-	// Framework and workflow guidance
-	sb.WriteString(a.buildFrameworkHintsSection())
-	sb.WriteString(a.buildNaturalLanguageSection())
-	sb.WriteString(a.buildErrorDiagnosisSection())
-	sb.WriteString(a.buildCommonErrorSection())
-	sb.WriteString(a.buildPersistenceSection())
-	sb.WriteString(a.buildTestingSection())
-	sb.WriteString(a.buildChainingSection())
-	sb.WriteString(a.buildAuthSection())
-	sb.WriteString(a.buildTestSuiteSection())
-
-	// Output format (always last)
-	sb.WriteString(a.buildOutputFormatSection())
-
+	for _, section := range a.promptSections() {
+		sb.WriteString(a.applyPromptOverride(section.name, section.build()))
+	}
 	return sb.String()
 }
 
@@ -130,7 +105,7 @@ func (a *Agent) buildBehavioralRulesSection() string {
 4. User mentions project conventions or preferences
 
 ### ALWAYS Check Before Acting:
-1. list_requests - Does a similar request already exist?
+1. list_requests (or search_requests) - Does a similar request already exist?
 2. memory recall - Have you learned this before?
 3. list_environments - Which environment is active?
 
@@ -148,8 +123,9 @@ Parse user request to identify: method, URL, headers, body, expected outcome.
 
 ### Step 2: Context Check (REQUIRED before every request)
 - memory recall: Check for saved project knowledge (base URLs, auth patterns)
-- list_requests: Check if similar request already exists
+- list_requests or search_requests: Check if similar request already exists
 - list_environments: Know which environment is active
+- list_routes: When the user names an endpoint you don't know the exact path of, look it up instead of guessing
 
 ### Step 3: Prepare Request
 - If similar request exists: load_request and modify as needed
@@ -163,9 +139,10 @@ Parse user request to identify: method, URL, headers, body, expected outcome.
 
 ### Step 5: Diagnose (on error)
 - Analyze error response for clues
-- search_code for endpoint path, error messages
+- repo_map (query: the resource name) to find the handler, then search_code for endpoint path, error messages
 - read_file to examine handler code
 - Provide: file:line + cause + suggested fix
+- To apply a fix the user asks for, use apply_patch with a minimal unified diff (write_file only for new files)
 
 ### Step 6: Learn & Persist
 - Save useful discoveries to memory (project patterns, endpoints)
@@ -233,11 +210,12 @@ BAD:  "api_key": "AIzaSyAbCdEfGhIjKlMnOpQrStUvWxYz"
 ### Variable Scopes:
 - session: Temporary, cleared on exit (USE FOR TOKENS)
 - global: Persisted to disk (USE ONLY for non-sensitive data like base URLs)
+- "secret": true: Value is masked in all output; a secret global is encrypted on disk
 
 When user provides a credential:
-1. Save it to session scope variable
+1. Save it to a session variable with "secret": true (global + secret only if the user wants it kept)
 2. Use {{VAR}} in the request
-3. Never persist secrets to global scope or memory
+3. Never persist secrets unencrypted to global scope or memory
 
 `
 }
@@ -260,12 +238,23 @@ func (a *Agent) buildToolUsageRules() string {
 | http_request | Execute the request |
 | assert_response | Validate response matches expectations |
 | extract_value | Pull values for request chaining |
+| iterate | Send a request for each element of a list variable |
+| generate_tests | Bootstrap suites (happy-path and negative tests) from an OpenAPI spec |
 | variable | Store extracted values |
 
 ### After Errors:
 | Tool | When to Use |
 |------|-------------|
-| search_code | Find endpoint handlers by path/error |
+| repo_map | Where code lives: functions, classes and routes per file (query narrows, e.g. "user") |
+| search_code | Find endpoint handlers by path/error; context 5 shows the code around matches without read_file |
+| list_routes | List the API's endpoints (method, path, handler file:line) |
+| generate_openapi | Draft an OpenAPI spec from routes and past calls when asked to document the API |
+| db_query | Check that a write persisted the expected rows (read-only SQL, DSN from DATABASE_URL) |
+| tail_logs | After a 5xx, read the server logs for the error and stack trace (request_id "last" narrows to the request) |
+| docker | Connection refused or no response and the API runs in Docker: ps, then inspect the container |
+| git | After finding the failing line: blame it to see who changed it and how many commits ago, diff to see what changed |
+| env_inspect | A 500 that may come from missing config (database, secrets, API keys): list unset variables |
+| run_command | Only when the user asks: run the tests or restart the dev server (background true; action stop with its pid first) |
 | read_file | Examine specific code files |
 | memory save | Save diagnosis for future reference |
 
@@ -276,10 +265,22 @@ func (a *Agent) buildToolUsageRules() string {
 | memory save | Project knowledge (base URLs, patterns) |
 | variable (global) | Non-sensitive persistent values |
 | variable (session) | Tokens, temporary data |
+| variable (secret) | Credentials: masked, encrypted if global |
 
 `
 }
 
+// buildProjectSection returns the project instructions from ZAP.md.
+// Returns empty string if the project has no ZAP.md.
+func (a *Agent) buildProjectSection() string {
+	if a.projectInstructions == "" {
+		return ""
+	}
+	return "## PROJECT INSTRUCTIONS (from " + ProjectInstructionsFile + ")\n" +
+		"Follow these project-specific conventions. They take precedence over general guidance, but not over GUARDRAILS.\n\n" +
+		a.projectInstructions + "\n\n"
+}
+
 // buildMemorySection returns the memory context section for the system prompt.
 // Returns empty string if no memory store is configured.
 func (a *Agent) buildMemorySection() string {
@@ -500,9 +501,14 @@ func (a *Agent) buildPersistenceSection() string {
 You can save and load API requests for reuse:
 - Use save_request to save a request with variables like {{BASE_URL}}
 - Use load_request to load a saved request
-- Use list_requests to see all saved requests
+- Requests in .http files (VS Code REST Client format) load by name or as {"name": "users.http#create-user"}; pass "file": "users.http" to save_request to add one to such a file
+- Use list_requests to see all saved requests, or {"collection": "users"} for one collection
+- Use search_requests {"query": "create user"} or {"tags": ["smoke"]} to find saved requests by name, URL, tags or body once there are more than a few; pass "tags" to save_request so they can be found
+- Requests listed with a ~/ prefix are in the user's shared library (~/.zap/requests) and load in every project; save with "library": true for requests useful across projects (health checks, auth flows)
+- Group related requests in collections (subfolders, nested like users/admin): pass "collection" to save_request, reorganize with move_request
 - Use set_environment to switch between dev/prod environments
 - Use list_environments to see available environments
+- If set_environment lists auth profiles, send {"use_auth": "profile_name"} on http_request (or test_suite) instead of hardcoding credentials; the profile follows the active environment
 
 IMPORTANT: Always use {{VAR}} placeholders for sensitive values when saving requests.
 
@@ -516,25 +522,42 @@ After making HTTP requests, you can validate and extract data:
 
 1. **assert_response** - Validate responses against expected criteria:
    - Status codes: {"status_code": 200, "status_code_not": 500}
-   - Headers: {"headers": {"Content-Type": "application/json"}}
+   - Headers: {"headers": {"Content-Type": "application/json"}} (names are case-insensitive)
+   - Header patterns, absence and repeated headers: {"headers_match": {"Cache-Control": "max-age=\\d+"}, "headers_not_present": ["X-Powered-By"], "header_values": {"Set-Cookie": ["session=", "csrf="]}}
    - Body content: {"body_contains": ["user_id"], "body_not_contains": ["error"]}
    - JSON path: {"json_path": {"$.status": "active", "$.data.id": 123}}
+   - Existence, lengths and ranges: {"json_path_exists": ["$.data.id"], "json_path_length": {"$.items": 3, "$.tags": {"gte": 1}}, "json_path_compare": {"$.total": {"gt": 0, "lte": 100}}}
+   - Types and negatives, to catch contract drift: {"json_path_type": {"$.id": "integer", "$.deleted_at": "string|null"}, "json_path_not": {"$.email": null, "$.status": "error"}}
+   - Regex: {"body_matches_regex": "\\d{4}-\\d{2}-\\d{2}"}
    - Performance: {"response_time_max_ms": 500}
+   - Compression: {"content_encoding": "gzip"} ("identity" = not compressed)
+   - Caching: send the same GET twice with "cache": "revalidate" on http_request, then {"not_modified": true, "cache_status": "revalidated"}
+   - Protocol: set "protocol": "http1.1", "h2" or "h3" on http_request, then {"protocol": "HTTP/2.0"}
+   - Request IDs: with "inject_ids": true (or request_ids enabled in config) http_request sends Idempotency-Key (POST/PATCH) and X-Request-Id, saved as {{idempotency_key}} / {{request_id}}; {"request_id_echoed": true} checks the server echoes it
 
 2. **extract_value** - Extract data from responses for chaining requests:
    - JSON path: {"json_path": "$.data.user_id", "save_as": "user_id"}
+   - JSONPath queries save a list: {"json_path": "$.items[?(@.status == 'active')].id", "save_as": "active_ids"}; also $.items[*].id, $..id, $.items[-1], $.items[0:5]
    - Headers: {"header": "X-Request-Id", "save_as": "request_id"}
    - Cookies: {"cookie": "session_token", "save_as": "token"}
    - Regex: {"regex": "token=([a-z0-9]+)", "save_as": "auth_token"}
+   - XPath (XML/SOAP/HTML): {"xpath": "//soap:Body/GetUserResponse/UserId", "save_as": "user_id"}, attributes with /@id, filters like //order[@status='paid'][1]
+   - CSS selector (HTML): {"css": "input[name=csrf_token]", "attribute": "value", "save_as": "csrf"} (the element text without "attribute")
+   - Lists: "all": true saves every match (regex, xpath, css, headers) as a JSON list; "append": true adds to the list in save_as, e.g. the id of each resource you create
+   - Then iterate sends a request once per element: {"items": "created_ids", "as": "id", "request": {"method": "DELETE", "url": "{{BASE_URL}}/users/{{id}}"}, "assertions": {"status_code": 204}}; object elements give {{item.field}}
 
 3. **variable** - Manage session and global variables:
    - Set: {"action": "set", "name": "user_id", "value": "123", "scope": "session"}
+   - Secret: {"action": "set", "name": "db_password", "value": "...", "scope": "global", "secret": true} (masked everywhere, encrypted on disk)
    - Get: {"action": "get", "name": "user_id"}
    - List all: {"action": "list"}
    - Use {{variable_name}} in http_request URLs, headers, and body
+   - Built-in functions generate fresh test data on every use: {{uuid}}, {{random_int 1 100}}, {{random_string 8}}, {{random_email}}, {{random_name}}, {{now "RFC3339"}} (or date, datetime, a Go layout), {{timestamp}}, {{timestamp_ms}}; now and timestamp take offsets like {{timestamp+1h}} or {{now-2d date}}
+   - Computed fields: {{sha256 text}}, {{md5 text}}, {{sha1 text}}, {{sha512 text}} (hex), {{hmac_sha256 KEY message}} (hex) or {{hmac_sha256_base64 KEY message}}, {{base64 text}}, {{base64url text}}, {{urlencode text}}; calls nest and take variables, e.g. {{hmac_sha256 {{SECRET}} {{timestamp}}.{{NONCE}}}} (quote a key with spaces)
 
 4. **wait** - Add delays for async operations:
    - {"duration_ms": 1000, "reason": "waiting for webhook"}
+   - For a server that is starting up, use **wait_for_service** instead: {"url": "{{BASE_URL}}/health", "timeout_seconds": 30}
 
 5. **retry** - Retry failed requests with backoff:
    - {"tool": "http_request", "args": {...}, "max_attempts": 3, "retry_delay_ms": 500, "backoff": "exponential"}
@@ -542,22 +565,64 @@ After making HTTP requests, you can validate and extract data:
 6. **validate_json_schema** - Validate against JSON Schema:
    - {"schema": {"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}}
    - Validates types, required fields, formats (email, uri), ranges, lengths
+   - Or load the schema from a file: {"schema": "schemas/user.json"}; $ref to $defs, other files and URLs are resolved
+   - Drafts 04 to 2020-12 (from $schema, draft-07 without one); errors give the JSON pointer of the failing value
 
 7. **compare_responses** - Compare responses for regression testing:
    - {"baseline": "baseline_name", "current": "last_response", "ignore_fields": ["timestamp"]}
-   - Detects added, removed, or changed fields
+   - Detects added, removed, or changed fields, by JSON path
+   - Ignore by name or path with wildcards: "ignore_fields": ["timestamp", "$.items[*].updated_at"]
+   - Arrays: "array_key": "id" matches items by id (reordered items aren't changes), "ignore_order": true compares them as sets
+   - Numbers: "tolerance": 0.01 (1%) overall, or "tolerances": {"$.items[*].price": 0.05}
+   - Show the user the diff block from the result; it is colored in the terminal
    - Save baseline: {"baseline": "my_baseline", "save_baseline": true}
+   - Response A vs B in one session: send each http_request with "save_response_as": "before" / "after", then {"baseline": "before", "current": "after"}; assert_response and extract_value take "response": "before" too
+   - For a golden-file workflow use **snapshot**: {"name": "get-user", "ignore_fields": ["updated_at"]} saves the response the first time and fails on any later difference; {"update": true} accepts an intended change
+   - Without a baseline, use **history**: every http_request is recorded. {"action": "list"}, {"action": "show", "id": "2"}, {"action": "diff"} (last call vs the previous call to the same URL), {"action": "rerun", "id": "3"}
 
 8. **performance_test** - Run load tests with concurrent users:
    - {"request": {...}, "duration_seconds": 30, "requests_per_second": 10, "concurrent_users": 5}
-   - Returns: throughput, latency percentiles (p50/p95/p99), error rate, status code distribution
+   - Returns: throughput, latency percentiles (p50/p95/p99), error rate, status code distribution, latency per status code and a latency histogram
+   - "samples_csv": "perf/samples.csv" writes every request's offset, latency, status and error for offline analysis
    - Use ramp_up_seconds to gradually increase load
+   - Load profiles: "profile": "ramp" (up, hold, down), "spike" (a tenth of the rate with a burst at the full rate) or "soak" (long constant run); or custom "stages": [{"duration_seconds": 60, "target_rps": 50}, ...], each moving the rate linearly to target_rps
+   - Soak and staged runs include interim reports (rate, p50/p95, errors per interval); "report_interval_seconds" sets the interval
+   - Every run is saved to .zap/perf-results/. Add "baseline": "previous" (or a saved run's id or "name") to report the p95/p99/throughput change in percent; "threshold" (default 10) sets what counts as a regression
+   - {"action": "list"} shows saved runs; {"action": "compare", "run": "last", "baseline": "before-cache"} compares two saved runs without a new test
 
 9. **webhook_listener** - Start HTTP server to capture webhook callbacks:
    - Start: {"action": "start", "port": 0, "path": "/webhook", "timeout_seconds": 60, "listener_id": "webhook_1"}
+   - Wait for webhooks: {"action": "wait", "listener_id": "webhook_1", "count": 1, "wait_seconds": 30}
+   - Wait for a specific request (earlier ones count too; saved as {{webhook_1_matched}}): {"action": "wait_for_request", "match": {"method": "POST", "path": "/webhook/*", "json_path": {"$.event": "order.paid"}}, "wait_seconds": 30}
+   - Assert on captured requests: {"action": "assert", "match": {"headers": {"X-Event": "order.paid"}}, "count": 1, "expect": {"headers": {"X-Signature": "sha256="}, "json_path_exists": ["$.order_id"]}}
+   - Debug a local handler: start with "forward_to": "http://localhost:3000/webhooks" to pass every request on (sub-paths and query kept) and relay the handler's response to the sender; {"action": "replay", "request_number": 1, "target": "http://localhost:3000/webhooks"} resends a captured request (target defaults to forward_to)
+   - match and expect take the assert_response criteria (headers, body_contains, json_path, ...), applied to the request's headers and body
    - Get requests: {"action": "get_requests", "listener_id": "webhook_1"}
    - Stop: {"action": "stop", "listener_id": "webhook_1"}
    - Returns URL to use for webhooks, captures all incoming requests with headers and body
+   - For third-party services that need a public HTTPS callback, add "tunnel": "ngrok" or "cloudflared" (the CLI must be installed) and register {{webhook_1_public_url}}; "tls": true serves HTTPS locally with a self-signed certificate
+
+10. **mock_server** - Run a local mock API with fixture responses:
+   - Start: {"action": "start", "routes": [{"method": "GET", "path": "/users/:id", "status": 200, "body": {"id": "{{params.id}}"}, "delay_ms": 0}]}
+   - Base URL is saved as {{mock_1_url}}; add_routes, list, get_requests and stop manage it
+   - To test a client's resilience, put **chaos_proxy** in front of the real service: {"action": "start", "target": "{{BASE_URL}}", "faults": {"latency_ms": 500, "error_rate": 0.2, "drop_rate": 0.1}}. Point the client at {{chaos_1_url}}, then use stats to compare what was injected with how the client coped; update changes the faults, stop ends it
+
+11. **validate_openapi** - Check the last response against the project's OpenAPI/Swagger spec:
+   - {"spec": "openapi.yaml"} finds the operation from the last request's method and path
+   - {"spec": "openapi.yaml", "operation_id": "getPetById"} picks it explicitly
+   - Reports undocumented status codes, missing or mistyped headers, and each body field that breaks the schema (e.g. body.items.0.id: expected integer, got string)
+
+12. **fuzz_endpoint** - Find payloads that crash or hang an endpoint:
+   - {"request": {"method": "POST", "url": "{{BASE_URL}}/users", "body": {"name": "Ann", "age": 30}}} mutates each body field and query parameter; without "request" it fuzzes the last http_request
+   - Add {"spec": "openapi.yaml"} to use the schema's types, required fields, limits and enums
+   - "categories" picks from missing, types, boundaries, injection; "fields" limits it to e.g. ["body.age"]; "max_requests" defaults to 100, "timeout" (seconds) to 10
+   - Reports payloads that got a 5xx, hung or dropped the connection, and invalid payloads accepted with 2xx
+   - Only fuzz local or test environments, and ask the user first: it sends many requests, and a POST creates records
+
+13. **security_scan** - Basic security checks on an endpoint, with findings by severity:
+   - {"request": {"method": "GET", "url": "{{BASE_URL}}/users/1", "headers": {"Authorization": "Bearer {{TOKEN}}"}}}; without "request" it scans the last http_request
+   - Checks (all by default, or pick with "checks"): headers (security headers, cookie flags, version disclosure), errors (stack traces, SQL errors, file paths), cors (foreign origin reflected), auth (the request resent without its credentials), injection (XSS, template and SQL probes in string fields and query parameters)
+   - Scan a request that sends credentials so the auth check can run; explain each finding and how to fix it
 
 `
 }
@@ -573,15 +638,32 @@ func (a *Agent) buildAuthSection() string {
    - {"username": "admin", "password": "secret", "save_as": "auth_header"}
    - Use: {"headers": {"Authorization": "{{auth_header}}"}}
 
-3. **auth_helper** - Parse JWT tokens, decode Basic auth:
+3. **auth_helper** - Parse and verify JWT tokens, decode Basic auth:
    - {"action": "parse_jwt", "token": "{{JWT_TOKEN}}"}
    - Shows header, payload (claims), expiration, subject
+   - {"action": "verify_jwt", "token": "{{JWT_TOKEN}}", "jwks_url": "https://auth.example.com/.well-known/jwks.json", "issuer": "...", "audience": "..."}
+   - Checks signature (jwks_url, "key" as PEM, or "secret" for HS256), exp/nbf, issuer and audience; reports which step failed
 
 4. **auth_oauth2** - Perform OAuth2 authentication flows:
    - Client credentials: {"flow": "client_credentials", "token_url": "...", "client_id": "...", "client_secret": "...", "scopes": ["api:read"], "save_token_as": "oauth_token"}
    - Password flow: {"flow": "password", "token_url": "...", "client_id": "...", "client_secret": "...", "username": "...", "password": "...", "save_token_as": "oauth_token"}
+   - Authorization code (browser login, PKCE): {"flow": "authorization_code", "auth_url": "...", "token_url": "...", "client_id": "...", "redirect_url": "http://localhost:8085/callback", "save_token_as": "oauth_token"} - the user logs in in the browser; use the redirect URL registered with the provider
+   - Saved tokens are renewed automatically (refresh token or client_credentials) when they expire or a request gets a 401 - no need to re-run auth_oauth2 mid-test
    - Returns access token and automatically saves as Bearer header ({{token_name}}_header)
 
+5. **auth_aws_sigv4** - Sign requests with AWS Signature V4 (API Gateway, S3, IAM-protected endpoints):
+   - {"service": "execute-api", "region": "us-east-1"} - credentials come from AWS_* env vars unless access_key_id/secret_access_key are given
+   - Every following http_request is signed (limit with "host"; {"disable": true} stops); or pass "aws_sigv4": {...} on one http_request
+
+6. **auth_hmac** - Sign requests with an HMAC (APIs that sign method + path + body + timestamp):
+   - {"secret_var": "HMAC_SECRET", "template": "{method}\n{path}\n{timestamp}\n{body}", "header": "X-Signature", "prefix": "sha256=", "encoding": "hex"}
+   - Check the server's signing code (search_code) for the exact canonical string, header names and encoding before configuring
+
+7. **login_flow** - Log in through a session/cookie login form (Django, Rails, Laravel, Spring, Express):
+   - {"form_url": "http://localhost:8000/login", "fields": {"username": "{{USERNAME}}", "password": "{{PASSWORD}}"}, "session_cookie": "sessionid", "name": "admin", "save": true}
+   - The CSRF token is detected from hidden inputs or meta tags (or set csrf_field / csrf_cookie / csrf_regex); then use {"headers": {"Cookie": "{{session}}"}}
+   - Rerun a saved flow with {"flow": "admin"}
+
 `
 }
 
@@ -626,10 +708,22 @@ func (a *Agent) buildTestSuiteSection() string {
 	return `## TEST SUITE WORKFLOW
 For running multiple related tests:
 1. Use test_suite to group tests logically
-2. Tests run sequentially and can share extracted variables
+2. Tests run sequentially and can share extracted variables; they are discarded when the suite ends unless listed in export_variables: ["user_id"] (or ["*"])
 3. Each test can have request, assertions, and extractions
 4. Suite returns summary: X/Y passed with timing
 5. Use on_failure: "stop" to halt on first failure or "continue" to run all
+6. Use login: "flow_name" to run a saved login_flow first; tests send {"Cookie": "{{session}}"}
+7. Use suite: "name" to run a saved suite from .zap/suites/ (e.g. one from zap import openapi --suite)
+8. For table-driven tests, add data: {"file": "testdata/cases.csv"} (or {"rows": [{...}]}); every test runs once per row with the columns as {{variables}}, e.g. "status_code": "{{expected_status}}"
+9. Put shared setup and cleanup in before_all/before_each/after_each/after_all hooks (each a list of {"request": {...}, "extract": {...}} or {"tool": "variable", "args": {...}}) instead of repeating it in every test
+10. Per test: "retries": 2 for a flaky endpoint, "timeout": 10 (seconds), "skip": true to leave it out, "only": true to run just the marked tests while debugging
+11. For independent tests (no extracted variable used by a later test), set parallel: true (max_concurrency defaults to 4) to run them concurrently
+12. To build on other saved suites (login, fixtures), add requires: ["auth-suite"]; they run first and their extracted variables are available
+13. Add "snapshot": {"name": "get-user", "ignore_fields": ["updated_at"]} to a test to fail it when the response drifts from the saved snapshot (zap run --update-snapshots accepts changes)
+14. For logic the schema can't express, add "pre_script" (before sending: compute variables, set request.headers["X"], request.body.field) or "post_script" (on the response: assert response.status == 200, "message"; save next = response.body.cursor) to a test or its request; statements are separated by ; or newlines
+15. Branch and poll without extra turns: "if": "response.status == 404" (or "unless") runs a test only when the expression on variables and the previous response holds; "repeat_until": {"condition": "response.body.status == 'done'", "max_attempts": 20, "interval_ms": 2000} resends the request until it does
+16. To clean up what a suite created, collect ids with "extract": {"created_ids[]": "$.id"} (a name ending in [] appends to a list) and end with a test that has "for_each": {"items": "created_ids", "as": "id"} and a DELETE request to .../{{id}}; it runs once per element
+17. Add save_as: "name" to save a suite the user wants to keep; they can run it in CI with: zap run name --env staging
 
 `
 }
@@ -660,9 +754,9 @@ Just write your response directly. No special prefix needed.
 
 ### CORRECT EXAMPLES:
 
-Example 1 - HTTP Request:
+Example 1 - HTTP Request (use "query" instead of hand-building query strings):
 ` + "```" + `
-ACTION: http_request({"method": "GET", "url": "http://localhost:8000/api/users"})
+ACTION: http_request({"method": "GET", "url": "http://localhost:8000/api/users", "query": {"page": 2, "search": "john doe"}})
 ` + "```" + `
 
 Example 2 - HTTP Request with headers and body:
@@ -672,7 +766,7 @@ ACTION: http_request({"method": "POST", "url": "http://localhost:8000/api/users"
 
 Example 3 - Search code:
 ` + "```" + `
-ACTION: search_code({"pattern": "/api/users", "file_pattern": "*.py"})
+ACTION: search_code({"pattern": "/api/users", "include": ["*.py"], "context": 5})
 ` + "```" + `
 
 Example 4 - Read file:
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PromptsDir is the folder (inside .zap) holding system prompt overrides.
+const PromptsDir = "prompts"
+
+// ProjectInstructionsFile is the optional file in the project root with
+// project-specific context for the agent (auth conventions, base URLs, naming).
+const ProjectInstructionsFile = "ZAP.md"
+
+// maxProjectInstructions caps how much of ZAP.md is put in the prompt.
+const maxProjectInstructions = 16 * 1024
+
+// promptAppendSuffix marks an override file that is appended to a section
+// instead of replacing it, e.g. guardrails.append.md.
+const promptAppendSuffix = ".append"
+
+// PromptOverride customizes one section of the system prompt.
+type PromptOverride struct {
+	Replace string // Replaces the built-in section when non-empty (<section>.md)
+	Append  string // Added after the (possibly replaced) section (<section>.append.md)
+}
+
+// promptSection is a named part of the system prompt.
+type promptSection struct {
+	name  string
+	build func() string
+}
+
+// promptSections lists the system prompt sections in order.
+// The names are the file names (without .md) used for overrides.
+func (a *Agent) promptSections() []promptSection {
+	return []promptSection{
+		// Core behavioral sections (order matters - most important first)
+		{"identity", a.buildIdentitySection},
+		{"scope", a.buildScopeSection},
+		{"guardrails", a.buildGuardrailsSection},
+		{"behavior", a.buildBehavioralRulesSection},
+		{"workflow", a.buildAutonomousWorkflow},
+		{"zap_folder", a.buildZapFolderSync},
+		{"secrets", a.buildSecretsHandling},
+		{"tool_usage", a.buildToolUsageRules},
+
+		// Context and memory
+		{"project", a.buildProjectSection},
+		{"memory", a.buildMemorySection},
+		{"tools", a.buildToolsSection},
+
+		// Framework and workflow guidance
+		{"framework", a.buildFrameworkHintsSection},
+		{"natural_language", a.buildNaturalLanguageSection},
+		{"error_diagnosis", a.buildErrorDiagnosisSection},
+		{"common_errors", a.buildCommonErrorSection},
+		{"persistence", a.buildPersistenceSection},
+		{"testing", a.buildTestingSection},
+		{"chaining", a.buildChainingSection},
+		{"auth", a.buildAuthSection},
+		{"test_suites", a.buildTestSuiteSection},
+
+		// Output format (always last)
+		{"output_format", a.buildOutputFormatSection},
+	}
+}
+
+// PromptSectionNames returns the names of the overridable prompt sections.
+func PromptSectionNames() []string {
+	sections := (&Agent{}).promptSections()
+	names := make([]string, len(sections))
+	for i, s := range sections {
+		names[i] = s.name
+	}
+	return names
+}
+
+// LoadPromptOverrides reads .zap/prompts/<section>.md (replace) and
+// <section>.append.md (append) files. A missing folder is not an error.
+// Files that don't match a known section name are rejected so typos
+// don't silently do nothing.
+func LoadPromptOverrides(zapDir string) (map[string]PromptOverride, error) {
+	dir := filepath.Join(zapDir, PromptsDir)
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompts folder: %w", err)
+	}
+
+	known := make(map[string]bool)
+	for _, name := range PromptSectionNames() {
+		known[name] = true
+	}
+
+	overrides := make(map[string]PromptOverride)
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".md" {
+			continue
+		}
+
+		base := strings.TrimSuffix(file.Name(), ".md")
+		section := strings.TrimSuffix(base, promptAppendSuffix)
+		if !known[section] {
+			return nil, fmt.Errorf("unknown prompt section %q in %s (valid: %s)", section, file.Name(), strings.Join(PromptSectionNames(), ", "))
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name(), err)
+		}
+		content := strings.TrimSpace(string(data))
+		if content == "" {
+			continue
+		}
+
+		override := overrides[section]
+		if base == section {
+			override.Replace = content
+		} else {
+			override.Append = content
+		}
+		overrides[section] = override
+	}
+	return overrides, nil
+}
+
+// LoadProjectInstructions reads ZAP.md from workDir.
+// Returns an empty string if the file doesn't exist.
+func LoadProjectInstructions(workDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(workDir, ProjectInstructionsFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", ProjectInstructionsFile, err)
+	}
+
+	content := strings.TrimSpace(string(data))
+	if len(content) > maxProjectInstructions {
+		content = content[:maxProjectInstructions] + "\n... (truncated)"
+	}
+	return content, nil
+}
+
+// SetProjectInstructions sets the project-specific instructions (from ZAP.md)
+// included in the system prompt.
+func (a *Agent) SetProjectInstructions(instructions string) {
+	a.projectInstructions = instructions
+}
+
+// SetPromptOverrides sets per-section system prompt overrides
+// (see LoadPromptOverrides).
+func (a *Agent) SetPromptOverrides(overrides map[string]PromptOverride) {
+	a.promptOverrides = overrides
+}
+
+// applyPromptOverride returns the section text after applying any override.
+func (a *Agent) applyPromptOverride(name, text string) string {
+	override, ok := a.promptOverrides[name]
+	if !ok {
+		return text
+	}
+
+	if override.Replace != "" {
+		text = override.Replace + "\n\n"
+	}
+	if override.Append != "" {
+		if text != "" && !strings.HasSuffix(text, "\n\n") {
+			text = strings.TrimRight(text, "\n") + "\n\n"
+		}
+		text += override.Append + "\n\n"
+	}
+	return text
+}
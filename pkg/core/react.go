@@ -2,8 +2,10 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/blackcoderx/zap/pkg/llm"
 )
@@ -25,14 +27,24 @@ func (a *Agent) ProcessMessage(input string) (string, error) {
 			return msg, nil
 		}
 
-		// Prepare system prompt with tool descriptions
-		systemPrompt := a.buildSystemPrompt()
+		// Check session token/cost budget
+		if reason := a.budgetExceeded(); reason != "" {
+			return a.budgetExceededMessage(reason), nil
+		}
 
-		messages := []llm.Message{{Role: "system", Content: systemPrompt}}
-		messages = append(messages, a.history...)
+		// Prepare system prompt, history summary and recent history
+		messages := a.buildMessages()
 
-		// Get LLM response
-		response, err := a.llmClient.Chat(messages)
+		// Get LLM response (transient failures are retried with backoff)
+		var response string
+		err := llm.Retry(context.Background(), a.retryPolicy, func() error {
+			start := time.Now()
+			var chatErr error
+			response, chatErr = a.llmClient.Chat(messages)
+			a.logLLMCall(a.llmClient, messages, response, nil, chatErr, start)
+			a.addUsage(messages, nil, response, nil, chatErr)
+			return chatErr
+		}, nil)
 		if err != nil {
 			return "", fmt.Errorf("agent chat error: %w", err)
 		}
@@ -97,9 +109,13 @@ func (a *Agent) ProcessMessage(input string) (string, error) {
 
 // ProcessMessageWithEvents handles a user message and emits events for each stage.
 // This enables real-time UI updates as the agent thinks, uses tools, and responds.
-// Events emitted: thinking, tool_call, observation, answer, error, streaming, tool_usage, confirmation_required
+// Events emitted: thinking, tool_call, observation, answer, error, streaming, tool_usage, confirmation_required, progress,
+// fallback, retry, budget_exceeded
 // The context can be used to cancel the agent mid-processing.
 func (a *Agent) ProcessMessageWithEvents(ctx context.Context, input string, callback EventCallback) (string, error) {
+	// Mask secrets in everything the user sees
+	callback = redactEvents(callback)
+
 	// Add user message to history
 	a.AppendHistory(llm.Message{Role: "user", Content: input})
 
@@ -127,42 +143,50 @@ func (a *Agent) ProcessMessageWithEvents(ctx context.Context, input string, call
 			return msg, nil
 		}
 
+		// Check session token/cost budget
+		if reason := a.budgetExceeded(); reason != "" {
+			msg := a.budgetExceededMessage(reason)
+			callback(AgentEvent{Type: "budget_exceeded", Content: msg})
+			return msg, nil
+		}
+
 		// Get current total for display
 		totalCalls, _ := a.GetTotalUsage()
 
 		// Emit thinking event
 		callback(AgentEvent{Type: "thinking", Content: fmt.Sprintf("reasoning (calls: %d)...", totalCalls)})
 
-		// Prepare system prompt with tool descriptions
-		systemPrompt := a.buildSystemPrompt()
-
-		messages := []llm.Message{{Role: "system", Content: systemPrompt}}
-		messages = append(messages, a.history...)
-
-		// Get LLM response with streaming
-		var response string
-		var streamErr error
-
-		// Stream callback emits chunks to TUI
-		streamCallback := func(chunk string) {
-			callback(AgentEvent{Type: "streaming", Content: chunk})
-		}
+		// Prepare system prompt, history summary and recent history
+		messages := a.buildMessages()
 
-		response, streamErr = a.llmClient.ChatStream(messages, streamCallback)
+		// Get LLM response with streaming (chunks are emitted as "streaming" events,
+		// and a "fallback" event is emitted if a fallback provider takes over)
+		response, toolCall, streamErr := a.chatStream(ctx, messages, callback)
 		if streamErr != nil {
+			if errors.Is(streamErr, context.Canceled) {
+				return "", streamErr
+			}
 			errorMsg := fmt.Sprintf("Connection Error: Could not talk to the AI provider.\nDetails: %v\n\nTip: Check if Ollama is running (try 'ollama serve') or check your API key.", streamErr)
 			callback(AgentEvent{Type: "error", Content: errorMsg})
 			return "", fmt.Errorf("agent chat error: %w", streamErr)
 		}
 
-		if response == "" {
+		if response == "" && toolCall == nil {
 			errorMsg := "Received an empty response from the AI. This usually happens if the model crashed or timed out."
 			callback(AgentEvent{Type: "error", Content: errorMsg})
 			return "I received an empty response from the AI.", nil
 		}
 
-		// Parse response for thoughts and tool calls
-		thought, toolName, toolArgs, finalAnswer := a.parseResponse(response)
+		// Parse response for thoughts and tool calls. Native tool calls are
+		// already structured; only the text fallback needs parsing.
+		var thought, toolName, toolArgs, finalAnswer string
+		if toolCall != nil {
+			thought = extractThought(response)
+			toolName, toolArgs = toolCall.Name, toolCall.Arguments
+			response = formatToolCall(response, toolCall)
+		} else {
+			thought, toolName, toolArgs, finalAnswer = a.parseResponse(response)
+		}
 
 		// If we got a thought (and it's different from the streamed content), emit it
 		if thought != "" && thought != response {
@@ -222,7 +246,7 @@ func (a *Agent) ProcessMessageWithEvents(ctx context.Context, input string, call
 			}
 
 			// Execute tool
-			observation, err := tool.Execute(toolArgs)
+			observation, err := executeWithProgress(tool, toolArgs, callback)
 			if err != nil {
 				// Detailed error for the agent to self-correct
 				observation = fmt.Sprintf("Tool Execution Error: %v", err)
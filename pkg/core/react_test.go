@@ -1,7 +1,13 @@
 package core
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/blackcoderx/zap/pkg/llm"
 )
@@ -408,3 +414,428 @@ func TestUnlimitedHistory(t *testing.T) {
 		t.Errorf("history length = %d, want 200 (unlimited)", len(history))
 	}
 }
+
+// toolCallingClient is a fake LLM client that supports native tool calling
+type toolCallingClient struct {
+	calls       []llm.ToolCall
+	unsupported bool
+	textReply   string
+}
+
+func (c *toolCallingClient) Chat(messages []llm.Message) (string, error) { return c.textReply, nil }
+func (c *toolCallingClient) ChatStream(messages []llm.Message, callback llm.StreamCallback) (string, error) {
+	return c.textReply, nil
+}
+func (c *toolCallingClient) CheckConnection() error   { return nil }
+func (c *toolCallingClient) GetModel() string         { return "fake" }
+func (c *toolCallingClient) SetModel(model string)    {}
+func (c *toolCallingClient) ModelInfo() llm.ModelInfo { return llm.ModelInfo{Model: "fake"} }
+func (c *toolCallingClient) ChatStreamWithTools(messages []llm.Message, tools []llm.ToolDefinition, callback llm.StreamCallback) (string, []llm.ToolCall, error) {
+	if c.unsupported {
+		return "", nil, llm.ErrToolsUnsupported
+	}
+	return "", c.calls, nil
+}
+
+func TestChatStream_NativeToolCall(t *testing.T) {
+	client := &toolCallingClient{calls: []llm.ToolCall{{Name: "read_file", Arguments: `{"path": "main.go"}`}}}
+	agent := NewAgent(client)
+	agent.RegisterTool(&mockTool{name: "read_file", params: `{"path": "string"}`})
+
+	_, call, err := agent.chatStream(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if call == nil || call.Name != "read_file" {
+		t.Fatalf("tool call = %+v, want read_file", call)
+	}
+
+	// History keeps the text form so every provider can read it
+	if got := formatToolCall("", call); got != `ACTION: read_file({"path": "main.go"})` {
+		t.Errorf("formatToolCall = %q", got)
+	}
+}
+
+func TestChatStream_NativeToolCallCountsTowardUsage(t *testing.T) {
+	args := `{"path": "` + strings.Repeat("a", 400) + `"}`
+	client := &toolCallingClient{calls: []llm.ToolCall{{Name: "read_file", Arguments: args}}}
+	agent := NewAgent(client)
+	agent.RegisterTool(&mockTool{name: "read_file", params: `{"path": "string"}`})
+
+	if _, _, err := agent.chatStream(context.Background(), nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usage := agent.GetBudgetUsage(); usage.OutputTokens < EstimateTokens(args) {
+		t.Errorf("output tokens = %d, want at least the %d of the tool call arguments", usage.OutputTokens, EstimateTokens(args))
+	}
+}
+
+func TestChatStream_ToolDefinitionsCountTowardUsage(t *testing.T) {
+	client := &toolCallingClient{}
+	agent := NewAgent(client)
+	description := strings.Repeat("Reads a file. ", 100)
+	agent.RegisterTool(&mockTool{name: "read_file", description: description, params: `{"path": "string"}`})
+
+	messages := []llm.Message{{Role: "user", Content: "hi"}}
+	if _, _, err := agent.chatStream(context.Background(), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := estimateMessagesTokens(messages) + EstimateTokens(description)
+	if usage := agent.GetBudgetUsage(); usage.InputTokens < want {
+		t.Errorf("input tokens = %d, want at least %d with the tool definitions", usage.InputTokens, want)
+	}
+
+	// Text mode sends no definitions
+	text := NewAgent(&toolCallingClient{})
+	text.SetNativeToolCalling(false)
+	text.RegisterTool(&mockTool{name: "read_file", description: description, params: `{"path": "string"}`})
+	if _, _, err := text.chatStream(context.Background(), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usage := text.GetBudgetUsage(); usage.InputTokens != estimateMessagesTokens(messages) {
+		t.Errorf("text mode input tokens = %d, want %d", usage.InputTokens, estimateMessagesTokens(messages))
+	}
+}
+
+func TestChatStream_FallsBackWhenToolsUnsupported(t *testing.T) {
+	client := &toolCallingClient{unsupported: true, textReply: `ACTION: read_file({"path": "main.go"})`}
+	agent := NewAgent(client)
+
+	response, call, err := agent.chatStream(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if call != nil {
+		t.Errorf("expected no native tool call, got %+v", call)
+	}
+	if response != client.textReply {
+		t.Errorf("response = %q, want text fallback", response)
+	}
+	if !agent.toolsUnsupported {
+		t.Error("native tool calling should be disabled after ErrToolsUnsupported")
+	}
+}
+
+func TestSchemaFromParameters(t *testing.T) {
+	schema := schemaFromParameters(`{"method": "GET|POST", "headers": {"key": "value"}, "timeout": 30}`)
+
+	props, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected properties map, got %T", schema["properties"])
+	}
+
+	wantTypes := map[string]string{"method": "string", "headers": "object", "timeout": "number"}
+	for key, want := range wantTypes {
+		prop, _ := props[key].(map[string]interface{})
+		if prop["type"] != want {
+			t.Errorf("%s type = %v, want %s", key, prop["type"], want)
+		}
+	}
+
+	// Non-JSON parameter descriptions still produce a valid object schema
+	if free := schemaFromParameters("free text"); free["type"] != "object" {
+		t.Errorf("free-form schema type = %v, want object", free["type"])
+	}
+}
+
+func TestCompactHistory_SummarizesOlderTurns(t *testing.T) {
+	agent := newTestAgent()
+	agent.SetHistoryTokenBudget(100)
+
+	long := strings.Repeat("x", 200) // ~50 tokens
+	for i := 0; i < 4; i++ {
+		agent.AppendHistory(llm.Message{Role: "user", Content: "check the users endpoint"})
+		agent.AppendHistoryPair(
+			llm.Message{Role: "assistant", Content: `ACTION: http_request({"method": "GET", "url": "/users"})`},
+			llm.Message{Role: "user", Content: "Observation: 200 OK\n" + long},
+		)
+		agent.AppendHistory(llm.Message{Role: "assistant", Content: "Final Answer: done"})
+	}
+
+	if !agent.compactHistory() {
+		t.Fatal("expected history to be compacted")
+	}
+
+	history := agent.GetHistory()
+	if len(history) == 0 || !isTurnStart(history[0]) {
+		t.Errorf("kept history should start with a user turn, got %+v", history)
+	}
+
+	summary := agent.GetHistorySummary()
+	if !strings.Contains(summary, "User asked: check the users endpoint") || !strings.Contains(summary, "Called http_request") {
+		t.Errorf("summary missing expected lines:\n%s", summary)
+	}
+}
+
+func TestCompactHistory_UnderBudget(t *testing.T) {
+	agent := newTestAgent()
+	agent.AppendHistory(llm.Message{Role: "user", Content: "hi"})
+
+	if agent.compactHistory() {
+		t.Error("history under budget should not be compacted")
+	}
+}
+
+// failingClient is a fake LLM client whose requests always fail
+type failingClient struct {
+	toolCallingClient
+}
+
+func (c *failingClient) ChatStream(messages []llm.Message, callback llm.StreamCallback) (string, error) {
+	return "", errors.New("503 service unavailable")
+}
+
+func TestChatStream_FallbackProvider(t *testing.T) {
+	agent := NewAgent(&failingClient{})
+	agent.SetNativeToolCalling(false)
+	agent.SetFallbackClients([]llm.LLMClient{&toolCallingClient{textReply: "Final Answer: ok"}})
+
+	var events []AgentEvent
+	response, _, err := agent.chatStream(context.Background(), nil, func(e AgentEvent) { events = append(events, e) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response != "Final Answer: ok" {
+		t.Errorf("response = %q, want fallback reply", response)
+	}
+	if len(events) == 0 || events[0].Type != "fallback" {
+		t.Errorf("expected a fallback event, got %+v", events)
+	}
+}
+
+// flakyClient fails with a 503 a fixed number of times before succeeding
+type flakyClient struct {
+	toolCallingClient
+	failures int
+}
+
+func (c *flakyClient) ChatStream(messages []llm.Message, callback llm.StreamCallback) (string, error) {
+	if c.failures > 0 {
+		c.failures--
+		return "", &llm.StatusError{StatusCode: 503, Message: "ollama returned status 503"}
+	}
+	return "Final Answer: recovered", nil
+}
+
+func TestChatStream_RetriesTransientErrors(t *testing.T) {
+	agent := NewAgent(&flakyClient{failures: 2})
+	agent.SetNativeToolCalling(false)
+	agent.SetRetryPolicy(llm.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	var retries int
+	response, _, err := agent.chatStream(context.Background(), nil, func(e AgentEvent) {
+		if e.Type == "retry" {
+			retries++
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response != "Final Answer: recovered" {
+		t.Errorf("response = %q", response)
+	}
+	if retries != 2 {
+		t.Errorf("retry events = %d, want 2", retries)
+	}
+}
+
+// cutOffClient streams part of an answer and fails once, then answers
+type cutOffClient struct {
+	toolCallingClient
+	failed bool
+}
+
+func (c *cutOffClient) ChatStream(messages []llm.Message, callback llm.StreamCallback) (string, error) {
+	if !c.failed {
+		c.failed = true
+		callback("Here is the key -----BEGIN")
+		return "", &llm.StatusError{StatusCode: 503, Message: "connection reset"}
+	}
+	callback("Final Answer: ")
+	callback("recovered")
+	return "Final Answer: recovered", nil
+}
+
+func TestChatStream_RetryDropsHeldBackText(t *testing.T) {
+	agent := NewAgent(&cutOffClient{})
+	agent.SetNativeToolCalling(false)
+	agent.SetRetryPolicy(llm.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	var streamed []string
+	_, _, err := agent.chatStream(context.Background(), nil, func(e AgentEvent) {
+		switch e.Type {
+		case "retry":
+			// As the TUI does, drop what the failed attempt showed
+			streamed = nil
+		case "streaming":
+			streamed = append(streamed, e.Content)
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The retried answer streams as it arrives, not held back behind the
+	// failed attempt's unfinished "-----BEGIN"
+	want := []string{"Final Answer: ", "recovered"}
+	if strings.Join(streamed, "|") != strings.Join(want, "|") {
+		t.Errorf("streamed %q, want %q", streamed, want)
+	}
+}
+
+func TestChatStream_FailedAttemptsCountTowardUsage(t *testing.T) {
+	agent := NewAgent(&cutOffClient{})
+	agent.SetNativeToolCalling(false)
+	agent.SetRetryPolicy(llm.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	messages := []llm.Message{{Role: "user", Content: "show me the key"}}
+	if _, _, err := agent.chatStream(context.Background(), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	usage := agent.GetBudgetUsage()
+	if usage.Requests != 1 {
+		t.Errorf("requests = %d, want 1 successful request", usage.Requests)
+	}
+	if want := 2 * estimateMessagesTokens(messages); usage.InputTokens != want {
+		t.Errorf("input tokens = %d, want %d for both attempts", usage.InputTokens, want)
+	}
+	if want := EstimateTokens("Here is the key -----BEGIN") + EstimateTokens("Final Answer: recovered"); usage.OutputTokens != want {
+		t.Errorf("output tokens = %d, want %d with the failed attempt's output", usage.OutputTokens, want)
+	}
+
+	// Attempts refused before any output are not billed
+	flaky := NewAgent(&flakyClient{failures: 2})
+	flaky.SetNativeToolCalling(false)
+	flaky.SetRetryPolicy(llm.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	if _, _, err := flaky.chatStream(context.Background(), messages, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usage := flaky.GetBudgetUsage(); usage.InputTokens != estimateMessagesTokens(messages) {
+		t.Errorf("input tokens = %d, want only the successful attempt's %d", usage.InputTokens, estimateMessagesTokens(messages))
+	}
+}
+
+func TestPromptOverrides(t *testing.T) {
+	zapDir := t.TempDir()
+	promptsDir := filepath.Join(zapDir, PromptsDir)
+	if err := os.Mkdir(promptsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(promptsDir, "identity.md"), []byte("## IDENTITY\nYou are ACME's API assistant."), 0644)
+	os.WriteFile(filepath.Join(promptsDir, "guardrails.append.md"), []byte("- Never call production."), 0644)
+
+	overrides, err := LoadPromptOverrides(zapDir)
+	if err != nil {
+		t.Fatalf("LoadPromptOverrides failed: %v", err)
+	}
+
+	agent := newTestAgent()
+	agent.SetPromptOverrides(overrides)
+	prompt := agent.buildSystemPrompt()
+
+	if strings.Contains(prompt, "You are ZAP") || !strings.Contains(prompt, "You are ACME's API assistant.") {
+		t.Error("identity section should be replaced")
+	}
+	if !strings.Contains(prompt, "## GUARDRAILS") || !strings.Contains(prompt, "- Never call production.") {
+		t.Error("guardrails section should be kept and extended")
+	}
+
+	os.WriteFile(filepath.Join(promptsDir, "identiy.md"), []byte("typo"), 0644)
+	if _, err := LoadPromptOverrides(zapDir); err == nil {
+		t.Error("expected error for unknown section name")
+	}
+}
+
+func TestProjectInstructions(t *testing.T) {
+	workDir := t.TempDir()
+
+	instructions, err := LoadProjectInstructions(workDir)
+	if err != nil || instructions != "" {
+		t.Fatalf("missing ZAP.md should be ignored, got %q, %v", instructions, err)
+	}
+
+	os.WriteFile(filepath.Join(workDir, ProjectInstructionsFile), []byte("Base URL is {{API_URL}}/v2\n"), 0644)
+	instructions, err = LoadProjectInstructions(workDir)
+	if err != nil {
+		t.Fatalf("LoadProjectInstructions failed: %v", err)
+	}
+
+	agent := newTestAgent()
+	agent.SetProjectInstructions(instructions)
+	prompt := agent.buildSystemPrompt()
+	if !strings.Contains(prompt, "## PROJECT INSTRUCTIONS") || !strings.Contains(prompt, "Base URL is {{API_URL}}/v2") {
+		t.Error("system prompt should include ZAP.md contents")
+	}
+}
+
+// keywordEmbedder is a fake embedder: one dimension per keyword
+type keywordEmbedder struct {
+	keywords []string
+	calls    int
+}
+
+func (e *keywordEmbedder) Embed(texts []string) ([][]float64, error) {
+	e.calls++
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vector := make([]float64, len(e.keywords))
+		for j, kw := range e.keywords {
+			if strings.Contains(strings.ToLower(text), kw) {
+				vector[j] = 1
+			}
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func (e *keywordEmbedder) GetModel() string { return "keywords" }
+
+func TestSemanticRecall(t *testing.T) {
+	store := NewMemoryStore(t.TempDir())
+	store.Save("login_endpoint", "POST /api/auth/login returns a session cookie", "endpoint")
+	store.Save("preferred_env", "staging", "preference")
+
+	embedder := &keywordEmbedder{keywords: []string{"auth", "login", "cookie", "staging"}}
+	store.SetEmbedder(embedder)
+
+	matches, err := store.SemanticRecall("how do I authenticate (auth login)?", 5)
+	if err != nil {
+		t.Fatalf("SemanticRecall failed: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Entry == nil || matches[0].Entry.Key != "login_endpoint" {
+		t.Fatalf("expected only login_endpoint, got %+v", matches)
+	}
+
+	// Second recall reuses cached vectors: only the query is embedded
+	embedder.calls = 0
+	if _, err := store.SemanticRecall("staging", 5); err != nil {
+		t.Fatalf("SemanticRecall failed: %v", err)
+	}
+	if embedder.calls != 1 {
+		t.Errorf("expected cached memory vectors (1 embed call), got %d calls", embedder.calls)
+	}
+}
+
+func TestProcessMessageWithEvents_BudgetExceeded(t *testing.T) {
+	agent := NewAgent(&toolCallingClient{textReply: "Final Answer: the API is healthy"})
+	agent.SetNativeToolCalling(false)
+	agent.SetBudget(Budget{MaxTokens: 10})
+
+	ignore := func(AgentEvent) {}
+	if _, err := agent.ProcessMessageWithEvents(context.Background(), "check the API", ignore); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usage := agent.GetBudgetUsage(); usage.Requests != 1 || usage.TotalTokens() < 10 {
+		t.Fatalf("usage = %+v, want 1 request over the 10-token budget", usage)
+	}
+
+	var exceeded string
+	agent.ProcessMessageWithEvents(context.Background(), "check it again", func(e AgentEvent) {
+		if e.Type == "budget_exceeded" {
+			exceeded = e.Content
+		}
+	})
+	if !strings.Contains(exceeded, "token budget of 10 reached") || !strings.Contains(exceeded, "LLM requests: 1") {
+		t.Errorf("expected budget_exceeded event with usage summary, got %q", exceeded)
+	}
+}
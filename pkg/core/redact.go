@@ -0,0 +1,184 @@
+package core
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redactPatterns match secrets that are safe to mask anywhere in free text.
+// Unlike SecretPatterns they leave IDs, hashes and UUIDs alone, so tool
+// output stays readable.
+var redactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{20,}`),                                  // OpenAI, Anthropic
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36}\b`),                             // GitHub tokens
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}`),                            // GitHub PAT (new)
+	regexp.MustCompile(`\bxox[baprs]-[A-Za-z0-9\-]{10,}`),                           // Slack tokens
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`), // JWT
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),                                      // AWS Access Key
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`),                                  // Google API Key
+	regexp.MustCompile(`\bSG\.[A-Za-z0-9_\-]{16,}\.[A-Za-z0-9_\-]{16,}`),            // SendGrid API Key
+	regexp.MustCompile(`\b[sr]k_(live|test)_[A-Za-z0-9]{24,}`),                      // Stripe keys
+	regexp.MustCompile(`\bsq0[a-z]{3}-[A-Za-z0-9_\-]{22,}`),                         // Square
+}
+
+// jsonFieldPattern matches a JSON string field, e.g. "password": "hunter2"
+var jsonFieldPattern = regexp.MustCompile(`"([A-Za-z0-9_\-]+)"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+
+// headerLinePattern matches a "Name: value" line, e.g. a response header
+var headerLinePattern = regexp.MustCompile(`(?m)^(\s*)([A-Za-z0-9_\-]+):[ \t]*(\S[^\r\n]*)$`)
+
+// minSecretValueLength keeps short values like "on" or "dev" from being masked everywhere
+const minSecretValueLength = 6
+
+// secretValues are known secrets (API keys, tokens, secret variables) that
+// RedactSecrets masks wherever they appear.
+var secretValues = struct {
+	sync.RWMutex
+	values map[string]struct{}
+	sorted []string // longest first, so a secret containing another is masked whole
+}{values: make(map[string]struct{})}
+
+// RegisterSecretValue makes RedactSecrets mask value wherever it appears.
+func RegisterSecretValue(value string) {
+	value = strings.TrimSpace(value)
+	if len(value) < minSecretValueLength || ContainsVariablePlaceholder(value) {
+		return
+	}
+
+	secretValues.Lock()
+	defer secretValues.Unlock()
+	if _, ok := secretValues.values[value]; ok {
+		return
+	}
+	secretValues.values[value] = struct{}{}
+	secretValues.sorted = append(secretValues.sorted, value)
+	sort.Slice(secretValues.sorted, func(i, j int) bool {
+		return len(secretValues.sorted[i]) > len(secretValues.sorted[j])
+	})
+}
+
+// IsSensitiveKey reports whether a variable, header or field name usually holds a secret.
+func IsSensitiveKey(key string) bool {
+	for _, pattern := range SensitiveKeyPatterns {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactSecrets masks secrets in text before it is displayed or persisted:
+// registered secret values, well-known key and token formats, credentials
+// after Bearer/Basic, and the values of sensitive JSON fields and headers.
+func RedactSecrets(text string) string {
+	if text == "" {
+		return text
+	}
+
+	secretValues.RLock()
+	for _, value := range secretValues.sorted {
+		if strings.Contains(text, value) {
+			text = strings.ReplaceAll(text, value, MaskSecret(value))
+		}
+	}
+	secretValues.RUnlock()
+
+	text = jsonFieldPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := jsonFieldPattern.FindStringSubmatch(match)
+		if !IsSensitiveKey(groups[1]) || !maskableValue(groups[2]) {
+			return match
+		}
+		return strings.TrimSuffix(match, `"`+groups[2]+`"`) + `"` + MaskSecret(groups[2]) + `"`
+	})
+
+	text = headerLinePattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := headerLinePattern.FindStringSubmatch(match)
+		value := groups[3]
+		if !IsSensitiveKey(groups[2]) || !maskableValue(value) || authSchemePattern.MatchString(value) {
+			return match
+		}
+		return groups[1] + groups[2] + ": " + MaskSecret(value)
+	})
+
+	text = authSchemePattern.ReplaceAllStringFunc(text, func(match string) string {
+		fields := strings.Fields(match)
+		credential := fields[len(fields)-1]
+		if strings.Contains(credential, "...") || len(credential) < minSecretValueLength {
+			return match // Already masked, or not a credential ("Bearer token")
+		}
+		return fields[0] + " " + MaskSecret(credential)
+	})
+
+	for _, pattern := range redactPatterns {
+		text = pattern.ReplaceAllStringFunc(text, MaskSecret)
+	}
+	return text
+}
+
+// maskableValue reports whether a sensitive field's value should be masked
+func maskableValue(value string) bool {
+	value = strings.TrimSpace(value)
+	return len(value) >= minSecretValueLength && !strings.Contains(value, "...") && !isOnlyPlaceholder(value)
+}
+
+// streamRedactor redacts streamed LLM output. A secret may be split across
+// chunks, so the last word of the stream is held back until it is complete.
+type streamRedactor struct {
+	pending string
+}
+
+// write adds a chunk and returns the redacted text that is safe to show
+func (s *streamRedactor) write(chunk string) string {
+	s.pending += chunk
+
+	cut := strings.LastIndexAny(s.pending, " \t\n")
+	// Keep an auth scheme together with the credential after it
+	for cut >= 0 {
+		words := strings.Fields(s.pending[:cut])
+		if len(words) == 0 || !authSchemePattern.MatchString(words[len(words)-1]+" x") {
+			break
+		}
+		cut = strings.LastIndexAny(strings.TrimRight(s.pending[:cut], " \t\n"), " \t\n")
+	}
+	// Keep a PEM block together until it ends
+	if begin := strings.LastIndex(s.pending, "-----BEGIN"); begin >= 0 && begin < cut &&
+		!strings.Contains(s.pending[begin:], "-----END") {
+		cut = begin - 1
+	}
+	if cut < 0 {
+		return ""
+	}
+
+	out := s.pending[:cut+1]
+	s.pending = s.pending[cut+1:]
+	return RedactSecrets(out)
+}
+
+// flush returns the rest of the stream, redacted
+func (s *streamRedactor) flush() string {
+	out := RedactSecrets(s.pending)
+	s.pending = ""
+	return out
+}
+
+// reset drops the held-back text of a stream that was abandoned
+func (s *streamRedactor) reset() {
+	s.pending = ""
+}
+
+// redactEvents wraps callback so secrets are masked in everything shown to
+// the user. Streaming chunks are redacted by chatStream itself.
+func redactEvents(callback EventCallback) EventCallback {
+	return func(event AgentEvent) {
+		switch event.Type {
+		case "thinking", "observation", "answer", "error", "fallback", "retry":
+			event.Content = RedactSecrets(event.Content)
+		case "tool_call":
+			event.ToolArgs = RedactSecrets(event.ToolArgs)
+		}
+		callback(event)
+	}
+}
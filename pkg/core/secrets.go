@@ -115,6 +115,33 @@ func MaskSecret(value string) string {
 	return value[:4] + "..." + value[len(value)-4:]
 }
 
+// secretTokenPattern matches candidate secret tokens inside free text.
+var secretTokenPattern = regexp.MustCompile(`[A-Za-z0-9_\-\.+/=~]{8,}`)
+
+// authSchemePattern matches credentials following an auth scheme, e.g. "Bearer abc".
+var authSchemePattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9_\-\.+/=~]+`)
+
+// MaskSecretsInText masks anything in text that looks like a secret, such as
+// API keys, JWTs and bearer credentials, using MaskSecret. Tokens without a
+// digit are left alone so ordinary words like "Authorization" stay readable.
+func MaskSecretsInText(text string) string {
+	if text == "" {
+		return text
+	}
+
+	text = authSchemePattern.ReplaceAllStringFunc(text, func(match string) string {
+		fields := strings.Fields(match)
+		return fields[0] + " " + MaskSecret(fields[len(fields)-1])
+	})
+
+	return secretTokenPattern.ReplaceAllStringFunc(text, func(token string) string {
+		if !strings.ContainsAny(token, "0123456789") || !isSecretValue(token) {
+			return token
+		}
+		return MaskSecret(token)
+	})
+}
+
 // HasPlaintextSecret checks if text contains hardcoded secrets without {{VAR}} placeholders.
 // This is used to validate that saved requests use environment variables instead of hardcoded secrets.
 func HasPlaintextSecret(text string) bool {
@@ -0,0 +1,265 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/llm"
+)
+
+// SetNativeToolCalling enables or disables native (structured) tool calling.
+// When enabled and the LLM client implements llm.ToolCallingClient, tool
+// definitions are sent with each request and tool calls are read from the
+// structured response instead of being parsed from "ACTION: ..." text.
+func (a *Agent) SetNativeToolCalling(enabled bool) {
+	a.nativeTools = enabled
+}
+
+// chatStream sends messages to the LLM and streams the text response, with
+// secrets masked, as "streaming" events. Transient failures are retried with backoff per the
+// agent's retry policy, emitting a "retry" event before each attempt. If the
+// primary client still fails and fallback clients are configured, each is
+// tried in order and a "fallback" event is emitted so the user knows another
+// provider answered. callback may be nil.
+func (a *Agent) chatStream(ctx context.Context, messages []llm.Message, callback EventCallback) (string, *llm.ToolCall, error) {
+	// Chunks are redacted before display; see streamRedactor
+	stream := &streamRedactor{}
+	emit := func(text string) {
+		if callback != nil && text != "" {
+			callback(AgentEvent{Type: "streaming", Content: text})
+		}
+	}
+	streamCallback := func(chunk string) {
+		emit(stream.write(chunk))
+	}
+	// A failed attempt's output is discarded, including the text the
+	// redactor still holds back
+	attemptCallback := func(event AgentEvent) {
+		if event.Type == "retry" {
+			stream.reset()
+		}
+		if callback != nil {
+			callback(event)
+		}
+	}
+
+	response, call, err := a.chatStreamWithRetry(ctx, a.llmClient, true, messages, streamCallback, attemptCallback)
+	if err == nil || errors.Is(err, context.Canceled) {
+		emit(stream.flush())
+		return response, call, err
+	}
+	stream.reset()
+
+	from := a.llmClient.ModelInfo()
+	for _, fallback := range a.fallbackClients {
+		to := fallback.ModelInfo()
+		if callback != nil {
+			callback(AgentEvent{
+				Type:    "fallback",
+				Content: fmt.Sprintf("%s (%s) failed: %v\nFalling back to %s (%s)", from.Model, from.Provider, err, to.Model, to.Provider),
+			})
+		}
+
+		response, call, err = a.chatStreamWithRetry(ctx, fallback, false, messages, streamCallback, attemptCallback)
+		if err == nil || errors.Is(err, context.Canceled) {
+			emit(stream.flush())
+			return response, call, err
+		}
+		stream.reset()
+		from = to
+	}
+
+	return "", nil, err
+}
+
+// chatStreamWithRetry calls chatStreamWith, retrying transient failures with
+// jittered exponential backoff. Each retry emits a "retry" event.
+func (a *Agent) chatStreamWithRetry(ctx context.Context, client llm.LLMClient, primary bool, messages []llm.Message, streamCallback llm.StreamCallback, callback EventCallback) (string, *llm.ToolCall, error) {
+	var response string
+	var call *llm.ToolCall
+
+	err := llm.Retry(ctx, a.retryPolicy, func() error {
+		start := time.Now()
+		// What an attempt streams before failing is billed too
+		var streamed strings.Builder
+		attemptCallback := func(chunk string) {
+			streamed.WriteString(chunk)
+			streamCallback(chunk)
+		}
+		var tools []llm.ToolDefinition
+		var err error
+		response, call, tools, err = a.chatStreamWith(client, primary, messages, attemptCallback)
+		a.logLLMCall(client, messages, response, call, err, start)
+		if err != nil {
+			a.addUsage(messages, tools, streamed.String(), nil, err)
+		} else {
+			a.addUsage(messages, tools, response, call, nil)
+		}
+		return err
+	}, func(attempt int, delay time.Duration, err error) {
+		if callback != nil {
+			callback(AgentEvent{
+				Type:    "retry",
+				Content: fmt.Sprintf("LLM request failed (attempt %d/%d): %v\nRetrying in %s...", attempt, a.retryPolicy.MaxAttempts, err, delay.Round(100*time.Millisecond)),
+			})
+		}
+	})
+
+	return response, call, err
+}
+
+// chatStreamWith performs a single streaming request against client.
+// If native tool calling is available, the first structured tool call is
+// returned as well, with the tool definitions that were sent. Models that
+// reject the tools parameter are transparently retried in text mode; for
+// the primary client native calling then stays off until the model or
+// client is changed.
+func (a *Agent) chatStreamWith(client llm.LLMClient, primary bool, messages []llm.Message, callback llm.StreamCallback) (string, *llm.ToolCall, []llm.ToolDefinition, error) {
+	if tc, ok := client.(llm.ToolCallingClient); ok && a.nativeTools && !(primary && a.toolsUnsupported) {
+		tools := a.toolDefinitions()
+		response, calls, err := tc.ChatStreamWithTools(messages, tools, callback)
+		if err == nil {
+			if len(calls) > 0 {
+				return response, &calls[0], tools, nil
+			}
+			return response, nil, tools, nil
+		}
+		if !errors.Is(err, llm.ErrToolsUnsupported) {
+			return "", nil, tools, err
+		}
+		if primary {
+			a.toolsUnsupported = true
+		}
+	}
+
+	response, err := client.ChatStream(messages, callback)
+	return response, nil, nil, err
+}
+
+// SetRetryPolicy sets how transient LLM failures are retried.
+// Use MaxAttempts 1 to disable retries.
+func (a *Agent) SetRetryPolicy(policy llm.RetryPolicy) {
+	a.retryPolicy = policy
+}
+
+// SetFallbackClients sets the ordered list of clients to try when the
+// primary LLM client fails a request.
+func (a *Agent) SetFallbackClients(clients []llm.LLMClient) {
+	a.fallbackClients = clients
+}
+
+// toolDefinitions converts the registered tools to native tool definitions,
+// sorted by name so requests are deterministic.
+func (a *Agent) toolDefinitions() []llm.ToolDefinition {
+	a.toolsMu.RLock()
+	defer a.toolsMu.RUnlock()
+
+	defs := make([]llm.ToolDefinition, 0, len(a.tools))
+	for _, tool := range a.tools {
+		defs = append(defs, llm.ToolDefinition{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Parameters:  schemaFromParameters(tool.Parameters()),
+		})
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// schemaFromParameters turns a tool's Parameters() string into a JSON Schema.
+// Tools describe their arguments with an example object such as
+// {"path": "string (required) - file path"}, so each top-level key becomes
+// a property whose type is inferred from the example value and whose
+// description is the example itself. Strings that are already a JSON Schema
+// (type "object" with "properties") are passed through unchanged.
+func schemaFromParameters(params string) map[string]interface{} {
+	var example map[string]interface{}
+	if err := json.Unmarshal([]byte(params), &example); err != nil {
+		// Not JSON: expose the text as a description of a free-form object
+		return map[string]interface{}{
+			"type":        "object",
+			"description": params,
+		}
+	}
+
+	if t, _ := example["type"].(string); t == "object" {
+		if _, ok := example["properties"]; ok {
+			return example
+		}
+	}
+
+	properties := make(map[string]interface{}, len(example))
+	for key, value := range example {
+		prop := map[string]interface{}{"type": jsonSchemaType(value)}
+		if s, ok := value.(string); ok {
+			prop["description"] = s
+		} else if raw, err := json.Marshal(value); err == nil {
+			prop["description"] = fmt.Sprintf("example: %s", raw)
+		}
+		properties[key] = prop
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+// jsonSchemaType returns the JSON Schema type name for a decoded JSON value.
+func jsonSchemaType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "string"
+	}
+}
+
+// formatToolCall renders a native tool call in the text ACTION format.
+// History is stored in this form so it stays readable by every provider,
+// including ones without native tool calling.
+func formatToolCall(response string, call *llm.ToolCall) string {
+	action := fmt.Sprintf("ACTION: %s(%s)", call.Name, call.Arguments)
+	if response == "" {
+		return action
+	}
+	return response + "\n" + action
+}
+
+// executeWithProgress runs a tool, forwarding anything a ProgressTool reports
+// while it runs as "progress" events. Other tools are simply executed.
+func executeWithProgress(tool Tool, args string, callback EventCallback) (string, error) {
+	reporter, ok := tool.(ProgressTool)
+	if !ok {
+		return tool.Execute(args)
+	}
+
+	progress := make(chan ProgressEvent, 16)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for event := range progress {
+			callback(AgentEvent{Type: "progress", Content: event.Tool, Progress: &event})
+		}
+	}()
+
+	reporter.SetProgressChannel(progress)
+	defer func() {
+		// The tool stops sending once the channel is unset
+		reporter.SetProgressChannel(nil)
+		close(progress)
+		<-forwarded
+	}()
+	return tool.Execute(args)
+}
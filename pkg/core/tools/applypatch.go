@@ -0,0 +1,479 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/blackcoderx/zap/pkg/core"
+)
+
+// ApplyPatchTool applies unified diffs to files in the project with
+// human-in-the-loop confirmation, so fixes are small reviewable edits
+// rather than whole-file rewrites.
+type ApplyPatchTool struct {
+	workDir        string
+	confirmManager *ConfirmationManager
+	eventCallback  core.EventCallback
+}
+
+// ApplyPatchParams defines the parameters for the apply_patch tool.
+type ApplyPatchParams struct {
+	Patch string `json:"patch"`          // Unified diff, one or more files
+	Path  string `json:"path,omitempty"` // File to patch when the diff has no ---/+++ headers
+}
+
+// NewApplyPatchTool creates a new patch tool.
+func NewApplyPatchTool(workDir string, confirmManager *ConfirmationManager) *ApplyPatchTool {
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	return &ApplyPatchTool{
+		workDir:        workDir,
+		confirmManager: confirmManager,
+	}
+}
+
+// Name returns the tool name.
+func (t *ApplyPatchTool) Name() string {
+	return "apply_patch"
+}
+
+// Description returns the tool description.
+func (t *ApplyPatchTool) Description() string {
+	return "Apply a unified diff (--- a/file, +++ b/file, @@ hunks) to files in the project. Context and removed lines must match the current file, and @@ line counts must match each hunk (or use a bare @@). Shows the diff and requires user confirmation. Prefer this over write_file for fixes to existing files."
+}
+
+// Parameters returns the tool parameter description.
+func (t *ApplyPatchTool) Parameters() string {
+	return `{"patch": "string (required) - unified diff with 3 lines of context per hunk", "path": "string - file to patch when the diff has no ---/+++ headers"}`
+}
+
+// SetEventCallback sets the callback for emitting events to the TUI.
+// This implements the ConfirmableTool interface.
+func (t *ApplyPatchTool) SetEventCallback(callback core.EventCallback) {
+	t.eventCallback = callback
+}
+
+// patchHunk is one @@ block of a unified diff
+type patchHunk struct {
+	header   string
+	oldStart int      // 1-based line the hunk starts at, 0 when the header has none
+	lines    []string // Lines with their ' ', '-' or '+' prefix
+	blank    int      // Trailing lines that were empty in the patch
+
+	// "\ No newline at end of file" followed the last old or new line
+	oldNoNewline, newNoNewline bool
+
+	// Line counts from the header, and how many of each were read. A hunk
+	// with counts ends after them, so its lines can start with "--" or "++".
+	counted            bool
+	oldCount, newCount int
+	oldSeen, newSeen   int
+}
+
+// complete reports whether a counted hunk has all the lines its header counts
+func (h *patchHunk) complete() bool {
+	return h.oldSeen == h.oldCount && h.newSeen == h.newCount
+}
+
+// add appends a line to a counted hunk, reporting whether the header
+// counts it
+func (h *patchHunk) add(line string) bool {
+	switch line[0] {
+	case ' ':
+		h.oldSeen++
+		h.newSeen++
+	case '-':
+		h.oldSeen++
+	case '+':
+		h.newSeen++
+	}
+	h.lines = append(h.lines, line)
+	return h.oldSeen <= h.oldCount && h.newSeen <= h.newCount
+}
+
+// markNoNewline records a "\ No newline at end of file" marker, which
+// applies to the line before it
+func (h *patchHunk) markNoNewline() {
+	if len(h.lines) == 0 {
+		return
+	}
+	switch h.lines[len(h.lines)-1][0] {
+	case ' ':
+		h.oldNoNewline, h.newNoNewline = true, true
+	case '-':
+		h.oldNoNewline = true
+	case '+':
+		h.newNoNewline = true
+	}
+}
+
+// countError explains that a hunk's lines don't add up to its header
+func (h *patchHunk) countError() error {
+	return fmt.Errorf("hunk %q has %d old and %d new lines, not the %d and %d its header counts; fix the counts or start the hunk with a bare @@",
+		h.header, h.oldSeen, h.newSeen, h.oldCount, h.newCount)
+}
+
+// filePatch is the part of a unified diff for one file
+type filePatch struct {
+	path    string
+	isNew   bool
+	deleted bool
+	hunks   []patchHunk
+}
+
+// patchedFile is a file's content before and after the patch
+type patchedFile struct {
+	path     string
+	absPath  string
+	isNew    bool
+	original string
+	patched  string
+}
+
+// Execute validates the patch against the files and applies it after user
+// confirmation. Nothing is written unless every hunk applies.
+func (t *ApplyPatchTool) Execute(args string) (string, error) {
+	var params ApplyPatchParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if strings.TrimSpace(params.Patch) == "" {
+		return "", fmt.Errorf("patch is required")
+	}
+
+	patches, err := parseUnifiedDiff(params.Patch, params.Path)
+	if err != nil {
+		return "", err
+	}
+
+	var files []patchedFile
+	seen := make(map[string]int) // Index in files by absolute path
+	for _, p := range patches {
+		if p.deleted {
+			return "", fmt.Errorf("%s: deleting files is not supported", p.path)
+		}
+		absPath, err := ValidatePathWithinWorkDir(p.path, t.workDir)
+		if err != nil {
+			return "", err
+		}
+		// Later sections for the same file apply on top of the earlier ones
+		if i, ok := seen[absPath]; ok {
+			if p.isNew {
+				return "", fmt.Errorf("%s: the patch creates it more than once", p.path)
+			}
+			patched, err := applyHunks(files[i].patched, p.hunks)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", p.path, err)
+			}
+			files[i].patched = patched
+			continue
+		}
+		original := ""
+		data, err := os.ReadFile(absPath)
+		switch {
+		case err == nil && p.isNew:
+			return "", fmt.Errorf("%s already exists; the patch creates it (--- /dev/null)", p.path)
+		case err == nil:
+			original = string(data)
+		case !os.IsNotExist(err):
+			return "", fmt.Errorf("failed to read %s: %w", p.path, err)
+		case !p.isNew:
+			return "", fmt.Errorf("%s does not exist", p.path)
+		}
+		patched, err := applyHunks(original, p.hunks)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", p.path, err)
+		}
+		seen[absPath] = len(files)
+		files = append(files, patchedFile{path: p.path, absPath: absPath, isNew: p.isNew, original: original, patched: patched})
+	}
+
+	// Show the diff of what will actually be written
+	var diff strings.Builder
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.path)
+		if unified, err := udiff.ToUnified("a/"+f.path, "b/"+f.path, f.original, udiff.Strings(f.original, f.patched), 3); err == nil {
+			diff.WriteString(unified)
+		}
+	}
+	if diff.Len() == 0 {
+		return "The patch makes no changes.", nil
+	}
+
+	if t.eventCallback != nil {
+		t.eventCallback(core.AgentEvent{
+			Type: "confirmation_required",
+			FileConfirmation: &core.FileConfirmation{
+				FilePath:  strings.Join(paths, ", "),
+				IsNewFile: len(files) == 1 && files[0].isNew,
+				Diff:      diff.String(),
+			},
+		})
+	}
+	if !t.confirmManager.RequestConfirmation() {
+		return "User rejected the patch. No files were modified.", nil
+	}
+
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.absPath), 0755); err != nil {
+			return "", fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(f.absPath, []byte(f.patched), 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", f.path, err)
+		}
+	}
+	return fmt.Sprintf("Successfully patched %s", strings.Join(paths, ", ")), nil
+}
+
+// hunkHeaderPattern matches "@@ -12,5 +12,6 @@"; the numbers are optional,
+// so a bare "@@" starts a hunk found by its lines alone. A missing count
+// is 1.
+var hunkHeaderPattern = regexp.MustCompile(`^@@(?:\s+-(\d+)(?:,(\d+))?(?:\s+\+(\d+)(?:,(\d+))?)?)?\s*(?:@@|$)`)
+
+// parseUnifiedDiff splits a unified diff into per-file hunks. path is used
+// when the diff has no file headers. A hunk whose header has both line
+// counts is exactly that many lines, as patch reads it; one without runs
+// until a line that can't be part of it.
+func parseUnifiedDiff(patch, path string) ([]filePatch, error) {
+	var patches []filePatch
+	var hunk *patchHunk
+
+	flush := func() {
+		if hunk != nil {
+			// Blank lines before the next file or the end separate rather
+			// than give context
+			hunk.lines = hunk.lines[:len(hunk.lines)-hunk.blank]
+			patches[len(patches)-1].hunks = append(patches[len(patches)-1].hunks, *hunk)
+		}
+		hunk = nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(patch, "\r\n", "\n"), "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if hunk != nil && hunk.counted && !hunk.complete() {
+			switch {
+			case strings.HasPrefix(line, `\`):
+				hunk.markNoNewline()
+			case line == "":
+				// An empty context line whose leading space was trimmed
+				if !hunk.add(" ") {
+					return nil, hunk.countError()
+				}
+			case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+				if !hunk.add(line) {
+					return nil, hunk.countError()
+				}
+			default:
+				return nil, hunk.countError()
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			flush()
+			oldPath := patchHeaderPath(line[4:])
+			newPath := patchHeaderPath(lines[i+1][4:])
+			i++
+			p := filePatch{path: newPath}
+			switch {
+			case oldPath == "/dev/null":
+				p.isNew = true
+			case newPath == "/dev/null":
+				p.path, p.deleted = oldPath, true
+			}
+			patches = append(patches, p)
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			m := hunkHeaderPattern.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("invalid hunk header %q", line)
+			}
+			if len(patches) == 0 {
+				if path == "" {
+					return nil, fmt.Errorf("the patch has no --- / +++ file headers: pass path")
+				}
+				patches = append(patches, filePatch{path: path})
+			}
+			hunk = &patchHunk{header: line}
+			hunk.oldStart, _ = strconv.Atoi(m[1])
+			if m[1] != "" && m[3] != "" {
+				hunk.counted = true
+				hunk.oldCount, hunk.newCount = 1, 1
+				if m[2] != "" {
+					hunk.oldCount, _ = strconv.Atoi(m[2])
+				}
+				if m[4] != "" {
+					hunk.newCount, _ = strconv.Atoi(m[4])
+				}
+			}
+		case hunk != nil && hunk.counted && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+")):
+			hunk.add(line)
+			return nil, hunk.countError()
+		case hunk != nil && hunk.counted && line == "":
+			// Blank lines after a hunk separate it from the next
+		case hunk != nil && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+")):
+			hunk.lines = append(hunk.lines, line)
+			hunk.blank = 0
+		case hunk != nil && line == "":
+			// An empty context line whose leading space was trimmed
+			hunk.lines = append(hunk.lines, " ")
+			hunk.blank++
+		case hunk != nil && strings.HasPrefix(line, `\`):
+			if hunk.blank == 0 {
+				hunk.markNoNewline()
+			}
+		case strings.HasPrefix(line, `\`):
+		default:
+			// diff --git, index and other header lines
+			flush()
+		}
+	}
+	if hunk != nil && hunk.counted && !hunk.complete() {
+		return nil, hunk.countError()
+	}
+	flush()
+
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no hunks found: the patch must be a unified diff with @@ hunks")
+	}
+	for _, p := range patches {
+		if len(p.hunks) == 0 && !p.deleted {
+			return nil, fmt.Errorf("%s: no hunks in the patch", p.path)
+		}
+	}
+	return patches, nil
+}
+
+// patchHeaderPath returns the path of a ---/+++ header without its a/ or b/
+// prefix and timestamp
+func patchHeaderPath(header string) string {
+	path, _, _ := strings.Cut(header, "\t")
+	path = strings.TrimSpace(path)
+	if path == "/dev/null" {
+		return path
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		path = path[2:]
+	}
+	return path
+}
+
+// applyHunks applies hunks to content in order. A hunk whose lines are
+// not at the line its header gives is looked for nearby, as patch does.
+// The file keeps its trailing newline, or lack of one, unless a hunk
+// reaching the end of the file has a "\ No newline at end of file" marker:
+// then the new side's marker decides.
+func applyHunks(content string, hunks []patchHunk) (string, error) {
+	crlf := strings.Contains(content, "\r\n")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	trailingNewline := content == "" || strings.HasSuffix(content, "\n")
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	var result []string
+	next := 0 // First line of lines not yet copied to result
+	for n, h := range hunks {
+		var old, added []string
+		for _, line := range h.lines {
+			text := strings.TrimSuffix(line[1:], "\r")
+			switch line[0] {
+			case ' ':
+				old = append(old, text)
+				added = append(added, text)
+			case '-':
+				old = append(old, text)
+			case '+':
+				added = append(added, text)
+			}
+		}
+
+		at, matches := findHunk(lines, old, next, h.oldStart-1)
+		if at < 0 {
+			return "", hunkMismatch(n+1, lines, old, max(h.oldStart-1, next))
+		}
+		if h.oldStart == 0 && matches > 1 {
+			return "", fmt.Errorf("hunk %d matches %d places and its @@ header has no line number; add context lines or the line number", n+1, matches)
+		}
+		result = append(result, lines[next:at]...)
+		result = append(result, added...)
+		next = at + len(old)
+		if next == len(lines) && (h.oldNoNewline || h.newNoNewline) {
+			trailingNewline = !h.newNoNewline
+		}
+	}
+	result = append(result, lines[next:]...)
+
+	patched := strings.Join(result, "\n")
+	if trailingNewline && len(result) > 0 {
+		patched += "\n"
+	}
+	if crlf {
+		patched = strings.ReplaceAll(patched, "\n", "\r\n")
+	}
+	return patched, nil
+}
+
+// findHunk returns where old occurs in lines at or after from, preferring
+// the occurrence closest to want, or -1, and the number of occurrences
+func findHunk(lines, old []string, from, want int) (int, int) {
+	if len(old) == 0 {
+		// Pure addition: insert at the given line
+		return min(max(want+1, from), len(lines)), 1
+	}
+	best, matches := -1, 0
+	for i := from; i+len(old) <= len(lines); i++ {
+		match := true
+		for j, line := range old {
+			if lines[i+j] != line {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		matches++
+		if best < 0 || abs(i-want) < abs(best-want) {
+			best = i
+		}
+	}
+	return best, matches
+}
+
+// hunkMismatch explains why a hunk doesn't apply, showing the file's lines
+// where it was expected
+func hunkMismatch(n int, lines, old []string, at int) error {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("hunk %d does not match the current file; re-read it and make the context and - lines exact.\nExpected:\n", n))
+	for _, line := range old {
+		sb.WriteString("  " + line + "\n")
+	}
+	at = min(max(at, 0), len(lines))
+	end := min(at+len(old), len(lines))
+	sb.WriteString(fmt.Sprintf("File lines %d-%d:\n", at+1, end))
+	for _, line := range lines[at:end] {
+		sb.WriteString("  " + line + "\n")
+	}
+	return errors.New(strings.TrimSuffix(sb.String(), "\n"))
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,298 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/core"
+)
+
+func TestApplyPatchTool(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string // Files in the work directory before the patch
+		patch  string
+		path   string
+		want   map[string]string // Files after the patch
+		errMsg string
+	}{
+		{
+			name:  "one hunk",
+			files: map[string]string{"main.go": "package main\n\nfunc main() {\n\tprintln(1)\n}\n"},
+			patch: "--- a/main.go\n+++ b/main.go\n@@ -3,3 +3,3 @@\n func main() {\n-\tprintln(1)\n+\tprintln(2)\n }\n",
+			want:  map[string]string{"main.go": "package main\n\nfunc main() {\n\tprintln(2)\n}\n"},
+		},
+		{
+			name:  "several files",
+			files: map[string]string{"a.txt": "a1\na2\na3\n", "dir/b.txt": "b1\nb2\n"},
+			patch: "diff --git a/a.txt b/a.txt\nindex 1..2 100644\n--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,4 @@\n a1\n+new\n a2\n a3\n" +
+				"diff --git a/dir/b.txt b/dir/b.txt\n--- a/dir/b.txt\n+++ b/dir/b.txt\n@@ -1,2 +1,1 @@\n-b1\n b2\n",
+			want: map[string]string{"a.txt": "a1\nnew\na2\na3\n", "dir/b.txt": "b2\n"},
+		},
+		{
+			name:  "several hunks",
+			files: map[string]string{"a.txt": "1\n2\n3\n4\n5\n6\n7\n8\n9\n"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n-1\n+one\n 2\n\n@@ -8,2 +8,2 @@\n 8\n-9\n+nine\n",
+			want:  map[string]string{"a.txt": "one\n2\n3\n4\n5\n6\n7\n8\nnine\n"},
+		},
+		{
+			name:  "new file",
+			patch: "--- /dev/null\n+++ b/docs/new.md\n@@ -0,0 +1,2 @@\n+# New\n+text\n",
+			want:  map[string]string{"docs/new.md": "# New\ntext\n"},
+		},
+		{
+			name:   "new file that exists",
+			files:  map[string]string{"new.md": "x\n"},
+			patch:  "--- /dev/null\n+++ b/new.md\n@@ -0,0 +1 @@\n+y\n",
+			errMsg: "already exists",
+		},
+		{
+			name:   "delete",
+			files:  map[string]string{"old.txt": "x\n"},
+			patch:  "--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-x\n",
+			errMsg: "deleting files is not supported",
+		},
+		{
+			name:   "missing file",
+			patch:  "--- a/none.txt\n+++ b/none.txt\n@@ -1 +1 @@\n-x\n+y\n",
+			errMsg: "none.txt does not exist",
+		},
+		{
+			name:   "context mismatch",
+			files:  map[string]string{"a.txt": "a\nb\nc\n"},
+			patch:  "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n a\n-B\n+x\n c\n",
+			errMsg: "hunk 1 does not match the current file",
+		},
+		{
+			name:   "nothing is written when a later file fails",
+			files:  map[string]string{"a.txt": "a\n", "b.txt": "b\n"},
+			patch:  "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+A\n--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-x\n+X\n",
+			want:   map[string]string{"a.txt": "a\n"},
+			errMsg: "b.txt: hunk 1 does not match",
+		},
+		{
+			name:  "lines starting with -- and ++ are content",
+			files: map[string]string{"q.sql": "SELECT 1;\n-- old note\nSELECT 2;\n"},
+			patch: "--- a/q.sql\n+++ b/q.sql\n@@ -1,3 +1,3 @@\n SELECT 1;\n--- old note\n+++ new note\n SELECT 2;\n",
+			want:  map[string]string{"q.sql": "SELECT 1;\n++ new note\nSELECT 2;\n"},
+		},
+		{
+			name:  "content like file headers at the end of a hunk",
+			files: map[string]string{"a.c": "int i;\n-- x\n"},
+			patch: "--- a/a.c\n+++ b/a.c\n@@ -1,2 +1,2 @@\n int i;\n--- x\n+++ y\n",
+			want:  map[string]string{"a.c": "int i;\n++ y\n"},
+		},
+		{
+			name:  "counts default to 1",
+			files: map[string]string{"a.txt": "a\nb\n"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -2 +2 @@\n-b\n+c\n",
+			want:  map[string]string{"a.txt": "a\nc\n"},
+		},
+		{
+			name:  "empty context line with its space trimmed",
+			files: map[string]string{"a.txt": "a\n\nb\n"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n a\n\n-b\n+c\n",
+			want:  map[string]string{"a.txt": "a\n\nc\n"},
+		},
+		{
+			name:  "no newline at end of file",
+			files: map[string]string{"a.txt": "a\nb"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+			want:  map[string]string{"a.txt": "a\nc"},
+		},
+		{
+			name:  "adds the missing newline at end of file",
+			files: map[string]string{"a.txt": "a\nb"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+			want:  map[string]string{"a.txt": "a\nb\n"},
+		},
+		{
+			name:  "removes the newline at end of file",
+			files: map[string]string{"a.txt": "a\nb\n"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+			want:  map[string]string{"a.txt": "a\nb"},
+		},
+		{
+			name:  "context line without a newline",
+			files: map[string]string{"a.txt": "a\nb"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,3 @@\n+z\n a\n b\n\\ No newline at end of file\n",
+			want:  map[string]string{"a.txt": "z\na\nb"},
+		},
+		{
+			name:  "new file without a newline",
+			patch: "--- /dev/null\n+++ b/x.txt\n@@ -0,0 +1 @@\n+x\n\\ No newline at end of file\n",
+			want:  map[string]string{"x.txt": "x"},
+		},
+		{
+			name:  "no newline marker in a bare @@ hunk",
+			files: map[string]string{"a.txt": "a\nb\n"},
+			patch: "@@\n a\n-b\n+c\n\\ No newline at end of file\n",
+			path:  "a.txt",
+			want:  map[string]string{"a.txt": "a\nc"},
+		},
+		{
+			name:  "no marker keeps the missing newline",
+			files: map[string]string{"a.txt": "a\nb"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+			want:  map[string]string{"a.txt": "a\nc"},
+		},
+		{
+			name:  "same file in two sections",
+			files: map[string]string{"a.txt": "1\n2\n3\n4\n5\n6\n7\n8\n9\n", "b.txt": "b\n"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n-1\n+one\n 2\n" +
+				"--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-b\n+B\n" +
+				"--- a/a.txt\n+++ b/a.txt\n@@ -8,2 +8,2 @@\n 8\n-9\n+nine\n",
+			want: map[string]string{"a.txt": "one\n2\n3\n4\n5\n6\n7\n8\nnine\n", "b.txt": "B\n"},
+		},
+		{
+			name:  "later section edits lines an earlier one added",
+			files: map[string]string{"a.txt": "a\nb\n"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,3 @@\n a\n+new\n b\n" +
+				"--- a/a.txt\n+++ b/a.txt\n@@ -2,2 +2,2 @@\n-new\n+NEW\n b\n",
+			want: map[string]string{"a.txt": "a\nNEW\nb\n"},
+		},
+		{
+			name:   "later section against the original file",
+			files:  map[string]string{"a.txt": "a\nb\n"},
+			patch:  "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+x\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+y\n",
+			want:   map[string]string{"a.txt": "a\nb\n"},
+			errMsg: "a.txt: hunk 1 does not match",
+		},
+		{
+			name:   "new file created twice",
+			patch:  "--- /dev/null\n+++ b/x.txt\n@@ -0,0 +1 @@\n+x\n--- /dev/null\n+++ b/x.txt\n@@ -0,0 +1 @@\n+y\n",
+			errMsg: "x.txt: the patch creates it more than once",
+		},
+		{
+			name:   "hunk shorter than its counts",
+			files:  map[string]string{"a.txt": "a\nb\nc\n"},
+			patch:  "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n",
+			errMsg: "has 2 old and 2 new lines, not the 3 and 3 its header counts",
+		},
+		{
+			name:   "hunk longer than its counts",
+			files:  map[string]string{"a.txt": "a\nb\nc\n"},
+			patch:  "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+x\n c\n",
+			errMsg: "not the 2 and 2 its header counts",
+		},
+		{
+			name:   "hunk cut short by the next one",
+			files:  map[string]string{"a.txt": "a\nb\nc\n"},
+			patch:  "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n@@ -3 +3 @@\n-c\n+y\n",
+			errMsg: "its header counts",
+		},
+		{
+			name:  "bare @@ without headers uses path",
+			files: map[string]string{"a.txt": "a\nb\nc\n"},
+			patch: "@@\n a\n-b\n+x\n c\n",
+			path:  "a.txt",
+			want:  map[string]string{"a.txt": "a\nx\nc\n"},
+		},
+		{
+			name:   "bare @@ matching several places",
+			files:  map[string]string{"a.txt": "a\nb\na\nb\n"},
+			patch:  "@@\n a\n-b\n+x\n",
+			path:   "a.txt",
+			errMsg: "hunk 1 matches 2 places",
+		},
+		{
+			name:  "hunk found away from its line number",
+			files: map[string]string{"a.txt": "0\n0\na\nb\nc\n"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+			want:  map[string]string{"a.txt": "0\n0\na\nx\nc\n"},
+		},
+		{
+			name:  "CRLF file keeps its line endings",
+			files: map[string]string{"a.txt": "a\r\nb\r\n"},
+			patch: "--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+			want:  map[string]string{"a.txt": "a\r\nc\r\n"},
+		},
+		{
+			name:   "no file headers or path",
+			patch:  "@@ -1 +1 @@\n-a\n+b\n",
+			errMsg: "pass path",
+		},
+		{
+			name:   "not a diff",
+			patch:  "just some text",
+			errMsg: "no hunks found",
+		},
+		{
+			name:   "outside the work directory",
+			patch:  "--- a/../x.txt\n+++ b/../x.txt\n@@ -1 +1 @@\n-a\n+b\n",
+			errMsg: "access denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(workDir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("failed to create dir: %v", err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+			confirmManager := NewConfirmationManager()
+			tool := NewApplyPatchTool(workDir, confirmManager)
+			tool.SetEventCallback(approveAll(confirmManager))
+
+			args, _ := json.Marshal(ApplyPatchParams{Patch: tt.patch, Path: tt.path})
+			got, err := tool.Execute(string(args))
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.errMsg)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if !strings.HasPrefix(got, "Successfully patched") {
+				t.Fatalf("unexpected result: %s", got)
+			}
+
+			for name, want := range tt.want {
+				data, err := os.ReadFile(filepath.Join(workDir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", name, err)
+				}
+				if string(data) != want {
+					t.Errorf("%s = %q, want %q", name, data, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyPatchTool_Rejected(t *testing.T) {
+	workDir := t.TempDir()
+	path := filepath.Join(workDir, "a.txt")
+	if err := os.WriteFile(path, []byte("a\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	confirmManager := NewConfirmationManager()
+	tool := NewApplyPatchTool(workDir, confirmManager)
+	tool.SetEventCallback(func(event core.AgentEvent) {
+		go func() {
+			for !confirmManager.IsPending() {
+				time.Sleep(5 * time.Millisecond)
+			}
+			confirmManager.SendResponse(false)
+		}()
+	})
+
+	got, err := tool.Execute(`{"patch": "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+b\n"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(got, "User rejected the patch") {
+		t.Errorf("unexpected result: %s", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "a\n" {
+		t.Errorf("file changed to %q", data)
+	}
+}
@@ -3,7 +3,10 @@ package tools
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"net/http"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -21,17 +24,30 @@ func NewAssertTool(responseManager *ResponseManager) *AssertTool {
 
 // AssertParams defines validation criteria
 type AssertParams struct {
-	StatusCode          *int                `json:"status_code,omitempty"`
-	StatusCodeNot       *int                `json:"status_code_not,omitempty"`
-	Headers             map[string]string   `json:"headers,omitempty"`
-	HeadersNotPresent   []string            `json:"headers_not_present,omitempty"`
-	BodyContains        []string            `json:"body_contains,omitempty"`
-	BodyNotContains     []string            `json:"body_not_contains,omitempty"`
-	BodyEquals          interface{}         `json:"body_equals,omitempty"`
-	BodyMatchesRegex    string              `json:"body_matches_regex,omitempty"`
-	JSONPath            map[string]interface{} `json:"json_path,omitempty"` // path -> expected value
-	ResponseTimeMaxMs   *int                `json:"response_time_max_ms,omitempty"`
-	ContentType         string              `json:"content_type,omitempty"`
+	StatusCode        *int                          `json:"status_code,omitempty"`
+	StatusCodeNot     *int                          `json:"status_code_not,omitempty"`
+	Headers           map[string]string             `json:"headers,omitempty"`
+	HeadersNotPresent []string                      `json:"headers_not_present,omitempty"`
+	HeadersMatch      map[string]string             `json:"headers_match,omitempty"` // header -> regex one of its values must match
+	HeaderValues      map[string][]string           `json:"header_values,omitempty"` // header -> substrings each found in one of its values (e.g. several Set-Cookie)
+	BodyContains      []string                      `json:"body_contains,omitempty"`
+	BodyNotContains   []string                      `json:"body_not_contains,omitempty"`
+	BodyEquals        interface{}                   `json:"body_equals,omitempty"`
+	BodyMatchesRegex  string                        `json:"body_matches_regex,omitempty"`
+	JSONPath          map[string]interface{}        `json:"json_path,omitempty"`         // path -> expected value
+	JSONPathExists    []string                      `json:"json_path_exists,omitempty"`  // Paths that must be present (a null value counts)
+	JSONPathLength    map[string]interface{}        `json:"json_path_length,omitempty"`  // path -> length of an array, string or object, or a comparison like {"gte": 1}
+	JSONPathCompare   map[string]map[string]float64 `json:"json_path_compare,omitempty"` // path -> {"gt": 0, "lte": 100}
+	JSONPathType      map[string]string             `json:"json_path_type,omitempty"`    // path -> integer, number, string, boolean, array, object or null; "string|null" allows either
+	JSONPathNot       map[string]interface{}        `json:"json_path_not,omitempty"`     // path -> value it must not have; null asserts the value isn't null
+	ResponseTimeMaxMs *int                          `json:"response_time_max_ms,omitempty"`
+	ContentType       string                        `json:"content_type,omitempty"`
+	ContentEncoding   string                        `json:"content_encoding,omitempty"`  // gzip, deflate, br, or "identity" for none
+	NotModified       *bool                         `json:"not_modified,omitempty"`      // Expect (or not) a 304 to a conditional request
+	CacheStatus       string                        `json:"cache_status,omitempty"`      // stored, revalidated, modified, uncacheable
+	Protocol          string                        `json:"protocol,omitempty"`          // Negotiated protocol: "HTTP/1.1", "HTTP/2.0" or "HTTP/3.0"
+	RequestIDEchoed   *bool                         `json:"request_id_echoed,omitempty"` // Response X-Request-Id matches the generated one
+	Response          string                        `json:"response,omitempty"`          // Response saved with save_response_as (default: the last one)
 }
 
 // AssertionResult represents the outcome of assertions
@@ -50,7 +66,7 @@ func (t *AssertTool) Name() string {
 
 // Description returns the tool description
 func (t *AssertTool) Description() string {
-	return "Validate the last HTTP response against expected criteria (status code, headers, body content, timing)"
+	return "Validate the last HTTP response against expected criteria (status code, headers, body content, JSON path values, lengths and ranges, timing)"
 }
 
 // Parameters returns the tool parameter description
@@ -58,26 +74,37 @@ func (t *AssertTool) Parameters() string {
 	return `{
   "status_code": 200,
   "headers": {"Content-Type": "application/json"},
+  "headers_not_present": ["X-Powered-By"],
+  "headers_match": {"cache-control": "max-age=\\d+"},
+  "header_values": {"Set-Cookie": ["session=", "csrf="]},
   "body_contains": ["user_id", "email"],
   "body_not_contains": ["error"],
   "body_equals": {"status": "ok"},
   "json_path": {"$.data.id": 123, "$.status": "active"},
-  "response_time_max_ms": 500
+  "json_path_exists": ["$.data.created_at"],
+  "json_path_length": {"$.data.items": 3, "$.data.tags": {"gte": 1}},
+  "json_path_compare": {"$.data.total": {"gt": 0, "lte": 100}},
+  "json_path_type": {"$.data.id": "integer", "$.data.deleted_at": "string|null"},
+  "json_path_not": {"$.data.email": null, "$.data.status": "error"},
+  "body_matches_regex": "\\d{4}-\\d{2}-\\d{2}",
+  "response_time_max_ms": 500,
+  "content_encoding": "gzip",
+  "response": "before_update (optional: a response saved with save_response_as; default the last one)"
 }`
 }
 
-// Execute performs assertions on the last HTTP response
+// Execute performs assertions on the last HTTP response, or a saved one
 func (t *AssertTool) Execute(args string) (string, error) {
-	lastResponse := t.responseManager.GetHTTPResponse()
-	if lastResponse == nil {
-		return "", fmt.Errorf("no HTTP response available - make an http_request first")
-	}
-
 	var params AssertParams
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
 		return "", fmt.Errorf("failed to parse assertion parameters: %w", err)
 	}
 
+	lastResponse, err := t.responseManager.Response(params.Response)
+	if err != nil {
+		return "", err
+	}
+
 	result := t.runAssertions(params, lastResponse)
 
 	// Format result
@@ -126,10 +153,10 @@ func (t *AssertTool) runAssertions(params AssertParams, lastResponse *HTTPRespon
 		}
 	}
 
-	// Check headers
+	// Check headers (names are case-insensitive)
 	for key, expectedValue := range params.Headers {
 		result.TotalChecks++
-		actualValue, ok := lastResponse.Headers[key]
+		actualValue, ok := responseHeader(lastResponse, key)
 		if !ok {
 			result.Failures = append(result.Failures,
 				fmt.Sprintf("Header '%s' not found", key))
@@ -146,15 +173,56 @@ func (t *AssertTool) runAssertions(params AssertParams, lastResponse *HTTPRespon
 	// Check headers NOT present
 	for _, key := range params.HeadersNotPresent {
 		result.TotalChecks++
-		if _, ok := lastResponse.Headers[key]; ok {
+		if actualValue, ok := responseHeader(lastResponse, key); ok {
 			result.Failures = append(result.Failures,
-				fmt.Sprintf("Header '%s' should not be present", key))
+				fmt.Sprintf("Header '%s' should not be present (got '%s')", key, actualValue))
 			result.Passed = false
 		} else {
 			result.PassedChecks++
 		}
 	}
 
+	// Check headers against regexes
+	for key, pattern := range params.HeadersMatch {
+		result.TotalChecks++
+		re, err := regexp.Compile(pattern)
+		values := responseHeaderValues(lastResponse, key)
+		switch {
+		case err != nil:
+			result.Failures = append(result.Failures,
+				fmt.Sprintf("Invalid regex pattern for header '%s': %v", key, err))
+			result.Passed = false
+		case len(values) == 0:
+			result.Failures = append(result.Failures,
+				fmt.Sprintf("Header '%s' not found", key))
+			result.Passed = false
+		case !slices.ContainsFunc(values, re.MatchString):
+			result.Failures = append(result.Failures,
+				fmt.Sprintf("Header '%s' does not match regex %s (got %s)", key, pattern, strings.Join(values, " | ")))
+			result.Passed = false
+		default:
+			result.PassedChecks++
+		}
+	}
+
+	// Check multi-value headers value by value
+	for key, expectedValues := range params.HeaderValues {
+		values := responseHeaderValues(lastResponse, key)
+		for _, expected := range expectedValues {
+			result.TotalChecks++
+			found := slices.ContainsFunc(values, func(value string) bool {
+				return strings.Contains(value, expected)
+			})
+			if !found {
+				result.Failures = append(result.Failures,
+					fmt.Sprintf("No '%s' header contains '%s' (%d value(s))", key, expected, len(values)))
+				result.Passed = false
+			} else {
+				result.PassedChecks++
+			}
+		}
+	}
+
 	// Check body contains
 	for _, needle := range params.BodyContains {
 		result.TotalChecks++
@@ -246,6 +314,78 @@ func (t *AssertTool) runAssertions(params AssertParams, lastResponse *HTTPRespon
 		}
 	}
 
+	// Check JSON path existence, lengths, numeric comparisons and types
+	if checks := len(params.JSONPathExists) + len(params.JSONPathLength) + len(params.JSONPathCompare) +
+		len(params.JSONPathType) + len(params.JSONPathNot); checks > 0 {
+		var body interface{}
+		if err := json.Unmarshal([]byte(lastResponse.Body), &body); err != nil {
+			result.TotalChecks += checks
+			result.Failures = append(result.Failures,
+				fmt.Sprintf("Cannot parse response as JSON for JSONPath checks: %v", err))
+			result.Passed = false
+		} else {
+			for _, path := range params.JSONPathExists {
+				result.TotalChecks++
+				if _, err := lookupJSONPath(body, path); err != nil {
+					result.Failures = append(result.Failures,
+						fmt.Sprintf("JSONPath '%s' does not exist: %v", path, err))
+					result.Passed = false
+				} else {
+					result.PassedChecks++
+				}
+			}
+
+			for path, expected := range params.JSONPathLength {
+				result.TotalChecks++
+				if err := checkJSONPathLength(body, path, expected); err != nil {
+					result.Failures = append(result.Failures,
+						fmt.Sprintf("JSONPath '%s' length: %v", path, err))
+					result.Passed = false
+				} else {
+					result.PassedChecks++
+				}
+			}
+
+			for path, ops := range params.JSONPathCompare {
+				result.TotalChecks++
+				if err := checkJSONPathNumber(body, path, ops); err != nil {
+					result.Failures = append(result.Failures,
+						fmt.Sprintf("JSONPath '%s': %v", path, err))
+					result.Passed = false
+				} else {
+					result.PassedChecks++
+				}
+			}
+
+			for path, expectedType := range params.JSONPathType {
+				result.TotalChecks++
+				if err := checkJSONPathType(body, path, expectedType); err != nil {
+					result.Failures = append(result.Failures,
+						fmt.Sprintf("JSONPath '%s': %v", path, err))
+					result.Passed = false
+				} else {
+					result.PassedChecks++
+				}
+			}
+
+			for path, unwanted := range params.JSONPathNot {
+				result.TotalChecks++
+				actualValue, err := lookupJSONPath(body, path)
+				if err != nil {
+					result.Failures = append(result.Failures,
+						fmt.Sprintf("JSONPath '%s': %v", path, err))
+					result.Passed = false
+				} else if deepEqual(actualValue, unwanted) {
+					result.Failures = append(result.Failures,
+						fmt.Sprintf("JSONPath '%s' should not be %s", path, describeJSONValue(unwanted)))
+					result.Passed = false
+				} else {
+					result.PassedChecks++
+				}
+			}
+		}
+	}
+
 	// Check response time
 	if params.ResponseTimeMaxMs != nil {
 		result.TotalChecks++
@@ -277,10 +417,305 @@ func (t *AssertTool) runAssertions(params AssertParams, lastResponse *HTTPRespon
 		}
 	}
 
+	// Check content encoding (the body itself is already decoded)
+	if params.ContentEncoding != "" {
+		result.TotalChecks++
+		expected := strings.ToLower(params.ContentEncoding)
+		actual := lastResponse.ContentEncoding
+		if actual == "" {
+			actual = "identity"
+		}
+		if actual != expected {
+			result.Failures = append(result.Failures,
+				fmt.Sprintf("Expected Content-Encoding '%s', got '%s'", expected, actual))
+			result.Passed = false
+		} else {
+			result.PassedChecks++
+		}
+	}
+
+	// Check 304 behavior of conditional requests
+	if params.NotModified != nil {
+		result.TotalChecks++
+		got304 := lastResponse.StatusCode == 304
+		if got304 != *params.NotModified {
+			if *params.NotModified {
+				result.Failures = append(result.Failures,
+					fmt.Sprintf("Expected 304 Not Modified, got %d (%s)", lastResponse.StatusCode, describeValidators(lastResponse)))
+			} else {
+				result.Failures = append(result.Failures,
+					"Expected a full response, got 304 Not Modified")
+			}
+			result.Passed = false
+		} else {
+			result.PassedChecks++
+		}
+	}
+
+	// Check response cache status
+	if params.CacheStatus != "" {
+		result.TotalChecks++
+		if lastResponse.CacheStatus != params.CacheStatus {
+			actual := lastResponse.CacheStatus
+			if actual == "" {
+				actual = "none (request did not use the cache option)"
+			}
+			result.Failures = append(result.Failures,
+				fmt.Sprintf("Expected cache status '%s', got '%s'", params.CacheStatus, actual))
+			result.Passed = false
+		} else {
+			result.PassedChecks++
+		}
+	}
+
+	// Check negotiated protocol
+	if params.Protocol != "" {
+		result.TotalChecks++
+		if !strings.EqualFold(lastResponse.Protocol, params.Protocol) {
+			result.Failures = append(result.Failures,
+				fmt.Sprintf("Expected protocol '%s', got '%s'", params.Protocol, lastResponse.Protocol))
+			result.Passed = false
+		} else {
+			result.PassedChecks++
+		}
+	}
+
+	// Check that the server echoes the generated X-Request-Id
+	if params.RequestIDEchoed != nil {
+		result.TotalChecks++
+		sent, generated := lastResponse.RequestIDs[RequestIDHeader]
+		echoed := lastResponse.Headers[RequestIDHeader]
+		switch {
+		case !generated:
+			result.Failures = append(result.Failures,
+				"No X-Request-Id was generated for the request (enable request_ids in config or pass \"inject_ids\": true)")
+			result.Passed = false
+		case (echoed == sent) != *params.RequestIDEchoed:
+			if *params.RequestIDEchoed {
+				result.Failures = append(result.Failures,
+					fmt.Sprintf("Expected X-Request-Id '%s' to be echoed, got '%s'", sent, echoed))
+			} else {
+				result.Failures = append(result.Failures,
+					fmt.Sprintf("Expected X-Request-Id '%s' not to be echoed", sent))
+			}
+			result.Passed = false
+		default:
+			result.PassedChecks++
+		}
+	}
+
 	result.FailedChecks = result.TotalChecks - result.PassedChecks
 	return result
 }
 
+// responseHeader looks up a response header by case-insensitive name
+func responseHeader(resp *HTTPResponse, name string) (string, bool) {
+	if value, ok := resp.Headers[http.CanonicalHeaderKey(name)]; ok {
+		return value, true
+	}
+	for key, value := range resp.Headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// responseHeaderValues returns every value of a response header, so a
+// repeated header like Set-Cookie can be checked value by value
+func responseHeaderValues(resp *HTTPResponse, name string) []string {
+	for key, values := range resp.HeaderValues {
+		if strings.EqualFold(key, name) {
+			return values
+		}
+	}
+	if value, ok := responseHeader(resp, name); ok {
+		return []string{value}
+	}
+	return nil
+}
+
+// describeValidators summarizes a response's cache validators for failure messages
+func describeValidators(resp *HTTPResponse) string {
+	etag, lastModified := resp.Headers["Etag"], resp.Headers["Last-Modified"]
+	switch {
+	case etag == "" && lastModified == "":
+		return "response has no ETag or Last-Modified"
+	case etag != "":
+		return "ETag " + etag
+	default:
+		return "Last-Modified " + lastModified
+	}
+}
+
+// checkJSONPathLength checks the length of the array, string or object at
+// path. expected is a number, or comparison operators like {"gte": 1}.
+func checkJSONPathLength(body interface{}, path string, expected interface{}) error {
+	var ops map[string]float64
+	switch want := expected.(type) {
+	case float64:
+		ops = map[string]float64{"eq": want}
+	case map[string]interface{}:
+		ops = make(map[string]float64, len(want))
+		for op, value := range want {
+			n, ok := value.(float64)
+			if !ok {
+				return fmt.Errorf("'%s' needs a number, got %v", op, value)
+			}
+			ops[op] = n
+		}
+	default:
+		return fmt.Errorf("expected length must be a number or an object like {\"gte\": 1}, got %v", expected)
+	}
+
+	value, err := lookupJSONPath(body, path)
+	if err != nil {
+		return err
+	}
+	var length int
+	switch v := value.(type) {
+	case []interface{}:
+		length = len(v)
+	case string:
+		length = len([]rune(v))
+	case map[string]interface{}:
+		length = len(v)
+	default:
+		return fmt.Errorf("expected an array, string or object, got %s", jsonTypeName(value))
+	}
+	return compareNumber(float64(length), ops)
+}
+
+// checkJSONPathNumber compares the number at path with operators like {"gt": 0}
+func checkJSONPathNumber(body interface{}, path string, ops map[string]float64) error {
+	value, err := lookupJSONPath(body, path)
+	if err != nil {
+		return err
+	}
+	n, ok := value.(float64)
+	if !ok {
+		return fmt.Errorf("expected a number, got %s %v", jsonTypeName(value), value)
+	}
+	return compareNumber(n, ops)
+}
+
+// jsonTypes are the types json_path_type accepts
+var jsonTypes = []string{"integer", "number", "string", "boolean", "array", "object", "null"}
+
+// checkJSONPathType checks the type of the value at path. expectedType may
+// list alternatives, like "string|null".
+func checkJSONPathType(body interface{}, path, expectedType string) error {
+	allowed := strings.Split(strings.ToLower(strings.ReplaceAll(expectedType, " ", "")), "|")
+	for _, typ := range allowed {
+		if !slices.Contains(jsonTypes, typ) {
+			return fmt.Errorf("unknown type '%s' (use %s)", typ, strings.Join(jsonTypes, ", "))
+		}
+	}
+
+	value, err := lookupJSONPath(body, path)
+	if err != nil {
+		return err
+	}
+	actual := jsonTypeName(value)
+	if n, ok := value.(float64); ok && n == math.Trunc(n) {
+		actual = "integer"
+	}
+	// An integer is also a number
+	if slices.Contains(allowed, actual) || (actual == "integer" && slices.Contains(allowed, "number")) {
+		return nil
+	}
+	return fmt.Errorf("expected %s, got %s %s", expectedType, actual, describeJSONValue(value))
+}
+
+// describeJSONValue renders a decoded value for failure messages
+func describeJSONValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(data) > 80 {
+		return string(data[:77]) + "..."
+	}
+	return string(data)
+}
+
+// compareOps are the comparison operators of json_path_compare and
+// json_path_length, in the order they are checked
+var compareOps = []string{"eq", "gt", "gte", "lt", "lte"}
+
+// compareNumber checks actual against every operator in ops
+func compareNumber(actual float64, ops map[string]float64) error {
+	if len(ops) == 0 {
+		return fmt.Errorf("no comparison given (use %s)", strings.Join(compareOps, ", "))
+	}
+	for op := range ops {
+		if !slices.Contains(compareOps, op) {
+			return fmt.Errorf("unknown operator '%s' (use %s)", op, strings.Join(compareOps, ", "))
+		}
+	}
+	for _, op := range compareOps {
+		want, ok := ops[op]
+		if !ok {
+			continue
+		}
+		var passed bool
+		switch op {
+		case "eq":
+			passed = actual == want
+		case "gt":
+			passed = actual > want
+		case "gte":
+			passed = actual >= want
+		case "lt":
+			passed = actual < want
+		case "lte":
+			passed = actual <= want
+		}
+		if !passed {
+			return fmt.Errorf("expected %s %v, got %v", op, want, actual)
+		}
+	}
+	return nil
+}
+
+// jsonTypeName names the JSON type of a decoded value
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// lookupJSONPath is getJSONPath for any decoded body, so "$" and "$[0]"
+// work on a top-level array too
+func lookupJSONPath(body interface{}, path string) (interface{}, error) {
+	if path == "$" || path == "" {
+		return body, nil
+	}
+	if isJSONPathQuery(path) {
+		return evalJSONPathQuery(body, path)
+	}
+	if m, ok := body.(map[string]interface{}); ok {
+		return getJSONPath(m, path)
+	}
+	if strings.HasPrefix(path, "$[") {
+		// getJSONPath reads "$[0]" as field "$", index 0
+		return getJSONPath(map[string]interface{}{"$": body}, path)
+	}
+	return nil, fmt.Errorf("expected object, got %s", jsonTypeName(body))
+}
+
 // deepEqual compares two interface{} values deeply
 func deepEqual(a, b interface{}) bool {
 	aJSON, _ := json.Marshal(a)
@@ -290,7 +725,12 @@ func deepEqual(a, b interface{}) bool {
 
 // getJSONPath extracts a value from nested JSON using a simple path syntax
 // Supports: $.field, $.nested.field, $.array[0]
+// Wildcards, slices, filters and the rest of JSONPath go to evalJSONPathQuery
 func getJSONPath(data map[string]interface{}, path string) (interface{}, error) {
+	if isJSONPathQuery(path) {
+		return evalJSONPathQuery(data, path)
+	}
+
 	// Remove leading $. if present
 	path = strings.TrimPrefix(path, "$.")
 	if path == "" || path == "$" {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/blackcoderx/zap/pkg/core/tools"
 )
@@ -26,12 +27,21 @@ func NewHelperTool(responseManager *tools.ResponseManager, varStore *tools.Varia
 
 // HelperParams defines the parameters for auth helper operations.
 type HelperParams struct {
-	// Action specifies the operation: "parse_jwt", "decode_basic"
+	// Action specifies the operation: "parse_jwt", "verify_jwt", "decode_basic"
 	Action string `json:"action"`
 	// Token is the token to parse or decode
 	Token string `json:"token,omitempty"`
 	// FromBody extracts the token from a response body field (optional)
 	FromBody string `json:"from_body,omitempty"`
+
+	// verify_jwt key source: jwks_url or key for asymmetric tokens, secret for HS*
+	JWKSURL string `json:"jwks_url,omitempty"`
+	Key     string `json:"key,omitempty"`    // PEM public key or certificate
+	Secret  string `json:"secret,omitempty"` // HMAC secret
+	// Optional claim checks for verify_jwt
+	Issuer        string `json:"issuer,omitempty"`
+	Audience      string `json:"audience,omitempty"`
+	LeewaySeconds int    `json:"leeway_seconds,omitempty"` // Allowed clock skew for exp/nbf
 }
 
 // Name returns the tool name.
@@ -41,20 +51,24 @@ func (t *HelperTool) Name() string {
 
 // Description returns a human-readable description of the tool.
 func (t *HelperTool) Description() string {
-	return "Auth utilities: parse JWT tokens, decode Basic auth, extract tokens from responses"
+	return "Auth utilities: parse JWT tokens, verify JWT signatures and claims against a JWKS URL, PEM key or HMAC secret, decode Basic auth, extract tokens from responses"
 }
 
 // Parameters returns an example of the JSON parameters this tool accepts.
 func (t *HelperTool) Parameters() string {
 	return `{
-  "action": "parse_jwt",
-  "token": "{{JWT_TOKEN}}"
+  "action": "parse_jwt|verify_jwt|decode_basic",
+  "token": "{{JWT_TOKEN}}",
+  "jwks_url": "https://auth.example.com/.well-known/jwks.json (verify_jwt)",
+  "issuer": "https://auth.example.com/ (optional)",
+  "audience": "my-api (optional)"
 }`
 }
 
 // Execute performs the requested auth helper action.
 // Supported actions:
 //   - parse_jwt: Decode and display JWT token claims (header, payload, signature)
+//   - verify_jwt: Verify the JWT signature, expiry, issuer and audience
 //   - decode_basic: Decode Base64-encoded Basic auth credentials
 func (t *HelperTool) Execute(args string) (string, error) {
 	// Substitute variables
@@ -70,10 +84,12 @@ func (t *HelperTool) Execute(args string) (string, error) {
 	switch params.Action {
 	case "parse_jwt":
 		return t.parseJWT(params.Token)
+	case "verify_jwt":
+		return t.verifyJWT(params)
 	case "decode_basic":
 		return t.decodeBasic(params.Token)
 	default:
-		return "", fmt.Errorf("unknown action '%s' (use: parse_jwt, decode_basic)", params.Action)
+		return "", fmt.Errorf("unknown action '%s' (use: parse_jwt, verify_jwt, decode_basic)", params.Action)
 	}
 }
 
@@ -132,11 +148,46 @@ func (t *HelperTool) parseJWT(token string) (string, error) {
 	}
 
 	sb.WriteString("\nSignature: " + parts[2] + " (not verified)\n")
-	sb.WriteString("\nNote: Signature verification requires the secret key and is not performed by this tool.")
+	sb.WriteString("\nNote: Use action 'verify_jwt' with a jwks_url, PEM key or secret to verify the signature.")
 
 	return sb.String(), nil
 }
 
+// verifyJWT verifies a token and reports each validation step.
+// A failed verification is a result, not a tool error, so the agent can see
+// exactly which step failed.
+func (t *HelperTool) verifyJWT(params HelperParams) (string, error) {
+	if params.Token == "" {
+		return "", fmt.Errorf("'token' parameter is required")
+	}
+
+	steps := checkJWT(params.Token, jwtVerifyOptions{
+		jwksURL:  params.JWKSURL,
+		key:      params.Key,
+		secret:   params.Secret,
+		issuer:   params.Issuer,
+		audience: params.Audience,
+		leeway:   time.Duration(params.LeewaySeconds) * time.Second,
+		now:      time.Now(),
+	})
+
+	var failed []string
+	var sb strings.Builder
+	for _, step := range steps {
+		mark := "✓"
+		if !step.ok {
+			mark = "✗"
+			failed = append(failed, step.name)
+		}
+		sb.WriteString(fmt.Sprintf("%s %s: %s\n", mark, step.name, step.detail))
+	}
+
+	if len(failed) == 0 {
+		return "JWT Verification: VALID\n\n" + sb.String(), nil
+	}
+	return fmt.Sprintf("JWT Verification: INVALID (failed: %s)\n\n%s", strings.Join(failed, ", "), sb.String()), nil
+}
+
 // decodeBasic decodes Base64-encoded Basic auth credentials.
 // The input should be in the format "Basic <base64>" or just the base64 string.
 func (t *HelperTool) decodeBasic(authHeader string) (string, error) {
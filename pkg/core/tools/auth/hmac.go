@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core/tools"
+)
+
+// HMACTool configures HMAC request signing for http_request.
+// Like SigV4, the signature depends on each request's method, path, body and
+// timestamp, so http_request computes it just before sending.
+type HMACTool struct {
+	httpTool *tools.HTTPTool
+	varStore *tools.VariableStore
+}
+
+// NewHMACTool creates a new HMAC signing tool that configures the given HTTP tool.
+func NewHMACTool(httpTool *tools.HTTPTool, varStore *tools.VariableStore) *HMACTool {
+	return &HMACTool{httpTool: httpTool, varStore: varStore}
+}
+
+// HMACParams defines the parameters for HMAC signing.
+type HMACParams struct {
+	tools.HMACConfig
+	// Disable stops signing requests
+	Disable bool `json:"disable,omitempty"`
+}
+
+// Name returns the tool name.
+func (t *HMACTool) Name() string {
+	return "auth_hmac"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *HMACTool) Description() string {
+	return "Sign subsequent http_request calls with an HMAC over a canonical string built from a template ({method}, {path}, {query}, {host}, {url}, {body}, {body_sha256}, {timestamp}, {nonce}). Configure algorithm, secret (or secret_var), header name, prefix and encoding to match the API. Use 'disable' to stop."
+}
+
+// Parameters returns an example of the JSON parameters this tool accepts.
+func (t *HMACTool) Parameters() string {
+	return `{
+  "algorithm": "sha256",
+  "secret_var": "HMAC_SECRET",
+  "template": "{method}\n{path}\n{timestamp}\n{body}",
+  "header": "X-Signature",
+  "prefix": "",
+  "encoding": "hex",
+  "timestamp_header": "X-Timestamp",
+  "timestamp_format": "unix",
+  "host": "api.example.com (optional)",
+  "disable": false
+}`
+}
+
+// Execute enables or disables HMAC signing on http_request.
+func (t *HMACTool) Execute(args string) (string, error) {
+	// Substitute variables in args
+	if t.varStore != nil {
+		args = t.varStore.Substitute(args)
+	}
+
+	var params HMACParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	if params.Disable {
+		t.httpTool.SetDefaultHMAC(nil)
+		return "HMAC signing disabled.", nil
+	}
+
+	cfg := params.HMACConfig
+	if err := cfg.Validate(); err != nil {
+		return "", err
+	}
+	if cfg.SecretVar != "" && t.varStore != nil {
+		if _, ok := t.varStore.Get(cfg.SecretVar); !ok {
+			return "", fmt.Errorf("secret variable '%s' is not set (set it with the variable tool, or pass \"secret\" instead)", cfg.SecretVar)
+		}
+	}
+	t.httpTool.SetDefaultHMAC(&cfg)
+
+	algorithm := cfg.Algorithm
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	template := cfg.Template
+	if template == "" {
+		template = tools.DefaultHMACTemplate
+	}
+	header := cfg.Header
+	if header == "" {
+		header = tools.DefaultHMACHeader
+	}
+
+	var sb strings.Builder
+	sb.WriteString("HMAC signing enabled.\n\n")
+	sb.WriteString(fmt.Sprintf("Algorithm: HMAC-%s\n", strings.ToUpper(algorithm)))
+	sb.WriteString(fmt.Sprintf("Canonical string: %s\n", strconv.Quote(template)))
+	sb.WriteString(fmt.Sprintf("Signature header: %s: %s<signature>\n", header, cfg.Prefix))
+	if cfg.SecretVar != "" {
+		sb.WriteString(fmt.Sprintf("Secret: {{%s}}\n", cfg.SecretVar))
+	}
+	if cfg.Host != "" {
+		sb.WriteString(fmt.Sprintf("Signing requests to: %s\n", cfg.Host))
+	} else {
+		sb.WriteString("Signing all requests (set 'host' to limit)\n")
+	}
+	sb.WriteString("\nUse {\"hmac\": {...}} on a single http_request to override.")
+	return sb.String(), nil
+}
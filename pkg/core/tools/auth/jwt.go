@@ -0,0 +1,418 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// jwksFetchTimeout bounds the JWKS download
+const jwksFetchTimeout = 10 * time.Second
+
+// jwtStep is the outcome of one validation step
+type jwtStep struct {
+	name   string
+	ok     bool
+	detail string
+}
+
+// jwtVerifyOptions are the inputs to verifyJWT
+type jwtVerifyOptions struct {
+	jwksURL  string
+	key      string // PEM public key or certificate
+	secret   string // HMAC secret
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      time.Time
+}
+
+// jsonWebKey is one key of a JWKS document
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// checkJWT checks format, algorithm, key, signature and claims in order and
+// returns every step it got to. Validation stops at the first failing step
+// before the claims, since claims of an unverified token can't be trusted.
+func checkJWT(token string, opts jwtVerifyOptions) []jwtStep {
+	var steps []jwtStep
+	fail := func(name, format string, args ...interface{}) []jwtStep {
+		return append(steps, jwtStep{name: name, detail: fmt.Sprintf(format, args...)})
+	}
+	pass := func(name, format string, args ...interface{}) {
+		steps = append(steps, jwtStep{name: name, ok: true, detail: fmt.Sprintf(format, args...)})
+	}
+
+	// 1. Format
+	parts := strings.Split(strings.TrimPrefix(token, "Bearer "), ".")
+	if len(parts) != 3 {
+		return fail("format", "expected 3 dot-separated parts, got %d", len(parts))
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	headerJSON, err := base64DecodeJWTPart(parts[0])
+	if err != nil || json.Unmarshal([]byte(headerJSON), &header) != nil {
+		return fail("format", "header is not base64url-encoded JSON")
+	}
+	payloadJSON, err := base64DecodeJWTPart(parts[1])
+	var claims map[string]interface{}
+	if err != nil || json.Unmarshal([]byte(payloadJSON), &claims) != nil {
+		return fail("format", "payload is not base64url-encoded JSON")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil {
+		return fail("format", "signature is not base64url-encoded")
+	}
+	pass("format", "header, payload and signature decoded")
+
+	// 2. Algorithm
+	hash, family := jwtAlgorithm(header.Alg)
+	switch {
+	case header.Alg == "" || strings.EqualFold(header.Alg, "none"):
+		return fail("algorithm", "alg is %q - unsigned tokens must be rejected", header.Alg)
+	case family == "":
+		return fail("algorithm", "unsupported alg %q", header.Alg)
+	}
+	pass("algorithm", "%s", header.Alg)
+
+	// 3. Key
+	key, keyDetail, err := jwtKey(header.Alg, header.Kid, family, opts)
+	if err != nil {
+		return fail("key", "%v", err)
+	}
+	pass("key", "%s", keyDetail)
+
+	// 4. Signature
+	signed := []byte(parts[0] + "." + parts[1])
+	if err := jwtVerifySignature(family, hash, key, signed, signature); err != nil {
+		return fail("signature", "%v", err)
+	}
+	pass("signature", "valid %s signature", header.Alg)
+
+	// 5. Expiry and not-before
+	now := opts.now
+	if exp, ok := claims["exp"].(float64); ok {
+		expires := time.Unix(int64(exp), 0)
+		if now.After(expires.Add(opts.leeway)) {
+			steps = append(steps, jwtStep{name: "expiry", detail: fmt.Sprintf("expired at %s (%s ago)", expires.UTC().Format(time.RFC3339), now.Sub(expires).Round(time.Second))})
+		} else {
+			pass("expiry", "valid until %s (%s left)", expires.UTC().Format(time.RFC3339), expires.Sub(now).Round(time.Second))
+		}
+	} else {
+		pass("expiry", "no exp claim (token never expires)")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok {
+		notBefore := time.Unix(int64(nbf), 0)
+		if now.Add(opts.leeway).Before(notBefore) {
+			steps = append(steps, jwtStep{name: "not_before", detail: fmt.Sprintf("not valid before %s", notBefore.UTC().Format(time.RFC3339))})
+		} else {
+			pass("not_before", "valid since %s", notBefore.UTC().Format(time.RFC3339))
+		}
+	}
+
+	// 6. Issuer and audience
+	if opts.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != opts.issuer {
+			steps = append(steps, jwtStep{name: "issuer", detail: fmt.Sprintf("expected %q, got %q", opts.issuer, iss)})
+		} else {
+			pass("issuer", "%s", iss)
+		}
+	}
+	if opts.audience != "" {
+		if !jwtHasAudience(claims["aud"], opts.audience) {
+			steps = append(steps, jwtStep{name: "audience", detail: fmt.Sprintf("%q not in aud %v", opts.audience, claims["aud"])})
+		} else {
+			pass("audience", "%s", opts.audience)
+		}
+	}
+	return steps
+}
+
+// jwtAlgorithm returns the hash and key family ("hmac", "rsa", "rsa-pss",
+// "ecdsa", "eddsa") for alg, or an empty family when unsupported
+func jwtAlgorithm(alg string) (crypto.Hash, string) {
+	if alg == "EdDSA" {
+		return 0, "eddsa"
+	}
+	if len(alg) != 5 {
+		return 0, ""
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return 0, ""
+	}
+	switch alg[:2] {
+	case "HS":
+		return hash, "hmac"
+	case "RS":
+		return hash, "rsa"
+	case "PS":
+		return hash, "rsa-pss"
+	case "ES":
+		return hash, "ecdsa"
+	}
+	return 0, ""
+}
+
+// jwtKey resolves the verification key from the secret, PEM key or JWKS
+func jwtKey(alg, kid, family string, opts jwtVerifyOptions) (interface{}, string, error) {
+	if family == "hmac" {
+		if opts.secret == "" {
+			return nil, "", fmt.Errorf("%s tokens are verified with a shared secret - pass 'secret'", alg)
+		}
+		return []byte(opts.secret), "shared secret", nil
+	}
+
+	switch {
+	case opts.key != "":
+		key, err := parsePEMPublicKey(opts.key)
+		if err != nil {
+			return nil, "", err
+		}
+		return key, "PEM public key", nil
+	case opts.jwksURL != "":
+		keys, err := fetchJWKS(opts.jwksURL)
+		if err != nil {
+			return nil, "", err
+		}
+		var candidates []jsonWebKey
+		for _, k := range keys {
+			if k.Use == "enc" {
+				continue
+			}
+			if kid != "" && k.Kid != kid {
+				continue
+			}
+			candidates = append(candidates, k)
+		}
+		if len(candidates) == 0 {
+			kids := make([]string, 0, len(keys))
+			for _, k := range keys {
+				kids = append(kids, k.Kid)
+			}
+			return nil, "", fmt.Errorf("no signing key with kid %q in JWKS (available: %s) - keys may have rotated", kid, strings.Join(kids, ", "))
+		}
+		key, err := candidates[0].publicKey()
+		if err != nil {
+			return nil, "", fmt.Errorf("JWKS key %q: %w", candidates[0].Kid, err)
+		}
+		return key, fmt.Sprintf("JWKS key %q (%s)", candidates[0].Kid, candidates[0].Kty), nil
+	default:
+		return nil, "", fmt.Errorf("%s tokens need a public key - pass 'jwks_url' or 'key' (PEM)", alg)
+	}
+}
+
+// jwtVerifySignature checks the signature over signed with key
+func jwtVerifySignature(family string, hash crypto.Hash, key interface{}, signed, signature []byte) error {
+	var digest []byte
+	if hash != 0 {
+		h := hash.New()
+		h.Write(signed)
+		digest = h.Sum(nil)
+	}
+
+	switch family {
+	case "hmac":
+		secret, _ := key.([]byte)
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("HMAC signature mismatch (wrong secret, or the token was modified)")
+		}
+	case "rsa", "rsa-pss":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key is %T, not an RSA public key", key)
+		}
+		var err error
+		if family == "rsa" {
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(pub, hash, digest, signature, nil)
+		}
+		if err != nil {
+			return fmt.Errorf("RSA signature invalid (wrong key, or the token was modified)")
+		}
+	case "ecdsa":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key is %T, not an EC public key", key)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("ECDSA signature has %d bytes, expected %d", len(signature), 2*size)
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return fmt.Errorf("ECDSA signature invalid (wrong key, or the token was modified)")
+		}
+	case "eddsa":
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("key is %T, not an Ed25519 public key", key)
+		}
+		if !ed25519.Verify(pub, signed, signature) {
+			return fmt.Errorf("EdDSA signature invalid (wrong key, or the token was modified)")
+		}
+	}
+	return nil
+}
+
+// fetchJWKS downloads and parses a JWKS document
+func fetchJWKS(jwksURL string) ([]jsonWebKey, error) {
+	client := &http.Client{Timeout: jwksFetchTimeout}
+	resp, err := client.Get(jwksURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+	if len(doc.Keys) == 0 {
+		return nil, fmt.Errorf("JWKS at %s contains no keys", jwksURL)
+	}
+	return doc.Keys, nil
+}
+
+// publicKey converts a JWK to a Go public key
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	decode := func(field, value string) ([]byte, error) {
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+		if err != nil || len(data) == 0 {
+			return nil, fmt.Errorf("invalid '%s'", field)
+		}
+		return data, nil
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode("n", k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode("e", k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode("x", k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode("y", k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode("x", k.X)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// parsePEMPublicKey parses a PEM public key (PKIX or PKCS#1) or certificate
+func parsePEMPublicKey(data string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(strings.ReplaceAll(data, `\n`, "\n")))
+	if block == nil {
+		return nil, fmt.Errorf("'key' is not a PEM block")
+	}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA public key: %w", err)
+		}
+		return key, nil
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return key, nil
+	}
+}
+
+// jwtHasAudience reports whether aud (string or array) contains audience
+func jwtHasAudience(aud interface{}, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,357 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jwtTestNow is the time tokens are checked at
+var jwtTestNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// signTestJWT signs a token for alg with key: a []byte secret for HS*, a
+// private key otherwise
+func signTestJWT(t *testing.T, alg, kid string, claims map[string]interface{}, key interface{}) string {
+	t.Helper()
+	header := map[string]interface{}{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(header) + "." + encode(claims)
+
+	hash, family := jwtAlgorithm(alg)
+	var digest []byte
+	if hash != 0 {
+		h := hash.New()
+		h.Write([]byte(signed))
+		digest = h.Sum(nil)
+	}
+	var signature []byte
+	var err error
+	switch family {
+	case "hmac":
+		mac := hmac.New(hash.New, key.([]byte))
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case "rsa":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), hash, digest)
+	case "rsa-pss":
+		signature, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), hash, digest, nil)
+	case "ecdsa":
+		priv := key.(*ecdsa.PrivateKey)
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, priv, digest)
+		size := (priv.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		if err == nil {
+			r.FillBytes(signature[:size])
+			s.FillBytes(signature[size:])
+		}
+	case "eddsa":
+		signature = ed25519.Sign(key.(ed25519.PrivateKey), []byte(signed))
+	default:
+		t.Fatalf("unsupported alg %s", alg)
+	}
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// pemPublicKey encodes a public key as a PKIX PEM block
+func pemPublicKey(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// failedSteps returns the names of the failed steps, and the last step
+func failedSteps(steps []jwtStep) ([]string, jwtStep) {
+	var failed []string
+	for _, step := range steps {
+		if !step.ok {
+			failed = append(failed, step.name)
+		}
+	}
+	return failed, steps[len(steps)-1]
+}
+
+func TestCheckJWT_RoundTrips(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	claims := map[string]interface{}{"sub": "42", "exp": float64(jwtTestNow.Add(time.Hour).Unix())}
+	rsaPEM := pemPublicKey(t, &rsaKey.PublicKey)
+	pkcs1PEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)}))
+
+	tests := []struct {
+		name   string
+		alg    string
+		sign   interface{}
+		opts   jwtVerifyOptions
+		failed []string // Failed steps, none for a valid token
+		detail string   // Detail of the last step
+	}{
+		{"HS256", "HS256", []byte("s3cret"), jwtVerifyOptions{secret: "s3cret"}, nil, "valid until"},
+		{"HS512", "HS512", []byte("s3cret"), jwtVerifyOptions{secret: "s3cret"}, nil, "valid until"},
+		{"HS256 wrong secret", "HS256", []byte("s3cret"), jwtVerifyOptions{secret: "other"}, []string{"signature"}, "HMAC signature mismatch"},
+		{"HS256 without a secret", "HS256", []byte("s3cret"), jwtVerifyOptions{key: rsaPEM}, []string{"key"}, "pass 'secret'"},
+		{"RS256 PKIX key", "RS256", rsaKey, jwtVerifyOptions{key: rsaPEM}, nil, "valid until"},
+		{"RS256 PKCS#1 key with escaped newlines", "RS256", rsaKey, jwtVerifyOptions{key: strings.ReplaceAll(pkcs1PEM, "\n", `\n`)}, nil, "valid until"},
+		{"RS256 wrong key", "RS256", rsaKey, jwtVerifyOptions{key: pemPublicKey(t, &otherRSA.PublicKey)}, []string{"signature"}, "RSA signature invalid"},
+		{"RS256 with a secret only", "RS256", rsaKey, jwtVerifyOptions{secret: "s3cret"}, []string{"key"}, "need a public key"},
+		{"RS256 checked with an EC key", "RS256", rsaKey, jwtVerifyOptions{key: pemPublicKey(t, &ecKey.PublicKey)}, []string{"signature"}, "not an RSA public key"},
+		{"PS256", "PS256", rsaKey, jwtVerifyOptions{key: rsaPEM}, nil, "valid until"},
+		{"ES256", "ES256", ecKey, jwtVerifyOptions{key: pemPublicKey(t, &ecKey.PublicKey)}, nil, "valid until"},
+		{"EdDSA", "EdDSA", edKey, jwtVerifyOptions{key: pemPublicKey(t, edKey.Public())}, nil, "valid until"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signTestJWT(t, tt.alg, "", claims, tt.sign)
+			tt.opts.now = jwtTestNow
+			failed, last := failedSteps(checkJWT(token, tt.opts))
+			if strings.Join(failed, ",") != strings.Join(tt.failed, ",") {
+				t.Errorf("failed steps = %v, want %v (last: %s: %s)", failed, tt.failed, last.name, last.detail)
+			}
+			if !strings.Contains(last.detail, tt.detail) {
+				t.Errorf("last step %s: %q, want it to contain %q", last.name, last.detail, tt.detail)
+			}
+		})
+	}
+}
+
+func TestCheckJWT_RFC7515Example(t *testing.T) {
+	// The HS256 example of RFC 7515 appendix A.1
+	key, err := base64.RawURLEncoding.DecodeString("AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow")
+	if err != nil {
+		t.Fatalf("failed to decode key: %v", err)
+	}
+	token := "eyJ0eXAiOiJKV1QiLA0KICJhbGciOiJIUzI1NiJ9" +
+		".eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ" +
+		".dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	steps := checkJWT(token, jwtVerifyOptions{secret: string(key), issuer: "joe", now: time.Unix(1300819000, 0)})
+	if failed, last := failedSteps(steps); len(failed) > 0 {
+		t.Errorf("failed steps %v (last: %s: %s)", failed, last.name, last.detail)
+	}
+
+	// The same token after its exp of 1300819380
+	steps = checkJWT(token, jwtVerifyOptions{secret: string(key), now: time.Unix(1300819400, 0)})
+	if failed, last := failedSteps(steps); strings.Join(failed, ",") != "expiry" || !strings.Contains(last.detail, "expired at 2011-03-22T18:43:00Z (20s ago)") {
+		t.Errorf("failed steps %v, last %s: %s", failed, last.name, last.detail)
+	}
+}
+
+func TestCheckJWT_Claims(t *testing.T) {
+	secret := []byte("s3cret")
+	unix := func(d time.Duration) float64 { return float64(jwtTestNow.Add(d).Unix()) }
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		opts   jwtVerifyOptions
+		failed []string
+		detail string // Detail of the step that failed, or of the last step
+	}{
+		{"expired", map[string]interface{}{"exp": unix(-time.Minute)}, jwtVerifyOptions{}, []string{"expiry"}, "expired at 2026-01-01T11:59:00Z (1m0s ago)"},
+		{"expired within the leeway", map[string]interface{}{"exp": unix(-time.Minute)}, jwtVerifyOptions{leeway: 2 * time.Minute}, nil, "valid until 2026-01-01T11:59:00Z"},
+		{"expires exactly now", map[string]interface{}{"exp": unix(0)}, jwtVerifyOptions{}, nil, "valid until"},
+		{"no exp", map[string]interface{}{"sub": "42"}, jwtVerifyOptions{}, nil, "no exp claim"},
+		{"not yet valid", map[string]interface{}{"nbf": unix(time.Hour)}, jwtVerifyOptions{}, []string{"not_before"}, "not valid before 2026-01-01T13:00:00Z"},
+		{"nbf within the leeway", map[string]interface{}{"nbf": unix(30 * time.Second)}, jwtVerifyOptions{leeway: time.Minute}, nil, "valid since"},
+		{"issuer", map[string]interface{}{"iss": "https://auth.test"}, jwtVerifyOptions{issuer: "https://auth.test"}, nil, "https://auth.test"},
+		{"wrong issuer", map[string]interface{}{"iss": "https://evil.test"}, jwtVerifyOptions{issuer: "https://auth.test"}, []string{"issuer"}, `expected "https://auth.test", got "https://evil.test"`},
+		{"audience in a list", map[string]interface{}{"aud": []string{"web", "api"}}, jwtVerifyOptions{audience: "api"}, nil, "api"},
+		{"wrong audience", map[string]interface{}{"aud": "web"}, jwtVerifyOptions{audience: "api"}, []string{"audience"}, `"api" not in aud web`},
+		{
+			"several failures", map[string]interface{}{"exp": unix(-time.Hour), "iss": "x", "aud": "y"},
+			jwtVerifyOptions{issuer: "https://auth.test", audience: "api"},
+			[]string{"expiry", "issuer", "audience"}, `"api" not in aud y`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signTestJWT(t, "HS256", "", tt.claims, secret)
+			tt.opts.secret, tt.opts.now = string(secret), jwtTestNow
+			steps := checkJWT(token, tt.opts)
+			failed, last := failedSteps(steps)
+			if strings.Join(failed, ",") != strings.Join(tt.failed, ",") {
+				t.Fatalf("failed steps = %v, want %v", failed, tt.failed)
+			}
+			detail := last.detail
+			for _, step := range steps {
+				if !step.ok && len(tt.failed) == 1 {
+					detail = step.detail
+				}
+			}
+			if !strings.Contains(detail, tt.detail) {
+				t.Errorf("detail = %q, want it to contain %q", detail, tt.detail)
+			}
+		})
+	}
+}
+
+func TestCheckJWT_Rejected(t *testing.T) {
+	secret := []byte("s3cret")
+	valid := signTestJWT(t, "HS256", "", map[string]interface{}{"sub": "42"}, secret)
+	parts := strings.Split(valid, ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1"}`)) + "." + parts[2]
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+
+	tests := []struct {
+		name   string
+		token  string
+		step   string
+		detail string
+	}{
+		{"two parts", "a.b", "format", "expected 3 dot-separated parts, got 2"},
+		{"header not JSON", "bm90IGpzb24." + parts[1] + "." + parts[2], "format", "header is not base64url-encoded JSON"},
+		{"payload not base64", parts[0] + ".!!." + parts[2], "format", "payload is not base64url-encoded JSON"},
+		{"alg none", none, "algorithm", `alg is "none"`},
+		{"unsupported alg", base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS1"}`)) + "." + parts[1] + "." + parts[2], "algorithm", `unsupported alg "HS1"`},
+		{"tampered payload", tampered, "signature", "HMAC signature mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := checkJWT(tt.token, jwtVerifyOptions{secret: string(secret), now: jwtTestNow})
+			failed, last := failedSteps(steps)
+			if strings.Join(failed, ",") != tt.step || last.name != tt.step {
+				t.Fatalf("failed steps = %v, want only %s as the last step", failed, tt.step)
+			}
+			if !strings.Contains(last.detail, tt.detail) {
+				t.Errorf("detail = %q, want it to contain %q", last.detail, tt.detail)
+			}
+		})
+	}
+
+	// A "Bearer " prefix is accepted
+	if failed, _ := failedSteps(checkJWT("Bearer "+valid, jwtVerifyOptions{secret: string(secret), now: jwtTestNow})); len(failed) > 0 {
+		t.Errorf("Bearer token failed %v", failed)
+	}
+}
+
+func TestCheckJWT_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "enc-key", "use": "enc", "n": b64(rsaKey.N.Bytes()), "e": "AQAB"},
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": "AQAB"},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-384", "x": b64(ecKey.X.FillBytes(make([]byte, 48))), "y": b64(ecKey.Y.FillBytes(make([]byte, 48)))},
+	}})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jwks.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(jwks)
+	}))
+	defer server.Close()
+
+	claims := map[string]interface{}{"sub": "42"}
+	tests := []struct {
+		name   string
+		token  string
+		url    string
+		failed string
+		detail string
+	}{
+		{"RS256 by kid", signTestJWT(t, "RS256", "rsa-1", claims, rsaKey), "/jwks.json", "", `JWKS key "rsa-1" (RSA)`},
+		{"ES384 by kid", signTestJWT(t, "ES384", "ec-1", claims, ecKey), "/jwks.json", "", `JWKS key "ec-1" (EC)`},
+		{"rotated kid", signTestJWT(t, "RS256", "rsa-0", claims, rsaKey), "/jwks.json", "key", `no signing key with kid "rsa-0" in JWKS (available: enc-key, rsa-1, ec-1)`},
+		{"JWKS not found", signTestJWT(t, "RS256", "rsa-1", claims, rsaKey), "/missing", "key", "failed to fetch JWKS: 404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := checkJWT(tt.token, jwtVerifyOptions{jwksURL: server.URL + tt.url, now: jwtTestNow})
+			failed, _ := failedSteps(steps)
+			if strings.Join(failed, ",") != tt.failed {
+				t.Fatalf("failed steps = %v, want %q: %+v", failed, tt.failed, steps)
+			}
+			var detail string
+			for _, step := range steps {
+				if step.name == "key" {
+					detail = step.detail
+				}
+			}
+			if !strings.Contains(detail, tt.detail) {
+				t.Errorf("key step = %q, want it to contain %q", detail, tt.detail)
+			}
+		})
+	}
+}
+
+func TestHelperTool_VerifyJWT(t *testing.T) {
+	tool := NewHelperTool(nil, nil)
+	secret := []byte("s3cret")
+	valid := signTestJWT(t, "HS256", "", map[string]interface{}{"exp": float64(time.Now().Add(time.Hour).Unix())}, secret)
+	expired := signTestJWT(t, "HS256", "", map[string]interface{}{"exp": float64(time.Now().Add(-time.Hour).Unix())}, secret)
+
+	got, err := tool.Execute(`{"action": "verify_jwt", "token": "` + valid + `", "secret": "s3cret"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(got, "JWT Verification: VALID") || !strings.Contains(got, "✓ signature: valid HS256 signature") {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	got, err = tool.Execute(`{"action": "verify_jwt", "token": "` + expired + `", "secret": "s3cret"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(got, "JWT Verification: INVALID (failed: expiry)") || !strings.Contains(got, "✗ expiry: expired at") {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	got, err = tool.Execute(`{"action": "verify_jwt", "token": "` + expired + `", "secret": "s3cret", "leeway_seconds": 7200}`)
+	if err != nil || !strings.HasPrefix(got, "JWT Verification: VALID") {
+		t.Errorf("with leeway: %v\n%s", err, got)
+	}
+
+	got, err = tool.Execute(`{"action": "parse_jwt", "token": "` + valid + `"}`)
+	if err != nil || !strings.Contains(got, `"alg": "HS256"`) {
+		t.Errorf("parse_jwt: %v\n%s", err, got)
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core/tools"
+)
+
+// LoginFlowTool logs in through an HTML (or JSON) login form in one step:
+// GET the form, extract the CSRF token, POST the credentials and save the
+// session cookie. Flows can be saved per project and reused by test suites.
+type LoginFlowTool struct {
+	httpTool *tools.HTTPTool
+	varStore *tools.VariableStore
+	zapDir   string
+}
+
+// NewLoginFlowTool creates a new login flow tool.
+func NewLoginFlowTool(httpTool *tools.HTTPTool, varStore *tools.VariableStore, zapDir string) *LoginFlowTool {
+	return &LoginFlowTool{httpTool: httpTool, varStore: varStore, zapDir: zapDir}
+}
+
+// LoginFlowParams defines the parameters for a login flow.
+type LoginFlowParams struct {
+	tools.LoginFlow
+	// Flow runs a saved flow from .zap/login_flows/
+	Flow string `json:"flow,omitempty"`
+	// Save stores the flow under Name (with its {{VAR}} placeholders) before running it
+	Save bool `json:"save,omitempty"`
+}
+
+// Name returns the tool name.
+func (t *LoginFlowTool) Name() string {
+	return "login_flow"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *LoginFlowTool) Description() string {
+	return "Log in through a login form in one step: GET the form page, extract the CSRF token (hidden input, meta tag, cookie or regex), POST the credentials and save the session cookie as {{session}} for the Cookie header. Save flows with 'name' + 'save' and rerun them with 'flow' (test_suite 'login' also runs them)."
+}
+
+// Parameters returns an example of the JSON parameters this tool accepts.
+func (t *LoginFlowTool) Parameters() string {
+	return `{
+  "form_url": "http://localhost:8000/login",
+  "fields": {"username": "{{USERNAME}}", "password": "{{PASSWORD}}"},
+  "csrf_field": "csrfmiddlewaretoken (optional, detected)",
+  "session_cookie": "sessionid (optional)",
+  "save_as": "session",
+  "name": "admin",
+  "save": true
+}
+or {"flow": "admin"} to run a saved flow`
+}
+
+// Execute runs (and optionally saves) a login flow.
+func (t *LoginFlowTool) Execute(args string) (string, error) {
+	// Parse before substitution so saved flows keep their placeholders
+	var params LoginFlowParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	flow := params.LoginFlow
+	var saved string
+	switch {
+	case params.Flow != "":
+		loaded, err := tools.LoadLoginFlow(t.zapDir, params.Flow)
+		if err != nil {
+			if names := tools.ListLoginFlows(t.zapDir); len(names) > 0 {
+				return "", fmt.Errorf("%w (saved flows: %s)", err, strings.Join(names, ", "))
+			}
+			return "", err
+		}
+		flow = *loaded
+	case params.Save:
+		if flow.FormURL == "" {
+			return "", fmt.Errorf("'form_url' is required")
+		}
+		path, err := tools.SaveLoginFlow(t.zapDir, flow)
+		if err != nil {
+			return "", err
+		}
+		saved = path
+	}
+
+	result := tools.RunLoginFlow(t.httpTool, t.varStore, flow)
+
+	var sb strings.Builder
+	if failed := result.Failed(); failed != nil {
+		sb.WriteString(fmt.Sprintf("Login FAILED at step '%s'\n\n", failed.Name))
+	} else {
+		sb.WriteString("Login succeeded\n\n")
+	}
+	sb.WriteString(result.Format())
+	if saved != "" {
+		sb.WriteString(fmt.Sprintf("\nSaved flow '%s' to %s - rerun with {\"flow\": \"%s\"} or test_suite \"login\": \"%s\"", flow.Name, saved, flow.Name, flow.Name))
+	}
+	if result.Failed() == nil {
+		saveAs := flow.SaveAs
+		if saveAs == "" {
+			saveAs = tools.DefaultLoginSaveAs
+		}
+		sb.WriteString(fmt.Sprintf("\nUse: {\"headers\": {\"Cookie\": \"{{%s}}\"}}", saveAs))
+	}
+	return sb.String(), nil
+}
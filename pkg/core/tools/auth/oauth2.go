@@ -2,27 +2,43 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
 	"strings"
+	"time"
 
+	"github.com/blackcoderx/zap/pkg/core"
 	"github.com/blackcoderx/zap/pkg/core/tools"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 )
 
 // OAuth2Tool handles OAuth2 authentication flows.
-// It supports client_credentials and password grant types, obtaining access tokens
-// and automatically saving them as variables for use in subsequent requests.
+// It supports client_credentials, password and authorization_code (with PKCE)
+// grant types, obtaining access tokens and automatically saving them as
+// variables for use in subsequent requests.
 type OAuth2Tool struct {
-	varStore *tools.VariableStore
+	varStore      *tools.VariableStore
+	eventCallback core.EventCallback
+	openBrowser   func(rawURL string) error
 }
 
 // NewOAuth2Tool creates a new OAuth2 auth tool with the given variable store.
 func NewOAuth2Tool(varStore *tools.VariableStore) *OAuth2Tool {
-	return &OAuth2Tool{varStore: varStore}
+	return &OAuth2Tool{varStore: varStore, openBrowser: openBrowser}
 }
 
+// defaultCallbackTimeout is how long authorization_code waits for the user
+const defaultCallbackTimeout = 2 * time.Minute
+
+// callbackPage is shown in the browser once the redirect arrives
+const callbackPage = `<html><body style="font-family: sans-serif"><h3>ZAP: authorization received</h3><p>You can close this tab and return to the terminal.</p></body></html>`
+
 // OAuth2Params defines the parameters for OAuth2 authentication.
 type OAuth2Params struct {
 	// Flow specifies the OAuth2 grant type: "client_credentials", "password"
@@ -39,12 +55,17 @@ type OAuth2Params struct {
 	Username string `json:"username,omitempty"`
 	// Password is required for password flow
 	Password string `json:"password,omitempty"`
-	// AuthURL is for authorization_code flow (not supported in CLI mode)
+	// AuthURL is the authorization endpoint for authorization_code flow
 	AuthURL string `json:"auth_url,omitempty"`
-	// RedirectURL is for authorization_code flow (not supported in CLI mode)
+	// RedirectURL is the registered http://localhost callback for authorization_code
+	// flow (a free port and /callback when empty)
 	RedirectURL string `json:"redirect_url,omitempty"`
-	// Code is for authorization_code flow (not supported in CLI mode)
+	// Code skips the browser step and exchanges an authorization code directly
 	Code string `json:"code,omitempty"`
+	// CodeVerifier is the PKCE verifier that goes with a manually supplied code
+	CodeVerifier string `json:"code_verifier,omitempty"`
+	// TimeoutSeconds is how long to wait for the browser callback (default 120)
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
 	// SaveTokenAs is the variable name to save the access token
 	SaveTokenAs string `json:"save_token_as,omitempty"`
 }
@@ -56,7 +77,13 @@ func (t *OAuth2Tool) Name() string {
 
 // Description returns a human-readable description of the tool.
 func (t *OAuth2Tool) Description() string {
-	return "Perform OAuth2 authentication flows (client_credentials, password). Obtains access token and saves to variable."
+	return "Perform OAuth2 authentication flows (client_credentials, password, authorization_code with PKCE). authorization_code opens the browser and waits for the localhost redirect. Obtains access token and saves to variable."
+}
+
+// SetEventCallback sets the callback used to show the authorization URL.
+// This implements the ConfirmableTool interface.
+func (t *OAuth2Tool) SetEventCallback(callback core.EventCallback) {
+	t.eventCallback = callback
 }
 
 // Parameters returns an example of the JSON parameters this tool accepts.
@@ -68,6 +95,17 @@ func (t *OAuth2Tool) Parameters() string {
   "client_secret": "{{CLIENT_SECRET}}",
   "scopes": ["api:read", "api:write"],
   "save_token_as": "oauth_token"
+}
+
+authorization_code (browser login, PKCE; client_secret optional):
+{
+  "flow": "authorization_code",
+  "auth_url": "https://auth.example.com/authorize",
+  "token_url": "https://auth.example.com/token",
+  "client_id": "{{CLIENT_ID}}",
+  "redirect_url": "http://localhost:8085/callback",
+  "scopes": ["openid", "profile"],
+  "save_token_as": "oauth_token"
 }`
 }
 
@@ -75,6 +113,7 @@ func (t *OAuth2Tool) Parameters() string {
 // Supported flows:
 //   - client_credentials: Server-to-server authentication using client ID and secret
 //   - password: User authentication using username and password (Resource Owner Password Credentials)
+//   - authorization_code: Browser login with a localhost redirect, secured with PKCE
 func (t *OAuth2Tool) Execute(args string) (string, error) {
 	// Substitute variables in args
 	if t.varStore != nil {
@@ -93,7 +132,8 @@ func (t *OAuth2Tool) Execute(args string) (string, error) {
 	if params.ClientID == "" {
 		return "", fmt.Errorf("'client_id' parameter is required")
 	}
-	if params.ClientSecret == "" {
+	// Public clients using PKCE have no secret
+	if params.ClientSecret == "" && params.Flow != "authorization_code" {
 		return "", fmt.Errorf("'client_secret' parameter is required")
 	}
 
@@ -104,9 +144,9 @@ func (t *OAuth2Tool) Execute(args string) (string, error) {
 	case "password":
 		return t.passwordFlow(params)
 	case "authorization_code":
-		return "", fmt.Errorf("authorization_code flow requires manual browser interaction and is not supported in CLI mode. Use 'client_credentials' or 'password' flows instead")
+		return t.authorizationCodeFlow(params)
 	default:
-		return "", fmt.Errorf("unknown flow '%s' (supported: client_credentials, password)", params.Flow)
+		return "", fmt.Errorf("unknown flow '%s' (supported: client_credentials, password, authorization_code)", params.Flow)
 	}
 }
 
@@ -159,6 +199,121 @@ func (t *OAuth2Tool) passwordFlow(params OAuth2Params) (string, error) {
 	return t.formatTokenResponse(token, params)
 }
 
+// authorizationCodeFlow performs OAuth2 authorization code flow with PKCE.
+// The user logs in through the browser, the provider redirects to a temporary
+// localhost listener, and the code is exchanged together with the PKCE verifier.
+func (t *OAuth2Tool) authorizationCodeFlow(params OAuth2Params) (string, error) {
+	if params.AuthURL == "" && params.Code == "" {
+		return "", fmt.Errorf("'auth_url' parameter is required for authorization_code flow")
+	}
+
+	config := oauth2.Config{
+		ClientID:     params.ClientID,
+		ClientSecret: params.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  params.AuthURL,
+			TokenURL: params.TokenURL,
+		},
+		RedirectURL: params.RedirectURL,
+		Scopes:      params.Scopes,
+	}
+	ctx := context.Background()
+
+	// A code obtained elsewhere is exchanged directly
+	if params.Code != "" {
+		var opts []oauth2.AuthCodeOption
+		if params.CodeVerifier != "" {
+			opts = append(opts, oauth2.VerifierOption(params.CodeVerifier))
+		}
+		token, err := config.Exchange(ctx, params.Code, opts...)
+		if err != nil {
+			return "", fmt.Errorf("OAuth2 authorization_code exchange failed: %w", err)
+		}
+		return t.formatTokenResponse(token, params)
+	}
+
+	listener, err := tools.StartCallbackListener(params.RedirectURL, callbackPage)
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	config.RedirectURL = listener.URL
+	if params.RedirectURL != "" {
+		// Keep the registered form (e.g. 127.0.0.1 vs localhost) for exact matching
+		config.RedirectURL = params.RedirectURL
+	}
+
+	verifier := oauth2.GenerateVerifier()
+	state := randomState()
+	authURL := config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+
+	notice := fmt.Sprintf("Waiting for OAuth2 login. Opened the browser at:\n%s", authURL)
+	if err := t.openBrowser(authURL); err != nil {
+		notice = fmt.Sprintf("Could not open a browser (%v). Open this URL to log in:\n%s", err, authURL)
+	}
+	if t.eventCallback != nil {
+		t.eventCallback(core.AgentEvent{Type: "notice", Content: notice})
+	}
+
+	timeout := defaultCallbackTimeout
+	if params.TimeoutSeconds > 0 {
+		timeout = time.Duration(params.TimeoutSeconds) * time.Second
+	}
+	callback, err := listener.Wait(timeout)
+	if err != nil {
+		return "", fmt.Errorf("OAuth2 authorization_code flow failed: %w. Authorization URL was: %s", err, authURL)
+	}
+
+	query, err := url.ParseQuery(callback.Query)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse callback query: %w", err)
+	}
+	if errCode := query.Get("error"); errCode != "" {
+		if desc := query.Get("error_description"); desc != "" {
+			errCode += ": " + desc
+		}
+		return "", fmt.Errorf("authorization server returned an error: %s", errCode)
+	}
+	if query.Get("state") != state {
+		return "", fmt.Errorf("callback state does not match the request (possible CSRF or stale browser tab)")
+	}
+	code := query.Get("code")
+	if code == "" {
+		return "", fmt.Errorf("callback did not include an authorization code")
+	}
+
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return "", fmt.Errorf("OAuth2 authorization_code exchange failed: %w", err)
+	}
+	return t.formatTokenResponse(token, params)
+}
+
+// randomState returns an unguessable OAuth2 state value
+func randomState() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// openBrowser opens rawURL in the user's default browser
+func openBrowser(rawURL string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", rawURL)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", rawURL)
+	default:
+		cmd = exec.Command("xdg-open", rawURL)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
 // formatTokenResponse formats the OAuth2 token response and saves it to variables.
 // If save_token_as is specified, both the raw token and a Bearer header are saved.
 func (t *OAuth2Tool) formatTokenResponse(token *oauth2.Token, params OAuth2Params) (string, error) {
@@ -176,16 +331,31 @@ func (t *OAuth2Tool) formatTokenResponse(token *oauth2.Token, params OAuth2Param
 		sb.WriteString(fmt.Sprintf("Expires: %s\n", token.Expiry.Format("2006-01-02 15:04:05")))
 	}
 
-	// Save token to variable if requested
+	// Save token to variable if requested. The refresh token and expiry are
+	// kept too, so http_request can renew the token when it runs out.
 	if params.SaveTokenAs != "" && t.varStore != nil {
-		t.varStore.Set(params.SaveTokenAs, token.AccessToken)
+		t.varStore.SetToken(tools.OAuthToken{
+			Variable:     params.SaveTokenAs,
+			AccessToken:  token.AccessToken,
+			RefreshToken: token.RefreshToken,
+			Expiry:       token.Expiry,
+			Flow:         params.Flow,
+			TokenURL:     params.TokenURL,
+			ClientID:     params.ClientID,
+			ClientSecret: params.ClientSecret,
+			Scopes:       params.Scopes,
+		})
 		sb.WriteString(fmt.Sprintf("\nToken saved as: {{%s}}\n", params.SaveTokenAs))
 
-		// Also save as Bearer header for convenience
+		// Also saved as Bearer header for convenience
 		authHeaderVar := params.SaveTokenAs + "_header"
-		bearerHeader := fmt.Sprintf("Bearer %s", token.AccessToken)
-		t.varStore.Set(authHeaderVar, bearerHeader)
 		sb.WriteString(fmt.Sprintf("Bearer header saved as: {{%s}}\n", authHeaderVar))
+		if token.RefreshToken != "" {
+			sb.WriteString(fmt.Sprintf("Refresh token saved as: {{%s_refresh_token}}\n", params.SaveTokenAs))
+		}
+		if token.RefreshToken != "" || params.Flow == "client_credentials" {
+			sb.WriteString("The token is renewed automatically when it expires or a request gets a 401.\n")
+		}
 
 		sb.WriteString("\nUse in requests:\n")
 		sb.WriteString("{\n")
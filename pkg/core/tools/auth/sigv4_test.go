@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blackcoderx/zap/pkg/core/tools"
+)
+
+func TestAWSSigV4Tool(t *testing.T) {
+	var authorization, amzDate string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization, amzDate = r.Header.Get("Authorization"), r.Header.Get("X-Amz-Date")
+	}))
+	defer server.Close()
+
+	httpTool := tools.NewHTTPTool(tools.NewResponseManager(), nil)
+	tool := NewAWSSigV4Tool(httpTool, nil)
+
+	got, err := tool.Execute(`{"service": "execute-api", "region": "eu-west-1", "access_key_id": "AKIDEXAMPLE", "secret_access_key": "secret"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(got, "Access Key: *******MPLE") || strings.Contains(got, "secret") {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	if _, err := httpTool.Execute(`{"method": "GET", "url": "` + server.URL + `/items"}`); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(authorization, "/eu-west-1/execute-api/aws4_request") || amzDate == "" {
+		t.Errorf("request not signed: Authorization = %q, X-Amz-Date = %q", authorization, amzDate)
+	}
+
+	if _, err := tool.Execute(`{"disable": true}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	authorization = ""
+	if _, err := httpTool.Execute(`{"method": "GET", "url": "` + server.URL + `/items"}`); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if authorization != "" {
+		t.Errorf("request signed after disable: %q", authorization)
+	}
+}
+
+func TestAWSSigV4Tool_OtherHost(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	httpTool := tools.NewHTTPTool(tools.NewResponseManager(), nil)
+	tool := NewAWSSigV4Tool(httpTool, nil)
+	if _, err := tool.Execute(`{"service": "s3", "region": "us-east-1", "access_key_id": "a", "secret_access_key": "b", "host": "bucket.s3.amazonaws.com"}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := httpTool.Execute(`{"method": "GET", "url": "` + server.URL + `"}`); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if authorization != "" {
+		t.Errorf("request to another host was signed: %q", authorization)
+	}
+}
+
+func TestAWSSigV4Tool_MissingSettings(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	tool := NewAWSSigV4Tool(tools.NewHTTPTool(tools.NewResponseManager(), nil), nil)
+
+	tests := []struct {
+		args   string
+		errMsg string
+	}{
+		{`{"service": "s3", "region": "us-east-1"}`, "AWS credentials missing"},
+		{`{"service": "s3", "access_key_id": "a", "secret_access_key": "b"}`, "AWS region missing"},
+		{`{"region": "us-east-1", "access_key_id": "a", "secret_access_key": "b"}`, "AWS service missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.errMsg, func(t *testing.T) {
+			_, err := tool.Execute(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
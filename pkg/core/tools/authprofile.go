@@ -0,0 +1,226 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/storage"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Auth profile types
+const (
+	AuthProfileBearer = "bearer"
+	AuthProfileBasic  = "basic"
+	AuthProfileAPIKey = "api_key"
+	AuthProfileOAuth2 = "oauth2"
+)
+
+// UseAuthNone disables a suite's default profile for one request
+const UseAuthNone = "none"
+
+// defaultAPIKeyHeader is the api_key header when a profile doesn't set one
+const defaultAPIKeyHeader = "X-API-Key"
+
+// SetAuthProfiles makes the auth profiles of environment env available to
+// use_auth. vars are the environment's variables, checked before session and
+// global variables when a profile names a secret variable.
+func (t *HTTPTool) SetAuthProfiles(env string, profiles map[string]storage.AuthProfile, vars map[string]string) {
+	t.authMu.Lock()
+	defer t.authMu.Unlock()
+	t.authEnv = env
+	t.authProfiles = profiles
+	t.authVars = vars
+}
+
+// AuthProfileNames returns the profiles of the active environment, sorted
+func (t *HTTPTool) AuthProfileNames() []string {
+	t.authMu.Lock()
+	defer t.authMu.Unlock()
+	names := make([]string, 0, len(t.authProfiles))
+	for name := range t.authProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyAuthProfile sets the credentials of the request's use_auth profile.
+// OAuth2 tokens are fetched once per profile and saved as {{auth_<name>}},
+// so Run renews them like any other saved token.
+func (t *HTTPTool) applyAuthProfile(req HTTPRequest) (HTTPRequest, string, error) {
+	if req.UseAuth == "" || req.UseAuth == UseAuthNone {
+		return req, "", nil
+	}
+
+	t.authMu.Lock()
+	env, vars := t.authEnv, t.authVars
+	profile, ok := t.authProfiles[req.UseAuth]
+	t.authMu.Unlock()
+	if !ok {
+		if env == "" {
+			return req, "", fmt.Errorf("auth profile '%s' not found: no environment is active (use set_environment; profiles are defined under 'auth:' in the environment file)", req.UseAuth)
+		}
+		return req, "", fmt.Errorf("auth profile '%s' not found in environment '%s' (available: %s)", req.UseAuth, env, strings.Join(t.AuthProfileNames(), ", "))
+	}
+
+	lookup := func(field, name string) (string, error) {
+		if name == "" {
+			return "", fmt.Errorf("auth profile '%s': '%s' is required", req.UseAuth, field)
+		}
+		if value, ok := vars[name]; ok && value != "" {
+			return value, nil
+		}
+		if t.varStore != nil {
+			if value, ok := t.varStore.Get(name); ok && value != "" {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("auth profile '%s': variable '%s' (%s) is not set", req.UseAuth, name, field)
+	}
+
+	var header, value string
+	switch profile.Type {
+	case AuthProfileBearer:
+		token, err := lookup("token_var", profile.TokenVar)
+		if err != nil {
+			return req, "", err
+		}
+		header, value = "Authorization", "Bearer "+token
+	case AuthProfileBasic:
+		username, err := profileUsername(profile, lookup)
+		if err != nil {
+			return req, "", err
+		}
+		password, err := lookup("password_var", profile.PasswordVar)
+		if err != nil {
+			return req, "", err
+		}
+		header, value = "Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	case AuthProfileAPIKey:
+		key, err := lookup("value_var", profile.ValueVar)
+		if err != nil {
+			return req, "", err
+		}
+		header, value = profile.Header, key
+		if header == "" {
+			header = defaultAPIKeyHeader
+		}
+	case AuthProfileOAuth2:
+		token, err := t.profileToken(req.UseAuth, profile, lookup)
+		if err != nil {
+			return req, "", err
+		}
+		header, value = "Authorization", "Bearer "+token
+	default:
+		return req, "", fmt.Errorf("auth profile '%s': unknown type '%s' (use bearer, basic, api_key or oauth2)", req.UseAuth, profile.Type)
+	}
+
+	headers := make(map[string]string, len(req.Headers)+1)
+	for key, v := range req.Headers {
+		headers[key] = v
+	}
+	headers[header] = value
+	req.Headers = headers
+	return req, fmt.Sprintf("profile '%s' (%s, environment %s)", req.UseAuth, profile.Type, env), nil
+}
+
+// profileUsername returns the username of a basic or password-flow profile
+func profileUsername(profile storage.AuthProfile, lookup func(field, name string) (string, error)) (string, error) {
+	if profile.Username != "" {
+		return profile.Username, nil
+	}
+	return lookup("username_var", profile.UsernameVar)
+}
+
+// profileToken returns a valid access token for an oauth2 profile, fetching
+// one when there is no saved token from the same client
+func (t *HTTPTool) profileToken(name string, profile storage.AuthProfile, lookup func(field, name string) (string, error)) (string, error) {
+	if profile.TokenURL == "" || profile.ClientID == "" {
+		return "", fmt.Errorf("auth profile '%s': 'token_url' and 'client_id' are required", name)
+	}
+	secret := ""
+	if profile.ClientSecretVar != "" {
+		var err error
+		if secret, err = lookup("client_secret_var", profile.ClientSecretVar); err != nil {
+			return "", err
+		}
+	}
+
+	variable := "auth_" + name
+	if t.varStore != nil {
+		// A token of another environment's client must not be reused
+		if tok := t.varStore.token(variable); tok != nil && tok.TokenURL == profile.TokenURL &&
+			tok.ClientID == profile.ClientID && tok.ClientSecret == secret {
+			if !tok.expired() {
+				return tok.AccessToken, nil
+			}
+			if tok.renewable() {
+				renewed, err := t.varStore.renewToken(tok)
+				if err != nil {
+					return "", err
+				}
+				return renewed.AccessToken, nil
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultHTTPTimeout)
+	defer cancel()
+
+	flow := profile.Flow
+	if flow == "" {
+		flow = "client_credentials"
+	}
+	var token *oauth2.Token
+	var err error
+	switch flow {
+	case "client_credentials":
+		config := clientcredentials.Config{
+			ClientID:     profile.ClientID,
+			ClientSecret: secret,
+			TokenURL:     profile.TokenURL,
+			Scopes:       profile.Scopes,
+		}
+		token, err = config.Token(ctx)
+	case "password":
+		var username, password string
+		if username, err = profileUsername(profile, lookup); err != nil {
+			return "", err
+		}
+		if password, err = lookup("password_var", profile.PasswordVar); err != nil {
+			return "", err
+		}
+		config := oauth2.Config{
+			ClientID:     profile.ClientID,
+			ClientSecret: secret,
+			Endpoint:     oauth2.Endpoint{TokenURL: profile.TokenURL},
+			Scopes:       profile.Scopes,
+		}
+		token, err = config.PasswordCredentialsToken(ctx, username, password)
+	default:
+		return "", fmt.Errorf("auth profile '%s': unsupported oauth2 flow '%s' (use client_credentials or password)", name, flow)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get token for auth profile '%s': %w", name, err)
+	}
+
+	if t.varStore != nil {
+		t.varStore.SetToken(OAuthToken{
+			Variable:     variable,
+			AccessToken:  token.AccessToken,
+			RefreshToken: token.RefreshToken,
+			Expiry:       token.Expiry,
+			Flow:         flow,
+			TokenURL:     profile.TokenURL,
+			ClientID:     profile.ClientID,
+			ClientSecret: secret,
+			Scopes:       profile.Scopes,
+		})
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Response body limits
+const (
+	DefaultMaxBodySize     = 10 << 20  // Bytes kept in memory per response
+	DefaultMaxDownloadSize = 100 << 20 // Bytes written by save_body_to
+	binaryPreviewSize      = 256       // Bytes shown in the hexdump of binary bodies
+)
+
+// acceptEncoding is sent unless the request sets its own Accept-Encoding
+const acceptEncoding = "gzip, deflate, br"
+
+// bodyResult is a response body read by readResponseBody
+type bodyResult struct {
+	data      []byte // In-memory body (or its head when saved to a file)
+	size      int64  // Total bytes received
+	truncated bool   // The size cap was hit
+	binary    bool
+	file      string // Absolute path when saved
+}
+
+// readBodyFile loads a request body from a file within the project and
+// guesses its Content-Type. {{VAR}} placeholders in text files are substituted.
+func (t *HTTPTool) readBodyFile(name string) ([]byte, string, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	path, err := ValidatePathWithinWorkDir(name, workDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid body_file: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read body_file: %w", err)
+	}
+	if info.Size() > DefaultMaxDownloadSize {
+		return nil, "", fmt.Errorf("body_file is too large (%s, max %s)", formatSize(int(info.Size())), formatSize(DefaultMaxDownloadSize))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read body_file: %w", err)
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	if !isBinaryBody(contentType, data) && t.varStore != nil {
+		data = []byte(t.varStore.Substitute(string(data)))
+	}
+	return data, contentType, nil
+}
+
+// readResponseBody reads a response body, keeping at most maxSize bytes in
+// memory. With saveTo set, the body is streamed to that file (capped at
+// maxDownload) and only its head is kept in memory.
+func readResponseBody(resp *http.Response, maxSize, maxDownload int64, saveTo string) (*bodyResult, error) {
+	result := &bodyResult{}
+
+	if saveTo == "" {
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		if int64(len(data)) > maxSize {
+			data = data[:maxSize]
+			result.truncated = true
+		}
+		result.data = data
+		result.size = int64(len(data))
+		result.binary = isBinaryBody(resp.Header.Get("Content-Type"), data)
+		return result, nil
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	path, err := ValidatePathWithinWorkDir(saveTo, workDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid save_body_to: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", saveTo, err)
+	}
+	defer file.Close()
+
+	// Stream to disk while keeping the head in memory for assertions/preview
+	head := &headBuffer{max: maxSize}
+	written, err := io.Copy(file, io.TeeReader(io.LimitReader(resp.Body, maxDownload+1), head))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download response: %w", err)
+	}
+	if written > maxDownload {
+		if err := file.Truncate(maxDownload); err != nil {
+			return nil, fmt.Errorf("failed to truncate %s: %w", saveTo, err)
+		}
+		written = maxDownload
+		result.truncated = true
+	}
+
+	result.data = head.Bytes()
+	result.size = written
+	result.file = path
+	result.binary = isBinaryBody(resp.Header.Get("Content-Type"), result.data)
+	return result, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// contentDecoding describes how a response body was decoded
+type contentDecoding struct {
+	encoding string          // Content-Encoding, lower-cased ("" when not encoded)
+	decoded  bool            // Body was decompressed
+	wire     *countingReader // Counts the encoded bytes received
+}
+
+// decodeResponseBody replaces resp.Body with a reader that decompresses
+// gzip, deflate and brotli bodies. Other encodings (zstd, ...) are left
+// as-is and reported with decoded=false.
+func decodeResponseBody(resp *http.Response) (*contentDecoding, error) {
+	result := &contentDecoding{
+		encoding: strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))),
+	}
+	if result.encoding == "" || result.encoding == "identity" {
+		result.encoding = ""
+		return result, nil
+	}
+	switch result.encoding {
+	case "gzip", "x-gzip", "deflate", "br":
+	default:
+		return result, nil
+	}
+
+	result.wire = &countingReader{r: resp.Body}
+	buffered := bufio.NewReader(result.wire)
+
+	// HEAD requests and 204/304 responses carry the header without a body
+	header, err := buffered.Peek(2)
+	if len(header) == 0 {
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		result.decoded = true
+		return result, nil
+	}
+
+	var decoder io.ReadCloser
+	switch {
+	case result.encoding == "br":
+		decoder = io.NopCloser(brotli.NewReader(buffered))
+	case result.encoding != "deflate":
+		decoder, err = gzip.NewReader(buffered)
+	case len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0:
+		// "deflate" is meant to be zlib-wrapped, but some servers send raw DEFLATE
+		decoder, err = zlib.NewReader(buffered)
+	default:
+		decoder = flate.NewReader(buffered)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s response: %w", result.encoding, err)
+	}
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{decoder, resp.Body}
+	result.decoded = true
+	return result, nil
+}
+
+// headBuffer keeps the first max bytes written to it and discards the rest
+type headBuffer struct {
+	bytes.Buffer
+	max int64
+}
+
+func (b *headBuffer) Write(p []byte) (int, error) {
+	if room := b.max - int64(b.Len()); room > 0 {
+		if int64(len(p)) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// isBinaryBody decides from the Content-Type (or by sniffing when it is
+// missing or generic) whether a body is binary rather than text.
+func isBinaryBody(contentType string, data []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.Contains(mediaType, "json"),
+		strings.Contains(mediaType, "xml"),
+		strings.Contains(mediaType, "javascript"),
+		strings.Contains(mediaType, "yaml"),
+		mediaType == "application/x-www-form-urlencoded",
+		mediaType == "application/graphql":
+		return false
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "font/"),
+		mediaType == "application/pdf",
+		mediaType == "application/zip",
+		mediaType == "application/gzip",
+		mediaType == "application/octet-stream":
+		return true
+	}
+
+	// Unknown types: treat valid UTF-8 without NUL bytes as text
+	return !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0
+}
+
+// formatBinaryPreview renders a hexdump of the start of a binary body
+func formatBinaryPreview(data []byte) string {
+	if len(data) > binaryPreviewSize {
+		data = data[:binaryPreviewSize]
+	}
+	return hex.Dump(data)
+}
@@ -0,0 +1,445 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/storage"
+)
+
+// bruBlock is one top-level block of a .bru file, e.g. "headers { ... }"
+type bruBlock struct {
+	Name  string    // e.g. "get", "headers", "body:json", "auth:bearer", "vars:secret"
+	Pairs []bruPair // Dictionary blocks
+	Items []string  // List blocks: vars:secret [ ... ]
+	Text  string    // Text blocks: body:json, body:text, script:*, tests, docs
+}
+
+// bruPair is a "key: value" line of a dictionary block; "~key" is disabled
+type bruPair struct {
+	Key      string
+	Value    string
+	Disabled bool
+}
+
+// bruFile is a parsed .bru file
+type bruFile []bruBlock
+
+// bruMethods are the blocks naming a request's method
+var bruMethods = []string{"get", "post", "put", "patch", "delete", "options", "head"}
+
+// brunoVarPattern matches Bruno variables, including {{process.env.NAME}}
+var brunoVarPattern = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// brunoPathParamPattern matches :name path parameters in a Bruno URL
+var brunoPathParamPattern = regexp.MustCompile(`/:([A-Za-z0-9_\-]+)`)
+
+// parseBru parses the .bru format: blocks opened by "name {" or "name [" at
+// the start of a line and closed by "}" or "]" at the start of a line, with
+// two-space indented content.
+func parseBru(data []byte) (bruFile, error) {
+	var file bruFile
+	var current *bruBlock
+	var closer string
+	var lines []string
+
+	for i, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if current == nil {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" {
+				continue
+			}
+			name, open, ok := strings.Cut(trimmed, " ")
+			open = strings.TrimSpace(open)
+			if !ok || (open != "{" && open != "[") {
+				return nil, fmt.Errorf("line %d: expected a block like 'headers {', got %q", i+1, trimmed)
+			}
+			current = &bruBlock{Name: name}
+			closer = map[string]string{"{": "}", "[": "]"}[open]
+			lines = nil
+			continue
+		}
+
+		if strings.TrimRight(line, " \t") == closer {
+			current.fill(lines, closer == "]")
+			file = append(file, *current)
+			current = nil
+			continue
+		}
+		lines = append(lines, strings.TrimPrefix(line, "  "))
+	}
+	if current != nil {
+		return nil, fmt.Errorf("block '%s' is not closed", current.Name)
+	}
+	return file, nil
+}
+
+// fill sets a block's content from its dedented lines
+func (b *bruBlock) fill(lines []string, list bool) {
+	switch {
+	case list:
+		for _, line := range lines {
+			if item := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), ",")); item != "" {
+				b.Items = append(b.Items, item)
+			}
+		}
+	case isBruTextBlock(b.Name):
+		b.Text = strings.TrimSpace(strings.Join(lines, "\n"))
+	default:
+		for _, line := range lines {
+			key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+			if !ok || key == "" {
+				continue
+			}
+			pair := bruPair{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)}
+			if strings.HasPrefix(pair.Key, "~") {
+				pair.Key, pair.Disabled = strings.TrimPrefix(pair.Key, "~"), true
+			}
+			b.Pairs = append(b.Pairs, pair)
+		}
+	}
+}
+
+// isBruTextBlock reports whether a block holds free text rather than key: value pairs
+func isBruTextBlock(name string) bool {
+	if name == "body:form-urlencoded" || name == "body:multipart-form" {
+		return false
+	}
+	return strings.HasPrefix(name, "body") || strings.HasPrefix(name, "script") || name == "tests" || name == "docs"
+}
+
+// block returns the named block, or nil
+func (f bruFile) block(name string) *bruBlock {
+	for i := range f {
+		if f[i].Name == name {
+			return &f[i]
+		}
+	}
+	return nil
+}
+
+// value returns the value of an enabled key in the named block
+func (f bruFile) value(block, key string) string {
+	if b := f.block(block); b != nil {
+		for _, pair := range b.Pairs {
+			if pair.Key == key && !pair.Disabled {
+				return pair.Value
+			}
+		}
+	}
+	return ""
+}
+
+// bruInherited holds the headers and auth a folder.bru or collection.bru
+// passes down to requests with "auth: inherit"
+type bruInherited struct {
+	headers map[string]string
+	auth    bruFile
+}
+
+// LoadBrunoCollection reads a Bruno collection folder (the one holding
+// bruno.json): every .bru request in it and its subfolders, and the
+// environments in environments/*.bru. Subfolders become request folders.
+func LoadBrunoCollection(dir string) (*ImportedCollection, error) {
+	if _, err := os.Stat(filepath.Join(dir, "bruno.json")); err != nil {
+		return nil, fmt.Errorf("not a Bruno collection: %s has no bruno.json", dir)
+	}
+
+	c := &ImportedCollection{Environments: make(map[string]map[string]string)}
+	if err := loadBrunoFolder(dir, "", bruInherited{headers: map[string]string{}}, c); err != nil {
+		return nil, err
+	}
+
+	envFiles, _ := filepath.Glob(filepath.Join(dir, "environments", "*.bru"))
+	for _, path := range envFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read environment: %w", err)
+		}
+		file, err := parseBru(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+		}
+
+		vars := make(map[string]string)
+		if b := file.block("vars"); b != nil {
+			for _, pair := range b.Pairs {
+				if !pair.Disabled {
+					vars[importVarName(pair.Key)] = convertBrunoVars(pair.Value)
+				}
+			}
+		}
+		if b := file.block("vars:secret"); b != nil {
+			for _, name := range b.Items {
+				name = importVarName(strings.TrimPrefix(name, "~"))
+				if !slices.Contains(c.SecretVars, name) {
+					c.SecretVars = append(c.SecretVars, name)
+				}
+			}
+		}
+		c.Environments[strings.TrimSuffix(filepath.Base(path), ".bru")] = vars
+	}
+	sort.Strings(c.SecretVars)
+	return c, nil
+}
+
+// loadBrunoFolder adds the requests of one folder, ordered by their seq,
+// then those of its subfolders
+func loadBrunoFolder(dir, folder string, inherited bruInherited, c *ImportedCollection) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	for _, settings := range []string{"collection.bru", "folder.bru"} {
+		data, err := os.ReadFile(filepath.Join(dir, settings))
+		if err != nil {
+			continue
+		}
+		file, err := parseBru(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", filepath.Join(folder, settings), err)
+		}
+		inherited = inherited.with(file)
+	}
+
+	type bruRequest struct {
+		seq  int
+		file bruFile
+		name string
+	}
+	var requests []bruRequest
+	var subfolders []string
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		if entry.IsDir() {
+			if strings.HasPrefix(name, ".") || name == "node_modules" || (folder == "" && name == "environments") {
+				continue
+			}
+			subfolders = append(subfolders, name)
+			continue
+		}
+		if !strings.HasSuffix(name, ".bru") || name == "collection.bru" || name == "folder.bru" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		file, err := parseBru(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", filepath.Join(folder, name), err)
+		}
+		seq, _ := strconv.Atoi(file.value("meta", "seq"))
+		requests = append(requests, bruRequest{seq: seq, file: file, name: name})
+	}
+
+	sort.SliceStable(requests, func(i, j int) bool {
+		if requests[i].seq != requests[j].seq {
+			return requests[i].seq < requests[j].seq
+		}
+		return requests[i].name < requests[j].name
+	})
+	for _, r := range requests {
+		req, ok := brunoRequest(r.file, strings.TrimSuffix(r.name, ".bru"), inherited, c)
+		if ok {
+			c.Requests = append(c.Requests, ImportedRequest{Folder: folder, Request: req})
+		}
+	}
+
+	for _, name := range subfolders {
+		if err := loadBrunoFolder(filepath.Join(dir, name), strings.TrimPrefix(folder+"/"+name, "/"), inherited, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// with returns the settings inherited by a folder with the given folder.bru or collection.bru
+func (in bruInherited) with(file bruFile) bruInherited {
+	headers := make(map[string]string, len(in.headers))
+	for key, value := range in.headers {
+		headers[key] = value
+	}
+	if b := file.block("headers"); b != nil {
+		for _, pair := range b.Pairs {
+			if !pair.Disabled {
+				headers[pair.Key] = convertBrunoVars(pair.Value)
+			}
+		}
+	}
+	out := bruInherited{headers: headers, auth: in.auth}
+	if mode := file.value("auth", "mode"); mode != "" && mode != "inherit" {
+		out.auth = file
+	}
+	return out
+}
+
+// brunoRequest converts a request .bru file. ok is false for files that
+// are not HTTP requests.
+func brunoRequest(file bruFile, fileName string, inherited bruInherited, c *ImportedCollection) (storage.Request, bool) {
+	name := file.value("meta", "name")
+	if name == "" {
+		name = fileName
+	}
+	if kind := file.value("meta", "type"); kind != "" && kind != "http" && kind != "graphql" {
+		c.Notes = append(c.Notes, fmt.Sprintf("Skipped '%s': %s requests are not imported", name, kind))
+		return storage.Request{}, false
+	}
+
+	var method string
+	for _, m := range bruMethods {
+		if file.block(m) != nil {
+			method = m
+			break
+		}
+	}
+	if method == "" {
+		c.Notes = append(c.Notes, fmt.Sprintf("Skipped '%s': no method block", name))
+		return storage.Request{}, false
+	}
+
+	req := storage.Request{
+		Name:    name,
+		Method:  strings.ToUpper(method),
+		Headers: make(map[string]string),
+		Query:   make(map[string]string),
+	}
+
+	// The URL repeats the query parameters; params:query also keeps the disabled ones
+	rawURL, _, _ := strings.Cut(convertBrunoVars(file.value(method, "url")), "?")
+	pathParams := make(map[string]string)
+	if b := file.block("params:path"); b != nil {
+		for _, pair := range b.Pairs {
+			pathParams[pair.Key] = convertBrunoVars(pair.Value)
+		}
+	}
+	req.URL = brunoPathParamPattern.ReplaceAllStringFunc(rawURL, func(match string) string {
+		param := strings.TrimPrefix(match, "/:")
+		if value, ok := pathParams[param]; ok && value != "" {
+			return "/" + value
+		}
+		return "/{{" + importVarName(param) + "}}"
+	})
+	if b := file.block("params:query"); b != nil {
+		for _, pair := range b.Pairs {
+			if !pair.Disabled {
+				req.Query[convertBrunoVars(pair.Key)] = convertBrunoVars(pair.Value)
+			}
+		}
+	}
+
+	for key, value := range inherited.headers {
+		req.Headers[key] = value
+	}
+	if b := file.block("headers"); b != nil {
+		for _, pair := range b.Pairs {
+			if !pair.Disabled {
+				req.Headers[pair.Key] = convertBrunoVars(pair.Value)
+			}
+		}
+	}
+
+	switch mode := file.value(method, "body"); mode {
+	case "", "none":
+	case "json", "text", "xml", "sparql":
+		text := convertBrunoVars(file.block("body:" + mode).textOrEmpty())
+		if mode == "json" {
+			req.Body = importBody(text)
+		} else {
+			req.Body = text
+		}
+		if headerValue(req.Headers, "Content-Type") == "" {
+			req.Headers["Content-Type"] = map[string]string{"json": "application/json", "text": "text/plain", "xml": "application/xml", "sparql": "application/sparql-query"}[mode]
+		}
+	case "formUrlEncoded":
+		form := url.Values{}
+		if b := file.block("body:form-urlencoded"); b != nil {
+			for _, pair := range b.Pairs {
+				if !pair.Disabled {
+					form.Add(convertBrunoVars(pair.Key), convertBrunoVars(pair.Value))
+				}
+			}
+		}
+		req.Body = form.Encode()
+		req.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	case "graphql":
+		body := map[string]interface{}{"query": convertBrunoVars(file.block("body:graphql").textOrEmpty())}
+		if vars := file.block("body:graphql:vars").textOrEmpty(); vars != "" {
+			var parsed interface{}
+			if err := json.Unmarshal([]byte(convertBrunoVars(vars)), &parsed); err == nil {
+				body["variables"] = parsed
+			}
+		}
+		req.Body = body
+		req.Headers["Content-Type"] = "application/json"
+	default:
+		c.Notes = append(c.Notes, fmt.Sprintf("'%s' has a %s body, which was not imported", name, mode))
+	}
+
+	authFile, mode := file, file.value(method, "auth")
+	if mode == "inherit" && inherited.auth != nil {
+		authFile, mode = inherited.auth, inherited.auth.value("auth", "mode")
+	}
+	authValue := func(key string) string { return convertBrunoVars(authFile.value("auth:"+mode, key)) }
+	switch mode {
+	case "", "none", "inherit":
+	case "bearer":
+		req.Headers["Authorization"] = "Bearer " + authValue("token")
+	case "basic":
+		req.Headers["Authorization"] = basicAuthHeader(authValue("username"), authValue("password"), c)
+	case "apikey":
+		if authValue("placement") == "queryparams" {
+			req.Query[authValue("key")] = authValue("value")
+		} else {
+			req.Headers[authValue("key")] = authValue("value")
+		}
+	default:
+		c.Notes = append(c.Notes, fmt.Sprintf("'%s' uses %s auth, which was not imported; use the auth tools or an auth profile", name, mode))
+	}
+
+	for _, b := range file {
+		if strings.HasPrefix(b.Name, "script") || b.Name == "tests" || b.Name == "assert" {
+			c.Notes = append(c.Notes, fmt.Sprintf("'%s' has scripts, tests or assertions, which were not imported; use test_suite", name))
+			break
+		}
+	}
+
+	if len(req.Headers) == 0 {
+		req.Headers = nil
+	}
+	if len(req.Query) == 0 {
+		req.Query = nil
+	}
+	return req, true
+}
+
+// textOrEmpty returns a text block's content, or "" for a missing block
+func (b *bruBlock) textOrEmpty() string {
+	if b == nil {
+		return ""
+	}
+	return b.Text
+}
+
+// convertBrunoVars rewrites Bruno variables as ZAP placeholders;
+// {{process.env.NAME}} becomes {{env:NAME}}
+func convertBrunoVars(text string) string {
+	return brunoVarPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := brunoVarPattern.FindStringSubmatch(match)[1]
+		if env, ok := strings.CutPrefix(name, "process.env."); ok {
+			return "{{env:" + env + "}}"
+		}
+		return "{{" + importVarName(name) + "}}"
+	})
+}
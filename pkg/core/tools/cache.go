@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Response cache modes for HTTPRequest.Cache
+const (
+	CacheRevalidate = "revalidate" // Send validators from the cached response; a 304 is served from cache
+	CacheRefresh    = "refresh"    // Ignore the cached entry but store the new response
+)
+
+// Cache statuses reported in HTTPResponse.CacheStatus
+const (
+	CacheStatusStored      = "stored"      // Response had validators and was cached
+	CacheStatusRevalidated = "revalidated" // Server answered 304; body served from cache
+	CacheStatusModified    = "modified"    // Cached entry existed but the server sent a new response
+	CacheStatusUncacheable = "uncacheable" // No ETag/Last-Modified (or not a 2xx GET/HEAD)
+)
+
+// cacheEntry is a cached response with its validators
+type cacheEntry struct {
+	etag         string
+	lastModified string
+	statusCode   int
+	headers      map[string]string
+	body         string
+}
+
+// responseCache stores GET/HEAD responses by URL for conditional requests
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// newResponseCache creates an empty cache
+func newResponseCache() *responseCache {
+	return &responseCache{entries: make(map[string]*cacheEntry)}
+}
+
+// cacheKey identifies a cacheable request, or "" when it isn't cacheable
+func cacheKey(method, url string) string {
+	if method != http.MethodGet && method != http.MethodHead {
+		return ""
+	}
+	return method + " " + url
+}
+
+// applyValidators adds If-None-Match / If-Modified-Since from the cached
+// entry unless the request sets them itself. It reports whether an entry exists.
+func (c *responseCache) applyValidators(key string, req *http.Request) bool {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+	if entry.etag != "" && req.Header.Get("If-None-Match") == "" {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+	if entry.lastModified != "" && req.Header.Get("If-Modified-Since") == "" {
+		req.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+	return true
+}
+
+// update applies a response to the cache and fills in the cached body for
+// 304s. It returns the cache status.
+func (c *responseCache) update(key string, hadEntry bool, resp *HTTPResponse) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if resp.StatusCode == http.StatusNotModified {
+		entry, ok := c.entries[key]
+		if !ok {
+			return CacheStatusUncacheable
+		}
+		// Keep the 304 status visible, but give assertions the cached body
+		resp.Body = entry.body
+		resp.BodySize = int64(len(entry.body))
+		for name, value := range entry.headers {
+			if _, ok := resp.Headers[name]; !ok {
+				resp.Headers[name] = value
+			}
+		}
+		return CacheStatusRevalidated
+	}
+
+	etag := resp.Headers["Etag"]
+	lastModified := resp.Headers["Last-Modified"]
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || (etag == "" && lastModified == "") || resp.Truncated || resp.BodyFile != "" {
+		delete(c.entries, key)
+		return CacheStatusUncacheable
+	}
+
+	c.entries[key] = &cacheEntry{
+		etag:         etag,
+		lastModified: lastModified,
+		statusCode:   resp.StatusCode,
+		headers:      resp.Headers,
+		body:         resp.Body,
+	}
+	if hadEntry {
+		return CacheStatusModified
+	}
+	return CacheStatusStored
+}
+
+// formatCacheStatus renders the cache line of FormatResponse
+func formatCacheStatus(r *HTTPResponse) string {
+	var validators []string
+	if etag := r.Headers["Etag"]; etag != "" {
+		validators = append(validators, "ETag "+etag)
+	}
+	if lm := r.Headers["Last-Modified"]; lm != "" {
+		validators = append(validators, "Last-Modified "+lm)
+	}
+	detail := ""
+	if len(validators) > 0 {
+		detail = " - " + strings.Join(validators, ", ")
+	}
+
+	switch r.CacheStatus {
+	case CacheStatusRevalidated:
+		return fmt.Sprintf("revalidated (304 Not Modified, body served from cache)%s", detail)
+	case CacheStatusModified:
+		return fmt.Sprintf("modified (cached copy replaced)%s", detail)
+	case CacheStatusStored:
+		return fmt.Sprintf("stored for revalidation%s", detail)
+	default:
+		return "not cacheable (needs a 2xx GET/HEAD response with ETag or Last-Modified)"
+	}
+}
@@ -0,0 +1,490 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Chaos proxy defaults
+const (
+	defaultChaosTimeout = 600  // Seconds before a proxy stops itself
+	maxChaosTimeout     = 3600 // Upper bound for timeout_seconds
+	maxChaosLatency     = 60000
+)
+
+var defaultChaosStatuses = []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable}
+
+// ChaosProxyTool runs local reverse proxies that inject faults (latency,
+// dropped connections, 5xx) in front of a target, to test client resilience
+type ChaosProxyTool struct {
+	varStore *VariableStore
+	mu       sync.Mutex
+	proxies  map[string]*chaosProxy
+}
+
+// ChaosFaults configures the faults a proxy injects. Rates are fractions
+// between 0 and 1 of the matching requests.
+type ChaosFaults struct {
+	LatencyMs     int      `json:"latency_ms,omitempty"`     // Delay added before forwarding
+	JitterMs      int      `json:"jitter_ms,omitempty"`      // Random extra delay, up to this
+	LatencyRate   float64  `json:"latency_rate,omitempty"`   // Default 1 when latency_ms is set
+	ErrorRate     float64  `json:"error_rate,omitempty"`     // Answer with a 5xx instead of forwarding
+	ErrorStatuses []int    `json:"error_statuses,omitempty"` // Default 500, 502, 503
+	DropRate      float64  `json:"drop_rate,omitempty"`      // Close the connection without a response
+	Paths         []string `json:"paths,omitempty"`          // Only fault these paths ("/users/:id", "/api/*"); default all
+}
+
+// chaosProxy is a running fault-injection proxy
+type chaosProxy struct {
+	server *http.Server
+	target *url.URL
+	proxy  *httputil.ReverseProxy
+	url    string
+	done   chan struct{}
+
+	mu     sync.Mutex
+	faults ChaosFaults
+	rng    *rand.Rand
+	stats  chaosStats
+}
+
+// chaosStats counts what happened to the proxied requests
+type chaosStats struct {
+	total     int
+	delayed   int
+	dropped   int
+	errors    map[int]int // Injected status -> count
+	forwarded int
+	upstream  int // Forwarded requests the target failed to answer
+}
+
+// ChaosProxyParams defines parameters for the chaos proxy
+type ChaosProxyParams struct {
+	Action         string      `json:"action"`
+	ProxyID        string      `json:"proxy_id,omitempty"`
+	Target         string      `json:"target,omitempty"` // Base URL of the real service
+	Port           int         `json:"port,omitempty"`
+	Faults         ChaosFaults `json:"faults"`
+	Seed           int64       `json:"seed,omitempty"` // Makes the fault sequence repeatable
+	TimeoutSeconds int         `json:"timeout_seconds,omitempty"`
+}
+
+// NewChaosProxyTool creates a new chaos proxy tool
+func NewChaosProxyTool(varStore *VariableStore) *ChaosProxyTool {
+	return &ChaosProxyTool{
+		varStore: varStore,
+		proxies:  make(map[string]*chaosProxy),
+	}
+}
+
+// Name returns the tool name
+func (t *ChaosProxyTool) Name() string {
+	return "chaos_proxy"
+}
+
+// Description returns the tool description
+func (t *ChaosProxyTool) Description() string {
+	return "Run a local fault-injection proxy in front of a target service. It forwards requests but injects latency, dropped connections and 5xx responses at configurable rates, to verify a client's retries, timeouts and fallbacks. Actions: start, update (change faults), stats, stop."
+}
+
+// Parameters returns the tool parameter description
+func (t *ChaosProxyTool) Parameters() string {
+	return `{
+  "action": "start|update|stats|stop",
+  "proxy_id": "chaos_1",
+  "target": "http://localhost:8000",
+  "port": 0,
+  "faults": {
+    "latency_ms": 500, "jitter_ms": 200, "latency_rate": 0.5,
+    "error_rate": 0.2, "error_statuses": [500, 503],
+    "drop_rate": 0.1,
+    "paths": ["/api/*"]
+  },
+  "seed": 42,
+  "timeout_seconds": 600
+}`
+}
+
+// Execute runs the chaos proxy command
+func (t *ChaosProxyTool) Execute(args string) (string, error) {
+	var params ChaosProxyParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	if params.ProxyID == "" {
+		params.ProxyID = "chaos_1"
+	}
+	if params.TimeoutSeconds == 0 {
+		params.TimeoutSeconds = defaultChaosTimeout
+	}
+
+	switch params.Action {
+	case "start":
+		return t.startProxy(params)
+	case "update":
+		return t.updateFaults(params)
+	case "stats":
+		return t.getStats(params.ProxyID)
+	case "stop":
+		return t.stopProxy(params.ProxyID)
+	default:
+		return "", fmt.Errorf("unknown action: %s (use 'start', 'update', 'stats', or 'stop')", params.Action)
+	}
+}
+
+// validateFaults checks rates and fills in defaults
+func validateFaults(faults ChaosFaults) (ChaosFaults, error) {
+	for name, rate := range map[string]float64{"latency_rate": faults.LatencyRate, "error_rate": faults.ErrorRate, "drop_rate": faults.DropRate} {
+		if rate < 0 || rate > 1 {
+			return faults, fmt.Errorf("%s must be between 0 and 1 (got %g)", name, rate)
+		}
+	}
+	if faults.ErrorRate+faults.DropRate > 1 {
+		return faults, fmt.Errorf("error_rate and drop_rate add up to more than 1")
+	}
+	if faults.LatencyMs < 0 || faults.JitterMs < 0 || faults.LatencyMs+faults.JitterMs > maxChaosLatency {
+		return faults, fmt.Errorf("latency_ms plus jitter_ms must be between 0 and %d", maxChaosLatency)
+	}
+	if (faults.LatencyMs > 0 || faults.JitterMs > 0) && faults.LatencyRate == 0 {
+		faults.LatencyRate = 1
+	}
+	if len(faults.ErrorStatuses) == 0 {
+		faults.ErrorStatuses = defaultChaosStatuses
+	}
+	for _, status := range faults.ErrorStatuses {
+		if status < 100 || status > 599 {
+			return faults, fmt.Errorf("invalid error status %d", status)
+		}
+	}
+	for _, path := range faults.Paths {
+		if !strings.HasPrefix(path, "/") {
+			return faults, fmt.Errorf("path must start with '/' (got %q)", path)
+		}
+	}
+	return faults, nil
+}
+
+// startProxy starts a new chaos proxy
+func (t *ChaosProxyTool) startProxy(params ChaosProxyParams) (string, error) {
+	if params.TimeoutSeconds < 0 || params.TimeoutSeconds > maxChaosTimeout {
+		return "", fmt.Errorf("timeout_seconds must be between 1 and %d", maxChaosTimeout)
+	}
+	if t.varStore != nil {
+		params.Target = t.varStore.Substitute(params.Target)
+	}
+	target, err := url.Parse(params.Target)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "", fmt.Errorf("'target' must be an http(s) URL, e.g. http://localhost:8000")
+	}
+	faults, err := validateFaults(params.Faults)
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.proxies[params.ProxyID]; exists {
+		return "", fmt.Errorf("chaos proxy '%s' already running. Stop it first, use update, or use a different proxy_id", params.ProxyID)
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", params.Port))
+	if err != nil {
+		return "", fmt.Errorf("failed to start chaos proxy: %w", err)
+	}
+	actualPort := listener.Addr().(*net.TCPAddr).Port
+
+	seed := params.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	cp := &chaosProxy{
+		target: target,
+		url:    fmt.Sprintf("http://localhost:%d", actualPort),
+		done:   make(chan struct{}),
+		faults: faults,
+		rng:    rand.New(rand.NewSource(seed)),
+		stats:  chaosStats{errors: make(map[int]int)},
+	}
+	cp.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target) // Also sets the Host header, for virtual hosts
+			r.SetXForwarded()
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			cp.mu.Lock()
+			cp.stats.upstream++
+			cp.mu.Unlock()
+			http.Error(w, fmt.Sprintf("chaos proxy: target unreachable: %v", err), http.StatusBadGateway)
+		},
+	}
+	cp.server = &http.Server{Handler: cp}
+
+	go func() {
+		cp.server.Serve(listener)
+	}()
+
+	// Auto-shutdown after timeout
+	go func() {
+		select {
+		case <-time.After(time.Duration(params.TimeoutSeconds) * time.Second):
+			t.stopProxy(params.ProxyID)
+		case <-cp.done:
+		}
+	}()
+
+	t.proxies[params.ProxyID] = cp
+
+	// Save the proxy URL so requests can use {{chaos_1_url}}
+	if t.varStore != nil {
+		t.varStore.Set(fmt.Sprintf("%s_url", params.ProxyID), cp.url)
+	}
+
+	return fmt.Sprintf(`Chaos proxy started!
+
+Proxy ID: %s
+URL: %s (saved as {{%s_url}}) -> %s
+Timeout: %d seconds
+
+Faults:
+%s
+Point the client under test at the proxy URL. Use 'stats' to see what was injected, 'update' to change the faults.`,
+		params.ProxyID, cp.url, params.ProxyID, target, params.TimeoutSeconds, formatChaosFaults(faults)), nil
+}
+
+// updateFaults replaces a running proxy's faults; stats are kept
+func (t *ChaosProxyTool) updateFaults(params ChaosProxyParams) (string, error) {
+	cp, err := t.getProxy(params.ProxyID)
+	if err != nil {
+		return "", err
+	}
+	faults, err := validateFaults(params.Faults)
+	if err != nil {
+		return "", err
+	}
+	cp.mu.Lock()
+	cp.faults = faults
+	cp.mu.Unlock()
+	return fmt.Sprintf("Updated faults of '%s':\n%s", params.ProxyID, formatChaosFaults(faults)), nil
+}
+
+// getStats shows what a proxy did to the requests it received
+func (t *ChaosProxyTool) getStats(proxyID string) (string, error) {
+	cp, err := t.getProxy(proxyID)
+	if err != nil {
+		return "", err
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return fmt.Sprintf("Chaos proxy '%s' at %s -> %s\n%s", proxyID, cp.url, cp.target, cp.stats.format()), nil
+}
+
+// stopProxy shuts down a chaos proxy
+func (t *ChaosProxyTool) stopProxy(proxyID string) (string, error) {
+	t.mu.Lock()
+	cp, exists := t.proxies[proxyID]
+	if exists {
+		delete(t.proxies, proxyID)
+	}
+	t.mu.Unlock()
+
+	if !exists {
+		return "", fmt.Errorf("chaos proxy '%s' not found", proxyID)
+	}
+
+	close(cp.done)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := cp.server.Shutdown(ctx); err != nil {
+		return "", fmt.Errorf("failed to shutdown chaos proxy: %w", err)
+	}
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return fmt.Sprintf("Chaos proxy '%s' stopped.\n%s", proxyID, cp.stats.format()), nil
+}
+
+// Cleanup stops all running chaos proxies (call on shutdown)
+func (t *ChaosProxyTool) Cleanup() {
+	t.mu.Lock()
+	ids := make([]string, 0, len(t.proxies))
+	for id := range t.proxies {
+		ids = append(ids, id)
+	}
+	t.mu.Unlock()
+
+	for _, id := range ids {
+		t.stopProxy(id)
+	}
+}
+
+// getProxy looks up a running proxy
+func (t *ChaosProxyTool) getProxy(proxyID string) (*chaosProxy, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cp, exists := t.proxies[proxyID]
+	if !exists {
+		return nil, fmt.Errorf("chaos proxy '%s' not found", proxyID)
+	}
+	return cp, nil
+}
+
+// chaosAction is the fault picked for one request
+type chaosAction struct {
+	delay  time.Duration
+	drop   bool
+	status int // Injected error status, or 0 to forward
+}
+
+// pick decides the fault for a request
+func (cp *chaosProxy) pick(path string) chaosAction {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.stats.total++
+
+	var action chaosAction
+	f := cp.faults
+	if len(f.Paths) > 0 && !matchesAnyMockPath(f.Paths, path) {
+		cp.stats.forwarded++
+		return action
+	}
+	if f.LatencyRate > 0 && cp.rng.Float64() < f.LatencyRate {
+		action.delay = time.Duration(f.LatencyMs) * time.Millisecond
+		if f.JitterMs > 0 {
+			action.delay += time.Duration(cp.rng.Intn(f.JitterMs+1)) * time.Millisecond
+		}
+		cp.stats.delayed++
+	}
+	switch roll := cp.rng.Float64(); {
+	case roll < f.DropRate:
+		action.drop = true
+		cp.stats.dropped++
+	case roll < f.DropRate+f.ErrorRate:
+		action.status = f.ErrorStatuses[cp.rng.Intn(len(f.ErrorStatuses))]
+		cp.stats.errors[action.status]++
+	default:
+		cp.stats.forwarded++
+	}
+	return action
+}
+
+// ServeHTTP injects the picked fault, or forwards the request to the target
+func (cp *chaosProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	action := cp.pick(r.URL.Path)
+
+	if action.delay > 0 {
+		select {
+		case <-time.After(action.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case action.drop:
+		// Close the connection without a response, like a crashed server
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		panic(http.ErrAbortHandler)
+	case action.status != 0:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Chaos-Injected", "true")
+		w.WriteHeader(action.status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": fmt.Sprintf("chaos proxy injected %d", action.status),
+		})
+	default:
+		cp.proxy.ServeHTTP(w, r)
+	}
+}
+
+// matchesAnyMockPath reports whether path matches one of the mock-style patterns
+func matchesAnyMockPath(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if _, ok := matchMockPath(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// format renders the counters
+func (s chaosStats) format() string {
+	if s.total == 0 {
+		return "No requests received yet."
+	}
+	pct := func(n int) float64 {
+		return float64(n) * 100 / float64(s.total)
+	}
+	injected := 0
+	statuses := make([]int, 0, len(s.errors))
+	for status, n := range s.errors {
+		injected += n
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Requests: %d\n", s.total))
+	sb.WriteString(fmt.Sprintf("  Delayed:   %d (%.1f%%)\n", s.delayed, pct(s.delayed)))
+	sb.WriteString(fmt.Sprintf("  Dropped:   %d (%.1f%%)\n", s.dropped, pct(s.dropped)))
+	sb.WriteString(fmt.Sprintf("  Errors:    %d (%.1f%%)", injected, pct(injected)))
+	if len(statuses) > 0 {
+		parts := make([]string, len(statuses))
+		for i, status := range statuses {
+			parts[i] = fmt.Sprintf("%d x%d", status, s.errors[status])
+		}
+		sb.WriteString(" - " + strings.Join(parts, ", "))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("  Forwarded: %d (%.1f%%)", s.forwarded, pct(s.forwarded)))
+	if s.upstream > 0 {
+		sb.WriteString(fmt.Sprintf(", %d failed to reach the target", s.upstream))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// formatChaosFaults describes a fault configuration
+func formatChaosFaults(f ChaosFaults) string {
+	var sb strings.Builder
+	if f.LatencyRate > 0 {
+		sb.WriteString(fmt.Sprintf("  Latency: %dms", f.LatencyMs))
+		if f.JitterMs > 0 {
+			sb.WriteString(fmt.Sprintf(" + up to %dms jitter", f.JitterMs))
+		}
+		sb.WriteString(fmt.Sprintf(" on %.0f%% of requests\n", f.LatencyRate*100))
+	}
+	if f.ErrorRate > 0 {
+		statuses := make([]string, len(f.ErrorStatuses))
+		for i, status := range f.ErrorStatuses {
+			statuses[i] = fmt.Sprint(status)
+		}
+		sb.WriteString(fmt.Sprintf("  Errors: %s on %.0f%% of requests\n", strings.Join(statuses, "/"), f.ErrorRate*100))
+	}
+	if f.DropRate > 0 {
+		sb.WriteString(fmt.Sprintf("  Dropped connections: %.0f%% of requests\n", f.DropRate*100))
+	}
+	if sb.Len() == 0 {
+		sb.WriteString("  None (requests pass through)\n")
+	}
+	if len(f.Paths) > 0 {
+		sb.WriteString(fmt.Sprintf("  Only on: %s\n", strings.Join(f.Paths, ", ")))
+	}
+	return sb.String()
+}
@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/blackcoderx/zap/pkg/storage"
+)
+
+// ImportedCollection is a request library read from another API client
+// (Insomnia, Bruno), before it is written to .zap/
+type ImportedCollection struct {
+	Requests     []ImportedRequest
+	Environments map[string]map[string]string // Variables by environment name
+	SecretVars   []string                     // Variables the source keeps secret, without their values
+	Notes        []string                     // Things that could not be imported
+}
+
+// ImportedRequest is a request and the folder it came from
+type ImportedRequest struct {
+	Folder  string // Folder path in the source collection, e.g. "Users/Admin"
+	Request storage.Request
+}
+
+// CollectionImportResult lists what ImportCollection wrote
+type CollectionImportResult struct {
+	Requests       []string // Saved requests
+	Skipped        []string // Existing requests that were left alone
+	Environments   []string // Environment files written
+	Secrets        []string // Credentials moved into encrypted global variables
+	MissingSecrets []string // Secret variables without a value, to be set by the user
+	Notes          []string
+}
+
+// importVarInvalid matches characters not allowed in a ZAP variable name
+var importVarInvalid = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// importVarName turns a variable name from another client ("base-url",
+// "api.key") into one ZAP substitutes ("base_url", "api_key")
+func importVarName(name string) string {
+	return strings.Trim(importVarInvalid.ReplaceAllString(strings.TrimSpace(name), "_"), "_")
+}
+
+// ImportCollection writes an imported collection to zapDir: a saved request
+// per request and an environment file per environment. Credentials written
+// out literally in the source (auth headers, tokens, passwords) are replaced
+// with {{VAR}} placeholders and their values stored as encrypted secret
+// global variables, so nothing secret ends up in .zap/requests or
+// .zap/environments. Existing requests and variables are kept unless overwrite.
+func ImportCollection(zapDir string, varStore *VariableStore, c *ImportedCollection, overwrite bool) (*CollectionImportResult, error) {
+	if len(c.Requests) == 0 {
+		return nil, fmt.Errorf("no requests found to import")
+	}
+
+	imp := &collectionImporter{varStore: varStore, overwrite: overwrite, secrets: make(map[string]string)}
+	result := &CollectionImportResult{Notes: append([]string{}, c.Notes...)}
+
+	requestsDir := storage.GetRequestsDir(zapDir)
+	seen := make(map[string]bool)
+	for _, imported := range c.Requests {
+		req := imported.Request
+		imp.scrub(&req)
+
+		// Requests in different folders often share a name ("list", "create")
+		name := kebabCase(req.Name)
+		if name == "" {
+			name = kebabCase(req.Method + " request")
+		}
+		if seen[name] && imported.Folder != "" {
+			name = kebabCase(imported.Folder + " " + req.Name)
+		}
+		base := name
+		for i := 2; seen[name]; i++ {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		seen[name] = true
+		req.Name = name
+
+		if msg := core.ValidateRequestForSecrets(req.URL, req.Headers, req.Body); msg != "" {
+			result.Notes = append(result.Notes, fmt.Sprintf("%s may still contain a hardcoded secret: %s", name, strings.SplitN(msg, "\n", 2)[0]))
+		}
+
+		path := filepath.Join(requestsDir, name+".yaml")
+		if _, err := os.Stat(path); err == nil && !overwrite {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		if err := storage.SaveRequest(req, path); err != nil {
+			return result, fmt.Errorf("failed to save request '%s': %w", name, err)
+		}
+		result.Requests = append(result.Requests, name)
+	}
+	core.UpdateManifestCounts(zapDir)
+
+	envNames := make([]string, 0, len(c.Environments))
+	for name := range c.Environments {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+	for _, envName := range envNames {
+		vars := make(map[string]string)
+		for name, value := range c.Environments[envName] {
+			if core.IsSensitiveKey(name) && value != "" && !core.ContainsVariablePlaceholder(value) {
+				// A credential: keep it encrypted instead of in the environment file.
+				// Secret variables are global, so only one environment's value fits.
+				if !imp.envSecret(name, value) {
+					result.Notes = append(result.Notes, fmt.Sprintf("'%s' of environment '%s' was not stored: it differs from the value already stored; set it with the variable tool when switching", name, envName))
+				}
+				continue
+			}
+			vars[name] = value
+		}
+		if len(vars) == 0 {
+			continue
+		}
+		path := filepath.Join(storage.GetEnvironmentsDir(zapDir), kebabCase(envName)+".yaml")
+		if _, err := storage.SetEnvironmentVariables(path, vars, overwrite); err != nil {
+			return result, err
+		}
+		result.Environments = append(result.Environments, path)
+	}
+
+	if imp.err != nil {
+		return result, imp.err
+	}
+	for name := range imp.secrets {
+		result.Secrets = append(result.Secrets, name)
+	}
+	sort.Strings(result.Secrets)
+	for _, name := range c.SecretVars {
+		if _, ok := varStore.Get(name); !ok {
+			result.MissingSecrets = append(result.MissingSecrets, name)
+		}
+	}
+	return result, nil
+}
+
+// Format describes the import for the user
+func (r *CollectionImportResult) Format() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Imported %d request(s) into .zap/requests/\n", len(r.Requests)))
+	if len(r.Skipped) > 0 {
+		sb.WriteString(fmt.Sprintf("Skipped %d existing request(s) (use --overwrite to replace): %s\n", len(r.Skipped), strings.Join(r.Skipped, ", ")))
+	}
+	for _, path := range r.Environments {
+		sb.WriteString(fmt.Sprintf("Wrote environment %s\n", path))
+	}
+	if len(r.Secrets) > 0 {
+		sb.WriteString(fmt.Sprintf("Stored credentials as encrypted secret variables: %s\n", strings.Join(r.Secrets, ", ")))
+	}
+	if len(r.MissingSecrets) > 0 {
+		sb.WriteString(fmt.Sprintf("Set these secret variables with the variable tool (\"secret\": true): %s\n", strings.Join(r.MissingSecrets, ", ")))
+	}
+	for _, note := range r.Notes {
+		sb.WriteString("Note: " + note + "\n")
+	}
+	return sb.String()
+}
+
+// collectionImporter moves literal credentials out of imported requests
+type collectionImporter struct {
+	varStore  *VariableStore
+	overwrite bool
+	secrets   map[string]string // Secret variables stored so far, by name
+	err       error
+}
+
+// scrub replaces literal credentials in a request's auth headers, sensitive
+// headers and query parameters, and sensitive top-level body fields
+func (imp *collectionImporter) scrub(req *storage.Request) {
+	for key, value := range req.Headers {
+		if strings.EqualFold(key, "Authorization") {
+			scheme, credential, found := strings.Cut(value, " ")
+			if !found {
+				req.Headers[key] = imp.placeholder("AUTHORIZATION", value)
+				continue
+			}
+			name := strings.ToUpper(scheme) + "_AUTH"
+			switch strings.ToLower(scheme) {
+			case "bearer":
+				name = "API_TOKEN"
+			case "basic":
+				name = "BASIC_AUTH"
+			}
+			req.Headers[key] = scheme + " " + imp.placeholder(name, strings.TrimSpace(credential))
+		} else if core.IsSensitiveKey(key) {
+			req.Headers[key] = imp.placeholder(key, value)
+		}
+	}
+	for key, value := range req.Query {
+		if core.IsSensitiveKey(key) {
+			req.Query[key] = imp.placeholder(key, value)
+		}
+	}
+	if fields, ok := req.Body.(map[string]interface{}); ok {
+		for key, value := range fields {
+			if s, ok := value.(string); ok && core.IsSensitiveKey(key) {
+				fields[key] = imp.placeholder(key, s)
+			}
+		}
+	}
+}
+
+// placeholder returns value if it holds no literal credential, else stores
+// it as a secret variable and returns its {{VAR}} placeholder
+func (imp *collectionImporter) placeholder(name, value string) string {
+	literal := strings.TrimSpace(core.VariablePlaceholderPattern.ReplaceAllString(value, ""))
+	if literal == "" {
+		return value
+	}
+	return "{{" + imp.secret(strings.ToUpper(importVarName(name)), value) + "}}"
+}
+
+// envSecret stores an environment's credential as an encrypted global
+// variable of the same name, so the requests using it still resolve. It
+// returns false when the variable already holds a different value.
+func (imp *collectionImporter) envSecret(name, value string) bool {
+	if stored, ok := imp.secrets[name]; ok {
+		return stored == value
+	}
+	if existing, ok := imp.varStore.Get(name); ok && existing != value && !imp.overwrite {
+		return false
+	}
+	imp.store(name, value)
+	return true
+}
+
+// secret stores value as an encrypted global variable and returns the
+// variable's name. A different value under the same name gets a numbered
+// name, so no credential is overwritten.
+func (imp *collectionImporter) secret(name, value string) string {
+	base := name
+	for i := 2; ; i++ {
+		stored, ok := imp.secrets[name]
+		if ok && stored == value {
+			return name
+		}
+		if !ok {
+			existing, exists := imp.varStore.Get(name)
+			if !exists || existing == value || imp.overwrite {
+				break
+			}
+		}
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+
+	imp.store(name, value)
+	return name
+}
+
+// store saves a secret global variable, keeping the first error
+func (imp *collectionImporter) store(name, value string) {
+	imp.secrets[name] = value
+	if err := imp.varStore.SetSecret(name, value, true); err != nil && imp.err == nil {
+		imp.err = fmt.Errorf("failed to store secret variable '%s': %w", name, err)
+	}
+}
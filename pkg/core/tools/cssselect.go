@@ -0,0 +1,435 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// CSS selectors supported by extraction: type, *, #id, .class, [attr] with
+// = ~= |= ^= $= *=, the descendant, >, + and ~ combinators, comma groups,
+// and the pseudo-classes :first-child, :last-child, :only-child,
+// :nth-child(), :nth-last-child(), :first-of-type, :last-of-type,
+// :nth-of-type(), :empty and :not().
+
+// cssCompound is a sequence of simple selectors on one element
+type cssCompound struct {
+	combinator byte // How it relates to the compound before it: ' ', '>', '+' or '~'
+	tag        string
+	id         string
+	classes    []string
+	attrs      []cssAttr
+	pseudos    []cssPseudo
+}
+
+type cssAttr struct {
+	name, op, value string
+}
+
+type cssPseudo struct {
+	name string
+	a, b int          // nth-*(an+b)
+	not  *cssCompound // :not()
+}
+
+// selectCSS returns the elements of doc matching selector, in document order
+func selectCSS(selector string, doc *html.Node) ([]*html.Node, error) {
+	group, err := parseCSS(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSS selector %q: %w", selector, err)
+	}
+	var matches []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for _, chain := range group {
+				if matchCSS(chain, len(chain)-1, n) {
+					matches = append(matches, n)
+					break
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return matches, nil
+}
+
+// parseCSS parses a comma-separated group of selectors
+func parseCSS(selector string) ([][]cssCompound, error) {
+	p := &cssParser{src: selector}
+	var group [][]cssCompound
+	for {
+		var chain []cssCompound
+		combinator := byte(0)
+		for {
+			hadSpace := p.skipSpace()
+			if p.done() || p.src[p.pos] == ',' {
+				break
+			}
+			if c := p.src[p.pos]; c == '>' || c == '+' || c == '~' {
+				if len(chain) == 0 || combinator != ' ' && combinator != 0 && !hadSpace {
+					return nil, fmt.Errorf("unexpected %q", c)
+				}
+				combinator = c
+				p.pos++
+				p.skipSpace()
+			} else if hadSpace && len(chain) > 0 && combinator == 0 {
+				combinator = ' '
+			}
+			if len(chain) > 0 && combinator == 0 {
+				return nil, fmt.Errorf("unexpected %q", p.src[p.pos])
+			}
+			compound, err := p.parseCompound()
+			if err != nil {
+				return nil, err
+			}
+			compound.combinator = combinator
+			chain = append(chain, compound)
+			combinator = 0
+		}
+		if len(chain) == 0 || combinator != 0 {
+			return nil, fmt.Errorf("empty selector")
+		}
+		group = append(group, chain)
+		if p.done() {
+			return group, nil
+		}
+		p.pos++ // ,
+	}
+}
+
+type cssParser struct {
+	src string
+	pos int
+}
+
+func (p *cssParser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+// skipSpace skips whitespace and reports whether there was any
+func (p *cssParser) skipSpace() bool {
+	start := p.pos
+	for !p.done() && strings.IndexByte(" \t\n\r", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *cssParser) ident() string {
+	start := p.pos
+	for !p.done() {
+		c := p.src[p.pos]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c >= 0x80 {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *cssParser) parseCompound() (cssCompound, error) {
+	var c cssCompound
+	if !p.done() && p.src[p.pos] == '*' {
+		p.pos++
+		c.tag = "*"
+	} else {
+		c.tag = p.ident()
+	}
+	for !p.done() {
+		switch p.src[p.pos] {
+		case '#':
+			p.pos++
+			if c.id = p.ident(); c.id == "" {
+				return c, fmt.Errorf("expected an id after #")
+			}
+		case '.':
+			p.pos++
+			class := p.ident()
+			if class == "" {
+				return c, fmt.Errorf("expected a class after .")
+			}
+			c.classes = append(c.classes, class)
+		case '[':
+			attr, err := p.parseAttr()
+			if err != nil {
+				return c, err
+			}
+			c.attrs = append(c.attrs, attr)
+		case ':':
+			pseudo, err := p.parsePseudo()
+			if err != nil {
+				return c, err
+			}
+			c.pseudos = append(c.pseudos, pseudo)
+		default:
+			if c.tag == "" && c.id == "" && len(c.classes) == 0 && len(c.attrs) == 0 && len(c.pseudos) == 0 {
+				return c, fmt.Errorf("unexpected %q", p.src[p.pos])
+			}
+			return c, nil
+		}
+	}
+	if c.tag == "" && c.id == "" && len(c.classes) == 0 && len(c.attrs) == 0 && len(c.pseudos) == 0 {
+		return c, fmt.Errorf("empty selector")
+	}
+	return c, nil
+}
+
+// parseAttr parses [name], [name=value] and the other operators
+func (p *cssParser) parseAttr() (cssAttr, error) {
+	var attr cssAttr
+	p.pos++ // [
+	p.skipSpace()
+	if attr.name = p.ident(); attr.name == "" {
+		return attr, fmt.Errorf("expected an attribute name after [")
+	}
+	p.skipSpace()
+	for _, op := range []string{"=", "~=", "|=", "^=", "$=", "*="} {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			attr.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if attr.op != "" {
+		p.skipSpace()
+		if !p.done() && (p.src[p.pos] == '"' || p.src[p.pos] == '\'') {
+			quote := p.src[p.pos]
+			end := strings.IndexByte(p.src[p.pos+1:], quote)
+			if end < 0 {
+				return attr, fmt.Errorf("unterminated string")
+			}
+			attr.value = p.src[p.pos+1 : p.pos+1+end]
+			p.pos += end + 2
+		} else {
+			attr.value = p.ident()
+		}
+		p.skipSpace()
+	}
+	if p.done() || p.src[p.pos] != ']' {
+		return attr, fmt.Errorf("expected ] after attribute %s", attr.name)
+	}
+	p.pos++
+	return attr, nil
+}
+
+func (p *cssParser) parsePseudo() (cssPseudo, error) {
+	p.pos++ // :
+	pseudo := cssPseudo{name: strings.ToLower(p.ident())}
+	arg := ""
+	if !p.done() && p.src[p.pos] == '(' {
+		end := strings.IndexByte(p.src[p.pos:], ')')
+		if end < 0 {
+			return pseudo, fmt.Errorf("expected ) after :%s(", pseudo.name)
+		}
+		arg = strings.TrimSpace(p.src[p.pos+1 : p.pos+end])
+		p.pos += end + 1
+	}
+
+	switch pseudo.name {
+	case "first-child", "last-child", "only-child", "first-of-type", "last-of-type", "empty":
+		if arg != "" {
+			return pseudo, fmt.Errorf(":%s takes no argument", pseudo.name)
+		}
+	case "nth-child", "nth-last-child", "nth-of-type":
+		a, b, err := parseNth(arg)
+		if err != nil {
+			return pseudo, fmt.Errorf(":%s(%s): %w", pseudo.name, arg, err)
+		}
+		pseudo.a, pseudo.b = a, b
+	case "not":
+		inner := &cssParser{src: arg}
+		compound, err := inner.parseCompound()
+		if err != nil || !inner.done() {
+			return pseudo, fmt.Errorf(":not() takes a simple selector, got %q", arg)
+		}
+		pseudo.not = &compound
+	default:
+		return pseudo, fmt.Errorf("unsupported pseudo-class :%s", pseudo.name)
+	}
+	return pseudo, nil
+}
+
+// parseNth parses an+b, odd and even
+func parseNth(arg string) (int, int, error) {
+	arg = strings.ToLower(strings.ReplaceAll(arg, " ", ""))
+	switch arg {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+	before, after, hasN := strings.Cut(arg, "n")
+	if !hasN {
+		b, err := strconv.Atoi(arg)
+		if err != nil {
+			return 0, 0, fmt.Errorf("expected a number, odd, even or an+b")
+		}
+		return 0, b, nil
+	}
+	a := 1
+	switch before {
+	case "", "+":
+	case "-":
+		a = -1
+	default:
+		var err error
+		if a, err = strconv.Atoi(before); err != nil {
+			return 0, 0, fmt.Errorf("expected a number, odd, even or an+b")
+		}
+	}
+	b := 0
+	if after != "" {
+		var err error
+		if b, err = strconv.Atoi(after); err != nil {
+			return 0, 0, fmt.Errorf("expected a number, odd, even or an+b")
+		}
+	}
+	return a, b, nil
+}
+
+// matchCSS reports whether n matches a selector chain up to and including part i
+func matchCSS(chain []cssCompound, i int, n *html.Node) bool {
+	if !chain[i].matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	switch chain[i].combinator {
+	case '>':
+		return n.Parent != nil && n.Parent.Type == html.ElementNode && matchCSS(chain, i-1, n.Parent)
+	case '+':
+		prev := prevElement(n)
+		return prev != nil && matchCSS(chain, i-1, prev)
+	case '~':
+		for prev := prevElement(n); prev != nil; prev = prevElement(prev) {
+			if matchCSS(chain, i-1, prev) {
+				return true
+			}
+		}
+		return false
+	}
+	for p := n.Parent; p != nil && p.Type == html.ElementNode; p = p.Parent {
+		if matchCSS(chain, i-1, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func prevElement(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+func (c *cssCompound) matches(n *html.Node) bool {
+	if c.tag != "" && c.tag != "*" && !strings.EqualFold(c.tag, n.Data) {
+		return false
+	}
+	if c.id != "" {
+		if id, _ := nodeAttr(n, "id"); id != c.id {
+			return false
+		}
+	}
+	if len(c.classes) > 0 {
+		class, _ := nodeAttr(n, "class")
+		classes := strings.Fields(class)
+		for _, want := range c.classes {
+			if !slices.Contains(classes, want) {
+				return false
+			}
+		}
+	}
+	for _, attr := range c.attrs {
+		if !attr.matches(n) {
+			return false
+		}
+	}
+	for _, pseudo := range c.pseudos {
+		if !pseudo.matches(n) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a cssAttr) matches(n *html.Node) bool {
+	v, ok := nodeAttr(n, a.name)
+	if !ok {
+		return false
+	}
+	switch a.op {
+	case "=":
+		return v == a.value
+	case "~=":
+		return slices.Contains(strings.Fields(v), a.value)
+	case "|=":
+		return v == a.value || strings.HasPrefix(v, a.value+"-")
+	case "^=":
+		return a.value != "" && strings.HasPrefix(v, a.value)
+	case "$=":
+		return a.value != "" && strings.HasSuffix(v, a.value)
+	case "*=":
+		return a.value != "" && strings.Contains(v, a.value)
+	}
+	return true
+}
+
+func (p cssPseudo) matches(n *html.Node) bool {
+	switch p.name {
+	case "not":
+		return !p.not.matches(n)
+	case "empty":
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode || c.Type == html.TextNode && c.Data != "" {
+				return false
+			}
+		}
+		return true
+	}
+
+	// The rest count n's position among its element siblings
+	if n.Parent == nil {
+		return false
+	}
+	ofType := strings.HasSuffix(p.name, "-of-type")
+	var siblings []*html.Node
+	for _, s := range elementChildren(n.Parent) {
+		if !ofType || s.Data == n.Data {
+			siblings = append(siblings, s)
+		}
+	}
+	index := 0
+	for i, s := range siblings {
+		if s == n {
+			index = i + 1
+		}
+	}
+	switch p.name {
+	case "first-child", "first-of-type":
+		return index == 1
+	case "last-child", "last-of-type":
+		return index == len(siblings)
+	case "only-child":
+		return len(siblings) == 1
+	case "nth-last-child":
+		index = len(siblings) - index + 1
+	}
+	if p.a == 0 {
+		return index == p.b
+	}
+	k := (index - p.b) / p.a
+	return k >= 0 && k*p.a+p.b == index
+}
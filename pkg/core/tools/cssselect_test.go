@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const cssTestHTML = `<!DOCTYPE html><html><body>
+<div id="main" class="box wide">
+  <h1 lang="en-US">Title</h1>
+  <p class="intro lead">P1</p>
+  <p>P2</p>
+  <span>S1</span>
+  <p data-tags="a b c">P3</p>
+  <ul><li>L1</li><li>L2</li><li>L3</li><li>L4</li><li>L5</li></ul>
+  <a href="https://example.com/doc.pdf">A1</a>
+  <b></b>
+</div>
+<p id="outer">P4</p>
+<section><i>only</i></section>
+</body></html>`
+
+func TestSelectCSS(t *testing.T) {
+	doc, err := parseMarkup(markupResponse("text/html", cssTestHTML))
+	if err != nil {
+		t.Fatalf("failed to parse HTML: %v", err)
+	}
+
+	tests := []struct {
+		selector string
+		want     []string // Text of each matching element, in document order
+	}{
+		// Simple selectors
+		{"h1", []string{"Title"}},
+		{"P", []string{"P1", "P2", "P3", "P4"}},
+		{"#outer", []string{"P4"}},
+		{".intro", []string{"P1"}},
+		{"p.intro.lead", []string{"P1"}},
+		{"p.intro.missing", nil},
+		{"section *", []string{"only"}},
+
+		// Attribute operators
+		{"[data-tags]", []string{"P3"}},
+		{"[data-tags='a b c']", []string{"P3"}},
+		{`[data-tags~="b"]`, []string{"P3"}},
+		{"[data-tags~=a]", []string{"P3"}},
+		{"[data-tags~=ab]", nil},
+		{"[lang|=en]", []string{"Title"}},
+		{"[lang|=en-US]", []string{"Title"}},
+		{"[lang|=e]", nil},
+		{"[href^='https://']", []string{"A1"}},
+		{"[href$='.pdf']", []string{"A1"}},
+		{"[href*=example]", []string{"A1"}},
+		{"[href*=nope]", nil},
+
+		// Combinators
+		{"#main p", []string{"P1", "P2", "P3"}},
+		{"body > p", []string{"P4"}},
+		{"div>h1", []string{"Title"}},
+		{"h1 + p", []string{"P1"}},
+		{"span + p", []string{"P3"}},
+		{"span ~ p", []string{"P3"}},
+		{"h1 ~ p", []string{"P1", "P2", "P3"}},
+		{"div > ul li", []string{"L1", "L2", "L3", "L4", "L5"}},
+		{"ul > li + li + li", []string{"L3", "L4", "L5"}},
+
+		// Groups are returned once, in document order
+		{"#outer, h1, .intro, h1", []string{"Title", "P1", "P4"}},
+
+		// Pseudo-classes
+		{"li:first-child", []string{"L1"}},
+		{"li:last-child", []string{"L5"}},
+		{"section > :only-child", []string{"only"}},
+		{"li:only-child", nil},
+		{"li:nth-child(2)", []string{"L2"}},
+		{"li:nth-child(odd)", []string{"L1", "L3", "L5"}},
+		{"li:nth-child(even)", []string{"L2", "L4"}},
+		{"li:nth-child(2n+1)", []string{"L1", "L3", "L5"}},
+		{"li:nth-child(3n)", []string{"L3"}},
+		{"li:nth-child(n+4)", []string{"L4", "L5"}},
+		{"li:nth-child(-n+2)", []string{"L1", "L2"}},
+		{"li:nth-child( 2n - 1 )", []string{"L1", "L3", "L5"}},
+		{"li:nth-last-child(1)", []string{"L5"}},
+		{"li:nth-last-child(-n+2)", []string{"L4", "L5"}},
+		{"#main > p:first-of-type", []string{"P1"}},
+		{"#main > p:last-of-type", []string{"P3"}},
+		{"#main > p:nth-of-type(2)", []string{"P2"}},
+		{"#main > :empty", []string{""}},
+		{"#main > p:not(.intro)", []string{"P2", "P3"}},
+		{"li:not(:first-child):not(:last-child)", []string{"L2", "L3", "L4"}},
+		{"body > :not(div):not(section)", []string{"P4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			nodes, err := selectCSS(tt.selector, doc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, n := range nodes {
+				got = append(got, strings.TrimSpace(nodeText(n)))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectCSS_Errors(t *testing.T) {
+	doc, err := parseMarkup(markupResponse("text/html", "<p>x</p>"))
+	if err != nil {
+		t.Fatalf("failed to parse HTML: %v", err)
+	}
+	tests := []struct {
+		selector string
+		errMsg   string
+	}{
+		{"", "empty selector"},
+		{"p,", "empty selector"},
+		{"p >", "empty selector"},
+		{"> p", `unexpected '>'`},
+		{"#", "expected an id after #"},
+		{"p.", "expected a class after ."},
+		{"[", "expected an attribute name after ["},
+		{"[a='x]", "unterminated string"},
+		{"[a=x", "expected ] after attribute a"},
+		{"p:hover", "unsupported pseudo-class :hover"},
+		{"p:first-child(2)", ":first-child takes no argument"},
+		{"p:nth-child(x)", "expected a number, odd, even or an+b"},
+		{"p:nth-child(2", "expected ) after :nth-child("},
+		{"p:not(div p)", ":not() takes a simple selector"},
+		{"p!", `unexpected '!'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			_, err := selectCSS(tt.selector, doc)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tt.errMsg)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,733 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/blackcoderx/zap/pkg/storage"
+)
+
+// curlValueFlags are the curl options that take a value, by short and long name.
+var curlValueFlags = map[string]string{
+	"-X": "--request", "-H": "--header", "-d": "--data", "-u": "--user",
+	"-b": "--cookie", "-A": "--user-agent", "-e": "--referer", "-m": "--max-time",
+	"-o": "--output", "-F": "--form", "-x": "--proxy", "-w": "--write-out",
+	"-T": "--upload-file", "-E": "--cert", "-c": "--cookie-jar",
+}
+
+// curlIgnoredValueFlags take a value that ZAP doesn't use.
+var curlIgnoredValueFlags = map[string]bool{
+	"--connect-timeout": true, "--retry": true, "--retry-delay": true, "--write-out": true,
+	"--cookie-jar": true, "--proxy": true, "--cert": true, "--key": true, "--limit-rate": true,
+	"--resolve": true, "--interface": true,
+}
+
+// ParseCurlCommand converts a curl command line into an HTTPRequest.
+// Unsupported options that don't change the request are ignored and
+// reported as warnings.
+func ParseCurlCommand(command string) (*HTTPRequest, []string, error) {
+	args, err := splitShellWords(command)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(args) > 0 && (args[0] == "curl" || strings.HasSuffix(args[0], "/curl") || args[0] == "curl.exe") {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil, nil, fmt.Errorf("empty curl command")
+	}
+
+	req := &HTTPRequest{Headers: make(map[string]string)}
+	var warnings, data []string
+	var method string
+	getMode, headMode, follow, jsonMode, hasBodyFile := false, false, false, false, false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// Positional argument: the URL
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			req.URL = arg
+			continue
+		}
+
+		// Normalize "--opt=value", "-XPOST" and "-sSL" forms
+		flag, value, hasValue := arg, "", false
+		if strings.HasPrefix(arg, "--") {
+			if name, v, ok := strings.Cut(arg, "="); ok {
+				flag, value, hasValue = name, v, true
+			}
+		} else if len(arg) > 2 {
+			if _, takesValue := curlValueFlags[arg[:2]]; takesValue {
+				flag, value, hasValue = arg[:2], arg[2:], true
+			} else {
+				// Bundled boolean flags: expand and re-process
+				var expanded []string
+				for _, c := range arg[1:] {
+					expanded = append(expanded, "-"+string(c))
+				}
+				args = append(args[:i], append(expanded, args[i+1:]...)...)
+				i--
+				continue
+			}
+		}
+		if long, ok := curlValueFlags[flag]; ok {
+			flag = long
+		}
+
+		takesValue := curlIgnoredValueFlags[flag]
+		switch flag {
+		case "--request", "--header", "--data", "--data-raw", "--data-binary", "--data-ascii",
+			"--data-urlencode", "--json", "--user", "--cookie", "--user-agent", "--referer",
+			"--max-time", "--output", "--form", "--url", "--cacert", "--max-redirs", "--upload-file":
+			takesValue = true
+		}
+		if takesValue && !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("option %s needs a value", flag)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch flag {
+		case "--request":
+			method = strings.ToUpper(value)
+		case "--url":
+			req.URL = value
+		case "--header":
+			name, v, ok := strings.Cut(value, ":")
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("ignored malformed header %q", value))
+				continue
+			}
+			addCurlHeader(req.Headers, strings.TrimSpace(name), strings.TrimSpace(v))
+		case "--data", "--data-ascii", "--data-binary", "--data-raw":
+			if strings.HasPrefix(value, "@") && flag != "--data-raw" {
+				// Read at send time, within the project directory
+				req.BodyFile = value[1:]
+				hasBodyFile = true
+				continue
+			}
+			data = append(data, value)
+		case "--data-urlencode":
+			data = append(data, curlURLEncode(value))
+		case "--json":
+			data = append(data, value)
+			jsonMode = true
+		case "--user":
+			req.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(value))
+		case "--cookie":
+			if strings.Contains(value, "=") {
+				addCurlHeader(req.Headers, "Cookie", value)
+			} else {
+				warnings = append(warnings, "ignored cookie file "+value)
+			}
+		case "--user-agent":
+			req.Headers["User-Agent"] = value
+		case "--referer":
+			req.Headers["Referer"] = value
+		case "--max-time":
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid --max-time %q", value)
+			}
+			req.Timeout = int(seconds + 0.999)
+		case "--max-redirs":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid --max-redirs %q", value)
+			}
+			req.MaxRedirects = n
+		case "--output":
+			req.SaveBodyTo = value
+		case "--cacert":
+			req.TLS = mergeTLS(req.TLS, TLSConfig{CAFile: value})
+		case "-k", "--insecure":
+			req.TLS = mergeTLS(req.TLS, TLSConfig{InsecureSkipVerify: true})
+		case "-L", "--location":
+			follow = true
+		case "-G", "--get":
+			getMode = true
+		case "-I", "--head":
+			headMode = true
+		case "--form", "--upload-file":
+			return nil, nil, fmt.Errorf("%s is not supported yet; send the body with -d or body_file instead", flag)
+		case "--http1.1":
+			req.Protocol = ProtocolHTTP1
+		case "--http2-prior-knowledge":
+			req.Protocol = ProtocolHTTP2
+		case "--http3", "--http3-only":
+			req.Protocol = ProtocolHTTP3
+		case "--compressed", "-s", "--silent", "-S", "--show-error", "-v", "--verbose", "-i", "--include",
+			"-f", "--fail", "--http2", "-N", "--no-buffer", "-#", "--progress-bar":
+			// Output/transport options that don't change the request
+		default:
+			if !curlIgnoredValueFlags[flag] {
+				warnings = append(warnings, "ignored unsupported option "+flag)
+			} else {
+				warnings = append(warnings, fmt.Sprintf("ignored %s %s", flag, value))
+			}
+		}
+	}
+
+	if req.URL == "" {
+		return nil, nil, fmt.Errorf("no URL found in curl command")
+	}
+	if !strings.Contains(req.URL, "://") {
+		req.URL = "http://" + req.URL // curl's default scheme
+	}
+
+	// curl doesn't follow redirects unless -L is given
+	if !follow {
+		req.FollowRedirects = new(bool)
+	}
+
+	if hasBodyFile && len(data) > 0 {
+		return nil, nil, fmt.Errorf("combining -d @file with other data is not supported")
+	}
+	if hasBodyFile {
+		// curl labels file data like any other -d data
+		setDefaultHeader(req.Headers, "Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	body := strings.Join(data, "&")
+	switch {
+	case getMode && len(data) > 0:
+		// -G appends the data to the URL as a query string
+		sep := "?"
+		if strings.Contains(req.URL, "?") {
+			sep = "&"
+		}
+		req.URL += sep + body
+	case len(data) > 0:
+		if jsonMode {
+			setDefaultHeader(req.Headers, "Content-Type", "application/json")
+			setDefaultHeader(req.Headers, "Accept", "application/json")
+		}
+		setDefaultHeader(req.Headers, "Content-Type", "application/x-www-form-urlencoded")
+		req.Body = body
+		// Keep JSON bodies structured so they are readable and editable
+		var parsed interface{}
+		if isJSONContentType(headerValue(req.Headers, "Content-Type")) && json.Unmarshal([]byte(body), &parsed) == nil {
+			req.Body = parsed
+		}
+	}
+
+	switch {
+	case method != "":
+		req.Method = method
+	case headMode:
+		req.Method = "HEAD"
+	case (len(data) > 0 && !getMode) || hasBodyFile:
+		req.Method = "POST"
+	default:
+		req.Method = "GET"
+	}
+
+	if len(req.Headers) == 0 {
+		req.Headers = nil
+	}
+	return req, warnings, nil
+}
+
+// setDefaultHeader sets a header unless it is already present (in any case)
+func setDefaultHeader(headers map[string]string, name, value string) {
+	if headerValue(headers, name) == "" {
+		headers[name] = value
+	}
+}
+
+// addCurlHeader adds a header the way curl sends repeats of it: as one
+// comma-separated value (Cookie pairs are joined with "; ")
+func addCurlHeader(headers map[string]string, name, value string) {
+	for key, existing := range headers {
+		if strings.EqualFold(key, name) {
+			sep := ", "
+			if strings.EqualFold(name, "Cookie") {
+				sep = "; "
+			}
+			headers[key] = existing + sep + value
+			return
+		}
+	}
+	headers[name] = value
+}
+
+// mergeTLS adds TLS settings from one curl option to those already parsed
+func mergeTLS(current *TLSConfig, add TLSConfig) *TLSConfig {
+	if current == nil {
+		current = &TLSConfig{}
+	}
+	if add.CAFile != "" {
+		current.CAFile = add.CAFile
+	}
+	current.InsecureSkipVerify = current.InsecureSkipVerify || add.InsecureSkipVerify
+	return current
+}
+
+// curlURLEncode implements --data-urlencode's "content", "=content" and
+// "name=content" forms.
+func curlURLEncode(value string) string {
+	name, content, ok := strings.Cut(value, "=")
+	if !ok {
+		return url.QueryEscape(value)
+	}
+	if name == "" {
+		return url.QueryEscape(content)
+	}
+	return name + "=" + url.QueryEscape(content)
+}
+
+// splitShellWords splits a command line like a POSIX shell: single and
+// double quotes, $'...' strings, backslash escapes and line continuations.
+func splitShellWords(line string) ([]string, error) {
+	var words []string
+	var current strings.Builder
+	inWord := false
+	runes := []rune(line)
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(runes) {
+				i++
+				if runes[i] == '\n' || runes[i] == '\r' {
+					continue // line continuation
+				}
+				current.WriteRune(runes[i])
+				inWord = true
+			}
+		case c == '\'':
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			current.WriteString(string(runes[i+1 : end]))
+			i = end
+			inWord = true
+		case c == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			// ANSI-C quoting, as produced by browsers' "Copy as cURL"
+			i += 2
+			for ; i < len(runes) && runes[i] != '\''; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						current.WriteRune('\n')
+					case 't':
+						current.WriteRune('\t')
+					case 'r':
+						current.WriteRune('\r')
+					default:
+						current.WriteRune(runes[i])
+					}
+					continue
+				}
+				current.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated $'...' string")
+			}
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				current.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
+
+// indexRune returns the index of r in runes at or after start, or -1
+func indexRune(runes []rune, start int, r rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// CurlImportTool parses pasted curl commands, runs them and saves them as requests
+type CurlImportTool struct {
+	httpTool        *HTTPTool
+	responseManager *ResponseManager
+	persistence     *PersistenceTool
+	varStore        *VariableStore
+
+	mu   sync.Mutex
+	last *HTTPRequest // Last imported request, for save_as without a command
+}
+
+// NewCurlImportTool creates a new curl import tool
+func NewCurlImportTool(httpTool *HTTPTool, responseManager *ResponseManager, persistence *PersistenceTool, varStore *VariableStore) *CurlImportTool {
+	return &CurlImportTool{
+		httpTool:        httpTool,
+		responseManager: responseManager,
+		persistence:     persistence,
+		varStore:        varStore,
+	}
+}
+
+// CurlImportParams defines the curl import parameters
+type CurlImportParams struct {
+	Command string `json:"command,omitempty"`
+	Run     *bool  `json:"run,omitempty"`     // Execute the request (default true)
+	SaveAs  string `json:"save_as,omitempty"` // Save as .zap/requests/<name>.yaml
+}
+
+// Name returns the tool name
+func (t *CurlImportTool) Name() string {
+	return "import_curl"
+}
+
+// Description returns the tool description
+func (t *CurlImportTool) Description() string {
+	return "Convert a curl command into an HTTP request, run it (unless run=false) and optionally save it with save_as. Call with only save_as to save the last imported request. Supports -X, -H, -d/--data*, --json, -u, -b, -G, -I, -L, -k, --cacert, -m and -o."
+}
+
+// Parameters returns the tool parameter description
+func (t *CurlImportTool) Parameters() string {
+	return `{
+  "command": "curl -X POST https://api.example.com/users -H 'Content-Type: application/json' -d '{\"name\": \"zap\"}'",
+  "run": true,
+  "save_as": "optional request name"
+}`
+}
+
+// Execute parses, runs and/or saves a curl command
+func (t *CurlImportTool) Execute(args string) (string, error) {
+	var params CurlImportParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	var sb strings.Builder
+	var req *HTTPRequest
+	if params.Command == "" {
+		if params.SaveAs == "" {
+			return "", fmt.Errorf("'command' is required")
+		}
+		t.mu.Lock()
+		req = t.last
+		t.mu.Unlock()
+		if req == nil {
+			return "", fmt.Errorf("no curl command imported yet; pass 'command'")
+		}
+	} else {
+		parsed, warnings, err := ParseCurlCommand(params.Command)
+		if err != nil {
+			return "", err
+		}
+		req = parsed
+		t.mu.Lock()
+		t.last = req
+		t.mu.Unlock()
+
+		var reqJSON bytes.Buffer
+		enc := json.NewEncoder(&reqJSON)
+		enc.SetEscapeHTML(false) // keep "&" in form bodies readable
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(req)
+		sb.WriteString("Parsed request:\n```json\n" + reqJSON.String() + "```\n")
+		for _, w := range warnings {
+			sb.WriteString("Warning: " + w + "\n")
+		}
+
+		if params.Run == nil || *params.Run {
+			runReq := *req
+			if t.varStore != nil {
+				// Allow {{VAR}} placeholders in pasted commands
+				data, _ := json.Marshal(runReq)
+				_ = json.Unmarshal([]byte(t.varStore.Substitute(string(data))), &runReq)
+			}
+			t.httpTool.SetLastRequest(*req)
+			resp, err := t.httpTool.Run(runReq)
+			if err != nil {
+				return sb.String(), err
+			}
+			if t.responseManager != nil {
+				t.responseManager.SetHTTPResponse(resp)
+			}
+			sb.WriteString("\n" + resp.FormatResponse() + "\n")
+		}
+	}
+
+	if params.SaveAs == "" {
+		sb.WriteString("\nTo keep it, save it with import_curl {\"save_as\": \"<name>\"} (or /curl save <name>).")
+		return sb.String(), nil
+	}
+
+	path, err := t.persistence.SaveRequest(curlToStoredRequest(params.SaveAs, req), "")
+	if err != nil {
+		return sb.String(), err
+	}
+	sb.WriteString(fmt.Sprintf("\nRequest saved to %s", path))
+	return sb.String(), nil
+}
+
+// curlToStoredRequest converts an imported request to the saved request format
+func curlToStoredRequest(name string, req *HTTPRequest) storage.Request {
+	stored := storage.Request{
+		Name:    name,
+		Method:  req.Method,
+		URL:     req.URL,
+		Headers: req.Headers,
+		Body:    req.Body,
+	}
+	if len(req.Query) > 0 {
+		stored.Query = make(map[string]string, len(req.Query))
+		for key, value := range req.Query {
+			stored.Query[key] = queryValueString(value)
+		}
+	}
+	return stored
+}
+
+// FormatCurlCommand renders req as a copy-pasteable curl command.
+func FormatCurlCommand(req *HTTPRequest) (string, error) {
+	rawURL := req.URL
+	if len(req.Query) > 0 {
+		merged, err := mergeQuery(rawURL, req.Query)
+		if err != nil {
+			return "", err
+		}
+		// Keep {{VAR}} placeholders in query values readable
+		rawURL = strings.NewReplacer("%7B%7B", "{{", "%7D%7D", "}}").Replace(merged)
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+
+	// "curl -X METHOD URL" on the first line, then one option per line
+	first := "curl "
+	if method == "HEAD" {
+		first += "-I "
+	} else if method != "GET" {
+		first += "-X " + method + " "
+	}
+	parts := []string{first + shellQuote(rawURL)}
+
+	// Sorted for stable output
+	names := make([]string, 0, len(req.Headers))
+	for name := range req.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, "-H "+shellQuote(name+": "+req.Headers[name]))
+	}
+
+	switch {
+	case req.BodyFile != "":
+		parts = append(parts, "--data-binary "+shellQuote("@"+req.BodyFile))
+	case req.Body != nil:
+		// Mirrors http_request: strings with a non-JSON Content-Type are sent
+		// as-is, everything else as JSON
+		body, isString := req.Body.(string)
+		if !isString || isJSONContentType(headerValue(req.Headers, "Content-Type")) {
+			data, err := json.Marshal(req.Body)
+			if err != nil {
+				return "", fmt.Errorf("failed to marshal body: %w", err)
+			}
+			body = string(data)
+			if headerValue(req.Headers, "Content-Type") == "" {
+				parts = append(parts, "-H "+shellQuote("Content-Type: application/json"))
+			}
+		}
+		parts = append(parts, "--data-raw "+shellQuote(body))
+	}
+
+	if req.FollowRedirects == nil || *req.FollowRedirects {
+		parts = append(parts, "-L")
+		if req.MaxRedirects > 0 {
+			parts = append(parts, "--max-redirs "+strconv.Itoa(req.MaxRedirects))
+		}
+	}
+	if req.TLS != nil {
+		if req.TLS.InsecureSkipVerify {
+			parts = append(parts, "-k")
+		}
+		if req.TLS.CAFile != "" {
+			parts = append(parts, "--cacert "+shellQuote(req.TLS.CAFile))
+		}
+	}
+	switch req.Protocol {
+	case ProtocolHTTP1:
+		parts = append(parts, "--http1.1")
+	case ProtocolHTTP2:
+		if strings.HasPrefix(strings.ToLower(rawURL), "http://") {
+			parts = append(parts, "--http2-prior-knowledge")
+		} else {
+			parts = append(parts, "--http2")
+		}
+	case ProtocolHTTP3:
+		parts = append(parts, "--http3-only")
+	}
+	if req.Timeout > 0 {
+		parts = append(parts, "-m "+strconv.Itoa(req.Timeout))
+	}
+	if req.SaveBodyTo != "" {
+		parts = append(parts, "-o "+shellQuote(req.SaveBodyTo))
+	}
+
+	return strings.Join(parts, " \\\n  "), nil
+}
+
+// shellQuote quotes s for POSIX shells, leaving simple words unquoted
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:@%+=,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// CurlExportTool converts saved or last-executed requests to curl commands
+type CurlExportTool struct {
+	httpTool    *HTTPTool
+	persistence *PersistenceTool
+	varStore    *VariableStore
+}
+
+// NewCurlExportTool creates a new curl export tool
+func NewCurlExportTool(httpTool *HTTPTool, persistence *PersistenceTool, varStore *VariableStore) *CurlExportTool {
+	return &CurlExportTool{
+		httpTool:    httpTool,
+		persistence: persistence,
+		varStore:    varStore,
+	}
+}
+
+// CurlExportParams defines the curl export parameters
+type CurlExportParams struct {
+	Name    string `json:"name,omitempty"`    // Saved request; empty for the last executed request
+	Resolve bool   `json:"resolve,omitempty"` // Substitute {{VAR}} placeholders
+}
+
+// Name returns the tool name
+func (t *CurlExportTool) Name() string {
+	return "export_curl"
+}
+
+// Description returns the tool description
+func (t *CurlExportTool) Description() string {
+	return "Convert a saved request (name) or the last executed request (no name) into a copy-pasteable curl command. Variables stay as {{VAR}} placeholders unless resolve=true, which fills them from the active environment and session variables."
+}
+
+// Parameters returns the tool parameter description
+func (t *CurlExportTool) Parameters() string {
+	return `{
+  "name": "optional saved request name (default: last executed request)",
+  "resolve": false
+}`
+}
+
+// Execute renders the request as a curl command
+func (t *CurlExportTool) Execute(args string) (string, error) {
+	var params CurlExportParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	var req *HTTPRequest
+	if params.Name != "" {
+		stored, err := t.persistence.LoadRequest(params.Name)
+		if err != nil {
+			return "", err
+		}
+		req = storedToHTTPRequest(stored)
+	} else {
+		if t.httpTool != nil {
+			req = t.httpTool.LastRequest()
+		}
+		if req == nil {
+			return "", fmt.Errorf("no request has been executed yet; pass 'name' to export a saved request")
+		}
+	}
+
+	if params.Resolve {
+		resolved, err := t.resolveVariables(req)
+		if err != nil {
+			return "", err
+		}
+		req = resolved
+	}
+
+	return FormatCurlCommand(req)
+}
+
+// resolveVariables substitutes environment and session variables everywhere
+// in the request, including structured bodies.
+func (t *CurlExportTool) resolveVariables(req *HTTPRequest) (*HTTPRequest, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	text := string(data)
+	if t.persistence != nil {
+		text = storage.SubstituteVariables(text, t.persistence.GetEnvironment())
+	}
+	if t.varStore != nil {
+		text = t.varStore.Substitute(text)
+	}
+
+	var resolved HTTPRequest
+	if err := json.Unmarshal([]byte(text), &resolved); err != nil {
+		return nil, fmt.Errorf("failed to resolve variables: %w", err)
+	}
+	return &resolved, nil
+}
+
+// storedToHTTPRequest converts a saved request to an HTTPRequest
+func storedToHTTPRequest(stored *storage.Request) *HTTPRequest {
+	req := &HTTPRequest{
+		Method:  stored.Method,
+		URL:     stored.URL,
+		Headers: stored.Headers,
+		Body:    stored.Body,
+		UseAuth: stored.UseAuth,
+	}
+	if len(stored.Query) > 0 {
+		req.Query = make(map[string]interface{}, len(stored.Query))
+		for key, value := range stored.Query {
+			req.Query[key] = value
+		}
+	}
+	return req
+}
@@ -0,0 +1,305 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"curl https://a.test", []string{"curl", "https://a.test"}},
+		{"  curl \t -s  ", []string{"curl", "-s"}},
+		{`-H 'X-Name: a b'`, []string{"-H", "X-Name: a b"}},
+		{`-d '{"a": "it'\''s"}'`, []string{"-d", `{"a": "it's"}`}},
+		{`-d "a \"b\" \$c \\ \d"`, []string{"-d", `a "b" $c \ \d`}},
+		{`-d $'line\nnext\ttab \'q\''`, []string{"-d", "line\nnext\ttab 'q'"}},
+		{`a\ b c`, []string{"a b", "c"}},
+		{"curl \\\n  -s \\\r\n  url", []string{"curl", "-s", "url"}},
+		{`''`, []string{""}},
+		{`pre'mid'"end"`, []string{"premidend"}},
+		{`"héllo wörld"`, []string{"héllo wörld"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := splitShellWords(tt.line)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for line, errMsg := range map[string]string{
+		`'open`:     "unterminated single quote",
+		`"open`:     "unterminated double quote",
+		`$'open`:    "unterminated $'...' string",
+		`-d "a\"b'`: "unterminated double quote",
+	} {
+		if _, err := splitShellWords(line); err == nil || !strings.Contains(err.Error(), errMsg) {
+			t.Errorf("%s: error = %v, want it to contain %q", line, err, errMsg)
+		}
+	}
+}
+
+func TestParseCurlCommand(t *testing.T) {
+	noFollow := false
+	tests := []struct {
+		name     string
+		command  string
+		want     HTTPRequest
+		warnings []string
+	}{
+		{
+			name:    "plain GET",
+			command: "curl https://api.test/users",
+			want:    HTTPRequest{Method: "GET", URL: "https://api.test/users", FollowRedirects: &noFollow},
+		},
+		{
+			name:    "default scheme",
+			command: "curl localhost:3000/health",
+			want:    HTTPRequest{Method: "GET", URL: "http://localhost:3000/health", FollowRedirects: &noFollow},
+		},
+		{
+			name:    "method forms",
+			command: "curl -XDELETE --url https://api.test/users/1",
+			want:    HTTPRequest{Method: "DELETE", URL: "https://api.test/users/1", FollowRedirects: &noFollow},
+		},
+		{
+			name:    "repeated headers",
+			command: `curl https://api.test -H 'Accept: text/html' -H "X-Trace:abc" -H 'accept: application/json' -H 'Cookie: a=1' -H 'Cookie: b=2'`,
+			want: HTTPRequest{Method: "GET", URL: "https://api.test", FollowRedirects: &noFollow, Headers: map[string]string{
+				"Accept":  "text/html, application/json",
+				"X-Trace": "abc",
+				"Cookie":  "a=1; b=2",
+			}},
+		},
+		{
+			name:    "header value with a colon",
+			command: `curl https://api.test -H 'Referer: https://app.test/x'`,
+			want:    HTTPRequest{Method: "GET", URL: "https://api.test", FollowRedirects: &noFollow, Headers: map[string]string{"Referer": "https://app.test/x"}},
+		},
+		{
+			name:     "malformed header",
+			command:  `curl https://api.test -H 'NoColon'`,
+			want:     HTTPRequest{Method: "GET", URL: "https://api.test", FollowRedirects: &noFollow},
+			warnings: []string{`ignored malformed header "NoColon"`},
+		},
+		{
+			name:    "form data",
+			command: "curl https://api.test/login -d user=ada -d 'pass=a b'",
+			want: HTTPRequest{Method: "POST", URL: "https://api.test/login", FollowRedirects: &noFollow,
+				Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+				Body:    "user=ada&pass=a b"},
+		},
+		{
+			name:    "JSON data stays structured",
+			command: `curl -X PUT https://api.test/users/1 -H 'Content-Type: application/json' -d '{"name": "Ada", "tags": ["x"]}'`,
+			want: HTTPRequest{Method: "PUT", URL: "https://api.test/users/1", FollowRedirects: &noFollow,
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    map[string]interface{}{"name": "Ada", "tags": []interface{}{"x"}}},
+		},
+		{
+			name:    "--json",
+			command: `curl --json '{"a":1}' https://api.test`,
+			want: HTTPRequest{Method: "POST", URL: "https://api.test", FollowRedirects: &noFollow,
+				Headers: map[string]string{"Content-Type": "application/json", "Accept": "application/json"},
+				Body:    map[string]interface{}{"a": 1.0}},
+		},
+		{
+			name:    "-d @file reads the file at send time",
+			command: "curl https://api.test/upload -d @payload.json",
+			want: HTTPRequest{Method: "POST", URL: "https://api.test/upload", FollowRedirects: &noFollow,
+				Headers:  map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+				BodyFile: "payload.json"},
+		},
+		{
+			name:    "--data-binary @file with a content type",
+			command: "curl https://api.test/upload -H 'Content-Type: image/png' --data-binary @img/logo.png",
+			want: HTTPRequest{Method: "POST", URL: "https://api.test/upload", FollowRedirects: &noFollow,
+				Headers:  map[string]string{"Content-Type": "image/png"},
+				BodyFile: "img/logo.png"},
+		},
+		{
+			name:    "--data-raw keeps a leading @",
+			command: "curl https://api.test --data-raw @handle",
+			want: HTTPRequest{Method: "POST", URL: "https://api.test", FollowRedirects: &noFollow,
+				Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+				Body:    "@handle"},
+		},
+		{
+			name:    "--data-urlencode forms",
+			command: "curl https://api.test --data-urlencode 'q=a b&c' --data-urlencode '=x/y' --data-urlencode plain",
+			want: HTTPRequest{Method: "POST", URL: "https://api.test", FollowRedirects: &noFollow,
+				Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+				Body:    "q=a+b%26c&x%2Fy&plain"},
+		},
+		{
+			name:    "-G moves data to the query",
+			command: "curl -G 'https://api.test/search?page=2' -d q=go --data-urlencode 'tag=a b'",
+			want:    HTTPRequest{Method: "GET", URL: "https://api.test/search?page=2&q=go&tag=a+b", FollowRedirects: &noFollow},
+		},
+		{
+			name:    "-u",
+			command: "curl -u ada:s3cr3t:x https://api.test",
+			want: HTTPRequest{Method: "GET", URL: "https://api.test", FollowRedirects: &noFollow,
+				Headers: map[string]string{"Authorization": "Basic YWRhOnMzY3IzdDp4"}},
+		},
+		{
+			name:    "--user=",
+			command: "curl --user=ada:pw https://api.test",
+			want: HTTPRequest{Method: "GET", URL: "https://api.test", FollowRedirects: &noFollow,
+				Headers: map[string]string{"Authorization": "Basic YWRhOnB3"}},
+		},
+		{
+			name:    "bundled flags, redirects and TLS",
+			command: "curl -sSLk --max-redirs 3 --cacert ca.pem -m 2.5 -o out.bin https://api.test",
+			want: HTTPRequest{Method: "GET", URL: "https://api.test", MaxRedirects: 3, Timeout: 3, SaveBodyTo: "out.bin",
+				TLS: &TLSConfig{InsecureSkipVerify: true, CAFile: "ca.pem"}},
+		},
+		{
+			name:    "-I",
+			command: "curl -I https://api.test",
+			want:    HTTPRequest{Method: "HEAD", URL: "https://api.test", FollowRedirects: &noFollow},
+		},
+		{
+			name:    "cookies, user agent and referer",
+			command: "curl -b 'sid=1' -b jar.txt -A zap/1 -e https://ref.test https://api.test",
+			want: HTTPRequest{Method: "GET", URL: "https://api.test", FollowRedirects: &noFollow,
+				Headers: map[string]string{"Cookie": "sid=1", "User-Agent": "zap/1", "Referer": "https://ref.test"}},
+			warnings: []string{"ignored cookie file jar.txt"},
+		},
+		{
+			name: "browser copy as cURL",
+			command: "curl 'https://api.test/graphql' \\\n  -H 'accept: */*' \\\n  -H 'content-type: application/json' \\\n" +
+				"  --data-raw $'{\"query\":\"{ me { name } }\",\"note\":\"it\\'s\"}' \\\n  --compressed",
+			want: HTTPRequest{Method: "POST", URL: "https://api.test/graphql", FollowRedirects: &noFollow,
+				Headers: map[string]string{"accept": "*/*", "content-type": "application/json"},
+				Body:    map[string]interface{}{"query": "{ me { name } }", "note": "it's"}},
+		},
+		{
+			name:    "protocols",
+			command: "curl --http1.1 https://api.test",
+			want:    HTTPRequest{Method: "GET", URL: "https://api.test", FollowRedirects: &noFollow, Protocol: ProtocolHTTP1},
+		},
+		{
+			name:     "ignored options",
+			command:  "curl --retry 3 --proxy http://p:8080 --tcp-nodelay https://api.test",
+			want:     HTTPRequest{Method: "GET", URL: "https://api.test", FollowRedirects: &noFollow},
+			warnings: []string{"ignored --retry 3", "ignored --proxy http://p:8080", "ignored unsupported option --tcp-nodelay"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings, err := ParseCurlCommand(tt.command)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", *got, tt.want)
+			}
+			if !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("warnings = %q, want %q", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestParseCurlCommand_Errors(t *testing.T) {
+	tests := []struct {
+		command string
+		errMsg  string
+	}{
+		{"curl", "empty curl command"},
+		{"curl -s", "no URL found"},
+		{"curl https://api.test -H", "option --header needs a value"},
+		{"curl https://api.test -m soon", "invalid --max-time"},
+		{"curl https://api.test -F file=@a.png", "--form is not supported"},
+		{"curl https://api.test -d @a.json -d b=1", "combining -d @file with other data"},
+		{"curl 'https://api.test", "unterminated single quote"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			_, _, err := ParseCurlCommand(tt.command)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestFormatCurlCommand(t *testing.T) {
+	follow := true
+	got, err := FormatCurlCommand(&HTTPRequest{
+		Method:  "post",
+		URL:     "https://api.test/users",
+		Query:   map[string]interface{}{"tag": []interface{}{"a", "b"}, "q": "{{NAME}}"},
+		Headers: map[string]string{"X-Note": "it's", "Authorization": "Bearer {{TOKEN}}"},
+		Body:    map[string]interface{}{"name": "Ada"},
+		Timeout: 5, FollowRedirects: &follow, MaxRedirects: 2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "curl -X POST 'https://api.test/users?q={{NAME}}&tag=a&tag=b' \\\n" +
+		"  -H 'Authorization: Bearer {{TOKEN}}' \\\n" +
+		"  -H 'X-Note: it'\\''s' \\\n" +
+		"  -H 'Content-Type: application/json' \\\n" +
+		"  --data-raw '{\"name\":\"Ada\"}' \\\n" +
+		"  -L \\\n" +
+		"  --max-redirs 2 \\\n" +
+		"  -m 5"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCurlRoundTrip(t *testing.T) {
+	noFollow := false
+	tests := []struct {
+		name string
+		req  HTTPRequest
+	}{
+		{"GET", HTTPRequest{Method: "GET", URL: "https://api.test/users?page=2", FollowRedirects: &noFollow}},
+		{"HEAD following redirects", HTTPRequest{Method: "HEAD", URL: "https://api.test", MaxRedirects: 4}},
+		{"JSON body", HTTPRequest{Method: "POST", URL: "https://api.test/users", FollowRedirects: &noFollow,
+			Headers: map[string]string{"Content-Type": "application/json", "X-Quote": `it's "quoted" $HOME`},
+			Body:    map[string]interface{}{"name": "O'Brien", "n": 2.0}}},
+		{"form body", HTTPRequest{Method: "PATCH", URL: "https://api.test/form", FollowRedirects: &noFollow,
+			Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			Body:    "a=1&b=two words"}},
+		{"text body with newlines", HTTPRequest{Method: "PUT", URL: "https://api.test/notes/1", FollowRedirects: &noFollow,
+			Headers: map[string]string{"Content-Type": "text/plain"},
+			Body:    "line one\nline 'two'\n"}},
+		{"body file", HTTPRequest{Method: "POST", URL: "https://api.test/upload", FollowRedirects: &noFollow,
+			Headers:  map[string]string{"Content-Type": "application/octet-stream"},
+			BodyFile: "data/blob.bin"}},
+		{"TLS, timeout, output and protocol", HTTPRequest{Method: "GET", URL: "http://localhost:8443/x", FollowRedirects: &noFollow,
+			TLS: &TLSConfig{InsecureSkipVerify: true, CAFile: "certs/ca dev.pem"}, Timeout: 30, SaveBodyTo: "out/x.bin", Protocol: ProtocolHTTP2}},
+		{"HTTP/1.1", HTTPRequest{Method: "DELETE", URL: "https://api.test/x", FollowRedirects: &noFollow, Protocol: ProtocolHTTP1}},
+		{"HTTP/3", HTTPRequest{Method: "GET", URL: "https://api.test/x", FollowRedirects: &noFollow, Protocol: ProtocolHTTP3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, err := FormatCurlCommand(&tt.req)
+			if err != nil {
+				t.Fatalf("failed to format: %v", err)
+			}
+			got, warnings, err := ParseCurlCommand(command)
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", command, err)
+			}
+			if len(warnings) > 0 {
+				t.Errorf("warnings: %q", warnings)
+			}
+			if !reflect.DeepEqual(*got, tt.req) {
+				t.Errorf("%s\nparsed %+v\nwant   %+v", command, *got, tt.req)
+			}
+		})
+	}
+}
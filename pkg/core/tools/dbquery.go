@@ -0,0 +1,470 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultDBQueryLimit and maxDBQueryLimit bound the rows returned
+	defaultDBQueryLimit = 50
+	maxDBQueryLimit     = 500
+	// dbQueryTimeout stops queries that scan too much
+	dbQueryTimeout = 30 * time.Second
+)
+
+// DBQueryTool runs read-only SQL against the project's database through
+// the psql, mysql or sqlite3 client, so writes made through the API can be
+// checked at the source
+type DBQueryTool struct {
+	persistence *PersistenceTool
+}
+
+// NewDBQueryTool creates a new database query tool. The DSN is looked up in
+// the active environment, then in the process environment.
+func NewDBQueryTool(persistence *PersistenceTool) *DBQueryTool {
+	return &DBQueryTool{persistence: persistence}
+}
+
+// DBQueryParams defines the query parameters
+type DBQueryParams struct {
+	Query  string `json:"query"`
+	DSNVar string `json:"dsn_var,omitempty"` // Variable holding the DSN (default DATABASE_URL)
+	Limit  int    `json:"limit,omitempty"`   // Max rows returned (default 50, max 500)
+}
+
+// Name returns the tool name
+func (t *DBQueryTool) Name() string {
+	return "db_query"
+}
+
+// Description returns the tool description
+func (t *DBQueryTool) Description() string {
+	return "Run a read-only SQL query (SELECT, WITH, SHOW, EXPLAIN) against the project's Postgres, MySQL or SQLite database, with the DSN taken from an environment variable. Use it to verify an API write actually persisted the expected rows"
+}
+
+// Parameters returns the tool parameter description
+func (t *DBQueryTool) Parameters() string {
+	return `{"query": "string (required) - one read-only statement, e.g. SELECT id, email FROM users WHERE email = 'a@b.co'", "dsn_var": "string - environment variable holding the DSN (default DATABASE_URL)", "limit": "number - max rows (default 50, max 500)"}`
+}
+
+// Execute checks the query is read-only and runs it
+func (t *DBQueryTool) Execute(args string) (string, error) {
+	var params DBQueryParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if strings.TrimSpace(params.Query) == "" {
+		return "", fmt.Errorf("query is required")
+	}
+	if params.DSNVar == "" {
+		params.DSNVar = "DATABASE_URL"
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultDBQueryLimit
+	}
+	params.Limit = min(params.Limit, maxDBQueryLimit)
+
+	dsn := t.lookupDSN(params.DSNVar)
+	if dsn == "" {
+		return "", fmt.Errorf("%s is not set in the active environment or the process environment", params.DSNVar)
+	}
+	target, err := parseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	query, err := readOnlyQuery(params.Query, target.driver, params.Limit)
+	if err != nil {
+		return "", err
+	}
+
+	columns, rows, err := target.run(query)
+	if err != nil {
+		return "", err
+	}
+	return formatQueryRows(target, columns, rows, params.Limit), nil
+}
+
+// lookupDSN reads name from the active environment, then the process
+func (t *DBQueryTool) lookupDSN(name string) string {
+	if t.persistence != nil {
+		if dsn := t.persistence.GetEnvironment()[name]; dsn != "" {
+			return dsn
+		}
+	}
+	return os.Getenv(name)
+}
+
+// dbTarget is a database and the client command that queries it
+type dbTarget struct {
+	driver   string // postgres, mysql or sqlite
+	client   string // Client binary
+	args     []string
+	env      []string // Extra environment, e.g. the password
+	describe string   // Host and database, without credentials
+}
+
+// mysqlDriverDSN matches the go-sql-driver form user:pass@tcp(host:port)/db
+var mysqlDriverDSN = regexp.MustCompile(`^([^:@/]*)(?::([^@]*))?@(?:tcp\(([^)]*)\))?/([^?]*)`)
+
+// parseDSN works out the driver and client arguments for a DSN:
+// postgres://, mysql://, user:pass@tcp(host)/db, sqlite://path, file:path
+// or a path to a .db/.sqlite file
+func parseDSN(dsn string) (*dbTarget, error) {
+	scheme, _, _ := strings.Cut(dsn, "://")
+	switch strings.ToLower(scheme) {
+	case "postgres", "postgresql":
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse DSN: %w", err)
+		}
+		target := &dbTarget{
+			driver: "postgres",
+			client: "psql",
+			// Read-only transactions are the second line of defense after readOnlyQuery
+			env:      []string{"PGOPTIONS=-c default_transaction_read_only=on"},
+			describe: u.Host + u.Path,
+		}
+		// Keep the password out of the process list
+		if u.User != nil {
+			if password, ok := u.User.Password(); ok {
+				target.env = append(target.env, "PGPASSWORD="+password)
+			}
+			u.User = url.User(u.User.Username())
+		}
+		target.args = []string{"-X", "-q", "-v", "ON_ERROR_STOP=1", "--csv", "-P", "null=NULL", "-d", u.String()}
+		return target, nil
+
+	case "mysql", "mariadb":
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse DSN: %w", err)
+		}
+		password, _ := u.User.Password()
+		return mysqlTarget(u.User.Username(), password, u.Host, strings.TrimPrefix(u.Path, "/")), nil
+
+	case "sqlite", "sqlite3":
+		return sqliteTarget(strings.TrimPrefix(dsn, scheme+"://"))
+	}
+
+	if path, ok := strings.CutPrefix(dsn, "file:"); ok {
+		path, _, _ = strings.Cut(path, "?")
+		return sqliteTarget(path)
+	}
+	if m := mysqlDriverDSN.FindStringSubmatch(dsn); m != nil {
+		return mysqlTarget(m[1], m[2], m[3], m[4]), nil
+	}
+	switch strings.ToLower(dsn[strings.LastIndex(dsn, ".")+1:]) {
+	case "db", "sqlite", "sqlite3":
+		return sqliteTarget(dsn)
+	}
+	return nil, fmt.Errorf("unsupported DSN: use postgres://, mysql://, user:pass@tcp(host:port)/db, sqlite://path or a .db/.sqlite file")
+}
+
+// mysqlTarget builds the mysql client arguments for a server
+func mysqlTarget(user, password, host, database string) *dbTarget {
+	target := &dbTarget{driver: "mysql", client: "mysql", describe: host + "/" + database}
+	// The query goes in on stdin, where --binary-mode turns off the
+	// client's own backslash commands such as \! (run a shell command)
+	target.args = []string{"--batch", "--binary-mode"}
+	if h, port, ok := strings.Cut(host, ":"); ok {
+		target.args = append(target.args, "-h", h, "-P", port)
+	} else if host != "" {
+		target.args = append(target.args, "-h", host)
+	}
+	if user != "" {
+		target.args = append(target.args, "-u", user)
+	}
+	if database != "" {
+		target.args = append(target.args, "-D", database)
+	}
+	if password != "" {
+		target.env = append(target.env, "MYSQL_PWD="+password)
+	}
+	return target
+}
+
+// sqliteTarget opens a database file read-only with the sqlite3 client
+func sqliteTarget(path string) (*dbTarget, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	return &dbTarget{
+		driver:   "sqlite",
+		client:   "sqlite3",
+		args:     []string{"-readonly", "-csv", "-header", "-nullvalue", "NULL", path},
+		describe: path,
+	}, nil
+}
+
+// run executes query with the client and parses its output into rows
+func (t *dbTarget) run(query string) ([]string, [][]string, error) {
+	if _, err := exec.LookPath(t.client); err != nil {
+		return nil, nil, fmt.Errorf("%s not found on PATH: install the %s client to query this database", t.client, t.driver)
+	}
+
+	args := append([]string{}, t.args...)
+	var stdin string
+	switch t.driver {
+	case "postgres":
+		args = append(args, "-c", query)
+	case "mysql":
+		stdin = "SET SESSION TRANSACTION READ ONLY;\n" + query + ";\n"
+	case "sqlite":
+		args = append(args, query)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbQueryTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, t.client, args...)
+	cmd.Env = append(os.Environ(), t.env...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, nil, fmt.Errorf("query timed out after %s", dbQueryTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, nil, fmt.Errorf("query failed: %s", msg)
+		}
+		return nil, nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	if t.driver == "mysql" {
+		return parseMySQLBatch(stdout.String())
+	}
+	reader := csv.NewReader(&stdout)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s output: %w", t.client, err)
+	}
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+	return records[0], records[1:], nil
+}
+
+// parseMySQLBatch splits the tab-separated output of mysql --batch, whose
+// tabs, newlines and backslashes in values are escaped
+func parseMySQLBatch(output string) ([]string, [][]string, error) {
+	unescape := strings.NewReplacer(`\t`, "\t", `\n`, "\n", `\0`, "\x00", `\\`, `\`)
+	var records [][]string
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		for i, field := range fields {
+			fields[i] = unescape.Replace(field)
+		}
+		records = append(records, fields)
+	}
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+	return records[0], records[1:], nil
+}
+
+// readOnlyStarts are the statements db_query runs
+var readOnlyStarts = map[string]bool{
+	"SELECT": true, "WITH": true, "VALUES": true, "TABLE": true,
+	"SHOW": true, "EXPLAIN": true, "DESCRIBE": true, "DESC": true, "PRAGMA": true,
+}
+
+// firstSQLWord is the statement keyword, after any opening parentheses
+var firstSQLWord = regexp.MustCompile(`^[\s(]*[A-Za-z]+`)
+
+// writeKeywords can't appear anywhere in a query: they write from inside a
+// SELECT (data-modifying CTEs, SELECT INTO) or lock rows
+var writeKeywords = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|MERGE|UPSERT|INTO|DROP|CREATE|ALTER|TRUNCATE|GRANT|REVOKE|NEXTVAL|SETVAL|LO_IMPORT|LO_EXPORT|DBLINK_EXEC|PG_TERMINATE_BACKEND|PG_CANCEL_BACKEND|LOAD_FILE)\b`)
+
+// limitKeywords mark a query that already bounds its rows
+var limitKeywords = regexp.MustCompile(`(?i)\b(LIMIT|FETCH)\b`)
+
+// readOnlyQuery checks query is a single read-only statement and adds a
+// LIMIT of limit+1 rows (one extra to tell a truncated result) to queries
+// that don't have one
+func readOnlyQuery(query, driver string, limit int) (string, error) {
+	// Cut the comments, whitespace and semicolons around the statement:
+	// leading comments would read as options to the sqlite3 client, and a
+	// LIMIT after a trailing comment or ";" would be commented out or run
+	// as a statement of its own
+	masked := maskSQL(query, driver)
+	end := len(strings.TrimRight(masked, " \t\r\n;"))
+	start := end - len(strings.TrimLeft(masked[:end], " \t\r\n"))
+	query, masked = query[start:end], masked[start:end]
+	if masked == "" {
+		return "", fmt.Errorf("query is empty")
+	}
+	if strings.Contains(masked, ";") {
+		return "", fmt.Errorf("only one statement can be run at a time")
+	}
+	if driver == "mysql" && strings.Contains(masked, `\`) {
+		// Outside quotes a backslash starts a mysql client command
+		return "", fmt.Errorf("db_query is read-only: mysql client commands such as \\! and \\. are not allowed")
+	}
+
+	first := strings.ToUpper(strings.TrimLeft(firstSQLWord.FindString(masked), " \t\r\n("))
+	if first == "" {
+		return "", fmt.Errorf("query must start with SELECT, WITH, SHOW or EXPLAIN")
+	}
+	if !readOnlyStarts[first] {
+		return "", fmt.Errorf("db_query is read-only: %s statements are not allowed (use SELECT, WITH, SHOW or EXPLAIN)", first)
+	}
+	if m := writeKeywords.FindString(masked); m != "" {
+		return "", fmt.Errorf("db_query is read-only: %s is not allowed", strings.ToUpper(m))
+	}
+	if first == "PRAGMA" && strings.Contains(masked, "=") {
+		return "", fmt.Errorf("db_query is read-only: PRAGMA assignments are not allowed")
+	}
+
+	switch first {
+	case "SELECT", "WITH", "VALUES", "TABLE":
+		if !limitKeywords.MatchString(masked) {
+			query = fmt.Sprintf("%s\nLIMIT %d", query, limit+1)
+		}
+	}
+	return query, nil
+}
+
+// maskSQL blanks out comments, string literals and quoted identifiers so
+// keywords inside them aren't mistaken for SQL: comments become spaces and
+// quoted text question marks, so offsets in the result match query.
+// Postgres dollar-quoted strings are blanked too; # comments and backslash
+// escapes only exist in MySQL, elsewhere they would hide what follows them.
+func maskSQL(query, driver string) string {
+	mysql := driver == "mysql"
+	var sb strings.Builder
+	blank := func(from, to int, quoted bool) {
+		filler := " "
+		if quoted {
+			filler = "?"
+		}
+		sb.WriteString(strings.Repeat(filler, to-from))
+	}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '-' && strings.HasPrefix(query[i:], "--"), c == '#' && mysql:
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			blank(i, i+end, false)
+			i += end - 1
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i - 4
+			}
+			blank(i, i+end+4, false)
+			i += end + 3
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for j < len(query) {
+				if query[j] == '\\' && mysql {
+					j += 2
+					continue
+				}
+				if query[j] == c {
+					if j+1 < len(query) && query[j+1] == c {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			j = min(j, len(query)-1)
+			blank(i, j+1, true)
+			i = j
+		case c == '$':
+			tag := dollarQuoteTag.FindString(query[i:])
+			if tag == "" {
+				sb.WriteByte(c)
+				continue
+			}
+			end := strings.Index(query[i+len(tag):], tag)
+			if end < 0 {
+				end = len(query) - i - 2*len(tag)
+			}
+			blank(i, i+len(tag)+end+len(tag), true)
+			i += len(tag) + end + len(tag) - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// dollarQuoteTag matches the opening of a Postgres dollar-quoted string
+var dollarQuoteTag = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// formatQueryRows renders rows as an aligned table, keeping at most limit
+func formatQueryRows(target *dbTarget, columns []string, rows [][]string, limit int) string {
+	var sb strings.Builder
+	truncated := len(rows) > limit
+	if truncated {
+		rows = rows[:limit]
+	}
+	sb.WriteString(fmt.Sprintf("%s %s: %d row(s)", target.driver, target.describe, len(rows)))
+	if truncated {
+		sb.WriteString(fmt.Sprintf(" (more rows not shown; narrow the query or raise limit, max %d)", maxDBQueryLimit))
+	}
+	sb.WriteString("\n")
+	if len(columns) == 0 {
+		return sb.String()
+	}
+
+	widths := make([]int, len(columns))
+	cells := make([][]string, len(rows))
+	for i, column := range columns {
+		widths[i] = len(column)
+	}
+	for r, row := range rows {
+		cells[r] = make([]string, len(columns))
+		for i := range columns {
+			if i < len(row) {
+				cells[r][i] = shortValue(strings.ReplaceAll(row[i], "\n", `\n`))
+			}
+			widths[i] = max(widths[i], len(cells[r][i]))
+		}
+	}
+
+	writeRow := func(values []string) {
+		sb.WriteString("\n")
+		for i, value := range values {
+			if i > 0 {
+				sb.WriteString(" | ")
+			}
+			if i == len(values)-1 {
+				sb.WriteString(value)
+			} else {
+				sb.WriteString(fmt.Sprintf("%-*s", widths[i], value))
+			}
+		}
+	}
+	writeRow(columns)
+	separators := make([]string, len(columns))
+	for i := range columns {
+		separators[i] = strings.Repeat("-", widths[i])
+	}
+	writeRow(separators)
+	for _, row := range cells {
+		writeRow(row)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
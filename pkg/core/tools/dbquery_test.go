@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadOnlyQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		driver string
+		want   string
+	}{
+		{"adds a limit", "SELECT * FROM users", "postgres", "SELECT * FROM users\nLIMIT 11"},
+		{"keeps a limit", "SELECT * FROM users LIMIT 5", "postgres", "SELECT * FROM users LIMIT 5"},
+		{"keeps a fetch", "SELECT * FROM users FETCH FIRST 5 ROWS ONLY", "postgres", "SELECT * FROM users FETCH FIRST 5 ROWS ONLY"},
+		{"limit in a string doesn't count", "SELECT 'limit' FROM users", "postgres", "SELECT 'limit' FROM users\nLIMIT 11"},
+		{"with", "WITH u AS (SELECT 1) SELECT * FROM u", "postgres", "WITH u AS (SELECT 1) SELECT * FROM u\nLIMIT 11"},
+		{"parenthesized select", "(SELECT 1)", "sqlite", "(SELECT 1)\nLIMIT 11"},
+		{"values", "VALUES (1), (2)", "postgres", "VALUES (1), (2)\nLIMIT 11"},
+		{"show has no limit", "SHOW TABLES", "mysql", "SHOW TABLES"},
+		{"explain has no limit", "EXPLAIN SELECT * FROM users", "postgres", "EXPLAIN SELECT * FROM users"},
+		{"pragma read", "PRAGMA table_info(users)", "sqlite", "PRAGMA table_info(users)"},
+		{"lowercase", "select 1", "sqlite", "select 1\nLIMIT 11"},
+
+		// Comments, whitespace and semicolons around the statement are cut
+		{"trailing semicolon", "SELECT 1;", "postgres", "SELECT 1\nLIMIT 11"},
+		{"trailing semicolons and space", "  SELECT 1 ; ;\n", "postgres", "SELECT 1\nLIMIT 11"},
+		{"trailing line comment", "SELECT 1 -- all of them", "postgres", "SELECT 1\nLIMIT 11"},
+		{"semicolon then comment", "SELECT 1; -- done", "postgres", "SELECT 1\nLIMIT 11"},
+		{"comment then semicolon", "SELECT 1 /* all */;", "postgres", "SELECT 1\nLIMIT 11"},
+		{"unterminated trailing comment", "SELECT 1 /* all", "postgres", "SELECT 1\nLIMIT 11"},
+		{"mysql hash comment", "SELECT 1; # done", "mysql", "SELECT 1\nLIMIT 11"},
+		{"leading comments", "-- users\n/* all */ SELECT * FROM users", "sqlite", "SELECT * FROM users\nLIMIT 11"},
+		{"inner comment stays", "SELECT 1 -- one\n, 2", "postgres", "SELECT 1 -- one\n, 2\nLIMIT 11"},
+		{"string at the end stays", "SELECT * FROM t WHERE a = 'x;  '", "postgres", "SELECT * FROM t WHERE a = 'x;  '\nLIMIT 11"},
+		{"mysql backslash in a string", `SELECT 'a\\! b'`, "mysql", "SELECT 'a\\\\! b'\nLIMIT 11"},
+		{"dollar-quoted string at the end stays", "SELECT $$a; b$$", "postgres", "SELECT $$a; b$$\nLIMIT 11"},
+		{"hash is not a comment outside mysql", "SELECT a #> '{b}' FROM t", "postgres", "SELECT a #> '{b}' FROM t\nLIMIT 11"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readOnlyQuery(tt.query, tt.driver, 10)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadOnlyQuery_Rejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		driver string
+		errMsg string
+	}{
+		{"empty", "", "postgres", "query is empty"},
+		{"only comments", "-- nothing\n/* here */ ;", "postgres", "query is empty"},
+		{"two statements", "SELECT 1; SELECT 2", "postgres", "only one statement"},
+		{"write after a select", "SELECT 1; DELETE FROM users", "postgres", "only one statement"},
+		{"statement after a comment", "SELECT 1 -- x\n; DROP TABLE users", "postgres", "only one statement"},
+		{"statement after a semicolon in a string", "SELECT ';'; DROP TABLE users", "postgres", "only one statement"},
+		{"leading semicolon", "; SELECT 1", "postgres", "only one statement"},
+		{"mysql comment hides nothing elsewhere", "SELECT 1 # x\n; DROP TABLE users", "sqlite", "only one statement"},
+		{"mysql shell command", `SELECT 1 \! id`, "mysql", "client commands"},
+		{"mysql source file", `\. /tmp/x.sql`, "mysql", "client commands"},
+		{"mysql vertical output", `SELECT 1\G`, "mysql", "client commands"},
+		{"insert", "INSERT INTO users VALUES (1)", "postgres", "INSERT statements are not allowed"},
+		{"update after a comment", "/* read */ UPDATE users SET a = 1", "postgres", "UPDATE statements are not allowed"},
+		{"data-modifying cte", "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", "postgres", "DELETE is not allowed"},
+		{"select into", "SELECT * INTO copy FROM users", "postgres", "INTO is not allowed"},
+		{"function with side effects", "SELECT nextval('seq')", "postgres", "NEXTVAL is not allowed"},
+		{"pragma assignment", "PRAGMA journal_mode = DELETE", "sqlite", "DELETE is not allowed"},
+		{"pragma set", "PRAGMA foreign_keys = 1", "sqlite", "PRAGMA assignments are not allowed"},
+		{"no keyword", "42", "postgres", "query must start with"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readOnlyQuery(tt.query, tt.driver, 10)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tt.errMsg)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestMaskSQL(t *testing.T) {
+	tests := []struct {
+		query  string
+		driver string
+		want   string
+	}{
+		{"SELECT 'a', \"b\", `c` FROM t", "mysql", "SELECT ???, ???, ??? FROM t"},
+		{"SELECT 'it''s'", "postgres", "SELECT ???????"},
+		{`SELECT 'a\'b'`, "mysql", "SELECT ??????"},
+		{"SELECT 1 -- x\n, 2", "postgres", "SELECT 1     \n, 2"},
+		{"SELECT /* x */ 1", "postgres", "SELECT         1"},
+		{"SELECT $t$x$t$ 1", "postgres", "SELECT ??????? 1"},
+		{"SELECT 1 # x", "mysql", "SELECT 1    "},
+		{"SELECT 'open", "postgres", "SELECT ?????"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := maskSQL(tt.query, tt.driver)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if len(got) != len(tt.query) {
+				t.Errorf("length %d, want %d", len(got), len(tt.query))
+			}
+		})
+	}
+}
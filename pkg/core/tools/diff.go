@@ -6,6 +6,8 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 )
@@ -26,26 +28,29 @@ func NewCompareResponsesTool(responseManager *ResponseManager, zapDir string) *C
 
 // CompareParams defines comparison parameters
 type CompareParams struct {
-	Baseline     string   `json:"baseline"`               // Baseline response ID or "last_response"
-	Current      string   `json:"current,omitempty"`      // Current response or "last_response"
-	IgnoreFields []string `json:"ignore_fields,omitempty"` // Fields to ignore (e.g., "timestamp")
-	IgnoreOrder  bool     `json:"ignore_order,omitempty"`  // Ignore array order
-	Tolerance    float64  `json:"tolerance,omitempty"`     // Numeric tolerance (0.01 = 1%)
-	SaveBaseline bool     `json:"save_baseline,omitempty"` // Save current as new baseline
+	Baseline     string             `json:"baseline"`                // Saved response name, baseline name or "last_response"
+	Current      string             `json:"current,omitempty"`       // Saved response name, baseline name or "last_response" (default)
+	IgnoreFields []string           `json:"ignore_fields,omitempty"` // Fields to ignore (e.g., "timestamp")
+	IgnoreOrder  bool               `json:"ignore_order,omitempty"`  // Ignore array order
+	Tolerance    float64            `json:"tolerance,omitempty"`     // Numeric tolerance (0.01 = 1%)
+	Tolerances   map[string]float64 `json:"tolerances,omitempty"`    // Per-path tolerances, e.g. {"$.items[*].price": 0.05}
+	ArrayKey     string             `json:"array_key,omitempty"`     // Match array items by this field (e.g. "id") instead of position
+	SaveBaseline bool               `json:"save_baseline,omitempty"` // Save current as new baseline
 }
 
 // ComparisonResult represents the comparison outcome
 type ComparisonResult struct {
-	Match      bool     `json:"match"`
-	Differences []string `json:"differences,omitempty"`
-	Summary    string   `json:"summary"`
+	Match       bool         `json:"match"`
+	Differences []string     `json:"differences,omitempty"`
+	Changes     []JSONChange `json:"changes,omitempty"`
+	Summary     string       `json:"summary"`
 }
 
 // Baseline stores a saved response
 type Baseline struct {
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	Response  string    `json:"response"`
+	Name      string            `json:"name"`
+	CreatedAt time.Time         `json:"created_at"`
+	Response  string            `json:"response"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
 }
 
@@ -56,17 +61,19 @@ func (t *CompareResponsesTool) Name() string {
 
 // Description returns the tool description
 func (t *CompareResponsesTool) Description() string {
-	return "Compare two API responses for regression testing. Detects added, removed, or changed fields."
+	return "Compare two API responses for regression testing. Structural diff of added, removed, or changed fields, with wildcard ignores, array matching by key or regardless of order, and numeric tolerances."
 }
 
 // Parameters returns the tool parameter description
 func (t *CompareResponsesTool) Parameters() string {
 	return `{
-  "baseline": "baseline_name",
+  "baseline": "baseline_name (or a response saved with save_response_as)",
   "current": "last_response",
-  "ignore_fields": ["timestamp", "request_id"],
-  "ignore_order": true,
-  "tolerance": 0.01
+  "ignore_fields": ["timestamp", "$.items[*].updated_at"],
+  "ignore_order": false,
+  "array_key": "id",
+  "tolerance": 0.01,
+  "tolerances": {"$.items[*].price": 0.05}
 }`
 }
 
@@ -116,15 +123,19 @@ func (t *CompareResponsesTool) Execute(args string) (string, error) {
 	return t.formatComparison(result), nil
 }
 
-// loadResponse loads a response (baseline file or last_response)
+// loadResponse loads a response: the last one, one saved with
+// save_response_as, or a baseline file
 func (t *CompareResponsesTool) loadResponse(source string) (string, error) {
-	if source == "" || source == "last_response" {
+	if source == "" || source == LastResponseName {
 		lastResp := t.responseManager.GetHTTPResponse()
 		if lastResp == nil {
 			return "", fmt.Errorf("no HTTP response available")
 		}
 		return lastResp.Body, nil
 	}
+	if resp, ok := t.responseManager.GetNamedResponse(source); ok {
+		return resp.Body, nil
+	}
 
 	// Load from baseline file
 	baselinesDir := filepath.Join(t.zapDir, "baselines")
@@ -185,29 +196,44 @@ func (t *CompareResponsesTool) saveBaseline(name string) (string, error) {
 		name, baselinePath, name), nil
 }
 
-// removeFields removes specified fields from JSON
+// removeFields removes ignored fields from JSON. A field is a key name,
+// like "timestamp", removed at any depth, or a path with wildcards, like
+// "$.items[*].updated_at", "$.meta.*" or "$..etag".
 func (t *CompareResponsesTool) removeFields(data interface{}, fields []string) interface{} {
+	var names []string
+	var patterns []*regexp.Regexp
+	for _, field := range fields {
+		if strings.HasPrefix(field, "$") {
+			patterns = append(patterns, jsonPathPattern(field))
+		} else {
+			names = append(names, field)
+		}
+	}
+	return stripFields(data, "$", names, patterns)
+}
+
+// stripFields removes the keys named in names, and the values whose path
+// matches one of patterns
+func stripFields(data interface{}, path string, names []string, patterns []*regexp.Regexp) interface{} {
 	switch v := data.(type) {
 	case map[string]interface{}:
-		result := make(map[string]interface{})
+		result := make(map[string]interface{}, len(v))
 		for key, value := range v {
-			// Check if this field should be ignored
-			ignored := false
-			for _, ignoreField := range fields {
-				if key == ignoreField {
-					ignored = true
-					break
-				}
-			}
-			if !ignored {
-				result[key] = t.removeFields(value, fields)
+			keyPath := path + "." + key
+			if slices.Contains(names, key) || matchesAnyPath(patterns, keyPath) {
+				continue
 			}
+			result[key] = stripFields(value, keyPath, names, patterns)
 		}
 		return result
 	case []interface{}:
-		result := make([]interface{}, len(v))
+		result := make([]interface{}, 0, len(v))
 		for i, item := range v {
-			result[i] = t.removeFields(item, fields)
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if matchesAnyPath(patterns, itemPath) {
+				continue
+			}
+			result = append(result, stripFields(item, itemPath, names, patterns))
 		}
 		return result
 	default:
@@ -215,162 +241,258 @@ func (t *CompareResponsesTool) removeFields(data interface{}, fields []string) i
 	}
 }
 
-// compareJSON compares two JSON values
+// jsonPathPattern compiles a JSON path with wildcards into a regex over
+// concrete paths: [*] matches any item, * any key and .. any depth
+func jsonPathPattern(path string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(path)
+	pattern = strings.ReplaceAll(pattern, `\.\.`, `(?:\.[^.\[]+|\[[^\]]+\])*\.`)
+	pattern = strings.ReplaceAll(pattern, `\[\*\]`, `\[[^\]]+\]`) // An index, or id=7 with array_key
+	pattern = strings.ReplaceAll(pattern, `\*`, `[^.\[]+`)
+	return regexp.MustCompile("^" + pattern + "$")
+}
+
+func matchesAnyPath(patterns []*regexp.Regexp, path string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Kinds of JSONChange
+const (
+	changeAdded   = "added"
+	changeRemoved = "removed"
+	changeValue   = "changed"
+	changeType    = "type"
+)
+
+// JSONChange is one structural difference between two JSON values
+type JSONChange struct {
+	Kind     string      `json:"kind"` // added, removed, changed or type
+	Path     string      `json:"path"` // e.g. $.items[0].name, or $.items[id=7].name with array_key
+	Baseline interface{} `json:"baseline,omitempty"`
+	Current  interface{} `json:"current,omitempty"`
+	Note     string      `json:"note,omitempty"` // e.g. the tolerance a number exceeded
+}
+
+// String describes the change in one line
+func (c JSONChange) String() string {
+	switch c.Kind {
+	case changeAdded:
+		return fmt.Sprintf("Added '%s': %s", c.Path, describeJSONValue(c.Current))
+	case changeRemoved:
+		return fmt.Sprintf("Removed '%s': %s", c.Path, describeJSONValue(c.Baseline))
+	case changeType:
+		return fmt.Sprintf("Type mismatch at '%s': expected %s, got %s", c.Path, jsonTypeName(c.Baseline), jsonTypeName(c.Current))
+	}
+	text := fmt.Sprintf("Value changed at '%s': baseline=%s, current=%s", c.Path, describeJSONValue(c.Baseline), describeJSONValue(c.Current))
+	if c.Note != "" {
+		text += " (" + c.Note + ")"
+	}
+	return text
+}
+
+// compareJSON compares two JSON values structurally. Arrays are compared by
+// position, by an item field with array_key, or regardless of order with
+// ignore_order; numbers may differ within tolerance.
 func (t *CompareResponsesTool) compareJSON(baseline, current interface{}, path string, params CompareParams) ComparisonResult {
-	result := ComparisonResult{Match: true}
+	d := &jsonDiff{params: params}
+	for pattern, tolerance := range params.Tolerances {
+		d.tolerances = append(d.tolerances, pathTolerance{pattern: jsonPathPattern(pattern), tolerance: tolerance})
+	}
+	if path == "" {
+		path = "$"
+	}
+	d.compare(baseline, current, path)
+
+	result := ComparisonResult{Match: len(d.changes) == 0, Changes: d.changes}
+	for _, change := range d.changes {
+		result.Differences = append(result.Differences, change.String())
+	}
+	return result
+}
+
+// pathTolerance is a numeric tolerance for the paths matching pattern
+type pathTolerance struct {
+	pattern   *regexp.Regexp
+	tolerance float64
+}
+
+// jsonDiff collects the changes between two JSON values
+type jsonDiff struct {
+	params     CompareParams
+	tolerances []pathTolerance
+	changes    []JSONChange
+}
+
+func (d *jsonDiff) add(change JSONChange) {
+	d.changes = append(d.changes, change)
+}
+
+func (d *jsonDiff) compare(baseline, current interface{}, path string) {
+	if jsonTypeName(baseline) != jsonTypeName(current) {
+		d.add(JSONChange{Kind: changeType, Path: path, Baseline: baseline, Current: current})
+		return
+	}
 
 	switch baselineVal := baseline.(type) {
 	case map[string]interface{}:
-		currentMap, ok := current.(map[string]interface{})
-		if !ok {
-			result.Match = false
-			result.Differences = append(result.Differences,
-				fmt.Sprintf("Type mismatch at '%s': expected object, got %T", path, current))
-			return result
-		}
-
-		// Check for missing fields in current
-		for key := range baselineVal {
+		currentMap := current.(map[string]interface{})
+		for _, key := range sortedKeys(baselineVal) {
 			keyPath := path + "." + key
-			if path == "" {
-				keyPath = key
-			}
-
-			if _, exists := currentMap[key]; !exists {
-				result.Match = false
-				result.Differences = append(result.Differences,
-					fmt.Sprintf("Field removed: '%s'", keyPath))
+			if currentVal, exists := currentMap[key]; exists {
+				d.compare(baselineVal[key], currentVal, keyPath)
 			} else {
-				// Recursively compare
-				subResult := t.compareJSON(baselineVal[key], currentMap[key], keyPath, params)
-				if !subResult.Match {
-					result.Match = false
-					result.Differences = append(result.Differences, subResult.Differences...)
-				}
+				d.add(JSONChange{Kind: changeRemoved, Path: keyPath, Baseline: baselineVal[key]})
 			}
 		}
-
-		// Check for new fields in current
-		for key := range currentMap {
+		for _, key := range sortedKeys(currentMap) {
 			if _, exists := baselineVal[key]; !exists {
-				keyPath := path + "." + key
-				if path == "" {
-					keyPath = key
-				}
-				result.Match = false
-				result.Differences = append(result.Differences,
-					fmt.Sprintf("Field added: '%s'", keyPath))
+				d.add(JSONChange{Kind: changeAdded, Path: path + "." + key, Current: currentMap[key]})
 			}
 		}
 
 	case []interface{}:
-		currentArray, ok := current.([]interface{})
-		if !ok {
-			result.Match = false
-			result.Differences = append(result.Differences,
-				fmt.Sprintf("Type mismatch at '%s': expected array, got %T", path, current))
-			return result
+		currentArray := current.([]interface{})
+		switch {
+		case d.params.ArrayKey != "" && d.compareByKey(baselineVal, currentArray, path):
+		case d.params.IgnoreOrder:
+			d.compareUnordered(baselineVal, currentArray, path)
+		default:
+			for i := 0; i < max(len(baselineVal), len(currentArray)); i++ {
+				itemPath := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(currentArray):
+					d.add(JSONChange{Kind: changeRemoved, Path: itemPath, Baseline: baselineVal[i]})
+				case i >= len(baselineVal):
+					d.add(JSONChange{Kind: changeAdded, Path: itemPath, Current: currentArray[i]})
+				default:
+					d.compare(baselineVal[i], currentArray[i], itemPath)
+				}
+			}
 		}
 
-		if len(baselineVal) != len(currentArray) {
-			result.Match = false
-			result.Differences = append(result.Differences,
-				fmt.Sprintf("Array length mismatch at '%s': baseline has %d items, current has %d",
-					path, len(baselineVal), len(currentArray)))
+	case float64:
+		currentFloat := current.(float64)
+		tolerance := d.toleranceFor(path)
+		diff := math.Abs(baselineVal - currentFloat)
+		if tolerance > 0 {
+			if diff > math.Abs(baselineVal*tolerance) {
+				d.add(JSONChange{Kind: changeValue, Path: path, Baseline: baseline, Current: current,
+					Note: fmt.Sprintf("diff=%g, tolerance=%g%%", diff, tolerance*100)})
+			}
+		} else if diff != 0 {
+			d.add(JSONChange{Kind: changeValue, Path: path, Baseline: baseline, Current: current})
 		}
 
-		// Compare array elements
-		minLen := len(baselineVal)
-		if len(currentArray) < minLen {
-			minLen = len(currentArray)
+	default:
+		// Strings, booleans and null
+		if baseline != current {
+			d.add(JSONChange{Kind: changeValue, Path: path, Baseline: baseline, Current: current})
 		}
+	}
+}
 
-		for i := 0; i < minLen; i++ {
-			itemPath := fmt.Sprintf("%s[%d]", path, i)
-			subResult := t.compareJSON(baselineVal[i], currentArray[i], itemPath, params)
-			if !subResult.Match {
-				result.Match = false
-				result.Differences = append(result.Differences, subResult.Differences...)
-			}
+// toleranceFor returns the numeric tolerance of a path: the first matching
+// entry of tolerances, or the global tolerance
+func (d *jsonDiff) toleranceFor(path string) float64 {
+	for _, t := range d.tolerances {
+		if t.pattern.MatchString(path) {
+			return t.tolerance
 		}
+	}
+	return d.params.Tolerance
+}
 
-	case float64:
-		currentFloat, ok := current.(float64)
+// compareByKey matches array items by their array_key field, so reordered,
+// added and removed items are reported as such. It reports false, leaving
+// the array to the other modes, when an item has no such key.
+func (d *jsonDiff) compareByKey(baseline, current []interface{}, path string) bool {
+	key := d.params.ArrayKey
+	keyOf := func(item interface{}) (string, bool) {
+		obj, ok := item.(map[string]interface{})
 		if !ok {
-			result.Match = false
-			result.Differences = append(result.Differences,
-				fmt.Sprintf("Type mismatch at '%s': expected number, got %T", path, current))
-			return result
+			return "", false
 		}
-
-		// Apply tolerance if specified
-		if params.Tolerance > 0 {
-			diff := math.Abs(baselineVal - currentFloat)
-			allowedDiff := math.Abs(baselineVal * params.Tolerance)
-			if diff > allowedDiff {
-				result.Match = false
-				result.Differences = append(result.Differences,
-					fmt.Sprintf("Numeric difference at '%s': baseline=%.2f, current=%.2f (diff=%.2f, tolerance=%.2f%%)",
-						path, baselineVal, currentFloat, diff, params.Tolerance*100))
-			}
-		} else if baselineVal != currentFloat {
-			result.Match = false
-			result.Differences = append(result.Differences,
-				fmt.Sprintf("Value changed at '%s': baseline=%.2f, current=%.2f",
-					path, baselineVal, currentFloat))
-		}
-
-	case string:
-		currentStr, ok := current.(string)
+		value, ok := obj[key]
 		if !ok {
-			result.Match = false
-			result.Differences = append(result.Differences,
-				fmt.Sprintf("Type mismatch at '%s': expected string, got %T", path, current))
-			return result
+			return "", false
 		}
+		return describeJSONValue(value), true
+	}
 
-		if baselineVal != currentStr {
-			result.Match = false
-			result.Differences = append(result.Differences,
-				fmt.Sprintf("Value changed at '%s': baseline='%s', current='%s'",
-					path, baselineVal, currentStr))
+	currentByKey := make(map[string]interface{}, len(current))
+	for _, item := range current {
+		k, ok := keyOf(item)
+		if !ok {
+			return false
 		}
-
-	case bool:
-		currentBool, ok := current.(bool)
+		currentByKey[k] = item
+	}
+	baselineKeys := make(map[string]bool, len(baseline))
+	for _, item := range baseline {
+		k, ok := keyOf(item)
 		if !ok {
-			result.Match = false
-			result.Differences = append(result.Differences,
-				fmt.Sprintf("Type mismatch at '%s': expected boolean, got %T", path, current))
-			return result
+			return false
 		}
+		baselineKeys[k] = true
+	}
 
-		if baselineVal != currentBool {
-			result.Match = false
-			result.Differences = append(result.Differences,
-				fmt.Sprintf("Value changed at '%s': baseline=%t, current=%t",
-					path, baselineVal, currentBool))
+	for _, item := range baseline {
+		k, _ := keyOf(item)
+		itemPath := fmt.Sprintf("%s[%s=%s]", path, key, k)
+		if match, ok := currentByKey[k]; ok {
+			d.compare(item, match, itemPath)
+		} else {
+			d.add(JSONChange{Kind: changeRemoved, Path: itemPath, Baseline: item})
 		}
-
-	case nil:
-		if current != nil {
-			result.Match = false
-			result.Differences = append(result.Differences,
-				fmt.Sprintf("Value changed at '%s': baseline=null, current=%v",
-					path, current))
+	}
+	for _, item := range current {
+		if k, _ := keyOf(item); !baselineKeys[k] {
+			d.add(JSONChange{Kind: changeAdded, Path: fmt.Sprintf("%s[%s=%s]", path, key, k), Current: item})
 		}
+	}
+	return true
+}
 
-	default:
-		// Fallback to simple equality
-		if baseline != current {
-			result.Match = false
-			result.Differences = append(result.Differences,
-				fmt.Sprintf("Value changed at '%s': baseline=%v, current=%v",
-					path, baseline, current))
+// compareUnordered compares arrays as multisets: items equal to an item of
+// the other array match wherever they are
+func (d *jsonDiff) compareUnordered(baseline, current []interface{}, path string) {
+	used := make([]bool, len(current))
+	for i, item := range baseline {
+		found := false
+		for j, candidate := range current {
+			if !used[j] && deepEqual(item, candidate) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			d.add(JSONChange{Kind: changeRemoved, Path: fmt.Sprintf("%s[%d]", path, i), Baseline: item})
 		}
 	}
+	for j, item := range current {
+		if !used[j] {
+			d.add(JSONChange{Kind: changeAdded, Path: fmt.Sprintf("%s[%d]", path, j), Current: item})
+		}
+	}
+}
 
-	return result
+// unifiedDiff renders changes as +/- lines, for a ```diff block that
+// markdown renderers (like the TUI's) color
+func unifiedDiff(changes []JSONChange) string {
+	var sb strings.Builder
+	for _, c := range changes {
+		if c.Kind != changeAdded {
+			sb.WriteString(fmt.Sprintf("- %s: %s\n", c.Path, describeJSONValue(c.Baseline)))
+		}
+		if c.Kind != changeRemoved {
+			sb.WriteString(fmt.Sprintf("+ %s: %s\n", c.Path, describeJSONValue(c.Current)))
+		}
+	}
+	return sb.String()
 }
 
 // formatComparison formats the comparison result
@@ -388,10 +510,12 @@ func (t *CompareResponsesTool) formatComparison(result ComparisonResult) string
 			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, diff))
 		}
 
+		sb.WriteString("\n```diff\n" + unifiedDiff(result.Changes) + "```\n")
+
 		sb.WriteString("\nTips:\n")
-		sb.WriteString("- Use 'ignore_fields' to skip dynamic fields like timestamps\n")
-		sb.WriteString("- Use 'tolerance' for numeric comparisons (e.g., 0.01 for 1%)\n")
-		sb.WriteString("- Use 'ignore_order' for arrays where order doesn't matter\n")
+		sb.WriteString("- Use 'ignore_fields' to skip dynamic fields like timestamps, by name or path (\"$.items[*].updated_at\")\n")
+		sb.WriteString("- Use 'tolerance' for numeric comparisons (e.g., 0.01 for 1%), or 'tolerances' per path\n")
+		sb.WriteString("- Use 'ignore_order' for arrays where order doesn't matter, or 'array_key' to match items by a field like \"id\"\n")
 	}
 
 	return sb.String()
@@ -0,0 +1,352 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dockerTimeout bounds docker ps and inspect calls
+const dockerTimeout = 15 * time.Second
+
+// composeServiceLabel names the Compose service a container runs
+const composeServiceLabel = "com.docker.compose.service"
+
+// DockerTool lists and inspects local containers so an API that doesn't
+// respond can be traced to a stopped, restarting or unpublished container
+type DockerTool struct{}
+
+// NewDockerTool creates a new docker inspection tool
+func NewDockerTool() *DockerTool {
+	return &DockerTool{}
+}
+
+// DockerParams defines the docker parameters
+type DockerParams struct {
+	Action    string `json:"action"`              // ps, inspect or logs
+	Container string `json:"container,omitempty"` // Container name, ID or Compose service (inspect, logs)
+	Lines     int    `json:"lines,omitempty"`     // Log lines (logs, default 100)
+	Running   bool   `json:"running,omitempty"`   // Only running containers (ps)
+}
+
+// Name returns the tool name
+func (t *DockerTool) Name() string {
+	return "docker"
+}
+
+// Description returns the tool description
+func (t *DockerTool) Description() string {
+	return "Inspect local Docker containers: 'ps' lists containers with their Compose service, state and published ports, 'inspect' shows one container's status, exit code, health checks, restarts and port mappings, 'logs' fetches its recent logs. Use when the API doesn't respond and runs in Docker or Compose"
+}
+
+// Parameters returns the tool parameter description
+func (t *DockerTool) Parameters() string {
+	return `{"action": "string (required) - ps, inspect or logs", "container": "string - container name, ID or Compose service name (inspect, logs)", "lines": "number - log lines (logs, default 100)", "running": "boolean - only running containers (ps)"}`
+}
+
+// Execute runs the requested docker action
+func (t *DockerTool) Execute(args string) (string, error) {
+	var params DockerParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", fmt.Errorf("docker not found on PATH")
+	}
+
+	switch params.Action {
+	case "ps", "list", "":
+		return dockerPS(params.Running)
+	case "inspect", "status":
+		if params.Container == "" {
+			return "", fmt.Errorf("container is required for inspect")
+		}
+		return dockerInspect(params.Container)
+	case "logs":
+		if params.Container == "" {
+			return "", fmt.Errorf("container is required for logs")
+		}
+		name, err := resolveContainer(params.Container)
+		if err != nil {
+			return "", err
+		}
+		if params.Lines <= 0 {
+			params.Lines = defaultTailLines
+		}
+		lines, err := readContainerLogs(name, min(params.Lines, maxTailLines), 0)
+		if err != nil {
+			return "", err
+		}
+		return formatLogLines("container "+name, lines, 0, nil, ""), nil
+	}
+	return "", fmt.Errorf("unknown action %q: use ps, inspect or logs", params.Action)
+}
+
+// runDocker runs a docker command and returns its stdout
+func runDocker(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("docker %s timed out; is the Docker daemon running?", args[0])
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("docker %s failed: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("docker %s failed: %w", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// dockerContainer is a line of docker ps --format '{{json .}}'
+type dockerContainer struct {
+	Names  string `json:"Names"`
+	Image  string `json:"Image"`
+	State  string `json:"State"`
+	Status string `json:"Status"`
+	Ports  string `json:"Ports"`
+	Labels string `json:"Labels"`
+}
+
+// label returns a label from the comma-separated docker ps Labels
+func (c dockerContainer) label(name string) string {
+	for _, pair := range strings.Split(c.Labels, ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok && key == name {
+			return value
+		}
+	}
+	return ""
+}
+
+// listContainers runs docker ps, including stopped containers unless
+// running is set
+func listContainers(running bool, filters ...string) ([]dockerContainer, error) {
+	args := []string{"ps", "--format", "{{json .}}", "--no-trunc"}
+	if !running {
+		args = append(args, "-a")
+	}
+	for _, filter := range filters {
+		args = append(args, "--filter", filter)
+	}
+	out, err := runDocker(args...)
+	if err != nil {
+		return nil, err
+	}
+	var containers []dockerContainer
+	for _, line := range splitLogLines(string(out)) {
+		var c dockerContainer
+		if err := json.Unmarshal([]byte(line), &c); err != nil {
+			return nil, fmt.Errorf("failed to parse docker ps output: %w", err)
+		}
+		containers = append(containers, c)
+	}
+	return containers, nil
+}
+
+// dockerPS lists the containers, running ones first
+func dockerPS(running bool) (string, error) {
+	containers, err := listContainers(running)
+	if err != nil {
+		return "", err
+	}
+	if len(containers) == 0 {
+		if running {
+			return "No running containers", nil
+		}
+		return "No containers", nil
+	}
+	sort.SliceStable(containers, func(i, j int) bool {
+		return containers[i].State == "running" && containers[j].State != "running"
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d container(s)\n", len(containers)))
+	for _, c := range containers {
+		sb.WriteString(fmt.Sprintf("\n%s (%s)\n", c.Names, c.Image))
+		if service := c.label(composeServiceLabel); service != "" {
+			sb.WriteString(fmt.Sprintf("  Compose:  %s/%s\n", c.label("com.docker.compose.project"), service))
+		}
+		sb.WriteString(fmt.Sprintf("  State:    %s (%s)\n", c.State, c.Status))
+		ports := c.Ports
+		if ports == "" {
+			ports = "none published"
+		}
+		sb.WriteString(fmt.Sprintf("  Ports:    %s\n", ports))
+	}
+	return sb.String(), nil
+}
+
+// resolveContainer returns name itself when docker knows it, or else the
+// container running the Compose service called name
+func resolveContainer(name string) (string, error) {
+	if _, err := runDocker("inspect", "--type", "container", "--format", "{{.Name}}", name); err == nil {
+		return name, nil
+	}
+	containers, err := listContainers(false, "label="+composeServiceLabel+"="+name)
+	if err != nil {
+		return "", err
+	}
+	if len(containers) == 0 {
+		return "", fmt.Errorf("no container or Compose service named %q (see action ps)", name)
+	}
+	return containers[0].Names, nil
+}
+
+// dockerInspection holds the docker inspect fields the summary uses
+type dockerInspection struct {
+	Name         string `json:"Name"`
+	RestartCount int    `json:"RestartCount"`
+	State        struct {
+		Status     string `json:"Status"`
+		Running    bool   `json:"Running"`
+		Restarting bool   `json:"Restarting"`
+		OOMKilled  bool   `json:"OOMKilled"`
+		ExitCode   int    `json:"ExitCode"`
+		Error      string `json:"Error"`
+		StartedAt  string `json:"StartedAt"`
+		FinishedAt string `json:"FinishedAt"`
+		Health     *struct {
+			Status        string `json:"Status"`
+			FailingStreak int    `json:"FailingStreak"`
+			Log           []struct {
+				ExitCode int    `json:"ExitCode"`
+				Output   string `json:"Output"`
+			} `json:"Log"`
+		} `json:"Health"`
+	} `json:"State"`
+	Config struct {
+		Image        string              `json:"Image"`
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+		Labels       map[string]string   `json:"Labels"`
+	} `json:"Config"`
+	HostConfig struct {
+		RestartPolicy struct {
+			Name string `json:"Name"`
+		} `json:"RestartPolicy"`
+	} `json:"HostConfig"`
+	NetworkSettings struct {
+		Ports map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"Ports"`
+	} `json:"NetworkSettings"`
+}
+
+// dockerInspect summarizes one container and points out why it may not
+// be answering
+func dockerInspect(container string) (string, error) {
+	name, err := resolveContainer(container)
+	if err != nil {
+		return "", err
+	}
+	out, err := runDocker("inspect", "--type", "container", name)
+	if err != nil {
+		return "", err
+	}
+	var inspections []dockerInspection
+	if err := json.Unmarshal(out, &inspections); err != nil || len(inspections) == 0 {
+		return "", fmt.Errorf("failed to parse docker inspect output: %v", err)
+	}
+	c := inspections[0]
+
+	var sb strings.Builder
+	var problems []string
+	sb.WriteString(fmt.Sprintf("%s (%s)\n", strings.TrimPrefix(c.Name, "/"), c.Config.Image))
+	if service := c.Config.Labels[composeServiceLabel]; service != "" {
+		sb.WriteString(fmt.Sprintf("  Compose:  %s/%s\n", c.Config.Labels["com.docker.compose.project"], service))
+	}
+
+	state := c.State
+	sb.WriteString(fmt.Sprintf("  Status:   %s", state.Status))
+	if state.Running {
+		sb.WriteString(fmt.Sprintf(" since %s", state.StartedAt))
+	} else if state.FinishedAt != "" && !strings.HasPrefix(state.FinishedAt, "0001") {
+		sb.WriteString(fmt.Sprintf(", exit code %d at %s", state.ExitCode, state.FinishedAt))
+	}
+	sb.WriteString("\n")
+	if state.Error != "" {
+		sb.WriteString(fmt.Sprintf("  Error:    %s\n", state.Error))
+	}
+	policy := c.HostConfig.RestartPolicy.Name
+	if policy == "" {
+		policy = "no"
+	}
+	sb.WriteString(fmt.Sprintf("  Restarts: %d (policy %s)\n", c.RestartCount, policy))
+
+	switch {
+	case state.OOMKilled:
+		problems = append(problems, "the container was killed for running out of memory (OOMKilled); raise its memory limit or look for a leak")
+	case state.Restarting || (c.RestartCount > 2 && state.Running):
+		problems = append(problems, fmt.Sprintf("the container keeps restarting (%d restarts); its logs show why the process exits", c.RestartCount))
+	case !state.Running && state.Status != "created":
+		problems = append(problems, fmt.Sprintf("the container isn't running (exit code %d); check its logs", state.ExitCode))
+	case state.Status == "created":
+		problems = append(problems, "the container was created but never started")
+	}
+
+	if health := state.Health; health != nil {
+		sb.WriteString(fmt.Sprintf("  Health:   %s", health.Status))
+		if health.FailingStreak > 0 {
+			sb.WriteString(fmt.Sprintf(" (%d failing check(s) in a row)", health.FailingStreak))
+		}
+		sb.WriteString("\n")
+		if n := len(health.Log); n > 0 {
+			last := health.Log[n-1]
+			sb.WriteString(fmt.Sprintf("  Last check: exit %d, %s\n", last.ExitCode, shortValue(strings.Join(strings.Fields(last.Output), " "))))
+		}
+		if health.Status == "unhealthy" && state.Running {
+			problems = append(problems, "the health check fails; its output above shows what it got")
+		}
+	}
+
+	sb.WriteString("  Ports:\n")
+	exposed := make([]string, 0, len(c.Config.ExposedPorts))
+	for port := range c.Config.ExposedPorts {
+		exposed = append(exposed, port)
+	}
+	for port := range c.NetworkSettings.Ports {
+		if _, ok := c.Config.ExposedPorts[port]; !ok {
+			exposed = append(exposed, port)
+		}
+	}
+	sort.Strings(exposed)
+	if len(exposed) == 0 {
+		sb.WriteString("    none exposed\n")
+	}
+	published := 0
+	for _, port := range exposed {
+		bindings := c.NetworkSettings.Ports[port]
+		if len(bindings) == 0 {
+			sb.WriteString(fmt.Sprintf("    %s not published to the host\n", port))
+			continue
+		}
+		published++
+		for _, b := range bindings {
+			host := b.HostIP
+			if host == "" || host == "0.0.0.0" || host == "::" {
+				host = "localhost"
+			}
+			sb.WriteString(fmt.Sprintf("    %s -> %s:%s\n", port, host, b.HostPort))
+		}
+	}
+	if state.Running && len(exposed) > 0 && published == 0 {
+		problems = append(problems, "no port is published, so the API can't be reached from the host; add a ports: mapping in Compose or -p to docker run")
+	}
+
+	if len(problems) > 0 {
+		sb.WriteString("\nLikely problems:\n")
+		for _, p := range problems {
+			sb.WriteString("  - " + p + "\n")
+		}
+	}
+	return sb.String(), nil
+}
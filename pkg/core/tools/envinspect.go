@@ -0,0 +1,326 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/joho/godotenv"
+)
+
+const (
+	maxEnvScanFiles    = 5000
+	maxEnvScanFileSize = 1024 * 1024
+)
+
+// envTemplateFiles document the variables a project expects
+var envTemplateFiles = []string{".env.example", ".env.sample", ".env.template", ".env.dist", "example.env"}
+
+// envLocalFiles are the dotenv files a dev server usually loads, in the
+// order their values win
+var envLocalFiles = []string{".env.local", ".env.development", ".env"}
+
+// envSourceExts are the files scanned for environment variable reads
+var envSourceExts = []string{".go", ".js", ".mjs", ".cjs", ".ts", ".jsx", ".tsx", ".py", ".rb", ".php", ".java", ".kt", ".rs", ".cs", ".yml", ".yaml", ".properties"}
+
+// envReadPatterns find environment variable reads. The name group is the
+// variable; a non-empty fallback group means the code has a default for it.
+var envReadPatterns = []*regexp.Regexp{
+	// Go
+	regexp.MustCompile(`\bos\.(?:Getenv|LookupEnv)\(\s*"(?P<name>\w+)"\s*\)`),
+	// JavaScript / TypeScript
+	regexp.MustCompile(`\b(?:process\.env|import\.meta\.env)(?:\.(?P<name>\w+)|\[\s*['"](?P<name2>\w+)['"]\s*\])(?P<fallback>\s*(?:\|\||\?\?))?`),
+	// Python
+	regexp.MustCompile(`\bos\.(?:getenv|environ\.get)\(\s*['"](?P<name>\w+)['"]\s*(?P<fallback>,)?`),
+	regexp.MustCompile(`\bos\.environ\[\s*['"](?P<name>\w+)['"]\s*\]`),
+	// Ruby
+	regexp.MustCompile(`\bENV(?:\[\s*['"](?P<name>\w+)['"]\s*\]|\.fetch\(\s*['"](?P<name2>\w+)['"]\s*(?P<fallback>,)?)`),
+	// PHP (Laravel's env() too), not methods such as os.getenv
+	regexp.MustCompile(`(?:^|[^.\w])(?:env|getenv)\(\s*['"](?P<name>\w+)['"]\s*(?P<fallback>,)?`),
+	regexp.MustCompile(`\$_(?:ENV|SERVER)\[\s*['"](?P<name>[A-Z]\w*)['"]\s*\]`),
+	// Java / Kotlin, Rust, C#
+	regexp.MustCompile(`\bSystem\.getenv\(\s*"(?P<name>\w+)"\s*\)`),
+	regexp.MustCompile(`\benv::var(?:_os)?\(\s*"(?P<name>\w+)"\s*\)`),
+	regexp.MustCompile(`\bEnvironment\.GetEnvironmentVariable\(\s*"(?P<name>\w+)"\s*\)`),
+}
+
+// envPlaceholderPattern finds ${NAME}, ${NAME:default} and ${NAME:-default}
+// in Spring and Compose config files
+var envPlaceholderPattern = regexp.MustCompile(`\$\{(?P<name>[A-Z][A-Z0-9_]*)(?P<fallback>:-?[^}]*)?\}`)
+
+// envConfigExts are the config files searched for placeholders
+var envConfigExts = []string{".yml", ".yaml", ".properties"}
+
+// envVar is an environment variable the project expects
+type envVar struct {
+	name       string
+	template   string   // The template file listing it
+	refs       []string // file:line of the reads in code
+	hasDefault bool     // Every read in code has a fallback
+}
+
+// EnvInspectTool reports which environment variables the project expects
+// and whether they're set, since a missing one is a common cause of 500s
+type EnvInspectTool struct {
+	workDir string
+}
+
+// NewEnvInspectTool creates a new environment variable inspection tool
+func NewEnvInspectTool(workDir string) *EnvInspectTool {
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	return &EnvInspectTool{workDir: workDir}
+}
+
+// EnvInspectParams defines the inspection parameters
+type EnvInspectParams struct {
+	Name string `json:"name,omitempty"` // Only variables whose name contains this (case-insensitive)
+}
+
+// Name returns the tool name
+func (t *EnvInspectTool) Name() string {
+	return "env_inspect"
+}
+
+// Description returns the tool description
+func (t *EnvInspectTool) Description() string {
+	return "List the environment variables the project expects (from .env.example and reads in code such as os.Getenv or process.env) and whether each is set in the environment or the .env files, with values masked. Use when a 500 may come from missing configuration"
+}
+
+// Parameters returns the tool parameter description
+func (t *EnvInspectTool) Parameters() string {
+	return `{"name": "string - only variables whose name contains this, e.g. DATABASE"}`
+}
+
+// Execute collects the expected variables and reports their state
+func (t *EnvInspectTool) Execute(args string) (string, error) {
+	var params EnvInspectParams
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", fmt.Errorf("failed to parse arguments: %w", err)
+		}
+	}
+
+	vars := make(map[string]*envVar)
+	get := func(name string) *envVar {
+		if vars[name] == nil {
+			vars[name] = &envVar{name: name, hasDefault: true}
+		}
+		return vars[name]
+	}
+
+	templates := 0
+	for _, file := range envTemplateFiles {
+		names := readEnvNames(filepath.Join(t.workDir, file))
+		if names == nil {
+			continue
+		}
+		templates++
+		for _, name := range names {
+			if v := get(name); v.template == "" {
+				v.template = file
+				v.hasDefault = false
+			}
+		}
+	}
+	scanned, err := t.scanEnvReads(func(name, ref string, fallback bool) {
+		v := get(name)
+		v.refs = append(v.refs, ref)
+		if !fallback {
+			v.hasDefault = false
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Values from the dotenv files, the first file listing a name wins
+	dotenv := make(map[string]string)
+	dotenvFile := make(map[string]string)
+	for _, file := range envLocalFiles {
+		values, err := godotenv.Read(filepath.Join(t.workDir, file))
+		if err != nil {
+			continue
+		}
+		for name, value := range values {
+			if _, ok := dotenv[name]; !ok {
+				dotenv[name] = value
+				dotenvFile[name] = file
+			}
+		}
+	}
+
+	var names []string
+	for name := range vars {
+		if params.Name == "" || strings.Contains(strings.ToLower(name), strings.ToLower(params.Name)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		if params.Name != "" {
+			return fmt.Sprintf("No expected environment variable matches %q (%d file(s) scanned)\n", params.Name, scanned), nil
+		}
+		return fmt.Sprintf("No environment variables found in .env.example or in code (%d file(s) scanned)\n", scanned), nil
+	}
+
+	var unset, empty, set []string
+	for _, name := range names {
+		v := vars[name]
+		where := envVarOrigin(v)
+		if value, ok := os.LookupEnv(name); ok {
+			if value == "" {
+				empty = append(empty, fmt.Sprintf("  %s  empty in the environment  %s", name, where))
+			} else {
+				set = append(set, fmt.Sprintf("  %s = %s  (environment)  %s", name, core.MaskSecret(value), where))
+			}
+			continue
+		}
+		if value, ok := dotenv[name]; ok {
+			if value == "" {
+				empty = append(empty, fmt.Sprintf("  %s  empty in %s  %s", name, dotenvFile[name], where))
+			} else {
+				set = append(set, fmt.Sprintf("  %s = %s  (%s)  %s", name, core.MaskSecret(value), dotenvFile[name], where))
+			}
+			continue
+		}
+		note := ""
+		if v.hasDefault && len(v.refs) > 0 {
+			note = " (the code has a default)"
+		}
+		unset = append(unset, fmt.Sprintf("  %s%s  %s", name, note, where))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d expected environment variable(s): %d unset, %d empty, %d set", len(names), len(unset), len(empty), len(set)))
+	sb.WriteString(fmt.Sprintf(" (%d template file(s), %d source file(s) scanned)\n", templates, scanned))
+	for _, group := range []struct {
+		title string
+		lines []string
+	}{{"Unset", unset}, {"Empty", empty}, {"Set", set}} {
+		if len(group.lines) == 0 {
+			continue
+		}
+		sb.WriteString("\n" + group.title + ":\n")
+		sb.WriteString(strings.Join(group.lines, "\n") + "\n")
+	}
+	sb.WriteString("\nZAP sees its own environment, which may differ from the server's.\n")
+	return sb.String(), nil
+}
+
+// scanEnvReads calls found for every environment variable read in the
+// project's source and config files and returns the number of files scanned
+func (t *EnvInspectTool) scanEnvReads(found func(name, ref string, fallback bool)) (int, error) {
+	scanned := 0
+	err := filepath.Walk(t.workDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != t.workDir && (strings.HasPrefix(name, ".") || slices.Contains(routeSkipDirs, name)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Size() > maxEnvScanFileSize || isTestSourceFile(info.Name()) ||
+			!slices.Contains(envSourceExts, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil
+		}
+		defer f.Close()
+		scanned++
+
+		rel, _ := filepath.Rel(t.workDir, path)
+		rel = filepath.ToSlash(rel)
+		patterns := envReadPatterns
+		if slices.Contains(envConfigExts, strings.ToLower(filepath.Ext(path))) {
+			patterns = []*regexp.Regexp{envPlaceholderPattern}
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), maxEnvScanFileSize)
+		for n := 1; scanner.Scan(); n++ {
+			line := scanner.Text()
+			for _, pattern := range patterns {
+				for _, m := range pattern.FindAllStringSubmatch(line, -1) {
+					name, fallback := "", false
+					for i, group := range pattern.SubexpNames() {
+						switch group {
+						case "name", "name2":
+							if m[i] != "" {
+								name = m[i]
+							}
+						case "fallback":
+							fallback = m[i] != ""
+						}
+					}
+					if name != "" {
+						found(name, fmt.Sprintf("%s:%d", rel, n), fallback)
+					}
+				}
+			}
+		}
+		if scanned >= maxEnvScanFiles {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil && err != filepath.SkipAll {
+		return scanned, fmt.Errorf("failed to scan for environment variables: %w", err)
+	}
+	return scanned, nil
+}
+
+// readEnvNames returns the variable names in a dotenv template, or nil when
+// the file doesn't exist. Templates often have values godotenv rejects, so
+// only the names are parsed.
+func readEnvNames(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	names := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "export "))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, _, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if ok && envNamePattern.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// envNamePattern matches a valid variable name
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// envVarOrigin says where a variable is expected: its template and first
+// reads in code
+func envVarOrigin(v *envVar) string {
+	var parts []string
+	if v.template != "" {
+		parts = append(parts, "in "+v.template)
+	}
+	if len(v.refs) > 0 {
+		read := "read at " + strings.Join(v.refs[:min(len(v.refs), 2)], ", ")
+		if len(v.refs) > 2 {
+			read += fmt.Sprintf(" (+%d)", len(v.refs)-2)
+		}
+		parts = append(parts, read)
+	}
+	return "[" + strings.Join(parts, "; ") + "]"
+}
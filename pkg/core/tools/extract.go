@@ -28,7 +28,13 @@ type ExtractParams struct {
 	Cookie    string `json:"cookie,omitempty"`      // e.g., "session_token"
 	Regex     string `json:"regex,omitempty"`       // e.g., "token=([a-z0-9]+)"
 	RegexGroup int   `json:"regex_group,omitempty"` // Which capture group to use (default: 1)
+	XPath     string `json:"xpath,omitempty"`       // e.g., "//soap:Body/GetUserResponse/UserId" (XML or HTML)
+	CSS       string `json:"css,omitempty"`         // e.g., "form#login input[name=csrf]" (HTML)
+	Attribute string `json:"attribute,omitempty"`   // With css, read this attribute instead of the text
+	All       bool   `json:"all,omitempty"`         // Save every match as a list, not just the first
+	Append    bool   `json:"append,omitempty"`      // Add to the list in save_as instead of replacing it
 	SaveAs    string `json:"save_as"`               // Variable name to save extracted value
+	Response  string `json:"response,omitempty"`    // Response saved with save_response_as (default: the last one)
 }
 
 // Name returns the tool name
@@ -38,7 +44,7 @@ func (t *ExtractTool) Name() string {
 
 // Description returns the tool description
 func (t *ExtractTool) Description() string {
-	return "Extract values from the last HTTP response (JSON path, headers, cookies, regex) and save as a variable for use in subsequent requests"
+	return "Extract values from the last HTTP response (JSON path, headers, cookies, regex, XPath, CSS selectors) and save as a variable for use in subsequent requests. all saves every match as a list; append adds to a list, e.g. the ids of created resources for iterate"
 }
 
 // Parameters returns the tool parameter description
@@ -49,28 +55,35 @@ func (t *ExtractTool) Parameters() string {
   "cookie": "session_token",
   "regex": "token=([a-z0-9]+)",
   "regex_group": 1,
-  "save_as": "user_id"
+  "xpath": "//order[@status='paid']/id",
+  "css": "input[name=csrf_token]",
+  "attribute": "value (optional, with css)",
+  "save_as": "user_id",
+  "all": false,
+  "append": false,
+  "response": "created (optional: a response saved with save_response_as; default the last one)"
 }`
 }
 
-// Execute extracts a value from the last response
+// Execute extracts a value from the last response, or a saved one
 func (t *ExtractTool) Execute(args string) (string, error) {
-	lastResponse := t.responseManager.GetHTTPResponse()
-	if lastResponse == nil {
-		return "", fmt.Errorf("no HTTP response available - make an http_request first")
-	}
-
 	var params ExtractParams
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
 		return "", fmt.Errorf("failed to parse extraction parameters: %w", err)
 	}
 
+	lastResponse, err := t.responseManager.Response(params.Response)
+	if err != nil {
+		return "", err
+	}
+
 	if params.SaveAs == "" {
 		return "", fmt.Errorf("'save_as' parameter is required")
 	}
 
 	var extractedValue string
 	var extractionMethod string
+	var matches []interface{} // Every value found, for all and append
 
 	// Try each extraction method (only one should be specified)
 	if params.JSONPath != "" {
@@ -80,6 +93,16 @@ func (t *ExtractTool) Execute(args string) (string, error) {
 		}
 		extractedValue = value
 		extractionMethod = "JSON path"
+		// A query matching several values gives a list; numbers stay numbers in it
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			decoded = value
+		}
+		if list, ok := decoded.([]interface{}); ok {
+			matches = list
+		} else {
+			matches = []interface{}{decoded}
+		}
 	} else if params.Header != "" {
 		value, ok := lastResponse.Headers[params.Header]
 		if !ok {
@@ -87,6 +110,10 @@ func (t *ExtractTool) Execute(args string) (string, error) {
 		}
 		extractedValue = value
 		extractionMethod = "header"
+		matches = []interface{}{value}
+		if params.All {
+			matches = stringValues(responseHeaderValues(lastResponse, params.Header))
+		}
 	} else if params.Cookie != "" {
 		value, err := t.extractCookie(params.Cookie, lastResponse)
 		if err != nil {
@@ -94,19 +121,54 @@ func (t *ExtractTool) Execute(args string) (string, error) {
 		}
 		extractedValue = value
 		extractionMethod = "cookie"
+		matches = []interface{}{value}
 	} else if params.Regex != "" {
 		group := params.RegexGroup
 		if group == 0 {
 			group = 1 // Default to first capture group
 		}
-		value, err := t.extractFromRegex(params.Regex, group, lastResponse)
+		values, err := t.extractFromRegex(params.Regex, group, lastResponse)
 		if err != nil {
 			return "", fmt.Errorf("regex extraction failed: %w", err)
 		}
-		extractedValue = value
+		extractedValue = values[0]
 		extractionMethod = "regex"
+		matches = stringValues(values)
+	} else if params.XPath != "" {
+		values, err := t.extractFromXPath(params.XPath, lastResponse)
+		if err != nil {
+			return "", fmt.Errorf("XPath extraction failed: %w", err)
+		}
+		extractedValue = values[0]
+		extractionMethod = "XPath"
+		matches = stringValues(values)
+	} else if params.CSS != "" {
+		values, err := t.extractFromCSS(params.CSS, params.Attribute, lastResponse)
+		if err != nil {
+			return "", fmt.Errorf("CSS selector extraction failed: %w", err)
+		}
+		extractedValue = values[0]
+		extractionMethod = "CSS selector"
+		matches = stringValues(values)
 	} else {
-		return "", fmt.Errorf("no extraction method specified (json_path, header, cookie, or regex)")
+		return "", fmt.Errorf("no extraction method specified (json_path, header, cookie, regex, xpath, or css)")
+	}
+
+	if params.All {
+		extractedValue = listText(matches)
+		extractionMethod += fmt.Sprintf(" (%d value(s))", len(matches))
+	} else if len(matches) > 1 && params.JSONPath == "" {
+		extractionMethod += fmt.Sprintf(" (first of %d matches)", len(matches))
+		matches = matches[:1]
+	}
+	if params.Append {
+		list, ok := t.variables.GetList(params.SaveAs)
+		if old, exists := t.variables.Get(params.SaveAs); exists && !ok && old != "" {
+			list = []interface{}{old}
+		}
+		list = append(list, matches...)
+		extractedValue = listText(list)
+		extractionMethod += fmt.Sprintf(", appended to a list of %d", len(list))
 	}
 
 	// Save to variables
@@ -118,12 +180,12 @@ func (t *ExtractTool) Execute(args string) (string, error) {
 
 // extractFromJSONPath extracts a value using JSON path notation
 func (t *ExtractTool) extractFromJSONPath(path string, lastResponse *HTTPResponse) (string, error) {
-	var jsonData map[string]interface{}
+	var jsonData interface{}
 	if err := json.Unmarshal([]byte(lastResponse.Body), &jsonData); err != nil {
 		return "", fmt.Errorf("response body is not valid JSON: %w", err)
 	}
 
-	value, err := getJSONPath(jsonData, path)
+	value, err := lookupJSONPath(jsonData, path)
 	if err != nil {
 		return "", err
 	}
@@ -166,21 +228,92 @@ func (t *ExtractTool) extractCookie(cookieName string, lastResponse *HTTPRespons
 	return "", fmt.Errorf("cookie '%s' not found in Set-Cookie header", cookieName)
 }
 
-// extractFromRegex extracts a value using regex pattern matching
-func (t *ExtractTool) extractFromRegex(pattern string, group int, lastResponse *HTTPResponse) (string, error) {
+// extractFromRegex returns the capture group of every match of a regex
+func (t *ExtractTool) extractFromRegex(pattern string, group int, lastResponse *HTTPResponse) ([]string, error) {
 	re, err := regexp.Compile(pattern)
 	if err != nil {
-		return "", fmt.Errorf("invalid regex pattern: %w", err)
+		return nil, fmt.Errorf("invalid regex pattern: %w", err)
 	}
 
-	matches := re.FindStringSubmatch(lastResponse.Body)
+	matches := re.FindAllStringSubmatch(lastResponse.Body, -1)
 	if matches == nil {
-		return "", fmt.Errorf("regex pattern '%s' did not match response body", pattern)
+		return nil, fmt.Errorf("regex pattern '%s' did not match response body", pattern)
+	}
+
+	if group < 0 || group >= len(matches[0]) {
+		return nil, fmt.Errorf("capture group %d not found (pattern has %d groups)", group, len(matches[0])-1)
+	}
+
+	values := make([]string, len(matches))
+	for i, match := range matches {
+		values[i] = match[group]
+	}
+	return values, nil
+}
+
+// extractFromXPath returns the text of every node or attribute an XPath
+// expression matches in an XML or HTML body
+func (t *ExtractTool) extractFromXPath(expr string, lastResponse *HTTPResponse) ([]string, error) {
+	doc, err := parseMarkup(lastResponse)
+	if err != nil {
+		return nil, err
+	}
+	values, err := evalXPath(expr, doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("XPath '%s' matched nothing", expr)
+	}
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+	}
+	return values, nil
+}
+
+// extractFromCSS returns the text, or the given attribute, of every element
+// a CSS selector matches in an HTML or XML body
+func (t *ExtractTool) extractFromCSS(selector, attribute string, lastResponse *HTTPResponse) ([]string, error) {
+	doc, err := parseMarkup(lastResponse)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := selectCSS(selector, doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("selector '%s' matched nothing", selector)
 	}
 
-	if group < 0 || group >= len(matches) {
-		return "", fmt.Errorf("capture group %d not found (pattern has %d groups)", group, len(matches)-1)
+	var values []string
+	for _, n := range nodes {
+		if attribute == "" {
+			values = append(values, strings.TrimSpace(nodeText(n)))
+		} else if value, ok := nodeAttr(n, attribute); ok {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("none of the %d element(s) matching '%s' has attribute '%s'", len(nodes), selector, attribute)
 	}
+	return values, nil
+}
 
-	return matches[group], nil
+// stringValues converts extracted texts to list elements
+func stringValues(texts []string) []interface{} {
+	values := make([]interface{}, len(texts))
+	for i, text := range texts {
+		values[i] = text
+	}
+	return values
+}
+
+// listText renders a list variable's value
+func listText(list []interface{}) string {
+	if list == nil {
+		list = []interface{}{}
+	}
+	data, _ := json.Marshal(list)
+	return string(data)
 }
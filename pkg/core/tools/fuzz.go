@@ -0,0 +1,607 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FuzzTool sends mutated variants of a request and reports the payloads that
+// break the server: 5xx responses, hangs and dropped connections
+type FuzzTool struct {
+	httpTool *HTTPTool
+	varStore *VariableStore
+}
+
+// NewFuzzTool creates a new endpoint fuzzing tool
+func NewFuzzTool(httpTool *HTTPTool, varStore *VariableStore) *FuzzTool {
+	return &FuzzTool{
+		httpTool: httpTool,
+		varStore: varStore,
+	}
+}
+
+// FuzzParams defines fuzzing parameters
+type FuzzParams struct {
+	Request     *HTTPRequest `json:"request,omitempty"`      // Example request to mutate (default: the last http_request)
+	Spec        string       `json:"spec,omitempty"`         // OpenAPI spec within the project, for field types and required fields
+	OperationID string       `json:"operation_id,omitempty"` // Defaults to the operation matching the request
+	Categories  []string     `json:"categories,omitempty"`   // missing, types, boundaries, injection (default: all)
+	Fields      []string     `json:"fields,omitempty"`       // Only these fields, e.g. "body.user.email" or "query.limit"
+	MaxRequests int          `json:"max_requests,omitempty"` // Default 100
+	Timeout     int          `json:"timeout,omitempty"`      // Seconds before a request counts as hanging (default 10)
+	DelayMs     int          `json:"delay_ms,omitempty"`     // Pause between requests
+}
+
+// Mutation categories, in the order they are sent
+const (
+	fuzzMissing    = "missing"
+	fuzzTypes      = "types"
+	fuzzBoundaries = "boundaries"
+	fuzzInjection  = "injection"
+)
+
+var fuzzCategories = []string{fuzzMissing, fuzzTypes, fuzzBoundaries, fuzzInjection}
+
+// Limits of a fuzzing run
+const (
+	defaultFuzzRequests = 100
+	maxFuzzRequests     = 500
+	defaultFuzzTimeout  = 10
+)
+
+// fuzzInjections are probes for injection bugs; none of them changes data
+// even when it gets through
+var fuzzInjections = []string{
+	`' OR '1'='1`,
+	`"; --`,
+	`<script>alert(1)</script>`,
+	`{{7*7}}${7*7}`,
+	`../../../../etc/passwd`,
+	"%00\x00",
+	`$(id)`,
+}
+
+// fuzzField is a body field or query parameter to mutate
+type fuzzField struct {
+	location string   // "body" or "query"
+	path     []string // Keys from the body root, or the query parameter name
+	typ      string   // JSON schema type: string, integer, number, boolean, object, array
+	required bool
+	schema   map[string]interface{} // From the spec, if any
+	example  interface{}
+}
+
+func (f fuzzField) name() string {
+	return f.location + "." + strings.Join(f.path, ".")
+}
+
+// fuzzCase is one mutated request
+type fuzzCase struct {
+	category string
+	label    string // e.g. body.age = "abc"
+	invalid  bool   // The server should reject it with a 4xx
+	request  HTTPRequest
+}
+
+// fuzzOutcome is what a fuzzCase got back
+type fuzzOutcome struct {
+	fuzzCase
+	status   int
+	duration time.Duration
+	err      error
+	hang     bool
+}
+
+// Name returns the tool name
+func (t *FuzzTool) Name() string {
+	return "fuzz_endpoint"
+}
+
+// Description returns the tool description
+func (t *FuzzTool) Description() string {
+	return "Fuzz an endpoint: send variants of an example request with fields missing, of the wrong type, at boundary values, or holding injection strings (typed by an OpenAPI spec when given), and report the payloads that caused 5xx responses, hangs or dropped connections."
+}
+
+// Parameters returns the tool parameter description
+func (t *FuzzTool) Parameters() string {
+	return `{
+  "request": {"method": "POST", "url": "http://localhost:8000/api/users", "body": {"name": "Ann", "age": 30}},
+  "spec": "openapi.yaml (optional: field types and required fields)",
+  "operation_id": "createUser (optional, default: match the request)",
+  "categories": ["missing", "types", "boundaries", "injection"],
+  "fields": ["body.age"],
+  "max_requests": 100,
+  "timeout": 10,
+  "delay_ms": 0
+}`
+}
+
+// Execute fuzzes the endpoint
+func (t *FuzzTool) Execute(args string) (string, error) {
+	var params FuzzParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	base := params.Request
+	if base == nil {
+		if base = t.httpTool.LastRequest(); base == nil {
+			return "", fmt.Errorf("no request to fuzz: pass 'request' or make an http_request first")
+		}
+	}
+	req, err := resolveRequest(t.varStore, *base)
+	if err != nil {
+		return "", err
+	}
+	if req.Method == "" || req.URL == "" {
+		return "", fmt.Errorf("request method and url are required")
+	}
+
+	categories := params.Categories
+	if len(categories) == 0 {
+		categories = fuzzCategories
+	}
+	for _, category := range categories {
+		if !slices.Contains(fuzzCategories, category) {
+			return "", fmt.Errorf("unknown category '%s' (use %s)", category, strings.Join(fuzzCategories, ", "))
+		}
+	}
+	maxRequests := params.MaxRequests
+	if maxRequests <= 0 {
+		maxRequests = defaultFuzzRequests
+	}
+	maxRequests = min(maxRequests, maxFuzzRequests)
+	timeout := params.Timeout
+	if timeout <= 0 {
+		timeout = defaultFuzzTimeout
+	}
+
+	var spec *OpenAPISpec
+	var op *OpenAPIOperation
+	if params.Spec != "" {
+		if spec, op, err = loadFuzzOperation(params.Spec, params.OperationID, req); err != nil {
+			return "", err
+		}
+		if req.Body == nil && op.BodySchema != nil {
+			req.Body = spec.Example(op.BodySchema, true)
+		}
+	}
+
+	fields := fuzzFields(req, spec, op)
+	if len(params.Fields) > 0 {
+		fields = slices.DeleteFunc(fields, func(f fuzzField) bool {
+			return !slices.Contains(params.Fields, f.name())
+		})
+	}
+	if len(fields) == 0 {
+		return "", fmt.Errorf("nothing to fuzz: the request has no JSON body fields or query parameters")
+	}
+
+	req.Timeout = timeout
+	req.Cache, req.SaveBodyTo = "", ""
+	var cases []fuzzCase
+	for _, category := range categories {
+		if category == fuzzMissing && req.Body != nil {
+			noBody := req
+			noBody.Body = nil
+			cases = append(cases, fuzzCase{category: category, label: "no body", invalid: true, request: noBody})
+		}
+		for _, field := range fields {
+			cases = append(cases, mutateField(req, field, category)...)
+		}
+	}
+	skipped := 0
+	if len(cases) > maxRequests {
+		skipped = len(cases) - maxRequests
+		cases = cases[:maxRequests]
+	}
+
+	baseline := t.send(fuzzCase{label: "original request", request: req})
+	outcomes := make([]fuzzOutcome, 0, len(cases))
+	for i, c := range cases {
+		if i > 0 && params.DelayMs > 0 {
+			time.Sleep(time.Duration(params.DelayMs) * time.Millisecond)
+		}
+		outcomes = append(outcomes, t.send(c))
+	}
+
+	return formatFuzzResult(req, fields, baseline, outcomes, skipped, timeout), nil
+}
+
+// resolveRequest substitutes variables into a request
+func resolveRequest(varStore *VariableStore, req HTTPRequest) (HTTPRequest, error) {
+	if varStore == nil {
+		return req, nil
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return req, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	var resolved HTTPRequest
+	if err := json.Unmarshal([]byte(varStore.Substitute(string(data))), &resolved); err != nil {
+		return req, fmt.Errorf("failed to parse request: %w", err)
+	}
+	return resolved, nil
+}
+
+// send runs a case, telling hangs (timeouts) apart from other errors
+func (t *FuzzTool) send(c fuzzCase) fuzzOutcome {
+	start := time.Now()
+	resp, err := t.httpTool.Run(c.request)
+	outcome := fuzzOutcome{fuzzCase: c, duration: time.Since(start), err: err}
+	if err != nil {
+		var netErr net.Error
+		outcome.hang = errors.As(err, &netErr) && netErr.Timeout()
+		return outcome
+	}
+	outcome.status = resp.StatusCode
+	return outcome
+}
+
+// loadFuzzOperation loads a spec and finds the request's operation in it
+func loadFuzzOperation(specFile, operationID string, req HTTPRequest) (*OpenAPISpec, *OpenAPIOperation, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	specPath, err := ValidatePathWithinWorkDir(specFile, workDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid spec: %w", err)
+	}
+	spec, err := LoadOpenAPISpec(specPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var op *OpenAPIOperation
+	if operationID != "" {
+		if op = spec.OperationByID(operationID); op == nil {
+			return nil, nil, fmt.Errorf("operation '%s' not found in %s", operationID, specFile)
+		}
+	} else if op = spec.FindOperation(req.Method, req.URL); op == nil {
+		return nil, nil, fmt.Errorf("no operation in %s matches %s %s; pass 'operation_id'", specFile, strings.ToUpper(req.Method), req.URL)
+	}
+	return spec, op, nil
+}
+
+// fuzzFields lists the request's JSON body fields (nested objects included)
+// and query parameters, typed by the spec when there is one
+func fuzzFields(req HTTPRequest, spec *OpenAPISpec, op *OpenAPIOperation) []fuzzField {
+	var fields []fuzzField
+
+	if body, ok := req.Body.(map[string]interface{}); ok {
+		var schema map[string]interface{}
+		if op != nil {
+			schema = op.BodySchema
+		}
+		fields = append(fields, bodyFuzzFields(body, nil, schema, spec)...)
+	}
+
+	query := make(map[string]interface{})
+	if _, rawQuery, ok := strings.Cut(req.URL, "?"); ok {
+		values, _ := url.ParseQuery(strings.SplitN(rawQuery, "#", 2)[0])
+		for key := range values {
+			query[key] = values.Get(key)
+		}
+	}
+	for key, value := range req.Query {
+		query[key] = value
+	}
+	params := make(map[string]OpenAPIParameter)
+	if op != nil {
+		for _, p := range op.Parameters {
+			if p.In == "query" {
+				params[p.Name] = p
+				if _, ok := query[p.Name]; !ok && p.Required {
+					query[p.Name] = p.Example
+				}
+			}
+		}
+	}
+	for _, key := range sortedKeys(query) {
+		field := fuzzField{location: "query", path: []string{key}, example: query[key], typ: queryValueType(query[key])}
+		if p, ok := params[key]; ok {
+			field.required = p.Required
+			if spec != nil {
+				field.schema = spec.resolve(p.Schema)
+			}
+			if typ := schemaType(field.schema); typ != "" {
+				field.typ = typ
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// bodyFuzzFields lists the fields of a body object, recursing into nested objects
+func bodyFuzzFields(obj map[string]interface{}, prefix []string, schema map[string]interface{}, spec *OpenAPISpec) []fuzzField {
+	var properties map[string]interface{}
+	var required []interface{}
+	if spec != nil {
+		schema = spec.resolve(schema)
+		properties = mapField(schema, "properties")
+		required = listField(schema, "required")
+	}
+
+	var fields []fuzzField
+	for _, key := range sortedKeys(obj) {
+		path := append(slices.Clone(prefix), key)
+		field := fuzzField{location: "body", path: path, example: obj[key], typ: valueSchemaType(obj[key])}
+		if spec != nil {
+			field.schema = spec.resolve(properties[key])
+			field.required = slices.Contains(required, interface{}(key))
+			if typ := schemaType(field.schema); typ != "" {
+				field.typ = typ
+			}
+		}
+		fields = append(fields, field)
+		if nested, ok := obj[key].(map[string]interface{}); ok {
+			fields = append(fields, bodyFuzzFields(nested, path, field.schema, spec)...)
+		}
+	}
+	return fields
+}
+
+// queryValueType infers a schema type from a query value, which is text
+// even when it holds a number or a boolean
+func queryValueType(v interface{}) string {
+	text, ok := v.(string)
+	if !ok {
+		return valueSchemaType(v)
+	}
+	if _, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return "integer"
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return "number"
+	}
+	if _, err := strconv.ParseBool(text); err == nil {
+		return "boolean"
+	}
+	return "string"
+}
+
+// valueSchemaType infers a schema type from an example value
+func valueSchemaType(v interface{}) string {
+	switch value := v.(type) {
+	case float64:
+		if value == float64(int64(value)) {
+			return "integer"
+		}
+		return "number"
+	case nil:
+		return ""
+	}
+	return jsonTypeName(v)
+}
+
+// mutateField returns the requests of one category for a field
+func mutateField(req HTTPRequest, field fuzzField, category string) []fuzzCase {
+	var cases []fuzzCase
+	set := func(label string, value interface{}, invalid bool) {
+		cases = append(cases, fuzzCase{
+			category: category,
+			label:    fmt.Sprintf("%s = %s (%s)", field.name(), describeJSONValue(value), label),
+			invalid:  invalid,
+			request:  withField(req, field, value, false),
+		})
+	}
+
+	switch category {
+	case fuzzMissing:
+		label := field.name() + " missing"
+		if field.required {
+			label += " (required)"
+		}
+		cases = append(cases, fuzzCase{category: category, label: label, invalid: field.required, request: withField(req, field, nil, true)})
+
+	case fuzzTypes:
+		wrong := []interface{}{"fuzz", float64(12345), true, nil, map[string]interface{}{}, []interface{}{}}
+		if field.location == "query" {
+			// Query values travel as text; only unparsable text and repeats are wrong
+			wrong = []interface{}{"fuzz", []interface{}{"1", "2"}}
+		}
+		for _, value := range wrong {
+			typ := valueSchemaType(value)
+			if typ == field.typ || (typ == "integer" && field.typ == "number") || (field.location == "query" && typ == "string" && field.typ == "string") {
+				continue
+			}
+			if value == nil && field.location == "body" {
+				set("null", nil, field.required)
+				continue
+			}
+			set("wrong type: "+typ, value, field.typ != "")
+		}
+
+	case fuzzBoundaries:
+		for _, b := range boundaryValues(field) {
+			set(b.label, b.value, b.invalid)
+		}
+
+	case fuzzInjection:
+		if field.typ != "string" && field.typ != "" {
+			return nil
+		}
+		for _, probe := range fuzzInjections {
+			set("injection", probe, false)
+		}
+	}
+	return cases
+}
+
+type boundaryValue struct {
+	label   string
+	value   interface{}
+	invalid bool // Outside what the schema allows
+}
+
+// boundaryValues returns edge values for a field's type, and just outside
+// the limits its schema declares
+func boundaryValues(field fuzzField) []boundaryValue {
+	var values []boundaryValue
+	schema := field.schema
+	switch field.typ {
+	case "integer", "number":
+		values = append(values,
+			boundaryValue{label: "zero", value: 0.0},
+			boundaryValue{label: "negative", value: -1.0},
+			boundaryValue{label: "int32 overflow", value: json.Number("2147483648")},
+			boundaryValue{label: "int64 overflow", value: json.Number("9223372036854775808")},
+			boundaryValue{label: "huge", value: json.Number("1e308")},
+		)
+		if field.typ == "integer" {
+			values = append(values, boundaryValue{label: "fraction", value: 1.5, invalid: true})
+		}
+		if minimum, ok := schema["minimum"].(float64); ok {
+			values = append(values, boundaryValue{label: "below minimum", value: minimum - 1, invalid: true})
+		}
+		if maximum, ok := schema["maximum"].(float64); ok {
+			values = append(values, boundaryValue{label: "above maximum", value: maximum + 1, invalid: true})
+		}
+	case "string", "":
+		values = append(values,
+			boundaryValue{label: "empty", value: ""},
+			boundaryValue{label: "whitespace", value: "   "},
+			boundaryValue{label: "10000 chars", value: strings.Repeat("A", 10000)},
+			boundaryValue{label: "unicode", value: "\U0001F4A5\u202e\u01c5\uffff"},
+		)
+		if maxLength, ok := schema["maxLength"].(float64); ok {
+			values = append(values, boundaryValue{label: "above maxLength", value: strings.Repeat("a", int(maxLength)+1), invalid: true})
+		}
+		if _, ok := schema["enum"]; ok {
+			values = append(values, boundaryValue{label: "not in enum", value: "not-in-enum", invalid: true})
+		}
+		if format := stringField(schema, "format"); format != "" {
+			values = append(values, boundaryValue{label: "invalid " + format, value: "not-a-" + format, invalid: true})
+		}
+	case "array":
+		var item interface{}
+		if list, ok := field.example.([]interface{}); ok && len(list) > 0 {
+			item = list[0]
+		}
+		values = append(values,
+			boundaryValue{label: "empty array", value: []interface{}{}},
+			boundaryValue{label: "1000 items", value: slices.Repeat([]interface{}{item}, 1000)},
+		)
+	case "object":
+		values = append(values, boundaryValue{label: "empty object", value: map[string]interface{}{}})
+	}
+	return values
+}
+
+// withField returns a copy of req with a field set to value, or removed
+func withField(req HTTPRequest, field fuzzField, value interface{}, remove bool) HTTPRequest {
+	if field.location == "query" {
+		query := make(map[string]interface{}, len(req.Query)+1)
+		for key, v := range req.Query {
+			query[key] = v
+		}
+		query[field.path[0]] = value // nil drops the parameter from the URL
+		if remove {
+			query[field.path[0]] = nil
+		}
+		req.Query = query
+		return req
+	}
+
+	// Deep-copy the body so cases don't share it
+	data, _ := json.Marshal(req.Body)
+	var body interface{}
+	_ = json.Unmarshal(data, &body)
+	obj, _ := body.(map[string]interface{})
+	for _, key := range field.path[:len(field.path)-1] {
+		obj, _ = obj[key].(map[string]interface{})
+	}
+	if obj != nil {
+		last := field.path[len(field.path)-1]
+		if remove {
+			delete(obj, last)
+		} else {
+			obj[last] = value
+		}
+	}
+	req.Body = body
+	return req
+}
+
+// formatFuzzResult renders the findings of a fuzzing run
+func formatFuzzResult(req HTTPRequest, fields []fuzzField, baseline fuzzOutcome, outcomes []fuzzOutcome, skipped, timeout int) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Fuzzed %s %s: %d request(s) over %d field(s)\n", strings.ToUpper(req.Method), req.URL, len(outcomes), len(fields)))
+	switch {
+	case baseline.err != nil:
+		sb.WriteString(fmt.Sprintf("Original request failed: %v\n", baseline.err))
+	default:
+		sb.WriteString(fmt.Sprintf("Original request: %d in %dms\n", baseline.status, baseline.duration.Milliseconds()))
+	}
+	if skipped > 0 {
+		sb.WriteString(fmt.Sprintf("%d more mutation(s) not sent (max_requests)\n", skipped))
+	}
+	sb.WriteString("\n")
+
+	var broken, accepted []fuzzOutcome
+	codes := make(map[int]int)
+	for _, o := range outcomes {
+		switch {
+		case o.err != nil || o.status >= 500:
+			broken = append(broken, o)
+		case o.status >= 200 && o.status < 300 && o.invalid:
+			accepted = append(accepted, o)
+		}
+		if o.err == nil {
+			codes[o.status]++
+		}
+	}
+
+	if len(broken) == 0 {
+		sb.WriteString("✓ No payload caused a 5xx, a hang or a dropped connection\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("✗ %d payload(s) broke the server:\n", len(broken)))
+		for i, o := range broken {
+			var what string
+			switch {
+			case o.hang:
+				what = fmt.Sprintf("HANG (no response in %ds)", timeout)
+			case o.err != nil:
+				what = fmt.Sprintf("ERROR %v", o.err)
+			default:
+				what = fmt.Sprintf("%d", o.status)
+			}
+			sb.WriteString(fmt.Sprintf("  %d. %s  %s  [%dms]\n", i+1, what, o.label, o.duration.Milliseconds()))
+		}
+	}
+
+	if len(codes) > 0 {
+		statuses := make([]int, 0, len(codes))
+		for code := range codes {
+			statuses = append(statuses, code)
+		}
+		sort.Ints(statuses)
+		parts := make([]string, len(statuses))
+		for i, code := range statuses {
+			parts[i] = fmt.Sprintf("%d x%d", code, codes[code])
+		}
+		sb.WriteString("\nStatus codes: " + strings.Join(parts, ", ") + "\n")
+	}
+
+	if len(accepted) > 0 {
+		sb.WriteString(fmt.Sprintf("\n⚠ %d invalid payload(s) were accepted with 2xx (missing validation?):\n", len(accepted)))
+		for i, o := range accepted {
+			if i == 10 {
+				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(accepted)-10))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("  - %d  %s\n", o.status, o.label))
+		}
+	}
+	return sb.String()
+}
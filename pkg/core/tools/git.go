@@ -0,0 +1,336 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// gitTimeout bounds every git call
+	gitTimeout = 15 * time.Second
+	// defaultBlameContext is the lines blamed on each side of line
+	defaultBlameContext = 5
+	// maxBlameLines caps a blame without a line number
+	maxBlameLines = 200
+	// defaultGitLogCount and maxGitLogCount bound the commits listed
+	defaultGitLogCount = 10
+	maxGitLogCount     = 50
+	// maxGitDiffLines caps diff output
+	maxGitDiffLines = 300
+)
+
+// GitTool answers who changed a file or line and when, so a failure can be
+// tied to the commit that introduced it. It only runs read-only git commands.
+type GitTool struct {
+	workDir string
+}
+
+// NewGitTool creates a new git history tool for the repository at workDir
+func NewGitTool(workDir string) *GitTool {
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	return &GitTool{workDir: workDir}
+}
+
+// GitParams defines the git parameters
+type GitParams struct {
+	Action  string `json:"action"`            // blame, log or diff
+	File    string `json:"file,omitempty"`    // File in the project
+	Line    int    `json:"line,omitempty"`    // Line to blame or follow the history of
+	Context int    `json:"context,omitempty"` // Lines blamed on each side of line (default 5)
+	Count   int    `json:"count,omitempty"`   // Commits listed by log (default 10, max 50)
+	Ref     string `json:"ref,omitempty"`     // diff: compare the working tree with this ref (default HEAD)
+	Commit  string `json:"commit,omitempty"`  // diff: show what this commit changed instead
+}
+
+// Name returns the tool name
+func (t *GitTool) Name() string {
+	return "git"
+}
+
+// Description returns the tool description
+func (t *GitTool) Description() string {
+	return "Read-only git history: 'blame' shows who last changed the lines around file:line and how many commits ago, 'log' lists recent commits touching a file (or a line), 'diff' shows uncommitted changes or what a commit changed. Use to find the change that caused a regression"
+}
+
+// Parameters returns the tool parameter description
+func (t *GitTool) Parameters() string {
+	return `{"action": "string (required) - blame, log or diff", "file": "string - file in the project (required for blame)", "line": "number - line to blame, or whose history log follows", "context": "number - lines blamed on each side of line (default 5)", "count": "number - commits listed by log (default 10, max 50)", "ref": "string - diff: compare the working tree with this ref, e.g. HEAD~3 (default HEAD)", "commit": "string - diff: show what this commit changed"}`
+}
+
+// Execute runs the requested git action
+func (t *GitTool) Execute(args string) (string, error) {
+	var params GitParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", fmt.Errorf("git not found on PATH")
+	}
+	if _, err := t.git("rev-parse", "--git-dir"); err != nil {
+		return "", fmt.Errorf("%s is not in a git repository", t.workDir)
+	}
+
+	file := ""
+	if params.File != "" {
+		path, err := ValidatePathWithinWorkDir(params.File, t.workDir)
+		if err != nil {
+			return "", err
+		}
+		// git wants paths relative to where it runs
+		absWorkDir, _ := filepath.Abs(t.workDir)
+		if file, err = filepath.Rel(absWorkDir, path); err != nil {
+			return "", fmt.Errorf("invalid path: %w", err)
+		}
+		file = filepath.ToSlash(file)
+	}
+	for _, ref := range []string{params.Ref, params.Commit} {
+		if strings.HasPrefix(ref, "-") {
+			return "", fmt.Errorf("invalid ref %q", ref)
+		}
+	}
+
+	switch params.Action {
+	case "blame":
+		if file == "" {
+			return "", fmt.Errorf("file is required for blame")
+		}
+		if params.Context <= 0 {
+			params.Context = defaultBlameContext
+		}
+		return t.blame(file, params.Line, params.Context)
+	case "log", "":
+		if params.Count <= 0 {
+			params.Count = defaultGitLogCount
+		}
+		return t.log(file, params.Line, min(params.Count, maxGitLogCount))
+	case "diff", "show":
+		return t.diff(file, params.Ref, params.Commit)
+	}
+	return "", fmt.Errorf("unknown action %q: use blame, log or diff", params.Action)
+}
+
+// git runs a git command in the project and returns its stdout
+func (t *GitTool) git(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--no-pager"}, args...)...)
+	cmd.Dir = t.workDir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// blameLine is one line of git blame --porcelain output
+type blameLine struct {
+	commit  string
+	number  int
+	content string
+}
+
+// blameCommit is the author and summary of a commit seen in a blame
+type blameCommit struct {
+	author  string
+	time    time.Time
+	summary string
+}
+
+// blame shows who last changed the lines around line (the start of the file
+// without a line) and lists those commits with their distance from HEAD
+func (t *GitTool) blame(file string, line, context int) (string, error) {
+	data, err := os.ReadFile(filepath.Join(t.workDir, file))
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	total := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		total++
+	}
+	if total == 0 {
+		return fmt.Sprintf("%s is empty\n", file), nil
+	}
+	if line > total {
+		return "", fmt.Errorf("%s has only %d lines", file, total)
+	}
+	start, end := 1, min(total, maxBlameLines)
+	if line > 0 {
+		start, end = max(line-context, 1), min(line+context, total)
+	}
+	out, err := t.git("blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", start, end), "--", file)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []blameLine
+	commits := make(map[string]*blameCommit)
+	var order []string
+	var current *blameLine
+	for _, raw := range strings.Split(out, "\n") {
+		if strings.HasPrefix(raw, "\t") {
+			if current != nil {
+				current.content = raw[1:]
+				lines = append(lines, *current)
+				current = nil
+			}
+			continue
+		}
+		fields := strings.Fields(raw)
+		if len(fields) >= 3 && len(fields[0]) == 40 {
+			number, _ := strconv.Atoi(fields[2])
+			current = &blameLine{commit: fields[0], number: number}
+			if commits[fields[0]] == nil {
+				commits[fields[0]] = &blameCommit{}
+				order = append(order, fields[0])
+			}
+			continue
+		}
+		if current == nil || len(fields) < 2 {
+			continue
+		}
+		c := commits[current.commit]
+		value := strings.TrimSpace(strings.TrimPrefix(raw, fields[0]))
+		switch fields[0] {
+		case "author":
+			c.author = value
+		case "author-time":
+			if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+				c.time = time.Unix(unix, 0)
+			}
+		case "summary":
+			c.summary = value
+		}
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("No lines to blame in %s\n", file), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Blame of %s:%d-%d\n\n", file, lines[0].number, lines[len(lines)-1].number))
+	for _, l := range lines {
+		marker := "  "
+		if l.number == line {
+			marker = "> "
+		}
+		c := commits[l.commit]
+		sb.WriteString(fmt.Sprintf("%s%5d  %s  %-16s %s  %s\n", marker, l.number, shortHash(l.commit),
+			c.author, c.time.Format("2006-01-02"), l.content))
+	}
+
+	sb.WriteString("\nCommits:\n")
+	for _, hash := range order {
+		c := commits[hash]
+		if strings.Trim(hash, "0") == "" {
+			sb.WriteString("  (uncommitted changes)\n")
+			continue
+		}
+		ago := ""
+		if count, err := t.git("rev-list", "--count", hash+"..HEAD"); err == nil {
+			switch n := strings.TrimSpace(count); n {
+			case "0":
+				ago = ", the latest commit"
+			case "1":
+				ago = ", 1 commit ago"
+			default:
+				ago = ", " + n + " commits ago"
+			}
+		}
+		sb.WriteString(fmt.Sprintf("  %s by %s on %s%s: %s\n", shortHash(hash), c.author,
+			c.time.Format("2006-01-02"), ago, c.summary))
+	}
+	return sb.String(), nil
+}
+
+// log lists the latest commits, touching file (or line in it) when given
+func (t *GitTool) log(file string, line, count int) (string, error) {
+	args := []string{"log", "-n", strconv.Itoa(count), "--format=%h%x1f%an%x1f%ad%x1f%ar%x1f%s", "--date=short"}
+	what := "the repository"
+	switch {
+	case file != "" && line > 0:
+		args = append(args, "-s", "-L", fmt.Sprintf("%d,%d:%s", line, line, file))
+		what = fmt.Sprintf("%s:%d", file, line)
+	case file != "":
+		args = append(args, "--follow", "--", file)
+		what = file
+	}
+	out, err := t.git(args...)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	entries := splitLogLines(out)
+	if len(entries) == 0 {
+		return fmt.Sprintf("No commits touch %s\n", what), nil
+	}
+	sb.WriteString(fmt.Sprintf("Last %d commit(s) touching %s:\n\n", len(entries), what))
+	for _, entry := range entries {
+		fields := strings.Split(entry, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  %s  %s (%s)  %s: %s\n", fields[0], fields[2], fields[3], fields[1], fields[4]))
+	}
+	return sb.String(), nil
+}
+
+// diff shows what commit changed, or the working tree's changes since ref
+func (t *GitTool) diff(file, ref, commit string) (string, error) {
+	var args []string
+	var what string
+	if commit != "" {
+		args = []string{"show", "--stat", "--patch", "--format=%h %an %ad%n%s%n", "--date=short", commit}
+		what = "Commit " + commit
+	} else {
+		if ref == "" {
+			ref = "HEAD"
+		}
+		args = []string{"diff", "--stat", "--patch", ref}
+		what = "Changes since " + ref
+	}
+	if file != "" {
+		args = append(args, "--", file)
+		what += " in " + file
+	}
+	out, err := t.git(args...)
+	if err != nil {
+		return "", err
+	}
+
+	lines := splitLogLines(out)
+	if len(lines) == 0 {
+		return what + ": none\n", nil
+	}
+	var sb strings.Builder
+	sb.WriteString(what + ":\n\n")
+	if len(lines) > maxGitDiffLines {
+		sb.WriteString(strings.Join(lines[:maxGitDiffLines], "\n"))
+		sb.WriteString(fmt.Sprintf("\n... (%d more line(s); pass file to narrow the diff)\n", len(lines)-maxGitDiffLines))
+		return sb.String(), nil
+	}
+	sb.WriteString(strings.Join(lines, "\n") + "\n")
+	return sb.String(), nil
+}
+
+// shortHash abbreviates a commit hash
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// gitignoreRule is one pattern of a .gitignore file
+type gitignoreRule struct {
+	base    string // Directory of the .gitignore, relative to the root ("" for the root)
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// gitignore matches paths against the .gitignore files of a tree. Rules of
+// a directory's .gitignore are added with loadDir as the walk enters it.
+type gitignore struct {
+	root   string
+	rules  []gitignoreRule
+	loaded map[string]bool
+}
+
+// newGitignore reads the root's .gitignore and .git/info/exclude
+func newGitignore(root string) *gitignore {
+	g := &gitignore{root: root, loaded: make(map[string]bool)}
+	g.loadFile(filepath.Join(root, ".git", "info", "exclude"), "")
+	g.loadDir("")
+	return g
+}
+
+// loadDir adds the rules of rel/.gitignore, once
+func (g *gitignore) loadDir(rel string) {
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		rel = ""
+	}
+	if g.loaded[rel] {
+		return
+	}
+	g.loaded[rel] = true
+	g.loadFile(filepath.Join(g.root, filepath.FromSlash(rel), ".gitignore"), rel)
+}
+
+// loadAncestors loads the .gitignore files from the root down to rel, for
+// walks starting below the root
+func (g *gitignore) loadAncestors(rel string) {
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == "" {
+		return
+	}
+	dir := ""
+	for _, part := range strings.Split(rel, "/") {
+		dir = path.Join(dir, part)
+		g.loadDir(dir)
+	}
+}
+
+// loadFile parses a gitignore-format file whose patterns are relative to base
+func (g *gitignore) loadFile(file, base string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := gitignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		// A slash anywhere but the end anchors the pattern to base
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr := globExpr(line)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			continue
+		}
+		rule.pattern = re
+		g.rules = append(g.rules, rule)
+	}
+}
+
+// ignored reports whether rel (slash-separated, relative to the root) is
+// ignored. The last matching rule wins, as in git.
+func (g *gitignore) ignored(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if rule.base != "" {
+			var ok bool
+			if sub, ok = strings.CutPrefix(rel, rule.base+"/"); !ok {
+				continue
+			}
+		}
+		if rule.pattern.MatchString(sub) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// globExpr converts a glob with *, **, ? and [...] to a regular expression
+func globExpr(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// globMatcher matches paths against include/exclude globs the way ripgrep's
+// -g does: a glob without a slash matches the file name, one with a slash
+// the path relative to the project
+type globMatcher struct {
+	name []*regexp.Regexp
+	path []*regexp.Regexp
+}
+
+// newGlobMatcher compiles globs; invalid ones are skipped
+func newGlobMatcher(globs []string) *globMatcher {
+	m := &globMatcher{}
+	for _, glob := range globs {
+		glob = strings.TrimSpace(glob)
+		if glob == "" {
+			continue
+		}
+		if strings.Contains(strings.TrimSuffix(glob, "/"), "/") {
+			if re, err := regexp.Compile("^" + globExpr(strings.TrimPrefix(strings.TrimSuffix(glob, "/"), "/")) + "$"); err == nil {
+				m.path = append(m.path, re)
+			}
+			continue
+		}
+		if re, err := regexp.Compile("^" + globExpr(strings.TrimSuffix(glob, "/")) + "$"); err == nil {
+			m.name = append(m.name, re)
+		}
+	}
+	return m
+}
+
+// empty reports whether there are no globs
+func (m *globMatcher) empty() bool {
+	return len(m.name) == 0 && len(m.path) == 0
+}
+
+// match reports whether rel or its file name matches a glob
+func (m *globMatcher) match(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, re := range m.path {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	name := path.Base(rel)
+	for _, re := range m.name {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
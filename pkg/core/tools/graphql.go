@@ -0,0 +1,359 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// graphQLIntrospectionQuery is the standard introspection query, trimmed to
+// what the condensed schema needs (no directives or descriptions of args).
+const graphQLIntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      description
+      fields(includeDeprecated: true) {
+        name
+        description
+        args { name type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+        isDeprecated
+      }
+      inputFields { name type { ...TypeRef } defaultValue }
+      enumValues(includeDeprecated: true) { name }
+      possibleTypes { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+// GraphQLIntrospectTool fetches a GraphQL schema via introspection and
+// condenses it so the agent can build valid queries.
+type GraphQLIntrospectTool struct {
+	httpTool        *HTTPTool
+	responseManager *ResponseManager
+	varStore        *VariableStore
+}
+
+// NewGraphQLIntrospectTool creates a new GraphQL introspection tool.
+func NewGraphQLIntrospectTool(httpTool *HTTPTool, responseManager *ResponseManager, varStore *VariableStore) *GraphQLIntrospectTool {
+	return &GraphQLIntrospectTool{
+		httpTool:        httpTool,
+		responseManager: responseManager,
+		varStore:        varStore,
+	}
+}
+
+// GraphQLIntrospectParams defines the introspection parameters.
+type GraphQLIntrospectParams struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Types   bool              `json:"types,omitempty"` // Include object/input/enum type definitions
+	Filter  string            `json:"filter,omitempty"`
+	Timeout int               `json:"timeout,omitempty"`
+}
+
+// gqlTypeRef is a (possibly wrapped) type reference.
+type gqlTypeRef struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	OfType *gqlTypeRef `json:"ofType"`
+}
+
+// gqlInputValue is an argument or input field.
+type gqlInputValue struct {
+	Name         string     `json:"name"`
+	Type         gqlTypeRef `json:"type"`
+	DefaultValue *string    `json:"defaultValue"`
+}
+
+// gqlField is a field of an object or interface type.
+type gqlField struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	Args         []gqlInputValue `json:"args"`
+	Type         gqlTypeRef      `json:"type"`
+	IsDeprecated bool            `json:"isDeprecated"`
+}
+
+// gqlType is a named type in the schema.
+type gqlType struct {
+	Kind          string                  `json:"kind"`
+	Name          string                  `json:"name"`
+	Description   string                  `json:"description"`
+	Fields        []gqlField              `json:"fields"`
+	InputFields   []gqlInputValue         `json:"inputFields"`
+	EnumValues    []struct{ Name string } `json:"enumValues"`
+	PossibleTypes []struct{ Name string } `json:"possibleTypes"`
+}
+
+// gqlIntrospectionResponse is the introspection result envelope.
+type gqlIntrospectionResponse struct {
+	Data struct {
+		Schema struct {
+			QueryType        *struct{ Name string } `json:"queryType"`
+			MutationType     *struct{ Name string } `json:"mutationType"`
+			SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+			Types            []gqlType              `json:"types"`
+		} `json:"__schema"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Name returns the tool name.
+func (t *GraphQLIntrospectTool) Name() string {
+	return "graphql_introspect"
+}
+
+// Description returns the tool description.
+func (t *GraphQLIntrospectTool) Description() string {
+	return "Fetch a GraphQL endpoint's schema via introspection and list its queries, mutations and subscriptions (with arguments and return types) in a condensed SDL-like format. Set types=true to include object, input and enum definitions. Use before writing GraphQL queries with http_request."
+}
+
+// Parameters returns the tool parameter description.
+func (t *GraphQLIntrospectTool) Parameters() string {
+	return `{
+  "url": "string (required) - GraphQL endpoint, e.g. {{BASE_URL}}/graphql",
+  "headers": {"Authorization": "Bearer {{TOKEN}}"},
+  "types": false,
+  "filter": "optional substring to limit output to matching operations/types",
+  "timeout": 30
+}`
+}
+
+// Execute runs the introspection query and returns the condensed schema.
+func (t *GraphQLIntrospectTool) Execute(args string) (string, error) {
+	if t.varStore != nil {
+		args = t.varStore.Substitute(args)
+	}
+
+	var params GraphQLIntrospectParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	if params.URL == "" {
+		return "", fmt.Errorf("'url' is required")
+	}
+
+	resp, err := t.httpTool.Run(HTTPRequest{
+		Method:  "POST",
+		URL:     params.URL,
+		Headers: params.Headers,
+		Body:    map[string]interface{}{"query": graphQLIntrospectionQuery},
+		Timeout: params.Timeout,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the raw schema available to extract_value / assert_response
+	if t.responseManager != nil {
+		t.responseManager.SetHTTPResponse(resp)
+	}
+
+	if resp.StatusCode != 200 {
+		return fmt.Sprintf("Introspection failed with %s\n\n%s", resp.Status, truncateBody(resp.Body, 2000)), nil
+	}
+
+	var result gqlIntrospectionResponse
+	if err := json.Unmarshal([]byte(resp.Body), &result); err != nil {
+		return "", fmt.Errorf("response is not a GraphQL JSON result: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		var msgs []string
+		for _, e := range result.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return fmt.Sprintf("Introspection returned errors (it may be disabled on this server):\n- %s", strings.Join(msgs, "\n- ")), nil
+	}
+
+	return formatGraphQLSchema(result, params.Types, params.Filter), nil
+}
+
+// formatGraphQLSchema renders the schema's root operations (and optionally
+// its types) as compact SDL-like text.
+func formatGraphQLSchema(result gqlIntrospectionResponse, includeTypes bool, filter string) string {
+	schema := result.Data.Schema
+	types := make(map[string]gqlType, len(schema.Types))
+	for _, t := range schema.Types {
+		types[t.Name] = t
+	}
+	filter = strings.ToLower(filter)
+
+	var sb strings.Builder
+	roots := []struct {
+		label string
+		ref   *struct{ Name string }
+	}{
+		{"Queries", schema.QueryType},
+		{"Mutations", schema.MutationType},
+		{"Subscriptions", schema.SubscriptionType},
+	}
+	for _, root := range roots {
+		if root.ref == nil {
+			continue
+		}
+		rootType, ok := types[root.ref.Name]
+		if !ok {
+			continue
+		}
+
+		var lines []string
+		for _, f := range rootType.Fields {
+			if filter != "" && !strings.Contains(strings.ToLower(f.Name), filter) {
+				continue
+			}
+			lines = append(lines, "  "+formatGraphQLField(f))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("## %s (%s, %d)\n", root.label, root.ref.Name, len(lines)))
+		sb.WriteString(strings.Join(lines, "\n"))
+		sb.WriteString("\n\n")
+	}
+
+	if !includeTypes {
+		sb.WriteString("(Use types=true to include object, input and enum definitions.)")
+		return strings.TrimSpace(sb.String())
+	}
+
+	rootNames := map[string]bool{}
+	for _, root := range roots {
+		if root.ref != nil {
+			rootNames[root.ref.Name] = true
+		}
+	}
+
+	names := make([]string, 0, len(types))
+	for name, t := range types {
+		if strings.HasPrefix(name, "__") || rootNames[name] || t.Kind == "SCALAR" {
+			continue
+		}
+		if filter != "" && !strings.Contains(strings.ToLower(name), filter) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > 0 {
+		sb.WriteString("## Types\n")
+	}
+	for _, name := range names {
+		sb.WriteString(formatGraphQLType(types[name]))
+		sb.WriteString("\n")
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// formatGraphQLField renders "name(arg: Type = default): ReturnType".
+func formatGraphQLField(f gqlField) string {
+	var sb strings.Builder
+	sb.WriteString(f.Name)
+	if len(f.Args) > 0 {
+		args := make([]string, len(f.Args))
+		for i, a := range f.Args {
+			args[i] = formatGraphQLInputValue(a)
+		}
+		sb.WriteString("(" + strings.Join(args, ", ") + ")")
+	}
+	sb.WriteString(": " + formatGraphQLTypeRef(f.Type))
+	if f.IsDeprecated {
+		sb.WriteString(" @deprecated")
+	}
+	if f.Description != "" {
+		sb.WriteString("  # " + firstLine(f.Description))
+	}
+	return sb.String()
+}
+
+// formatGraphQLInputValue renders "name: Type = default".
+func formatGraphQLInputValue(v gqlInputValue) string {
+	s := v.Name + ": " + formatGraphQLTypeRef(v.Type)
+	if v.DefaultValue != nil {
+		s += " = " + *v.DefaultValue
+	}
+	return s
+}
+
+// formatGraphQLTypeRef renders a type reference with list and non-null wrappers, e.g. [User!]!.
+func formatGraphQLTypeRef(ref gqlTypeRef) string {
+	switch ref.Kind {
+	case "NON_NULL":
+		if ref.OfType != nil {
+			return formatGraphQLTypeRef(*ref.OfType) + "!"
+		}
+	case "LIST":
+		if ref.OfType != nil {
+			return "[" + formatGraphQLTypeRef(*ref.OfType) + "]"
+		}
+	}
+	return ref.Name
+}
+
+// formatGraphQLType renders an object, interface, input, enum or union definition.
+func formatGraphQLType(t gqlType) string {
+	var sb strings.Builder
+	switch t.Kind {
+	case "ENUM":
+		values := make([]string, len(t.EnumValues))
+		for i, v := range t.EnumValues {
+			values[i] = v.Name
+		}
+		sb.WriteString(fmt.Sprintf("enum %s { %s }\n", t.Name, strings.Join(values, " ")))
+
+	case "UNION":
+		members := make([]string, len(t.PossibleTypes))
+		for i, p := range t.PossibleTypes {
+			members[i] = p.Name
+		}
+		sb.WriteString(fmt.Sprintf("union %s = %s\n", t.Name, strings.Join(members, " | ")))
+
+	case "INPUT_OBJECT":
+		sb.WriteString(fmt.Sprintf("input %s {\n", t.Name))
+		for _, f := range t.InputFields {
+			sb.WriteString("  " + formatGraphQLInputValue(f) + "\n")
+		}
+		sb.WriteString("}\n")
+
+	default:
+		keyword := "type"
+		if t.Kind == "INTERFACE" {
+			keyword = "interface"
+		}
+		sb.WriteString(fmt.Sprintf("%s %s {\n", keyword, t.Name))
+		for _, f := range t.Fields {
+			sb.WriteString("  " + formatGraphQLField(f) + "\n")
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+}
+
+// truncateBody shortens a response body for error output.
+func truncateBody(body string, max int) string {
+	if len(body) > max {
+		return body[:max] + "\n... (truncated)"
+	}
+	return body
+}
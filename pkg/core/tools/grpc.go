@@ -0,0 +1,664 @@
+package tools
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	rpbv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	rpbv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPCTool calls unary gRPC methods with JSON messages. Method and message
+// types are resolved through server reflection or a local .proto/.protoset.
+type GRPCTool struct {
+	responseManager *ResponseManager
+	varStore        *VariableStore
+}
+
+// NewGRPCTool creates a new gRPC request tool
+func NewGRPCTool(responseManager *ResponseManager, varStore *VariableStore) *GRPCTool {
+	return &GRPCTool{
+		responseManager: responseManager,
+		varStore:        varStore,
+	}
+}
+
+// GRPCParams defines the gRPC request parameters
+type GRPCParams struct {
+	Target      string            `json:"target"`                 // host:port
+	Method      string            `json:"method,omitempty"`       // package.Service/Method
+	Message     json.RawMessage   `json:"message,omitempty"`      // Request message as JSON
+	Metadata    map[string]string `json:"metadata,omitempty"`     // Request metadata (headers)
+	ProtoFile   string            `json:"proto_file,omitempty"`   // .proto (needs protoc) or .protoset instead of reflection
+	ImportPaths []string          `json:"import_paths,omitempty"` // -I paths for protoc
+	TLS         bool              `json:"tls,omitempty"`          // Use TLS instead of plaintext
+	Describe    bool              `json:"describe,omitempty"`     // Show the method's message fields instead of calling it
+	Timeout     int               `json:"timeout,omitempty"`      // Seconds
+}
+
+// Name returns the tool name
+func (t *GRPCTool) Name() string {
+	return "grpc_request"
+}
+
+// Description returns the tool description
+func (t *GRPCTool) Description() string {
+	return "Call a unary gRPC method with a JSON message. Types come from server reflection, or from proto_file (.proto with protoc installed, or a .protoset). Omit 'method' to list services and methods; set describe=true to see the request/response fields. The response is stored for extract_value and assert_response (status_code is the HTTP equivalent of the gRPC code)."
+}
+
+// Parameters returns the tool parameter description
+func (t *GRPCTool) Parameters() string {
+	return `{
+  "target": "localhost:50051",
+  "method": "helloworld.Greeter/SayHello",
+  "message": {"name": "zap"},
+  "metadata": {"authorization": "Bearer {{TOKEN}}"},
+  "proto_file": "optional path to .proto or .protoset (default: server reflection)",
+  "import_paths": ["proto"],
+  "tls": false,
+  "describe": false,
+  "timeout": 30
+}`
+}
+
+// Execute performs the gRPC call, or lists/describes methods
+func (t *GRPCTool) Execute(args string) (string, error) {
+	if t.varStore != nil {
+		args = t.varStore.Substitute(args)
+	}
+
+	var params GRPCParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	if params.Target == "" {
+		return "", fmt.Errorf("'target' is required (host:port)")
+	}
+
+	timeout := 30 * time.Second
+	if params.Timeout > 0 {
+		timeout = time.Duration(params.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	creds := insecure.NewCredentials()
+	if params.TLS {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	conn, err := grpc.NewClient(params.Target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return "", fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+	defer conn.Close()
+
+	var source descriptorSource
+	if params.ProtoFile != "" {
+		files, err := loadProtoFiles(params.ProtoFile, params.ImportPaths)
+		if err != nil {
+			return "", err
+		}
+		source = &fileSource{files: files}
+	} else {
+		rc, err := newReflectionClient(ctx, conn)
+		if err != nil {
+			return "", fmt.Errorf("server reflection failed (enable it on the server or pass proto_file): %w", err)
+		}
+		defer rc.close()
+		source = rc
+	}
+
+	if params.Method == "" {
+		return listGRPCServices(source)
+	}
+
+	method, err := findGRPCMethod(source, params.Method)
+	if err != nil {
+		return "", err
+	}
+	if params.Describe {
+		return describeGRPCMethod(method), nil
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return "", fmt.Errorf("%s is a streaming method; only unary methods are supported", method.FullName())
+	}
+
+	return t.invoke(ctx, conn, source, method, params)
+}
+
+// invoke calls a unary method and formats the response
+func (t *GRPCTool) invoke(ctx context.Context, conn *grpc.ClientConn, source descriptorSource, method protoreflect.MethodDescriptor, params GRPCParams) (string, error) {
+	types := dynamicpb.NewTypes(source.registry())
+
+	req := dynamicpb.NewMessage(method.Input())
+	if len(params.Message) > 0 && string(params.Message) != "null" {
+		if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal(params.Message, req); err != nil {
+			return "", fmt.Errorf("message does not match %s: %w", method.Input().FullName(), err)
+		}
+	}
+	resp := dynamicpb.NewMessage(method.Output())
+
+	if len(params.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(params.Metadata))
+	}
+
+	fullMethod := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+	var header, trailer metadata.MD
+	start := time.Now()
+	callErr := conn.Invoke(ctx, fullMethod, req, resp, grpc.Header(&header), grpc.Trailer(&trailer))
+	duration := time.Since(start)
+
+	st := status.Convert(callErr)
+	headers := make(map[string]string)
+	for _, md := range []metadata.MD{header, trailer} {
+		for key, values := range md {
+			headers[key] = strings.Join(values, ", ")
+		}
+	}
+	headers["grpc-status"] = fmt.Sprintf("%d", st.Code())
+	if st.Message() != "" {
+		headers["grpc-message"] = st.Message()
+	}
+
+	var body string
+	if callErr == nil {
+		data, err := (protojson.MarshalOptions{Resolver: types, EmitUnpopulated: true}).Marshal(resp)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode response: %w", err)
+		}
+		body = string(data)
+	} else {
+		data, _ := json.Marshal(map[string]interface{}{
+			"code":    st.Code().String(),
+			"message": st.Message(),
+		})
+		body = string(data)
+	}
+
+	httpResp := &HTTPResponse{
+		StatusCode: grpcHTTPStatus(st.Code()),
+		Status:     fmt.Sprintf("%s (gRPC code %d)", st.Code(), st.Code()),
+		Headers:    headers,
+		Body:       body,
+		Duration:   duration,
+	}
+	if t.responseManager != nil {
+		t.responseManager.SetHTTPResponse(httpResp)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("gRPC %s\n", fullMethod))
+	sb.WriteString(fmt.Sprintf("Status: %s\n", httpResp.Status))
+	sb.WriteString(fmt.Sprintf("Time:   %dms\n", duration.Milliseconds()))
+	if callErr != nil {
+		sb.WriteString(fmt.Sprintf("Error:  %s\n", st.Message()))
+	}
+
+	if len(headers) > 0 {
+		keys := make([]string, 0, len(headers))
+		for key := range headers {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		sb.WriteString("\nMetadata:\n")
+		for _, key := range keys {
+			sb.WriteString(fmt.Sprintf("  %s: %s\n", key, headers[key]))
+		}
+	}
+
+	sb.WriteString("\nBody:\n```json\n")
+	var pretty strings.Builder
+	var v interface{}
+	if json.Unmarshal([]byte(body), &v) == nil {
+		data, _ := json.MarshalIndent(v, "", "  ")
+		pretty.Write(data)
+	} else {
+		pretty.WriteString(body)
+	}
+	sb.WriteString(pretty.String())
+	sb.WriteString("\n```")
+	return sb.String(), nil
+}
+
+// grpcHTTPStatus maps a gRPC code to its HTTP equivalent (as grpc-gateway does)
+// so assert_response status checks work for gRPC calls.
+func grpcHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return 200
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return 400
+	case codes.DeadlineExceeded:
+		return 504
+	case codes.NotFound:
+		return 404
+	case codes.AlreadyExists, codes.Aborted:
+		return 409
+	case codes.PermissionDenied:
+		return 403
+	case codes.Unauthenticated:
+		return 401
+	case codes.ResourceExhausted:
+		return 429
+	case codes.Unimplemented:
+		return 501
+	case codes.Unavailable:
+		return 503
+	default:
+		return 500
+	}
+}
+
+// listGRPCServices lists every service and its methods
+func listGRPCServices(source descriptorSource) (string, error) {
+	names, err := source.listServices()
+	if err != nil {
+		return "", fmt.Errorf("failed to list services: %w", err)
+	}
+	var services []string
+	for _, name := range names {
+		if !strings.HasPrefix(name, "grpc.reflection.") {
+			services = append(services, name)
+		}
+	}
+	sort.Strings(services)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Services (%d):\n", len(services)))
+	for _, name := range services {
+		desc, err := source.findSymbol(name)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("\n%s (could not resolve: %v)\n", name, err))
+			continue
+		}
+		svc, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\nservice %s\n", svc.FullName()))
+		methods := svc.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			sb.WriteString("  " + formatGRPCMethod(methods.Get(i)) + "\n")
+		}
+	}
+	sb.WriteString("\nUse method=\"Service/Method\" with describe=true to see message fields.")
+	return sb.String(), nil
+}
+
+// findGRPCMethod resolves "pkg.Service/Method" or "pkg.Service.Method"
+func findGRPCMethod(source descriptorSource, name string) (protoreflect.MethodDescriptor, error) {
+	name = strings.TrimPrefix(name, "/")
+	sep := strings.LastIndex(name, "/")
+	if sep < 0 {
+		sep = strings.LastIndex(name, ".")
+	}
+	if sep <= 0 || sep == len(name)-1 {
+		return nil, fmt.Errorf("method must look like 'package.Service/Method', got %q", name)
+	}
+	serviceName, methodName := name[:sep], name[sep+1:]
+
+	desc, err := source.findSymbol(serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", serviceName, err)
+	}
+	svc, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", serviceName)
+	}
+	method := svc.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		var available []string
+		for i := 0; i < svc.Methods().Len(); i++ {
+			available = append(available, string(svc.Methods().Get(i).Name()))
+		}
+		return nil, fmt.Errorf("method %s not found on %s (available: %s)", methodName, serviceName, strings.Join(available, ", "))
+	}
+	return method, nil
+}
+
+// formatGRPCMethod renders "rpc Name(Input) returns (Output)"
+func formatGRPCMethod(m protoreflect.MethodDescriptor) string {
+	in, out := string(m.Input().FullName()), string(m.Output().FullName())
+	if m.IsStreamingClient() {
+		in = "stream " + in
+	}
+	if m.IsStreamingServer() {
+		out = "stream " + out
+	}
+	return fmt.Sprintf("rpc %s(%s) returns (%s)", m.Name(), in, out)
+}
+
+// describeGRPCMethod shows a method's signature and message fields
+func describeGRPCMethod(m protoreflect.MethodDescriptor) string {
+	var sb strings.Builder
+	sb.WriteString(formatGRPCMethod(m) + "\n\n")
+	sb.WriteString(fmt.Sprintf("Request %s:\n", m.Input().FullName()))
+	writeMessageFields(&sb, m.Input(), "  ", map[protoreflect.FullName]bool{})
+	sb.WriteString(fmt.Sprintf("\nResponse %s:\n", m.Output().FullName()))
+	writeMessageFields(&sb, m.Output(), "  ", map[protoreflect.FullName]bool{})
+	sb.WriteString("\nUse the JSON field names shown (protojson format) in 'message'.")
+	return sb.String()
+}
+
+// writeMessageFields lists a message's fields, expanding nested messages once
+func writeMessageFields(sb *strings.Builder, md protoreflect.MessageDescriptor, indent string, seen map[protoreflect.FullName]bool) {
+	seen[md.FullName()] = true
+	defer delete(seen, md.FullName())
+
+	fields := md.Fields()
+	if fields.Len() == 0 {
+		sb.WriteString(indent + "(no fields)\n")
+		return
+	}
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		typeName := f.Kind().String()
+		switch f.Kind() {
+		case protoreflect.MessageKind, protoreflect.GroupKind:
+			typeName = string(f.Message().FullName())
+		case protoreflect.EnumKind:
+			var values []string
+			for j := 0; j < f.Enum().Values().Len(); j++ {
+				values = append(values, string(f.Enum().Values().Get(j).Name()))
+			}
+			typeName = fmt.Sprintf("enum %s {%s}", f.Enum().Name(), strings.Join(values, ", "))
+		}
+		switch {
+		case f.IsMap():
+			typeName = fmt.Sprintf("map<%s, %s>", f.MapKey().Kind(), mapValueTypeName(f.MapValue()))
+		case f.IsList():
+			typeName = "repeated " + typeName
+		}
+		sb.WriteString(fmt.Sprintf("%s%s: %s\n", indent, f.JSONName(), typeName))
+
+		// Expand nested messages (except well-known types and recursion)
+		if f.Kind() == protoreflect.MessageKind && !f.IsMap() {
+			nested := f.Message()
+			if !seen[nested.FullName()] && !strings.HasPrefix(string(nested.FullName()), "google.protobuf.") {
+				writeMessageFields(sb, nested, indent+"  ", seen)
+			}
+		}
+	}
+}
+
+// mapValueTypeName returns the type name of a map value field
+func mapValueTypeName(f protoreflect.FieldDescriptor) string {
+	if f.Kind() == protoreflect.MessageKind {
+		return string(f.Message().FullName())
+	}
+	return f.Kind().String()
+}
+
+// descriptorSource resolves services and types for gRPC calls
+type descriptorSource interface {
+	listServices() ([]string, error)
+	findSymbol(name string) (protoreflect.Descriptor, error)
+	registry() *protoregistry.Files
+}
+
+// fileSource resolves descriptors from local proto files
+type fileSource struct {
+	files *protoregistry.Files
+}
+
+func (s *fileSource) listServices() ([]string, error) {
+	var names []string
+	s.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			names = append(names, string(fd.Services().Get(i).FullName()))
+		}
+		return true
+	})
+	return names, nil
+}
+
+func (s *fileSource) findSymbol(name string) (protoreflect.Descriptor, error) {
+	return s.files.FindDescriptorByName(protoreflect.FullName(name))
+}
+
+func (s *fileSource) registry() *protoregistry.Files {
+	return s.files
+}
+
+// loadProtoFiles loads a .protoset, or compiles a .proto with protoc
+func loadProtoFiles(path string, importPaths []string) (*protoregistry.Files, error) {
+	descriptorPath := path
+	if strings.HasSuffix(path, ".proto") {
+		protoc, err := exec.LookPath("protoc")
+		if err != nil {
+			return nil, fmt.Errorf("protoc is required to load .proto files (install it, pass a .protoset, or use server reflection)")
+		}
+
+		tmp, err := os.CreateTemp("", "zap-*.protoset")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp file: %w", err)
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+
+		args := []string{"--include_imports", "--descriptor_set_out=" + tmp.Name()}
+		if len(importPaths) == 0 {
+			importPaths = []string{filepath.Dir(path)}
+		}
+		for _, dir := range importPaths {
+			args = append(args, "-I", dir)
+		}
+		args = append(args, path)
+		if out, err := exec.Command(protoc, args...).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("protoc failed: %v\n%s", err, strings.TrimSpace(string(out)))
+		}
+		descriptorPath = tmp.Name()
+	}
+
+	data, err := os.ReadFile(descriptorPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", descriptorPath, err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set %s: %w", path, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// reflectionClient resolves descriptors via the gRPC server reflection
+// service, preferring v1 and falling back to v1alpha for older servers.
+type reflectionClient struct {
+	send    func(req *rpbv1.ServerReflectionRequest) (*rpbv1.ServerReflectionResponse, error)
+	cancel  context.CancelFunc
+	files   map[string]*descriptorpb.FileDescriptorProto
+	reg     *protoregistry.Files
+	service []string
+}
+
+// newReflectionClient opens a reflection stream and lists services to
+// detect which reflection version the server supports.
+func newReflectionClient(ctx context.Context, conn *grpc.ClientConn) (*reflectionClient, error) {
+	rc := &reflectionClient{files: make(map[string]*descriptorpb.FileDescriptorProto)}
+	listReq := &rpbv1.ServerReflectionRequest{
+		MessageRequest: &rpbv1.ServerReflectionRequest_ListServices{ListServices: "*"},
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	v1, err := rpbv1.NewServerReflectionClient(conn).ServerReflectionInfo(streamCtx)
+	if err == nil {
+		rc.send = func(req *rpbv1.ServerReflectionRequest) (*rpbv1.ServerReflectionResponse, error) {
+			if err := v1.Send(req); err != nil {
+				return nil, err
+			}
+			return v1.Recv()
+		}
+		var resp *rpbv1.ServerReflectionResponse
+		if resp, err = rc.send(listReq); err == nil {
+			rc.cancel = cancel
+			return rc, rc.storeServices(resp)
+		}
+	}
+	cancel()
+	if status.Code(err) != codes.Unimplemented {
+		return nil, err
+	}
+
+	// Older servers only implement v1alpha, which has the same messages
+	streamCtx, cancel = context.WithCancel(ctx)
+	alpha, err := rpbv1alpha.NewServerReflectionClient(conn).ServerReflectionInfo(streamCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	rc.send = func(req *rpbv1.ServerReflectionRequest) (*rpbv1.ServerReflectionResponse, error) {
+		var alphaReq rpbv1alpha.ServerReflectionRequest
+		if err := convertReflectionMessage(req, &alphaReq); err != nil {
+			return nil, err
+		}
+		if err := alpha.Send(&alphaReq); err != nil {
+			return nil, err
+		}
+		alphaResp, err := alpha.Recv()
+		if err != nil {
+			return nil, err
+		}
+		var resp rpbv1.ServerReflectionResponse
+		return &resp, convertReflectionMessage(alphaResp, &resp)
+	}
+	rc.cancel = cancel
+	resp, err := rc.send(listReq)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return rc, rc.storeServices(resp)
+}
+
+// convertReflectionMessage copies between the wire-compatible v1 and v1alpha messages
+func convertReflectionMessage(from, to proto.Message) error {
+	data, err := proto.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, to)
+}
+
+// storeServices records the service names from a list_services response
+func (rc *reflectionClient) storeServices(resp *rpbv1.ServerReflectionResponse) error {
+	if e := resp.GetErrorResponse(); e != nil {
+		return status.Error(codes.Code(e.ErrorCode), e.ErrorMessage)
+	}
+	for _, svc := range resp.GetListServicesResponse().GetService() {
+		rc.service = append(rc.service, svc.Name)
+	}
+	return nil
+}
+
+func (rc *reflectionClient) close() {
+	rc.cancel()
+}
+
+func (rc *reflectionClient) listServices() ([]string, error) {
+	return rc.service, nil
+}
+
+func (rc *reflectionClient) registry() *protoregistry.Files {
+	if rc.reg == nil {
+		rc.reg = new(protoregistry.Files)
+	}
+	return rc.reg
+}
+
+// findSymbol fetches the file defining name (and its dependencies) and
+// rebuilds the registry from everything fetched so far.
+func (rc *reflectionClient) findSymbol(name string) (protoreflect.Descriptor, error) {
+	if rc.reg != nil {
+		if desc, err := rc.reg.FindDescriptorByName(protoreflect.FullName(name)); err == nil {
+			return desc, nil
+		}
+	}
+
+	if err := rc.fetch(&rpbv1.ServerReflectionRequest{
+		MessageRequest: &rpbv1.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: name},
+	}); err != nil {
+		return nil, err
+	}
+
+	// Servers may omit dependencies they already sent; fetch any still missing
+	for {
+		var missing []string
+		for _, fd := range rc.files {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := rc.files[dep]; !ok {
+					missing = append(missing, dep)
+				}
+			}
+		}
+		if len(missing) == 0 {
+			break
+		}
+		for _, dep := range missing {
+			if err := rc.fetch(&rpbv1.ServerReflectionRequest{
+				MessageRequest: &rpbv1.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+			}); err != nil {
+				return nil, fmt.Errorf("failed to fetch %s: %w", dep, err)
+			}
+			if _, ok := rc.files[dep]; !ok {
+				return nil, fmt.Errorf("server did not return %s", dep)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range rc.files {
+		set.File = append(set.File, fd)
+	}
+	reg, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptors from server: %w", err)
+	}
+	rc.reg = reg
+	return reg.FindDescriptorByName(protoreflect.FullName(name))
+}
+
+// fetch sends a reflection request and stores the returned file descriptors
+func (rc *reflectionClient) fetch(req *rpbv1.ServerReflectionRequest) error {
+	resp, err := rc.send(req)
+	if err != nil {
+		return err
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return status.Error(codes.Code(e.ErrorCode), e.ErrorMessage)
+	}
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		var fd descriptorpb.FileDescriptorProto
+		if err := proto.Unmarshal(raw, &fd); err != nil {
+			return fmt.Errorf("failed to parse file descriptor: %w", err)
+		}
+		rc.files[fd.GetName()] = &fd
+	}
+	return nil
+}
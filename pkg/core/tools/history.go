@@ -0,0 +1,546 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/core"
+)
+
+// HistoryDir is the folder (inside .zap) holding the response history
+const HistoryDir = "history"
+
+// maxHistoryBody caps the response body kept per record
+const maxHistoryBody = 256 * 1024
+
+// HistoryRecord is one http_request call in the response history. Secrets
+// are masked with core.RedactSecrets before it is written.
+type HistoryRecord struct {
+	ID          string            `json:"id"`                    // e.g. 20261015-153012.123, with -2, -3... for calls in the same millisecond
+	Timestamp   string            `json:"timestamp"`             // RFC3339
+	Environment string            `json:"environment,omitempty"` // Active environment
+	Request     HTTPRequest       `json:"request"`               // As called, with {{VAR}} placeholders
+	URL         string            `json:"url"`                   // Resolved URL
+	StatusCode  int               `json:"status_code"`
+	Status      string            `json:"status"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	BodySize    int               `json:"body_size"`
+	Truncated   bool              `json:"truncated,omitempty"` // Body cut at maxHistoryBody
+	DurationMs  int64             `json:"duration_ms"`
+	Timing      *RequestTiming    `json:"timing,omitempty"`
+}
+
+// ResponseHistory appends every http_request call to
+// .zap/history/<date>.jsonl, so past calls can be inspected, re-run and
+// compared after the response manager has moved on.
+type ResponseHistory struct {
+	dir string
+	mu  sync.Mutex
+
+	lastStamp string // Millisecond of the last record
+	sameStamp int    // Records written in that millisecond
+}
+
+// NewResponseHistory creates a history writing under zapDir/history
+func NewResponseHistory(zapDir string) *ResponseHistory {
+	return &ResponseHistory{dir: filepath.Join(zapDir, HistoryDir)}
+}
+
+// Record appends a call: req as called (before variable substitution),
+// the URL it resolved to, and its response
+func (h *ResponseHistory) Record(req HTTPRequest, resolvedURL, env string, resp *HTTPResponse) error {
+	now := time.Now()
+	record := HistoryRecord{
+		Timestamp:   now.Format(time.RFC3339),
+		Environment: env,
+		Request:     redactHistoryRequest(req),
+		URL:         core.RedactSecrets(resolvedURL),
+		StatusCode:  resp.StatusCode,
+		Status:      resp.Status,
+		Headers:     make(map[string]string, len(resp.Headers)),
+		Body:        core.RedactSecrets(resp.Body),
+		BodySize:    len(resp.Body),
+		DurationMs:  resp.Duration.Milliseconds(),
+		Timing:      resp.Timing,
+	}
+	if resp.BodySize > 0 {
+		record.BodySize = int(resp.BodySize)
+	}
+	for name, value := range resp.Headers {
+		if core.IsSensitiveKey(name) || strings.EqualFold(name, "Set-Cookie") {
+			value = core.MaskSecret(value)
+		}
+		record.Headers[name] = value
+	}
+	if resp.Binary {
+		record.Body = ""
+	} else if len(record.Body) > maxHistoryBody {
+		record.Body = record.Body[:maxHistoryBody]
+		record.Truncated = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	record.ID = h.nextID(now)
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history record: %w", err)
+	}
+
+	if err := os.MkdirAll(h.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history folder: %w", err)
+	}
+	path := filepath.Join(h.dir, now.Format("2006-01-02")+".jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history record: %w", err)
+	}
+	return nil
+}
+
+// nextID returns the ID of a record written at now. Calls made in the
+// same millisecond (parallel suite workers) get -2, -3... appended, so
+// IDs stay unique. h.mu must be held.
+func (h *ResponseHistory) nextID(now time.Time) string {
+	stamp := now.Format("20060102-150405.000")
+	if stamp != h.lastStamp {
+		h.lastStamp, h.sameStamp = stamp, 1
+		return stamp
+	}
+	h.sameStamp++
+	return fmt.Sprintf("%s-%d", stamp, h.sameStamp)
+}
+
+// redactHistoryRequest masks literal secrets in a request; {{VAR}}
+// placeholders are kept so the call can be re-run
+func redactHistoryRequest(req HTTPRequest) HTTPRequest {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return req
+	}
+	var redacted HTTPRequest
+	if err := json.Unmarshal([]byte(core.RedactSecrets(string(data))), &redacted); err != nil {
+		return req
+	}
+	return redacted
+}
+
+// List returns up to limit records, newest first, whose method and URL
+// contain filter (case-insensitive). limit <= 0 returns all.
+func (h *ResponseHistory) List(limit int, filter string) ([]HistoryRecord, error) {
+	files, err := filepath.Glob(filepath.Join(h.dir, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history files: %w", err)
+	}
+	// Files are named by date, so lexical order is chronological
+	sort.Strings(files)
+	filter = strings.ToLower(filter)
+
+	var records []HistoryRecord
+	for i := len(files) - 1; i >= 0; i-- {
+		fileRecords, err := readHistoryFile(files[i])
+		if err != nil {
+			return nil, err
+		}
+		for j := len(fileRecords) - 1; j >= 0; j-- {
+			r := fileRecords[j]
+			if filter != "" && !strings.Contains(strings.ToLower(r.Request.Method+" "+r.Request.URL+" "+r.URL), filter) {
+				continue
+			}
+			records = append(records, r)
+			if limit > 0 && len(records) >= limit {
+				return records, nil
+			}
+		}
+	}
+	return records, nil
+}
+
+// Find returns a record by ID (or a unique ID prefix), or by position: "1"
+// or "last" is the most recent call, "2" the one before.
+func (h *ResponseHistory) Find(ref string) (*HistoryRecord, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || ref == "last" {
+		ref = "1"
+	}
+
+	if n, err := strconv.Atoi(ref); err == nil && n > 0 && n < 100000 {
+		records, err := h.List(n, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("no calls in the history yet")
+		}
+		if len(records) < n {
+			return nil, fmt.Errorf("history has only %d call(s)", len(records))
+		}
+		return &records[n-1], nil
+	}
+
+	records, err := h.List(0, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		if records[i].ID == ref {
+			return &records[i], nil
+		}
+	}
+	var found *HistoryRecord
+	for i := range records {
+		if strings.HasPrefix(records[i].ID, ref) {
+			if found != nil {
+				return nil, fmt.Errorf("'%s' matches more than one call; use the full id", ref)
+			}
+			found = &records[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no call '%s' in the history", ref)
+	}
+	return found, nil
+}
+
+// Previous returns the call to the same method and URL made before r, or nil
+func (h *ResponseHistory) Previous(r *HistoryRecord) (*HistoryRecord, error) {
+	records, err := h.List(0, "")
+	if err != nil {
+		return nil, err
+	}
+	// Records are newest first: look after r. IDs of calls made in the
+	// same millisecond don't sort in call order.
+	start := slices.IndexFunc(records, func(record HistoryRecord) bool { return record.ID == r.ID })
+	if start < 0 {
+		return nil, nil
+	}
+	for i := start + 1; i < len(records); i++ {
+		if records[i].Request.Method == r.Request.Method && records[i].Request.URL == r.Request.URL {
+			return &records[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// readHistoryFile parses one JSONL history file, skipping malformed lines
+func readHistoryFile(path string) ([]HistoryRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var records []HistoryRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*maxHistoryBody)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record HistoryRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	return records, nil
+}
+
+// HistoryTool lists, inspects, re-runs and compares past http_request calls
+type HistoryTool struct {
+	history  *ResponseHistory
+	httpTool *HTTPTool
+}
+
+// NewHistoryTool creates a new history tool
+func NewHistoryTool(history *ResponseHistory, httpTool *HTTPTool) *HistoryTool {
+	return &HistoryTool{history: history, httpTool: httpTool}
+}
+
+// HistoryParams defines history tool parameters
+type HistoryParams struct {
+	Action       string   `json:"action"`                  // list, show, rerun or diff
+	ID           string   `json:"id,omitempty"`            // Call id, or 1 = most recent, 2 = the one before
+	Other        string   `json:"other,omitempty"`         // diff: call to compare with (default: previous call to the same URL)
+	Filter       string   `json:"filter,omitempty"`        // list: only calls whose method/URL contain this
+	Limit        int      `json:"limit,omitempty"`         // list: number of calls (default 20)
+	IgnoreFields []string `json:"ignore_fields,omitempty"` // diff/rerun: body fields to skip, e.g. timestamps
+}
+
+// Name returns the tool name
+func (t *HistoryTool) Name() string {
+	return "history"
+}
+
+// Description returns the tool description
+func (t *HistoryTool) Description() string {
+	return "Browse the archive of past http_request calls in .zap/history: list them, show one call's request and response, re-run it, or diff two calls (status, headers, body)."
+}
+
+// Parameters returns the tool parameter description
+func (t *HistoryTool) Parameters() string {
+	return `{
+  "action": "list|show|rerun|diff (required)",
+  "id": "call id from list, or 1 = most recent, 2 = the one before (default 1)",
+  "other": "diff: call to compare with (default: the previous call to the same method and URL)",
+  "filter": "list: only calls whose method or URL contain this",
+  "limit": 20,
+  "ignore_fields": ["timestamp", "request_id"]
+}`
+}
+
+// Execute runs a history action
+func (t *HistoryTool) Execute(args string) (string, error) {
+	var params HistoryParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	switch params.Action {
+	case "list", "":
+		limit := params.Limit
+		if limit == 0 {
+			limit = 20
+		}
+		records, err := t.history.List(limit, params.Filter)
+		if err != nil {
+			return "", err
+		}
+		return formatHistoryList(records), nil
+
+	case "show":
+		record, err := t.history.Find(params.ID)
+		if err != nil {
+			return "", err
+		}
+		return formatHistoryRecord(record), nil
+
+	case "rerun":
+		return t.rerun(params)
+
+	case "diff":
+		record, err := t.history.Find(params.ID)
+		if err != nil {
+			return "", err
+		}
+		var other *HistoryRecord
+		if params.Other != "" {
+			other, err = t.history.Find(params.Other)
+		} else {
+			other, err = t.history.Previous(record)
+			if err == nil && other == nil {
+				err = fmt.Errorf("no earlier call to %s %s; pass 'other' to pick one", record.Request.Method, record.Request.URL)
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+		return diffHistoryRecords(other, record, params.IgnoreFields), nil
+
+	default:
+		return "", fmt.Errorf("unknown action '%s': use list, show, rerun or diff", params.Action)
+	}
+}
+
+// rerun sends a recorded request again with the current variables and
+// compares the new response with the recorded one
+func (t *HistoryTool) rerun(params HistoryParams) (string, error) {
+	if t.httpTool == nil {
+		return "", fmt.Errorf("rerun is not available here")
+	}
+	record, err := t.history.Find(params.ID)
+	if err != nil {
+		return "", err
+	}
+
+	args, err := json.Marshal(record.Request)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	output, err := t.httpTool.Execute(string(args))
+	if err != nil {
+		return "", err
+	}
+
+	latest, err := t.history.Find("1")
+	if err != nil || latest.ID == record.ID {
+		return output, nil
+	}
+	return output + "\n\n" + diffHistoryRecords(record, latest, params.IgnoreFields), nil
+}
+
+// formatHistoryList lists calls, newest first
+func formatHistoryList(records []HistoryRecord) string {
+	if len(records) == 0 {
+		return "No calls in the history yet. Every http_request is recorded in .zap/history/."
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d call(s), newest first:\n", len(records)))
+	for i, r := range records {
+		sb.WriteString(fmt.Sprintf("%3d. %s  %s %s -> %d  (%dms)\n", i+1, r.ID, r.Request.Method, r.Request.URL, r.StatusCode, r.DurationMs))
+	}
+	return sb.String()
+}
+
+// formatHistoryRecord shows one call's request and response
+func formatHistoryRecord(r *HistoryRecord) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Call %s (%s", r.ID, r.Timestamp))
+	if r.Environment != "" {
+		sb.WriteString(", env " + r.Environment)
+	}
+	sb.WriteString(")\n\nRequest:\n")
+	sb.WriteString(fmt.Sprintf("  %s %s\n", r.Request.Method, r.Request.URL))
+	if r.URL != r.Request.URL {
+		sb.WriteString(fmt.Sprintf("  resolved: %s\n", r.URL))
+	}
+	for _, name := range sortedKeys(r.Request.Headers) {
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", name, r.Request.Headers[name]))
+	}
+	if r.Request.Body != nil {
+		body, _ := json.MarshalIndent(r.Request.Body, "  ", "  ")
+		sb.WriteString("  \n  " + string(body) + "\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("\nResponse: %s (%dms, %s)\n", r.Status, r.DurationMs, formatSize(r.BodySize)))
+	for _, name := range sortedKeys(r.Headers) {
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", name, r.Headers[name]))
+	}
+	if r.Body != "" {
+		sb.WriteString("\n" + prettyHistoryBody(r.Body) + "\n")
+		if r.Truncated {
+			sb.WriteString("... (body truncated in the history)\n")
+		}
+	}
+	return sb.String()
+}
+
+// diffHistoryRecords compares a later call with an earlier one: status,
+// duration, headers and body (field by field when both are JSON)
+func diffHistoryRecords(before, after *HistoryRecord, ignoreFields []string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Compared %s (before) with %s (after):\n", before.ID, after.ID))
+
+	var changes []string
+	if before.StatusCode != after.StatusCode {
+		changes = append(changes, fmt.Sprintf("status: %d -> %d", before.StatusCode, after.StatusCode))
+	}
+	if before.Request.Method != after.Request.Method || before.Request.URL != after.Request.URL {
+		changes = append(changes, fmt.Sprintf("request: %s %s -> %s %s", before.Request.Method, before.Request.URL, after.Request.Method, after.Request.URL))
+	}
+	for _, name := range unionKeys(before.Headers, after.Headers) {
+		if volatileHeaders[strings.ToLower(name)] {
+			continue
+		}
+		b, inBefore := headerLookup(before.Headers, name)
+		a, inAfter := headerLookup(after.Headers, name)
+		switch {
+		case !inBefore:
+			changes = append(changes, fmt.Sprintf("header %s added: %s", name, a))
+		case !inAfter:
+			changes = append(changes, fmt.Sprintf("header %s removed", name))
+		case a != b:
+			changes = append(changes, fmt.Sprintf("header %s: %s -> %s", name, b, a))
+		}
+	}
+
+	var beforeJSON, afterJSON interface{}
+	if json.Unmarshal([]byte(before.Body), &beforeJSON) == nil && json.Unmarshal([]byte(after.Body), &afterJSON) == nil {
+		compare := &CompareResponsesTool{}
+		params := CompareParams{IgnoreFields: ignoreFields}
+		if len(ignoreFields) > 0 {
+			beforeJSON = compare.removeFields(beforeJSON, ignoreFields)
+			afterJSON = compare.removeFields(afterJSON, ignoreFields)
+		}
+		for _, diff := range compare.compareJSON(beforeJSON, afterJSON, "", params).Differences {
+			changes = append(changes, "body: "+diff)
+		}
+	} else if before.Body != after.Body {
+		changes = append(changes, fmt.Sprintf("body changed (%s -> %s)", formatSize(before.BodySize), formatSize(after.BodySize)))
+	}
+
+	if len(changes) == 0 {
+		sb.WriteString("  No differences in status, headers or body.\n")
+	}
+	for _, change := range changes {
+		sb.WriteString("  - " + change + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("  duration: %dms -> %dms\n", before.DurationMs, after.DurationMs))
+	return sb.String()
+}
+
+// volatileHeaders change on every call, so diffs skip them
+var volatileHeaders = map[string]bool{
+	"date": true, "age": true, "expires": true, "x-request-id": true, "x-correlation-id": true,
+	"x-amzn-requestid": true, "x-amz-cf-id": true, "cf-ray": true, "set-cookie": true,
+	"content-length": true, "etag": true, "last-modified": true, "server-timing": true,
+}
+
+// headerLookup finds a header case-insensitively
+func headerLookup(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// unionKeys returns the header names of a and b, sorted, without
+// case-insensitive duplicates
+func unionKeys(a, b map[string]string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]string{a, b} {
+		for key := range m {
+			if !seen[strings.ToLower(key)] {
+				seen[strings.ToLower(key)] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// prettyHistoryBody indents a JSON body, leaving other bodies as they are
+func prettyHistoryBody(body string) string {
+	var parsed interface{}
+	if json.Unmarshal([]byte(body), &parsed) != nil {
+		return body
+	}
+	pretty, err := json.MarshalIndent(parsed, "", "  ")
+	if err != nil {
+		return body
+	}
+	return string(pretty)
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResponseHistory_NextID(t *testing.T) {
+	h := NewResponseHistory(t.TempDir())
+	at := time.Date(2026, 10, 15, 15, 30, 12, 123_000_000, time.Local)
+
+	got := []string{
+		h.nextID(at),
+		h.nextID(at.Add(400 * time.Microsecond)),
+		h.nextID(at),
+		h.nextID(at.Add(time.Millisecond)),
+		h.nextID(at.Add(time.Millisecond)),
+	}
+	want := []string{
+		"20261015-153012.123",
+		"20261015-153012.123-2",
+		"20261015-153012.123-3",
+		"20261015-153012.124",
+		"20261015-153012.124-2",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("ids = %v, want %v", got, want)
+	}
+}
+
+func TestResponseHistory_ParallelRecords(t *testing.T) {
+	h := NewResponseHistory(t.TempDir())
+	const calls = 40
+
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := HTTPRequest{Method: "GET", URL: fmt.Sprintf("{{BASE_URL}}/items/%d", i%2)}
+			resp := &HTTPResponse{StatusCode: 200, Status: "200 OK", Body: fmt.Sprint(i)}
+			if err := h.Record(req, "http://localhost"+strings.TrimPrefix(req.URL, "{{BASE_URL}}"), "", resp); err != nil {
+				t.Errorf("failed to record: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	records, err := h.List(0, "")
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(records) != calls {
+		t.Fatalf("got %d records, want %d", len(records), calls)
+	}
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if seen[r.ID] {
+			t.Errorf("duplicate id %s", r.ID)
+		}
+		seen[r.ID] = true
+
+		found, err := h.Find(r.ID)
+		if err != nil {
+			t.Errorf("Find(%s): %v", r.ID, err)
+		} else if found.Body != r.Body {
+			t.Errorf("Find(%s) returned the call with body %s, want %s", r.ID, found.Body, r.Body)
+		}
+	}
+
+	// Previous goes by the order calls were written, whatever their ids
+	for i, r := range records {
+		prev, err := h.Previous(&records[i])
+		if err != nil {
+			t.Fatalf("Previous: %v", err)
+		}
+		var want *HistoryRecord
+		for j := i + 1; j < len(records); j++ {
+			if records[j].Request.URL == r.Request.URL {
+				want = &records[j]
+				break
+			}
+		}
+		switch {
+		case want == nil && prev != nil:
+			t.Errorf("Previous(%s) = %s, want none", r.ID, prev.ID)
+		case want != nil && (prev == nil || prev.ID != want.ID):
+			t.Errorf("Previous(%s) = %v, want %s", r.ID, prev, want.ID)
+		}
+	}
+}
+
+func TestResponseHistory_Find(t *testing.T) {
+	zapDir := t.TempDir()
+	h := NewResponseHistory(zapDir)
+	// Three calls in one millisecond: the first ID is a prefix of the others
+	var lines []string
+	for i, id := range []string{"20261015-153012.123", "20261015-153012.123-2", "20261015-153012.123-3"} {
+		data, _ := json.Marshal(HistoryRecord{ID: id, Request: HTTPRequest{Method: "GET", URL: "/a"}, StatusCode: 200, Body: fmt.Sprint(i)})
+		lines = append(lines, string(data))
+	}
+	path := filepath.Join(zapDir, HistoryDir, "2026-10-15.jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write history: %v", err)
+	}
+
+	tests := []struct {
+		ref    string
+		body   string
+		errMsg string
+	}{
+		{ref: "last", body: "2"},
+		{ref: "3", body: "0"},
+		{ref: "4", errMsg: "history has only 3 call(s)"},
+		{ref: "20261015-153012.123", body: "0"},
+		{ref: "20261015-153012.123-2", body: "1"},
+		{ref: "20261015-153012.123-", errMsg: "matches more than one call"},
+		{ref: "20261014", errMsg: "no call '20261014'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := h.Find(tt.ref)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Body != tt.body {
+				t.Errorf("found the call with body %s, want %s", got.Body, tt.body)
+			}
+		})
+	}
+
+	// The call before -3 is -2
+	prev, err := h.Previous(&HistoryRecord{ID: "20261015-153012.123-3", Request: HTTPRequest{Method: "GET", URL: "/a"}})
+	if err != nil || prev == nil || prev.Body != "1" {
+		t.Errorf("Previous = %+v, %v", prev, err)
+	}
+}
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for HMAC signing
+const (
+	DefaultHMACTemplate        = "{method}\n{path}\n{timestamp}\n{body}"
+	DefaultHMACHeader          = "X-Signature"
+	DefaultHMACTimestampHeader = "X-Timestamp"
+)
+
+// HMACConfig describes how to sign a request with an HMAC. The canonical
+// string is built from Template, whose placeholders are {method}, {path},
+// {query}, {host}, {url}, {body}, {body_sha256}, {timestamp} and {nonce}.
+type HMACConfig struct {
+	Algorithm       string `json:"algorithm,omitempty"`        // sha256 (default), sha1, sha512
+	Secret          string `json:"secret,omitempty"`           // Secret value
+	SecretVar       string `json:"secret_var,omitempty"`       // Or the name of the variable holding it
+	Template        string `json:"template,omitempty"`         // Canonical string template
+	Header          string `json:"header,omitempty"`           // Signature header (default X-Signature)
+	Prefix          string `json:"prefix,omitempty"`           // Prepended to the signature, e.g. "sha256="
+	Encoding        string `json:"encoding,omitempty"`         // hex (default) or base64
+	TimestampHeader string `json:"timestamp_header,omitempty"` // Default X-Timestamp; "none" to omit
+	TimestampFormat string `json:"timestamp_format,omitempty"` // unix (default), unix_ms, rfc3339
+	NonceHeader     string `json:"nonce_header,omitempty"`     // Header carrying {nonce}, if the template uses it
+	Host            string `json:"host,omitempty"`             // Only sign requests to this host (auth_hmac defaults)
+}
+
+// Validate reports invalid settings
+func (c HMACConfig) Validate() error {
+	if _, err := hmacHash(c.Algorithm); err != nil {
+		return err
+	}
+	switch c.Encoding {
+	case "", "hex", "base64":
+	default:
+		return fmt.Errorf("invalid encoding %q (use hex or base64)", c.Encoding)
+	}
+	switch c.TimestampFormat {
+	case "", "unix", "unix_ms", "rfc3339":
+	default:
+		return fmt.Errorf("invalid timestamp_format %q (use unix, unix_ms or rfc3339)", c.TimestampFormat)
+	}
+	if c.Secret == "" && c.SecretVar == "" {
+		return fmt.Errorf("'secret' or 'secret_var' is required")
+	}
+	return nil
+}
+
+// SetDefaultHMAC signs subsequent requests with cfg (limited to cfg.Host when
+// set). Pass nil to stop signing.
+func (t *HTTPTool) SetDefaultHMAC(cfg *HMACConfig) {
+	t.signingMu.Lock()
+	defer t.signingMu.Unlock()
+	t.defaultHMAC = cfg
+}
+
+// defaultHMACFor returns the default HMAC config if it applies to host
+func (t *HTTPTool) defaultHMACFor(host string) *HMACConfig {
+	t.signingMu.Lock()
+	defer t.signingMu.Unlock()
+	if t.defaultHMAC == nil {
+		return nil
+	}
+	if t.defaultHMAC.Host != "" && !strings.EqualFold(t.defaultHMAC.Host, host) {
+		return nil
+	}
+	return t.defaultHMAC
+}
+
+// signHMAC resolves the secret and signs req
+func (t *HTTPTool) signHMAC(req *http.Request, payload []byte, cfg HMACConfig, now time.Time) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	secret := cfg.Secret
+	if cfg.SecretVar != "" {
+		value, ok := "", false
+		if t.varStore != nil {
+			value, ok = t.varStore.Get(cfg.SecretVar)
+		}
+		if !ok || value == "" {
+			return fmt.Errorf("HMAC secret variable '%s' is not set", cfg.SecretVar)
+		}
+		secret = value
+	}
+	return signHMACRequest(req, payload, cfg, secret, now)
+}
+
+// signHMACRequest sets the signature (and timestamp/nonce) headers on req
+func signHMACRequest(req *http.Request, payload []byte, cfg HMACConfig, secret string, now time.Time) error {
+	newHash, err := hmacHash(cfg.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	var timestamp string
+	switch cfg.TimestampFormat {
+	case "unix_ms":
+		timestamp = strconv.FormatInt(now.UnixMilli(), 10)
+	case "rfc3339":
+		timestamp = now.UTC().Format(time.RFC3339)
+	default:
+		timestamp = strconv.FormatInt(now.Unix(), 10)
+	}
+
+	template := cfg.Template
+	if template == "" {
+		template = DefaultHMACTemplate
+	}
+	nonce := ""
+	if strings.Contains(template, "{nonce}") {
+		nonce = randomHex(16)
+	}
+	bodySum := sha256.Sum256(payload)
+
+	canonical := strings.NewReplacer(
+		"{method}", req.Method,
+		"{path}", req.URL.EscapedPath(),
+		"{query}", req.URL.RawQuery,
+		"{host}", req.URL.Host,
+		"{url}", req.URL.String(),
+		"{body}", string(payload),
+		"{body_sha256}", hex.EncodeToString(bodySum[:]),
+		"{timestamp}", timestamp,
+		"{nonce}", nonce,
+	).Replace(template)
+
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(canonical))
+	sum := mac.Sum(nil)
+	signature := hex.EncodeToString(sum)
+	if cfg.Encoding == "base64" {
+		signature = base64.StdEncoding.EncodeToString(sum)
+	}
+
+	header := cfg.Header
+	if header == "" {
+		header = DefaultHMACHeader
+	}
+	req.Header.Set(header, cfg.Prefix+signature)
+
+	timestampHeader := cfg.TimestampHeader
+	if timestampHeader == "" {
+		timestampHeader = DefaultHMACTimestampHeader
+	}
+	if timestampHeader != "none" {
+		req.Header.Set(timestampHeader, timestamp)
+	}
+	if nonce != "" && cfg.NonceHeader != "" {
+		req.Header.Set(cfg.NonceHeader, nonce)
+	}
+	return nil
+}
+
+// hmacHash maps an algorithm name to its hash constructor
+func hmacHash(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToLower(strings.ReplaceAll(algorithm, "-", "")) {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported HMAC algorithm %q (use sha256, sha1 or sha512)", algorithm)
+	}
+}
@@ -2,12 +2,21 @@ package tools
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/blackcoderx/zap/pkg/storage"
+	"github.com/quic-go/quic-go/http3"
 )
 
 // Default timeout for HTTP requests
@@ -19,6 +28,28 @@ type HTTPTool struct {
 	responseManager *ResponseManager
 	varStore        *VariableStore
 	defaultTimeout  time.Duration
+
+	tlsMu      sync.Mutex
+	defaultTLS *TLSConfig                         // TLS settings of the active environment
+	transports map[transportKey]http.RoundTripper // Cached per TLS config/protocol for connection pooling
+
+	lastMu      sync.Mutex
+	lastRequest *HTTPRequest // Last executed request, before variable substitution
+
+	cache *responseCache // Responses kept for conditional requests (cache option)
+
+	history *ResponseHistory // Archive of every call, when set
+
+	requestIDs requestIDGenerator // Automatic Idempotency-Key / X-Request-Id headers
+
+	signingMu    sync.Mutex
+	defaultSigV4 *AWSSigV4Config // Signing set by auth_aws_sigv4
+	defaultHMAC  *HMACConfig     // Signing set by auth_hmac
+
+	authMu       sync.Mutex
+	authEnv      string                         // Environment the profiles come from
+	authProfiles map[string]storage.AuthProfile // Auth profiles of the active environment, for use_auth
+	authVars     map[string]string              // Variables of the active environment
 }
 
 // NewHTTPTool creates a new HTTP tool with the default 30-second timeout.
@@ -30,6 +61,7 @@ func NewHTTPTool(responseManager *ResponseManager, varStore *VariableStore) *HTT
 		responseManager: responseManager,
 		varStore:        varStore,
 		defaultTimeout:  DefaultHTTPTimeout,
+		cache:           newResponseCache(),
 	}
 }
 
@@ -40,24 +72,132 @@ func (t *HTTPTool) SetTimeout(timeout time.Duration) {
 	t.client.Timeout = timeout
 }
 
+// SetDefaultTLS sets the TLS settings used when a request has none,
+// typically from the active environment. Pass nil to clear them.
+func (t *HTTPTool) SetDefaultTLS(cfg *TLSConfig) {
+	t.tlsMu.Lock()
+	defer t.tlsMu.Unlock()
+	t.defaultTLS = cfg
+}
+
+// SetLastRequest records req as the last executed request
+func (t *HTTPTool) SetLastRequest(req HTTPRequest) {
+	t.lastMu.Lock()
+	defer t.lastMu.Unlock()
+	t.lastRequest = &req
+}
+
+// SetHistory records every call made through Execute in history
+func (t *HTTPTool) SetHistory(history *ResponseHistory) {
+	t.history = history
+}
+
+// LastRequest returns a copy of the last executed request, or nil
+func (t *HTTPTool) LastRequest() *HTTPRequest {
+	t.lastMu.Lock()
+	defer t.lastMu.Unlock()
+	if t.lastRequest == nil {
+		return nil
+	}
+	req := *t.lastRequest
+	return &req
+}
+
+// Protocols accepted by HTTPRequest.Protocol
+const (
+	ProtocolHTTP1 = "http1.1" // Never negotiate HTTP/2
+	ProtocolHTTP2 = "h2"      // HTTP/2 only: ALPN over TLS, prior-knowledge h2c for http://
+	ProtocolHTTP3 = "h3"      // HTTP/3 over QUIC, https:// only (experimental)
+)
+
+// transportKey identifies a cached transport
+type transportKey struct {
+	tls      TLSConfig
+	protocol string
+}
+
 // HTTPRequest represents an HTTP request
 type HTTPRequest struct {
-	Method  string            `json:"method"`
-	URL     string            `json:"url"`
-	Headers map[string]string `json:"headers,omitempty"`
-	Body    interface{}       `json:"body,omitempty"`
-	Timeout int               `json:"timeout,omitempty"` // Timeout in seconds (0 = use default)
+	Method  string                 `json:"method"`
+	URL     string                 `json:"url"`
+	Query   map[string]interface{} `json:"query,omitempty"` // Merged into the URL; arrays repeat the key
+	Headers map[string]string      `json:"headers,omitempty"`
+	Body    interface{}            `json:"body,omitempty"`
+	Timeout int                    `json:"timeout,omitempty"` // Timeout in seconds (0 = use default)
+	TLS     *TLSConfig             `json:"tls,omitempty"`     // Overrides the environment's TLS settings
+
+	FollowRedirects *bool `json:"follow_redirects,omitempty"` // Default true
+	MaxRedirects    int   `json:"max_redirects,omitempty"`    // Default 10
+
+	BodyFile    string `json:"body_file,omitempty"`     // Send this file (within the project) as the body
+	SaveBodyTo  string `json:"save_body_to,omitempty"`  // Stream the body to this file (within the project)
+	MaxBodySize int64  `json:"max_body_size,omitempty"` // Bytes; caps the in-memory body and downloads
+
+	Cache string `json:"cache,omitempty"` // "revalidate" (conditional request from cache) or "refresh"
+
+	Protocol string `json:"protocol,omitempty"` // "http1.1", "h2" or "h3"; default negotiates HTTP/1.1 or HTTP/2
+
+	InjectIDs *bool `json:"inject_ids,omitempty"` // Override the request_ids config: true adds Idempotency-Key/X-Request-Id, false skips them
+
+	AWSSigV4 *AWSSigV4Config `json:"aws_sigv4,omitempty"` // Sign with AWS Signature V4 (overrides auth_aws_sigv4 defaults)
+	HMAC     *HMACConfig     `json:"hmac,omitempty"`      // Sign with an HMAC over a canonical string (overrides auth_hmac defaults)
+
+	UseAuth string `json:"use_auth,omitempty"` // Auth profile of the active environment ("none" skips a suite default)
+
+	SaveResponseAs string `json:"save_response_as,omitempty"` // Also keep the response under this name for assert/extract/compare
+
+	PreScript  string `json:"pre_script,omitempty"`  // Runs before sending; can set variables, headers and body fields
+	PostScript string `json:"post_script,omitempty"` // Runs on the response; can save variables and assert
+}
+
+// TLSConfig controls certificate verification, e.g. for self-signed dev servers
+type TLSConfig struct {
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"` // Skip certificate verification
+	CAFile             string `json:"ca_file,omitempty"`              // Extra CA bundle (PEM) to trust
 }
 
 // HTTPResponse represents an HTTP response
 type HTTPResponse struct {
 	StatusCode int               `json:"status_code"`
 	Status     string            `json:"status"`
+	Protocol   string            `json:"protocol,omitempty"` // Negotiated protocol, e.g. "HTTP/2.0"
 	Headers    map[string]string `json:"headers"`
 	Body       string            `json:"body"`
 	Duration   time.Duration     `json:"duration"`
+	Redirects  []RedirectHop     `json:"redirects,omitempty"` // Redirects followed, in order
+	Timing     *RequestTiming    `json:"timing,omitempty"`    // Per-phase latency breakdown
+	BodySize   int64             `json:"body_size,omitempty"` // Bytes received (Body may hold only the head)
+	BodyFile   string            `json:"body_file,omitempty"` // Where save_body_to wrote the body
+	Binary     bool              `json:"binary,omitempty"`    // Body is binary (image, archive, ...)
+	Truncated  bool              `json:"truncated,omitempty"` // Body exceeded the size cap
+
+	ContentEncoding string `json:"content_encoding,omitempty"` // e.g. "gzip"; Body is decoded when supported
+	CompressedSize  int64  `json:"compressed_size,omitempty"`  // Encoded bytes received, when decoded
+	Undecoded       bool   `json:"undecoded,omitempty"`        // Encoding not supported; Body is still encoded
+
+	CacheStatus string `json:"cache_status,omitempty"` // Set when the request used the cache option
+
+	HeaderValues map[string][]string `json:"header_values,omitempty"` // Headers sent more than once (e.g. Set-Cookie), value by value
+
+	RequestIDs map[string]string `json:"request_ids,omitempty"` // Generated Idempotency-Key / X-Request-Id values sent
+
+	TokenRefresh string `json:"token_refresh,omitempty"` // Set when a saved OAuth2 token was renewed
+	AuthProfile  string `json:"auth_profile,omitempty"`  // Auth profile applied by use_auth
+
+	ScriptChecks   int      `json:"script_checks,omitempty"`   // Asserts the post_script ran
+	ScriptFailures []string `json:"script_failures,omitempty"` // Asserts that failed, or the error that stopped it
+}
+
+// RedirectHop is one redirect response in a redirect chain
+type RedirectHop struct {
+	StatusCode int    `json:"status_code"`
+	URL        string `json:"url"`      // URL that answered with the redirect
+	Location   string `json:"location"` // Resolved redirect target
 }
 
+// DefaultMaxRedirects is the redirect limit when a request doesn't set one
+const DefaultMaxRedirects = 10
+
 // Name returns the tool name
 func (t *HTTPTool) Name() string {
 	return "http_request"
@@ -70,11 +210,34 @@ func (t *HTTPTool) Description() string {
 
 // Parameters returns the tool parameter description
 func (t *HTTPTool) Parameters() string {
-	return `{"method": "GET|POST|PUT|DELETE", "url": "string", "headers": {"key": "value"}, "body": {}, "timeout": 30}`
+	return `{"method": "GET|POST|PUT|DELETE", "url": "string", "query": {"page": 1, "tags": ["a", "b"]}, "headers": {"key": "value"}, "body": {}, "body_file": "payloads/large.json (instead of body)", "timeout": 30, "follow_redirects": true, "max_redirects": 10, "save_body_to": "downloads/file.bin", "max_body_size": 10485760, "tls": {"insecure_skip_verify": false, "ca_file": "certs/dev-ca.pem"}, "cache": "revalidate|refresh (optional ETag/Last-Modified revalidation)", "protocol": "http1.1|h2|h3 (optional)", "inject_ids": true, "save_response_as": "before_update (optional: keep this response for assert/extract/compare by name)", "use_auth": "auth profile of the active environment (optional)", "pre_script": "sig = hmac_sha256(SECRET, request.body.id); request.headers['X-Signature'] = sig (optional)", "post_script": "assert response.status == 200; user_id = response.body.id (optional)"}`
 }
 
 // Execute performs an HTTP request (implements core.Tool)
 func (t *HTTPTool) Execute(args string) (string, error) {
+	return t.ExecuteInto(args, t.responseManager)
+}
+
+// ExecuteInto is Execute, storing the response in responseManager instead of
+// the tool's own, so concurrent callers each see their own response
+func (t *HTTPTool) ExecuteInto(args string, responseManager *ResponseManager) (string, error) {
+	// Remember the request with its {{VAR}} placeholders for export_curl
+	var raw HTTPRequest
+	if err := json.Unmarshal([]byte(args), &raw); err == nil {
+		t.SetLastRequest(raw)
+		if raw.PreScript != "" {
+			prepared, err := t.runPreScript(raw)
+			if err != nil {
+				return "", err
+			}
+			data, err := json.Marshal(prepared)
+			if err != nil {
+				return "", fmt.Errorf("failed to marshal request: %w", err)
+			}
+			args = string(data)
+		}
+	}
+
 	// Substitute variables in args if varStore is available
 	if t.varStore != nil {
 		args = t.varStore.Substitute(args)
@@ -89,17 +252,85 @@ func (t *HTTPTool) Execute(args string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if req.PostScript != "" {
+		t.runPostScript(req, resp)
+	}
 
 	// Store response for assert/extract tools
-	if t.responseManager != nil {
-		t.responseManager.SetHTTPResponse(resp)
+	if responseManager != nil {
+		responseManager.SetHTTPResponse(resp)
+		if req.SaveResponseAs != "" {
+			responseManager.SaveHTTPResponse(req.SaveResponseAs, resp)
+		}
+	}
+
+	if t.history != nil {
+		t.authMu.Lock()
+		env := t.authEnv
+		t.authMu.Unlock()
+		// The archive is best-effort; a failed write shouldn't fail the request
+		_ = t.history.Record(raw, req.URL, env, resp)
 	}
 
 	return resp.FormatResponse(), nil
 }
 
-// Run performs an HTTP request
+// Run performs an HTTP request. A saved OAuth2 token used by the request is
+// renewed first when it has expired, or after a 401 followed by one retry.
 func (t *HTTPTool) Run(req HTTPRequest) (*HTTPResponse, error) {
+	req, profile, err := t.applyAuthProfile(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var tok *OAuthToken
+	if t.varStore != nil {
+		if tok = t.varStore.tokenUsedBy(req); tok != nil && !tok.renewable() {
+			tok = nil
+		}
+	}
+
+	refreshed := ""
+	if tok != nil && tok.expired() {
+		renewed, err := t.varStore.renewToken(tok)
+		if err != nil {
+			return nil, err
+		}
+		req = withRenewedToken(req, tok.AccessToken, renewed.AccessToken)
+		tok, refreshed = renewed, fmt.Sprintf("renewed expired token {{%s}}", tok.Variable)
+	}
+
+	resp, err := t.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && tok != nil && refreshed == "" {
+		renewed, err := t.varStore.renewToken(tok)
+		if err != nil {
+			// Keep the 401 inspectable
+			resp.TokenRefresh = fmt.Sprintf("got 401, %v", err)
+			return resp, nil
+		}
+		retry := withRenewedToken(req, tok.AccessToken, renewed.AccessToken)
+		// Reuse the generated IDs: the retry is the same logical request
+		for header, value := range resp.RequestIDs {
+			retry.Headers[header] = value
+		}
+		retried, err := t.send(retry)
+		if err != nil {
+			return nil, err
+		}
+		retried.RequestIDs = resp.RequestIDs
+		resp, refreshed = retried, fmt.Sprintf("got 401, renewed token {{%s}} and retried", tok.Variable)
+	}
+	resp.TokenRefresh = refreshed
+	resp.AuthProfile = profile
+	return resp, nil
+}
+
+// send performs a single HTTP request
+func (t *HTTPTool) send(req HTTPRequest) (*HTTPResponse, error) {
 	startTime := time.Now()
 
 	// Determine timeout: use per-request timeout if specified, otherwise use default
@@ -108,66 +339,378 @@ func (t *HTTPTool) Run(req HTTPRequest) (*HTTPResponse, error) {
 		timeout = time.Duration(req.Timeout) * time.Second
 	}
 
-	// Create a client with the appropriate timeout for this request
-	// We create a new client only if timeout differs from default to preserve connection pooling
-	client := t.client
-	if timeout != t.defaultTimeout {
-		client = &http.Client{
-			Timeout:   timeout,
-			Transport: t.client.Transport, // Reuse transport for connection pooling
+	// Per-request TLS settings take precedence over the environment's
+	tlsCfg := req.TLS
+	if tlsCfg == nil {
+		t.tlsMu.Lock()
+		tlsCfg = t.defaultTLS
+		t.tlsMu.Unlock()
+	}
+	transport := t.client.Transport
+	switch req.Protocol {
+	case "", ProtocolHTTP1, ProtocolHTTP2, ProtocolHTTP3:
+	default:
+		return nil, fmt.Errorf("invalid protocol %q (use %q, %q or %q)", req.Protocol, ProtocolHTTP1, ProtocolHTTP2, ProtocolHTTP3)
+	}
+	customTLS := tlsCfg != nil && (tlsCfg.InsecureSkipVerify || tlsCfg.CAFile != "")
+	if customTLS || req.Protocol != "" {
+		key := transportKey{protocol: req.Protocol}
+		if customTLS {
+			key.tls = *tlsCfg
 		}
+		var err error
+		if transport, err = t.transportFor(key); err != nil {
+			return nil, err
+		}
+	}
+
+	followRedirects := req.FollowRedirects == nil || *req.FollowRedirects
+	maxRedirects := DefaultMaxRedirects
+	if req.MaxRedirects > 0 {
+		maxRedirects = req.MaxRedirects
 	}
 
-	// Prepare request body
+	// Create a per-request client so redirects can be tracked; the transport
+	// is shared to preserve connection pooling
+	var redirects []RedirectHop
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(next *http.Request, via []*http.Request) error {
+			// Returning the 3xx response (instead of an error) keeps it inspectable
+			if !followRedirects || len(via) > maxRedirects {
+				return http.ErrUseLastResponse
+			}
+			if next.Response != nil {
+				redirects = append(redirects, RedirectHop{
+					StatusCode: next.Response.StatusCode,
+					URL:        via[len(via)-1].URL.String(),
+					Location:   next.URL.String(),
+				})
+			}
+			return nil
+		},
+	}
+
+	// Prepare request body (payload is kept for request signing)
 	var bodyReader io.Reader
-	if req.Body != nil {
+	var payload []byte
+	var contentType string
+	if req.Body != nil && req.BodyFile != "" {
+		return nil, fmt.Errorf("use either body or body_file, not both")
+	}
+	if raw, ok := req.Body.(string); ok && !isJSONContentType(headerValue(req.Headers, "Content-Type")) {
+		// String bodies with a non-JSON Content-Type (form data, XML, ...) are sent as-is
+		payload = []byte(raw)
+		bodyReader = bytes.NewReader(payload)
+	} else if req.Body != nil {
 		jsonBody, err := json.Marshal(req.Body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal body: %w", err)
 		}
+		payload = jsonBody
 		bodyReader = bytes.NewBuffer(jsonBody)
+		contentType = "application/json"
+	}
+	if req.BodyFile != "" {
+		data, fileType, err := t.readBodyFile(req.BodyFile)
+		if err != nil {
+			return nil, err
+		}
+		payload = data
+		bodyReader = bytes.NewReader(data)
+		contentType = fileType
+	}
+
+	requestURL, err := mergeQuery(req.URL, req.Query)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create HTTP request
-	httpReq, err := http.NewRequest(strings.ToUpper(req.Method), req.URL, bodyReader)
+	httpReq, err := http.NewRequest(strings.ToUpper(req.Method), requestURL, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if req.Protocol == ProtocolHTTP3 && httpReq.URL.Scheme != "https" {
+		return nil, fmt.Errorf("HTTP/3 needs an https:// URL (QUIC always uses TLS)")
+	}
+
+	// Trace connection phases for the timing breakdown
+	tracer := &timingTracer{}
+	httpReq = httpReq.WithContext(tracer.withTrace(httpReq.Context()))
 
 	// Set headers
-	if req.Body != nil {
-		httpReq.Header.Set("Content-Type", "application/json")
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
 	}
+	// Asking explicitly disables the transport's transparent gzip handling,
+	// so the encoding and compressed size can be reported
+	httpReq.Header.Set("Accept-Encoding", acceptEncoding)
 	for key, value := range req.Headers {
 		httpReq.Header.Set(key, value)
 	}
 
+	// Generated IDs are saved as variables so they can be matched against
+	// server logs and responses
+	requestIDs := t.requestIDs.inject(httpReq, req.InjectIDs)
+	if t.varStore != nil {
+		if value, ok := requestIDs[IdempotencyKeyHeader]; ok {
+			t.varStore.Set(idempotencyKeyVar, value)
+		}
+		if value, ok := requestIDs[RequestIDHeader]; ok {
+			t.varStore.Set(requestIDVar, value)
+		}
+	}
+
+	// Conditional request from the response cache
+	key, hadEntry := "", false
+	switch req.Cache {
+	case "":
+	case CacheRevalidate, CacheRefresh:
+		key = cacheKey(httpReq.Method, requestURL)
+		if key != "" && req.Cache == CacheRevalidate {
+			hadEntry = t.cache.applyValidators(key, httpReq)
+		}
+	default:
+		return nil, fmt.Errorf("invalid cache mode %q (use %q or %q)", req.Cache, CacheRevalidate, CacheRefresh)
+	}
+
+	// Signing comes last so it covers every header set above
+	hmacCfg := req.HMAC
+	if hmacCfg == nil {
+		hmacCfg = t.defaultHMACFor(httpReq.URL.Host)
+	}
+	if hmacCfg != nil {
+		if err := t.signHMAC(httpReq, payload, *hmacCfg, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	sigv4 := req.AWSSigV4
+	if sigv4 == nil {
+		sigv4 = t.defaultSigV4For(httpReq.URL.Host)
+	}
+	if sigv4 != nil {
+		if err := signSigV4(httpReq, payload, sigv4.WithEnvDefaults(), time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
 	// Execute request
 	httpResp, err := client.Do(httpReq)
 	if err != nil {
+		if isCertificateError(err) {
+			return nil, fmt.Errorf("failed to execute request: %w (for self-signed dev servers set \"tls\": {\"ca_file\": \"path/to/ca.pem\"} or {\"insecure_skip_verify\": true}; tls_inspect shows the certificate chain)", err)
+		}
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer httpResp.Body.Close()
 
-	// Read response body
-	bodyBytes, err := io.ReadAll(httpResp.Body)
+	decoding, err := decodeResponseBody(httpResp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read response body (or stream it to a file)
+	maxBody, maxDownload := int64(DefaultMaxBodySize), int64(DefaultMaxDownloadSize)
+	if req.MaxBodySize > 0 {
+		maxBody, maxDownload = req.MaxBodySize, req.MaxBodySize
+	}
+	body, err := readResponseBody(httpResp, maxBody, maxDownload, req.SaveBodyTo)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
+	}
+	var compressedSize int64
+	if decoding.wire != nil {
+		compressedSize = decoding.wire.n
+	}
+	if decoding.encoding != "" && !decoding.decoded {
+		body.binary = true
 	}
 
-	// Build response headers map
+	// Build response headers map; headers sent more than once are also kept value by value
 	headers := make(map[string]string)
+	var headerValues map[string][]string
 	for key, values := range httpResp.Header {
 		headers[key] = strings.Join(values, ", ")
+		if len(values) > 1 {
+			if headerValues == nil {
+				headerValues = make(map[string][]string)
+			}
+			headerValues[key] = values
+		}
 	}
 
-	return &HTTPResponse{
+	resp := &HTTPResponse{
 		StatusCode: httpResp.StatusCode,
 		Status:     httpResp.Status,
+		Protocol:   httpResp.Proto,
 		Headers:    headers,
-		Body:       string(bodyBytes),
+		Body:       string(body.data),
 		Duration:   time.Since(startTime),
-	}, nil
+		Redirects:  redirects,
+		Timing:     tracer.finish(),
+		BodySize:   body.size,
+		BodyFile:   body.file,
+		Binary:     body.binary,
+		Truncated:  body.truncated,
+
+		HeaderValues: headerValues,
+
+		ContentEncoding: decoding.encoding,
+		CompressedSize:  compressedSize,
+		Undecoded:       decoding.encoding != "" && !decoding.decoded,
+
+		RequestIDs: requestIDs,
+	}
+	if req.Cache != "" {
+		resp.CacheStatus = CacheStatusUncacheable
+		if key != "" {
+			resp.CacheStatus = t.cache.update(key, hadEntry, resp)
+		}
+	}
+	return resp, nil
+}
+
+// joinNonEmpty joins the non-empty parts with sep
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+// headerValue looks up a header case-insensitively
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// isJSONContentType reports whether a Content-Type is JSON (or unset, which
+// means the body will be sent as JSON)
+func isJSONContentType(contentType string) bool {
+	return contentType == "" || strings.Contains(strings.ToLower(contentType), "json")
+}
+
+// mergeQuery URL-encodes query parameters into rawURL. Values given here
+// replace parameters of the same name already in the URL; arrays add the key
+// once per element.
+func mergeQuery(rawURL string, query map[string]interface{}) (string, error) {
+	if len(query) == 0 {
+		return rawURL, nil
+	}
+
+	// Only the query string is rewritten, so the rest of the URL (which may
+	// hold {{VAR}} placeholders when exporting) is kept verbatim
+	base, fragment, hasFragment := strings.Cut(rawURL, "#")
+	base, rawQuery, _ := strings.Cut(base, "?")
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("invalid url query: %w", err)
+	}
+
+	for key, value := range query {
+		values.Del(key)
+		switch v := value.(type) {
+		case nil:
+			// null removes the parameter
+		case []interface{}:
+			for _, item := range v {
+				values.Add(key, queryValueString(item))
+			}
+		default:
+			values.Set(key, queryValueString(v))
+		}
+	}
+	merged := base
+	if encoded := values.Encode(); encoded != "" {
+		merged += "?" + encoded
+	}
+	if hasFragment {
+		merged += "#" + fragment
+	}
+	return merged, nil
+}
+
+// queryValueString formats a JSON value as a query parameter value
+func queryValueString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		data, _ := json.Marshal(val)
+		return string(data)
+	}
+}
+
+// transportFor returns a cached transport for the given TLS settings
+func (t *HTTPTool) transportFor(key transportKey) (http.RoundTripper, error) {
+	t.tlsMu.Lock()
+	defer t.tlsMu.Unlock()
+
+	if transport, ok := t.transports[key]; ok {
+		return transport, nil
+	}
+	cfg := key.tls
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if t.transports == nil {
+		t.transports = make(map[transportKey]http.RoundTripper)
+	}
+	if key.protocol == ProtocolHTTP3 {
+		transport := &http3.Transport{TLSClientConfig: tlsConfig}
+		t.transports[key] = transport
+		return transport, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	var protocols http.Protocols
+	switch key.protocol {
+	case ProtocolHTTP1:
+		protocols.SetHTTP1(true)
+		transport.Protocols = &protocols
+	case ProtocolHTTP2:
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		transport.Protocols = &protocols
+	}
+
+	t.transports[key] = transport
+	return transport, nil
+}
+
+// isCertificateError reports whether err is a TLS certificate verification failure
+func isCertificateError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "x509:") || strings.Contains(msg, "tls: failed to verify certificate")
 }
 
 // StatusCodeMeaning returns a human-readable explanation of HTTP status codes
@@ -176,6 +719,7 @@ func StatusCodeMeaning(code int) string {
 		200: "OK - Request succeeded",
 		201: "Created - Resource created successfully",
 		204: "No Content - Request succeeded, no response body",
+		304: "Not Modified - Cached copy is still valid (ETag/Last-Modified matched)",
 		400: "Bad Request - Invalid request syntax or missing required fields",
 		401: "Unauthorized - Missing or invalid authentication token",
 		403: "Forbidden - Valid auth but insufficient permissions",
@@ -214,16 +758,53 @@ func (r *HTTPResponse) FormatResponse() string {
 
 	// Calculate body size
 	bodySize := len(r.Body)
+	if r.BodySize > int64(bodySize) {
+		bodySize = int(r.BodySize)
+	}
 	sizeStr := formatSize(bodySize)
 
 	// Status line with meaning, duration, and size
-	sb.WriteString(fmt.Sprintf("Status: %s\n", r.Status))
+	if r.Protocol != "" {
+		sb.WriteString(fmt.Sprintf("Status: %s (%s)\n", r.Status, r.Protocol))
+	} else {
+		sb.WriteString(fmt.Sprintf("Status: %s\n", r.Status))
+	}
 	sb.WriteString(fmt.Sprintf("Time:   %dms\n", r.Duration.Milliseconds()))
+	if r.Timing != nil {
+		sb.WriteString(fmt.Sprintf("        (%s)\n", r.Timing))
+	}
+	switch {
+	case r.Undecoded:
+		sizeStr += fmt.Sprintf(" (%s-encoded, not decoded)", r.ContentEncoding)
+	case r.ContentEncoding != "":
+		sizeStr += fmt.Sprintf(" (%s, %s compressed)", r.ContentEncoding, formatSize(int(r.CompressedSize)))
+	}
 	sb.WriteString(fmt.Sprintf("Size:   %s\n", sizeStr))
+	if r.CacheStatus != "" {
+		sb.WriteString(fmt.Sprintf("Cache:  %s\n", formatCacheStatus(r)))
+	}
+	if len(r.RequestIDs) > 0 {
+		sb.WriteString(fmt.Sprintf("Sent:   %s\n", formatRequestIDs(r)))
+	}
+	if auth := joinNonEmpty("; ", r.AuthProfile, r.TokenRefresh); auth != "" {
+		sb.WriteString(fmt.Sprintf("Auth:   %s\n", auth))
+	}
+	if r.ScriptChecks > 0 || len(r.ScriptFailures) > 0 {
+		sb.WriteString(formatScriptResult(r))
+	}
 	sb.WriteString(fmt.Sprintf("Meaning: %s\n\n", StatusCodeMeaning(r.StatusCode)))
 
+	// Redirect chain (status + Location per hop)
+	if len(r.Redirects) > 0 {
+		sb.WriteString("Redirects:\n")
+		for i, hop := range r.Redirects {
+			sb.WriteString(fmt.Sprintf("  %d. %d %s -> %s\n", i+1, hop.StatusCode, hop.URL, hop.Location))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Headers (condensed - only show important ones)
-	importantHeaders := []string{"Content-Type", "Authorization", "X-Request-Id", "X-Error-Code"}
+	importantHeaders := []string{"Content-Type", "Location", "Authorization", "X-Request-Id", "X-Error-Code", "Cache-Control"}
 	sb.WriteString("Headers:\n")
 	for _, key := range importantHeaders {
 		if value, ok := r.Headers[key]; ok {
@@ -245,10 +826,28 @@ func (r *HTTPResponse) FormatResponse() string {
 	}
 	sb.WriteString("\n")
 
+	if r.BodyFile != "" {
+		sb.WriteString(fmt.Sprintf("Saved:  %s (%s)\n\n", r.BodyFile, sizeStr))
+	}
+	if r.Truncated {
+		sb.WriteString(fmt.Sprintf("Note: body exceeded the size cap and was truncated at %s (raise max_body_size", sizeStr))
+		if r.BodyFile == "" {
+			sb.WriteString(" or use save_body_to")
+		}
+		sb.WriteString(")\n\n")
+	}
+
 	// Body (try to pretty-print JSON)
 	sb.WriteString("Body:\n")
 	var prettyJSON bytes.Buffer
-	if err := json.Indent(&prettyJSON, []byte(r.Body), "", "  "); err == nil {
+	if r.Binary {
+		sb.WriteString(fmt.Sprintf("(binary, first %d bytes)\n```\n", min(len(r.Body), binaryPreviewSize)))
+		sb.WriteString(formatBinaryPreview([]byte(r.Body)))
+		sb.WriteString("```")
+		if r.BodyFile == "" {
+			sb.WriteString("\nUse save_body_to to download the full body.")
+		}
+	} else if err := json.Indent(&prettyJSON, []byte(r.Body), "", "  "); err == nil {
 		sb.WriteString("```json\n")
 		sb.WriteString(prettyJSON.String())
 		sb.WriteString("\n```")
@@ -0,0 +1,306 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// insomniaResource is one entry of an Insomnia v4 export's resources
+type insomniaResource struct {
+	ID       string `json:"_id"`
+	Type     string `json:"_type"`
+	ParentID string `json:"parentId"`
+	Name     string `json:"name"`
+
+	// request
+	Method         string                 `json:"method"`
+	URL            string                 `json:"url"`
+	Headers        []insomniaPair         `json:"headers"`
+	Parameters     []insomniaPair         `json:"parameters"`
+	Body           insomniaBody           `json:"body"`
+	Authentication map[string]interface{} `json:"authentication"`
+
+	// environment
+	Data map[string]interface{} `json:"data"`
+}
+
+// insomniaPair is a header, query parameter or form field
+type insomniaPair struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+// insomniaBody is a request body
+type insomniaBody struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []insomniaPair `json:"params"`
+}
+
+// insomniaVarPattern matches Insomnia variables: {{ base_url }}, {{ _.base_url }}, {{ _['base-url'] }}
+var insomniaVarPattern = regexp.MustCompile(`\{\{\s*(?:_\.)?(?:_\[['"])?([A-Za-z0-9_.\-]+)(?:['"]\])?\s*\}\}`)
+
+// insomniaTagPattern matches template tags like {% response ... %} or {% uuid %}
+var insomniaTagPattern = regexp.MustCompile(`\{%.*?%\}`)
+
+// LoadInsomniaExport reads an Insomnia export file (v4 JSON or YAML)
+func LoadInsomniaExport(path string) (*ImportedCollection, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+	return ParseInsomniaExport(data)
+}
+
+// ParseInsomniaExport converts an Insomnia v4 export (Application menu >
+// Export Data) into an ImportedCollection. Request groups become folders;
+// sub environments are merged over the base environment.
+func ParseInsomniaExport(data []byte) (*ImportedCollection, error) {
+	// YAML is a superset of JSON, so one decoder handles both
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse Insomnia export: %w", err)
+	}
+	root, ok := normalizeYAML(raw).(map[string]interface{})
+	if !ok || root["_type"] != "export" {
+		return nil, fmt.Errorf("not an Insomnia export: expected \"_type\": \"export\" (export with format v4)")
+	}
+	if format := fmt.Sprint(root["__export_format"]); format != "4" {
+		return nil, fmt.Errorf("unsupported Insomnia export format %s: export with format v4", format)
+	}
+
+	normalized, err := json.Marshal(root["resources"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resources: %w", err)
+	}
+	var resources []insomniaResource
+	if err := json.Unmarshal(normalized, &resources); err != nil {
+		return nil, fmt.Errorf("failed to read resources: %w", err)
+	}
+
+	byID := make(map[string]insomniaResource, len(resources))
+	for _, r := range resources {
+		byID[r.ID] = r
+	}
+
+	c := &ImportedCollection{Environments: make(map[string]map[string]string)}
+	tags := 0
+	for _, r := range resources {
+		switch r.Type {
+		case "request":
+			req, n := insomniaRequest(r, c)
+			tags += n
+			c.Requests = append(c.Requests, ImportedRequest{Folder: insomniaFolder(r.ParentID, byID), Request: req})
+		case "grpc_request", "websocket_request":
+			c.Notes = append(c.Notes, fmt.Sprintf("Skipped %s '%s': only HTTP requests are imported", strings.TrimSuffix(r.Type, "_request"), r.Name))
+		case "environment":
+			parent, isSub := byID[r.ParentID]
+			if isSub && parent.Type == "environment" {
+				vars := flattenImportVars(parent.Data)
+				for name, value := range flattenImportVars(r.Data) {
+					vars[name] = value
+				}
+				c.Environments[r.Name] = vars
+			} else if _, ok := c.Environments["default"]; !ok {
+				c.Environments["default"] = flattenImportVars(r.Data)
+			}
+		}
+	}
+
+	// The base environment only matters when there are no sub environments
+	if len(c.Environments) > 1 || len(c.Environments["default"]) == 0 {
+		delete(c.Environments, "default")
+	}
+	for _, vars := range c.Environments {
+		for key, value := range vars {
+			vars[key] = convertInsomniaVars(value)
+		}
+	}
+	if tags > 0 {
+		c.Notes = append(c.Notes, fmt.Sprintf("%d template tag(s) like {%% response %%} or {%% uuid %%} were kept as text; replace them with extract_value or variables", tags))
+	}
+	return c, nil
+}
+
+// insomniaRequest converts a request resource. It returns the number of
+// template tags left in it.
+func insomniaRequest(r insomniaResource, c *ImportedCollection) (storage.Request, int) {
+	req := storage.Request{
+		Name:    r.Name,
+		Method:  strings.ToUpper(r.Method),
+		Headers: make(map[string]string),
+		Query:   make(map[string]string),
+	}
+	if req.Method == "" {
+		req.Method = "GET"
+	}
+
+	rawURL, query, _ := strings.Cut(convertInsomniaVars(r.URL), "?")
+	req.URL = rawURL
+	if values, err := url.ParseQuery(query); err == nil {
+		for key := range values {
+			req.Query[key] = values.Get(key)
+		}
+	}
+	for _, p := range r.Parameters {
+		if !p.Disabled && p.Name != "" {
+			req.Query[convertInsomniaVars(p.Name)] = convertInsomniaVars(p.Value)
+		}
+	}
+	for _, h := range r.Headers {
+		if !h.Disabled && h.Name != "" {
+			req.Headers[h.Name] = convertInsomniaVars(h.Value)
+		}
+	}
+
+	switch {
+	case r.Body.MimeType == "application/x-www-form-urlencoded":
+		form := url.Values{}
+		for _, p := range r.Body.Params {
+			if !p.Disabled {
+				form.Add(convertInsomniaVars(p.Name), convertInsomniaVars(p.Value))
+			}
+		}
+		req.Body = form.Encode()
+		req.Headers["Content-Type"] = r.Body.MimeType
+	case r.Body.MimeType == "multipart/form-data":
+		c.Notes = append(c.Notes, fmt.Sprintf("'%s' has a multipart body, which was not imported", r.Name))
+	case r.Body.MimeType == "application/graphql":
+		// The text is {"query": ..., "variables": ...}; send it as JSON
+		req.Body = importBody(convertInsomniaVars(r.Body.Text))
+		req.Headers["Content-Type"] = "application/json"
+	case r.Body.Text != "":
+		req.Body = importBody(convertInsomniaVars(r.Body.Text))
+		if r.Body.MimeType != "" && headerValue(req.Headers, "Content-Type") == "" {
+			req.Headers["Content-Type"] = r.Body.MimeType
+		}
+	}
+
+	if auth := r.Authentication; len(auth) > 0 && auth["disabled"] != true {
+		authString := func(key string) string { return convertInsomniaVars(stringField(auth, key)) }
+		switch auth["type"] {
+		case "bearer":
+			prefix := "Bearer"
+			if p, ok := auth["prefix"].(string); ok && p != "" {
+				prefix = p
+			}
+			req.Headers["Authorization"] = prefix + " " + authString("token")
+		case "basic":
+			req.Headers["Authorization"] = basicAuthHeader(authString("username"), authString("password"), c)
+		case "apikey":
+			if auth["addTo"] == "queryParams" {
+				req.Query[authString("key")] = authString("value")
+			} else {
+				req.Headers[authString("key")] = authString("value")
+			}
+		case "none", nil:
+		default:
+			c.Notes = append(c.Notes, fmt.Sprintf("'%s' uses %v auth, which was not imported; use the auth tools or an auth profile", r.Name, auth["type"]))
+		}
+	}
+
+	if len(req.Headers) == 0 {
+		req.Headers = nil
+	}
+	if len(req.Query) == 0 {
+		req.Query = nil
+	}
+
+	tags := len(insomniaTagPattern.FindAllString(r.URL, -1))
+	for _, h := range r.Headers {
+		tags += len(insomniaTagPattern.FindAllString(h.Value, -1))
+	}
+	tags += len(insomniaTagPattern.FindAllString(r.Body.Text, -1))
+	return req, tags
+}
+
+// insomniaFolder returns the request group path of a resource, e.g. "Users/Admin"
+func insomniaFolder(parentID string, byID map[string]insomniaResource) string {
+	var parts []string
+	for i := 0; i < maxSchemaDepth; i++ {
+		parent, ok := byID[parentID]
+		if !ok || parent.Type != "request_group" {
+			break
+		}
+		parts = append([]string{parent.Name}, parts...)
+		parentID = parent.ParentID
+	}
+	return strings.Join(parts, "/")
+}
+
+// convertInsomniaVars rewrites Insomnia variables as ZAP {{VAR}} placeholders
+func convertInsomniaVars(text string) string {
+	return insomniaVarPattern.ReplaceAllStringFunc(text, insomniaVar)
+}
+
+func insomniaVar(match string) string {
+	return "{{" + importVarName(insomniaVarPattern.FindStringSubmatch(match)[1]) + "}}"
+}
+
+// flattenImportVars flattens nested environment data into variables:
+// {"api": {"url": "..."}} becomes api_url
+func flattenImportVars(data map[string]interface{}) map[string]string {
+	vars := make(map[string]string)
+	var walk func(prefix string, node map[string]interface{})
+	walk = func(prefix string, node map[string]interface{}) {
+		keys := make([]string, 0, len(node))
+		for key := range node {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			name := importVarName(prefix + key)
+			switch v := node[key].(type) {
+			case map[string]interface{}:
+				walk(name+"_", v)
+			case nil:
+				vars[name] = ""
+			case string:
+				vars[name] = v
+			default:
+				encoded, _ := json.Marshal(v)
+				vars[name] = string(encoded)
+			}
+		}
+	}
+	walk("", data)
+	return vars
+}
+
+// importBody returns text as parsed JSON when it is JSON, else as a string
+func importBody(text string) interface{} {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(text), &parsed); err == nil {
+		if _, ok := parsed.(map[string]interface{}); ok {
+			return parsed
+		}
+		if _, ok := parsed.([]interface{}); ok {
+			return parsed
+		}
+	}
+	return text
+}
+
+// basicAuthHeader builds a Basic Authorization header. With variables in the
+// username or password it can't be encoded up front, so it refers to a
+// BASIC_AUTH secret variable holding base64(username:password) instead.
+func basicAuthHeader(username, password string, c *ImportedCollection) string {
+	if strings.Contains(username+password, "{{") {
+		if !slices.Contains(c.SecretVars, "BASIC_AUTH") {
+			c.SecretVars = append(c.SecretVars, "BASIC_AUTH")
+		}
+		return "Basic {{BASIC_AUTH}}"
+	}
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
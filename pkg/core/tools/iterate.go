@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ForEach runs a test or request once per element of a list, e.g. deleting
+// every resource a suite created
+type ForEach struct {
+	Items         interface{} `json:"items"`                     // A list variable's name ("created_ids" or "{{created_ids}}"), or a list
+	As            string      `json:"as,omitempty"`              // Variable holding the element (default "item")
+	StopOnFailure bool        `json:"stop_on_failure,omitempty"` // Leave the remaining elements once one fails
+}
+
+// maxIterations caps the elements a for_each or iterate runs
+const maxIterations = 1000
+
+// resolveList returns the elements a for_each names
+func resolveList(items interface{}, vars *VariableStore) ([]interface{}, error) {
+	switch v := items.(type) {
+	case []interface{}:
+		return v, nil
+	case string:
+		text := strings.TrimSpace(v)
+		if strings.HasPrefix(text, "[") {
+			var list []interface{}
+			if err := json.Unmarshal([]byte(text), &list); err != nil {
+				return nil, fmt.Errorf("items is not a valid JSON list: %w", err)
+			}
+			return list, nil
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(text, "{{"), "}}")
+		if list, ok := vars.GetList(name); ok {
+			return list, nil
+		}
+		if value, ok := vars.Get(name); ok {
+			return nil, fmt.Errorf("variable '%s' is not a list: %s", name, shortValue(value))
+		}
+		return nil, fmt.Errorf("variable '%s' not found (extract a list with \"all\" or \"append\" first)", name)
+	case nil:
+		return nil, fmt.Errorf("'items' is required")
+	}
+	return nil, fmt.Errorf("items must be a list variable's name or a list")
+}
+
+// setLoopVariables puts the element in {{as}} and its position in
+// {{as_index}}; an object's fields also go in {{as.field}}
+func setLoopVariables(vars *VariableStore, as string, index int, item interface{}) {
+	vars.Set(as, scriptString(item))
+	vars.Set(as+"_index", fmt.Sprint(index))
+	if obj, ok := item.(map[string]interface{}); ok {
+		for key, value := range obj {
+			vars.Set(as+"."+key, scriptString(value))
+		}
+	}
+}
+
+// shortValue shortens a value for messages
+func shortValue(value string) string {
+	if len(value) > 40 {
+		return value[:37] + "..."
+	}
+	return value
+}
+
+// forEachRun is a test's run for one element
+type forEachRun struct {
+	element string // The element, shortened for display
+	result  TestResult
+}
+
+// runForEach runs a test once per element of spec's list
+func (t *TestSuiteTool) runForEach(test TestDefinition, spec ForEach, testNum, totalTests int, tt testTools) ([]forEachRun, error) {
+	items, err := resolveList(spec.Items, t.varStore)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) > maxIterations {
+		return nil, fmt.Errorf("%d elements is more than the %d allowed", len(items), maxIterations)
+	}
+	as := spec.As
+	if as == "" {
+		as = "item"
+	}
+
+	var runs []forEachRun
+	for i, item := range items {
+		setLoopVariables(t.varStore, as, i, item)
+		result := t.runTestWithRetries(test, testNum, totalTests, tt)
+		runs = append(runs, forEachRun{element: shortValue(scriptString(item)), result: result})
+		if !result.Passed && spec.StopOnFailure {
+			break
+		}
+	}
+	return runs, nil
+}
+
+// runTestForEach runs a suite test with for_each and sums up its runs as one
+// result, which fails when any run fails
+func (t *TestSuiteTool) runTestForEach(test TestDefinition, testNum, totalTests int, tt testTools) TestResult {
+	start := time.Now()
+	runs, err := t.runForEach(test, *test.ForEach, testNum, totalTests, tt)
+	if err != nil {
+		return TestResult{Name: test.Name, Error: "for_each: " + err.Error(), Duration: time.Since(start)}
+	}
+
+	result := TestResult{Name: test.Name, Passed: true, Iterations: len(runs)}
+	var failures []string
+	for _, run := range runs {
+		result.StatusCode = run.result.StatusCode
+		if !run.result.Passed {
+			result.Passed = false
+			failures = append(failures, fmt.Sprintf("[%s] %s", run.element, strings.TrimSpace(run.result.Error)))
+		}
+	}
+	if len(failures) > 0 {
+		result.Error = fmt.Sprintf("%d of %d runs failed\n%s", len(failures), len(runs), strings.Join(failures, "\n"))
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// IterateTool sends a request once per element of a list
+type IterateTool struct {
+	suite *TestSuiteTool
+}
+
+// NewIterateTool creates a new iterate tool running requests the way suite
+// tests do
+func NewIterateTool(suite *TestSuiteTool) *IterateTool {
+	return &IterateTool{suite: suite}
+}
+
+// IterateParams defines the list and the request to send for each element
+type IterateParams struct {
+	ForEach
+	Request    HTTPRequest       `json:"request"`
+	Assertions *AssertParams     `json:"assertions,omitempty"`
+	Extract    map[string]string `json:"extract,omitempty"` // var_name -> json_path, after each request
+}
+
+// Name returns the tool name
+func (t *IterateTool) Name() string {
+	return "iterate"
+}
+
+// Description returns the tool description
+func (t *IterateTool) Description() string {
+	return "Send a request once per element of a list variable (from extract_value with all or append), e.g. delete every resource created during a test. The element is {{item}} (or the name in 'as'), its fields {{item.id}}, its position {{item_index}}"
+}
+
+// Parameters returns the tool parameter description
+func (t *IterateTool) Parameters() string {
+	return `{
+  "items": "created_ids (a list variable, or a list like [1, 2, 3])",
+  "as": "id",
+  "request": {"method": "DELETE", "url": "{{BASE_URL}}/users/{{id}}"},
+  "assertions": {"status_code": 204},
+  "stop_on_failure": false
+}`
+}
+
+// Execute runs the request for every element
+func (t *IterateTool) Execute(args string) (string, error) {
+	var params IterateParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse iterate parameters: %w", err)
+	}
+	if params.Request.URL == "" {
+		return "", fmt.Errorf("'request' with a url is required")
+	}
+	as := params.As
+	if as == "" {
+		as = "item"
+	}
+
+	// The element variables only live during the loop; variables the
+	// requests extract are kept
+	vars := t.suite.varStore
+	vars.PushScope()
+	test := TestDefinition{Name: params.Request.Method + " " + params.Request.URL, Request: params.Request, Assertions: params.Assertions, Extract: params.Extract}
+	runs, err := t.suite.runForEach(test, params.ForEach, 1, 1, t.suite.sharedTools())
+	for name, value := range vars.PopScope() {
+		if name != as && name != as+"_index" && !strings.HasPrefix(name, as+".") {
+			vars.Set(name, value)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return formatIteration(runs, as), nil
+}
+
+// formatIteration lists the result for each element
+func formatIteration(runs []forEachRun, as string) string {
+	passed := 0
+	for _, run := range runs {
+		if run.result.Passed {
+			passed++
+		}
+	}
+
+	var sb strings.Builder
+	if passed == len(runs) {
+		sb.WriteString(fmt.Sprintf("✓ Iterated over %d element(s), all passed\n\n", len(runs)))
+	} else {
+		sb.WriteString(fmt.Sprintf("✗ Iterated over %d element(s): %d passed, %d failed\n\n", len(runs), passed, len(runs)-passed))
+	}
+	for i, run := range runs {
+		r := run.result
+		if r.Passed {
+			sb.WriteString(fmt.Sprintf("%d. ✓ %s = %s -> %d (%v)\n", i+1, as, run.element, r.StatusCode, r.Duration.Round(time.Millisecond)))
+		} else {
+			sb.WriteString(fmt.Sprintf("%d. ✗ %s = %s -> %d: %s\n", i+1, as, run.element, r.StatusCode, r.Error))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,504 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPath queries: wildcards ($.items[*].id), recursive descent ($..id),
+// negative indexes and slices ($.items[-1], $.items[0:5:2]), unions
+// ($.items[0,2], $['a','b']) and filters ($.items[?(@.status == "active")]).
+// Filters are script expressions in which @ is the element being tested and
+// $ the whole body, so they can use and/or/not, contains(), matches(), len()
+// and the other script functions. A filter that is only a path, such as
+// [?@.isbn] or [?!@.isbn], tests whether the path exists, as in RFC 9535.
+
+// Selector kinds of a JSONPath segment
+const (
+	jsonPathName = iota
+	jsonPathWildcard
+	jsonPathIndex
+	jsonPathSlice
+	jsonPathFilter
+)
+
+type jsonPathSelector struct {
+	kind   int
+	name   string
+	index  int
+	slice  [3]*int         // start, end, step
+	filter string          // Script expression
+	exists *jsonPathExists // Set when the filter only tests that a path exists
+}
+
+// jsonPathExists is an existence test: @.b, $.b or !@.b
+type jsonPathExists struct {
+	root     bool // $ rather than @
+	negate   bool
+	segments []jsonPathSegment
+}
+
+// jsonPathSegment is one step of a path: .name, [selectors] or ..name
+type jsonPathSegment struct {
+	recursive bool
+	selectors []jsonPathSelector
+}
+
+// filterCurrent and filterRoot stand for @ and $ in filter expressions
+const (
+	filterCurrent = "__current"
+	filterRoot    = "__root"
+)
+
+// filterStage is the stage of the script environment filters run in
+const filterStage = "filter"
+
+// isJSONPathQuery reports whether path needs queryJSONPath rather than a
+// plain field and index lookup
+func isJSONPathQuery(path string) bool {
+	return strings.ContainsAny(path, "*?:,'\"") || strings.Contains(path, "..") || strings.Contains(path, "[-")
+}
+
+// evalJSONPathQuery returns the value a definite path (one field or index
+// per step) points to, or the list of every match of any other query, which
+// may be empty
+func evalJSONPathQuery(body interface{}, path string) (interface{}, error) {
+	matches, definite, err := queryJSONPath(body, path)
+	if err != nil {
+		return nil, err
+	}
+	if !definite {
+		if matches == nil {
+			matches = []interface{}{}
+		}
+		return matches, nil
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("nothing found at '%s'", path)
+	}
+	return matches[0], nil
+}
+
+// queryJSONPath returns every value path matches in body, in order, and
+// whether path is definite
+func queryJSONPath(body interface{}, path string) ([]interface{}, bool, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid JSONPath '%s': %w", path, err)
+	}
+
+	definite := true
+	for _, seg := range segments {
+		if seg.recursive || len(seg.selectors) != 1 ||
+			seg.selectors[0].kind != jsonPathName && seg.selectors[0].kind != jsonPathIndex {
+			definite = false
+		}
+	}
+	return runJSONPath(body, body, segments), definite, nil
+}
+
+// runJSONPath applies segments to node; filters see root as $
+func runJSONPath(node, root interface{}, segments []jsonPathSegment) []interface{} {
+	nodes := []interface{}{node}
+	for _, seg := range segments {
+		var next []interface{}
+		for _, node := range nodes {
+			targets := []interface{}{node}
+			if seg.recursive {
+				targets = jsonDescendants(node, nil)
+			}
+			for _, target := range targets {
+				for _, sel := range seg.selectors {
+					next = append(next, sel.apply(target, root)...)
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// parseJSONPath splits a path into segments. The leading $ is optional.
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	path = strings.TrimSpace(path)
+	switch {
+	case strings.HasPrefix(path, "$"):
+		path = path[1:]
+	case strings.HasPrefix(path, "["), strings.HasPrefix(path, "."):
+	default:
+		path = "." + path
+	}
+
+	var segments []jsonPathSegment
+	for i := 0; i < len(path); {
+		seg := jsonPathSegment{}
+		switch {
+		case strings.HasPrefix(path[i:], ".."):
+			seg.recursive = true
+			i += 2
+			if i < len(path) && path[i] == '[' {
+				break
+			}
+			sel, n, err := parseDotSelector(path[i:])
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = []jsonPathSelector{sel}
+			i += n
+		case path[i] == '.':
+			i++
+			sel, n, err := parseDotSelector(path[i:])
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = []jsonPathSelector{sel}
+			i += n
+		case path[i] == '[':
+		default:
+			return nil, fmt.Errorf("unexpected '%c' at %d", path[i], i)
+		}
+
+		if seg.selectors == nil {
+			sels, n, err := parseBracket(path[i:])
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = sels
+			i += n
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// parseDotSelector reads the name or * after a dot
+func parseDotSelector(s string) (jsonPathSelector, int, error) {
+	if strings.HasPrefix(s, "*") {
+		return jsonPathSelector{kind: jsonPathWildcard}, 1, nil
+	}
+	n := strings.IndexAny(s, ".[")
+	if n < 0 {
+		n = len(s)
+	}
+	if n == 0 {
+		return jsonPathSelector{}, 0, fmt.Errorf("expected a field name after '.'")
+	}
+	return jsonPathSelector{kind: jsonPathName, name: s[:n]}, n, nil
+}
+
+// parseBracket reads [selector, selector, ...] at the start of s
+func parseBracket(s string) ([]jsonPathSelector, int, error) {
+	var sels []jsonPathSelector
+	i := 1
+	for {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i >= len(s) {
+			return nil, 0, fmt.Errorf("missing ']'")
+		}
+		switch c := s[i]; {
+		case c == '\'' || c == '"':
+			name, n, err := lexString(s[i:])
+			if err != nil {
+				return nil, 0, err
+			}
+			sels = append(sels, jsonPathSelector{kind: jsonPathName, name: name})
+			i += n
+		case c == '*':
+			sels = append(sels, jsonPathSelector{kind: jsonPathWildcard})
+			i++
+		case c == '?':
+			expr, n, err := scanFilter(s[i+1:])
+			if err != nil {
+				return nil, 0, err
+			}
+			sels = append(sels, jsonPathSelector{kind: jsonPathFilter, filter: expr, exists: parseExistenceTest(s[i+1 : i+1+n])})
+			i += 1 + n
+		default:
+			end := strings.IndexAny(s[i:], ",]")
+			if end < 0 {
+				return nil, 0, fmt.Errorf("missing ']'")
+			}
+			sel, err := parseIndexOrSlice(strings.TrimSpace(s[i : i+end]))
+			if err != nil {
+				return nil, 0, err
+			}
+			sels = append(sels, sel)
+			i += end
+		}
+
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i >= len(s) {
+			return nil, 0, fmt.Errorf("missing ']'")
+		}
+		if s[i] == ']' {
+			return sels, i + 1, nil
+		}
+		if s[i] != ',' {
+			return nil, 0, fmt.Errorf("expected ',' or ']', got '%c'", s[i])
+		}
+		i++
+	}
+}
+
+// parseIndexOrSlice parses 3, -1 or start:end:step
+func parseIndexOrSlice(s string) (jsonPathSelector, error) {
+	if !strings.Contains(s, ":") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return jsonPathSelector{}, fmt.Errorf("invalid index '%s'", s)
+		}
+		return jsonPathSelector{kind: jsonPathIndex, index: n}, nil
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return jsonPathSelector{}, fmt.Errorf("invalid slice '%s'", s)
+	}
+	sel := jsonPathSelector{kind: jsonPathSlice}
+	for i, part := range parts {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return jsonPathSelector{}, fmt.Errorf("invalid slice '%s'", s)
+		}
+		sel.slice[i] = &n
+	}
+	if step := sel.slice[2]; step != nil && *step == 0 {
+		return jsonPathSelector{}, fmt.Errorf("slice step can't be 0")
+	}
+	return sel, nil
+}
+
+// scanFilter reads a filter expression up to the ']' or ',' that ends it and
+// returns it as a script expression, with @ and $ replaced
+func scanFilter(s string) (string, int, error) {
+	var sb strings.Builder
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"':
+			_, n, err := lexString(s[i:])
+			if err != nil {
+				return "", 0, err
+			}
+			sb.WriteString(s[i : i+n])
+			i += n - 1
+		case '(', '[', '{':
+			depth++
+			sb.WriteByte(c)
+		case ')', '}':
+			depth--
+			sb.WriteByte(c)
+		case ']', ',':
+			if depth == 0 {
+				expr := strings.TrimSpace(sb.String())
+				if expr == "" {
+					return "", 0, fmt.Errorf("empty filter")
+				}
+				return expr, i, checkFilter(expr)
+			}
+			if c == ']' {
+				depth--
+			}
+			sb.WriteByte(c)
+		case '@':
+			sb.WriteString(filterCurrent)
+		case '$':
+			sb.WriteString(filterRoot)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("missing ']' after filter")
+}
+
+// existenceTest matches a filter that is only a path: @ or $ then names
+// and brackets, maybe negated
+var existenceTest = regexp.MustCompile(`^(!|not\s)?\s*([@$])((?:\.[A-Za-z_][\w-]*|\[[^\[\]]*\])*)$`)
+
+// parseExistenceTest returns the existence test filter is, or nil when it
+// is any other expression
+func parseExistenceTest(filter string) *jsonPathExists {
+	filter = strings.TrimSpace(filter)
+	if strings.HasPrefix(filter, "(") && strings.HasSuffix(filter, ")") {
+		filter = strings.TrimSpace(filter[1 : len(filter)-1])
+	}
+	m := existenceTest.FindStringSubmatch(filter)
+	if m == nil {
+		return nil
+	}
+	segments, err := parseJSONPath("$" + m[3])
+	if err != nil {
+		return nil
+	}
+	return &jsonPathExists{negate: m[1] != "", root: m[2] == "$", segments: segments}
+}
+
+// checkFilter rejects names a filter can't mean, such as an unquoted string
+func checkFilter(expr string) error {
+	tokens, err := lexScript(expr)
+	if err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	for i, tok := range tokens {
+		if tok.kind != tokIdent || tok.text == filterCurrent || tok.text == filterRoot {
+			continue
+		}
+		if i > 0 && tokens[i-1].kind == tokOp && tokens[i-1].text == "." {
+			continue
+		}
+		if i+1 < len(tokens) && tokens[i+1].kind == tokOp && tokens[i+1].text == "(" {
+			if _, ok := scriptFuncs[tok.text]; !ok {
+				return fmt.Errorf("unknown function '%s' in filter", tok.text)
+			}
+			continue
+		}
+		if _, ok := scriptConstants[tok.text]; !ok {
+			return fmt.Errorf("unknown name '%s' in filter (use @ for the element and quote strings)", tok.text)
+		}
+	}
+	return nil
+}
+
+// apply returns what sel selects from node
+func (sel jsonPathSelector) apply(node, root interface{}) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		switch sel.kind {
+		case jsonPathName:
+			if value, ok := v[sel.name]; ok {
+				return []interface{}{value}
+			}
+		case jsonPathWildcard:
+			return objectValues(v)
+		case jsonPathFilter:
+			return filterJSON(objectValues(v), sel, root)
+		}
+	case []interface{}:
+		switch sel.kind {
+		case jsonPathWildcard:
+			return v
+		case jsonPathIndex:
+			i := sel.index
+			if i < 0 {
+				i += len(v)
+			}
+			if i >= 0 && i < len(v) {
+				return []interface{}{v[i]}
+			}
+		case jsonPathSlice:
+			return sliceJSON(v, sel.slice)
+		case jsonPathFilter:
+			return filterJSON(v, sel, root)
+		}
+	}
+	return nil
+}
+
+// objectValues returns an object's values ordered by key
+func objectValues(obj map[string]interface{}) []interface{} {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		values[i] = obj[key]
+	}
+	return values
+}
+
+// jsonDescendants returns node and everything inside it, depth first
+func jsonDescendants(node interface{}, out []interface{}) []interface{} {
+	out = append(out, node)
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for _, value := range objectValues(v) {
+			out = jsonDescendants(value, out)
+		}
+	case []interface{}:
+		for _, value := range v {
+			out = jsonDescendants(value, out)
+		}
+	}
+	return out
+}
+
+// sliceJSON applies start:end:step to a list, as Python does
+func sliceJSON(list []interface{}, bounds [3]*int) []interface{} {
+	n := len(list)
+	step := 1
+	if bounds[2] != nil {
+		step = *bounds[2]
+	}
+	normalize := func(i int) int {
+		if i < 0 {
+			i += n
+		}
+		if step > 0 {
+			return max(0, min(i, n))
+		}
+		return max(-1, min(i, n-1))
+	}
+
+	var out []interface{}
+	if step > 0 {
+		start, end := 0, n
+		if bounds[0] != nil {
+			start = normalize(*bounds[0])
+		}
+		if bounds[1] != nil {
+			end = normalize(*bounds[1])
+		}
+		for i := start; i < end; i += step {
+			out = append(out, list[i])
+		}
+		return out
+	}
+	start, end := n-1, -1
+	if bounds[0] != nil {
+		start = normalize(*bounds[0])
+	}
+	if bounds[1] != nil {
+		end = normalize(*bounds[1])
+	}
+	for i := start; i > end; i += step {
+		out = append(out, list[i])
+	}
+	return out
+}
+
+// filterJSON keeps the candidates sel's filter is true for. A filter that
+// fails on an element, e.g. adding text to an object, doesn't match it.
+func filterJSON(candidates []interface{}, sel jsonPathSelector, root interface{}) []interface{} {
+	var out []interface{}
+	for _, candidate := range candidates {
+		if test := sel.exists; test != nil {
+			node := candidate
+			if test.root {
+				node = root
+			}
+			if found := len(runJSONPath(node, root, test.segments)) > 0; found != test.negate {
+				out = append(out, candidate)
+			}
+			continue
+		}
+		env := newScriptEnv(filterStage, nil)
+		env.locals[filterCurrent] = candidate
+		env.locals[filterRoot] = root
+		value, err := evalScriptExpr(sel.filter, env)
+		if err == nil && truthy(value) {
+			out = append(out, candidate)
+		}
+	}
+	return out
+}
@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// The documents below are the examples of RFC 9535. Object members come out
+// ordered by key, one of the orders the RFC allows.
+
+const jsonPathBookstore = `{"store": {
+	"book": [
+		{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+		{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+		{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+		{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+	],
+	"bicycle": {"color": "red", "price": 399}
+}}`
+
+const (
+	jsonPathBook0 = `{"author":"Nigel Rees","category":"reference","price":8.95,"title":"Sayings of the Century"}`
+	jsonPathBook1 = `{"author":"Evelyn Waugh","category":"fiction","price":12.99,"title":"Sword of Honour"}`
+	jsonPathBook2 = `{"author":"Herman Melville","category":"fiction","isbn":"0-553-21311-3","price":8.99,"title":"Moby Dick"}`
+	jsonPathBook3 = `{"author":"J. R. R. Tolkien","category":"fiction","isbn":"0-395-19395-8","price":22.99,"title":"The Lord of the Rings"}`
+)
+
+func TestQueryJSONPath(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		path string
+		want string // JSON list of the matches
+	}{
+		// Table 2: the bookstore
+		{"authors of all books", jsonPathBookstore, "$.store.book[*].author", `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{"all authors", jsonPathBookstore, "$..author", `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{"everything in the store", jsonPathBookstore, "$.store.*", `[{"color":"red","price":399},[` + jsonPathBook0 + `,` + jsonPathBook1 + `,` + jsonPathBook2 + `,` + jsonPathBook3 + `]]`},
+		{"all prices in the store", jsonPathBookstore, "$.store..price", `[399,8.95,12.99,8.99,22.99]`},
+		{"third book", jsonPathBookstore, "$..book[2]", `[` + jsonPathBook2 + `]`},
+		{"third book's author", jsonPathBookstore, "$..book[2].author", `["Herman Melville"]`},
+		{"empty result", jsonPathBookstore, "$..book[2].publisher", `[]`},
+		{"last book", jsonPathBookstore, "$..book[-1]", `[` + jsonPathBook3 + `]`},
+		{"first two books by index", jsonPathBookstore, "$..book[0,1]", `[` + jsonPathBook0 + `,` + jsonPathBook1 + `]`},
+		{"first two books by slice", jsonPathBookstore, "$..book[:2]", `[` + jsonPathBook0 + `,` + jsonPathBook1 + `]`},
+		{"books with an isbn", jsonPathBookstore, "$..book[?@.isbn]", `[` + jsonPathBook2 + `,` + jsonPathBook3 + `]`},
+		{"parenthesized existence", jsonPathBookstore, "$..book[?(@.isbn)].title", `["Moby Dick","The Lord of the Rings"]`},
+		{"books without an isbn", jsonPathBookstore, "$..book[?not @.isbn].title", `["Sayings of the Century","Sword of Honour"]`},
+		{"books cheaper than 10", jsonPathBookstore, "$..book[?@.price<10]", `[` + jsonPathBook0 + `,` + jsonPathBook2 + `]`},
+		{"parenthesized filter", jsonPathBookstore, "$..book[?(@.price < 10)].title", `["Sayings of the Century","Moby Dick"]`},
+
+		// 2.3.1.3: name selector
+		{"quoted name", `{"o": {"j j": {"k.k": 3}}, "'": {"@": 2}}`, "$.o['j j']", `[{"k.k":3}]`},
+		{"quoted names", `{"o": {"j j": {"k.k": 3}}, "'": {"@": 2}}`, "$.o['j j']['k.k']", `[3]`},
+		{"double-quoted names", `{"o": {"j j": {"k.k": 3}}, "'": {"@": 2}}`, `$.o["j j"]["k.k"]`, `[3]`},
+		{"quote and at as names", `{"o": {"j j": {"k.k": 3}}, "'": {"@": 2}}`, `$["'"]["@"]`, `[2]`},
+
+		// 2.3.2.3: wildcard selector
+		{"root wildcard", `{"o": {"j": 1, "k": 2}, "a": [5, 3]}`, "$[*]", `[[5,3],{"j":1,"k":2}]`},
+		{"object wildcard", `{"o": {"j": 1, "k": 2}, "a": [5, 3]}`, "$.o[*]", `[1,2]`},
+		{"repeated wildcard", `{"o": {"j": 1, "k": 2}, "a": [5, 3]}`, "$.o[*, *]", `[1,2,1,2]`},
+		{"array wildcard", `{"o": {"j": 1, "k": 2}, "a": [5, 3]}`, "$.a[*]", `[5,3]`},
+
+		// 2.3.3.3: index selector
+		{"index", `["a", "b"]`, "$[1]", `["b"]`},
+		{"negative index", `["a", "b"]`, "$[-2]", `["a"]`},
+		{"index out of range", `["a", "b"]`, "$[2]", `[]`},
+
+		// 2.3.4.3: array slice selector
+		{"slice with start and end", `["a", "b", "c", "d", "e", "f", "g"]`, "$[1:3]", `["b","c"]`},
+		{"slice with no end", `["a", "b", "c", "d", "e", "f", "g"]`, "$[5:]", `["f","g"]`},
+		{"slice with step", `["a", "b", "c", "d", "e", "f", "g"]`, "$[1:5:2]", `["b","d"]`},
+		{"slice with negative step", `["a", "b", "c", "d", "e", "f", "g"]`, "$[5:1:-2]", `["f","d"]`},
+		{"slice in reverse", `["a", "b", "c", "d", "e", "f", "g"]`, "$[::-1]", `["g","f","e","d","c","b","a"]`},
+
+		// 2.3.5.3: filter selector
+		{"member value comparison", jsonPathFilterDoc, "$.a[?@.b == 'kilo']", `[{"b":"kilo"}]`},
+		{"parenthesized comparison", jsonPathFilterDoc, "$.a[?(@.b == 'kilo')]", `[{"b":"kilo"}]`},
+		{"array value comparison", jsonPathFilterDoc, "$.a[?@>3.5]", `[5,4,6]`},
+		{"array value existence", jsonPathFilterDoc, "$.a[?@.b]", `[{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]`},
+		{"existence of a falsy member", `[{"b": 0}, {"b": false}, {"b": null}, {"b": ""}, {"c": 1}]`, "$[?@.b]", `[{"b":0},{"b":false},{"b":null},{"b":""}]`},
+		{"negated existence", jsonPathFilterDoc, "$.a[?!@.b]", `[3,5,1,2,4,6]`},
+		{"root existence", jsonPathFilterDoc, "$.a[?$.e]", `[3,5,1,2,4,6,{"b":"j"},{"b":"k"},{"b":{}},{"b":"kilo"}]`},
+		{"filters in a union", jsonPathFilterDoc, "$.o[?@<3, ?@<3]", `[1,2,1,2]`},
+		{"logical or", jsonPathFilterDoc, `$.a[?@<2 || @.b == "k"]`, `[1,{"b":"k"}]`},
+		{"regular expression", jsonPathFilterDoc, `$.a[?matches(@.b, "^[jk]$")]`, `[{"b":"j"},{"b":"k"}]`},
+		{"logical and on object values", jsonPathFilterDoc, "$.o[?@>1 && @<4]", `[2,3]`},
+		{"logical or with member existence", jsonPathFilterDoc, "$.o[?@.u || @.x]", `[{"u":6}]`},
+		{"root comparison", jsonPathFilterDoc, "$.a[?@ == $.a[1]]", `[5]`},
+
+		// 2.5.2.3: descendant segment
+		{"descendant name", jsonPathDescendantDoc, "$..j", `[4,1]`},
+		{"descendant index", jsonPathDescendantDoc, "$..[0]", `[5,{"j":4}]`},
+		{"descendant object", jsonPathDescendantDoc, "$..o", `[{"j":1,"k":2}]`},
+		{"descendant repeated wildcard", jsonPathDescendantDoc, "$.o..[*, *]", `[1,2,1,2]`},
+		{"descendant union", jsonPathDescendantDoc, "$.a..[0, 1]", `[5,3,{"j":4},{"k":6}]`},
+
+		// 2.6.1: null semantics
+		{"null value", jsonPathNullDoc, "$.a", `[null]`},
+		{"index into null", jsonPathNullDoc, "$.a[0]", `[]`},
+		{"member of null", jsonPathNullDoc, "$.a.d", `[]`},
+		{"null element", jsonPathNullDoc, "$.b[0]", `[null]`},
+		{"wildcard over null", jsonPathNullDoc, "$.b[*]", `[null]`},
+		{"existence of null", jsonPathNullDoc, "$.b[?@]", `[null]`},
+		{"comparison with null", jsonPathNullDoc, "$.b[?@==null]", `[null]`},
+		{"member named null", jsonPathNullDoc, "$.null", `[1]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc interface{}
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatalf("invalid document: %v", err)
+			}
+			matches, _, err := queryJSONPath(doc, tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if matches == nil {
+				matches = []interface{}{}
+			}
+			got, _ := json.Marshal(matches)
+			if string(got) != tt.want {
+				t.Errorf("%s\ngot  %s\nwant %s", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+const (
+	jsonPathFilterDoc     = `{"a": [3, 5, 1, 2, 4, 6, {"b": "j"}, {"b": "k"}, {"b": {}}, {"b": "kilo"}], "o": {"p": 1, "q": 2, "r": 3, "s": 5, "t": {"u": 6}}, "e": "f"}`
+	jsonPathDescendantDoc = `{"o": {"j": 1, "k": 2}, "a": [5, 3, [{"j": 4}, {"k": 6}]]}`
+	jsonPathNullDoc       = `{"a": null, "b": [null], "c": [{}], "null": 1}`
+)
+
+func TestQueryJSONPath_Definite(t *testing.T) {
+	tests := []struct {
+		path     string
+		definite bool
+	}{
+		{"$.store.book[0].title", true},
+		{"store.bicycle.color", true},
+		{"$['store']['bicycle']", true},
+		{"$.store.book[-1]", true},
+		{"$.store.book[*]", false},
+		{"$..price", false},
+		{"$.store.book[0,1]", false},
+		{"$.store.book[:1]", false},
+		{"$.store.book[?@.isbn]", false},
+	}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(jsonPathBookstore), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, definite, err := queryJSONPath(doc, tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if definite != tt.definite {
+				t.Errorf("definite = %v, want %v", definite, tt.definite)
+			}
+		})
+	}
+}
+
+func TestQueryJSONPath_Errors(t *testing.T) {
+	tests := []struct {
+		path   string
+		errMsg string
+	}{
+		{"$.store.", "expected a field name after '.'"},
+		{"$.store[0", "missing ']'"},
+		{"$[0 1]", "invalid index"},
+		{"$[a]", "invalid index 'a'"},
+		{"$[1:2:3:4]", "invalid slice"},
+		{"$[::0]", "slice step can't be 0"},
+		{"$['a]", "unterminated string"},
+		{"$[?]", "empty filter"},
+		{"$[?@.a == 1", "missing ']' after filter"},
+		{"$[?@.a == active]", "unknown name 'active' in filter"},
+		{"$[?length(@) > 1]", "unknown function 'length' in filter"},
+		{"$x", "unexpected 'x'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, _, err := queryJSONPath(map[string]interface{}{}, tt.path)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tt.errMsg)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
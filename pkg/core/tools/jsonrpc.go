@@ -0,0 +1,269 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// JSONRPCTool sends JSON-RPC 2.0 calls over HTTP, building the envelope and
+// matching responses to requests by id.
+type JSONRPCTool struct {
+	httpTool        *HTTPTool
+	responseManager *ResponseManager
+	varStore        *VariableStore
+	nextID          atomic.Int64
+}
+
+// NewJSONRPCTool creates a new JSON-RPC tool.
+func NewJSONRPCTool(httpTool *HTTPTool, responseManager *ResponseManager, varStore *VariableStore) *JSONRPCTool {
+	return &JSONRPCTool{
+		httpTool:        httpTool,
+		responseManager: responseManager,
+		varStore:        varStore,
+	}
+}
+
+// JSONRPCCall is one method call; a nil ID is assigned automatically.
+type JSONRPCCall struct {
+	Method       string          `json:"method"`
+	Params       json.RawMessage `json:"params,omitempty"` // Object or array
+	ID           interface{}     `json:"id,omitempty"`
+	Notification bool            `json:"notification,omitempty"` // Send without an id; no response expected
+}
+
+// JSONRPCParams defines the JSON-RPC request parameters.
+type JSONRPCParams struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Timeout int               `json:"timeout,omitempty"`
+	JSONRPCCall
+	Batch []JSONRPCCall `json:"batch,omitempty"` // Send several calls in one batch instead
+}
+
+// JSONRPCError is a JSON-RPC error object.
+type JSONRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// jsonRPCResponse is one response object.
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+}
+
+// jsonRPCErrorNames are the error codes reserved by the JSON-RPC 2.0 spec.
+var jsonRPCErrorNames = map[int]string{
+	-32700: "Parse error",
+	-32600: "Invalid Request",
+	-32601: "Method not found",
+	-32602: "Invalid params",
+	-32603: "Internal error",
+}
+
+// Name returns the tool name.
+func (t *JSONRPCTool) Name() string {
+	return "jsonrpc_request"
+}
+
+// Description returns the tool description.
+func (t *JSONRPCTool) Description() string {
+	return "Call a JSON-RPC 2.0 method over HTTP. Builds the {jsonrpc, method, params, id} envelope, matches responses by id, and reports JSON-RPC error objects (code, message, data) separately from HTTP/transport failures. Use 'batch' to send several calls at once."
+}
+
+// Parameters returns the tool parameter description.
+func (t *JSONRPCTool) Parameters() string {
+	return `{
+  "url": "string (required) - e.g. {{BASE_URL}}/rpc",
+  "method": "user.get",
+  "params": {"id": 42},
+  "id": "optional (auto-assigned)",
+  "notification": false,
+  "batch": [{"method": "a"}, {"method": "b", "params": [1, 2]}],
+  "headers": {"Authorization": "Bearer {{TOKEN}}"},
+  "timeout": 30
+}`
+}
+
+// Execute sends the call(s) and reports the matched results.
+func (t *JSONRPCTool) Execute(args string) (string, error) {
+	if t.varStore != nil {
+		args = t.varStore.Substitute(args)
+	}
+
+	var params JSONRPCParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	if params.URL == "" {
+		return "", fmt.Errorf("'url' is required")
+	}
+
+	calls := params.Batch
+	if len(calls) == 0 {
+		if params.Method == "" {
+			return "", fmt.Errorf("'method' or 'batch' is required")
+		}
+		calls = []JSONRPCCall{params.JSONRPCCall}
+	}
+
+	// Build the envelopes, remembering the id of each call
+	envelopes := make([]map[string]interface{}, len(calls))
+	ids := make([]string, len(calls))
+	for i, call := range calls {
+		if call.Method == "" {
+			return "", fmt.Errorf("batch[%d]: 'method' is required", i)
+		}
+		envelope := map[string]interface{}{"jsonrpc": "2.0", "method": call.Method}
+		if len(call.Params) > 0 {
+			trimmed := bytes.TrimSpace(call.Params)
+			if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+				return "", fmt.Errorf("params of %s must be an object or an array", call.Method)
+			}
+			envelope["params"] = call.Params
+		}
+		if !call.Notification {
+			id := call.ID
+			if id == nil {
+				id = t.nextID.Add(1)
+			}
+			envelope["id"] = id
+			idJSON, _ := json.Marshal(id)
+			ids[i] = string(idJSON)
+		}
+		envelopes[i] = envelope
+	}
+
+	var body interface{} = envelopes
+	if len(params.Batch) == 0 {
+		body = envelopes[0]
+	}
+	resp, err := t.httpTool.Run(HTTPRequest{
+		Method:  "POST",
+		URL:     params.URL,
+		Headers: params.Headers,
+		Body:    body,
+		Timeout: params.Timeout,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// The raw JSON-RPC response stays available to assert_response / extract_value
+	if t.responseManager != nil {
+		t.responseManager.SetHTTPResponse(resp)
+	}
+
+	expectsResponse := false
+	for _, id := range ids {
+		expectsResponse = expectsResponse || id != ""
+	}
+	trimmed := strings.TrimSpace(resp.Body)
+	if !expectsResponse && trimmed == "" {
+		return fmt.Sprintf("Notification sent (%s, no response expected)", resp.Status), nil
+	}
+	if resp.StatusCode >= 300 && !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return "Transport error: the server did not return a JSON-RPC response.\n\n" + resp.FormatResponse(), nil
+	}
+
+	var responses []jsonRPCResponse
+	if strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal([]byte(trimmed), &responses)
+	} else {
+		var single jsonRPCResponse
+		err = json.Unmarshal([]byte(trimmed), &single)
+		responses = []jsonRPCResponse{single}
+	}
+	if err != nil {
+		return "Transport error: the response is not valid JSON-RPC.\n\n" + resp.FormatResponse(), nil
+	}
+
+	return formatJSONRPCResults(calls, ids, responses, resp), nil
+}
+
+// formatJSONRPCResults pairs each call with its response by id and renders
+// results and JSON-RPC errors.
+func formatJSONRPCResults(calls []JSONRPCCall, ids []string, responses []jsonRPCResponse, resp *HTTPResponse) string {
+	byID := make(map[string]jsonRPCResponse, len(responses))
+	var unmatched []jsonRPCResponse
+	for _, r := range responses {
+		id := string(bytes.TrimSpace(r.ID))
+		if id == "" || id == "null" {
+			// Errors for requests whose id couldn't be read (e.g. parse errors)
+			unmatched = append(unmatched, r)
+			continue
+		}
+		byID[id] = r
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("HTTP %s in %dms\n", resp.Status, resp.Duration.Milliseconds()))
+
+	errorCount := 0
+	for i, call := range calls {
+		sb.WriteString(fmt.Sprintf("\n%s", call.Method))
+		if ids[i] == "" {
+			sb.WriteString(" (notification)\n")
+			continue
+		}
+		sb.WriteString(fmt.Sprintf(" [id %s]\n", ids[i]))
+
+		r, ok := byID[ids[i]]
+		switch {
+		case !ok:
+			sb.WriteString("  ✗ No response with this id\n")
+			errorCount++
+		case r.Error != nil:
+			sb.WriteString("  " + formatJSONRPCError(r.Error))
+			errorCount++
+		default:
+			sb.WriteString("  ✓ Result: " + truncateBody(formatJSONRPCValue(r.Result, "  "), 2000) + "\n")
+		}
+	}
+
+	for _, r := range unmatched {
+		if r.Error != nil {
+			sb.WriteString("\nResponse without id\n  " + formatJSONRPCError(r.Error))
+			errorCount++
+		}
+	}
+
+	if errorCount > 0 {
+		sb.WriteString(fmt.Sprintf("\n%d JSON-RPC error(s). These are application errors returned by the server, not transport failures.\n", errorCount))
+	}
+	return sb.String()
+}
+
+// formatJSONRPCError renders an error object, naming reserved codes.
+func formatJSONRPCError(e *JSONRPCError) string {
+	line := fmt.Sprintf("✗ JSON-RPC error %d: %s", e.Code, e.Message)
+	if name, ok := jsonRPCErrorNames[e.Code]; ok && !strings.EqualFold(name, e.Message) {
+		line += fmt.Sprintf(" (%s)", name)
+	} else if e.Code <= -32000 && e.Code >= -32099 {
+		line += " (server error)"
+	}
+	line += "\n"
+	if len(e.Data) > 0 {
+		line += "    data: " + truncateBody(formatJSONRPCValue(e.Data, "    "), 1000) + "\n"
+	}
+	return line
+}
+
+// formatJSONRPCValue pretty-prints a raw JSON value, indenting continuation
+// lines by prefix.
+func formatJSONRPCValue(raw json.RawMessage, prefix string) string {
+	if len(raw) == 0 {
+		return "null"
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, raw, prefix, "  "); err != nil {
+		return string(raw)
+	}
+	return out.String()
+}
@@ -0,0 +1,478 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Defaults for login flows
+const (
+	DefaultLoginSaveAs = "session"
+	DefaultCSRFHeader  = "X-CSRF-Token"
+	loginFlowsDir      = "login_flows"
+)
+
+// commonCSRFFields are hidden input names checked when csrf_field isn't set
+// (Django, Rails, Laravel, Spring, generic)
+var commonCSRFFields = []string{"csrfmiddlewaretoken", "authenticity_token", "_token", "_csrf", "csrf_token", "csrf", "__RequestVerificationToken"}
+
+var (
+	inputTagRe       = regexp.MustCompile(`(?is)<input\b[^>]*>`)
+	metaTagRe        = regexp.MustCompile(`(?is)<meta\b[^>]*>`)
+	formTagRe        = regexp.MustCompile(`(?is)<form\b[^>]*>`)
+	htmlAttrRe       = regexp.MustCompile(`(?is)([a-z_:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	csrfMetaRe       = regexp.MustCompile(`(?i)^(csrf-token|_csrf|csrf_token|xsrf-token)$`)
+	setCookieStartRe = regexp.MustCompile(`^\s*[^;=\s]+=`)
+)
+
+// LoginFlow describes a form login: GET the form page, extract the CSRF
+// token, POST the credentials and keep the session cookie. Saved flows keep
+// their {{VAR}} placeholders, so credentials stay in variables.
+type LoginFlow struct {
+	Name          string            `json:"name,omitempty"`
+	FormURL       string            `json:"form_url"`                 // Page with the login form
+	SubmitURL     string            `json:"submit_url,omitempty"`     // Default: the form's action, else form_url
+	Method        string            `json:"method,omitempty"`         // Submit method (default POST)
+	Fields        map[string]string `json:"fields"`                   // Credentials and other form fields
+	Headers       map[string]string `json:"headers,omitempty"`        // Extra headers for both requests
+	JSON          bool              `json:"json,omitempty"`           // Submit fields as JSON instead of a form
+	CSRFField     string            `json:"csrf_field,omitempty"`     // Hidden input name; common names are detected if empty
+	CSRFRegex     string            `json:"csrf_regex,omitempty"`     // Or a regex whose first group is the token
+	CSRFCookie    string            `json:"csrf_cookie,omitempty"`    // Or a cookie holding the token (e.g. XSRF-TOKEN)
+	CSRFHeader    string            `json:"csrf_header,omitempty"`    // Also send the token in this header
+	SessionCookie string            `json:"session_cookie,omitempty"` // Cookie that proves the login succeeded
+	SaveAs        string            `json:"save_as,omitempty"`        // Variable for the Cookie header (default "session")
+}
+
+// LoginStep is the outcome of one login flow step
+type LoginStep struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// LoginResult is the outcome of a login flow
+type LoginResult struct {
+	Steps     []LoginStep
+	Cookie    string // Cookie header value for later requests
+	CSRFToken string
+	Response  *HTTPResponse // Response to the credentials request
+}
+
+// Failed returns the first failed step, or nil
+func (r *LoginResult) Failed() *LoginStep {
+	for i := range r.Steps {
+		if !r.Steps[i].OK {
+			return &r.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Format renders the steps as a ✓/✗ list
+func (r *LoginResult) Format() string {
+	var sb strings.Builder
+	for _, step := range r.Steps {
+		mark := "✓"
+		if !step.OK {
+			mark = "✗"
+		}
+		sb.WriteString(fmt.Sprintf("%s %s: %s\n", mark, step.Name, step.Detail))
+	}
+	return sb.String()
+}
+
+// RunLoginFlow substitutes variables in flow, performs it with httpTool and
+// saves the session cookie (and CSRF token) as variables. Failures are
+// reported as steps, not errors.
+func RunLoginFlow(httpTool *HTTPTool, varStore *VariableStore, flow LoginFlow) *LoginResult {
+	result := &LoginResult{}
+	fail := func(name, format string, args ...interface{}) *LoginResult {
+		result.Steps = append(result.Steps, LoginStep{Name: name, Detail: fmt.Sprintf(format, args...)})
+		return result
+	}
+	pass := func(name, format string, args ...interface{}) {
+		result.Steps = append(result.Steps, LoginStep{Name: name, OK: true, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	if varStore != nil {
+		data, err := json.Marshal(flow)
+		if err != nil {
+			return fail("form", "failed to marshal login flow: %v", err)
+		}
+		var resolved LoginFlow
+		if err := json.Unmarshal([]byte(varStore.Substitute(string(data))), &resolved); err != nil {
+			return fail("form", "failed to substitute variables: %v", err)
+		}
+		flow = resolved
+	}
+
+	if flow.FormURL == "" {
+		return fail("form", "'form_url' is required")
+	}
+	jar := &cookieJar{}
+
+	// 1. Load the form page
+	formResp, err := httpTool.Run(HTTPRequest{Method: "GET", URL: flow.FormURL, Headers: copyHeaders(flow.Headers)})
+	if err != nil {
+		return fail("form", "%v", err)
+	}
+	if formResp.StatusCode >= 400 {
+		return fail("form", "GET %s returned %s", flow.FormURL, formResp.Status)
+	}
+	jar.addSetCookie(formResp.Headers["Set-Cookie"])
+	pass("form", "GET %s → %d (%d cookies)", flow.FormURL, formResp.StatusCode, len(jar.cookies))
+
+	// The form page may have been reached through redirects
+	pageURL := flow.FormURL
+	if n := len(formResp.Redirects); n > 0 {
+		pageURL = formResp.Redirects[n-1].Location
+	}
+
+	// 2. CSRF token
+	csrfName, csrfToken, source, err := findCSRFToken(flow, formResp.Body, jar)
+	switch {
+	case err != nil:
+		return fail("csrf", "%v", err)
+	case csrfToken == "":
+		pass("csrf", "no CSRF token found on the page (submitting without one)")
+	default:
+		pass("csrf", "found %s (%s)", source, maskValue(csrfToken))
+	}
+	csrfHeader := flow.CSRFHeader
+	if csrfToken != "" && csrfHeader == "" && csrfName == "" {
+		// Tokens from a cookie or meta tag are usually expected in a header
+		csrfHeader = DefaultCSRFHeader
+	}
+
+	// 3. Submit the credentials
+	submitURL := flow.SubmitURL
+	if submitURL == "" {
+		submitURL = formAction(formResp.Body, pageURL)
+	}
+	method := strings.ToUpper(flow.Method)
+	if method == "" {
+		method = "POST"
+	}
+	fields := make(map[string]string, len(flow.Fields)+1)
+	for name, value := range flow.Fields {
+		fields[name] = value
+	}
+	if csrfToken != "" && csrfName != "" {
+		fields[csrfName] = csrfToken
+	}
+
+	headers := copyHeaders(flow.Headers)
+	if cookie := jar.header(); cookie != "" {
+		headers["Cookie"] = cookie
+	}
+	if csrfToken != "" && csrfHeader != "" {
+		headers[csrfHeader] = csrfToken
+	}
+	var body interface{}
+	if flow.JSON {
+		body = fields
+	} else {
+		form := url.Values{}
+		for name, value := range fields {
+			form.Set(name, value)
+		}
+		body = form.Encode()
+		headers["Content-Type"] = "application/x-www-form-urlencoded"
+	}
+
+	// Don't follow the post-login redirect: its Set-Cookie would be lost
+	noRedirects := false
+	submitResp, err := httpTool.Run(HTTPRequest{Method: method, URL: submitURL, Headers: headers, Body: body, FollowRedirects: &noRedirects})
+	if err != nil {
+		return fail("submit", "%v", err)
+	}
+	result.Response = submitResp
+	if submitResp.StatusCode >= 400 {
+		return fail("submit", "%s %s returned %s (wrong credentials or CSRF token?)", method, submitURL, submitResp.Status)
+	}
+	newCookies := jar.addSetCookie(submitResp.Headers["Set-Cookie"])
+	detail := fmt.Sprintf("%s %s → %d", method, submitURL, submitResp.StatusCode)
+	if location := headerValue(submitResp.Headers, "Location"); location != "" {
+		detail += " → " + location
+	}
+	pass("submit", "%s", detail)
+
+	// 4. Session cookie
+	switch {
+	case flow.SessionCookie != "":
+		// The cookie must come from the login response; most frameworks
+		// rotate the session ID on login, so one from the form page proves nothing
+		if !slices.Contains(newCookies, flow.SessionCookie) {
+			return fail("session", "the login response didn't set cookie %q (cookies: %s) - the login was probably rejected", flow.SessionCookie, jar.names())
+		}
+		pass("session", "cookie %q set", flow.SessionCookie)
+	case len(newCookies) == 0:
+		return fail("session", "the login response set no cookies - the login was probably rejected (set 'session_cookie' to check a specific one)")
+	default:
+		pass("session", "cookies set: %s", strings.Join(newCookies, ", "))
+	}
+
+	// 5. Save variables
+	saveAs := flow.SaveAs
+	if saveAs == "" {
+		saveAs = DefaultLoginSaveAs
+	}
+	result.Cookie, result.CSRFToken = jar.header(), csrfToken
+	if varStore != nil {
+		varStore.Set(saveAs, result.Cookie)
+		if csrfToken != "" {
+			varStore.Set(saveAs+"_csrf", csrfToken)
+		}
+	}
+	saved := fmt.Sprintf("{{%s}} (Cookie header)", saveAs)
+	if csrfToken != "" {
+		saved += fmt.Sprintf(", {{%s_csrf}}", saveAs)
+	}
+	pass("saved", "%s", saved)
+	return result
+}
+
+// findCSRFToken returns the form field name (empty when the token should go
+// in a header), the token and where it was found
+func findCSRFToken(flow LoginFlow, body string, jar *cookieJar) (string, string, string, error) {
+	switch {
+	case flow.CSRFCookie != "":
+		token, ok := jar.get(flow.CSRFCookie)
+		if !ok {
+			return "", "", "", fmt.Errorf("cookie %q was not set by the form page (cookies: %s)", flow.CSRFCookie, jar.names())
+		}
+		return flow.CSRFField, token, "cookie " + flow.CSRFCookie, nil
+	case flow.CSRFRegex != "":
+		re, err := regexp.Compile(flow.CSRFRegex)
+		if err != nil {
+			return "", "", "", fmt.Errorf("invalid csrf_regex: %w", err)
+		}
+		match := re.FindStringSubmatch(body)
+		if len(match) < 2 {
+			return "", "", "", fmt.Errorf("csrf_regex %q matched nothing on the form page", flow.CSRFRegex)
+		}
+		return flow.CSRFField, match[1], "csrf_regex match", nil
+	}
+
+	inputs := hiddenInputs(body)
+	if flow.CSRFField != "" {
+		if token, ok := inputs[flow.CSRFField]; ok {
+			return flow.CSRFField, token, "input " + flow.CSRFField, nil
+		}
+		return "", "", "", fmt.Errorf("no input named %q on the form page", flow.CSRFField)
+	}
+	for _, name := range commonCSRFFields {
+		if token, ok := inputs[name]; ok {
+			return name, token, "input " + name, nil
+		}
+	}
+	for _, tag := range metaTagRe.FindAllString(body, -1) {
+		attrs := htmlAttrs(tag)
+		if csrfMetaRe.MatchString(attrs["name"]) && attrs["content"] != "" {
+			return "", attrs["content"], "meta " + attrs["name"], nil
+		}
+	}
+	return "", "", "", nil
+}
+
+// hiddenInputs maps input names to values
+func hiddenInputs(body string) map[string]string {
+	inputs := make(map[string]string)
+	for _, tag := range inputTagRe.FindAllString(body, -1) {
+		attrs := htmlAttrs(tag)
+		if attrs["name"] != "" {
+			inputs[attrs["name"]] = attrs["value"]
+		}
+	}
+	return inputs
+}
+
+// formAction resolves the action of the first form on the page against
+// pageURL, falling back to pageURL itself
+func formAction(body, pageURL string) string {
+	tag := formTagRe.FindString(body)
+	action := htmlAttrs(tag)["action"]
+	if action == "" {
+		return pageURL
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return pageURL
+	}
+	ref, err := url.Parse(action)
+	if err != nil {
+		return pageURL
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// htmlAttrs parses the attributes of a single tag
+func htmlAttrs(tag string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range htmlAttrRe.FindAllStringSubmatch(tag, -1) {
+		attrs[strings.ToLower(m[1])] = html.UnescapeString(m[2] + m[3] + m[4])
+	}
+	return attrs
+}
+
+// cookieJar keeps cookies in the order they were first set
+type cookieJar struct {
+	cookies []jarCookie
+}
+
+type jarCookie struct {
+	name, value string
+}
+
+// addSetCookie stores cookies from a (comma-joined) Set-Cookie header and
+// returns the names that were set
+func (j *cookieJar) addSetCookie(header string) []string {
+	var set []string
+	for _, cookie := range splitSetCookie(header) {
+		nameValue := strings.SplitN(strings.SplitN(cookie, ";", 2)[0], "=", 2)
+		if len(nameValue) != 2 {
+			continue
+		}
+		name, value := strings.TrimSpace(nameValue[0]), strings.TrimSpace(nameValue[1])
+		j.set(name, value)
+		set = append(set, name)
+	}
+	return set
+}
+
+func (j *cookieJar) set(name, value string) {
+	for i := range j.cookies {
+		if j.cookies[i].name == name {
+			j.cookies[i].value = value
+			return
+		}
+	}
+	j.cookies = append(j.cookies, jarCookie{name: name, value: value})
+}
+
+func (j *cookieJar) get(name string) (string, bool) {
+	for _, c := range j.cookies {
+		if c.name == name {
+			return c.value, true
+		}
+	}
+	return "", false
+}
+
+// header returns the Cookie header value, skipping deleted cookies
+func (j *cookieJar) header() string {
+	var pairs []string
+	for _, c := range j.cookies {
+		if c.value != "" {
+			pairs = append(pairs, c.name+"="+c.value)
+		}
+	}
+	return strings.Join(pairs, "; ")
+}
+
+func (j *cookieJar) names() string {
+	if len(j.cookies) == 0 {
+		return "none"
+	}
+	names := make([]string, len(j.cookies))
+	for i, c := range j.cookies {
+		names[i] = c.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// splitSetCookie splits Set-Cookie values joined with ", ". Commas inside
+// Expires dates are kept: a new cookie starts only where "name=" follows.
+func splitSetCookie(header string) []string {
+	var cookies []string
+	for _, part := range strings.Split(header, ",") {
+		if len(cookies) > 0 && !setCookieStartRe.MatchString(part) {
+			cookies[len(cookies)-1] += "," + part
+			continue
+		}
+		if strings.TrimSpace(part) != "" {
+			cookies = append(cookies, strings.TrimSpace(part))
+		}
+	}
+	return cookies
+}
+
+// copyHeaders returns a copy of headers that is safe to modify
+func copyHeaders(headers map[string]string) map[string]string {
+	copied := make(map[string]string, len(headers)+3)
+	for key, value := range headers {
+		copied[key] = value
+	}
+	return copied
+}
+
+// maskValue shows only the start of a secret value
+func maskValue(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return fmt.Sprintf("%s… (%d chars)", value[:4], len(value))
+}
+
+// LoadLoginFlow reads a saved flow from .zap/login_flows/<name>.json
+func LoadLoginFlow(zapDir, name string) (*LoginFlow, error) {
+	data, err := os.ReadFile(filepath.Join(zapDir, loginFlowsDir, name+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("login flow '%s' not found (save one with login_flow and \"save\": true)", name)
+		}
+		return nil, fmt.Errorf("failed to read login flow: %w", err)
+	}
+	var flow LoginFlow
+	if err := json.Unmarshal(data, &flow); err != nil {
+		return nil, fmt.Errorf("invalid login flow file: %w", err)
+	}
+	flow.Name = name
+	return &flow, nil
+}
+
+// SaveLoginFlow writes flow to .zap/login_flows/<name>.json and returns the path
+func SaveLoginFlow(zapDir string, flow LoginFlow) (string, error) {
+	if flow.Name == "" {
+		return "", fmt.Errorf("'name' is required to save a login flow")
+	}
+	if strings.ContainsAny(flow.Name, `/\`) || strings.Contains(flow.Name, "..") {
+		return "", fmt.Errorf("invalid login flow name '%s'", flow.Name)
+	}
+	dir := filepath.Join(zapDir, loginFlowsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create login flows directory: %w", err)
+	}
+	data, err := json.MarshalIndent(flow, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal login flow: %w", err)
+	}
+	path := filepath.Join(dir, flow.Name+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write login flow: %w", err)
+	}
+	return path, nil
+}
+
+// ListLoginFlows returns the names of saved login flows
+func ListLoginFlows(zapDir string) []string {
+	entries, err := os.ReadDir(filepath.Join(zapDir, loginFlowsDir))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -1,12 +1,18 @@
 package tools
 
-import "sync"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
 
 // ResponseManager manages shared state between tools
 // This allows tools like assert_response and extract_value to access
-// the last HTTP response from http_request tool
+// the last HTTP response from http_request tool, and responses saved by name
 type ResponseManager struct {
 	lastHTTPResponse *HTTPResponse
+	named            map[string]*HTTPResponse // Saved with save_response_as
 	mu               sync.RWMutex
 }
 
@@ -28,3 +34,58 @@ func (rm *ResponseManager) GetHTTPResponse() *HTTPResponse {
 	defer rm.mu.RUnlock()
 	return rm.lastHTTPResponse
 }
+
+// LastResponseName refers to the last response wherever a saved response's
+// name is accepted
+const LastResponseName = "last_response"
+
+// SaveHTTPResponse keeps resp under name, so later tools can refer to it
+// after other requests
+func (rm *ResponseManager) SaveHTTPResponse(name string, resp *HTTPResponse) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if rm.named == nil {
+		rm.named = make(map[string]*HTTPResponse)
+	}
+	rm.named[name] = resp
+}
+
+// GetNamedResponse returns the response saved under name
+func (rm *ResponseManager) GetNamedResponse(name string) (*HTTPResponse, bool) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	resp, ok := rm.named[name]
+	return resp, ok
+}
+
+// ResponseNames returns the names responses are saved under, sorted
+func (rm *ResponseManager) ResponseNames() []string {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	names := make([]string, 0, len(rm.named))
+	for name := range rm.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Response returns the response saved under name, or the last response when
+// name is "" or "last_response"
+func (rm *ResponseManager) Response(name string) (*HTTPResponse, error) {
+	if name == "" || name == LastResponseName {
+		resp := rm.GetHTTPResponse()
+		if resp == nil {
+			return nil, fmt.Errorf("no HTTP response available - make an http_request first")
+		}
+		return resp, nil
+	}
+	if resp, ok := rm.GetNamedResponse(name); ok {
+		return resp, nil
+	}
+	names := rm.ResponseNames()
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no response saved as '%s' (save one with save_response_as on http_request)", name)
+	}
+	return nil, fmt.Errorf("no response saved as '%s' (saved: %s)", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// parseMarkup parses an XML or HTML response body into one kind of node
+// tree, for XPath and CSS selectors. The Content-Type decides, or else the
+// body itself; XML element and attribute names lose their namespace prefix.
+func parseMarkup(resp *HTTPResponse) (*html.Node, error) {
+	contentType, _ := responseHeader(resp, "Content-Type")
+	contentType = strings.ToLower(contentType)
+	head := strings.ToLower(strings.TrimSpace(resp.Body))
+	if len(head) > 100 {
+		head = head[:100]
+	}
+
+	isHTML := strings.Contains(contentType, "html") && !strings.Contains(contentType, "xhtml")
+	isXML := strings.Contains(contentType, "xml")
+	if !isHTML && !isXML {
+		isHTML = strings.HasPrefix(head, "<!doctype html") || strings.HasPrefix(head, "<html")
+		isXML = !isHTML && strings.HasPrefix(head, "<")
+	}
+
+	if isXML {
+		if doc, err := parseXMLTree(resp.Body); err == nil {
+			return doc, nil
+		} else if !strings.HasPrefix(head, "<!doctype html") {
+			return nil, err
+		}
+	}
+	if !isHTML && !strings.HasPrefix(head, "<") {
+		return nil, fmt.Errorf("response body is not XML or HTML")
+	}
+	doc, err := html.Parse(strings.NewReader(resp.Body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	return doc, nil
+}
+
+// parseXMLTree builds a node tree from an XML document
+func parseXMLTree(body string) (*html.Node, error) {
+	dec := xml.NewDecoder(strings.NewReader(body))
+	dec.Entity = xml.HTMLEntity
+	doc := &html.Node{Type: html.DocumentNode}
+	cur := doc
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &html.Node{Type: html.ElementNode, Data: t.Name.Local}
+			for _, attr := range t.Attr {
+				n.Attr = append(n.Attr, html.Attribute{Namespace: attr.Name.Space, Key: attr.Name.Local, Val: attr.Value})
+			}
+			cur.AppendChild(n)
+			cur = n
+		case xml.EndElement:
+			if cur.Parent != nil {
+				cur = cur.Parent
+			}
+		case xml.CharData:
+			cur.AppendChild(&html.Node{Type: html.TextNode, Data: string(t)})
+		}
+	}
+	if doc.FirstChild == nil {
+		return nil, fmt.Errorf("invalid XML: no elements")
+	}
+	return doc, nil
+}
+
+// nodeText returns the text inside n
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode || c.Type == html.ElementNode {
+			sb.WriteString(nodeText(c))
+		}
+	}
+	return sb.String()
+}
+
+// nodeAttr returns the value of n's attribute key
+func nodeAttr(n *html.Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// elementChildren returns n's child elements
+func elementChildren(n *html.Node) []*html.Node {
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// localName drops a namespace prefix, e.g. soap:Body -> Body
+func localName(name string) string {
+	if _, local, ok := strings.Cut(name, ":"); ok {
+		return local
+	}
+	return name
+}
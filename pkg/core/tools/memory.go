@@ -34,7 +34,7 @@ func (t *MemoryTool) Name() string {
 
 // Description returns the tool description.
 func (t *MemoryTool) Description() string {
-	return "Manage persistent agent memory across sessions. Save important facts, recall previous knowledge, or forget outdated info. Actions: save, recall, forget, list"
+	return "Manage persistent agent memory across sessions. Save important facts, recall previous knowledge (semantic search over facts and past sessions when embeddings are enabled), or forget outdated info. Actions: save, recall, forget, list"
 }
 
 // Parameters returns the tool parameter description.
@@ -79,6 +79,15 @@ func (t *MemoryTool) Execute(args string) (string, error) {
 			return "", fmt.Errorf("'query' is required for recall action")
 		}
 
+		// Prefer semantic search when an embedding model is configured
+		if t.store.HasEmbedder() {
+			matches, err := t.store.SemanticRecall(params.Query, 10)
+			if err == nil {
+				return formatMemoryMatches(params.Query, matches), nil
+			}
+			// Fall through to substring search if the embedding model is unavailable
+		}
+
 		results := t.store.Recall(params.Query)
 		if len(results) == 0 {
 			return fmt.Sprintf("No memories found matching '%s'.", params.Query), nil
@@ -131,3 +140,21 @@ func (t *MemoryTool) Execute(args string) (string, error) {
 		return "", fmt.Errorf("unknown action '%s' (use: save, recall, forget, list)", params.Action)
 	}
 }
+
+// formatMemoryMatches renders semantic recall results with their similarity scores.
+func formatMemoryMatches(query string, matches []core.MemoryMatch) string {
+	if len(matches) == 0 {
+		return fmt.Sprintf("No memories related to '%s'.", query)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d memories related to '%s' (most similar first):\n\n", len(matches), query))
+	for _, m := range matches {
+		if m.Entry != nil {
+			sb.WriteString(fmt.Sprintf("  (%.2f) [%s] %s: %s\n", m.Score, m.Entry.Category, m.Entry.Key, m.Entry.Value))
+		} else if m.Session != nil {
+			sb.WriteString(fmt.Sprintf("  (%.2f) [session %s] %s\n", m.Score, m.Session.StartTime, m.Session.Summary))
+		}
+	}
+	return sb.String()
+}
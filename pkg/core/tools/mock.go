@@ -0,0 +1,539 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mock server defaults
+const (
+	defaultMockTimeout = 600  // Seconds before a mock server stops itself
+	maxMockTimeout     = 3600 // Upper bound for timeout_seconds
+	maxMockDelay       = 60000
+)
+
+// MockServerTool runs local HTTP servers that answer with fixture responses
+type MockServerTool struct {
+	varStore *VariableStore
+	mu       sync.Mutex
+	servers  map[string]*mockServer
+}
+
+// MockRoute is a fixture: requests matching method and path get this response
+type MockRoute struct {
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"` // Empty or "*" matches any method
+	Path    string            `json:"path" yaml:"path"`                         // "/users/:id", "/files/*"
+	Status  int               `json:"status,omitempty" yaml:"status,omitempty"` // Default 200
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty" yaml:"body,omitempty"`         // Strings are sent as-is, anything else as JSON
+	DelayMs int               `json:"delay_ms,omitempty" yaml:"delay_ms,omitempty"` // Simulated latency
+}
+
+// mockFixtures is the format of a fixtures file
+type mockFixtures struct {
+	Routes []MockRoute `json:"routes" yaml:"routes"`
+}
+
+// mockServer is a running mock server
+type mockServer struct {
+	server   *http.Server
+	url      string
+	mu       sync.Mutex
+	routes   []MockRoute
+	requests []CapturedRequest
+	misses   int
+	done     chan struct{}
+}
+
+// MockServerParams defines parameters for the mock server
+type MockServerParams struct {
+	Action         string      `json:"action"`
+	ServerID       string      `json:"server_id,omitempty"`
+	Port           int         `json:"port,omitempty"`
+	Routes         []MockRoute `json:"routes,omitempty"`
+	FixturesFile   string      `json:"fixtures_file,omitempty"` // YAML/JSON file with a "routes" list
+	TimeoutSeconds int         `json:"timeout_seconds,omitempty"`
+}
+
+// NewMockServerTool creates a new mock server tool
+func NewMockServerTool(varStore *VariableStore) *MockServerTool {
+	return &MockServerTool{
+		varStore: varStore,
+		servers:  make(map[string]*mockServer),
+	}
+}
+
+// Name returns the tool name
+func (t *MockServerTool) Name() string {
+	return "mock_server"
+}
+
+// Description returns the tool description
+func (t *MockServerTool) Description() string {
+	return "Run a local mock HTTP server that answers with fixture responses (status, headers, body, delay) per method and path, e.g. to test client code or webhook consumers without a real backend. Paths support :params (echoed into bodies as {{params.name}}) and a trailing *. Actions: start, add_routes, list, get_requests, stop."
+}
+
+// Parameters returns the tool parameter description
+func (t *MockServerTool) Parameters() string {
+	return `{
+  "action": "start|add_routes|list|get_requests|stop",
+  "server_id": "mock_1",
+  "port": 0,
+  "routes": [
+    {"method": "GET", "path": "/users/:id", "status": 200, "body": {"id": "{{params.id}}", "name": "Test"}},
+    {"method": "POST", "path": "/users", "status": 201, "headers": {"Location": "/users/1"}, "delay_ms": 200}
+  ],
+  "fixtures_file": "optional .zap/mocks/users.yaml",
+  "timeout_seconds": 600
+}`
+}
+
+// Execute runs the mock server command
+func (t *MockServerTool) Execute(args string) (string, error) {
+	var params MockServerParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	if params.ServerID == "" {
+		params.ServerID = "mock_1"
+	}
+	if params.TimeoutSeconds == 0 {
+		params.TimeoutSeconds = defaultMockTimeout
+	}
+
+	switch params.Action {
+	case "start":
+		return t.startServer(params)
+	case "add_routes":
+		return t.addRoutes(params)
+	case "list":
+		return t.listRoutes(params.ServerID)
+	case "get_requests":
+		return t.getRequests(params.ServerID)
+	case "stop":
+		return t.stopServer(params.ServerID)
+	default:
+		return "", fmt.Errorf("unknown action: %s (use 'start', 'add_routes', 'list', 'get_requests', or 'stop')", params.Action)
+	}
+}
+
+// loadRoutes validates the inline routes and those from the fixtures file
+func loadRoutes(params MockServerParams) ([]MockRoute, error) {
+	routes := append([]MockRoute{}, params.Routes...)
+
+	if params.FixturesFile != "" {
+		workDir, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		path, err := ValidatePathWithinWorkDir(params.FixturesFile, workDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fixtures_file: %w", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixtures_file: %w", err)
+		}
+		// YAML is a superset of JSON, so one decoder handles both
+		var fixtures mockFixtures
+		if err := yaml.Unmarshal(data, &fixtures); err != nil {
+			return nil, fmt.Errorf("failed to parse fixtures_file: %w", err)
+		}
+		routes = append(routes, fixtures.Routes...)
+	}
+
+	for i := range routes {
+		r := &routes[i]
+		if !strings.HasPrefix(r.Path, "/") {
+			return nil, fmt.Errorf("route %d: path must start with '/' (got %q)", i+1, r.Path)
+		}
+		r.Method = strings.ToUpper(r.Method)
+		if r.Status == 0 {
+			r.Status = http.StatusOK
+		}
+		if r.Status < 100 || r.Status > 599 {
+			return nil, fmt.Errorf("route %d: invalid status %d", i+1, r.Status)
+		}
+		if r.DelayMs < 0 || r.DelayMs > maxMockDelay {
+			return nil, fmt.Errorf("route %d: delay_ms must be between 0 and %d", i+1, maxMockDelay)
+		}
+		r.Body = normalizeYAMLValue(r.Body)
+	}
+	return routes, nil
+}
+
+// startServer starts a new mock server
+func (t *MockServerTool) startServer(params MockServerParams) (string, error) {
+	if params.TimeoutSeconds < 0 || params.TimeoutSeconds > maxMockTimeout {
+		return "", fmt.Errorf("timeout_seconds must be between 1 and %d", maxMockTimeout)
+	}
+	routes, err := loadRoutes(params)
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.servers[params.ServerID]; exists {
+		return "", fmt.Errorf("mock server '%s' already running. Stop it first, use add_routes, or use a different server_id", params.ServerID)
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", params.Port))
+	if err != nil {
+		return "", fmt.Errorf("failed to start mock server: %w", err)
+	}
+	actualPort := listener.Addr().(*net.TCPAddr).Port
+
+	ms := &mockServer{
+		url:    fmt.Sprintf("http://localhost:%d", actualPort),
+		routes: routes,
+		done:   make(chan struct{}),
+	}
+	ms.server = &http.Server{Handler: ms}
+
+	go func() {
+		ms.server.Serve(listener)
+	}()
+
+	// Auto-shutdown after timeout
+	go func() {
+		select {
+		case <-time.After(time.Duration(params.TimeoutSeconds) * time.Second):
+			t.stopServer(params.ServerID)
+		case <-ms.done:
+		}
+	}()
+
+	t.servers[params.ServerID] = ms
+
+	// Save the base URL so requests can use {{mock_1_url}}
+	if t.varStore != nil {
+		t.varStore.Set(fmt.Sprintf("%s_url", params.ServerID), ms.url)
+	}
+
+	return fmt.Sprintf(`Mock server started!
+
+Server ID: %s
+URL: %s (saved as {{%s_url}})
+Routes: %d
+Timeout: %d seconds
+
+%s
+Unmatched requests get a 404 listing the available routes. Use 'get_requests' to see what was received.`,
+		params.ServerID, ms.url, params.ServerID, len(routes), params.TimeoutSeconds, formatMockRoutes(routes)), nil
+}
+
+// addRoutes adds routes to a running server; later routes win over earlier ones
+func (t *MockServerTool) addRoutes(params MockServerParams) (string, error) {
+	ms, err := t.getServer(params.ServerID)
+	if err != nil {
+		return "", err
+	}
+	routes, err := loadRoutes(params)
+	if err != nil {
+		return "", err
+	}
+	if len(routes) == 0 {
+		return "", fmt.Errorf("'routes' or 'fixtures_file' is required")
+	}
+
+	ms.mu.Lock()
+	ms.routes = append(ms.routes, routes...)
+	total := len(ms.routes)
+	ms.mu.Unlock()
+
+	return fmt.Sprintf("Added %d route(s) to '%s' (%d total).", len(routes), params.ServerID, total), nil
+}
+
+// listRoutes shows a server's routes
+func (t *MockServerTool) listRoutes(serverID string) (string, error) {
+	ms, err := t.getServer(serverID)
+	if err != nil {
+		return "", err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return fmt.Sprintf("Mock server '%s' at %s:\n%s", serverID, ms.url, formatMockRoutes(ms.routes)), nil
+}
+
+// getRequests lists the requests a server received
+func (t *MockServerTool) getRequests(serverID string) (string, error) {
+	ms, err := t.getServer(serverID)
+	if err != nil {
+		return "", err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if len(ms.requests) == 0 {
+		return fmt.Sprintf("No requests received yet by mock server '%s'.", serverID), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Mock server '%s' received %d request(s) (%d unmatched):\n\n", serverID, len(ms.requests), ms.misses))
+	for i, req := range ms.requests {
+		sb.WriteString(fmt.Sprintf("Request #%d (%s) %s %s\n", i+1, req.Timestamp.Format("15:04:05"), req.Method, req.Path))
+		if len(req.Headers) > 0 {
+			sb.WriteString("  Headers:\n")
+			for key, value := range req.Headers {
+				sb.WriteString(fmt.Sprintf("    %s: %s\n", key, value))
+			}
+		}
+		if req.Body != "" {
+			sb.WriteString(fmt.Sprintf("  Body: %s\n", truncateBody(req.Body, 1000)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if t.varStore != nil {
+		if requestsJSON, err := json.Marshal(ms.requests); err == nil {
+			t.varStore.Set(fmt.Sprintf("%s_requests", serverID), string(requestsJSON))
+		}
+	}
+	return sb.String(), nil
+}
+
+// stopServer shuts down a mock server
+func (t *MockServerTool) stopServer(serverID string) (string, error) {
+	t.mu.Lock()
+	ms, exists := t.servers[serverID]
+	if exists {
+		delete(t.servers, serverID)
+	}
+	t.mu.Unlock()
+
+	if !exists {
+		return "", fmt.Errorf("mock server '%s' not found", serverID)
+	}
+
+	close(ms.done)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ms.server.Shutdown(ctx); err != nil {
+		return "", fmt.Errorf("failed to shutdown mock server: %w", err)
+	}
+
+	ms.mu.Lock()
+	count := len(ms.requests)
+	ms.mu.Unlock()
+	return fmt.Sprintf("Mock server '%s' stopped. Received %d request(s).", serverID, count), nil
+}
+
+// Cleanup stops all running mock servers (call on shutdown)
+func (t *MockServerTool) Cleanup() {
+	t.mu.Lock()
+	ids := make([]string, 0, len(t.servers))
+	for id := range t.servers {
+		ids = append(ids, id)
+	}
+	t.mu.Unlock()
+
+	for _, id := range ids {
+		t.stopServer(id)
+	}
+}
+
+// getServer looks up a running server
+func (t *MockServerTool) getServer(serverID string) (*mockServer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ms, exists := t.servers[serverID]
+	if !exists {
+		return nil, fmt.Errorf("mock server '%s' not found", serverID)
+	}
+	return ms, nil
+}
+
+// ServeHTTP records the request and answers with the matching fixture
+func (ms *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	headers := make(map[string]string)
+	for key, values := range r.Header {
+		headers[key] = strings.Join(values, ", ")
+	}
+	path := r.URL.Path
+	if r.URL.RawQuery != "" {
+		path += "?" + r.URL.RawQuery
+	}
+
+	ms.mu.Lock()
+	ms.requests = append(ms.requests, CapturedRequest{
+		Method:    r.Method,
+		Path:      path,
+		Headers:   headers,
+		Body:      string(body),
+		Timestamp: time.Now(),
+	})
+	route, pathParams, ok := matchMockRoute(ms.routes, r.Method, r.URL.Path)
+	if !ok {
+		ms.misses++
+	}
+	var available []string
+	for _, route := range ms.routes {
+		available = append(available, mockRouteLine(route))
+	}
+	ms.mu.Unlock()
+
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.Encode(map[string]interface{}{
+			"error":  "no mock route matches " + r.Method + " " + r.URL.Path,
+			"routes": available,
+		})
+		return
+	}
+
+	if route.DelayMs > 0 {
+		select {
+		case <-time.After(time.Duration(route.DelayMs) * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	var payload []byte
+	contentType := ""
+	switch b := route.Body.(type) {
+	case nil:
+	case string:
+		payload = []byte(b)
+		contentType = "text/plain; charset=utf-8"
+		if json.Valid(payload) {
+			contentType = "application/json"
+		}
+	default:
+		payload, _ = json.Marshal(b)
+		contentType = "application/json"
+	}
+	payload = []byte(fillPathParams(string(payload), pathParams))
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	for key, value := range route.Headers {
+		w.Header().Set(key, fillPathParams(value, pathParams))
+	}
+	w.WriteHeader(route.Status)
+	w.Write(payload)
+}
+
+// matchMockRoute finds the route for a request. Later routes take
+// precedence, so add_routes can override earlier fixtures.
+func matchMockRoute(routes []MockRoute, method, path string) (MockRoute, map[string]string, bool) {
+	for i := len(routes) - 1; i >= 0; i-- {
+		route := routes[i]
+		if route.Method != "" && route.Method != "*" && route.Method != method {
+			continue
+		}
+		if params, ok := matchMockPath(route.Path, path); ok {
+			return route, params, true
+		}
+	}
+	return MockRoute{}, nil, false
+}
+
+// matchMockPath matches a path against a pattern with :name segments and an
+// optional trailing "*" that matches the rest of the path.
+func matchMockPath(pattern, path string) (map[string]string, bool) {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	params := make(map[string]string)
+
+	for i, part := range patternParts {
+		if part == "*" && i == len(patternParts)-1 {
+			return params, true
+		}
+		if i >= len(pathParts) {
+			return nil, false
+		}
+		switch {
+		case strings.HasPrefix(part, ":"):
+			params[part[1:]] = pathParts[i]
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			params[part[1:len(part)-1]] = pathParts[i]
+		case part != pathParts[i]:
+			return nil, false
+		}
+	}
+	return params, len(patternParts) == len(pathParts)
+}
+
+// mockParamPattern matches {{params.name}} placeholders in fixture responses
+var mockParamPattern = regexp.MustCompile(`\{\{\s*params\.([A-Za-z0-9_]+)\s*\}\}`)
+
+// fillPathParams replaces {{params.name}} with the matched path segment
+func fillPathParams(text string, params map[string]string) string {
+	if len(params) == 0 {
+		return text
+	}
+	return mockParamPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := mockParamPattern.FindStringSubmatch(match)[1]
+		if value, ok := params[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// formatMockRoutes lists routes one per line
+func formatMockRoutes(routes []MockRoute) string {
+	var sb strings.Builder
+	for _, r := range routes {
+		sb.WriteString("  " + mockRouteLine(r) + "\n")
+	}
+	return sb.String()
+}
+
+// mockRouteLine describes a route, e.g. "GET    /users/:id -> 200"
+func mockRouteLine(r MockRoute) string {
+	method := r.Method
+	if method == "" {
+		method = "*"
+	}
+	line := fmt.Sprintf("%-6s %s -> %d", method, r.Path, r.Status)
+	if r.DelayMs > 0 {
+		line += fmt.Sprintf(" (after %dms)", r.DelayMs)
+	}
+	return line
+}
+
+// normalizeYAMLValue converts map[interface{}]interface{} from YAML into
+// JSON-compatible maps.
+func normalizeYAMLValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			m[fmt.Sprint(key)] = normalizeYAMLValue(item)
+		}
+		return m
+	case map[string]interface{}:
+		for key, item := range val {
+			val[key] = normalizeYAMLValue(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeYAMLValue(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,586 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPISpec is a parsed OpenAPI 3.x or Swagger 2.0 document. Schemas are
+// kept as decoded maps; $refs are resolved when they are used.
+type OpenAPISpec struct {
+	Title           string
+	Version         string   // OpenAPI/Swagger version, e.g. "3.0.3" or "2.0"
+	Servers         []string // Server URLs with their variables filled in
+	Operations      []OpenAPIOperation
+	SecuritySchemes map[string]OpenAPISecurityScheme
+
+	root map[string]interface{}
+}
+
+// OpenAPIOperation is one method and path of a spec
+type OpenAPIOperation struct {
+	ID              string
+	Method          string
+	Path            string // Path template, e.g. /pets/{petId}
+	Summary         string
+	Tags            []string
+	Parameters      []OpenAPIParameter
+	BodyContentType string
+	BodySchema      map[string]interface{}
+	BodyExample     interface{}                // Example given in the spec, if any
+	Responses       map[string]OpenAPIResponse // By status code, "2XX" or "default"
+	Security        []string                   // Schemes of the first security requirement
+}
+
+// OpenAPIParameter is a path, query, header or cookie parameter
+type OpenAPIParameter struct {
+	Name     string
+	In       string
+	Required bool
+	Schema   map[string]interface{}
+	Example  interface{}
+}
+
+// OpenAPIResponse is one documented response of an operation
+type OpenAPIResponse struct {
+	Description string
+	ContentType string
+	Schema      map[string]interface{}
+	Headers     map[string]OpenAPIHeader
+}
+
+// OpenAPIHeader is a documented response header
+type OpenAPIHeader struct {
+	Required bool
+	Schema   map[string]interface{}
+}
+
+// OpenAPISecurityScheme is an entry of components.securitySchemes
+// (securityDefinitions in Swagger 2)
+type OpenAPISecurityScheme struct {
+	Type   string // http, apiKey, oauth2, openIdConnect (Swagger 2: basic, apiKey, oauth2)
+	Scheme string // http: bearer, basic
+	In     string // apiKey: header, query or cookie
+	Name   string // apiKey: header or parameter name
+}
+
+// openAPIMethods are the operation keys of a path item, in display order
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete", "head", "options", "trace"}
+
+// maxSchemaDepth stops recursive schemas
+const maxSchemaDepth = 8
+
+// LoadOpenAPISpec reads an OpenAPI 3.x or Swagger 2.0 spec in YAML or JSON
+func LoadOpenAPISpec(path string) (*OpenAPISpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	return ParseOpenAPISpec(data)
+}
+
+// ParseOpenAPISpec parses an OpenAPI 3.x or Swagger 2.0 spec in YAML or JSON
+func ParseOpenAPISpec(data []byte) (*OpenAPISpec, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	root, ok := normalizeYAML(raw).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("spec is not a YAML/JSON object")
+	}
+
+	spec := &OpenAPISpec{root: root, SecuritySchemes: make(map[string]OpenAPISecurityScheme)}
+	swagger2 := false
+	if v, ok := root["openapi"]; ok {
+		spec.Version = fmt.Sprint(v)
+	} else if v, ok := root["swagger"]; ok {
+		spec.Version = fmt.Sprint(v)
+		swagger2 = true
+	} else {
+		return nil, fmt.Errorf("not an OpenAPI spec: missing 'openapi' or 'swagger' version field")
+	}
+	if info, ok := root["info"].(map[string]interface{}); ok {
+		spec.Title = stringField(info, "title")
+	}
+
+	if swagger2 {
+		spec.Servers = swagger2Servers(root)
+		for name, raw := range mapField(root, "securityDefinitions") {
+			def := spec.resolve(raw)
+			scheme := OpenAPISecurityScheme{Type: stringField(def, "type"), In: stringField(def, "in"), Name: stringField(def, "name")}
+			if scheme.Type == "basic" {
+				scheme.Type, scheme.Scheme = "http", "basic"
+			}
+			spec.SecuritySchemes[name] = scheme
+		}
+	} else {
+		for _, raw := range listField(root, "servers") {
+			if server, ok := raw.(map[string]interface{}); ok {
+				spec.Servers = append(spec.Servers, serverURL(server))
+			}
+		}
+		for name, raw := range mapField(mapField(root, "components"), "securitySchemes") {
+			def := spec.resolve(raw)
+			spec.SecuritySchemes[name] = OpenAPISecurityScheme{
+				Type:   stringField(def, "type"),
+				Scheme: strings.ToLower(stringField(def, "scheme")),
+				In:     stringField(def, "in"),
+				Name:   stringField(def, "name"),
+			}
+		}
+	}
+
+	globalSecurity := firstSecurityRequirement(root["security"])
+	paths := mapField(root, "paths")
+	pathNames := make([]string, 0, len(paths))
+	for path := range paths {
+		pathNames = append(pathNames, path)
+	}
+	sort.Strings(pathNames)
+
+	for _, path := range pathNames {
+		item := spec.resolve(paths[path])
+		shared := listField(item, "parameters")
+		for _, method := range openAPIMethods {
+			raw, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			op := spec.parseOperation(method, path, raw, shared, swagger2)
+			if _, ok := raw["security"]; ok {
+				op.Security = firstSecurityRequirement(raw["security"])
+			} else {
+				op.Security = globalSecurity
+			}
+			spec.Operations = append(spec.Operations, op)
+		}
+	}
+	return spec, nil
+}
+
+// parseOperation reads one operation of a path item
+func (s *OpenAPISpec) parseOperation(method, path string, raw map[string]interface{}, shared []interface{}, swagger2 bool) OpenAPIOperation {
+	op := OpenAPIOperation{
+		ID:        stringField(raw, "operationId"),
+		Method:    strings.ToUpper(method),
+		Path:      path,
+		Summary:   stringField(raw, "summary"),
+		Responses: make(map[string]OpenAPIResponse),
+	}
+	for _, tag := range listField(raw, "tags") {
+		op.Tags = append(op.Tags, fmt.Sprint(tag))
+	}
+
+	// Operation parameters override path-level ones with the same name and location
+	params := make(map[string]OpenAPIParameter)
+	var order []string
+	formSchema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	for _, list := range [][]interface{}{shared, listField(raw, "parameters")} {
+		for _, rawParam := range list {
+			p := s.resolve(rawParam)
+			param := OpenAPIParameter{
+				Name:     stringField(p, "name"),
+				In:       stringField(p, "in"),
+				Required: p["required"] == true,
+				Schema:   s.resolve(p["schema"]),
+				Example:  p["example"],
+			}
+			if swagger2 {
+				switch param.In {
+				case "body":
+					op.BodyContentType = "application/json"
+					op.BodySchema = param.Schema
+					continue
+				case "formData":
+					formSchema["properties"].(map[string]interface{})[param.Name] = p
+					continue
+				}
+				if param.Schema == nil {
+					// Swagger 2 puts type and format on the parameter itself
+					param.Schema = p
+				}
+			}
+			key := param.In + ":" + param.Name
+			if _, ok := params[key]; !ok {
+				order = append(order, key)
+			}
+			params[key] = param
+		}
+	}
+	for _, key := range order {
+		op.Parameters = append(op.Parameters, params[key])
+	}
+	if len(formSchema["properties"].(map[string]interface{})) > 0 && op.BodySchema == nil {
+		op.BodyContentType = "application/x-www-form-urlencoded"
+		op.BodySchema = formSchema
+	}
+
+	if !swagger2 {
+		body := s.resolve(raw["requestBody"])
+		if contentType, media := jsonMedia(mapField(body, "content")); media != nil {
+			op.BodyContentType = contentType
+			op.BodySchema = s.resolve(media["schema"])
+			op.BodyExample = mediaExample(s, media)
+		}
+	}
+
+	for status, rawResp := range mapField(raw, "responses") {
+		r := s.resolve(rawResp)
+		resp := OpenAPIResponse{Description: stringField(r, "description"), Headers: make(map[string]OpenAPIHeader)}
+		if swagger2 {
+			if schema := s.resolve(r["schema"]); schema != nil {
+				resp.ContentType = "application/json"
+				resp.Schema = schema
+			}
+		} else if contentType, media := jsonMedia(mapField(r, "content")); media != nil {
+			resp.ContentType = contentType
+			resp.Schema = s.resolve(media["schema"])
+		}
+		for name, rawHeader := range mapField(r, "headers") {
+			h := s.resolve(rawHeader)
+			schema := s.resolve(h["schema"])
+			if swagger2 {
+				schema = h
+			}
+			resp.Headers[name] = OpenAPIHeader{Required: h["required"] == true, Schema: schema}
+		}
+		op.Responses[strings.ToUpper(status)] = resp
+	}
+	return op
+}
+
+// Name returns a file-friendly name for the operation: its operationId in
+// kebab case, or the method and path
+func (op OpenAPIOperation) Name() string {
+	if op.ID != "" {
+		return kebabCase(op.ID)
+	}
+	return kebabCase(strings.ToLower(op.Method) + " " + op.Path)
+}
+
+// SuccessStatus returns the lowest documented 2xx status, or 0
+func (op OpenAPIOperation) SuccessStatus() int {
+	best := 0
+	for status := range op.Responses {
+		var code int
+		if _, err := fmt.Sscanf(status, "%d", &code); err != nil || code < 200 || code > 299 {
+			continue
+		}
+		if best == 0 || code < best {
+			best = code
+		}
+	}
+	return best
+}
+
+// Example builds an example value for schema: its example, default or first
+// enum value, or one generated from its type. readOnly properties are left
+// out of request examples.
+func (s *OpenAPISpec) Example(schema map[string]interface{}, request bool) interface{} {
+	return s.example(schema, request, 0)
+}
+
+func (s *OpenAPISpec) example(schema map[string]interface{}, request bool, depth int) interface{} {
+	schema = s.resolve(schema)
+	if schema == nil || depth > maxSchemaDepth {
+		return nil
+	}
+	if v, ok := schema["example"]; ok {
+		return v
+	}
+	if list := listField(schema, "examples"); len(list) > 0 {
+		return list[0]
+	}
+	if v, ok := schema["default"]; ok {
+		return v
+	}
+	if list := listField(schema, "enum"); len(list) > 0 {
+		return list[0]
+	}
+	if v, ok := schema["const"]; ok {
+		return v
+	}
+	if all := listField(schema, "allOf"); len(all) > 0 {
+		merged := make(map[string]interface{})
+		for _, sub := range all {
+			if obj, ok := s.example(s.resolve(sub), request, depth+1).(map[string]interface{}); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if list := listField(schema, key); len(list) > 0 {
+			return s.example(s.resolve(list[0]), request, depth+1)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		obj := make(map[string]interface{})
+		for name, raw := range mapField(schema, "properties") {
+			prop := s.resolve(raw)
+			if request && prop["readOnly"] == true {
+				continue
+			}
+			if !request && prop["writeOnly"] == true {
+				continue
+			}
+			// Credentials become placeholders so they never get saved in a request
+			if request && schemaType(prop) == "string" && core.IsSensitiveKey(name) {
+				obj[name] = "{{" + strings.ToUpper(strings.ReplaceAll(kebabCase(name), "-", "_")) + "}}"
+				continue
+			}
+			obj[name] = s.example(prop, request, depth+1)
+		}
+		return obj
+	case "array":
+		item := s.example(s.resolve(schema["items"]), request, depth+1)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	case "integer":
+		if min, ok := schema["minimum"].(int); ok && min > 1 {
+			return min
+		}
+		return 1
+	case "number":
+		return 1.5
+	case "boolean":
+		return true
+	case "string":
+		return stringExample(stringField(schema, "format"))
+	}
+	return nil
+}
+
+// stringExample returns an example for a string format
+func stringExample(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "12:00:00"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte":
+		return "c3RyaW5n"
+	case "password":
+		return "{{PASSWORD}}"
+	}
+	return "string"
+}
+
+// schemaType returns a schema's type, inferring object and array from
+// properties and items. OpenAPI 3.1 type lists use their first non-null type.
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if name := fmt.Sprint(v); name != "null" {
+				return name
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return ""
+}
+
+// resolve follows a local $ref ("#/components/schemas/Pet") and returns the
+// target object. External refs resolve to nil.
+func (s *OpenAPISpec) resolve(node interface{}) map[string]interface{} {
+	obj, _ := node.(map[string]interface{})
+	for i := 0; obj != nil && i < maxSchemaDepth; i++ {
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj
+		}
+		obj = s.lookupRef(ref)
+	}
+	return obj
+}
+
+// lookupRef walks a JSON pointer within the spec
+func (s *OpenAPISpec) lookupRef(ref string) map[string]interface{} {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	var node interface{} = s.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = obj[part]
+	}
+	obj, _ := node.(map[string]interface{})
+	return obj
+}
+
+// jsonMedia picks the JSON media type of a content map, or else the first one
+func jsonMedia(content map[string]interface{}) (string, map[string]interface{}) {
+	types := make([]string, 0, len(content))
+	for contentType := range content {
+		types = append(types, contentType)
+	}
+	sort.Strings(types)
+	for _, contentType := range types {
+		if contentType == "application/json" || strings.HasSuffix(contentType, "+json") {
+			media, _ := content[contentType].(map[string]interface{})
+			return contentType, media
+		}
+	}
+	if len(types) > 0 {
+		media, _ := content[types[0]].(map[string]interface{})
+		return types[0], media
+	}
+	return "", nil
+}
+
+// mediaExample returns the example of a media type object, if any
+func mediaExample(s *OpenAPISpec, media map[string]interface{}) interface{} {
+	if v, ok := media["example"]; ok {
+		return v
+	}
+	examples := mapField(media, "examples")
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v, ok := s.resolve(examples[name])["value"]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// serverURL fills in an OpenAPI 3 server's variables with their defaults
+func serverURL(server map[string]interface{}) string {
+	url := stringField(server, "url")
+	for name, raw := range mapField(server, "variables") {
+		if variable, ok := raw.(map[string]interface{}); ok {
+			url = strings.ReplaceAll(url, "{"+name+"}", fmt.Sprint(variable["default"]))
+		}
+	}
+	return strings.TrimSuffix(url, "/")
+}
+
+// swagger2Servers builds server URLs from host, basePath and schemes
+func swagger2Servers(root map[string]interface{}) []string {
+	host := stringField(root, "host")
+	basePath := strings.TrimSuffix(stringField(root, "basePath"), "/")
+	if host == "" {
+		if basePath == "" {
+			return nil
+		}
+		return []string{basePath}
+	}
+	schemes := listField(root, "schemes")
+	if len(schemes) == 0 {
+		schemes = []interface{}{"https"}
+	}
+	var servers []string
+	for _, scheme := range schemes {
+		servers = append(servers, fmt.Sprintf("%s://%s%s", scheme, host, basePath))
+	}
+	return servers
+}
+
+// firstSecurityRequirement returns the scheme names of the first requirement
+func firstSecurityRequirement(node interface{}) []string {
+	list, _ := node.([]interface{})
+	if len(list) == 0 {
+		return nil
+	}
+	requirement, _ := list[0].(map[string]interface{})
+	names := make([]string, 0, len(requirement))
+	for name := range requirement {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeYAML converts the map[interface{}]interface{} values yaml.v3
+// produces for non-string keys (like response codes) to string-keyed maps
+func normalizeYAML(node interface{}) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalizeYAML(value)
+		}
+		return v
+	case map[interface{}]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			obj[fmt.Sprint(key)] = normalizeYAML(value)
+		}
+		return obj
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeYAML(value)
+		}
+		return v
+	}
+	return node
+}
+
+func stringField(obj map[string]interface{}, key string) string {
+	if v, ok := obj[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func mapField(obj map[string]interface{}, key string) map[string]interface{} {
+	v, _ := obj[key].(map[string]interface{})
+	return v
+}
+
+func listField(obj map[string]interface{}, key string) []interface{} {
+	v, _ := obj[key].([]interface{})
+	return v
+}
+
+var (
+	kebabBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	kebabInvalid  = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// kebabCase turns "listPets" or "get /pets/{petId}" into "list-pets" or "get-pets-petid"
+func kebabCase(s string) string {
+	s = strings.ToLower(kebabBoundary.ReplaceAllString(s, "$1-$2"))
+	return strings.Trim(kebabInvalid.ReplaceAllString(s, "-"), "-")
+}
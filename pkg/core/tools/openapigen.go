@@ -0,0 +1,1152 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIDraftOptions controls GenerateOpenAPIDraft
+type OpenAPIDraftOptions struct {
+	Title   string // info.title (default "API")
+	Version string // info.version (default "0.1.0")
+	Server  string // Only use history calls whose URL contains this
+}
+
+// OpenAPIDraftResult is a generated spec and what it was built from
+type OpenAPIDraftResult struct {
+	YAML        []byte
+	Paths       int
+	Operations  int
+	Routes      int // Routes found in code
+	Calls       int // History calls used
+	Observed    int // Operations declared in code and called
+	CodeOnly    int // Operations declared in code but never called
+	TrafficOnly int // Operations called but not found in code
+	Servers     []string
+	Notes       []string
+}
+
+// maxDraftCalls caps the calls an operation's schemas are inferred from
+const maxDraftCalls = 50
+
+// draftOperation collects a route and the calls made to it
+type draftOperation struct {
+	method     string
+	path       string            // OpenAPI path template
+	paramTypes map[string]string // Parameter types known from the route
+	route      *Route
+	calls      []draftCall
+}
+
+// draftCall is a history call matched to an operation
+type draftCall struct {
+	record   *HistoryRecord
+	url      *url.URL
+	segments []string
+}
+
+// oasDocument is the generated document; structs keep the usual key order
+type oasDocument struct {
+	OpenAPI string                  `yaml:"openapi"`
+	Info    oasInfo                 `yaml:"info"`
+	Servers []oasServer             `yaml:"servers,omitempty"`
+	Paths   map[string]*oasPathItem `yaml:"paths"`
+}
+
+type oasInfo struct {
+	Title       string `yaml:"title"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description,omitempty"`
+}
+
+type oasServer struct {
+	URL string `yaml:"url"`
+}
+
+type oasPathItem struct {
+	Get     *oasOperation `yaml:"get,omitempty"`
+	Put     *oasOperation `yaml:"put,omitempty"`
+	Post    *oasOperation `yaml:"post,omitempty"`
+	Delete  *oasOperation `yaml:"delete,omitempty"`
+	Options *oasOperation `yaml:"options,omitempty"`
+	Head    *oasOperation `yaml:"head,omitempty"`
+	Patch   *oasOperation `yaml:"patch,omitempty"`
+	Trace   *oasOperation `yaml:"trace,omitempty"`
+}
+
+type oasOperation struct {
+	Tags        []string               `yaml:"tags,omitempty"`
+	OperationID string                 `yaml:"operationId"`
+	Description string                 `yaml:"description,omitempty"`
+	Parameters  []oasParameter         `yaml:"parameters,omitempty"`
+	RequestBody *oasRequestBody        `yaml:"requestBody,omitempty"`
+	Responses   map[string]oasResponse `yaml:"responses"`
+}
+
+type oasParameter struct {
+	Name     string      `yaml:"name"`
+	In       string      `yaml:"in"`
+	Required bool        `yaml:"required,omitempty"`
+	Schema   draftSchema `yaml:"schema"`
+	Example  interface{} `yaml:"example,omitempty"`
+}
+
+type oasRequestBody struct {
+	Required bool                `yaml:"required,omitempty"`
+	Content  map[string]oasMedia `yaml:"content"`
+}
+
+type oasResponse struct {
+	Description string              `yaml:"description"`
+	Content     map[string]oasMedia `yaml:"content,omitempty"`
+}
+
+type oasMedia struct {
+	Schema  draftSchema `yaml:"schema,omitempty"`
+	Example interface{} `yaml:"example,omitempty"`
+}
+
+// draftSchema is an inferred schema, marshaled with its type first
+type draftSchema map[string]interface{}
+
+// draftSchemaKeys is the order schema keys are written in
+var draftSchemaKeys = []string{"type", "format", "nullable", "oneOf", "items", "properties", "required"}
+
+// MarshalYAML writes the keys in draftSchemaKeys order
+func (s draftSchema) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, key := range draftSchemaKeys {
+		value, ok := s[key]
+		if !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if key == "properties" {
+				props := make(map[string]draftSchema, len(v))
+				for name, prop := range v {
+					props[name] = draftSchema(prop.(map[string]interface{}))
+				}
+				value = props
+			} else {
+				value = draftSchema(v)
+			}
+		case []map[string]interface{}:
+			alternatives := make([]draftSchema, len(v))
+			for i, alt := range v {
+				alternatives[i] = draftSchema(alt)
+			}
+			value = alternatives
+		}
+		var child yaml.Node
+		if err := child.Encode(value); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &child)
+	}
+	return node, nil
+}
+
+// operation returns the slot of a path item for method, nil for methods
+// OpenAPI has no field for
+func (p *oasPathItem) operation(method string) **oasOperation {
+	switch method {
+	case "GET":
+		return &p.Get
+	case "PUT":
+		return &p.Put
+	case "POST":
+		return &p.Post
+	case "DELETE":
+		return &p.Delete
+	case "OPTIONS":
+		return &p.Options
+	case "HEAD":
+		return &p.Head
+	case "PATCH":
+		return &p.Patch
+	case "TRACE":
+		return &p.Trace
+	}
+	return nil
+}
+
+// GenerateOpenAPIDraft builds an OpenAPI 3.0 document from the routes
+// declared in code and the calls in the response history. Calls are
+// matched to routes by method and path; parameters and request and
+// response schemas are inferred from what was sent and received. Calls
+// matching no route become operations of their own, with ID-like path
+// segments turned into parameters. records are expected newest first, as
+// ResponseHistory.List returns them.
+func GenerateOpenAPIDraft(routes []Route, records []HistoryRecord, opts OpenAPIDraftOptions) (*OpenAPIDraftResult, error) {
+	if opts.Title == "" {
+		opts.Title = "API"
+	}
+	if opts.Version == "" {
+		opts.Version = "0.1.0"
+	}
+	result := &OpenAPIDraftResult{Routes: len(routes)}
+
+	ops := make(map[string]*draftOperation)
+	var order []string
+	add := func(method, path string) *draftOperation {
+		key := method + " " + path
+		if op, ok := ops[key]; ok {
+			return op
+		}
+		op := &draftOperation{method: method, path: path, paramTypes: make(map[string]string)}
+		ops[key] = op
+		order = append(order, key)
+		return op
+	}
+
+	var declared []*draftOperation
+	anyMethod := 0
+	for i := range routes {
+		path, types := openAPIPath(routes[i].Path)
+		op := add(routes[i].Method, path)
+		if op.route == nil {
+			op.route = &routes[i]
+			op.paramTypes = types
+			declared = append(declared, op)
+		}
+	}
+
+	servers := make(map[string]int)
+	for i := range records {
+		r := &records[i]
+		if r.StatusCode == 0 || (opts.Server != "" && !strings.Contains(r.URL, opts.Server)) {
+			continue
+		}
+		u, err := url.Parse(r.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		method := strings.ToUpper(r.Request.Method)
+		if method == "" {
+			method = "GET"
+		}
+		call := draftCall{record: r, url: u, segments: urlPathSegments(r.URL)}
+
+		op := matchDraftRoute(declared, method, call.segments)
+		switch {
+		case op == nil:
+			op = add(method, observedPathTemplate(call.segments))
+		case op.method == "ANY":
+			// A route for any method gets an operation per method called
+			matched := add(method, op.path)
+			if matched.route == nil {
+				matched.route = op.route
+				matched.paramTypes = op.paramTypes
+			}
+			op = matched
+		}
+		if len(op.calls) < maxDraftCalls {
+			op.calls = append(op.calls, call)
+		}
+		servers[u.Scheme+"://"+u.Host]++
+		result.Calls++
+	}
+
+	doc := oasDocument{
+		OpenAPI: "3.0.3",
+		Info: oasInfo{
+			Title:       opts.Title,
+			Version:     opts.Version,
+			Description: "Draft generated by ZAP from the routes declared in code and the calls in .zap/history. Schemas only reflect the traffic seen: review them before publishing.",
+		},
+		Paths: make(map[string]*oasPathItem),
+	}
+	for server := range servers {
+		result.Servers = append(result.Servers, server)
+	}
+	sort.Slice(result.Servers, func(i, j int) bool {
+		a, b := result.Servers[i], result.Servers[j]
+		if servers[a] != servers[b] {
+			return servers[a] > servers[b]
+		}
+		return a < b
+	})
+	for _, server := range result.Servers {
+		doc.Servers = append(doc.Servers, oasServer{URL: server})
+	}
+
+	usedIDs := make(map[string]bool)
+	for _, key := range order {
+		op := ops[key]
+		method := op.method
+		if method == "ANY" {
+			// Called ANY routes got per-method operations above
+			if len(op.calls) > 0 || hasDraftMethods(ops, op.path) {
+				continue
+			}
+			method = "GET"
+			anyMethod++
+		}
+		item := doc.Paths[op.path]
+		if item == nil {
+			item = &oasPathItem{}
+		}
+		slot := item.operation(method)
+		if slot == nil || *slot != nil {
+			continue
+		}
+		*slot = op.build(method, usedIDs)
+		doc.Paths[op.path] = item
+
+		result.Operations++
+		switch {
+		case op.route != nil && len(op.calls) > 0:
+			result.Observed++
+		case op.route != nil:
+			result.CodeOnly++
+		default:
+			result.TrafficOnly++
+		}
+	}
+	if len(doc.Paths) == 0 {
+		return nil, fmt.Errorf("no routes or calls to generate a spec from")
+	}
+	result.Paths = len(doc.Paths)
+
+	if anyMethod > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d route(s) registered for any method are listed as GET", anyMethod))
+	}
+	if result.CodeOnly > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d operation(s) were never called and have a placeholder 'default' response: call them and regenerate, or document them by hand", result.CodeOnly))
+	}
+	if result.TrafficOnly > 0 && len(routes) > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d operation(s) come from calls matching no route in code: check their path templates", result.TrafficOnly))
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal spec: %w", err)
+	}
+	result.YAML = buf.Bytes()
+	return result, nil
+}
+
+// hasDraftMethods reports whether path has operations with a concrete method
+func hasDraftMethods(ops map[string]*draftOperation, path string) bool {
+	for _, op := range ops {
+		if op.path == path && op.method != "ANY" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDraftRoute finds the declared operation a call belongs to, the
+// one with the most literal segments when several match
+func matchDraftRoute(declared []*draftOperation, method string, segments []string) *draftOperation {
+	var best *draftOperation
+	bestLiterals := -1
+	for _, op := range declared {
+		if op.method != method && op.method != "ANY" {
+			continue
+		}
+		template := strings.FieldsFunc(op.path, func(r rune) bool { return r == '/' })
+		if len(template) != len(segments) {
+			continue
+		}
+		literals, ok := matchPathSegments(template, segments)
+		// A route for the exact method beats an ANY route
+		if ok && op.method == method {
+			literals++
+		}
+		if ok && literals > bestLiterals {
+			best, bestLiterals = op, literals
+		}
+	}
+	return best
+}
+
+var (
+	// routeParamPattern matches a {name} parameter in a route, with an
+	// optional regex or wildcard suffix
+	routeParamPattern = regexp.MustCompile(`\{\*?(\w*)[^{}]*\}`)
+	// idSegmentPattern matches path segments that look like identifiers:
+	// numbers, UUIDs and long hex strings like Mongo ObjectIDs
+	idSegmentPattern = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]*\d[0-9a-fA-F]*)$`)
+	// draftWordPattern matches the words of a handler or path
+	draftWordPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*`)
+)
+
+// openAPIPath converts a route's path to an OpenAPI template: :id,
+// <int:id>, {id:[0-9]+} and *path become {id} and {path}. It also returns
+// the types the route declares for parameters.
+func openAPIPath(path string) (string, map[string]string) {
+	types := make(map[string]string)
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			name, pattern, _ := strings.Cut(strings.TrimSuffix(segment[1:], "?"), "(")
+			if strings.Contains(pattern, `\d`) || strings.Contains(pattern, "0-9") {
+				types[name] = "integer"
+			}
+			segments[i] = "{" + name + "}"
+		case strings.HasPrefix(segment, "*"):
+			name := segment[1:]
+			if name == "" {
+				name = "path"
+			}
+			segments[i] = "{" + name + "}"
+		case strings.HasPrefix(segment, "<") && strings.HasSuffix(segment, ">"):
+			converter, name, ok := strings.Cut(segment[1:len(segment)-1], ":")
+			if !ok {
+				name, converter = converter, ""
+			}
+			if converter == "int" {
+				types[name] = "integer"
+			}
+			segments[i] = "{" + name + "}"
+		case segment == "{$}":
+			segments[i] = ""
+		case strings.Contains(segment, "{"):
+			segments[i] = routeParamPattern.ReplaceAllStringFunc(segment, func(param string) string {
+				m := routeParamPattern.FindStringSubmatch(param)
+				name := m[1]
+				if name == "" {
+					name = "path"
+				}
+				if _, pattern, ok := strings.Cut(param, ":"); ok && (strings.Contains(pattern, `\d`) || strings.Contains(pattern, "0-9") || strings.HasPrefix(pattern, "int")) {
+					types[name] = "integer"
+				}
+				return "{" + name + "}"
+			})
+		}
+	}
+	path = strings.Join(segments, "/")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path, types
+}
+
+// observedPathTemplate turns a called path into a template, replacing
+// numbers, UUIDs and hex IDs with parameters named after the segment
+// before them: /users/42 becomes /users/{userId}
+func observedPathTemplate(segments []string) string {
+	if len(segments) == 0 {
+		return "/"
+	}
+	used := make(map[string]bool)
+	parts := make([]string, len(segments))
+	for i, segment := range segments {
+		if !idSegmentPattern.MatchString(segment) || (len(segment) < 16 && strings.Trim(segment, "0123456789") != "") {
+			parts[i] = segment
+			continue
+		}
+		name := "id"
+		if i > 0 && !strings.HasPrefix(parts[i-1], "{") {
+			if words := draftWordPattern.FindAllString(singularRouteName(parts[i-1]), -1); len(words) > 0 {
+				name = camelWords(append(words, "Id"))
+			}
+		}
+		base := name
+		for n := 2; used[name]; n++ {
+			name = base + fmt.Sprint(n)
+		}
+		used[name] = true
+		parts[i] = "{" + name + "}"
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// isVersionSegment reports whether a path segment is an API version like v1
+func isVersionSegment(segment string) bool {
+	return len(segment) > 1 && (segment[0] == 'v' || segment[0] == 'V') && strings.Trim(segment[1:], "0123456789.") == ""
+}
+
+// camelWords joins words in camelCase
+func camelWords(words []string) string {
+	var sb strings.Builder
+	for i, word := range words {
+		if i == 0 {
+			sb.WriteString(strings.ToLower(word[:1]) + word[1:])
+		} else {
+			sb.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return sb.String()
+}
+
+// build creates the operation, inferring its parameters and bodies from
+// its calls
+func (op *draftOperation) build(method string, usedIDs map[string]bool) *oasOperation {
+	result := &oasOperation{Responses: make(map[string]oasResponse)}
+	if tag := draftTag(op.path); tag != "" {
+		result.Tags = []string{tag}
+	}
+
+	handler := ""
+	var notes []string
+	if op.route != nil {
+		handler = op.route.Handler
+		if handler != "" {
+			notes = append(notes, fmt.Sprintf("Handler: %s (%s:%d)", handler, op.route.File, op.route.Line))
+		} else {
+			notes = append(notes, fmt.Sprintf("Declared in %s:%d", op.route.File, op.route.Line))
+		}
+	} else {
+		notes = append(notes, "Not found in the code: inferred from calls only.")
+	}
+	if len(op.calls) > 0 {
+		notes = append(notes, fmt.Sprintf("Inferred from %d call(s).", len(op.calls)))
+	}
+	result.Description = strings.Join(notes, "\n")
+
+	base := draftOperationID(method, op.path, handler)
+	id := base
+	for n := 2; usedIDs[id]; n++ {
+		id = base + fmt.Sprint(n)
+	}
+	usedIDs[id] = true
+	result.OperationID = id
+
+	result.Parameters = op.pathParameters()
+	result.Parameters = append(result.Parameters, op.queryParameters()...)
+	result.RequestBody = op.requestBody()
+
+	byStatus := make(map[int][]draftCall)
+	for _, call := range op.calls {
+		byStatus[call.record.StatusCode] = append(byStatus[call.record.StatusCode], call)
+	}
+	for status, calls := range byStatus {
+		result.Responses[fmt.Sprint(status)] = draftResponse(status, calls)
+	}
+	if len(result.Responses) == 0 {
+		result.Responses["default"] = oasResponse{Description: "Not observed yet"}
+	}
+	return result
+}
+
+// draftTag tags an operation with its path's first word, skipping api and
+// version prefixes
+func draftTag(path string) string {
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' }) {
+		if strings.Contains(segment, "{") || strings.EqualFold(segment, "api") || isVersionSegment(segment) {
+			continue
+		}
+		return segment
+	}
+	return ""
+}
+
+// draftOperationID names an operation after its handler (ListUsers,
+// users#index), or after its method and path when it has none or it is
+// an inline closure
+func draftOperationID(method, path, handler string) string {
+	words := draftWordPattern.FindAllString(handler, -1)
+	words = slices.DeleteFunc(words, func(w string) bool {
+		switch w {
+		case "func", "function", "async", "lambda", "class", "self", "this", "c", "ctx", "req", "res", "r", "w", "inline":
+			return true
+		}
+		return false
+	})
+	if len(words) > 0 {
+		name := words[len(words)-1]
+		// CRUD actions like index and show need their controller
+		if len(words) > 1 && strings.ToLower(name) == name && len(name) <= 8 {
+			controller := strings.TrimSuffix(strings.TrimSuffix(words[len(words)-2], "Controller"), "Handler")
+			if controller != "" {
+				return camelWords([]string{controller, name})
+			}
+		}
+		return camelWords([]string{name})
+	}
+
+	words = []string{strings.ToLower(method)}
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' }) {
+		if m := pathParamPattern.FindStringSubmatch(segment); m != nil {
+			words = append(words, "By")
+			segment = m[1]
+		}
+		words = append(words, draftWordPattern.FindAllString(segment, -1)...)
+	}
+	return camelWords(words)
+}
+
+// pathParameters lists the path's parameters, typed from the route or the
+// values called with
+func (op *draftOperation) pathParameters() []oasParameter {
+	var params []oasParameter
+	template := strings.FieldsFunc(op.path, func(r rune) bool { return r == '/' })
+	for i, segment := range template {
+		for _, m := range pathParamPattern.FindAllStringSubmatch(segment, -1) {
+			var values []string
+			if segment == m[0] {
+				for _, call := range op.calls {
+					if i < len(call.segments) && !strings.Contains(call.segments[i], "{{") {
+						values = append(values, call.segments[i])
+					}
+				}
+			}
+			schema := draftParamSchema(values)
+			if t, ok := op.paramTypes[m[1]]; ok {
+				schema = map[string]interface{}{"type": t}
+			}
+			param := oasParameter{Name: m[1], In: "path", Required: true, Schema: schema}
+			if len(values) > 0 {
+				param.Example = draftParamValue(values[0], schema)
+			}
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// queryParameters lists the query parameters sent, required when every
+// call sent them
+func (op *draftOperation) queryParameters() []oasParameter {
+	values := make(map[string][]string)
+	for _, call := range op.calls {
+		for name, v := range call.url.Query() {
+			values[name] = append(values[name], v...)
+		}
+	}
+	var params []oasParameter
+	for _, name := range sortedKeys(values) {
+		required := true
+		for _, call := range op.calls {
+			if !call.url.Query().Has(name) {
+				required = false
+			}
+		}
+		schema := draftParamSchema(values[name])
+		params = append(params, oasParameter{
+			Name:     name,
+			In:       "query",
+			Required: required,
+			Schema:   schema,
+			Example:  draftParamValue(values[name][0], schema),
+		})
+	}
+	return params
+}
+
+// draftParamSchema infers a parameter's type from its values
+func draftParamSchema(values []string) map[string]interface{} {
+	if len(values) == 0 {
+		return map[string]interface{}{"type": "string"}
+	}
+	integer, number, boolean := true, true, true
+	for _, v := range values {
+		var n json.Number
+		isNumber := json.Unmarshal([]byte(v), &n) == nil
+		integer = integer && isNumber && !strings.ContainsAny(v, ".eE")
+		number = number && isNumber
+		boolean = boolean && (v == "true" || v == "false")
+	}
+	switch {
+	case integer:
+		return map[string]interface{}{"type": "integer"}
+	case number:
+		return map[string]interface{}{"type": "number"}
+	case boolean:
+		return map[string]interface{}{"type": "boolean"}
+	}
+	schema := map[string]interface{}{"type": "string"}
+	if format := draftStringFormat(values[0]); format != "" {
+		for _, v := range values {
+			if draftStringFormat(v) != format {
+				return schema
+			}
+		}
+		schema["format"] = format
+	}
+	return schema
+}
+
+// draftParamValue converts a parameter's example to its schema's type
+func draftParamValue(value string, schema map[string]interface{}) interface{} {
+	switch schema["type"] {
+	case "integer", "number", "boolean":
+		var v interface{}
+		if json.Unmarshal([]byte(value), &v) == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// requestBody infers the request body from the bodies sent
+func (op *draftOperation) requestBody() *oasRequestBody {
+	var schema map[string]interface{}
+	var example interface{}
+	mediaType := ""
+	sent := 0
+	for _, call := range op.calls {
+		req := call.record.Request
+		if req.Body == nil || req.Body == "" {
+			continue
+		}
+		sent++
+		contentType, _ := headerLookup(req.Headers, "Content-Type")
+		value, isJSON := draftRequestJSON(req.Body)
+		if mediaType == "" {
+			mediaType = mediaTypeOf(contentType, isJSON)
+		}
+		if !isJSON {
+			schema = mergeDraftSchemas(schema, map[string]interface{}{"type": "string"})
+			continue
+		}
+		if isEmptyDraftValue(example) {
+			example = draftExample(value)
+		}
+		schema = mergeDraftSchemas(schema, inferDraftSchema(value))
+	}
+	if sent == 0 {
+		return nil
+	}
+	return &oasRequestBody{
+		Required: sent == len(op.calls),
+		Content:  map[string]oasMedia{mediaType: {Schema: schema, Example: example}},
+	}
+}
+
+// draftRequestJSON returns a request body as JSON values, decoding bodies
+// sent as JSON strings
+func draftRequestJSON(body interface{}) (interface{}, bool) {
+	data, ok := body.(string)
+	if !ok {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, false
+		}
+		data = string(encoded)
+	}
+	value, err := decodeJSONNumbers([]byte(data))
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// mediaTypeOf returns the media type of a Content-Type header, or
+// application/json for JSON bodies sent without one
+func mediaTypeOf(contentType string, isJSON bool) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	switch {
+	case mediaType != "":
+		return mediaType
+	case isJSON:
+		return "application/json"
+	}
+	return "text/plain"
+}
+
+// draftResponse infers a response from the calls that got status
+func draftResponse(status int, calls []draftCall) oasResponse {
+	response := oasResponse{Description: http.StatusText(status)}
+	if response.Description == "" {
+		response.Description = calls[0].record.Status
+	}
+
+	var schema map[string]interface{}
+	var example interface{}
+	mediaType := ""
+	for _, call := range calls {
+		r := call.record
+		if r.Body == "" {
+			continue
+		}
+		contentType, _ := headerLookup(r.Headers, "Content-Type")
+		value, err := decodeJSONNumbers([]byte(r.Body))
+		isJSON := err == nil && !r.Truncated
+		if mediaType == "" {
+			mediaType = mediaTypeOf(contentType, isJSON)
+		}
+		if !isJSON {
+			schema = mergeDraftSchemas(schema, map[string]interface{}{"type": "string"})
+			continue
+		}
+		if isEmptyDraftValue(example) {
+			example = draftExample(value)
+		}
+		schema = mergeDraftSchemas(schema, inferDraftSchema(value))
+	}
+	if mediaType != "" {
+		response.Content = map[string]oasMedia{mediaType: {Schema: schema, Example: example}}
+	}
+	return response
+}
+
+// inferDraftSchema infers the schema of a JSON value; every field of an
+// object is required until a sample without it is merged in
+func inferDraftSchema(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case nil:
+		return map[string]interface{}{"nullable": true}
+	case bool:
+		return map[string]interface{}{"type": "boolean"}
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return map[string]interface{}{"type": "number"}
+		}
+		return map[string]interface{}{"type": "integer"}
+	case string:
+		schema := map[string]interface{}{"type": "string"}
+		if format := draftStringFormat(v); format != "" {
+			schema["format"] = format
+		}
+		return schema
+	case []interface{}:
+		var items map[string]interface{}
+		for _, item := range v {
+			items = mergeDraftSchemas(items, inferDraftSchema(item))
+		}
+		if items == nil {
+			items = map[string]interface{}{}
+		}
+		return map[string]interface{}{"type": "array", "items": items}
+	case map[string]interface{}:
+		schema := map[string]interface{}{"type": "object"}
+		if len(v) > 0 {
+			props := make(map[string]interface{})
+			for key, field := range v {
+				props[key] = inferDraftSchema(field)
+			}
+			schema["properties"] = props
+			schema["required"] = sortedKeys(v)
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+// draftUUIDPattern matches a UUID in any case
+var draftUUIDPattern = regexp.MustCompile(`^(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// isTime reports whether value is a time in layout
+func isTime(layout, value string) bool {
+	_, err := time.Parse(layout, value)
+	return err == nil
+}
+
+// draftStringFormat detects the format of a string value
+func draftStringFormat(value string) string {
+	switch {
+	case draftUUIDPattern.MatchString(value):
+		return "uuid"
+	case isTime(time.RFC3339, strings.ToUpper(value)):
+		return "date-time"
+	case isTime("2006-01-02", value):
+		return "date"
+	}
+	if addr, err := mail.ParseAddress(value); err == nil && addr.Address == value {
+		return "email"
+	}
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return "uri"
+	}
+	return ""
+}
+
+// mergeDraftSchemas merges schemas inferred from two samples: object
+// properties are combined and only fields in both stay required, integer
+// and number make number, and other type mismatches make a oneOf
+func mergeDraftSchemas(a, b map[string]interface{}) map[string]interface{} {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	typeA, _ := a["type"].(string)
+	typeB, _ := b["type"].(string)
+	var merged map[string]interface{}
+	switch {
+	case typeA == "" && a["oneOf"] == nil:
+		merged = maps.Clone(b)
+	case typeB == "" && b["oneOf"] == nil:
+		merged = maps.Clone(a)
+	case typeA == typeB && typeA != "":
+		merged = map[string]interface{}{"type": typeA}
+		if a["format"] != nil && a["format"] == b["format"] {
+			merged["format"] = a["format"]
+		}
+		switch typeA {
+		case "array":
+			itemsA, _ := a["items"].(map[string]interface{})
+			itemsB, _ := b["items"].(map[string]interface{})
+			switch {
+			case len(itemsA) == 0:
+				merged["items"] = itemsB
+			case len(itemsB) == 0:
+				merged["items"] = itemsA
+			default:
+				merged["items"] = mergeDraftSchemas(itemsA, itemsB)
+			}
+		case "object":
+			mergeDraftProperties(merged, a, b)
+		}
+	case typeA == "integer" && typeB == "number", typeA == "number" && typeB == "integer":
+		merged = map[string]interface{}{"type": "number"}
+	default:
+		alternatives := draftAlternatives(a)
+		for _, alt := range draftAlternatives(b) {
+			found := false
+			for i, existing := range alternatives {
+				if t := existing["type"]; t == alt["type"] || (t == "integer" || t == "number") && (alt["type"] == "integer" || alt["type"] == "number") {
+					alternatives[i] = mergeDraftSchemas(existing, alt)
+					found = true
+					break
+				}
+			}
+			if !found {
+				alternatives = append(alternatives, alt)
+			}
+		}
+		merged = map[string]interface{}{"oneOf": alternatives}
+	}
+	if a["nullable"] == true || b["nullable"] == true {
+		merged["nullable"] = true
+	}
+	return merged
+}
+
+// mergeDraftProperties combines the properties of two object schemas into
+// merged, keeping required the fields both require
+func mergeDraftProperties(merged, a, b map[string]interface{}) {
+	propsA, _ := a["properties"].(map[string]interface{})
+	propsB, _ := b["properties"].(map[string]interface{})
+	props := make(map[string]interface{})
+	for key, schema := range propsA {
+		props[key] = schema
+	}
+	for key, schema := range propsB {
+		if existing, ok := props[key].(map[string]interface{}); ok {
+			props[key] = mergeDraftSchemas(existing, schema.(map[string]interface{}))
+		} else {
+			props[key] = schema
+		}
+	}
+	if len(props) > 0 {
+		merged["properties"] = props
+	}
+
+	requiredA, _ := a["required"].([]string)
+	requiredB, _ := b["required"].([]string)
+	var required []string
+	for _, key := range requiredA {
+		if slices.Contains(requiredB, key) {
+			required = append(required, key)
+		}
+	}
+	if len(required) > 0 {
+		merged["required"] = required
+	}
+}
+
+// draftAlternatives returns the oneOf alternatives of a schema, or the
+// schema itself without nullable
+func draftAlternatives(schema map[string]interface{}) []map[string]interface{} {
+	if alternatives, ok := schema["oneOf"].([]map[string]interface{}); ok {
+		return slices.Clone(alternatives)
+	}
+	alt := maps.Clone(schema)
+	delete(alt, "nullable")
+	return []map[string]interface{}{alt}
+}
+
+// draftExample shortens a value for use as an example: arrays keep their
+// first few elements and numbers become plain numbers
+func draftExample(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		if len(v) > 3 {
+			v = v[:3]
+		}
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = draftExample(item)
+		}
+		return items
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			obj[key] = draftExample(field)
+		}
+		return obj
+	}
+	return value
+}
+
+// isEmptyDraftValue reports whether an example is missing or an empty list
+// or object, to be replaced by a fuller sample
+func isEmptyDraftValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// Format describes the generated spec for the user
+func (r *OpenAPIDraftResult) Format(path string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("✓ Wrote OpenAPI draft to %s\n\n", path))
+	sb.WriteString(fmt.Sprintf("%d path(s), %d operation(s) from %d route(s) in code and %d call(s) in history\n", r.Paths, r.Operations, r.Routes, r.Calls))
+	sb.WriteString(fmt.Sprintf("  %d declared and called, %d declared but never called, %d called but not found in code\n", r.Observed, r.CodeOnly, r.TrafficOnly))
+	if len(r.Servers) > 0 {
+		sb.WriteString(fmt.Sprintf("Servers: %s\n", strings.Join(r.Servers, ", ")))
+	}
+	for _, note := range r.Notes {
+		sb.WriteString("Note: " + note + "\n")
+	}
+	sb.WriteString("\nSchemas only reflect the bodies seen: review required fields, formats and enums before relying on it.\n")
+	return sb.String()
+}
+
+// GenerateOpenAPITool writes an OpenAPI draft from discovered routes and
+// the response history
+type GenerateOpenAPITool struct {
+	history   *ResponseHistory
+	workDir   string
+	framework string
+}
+
+// NewGenerateOpenAPITool creates a new generate_openapi tool
+func NewGenerateOpenAPITool(history *ResponseHistory, workDir, framework string) *GenerateOpenAPITool {
+	return &GenerateOpenAPITool{history: history, workDir: workDir, framework: framework}
+}
+
+// GenerateOpenAPIParams defines where the draft goes and what it is built from
+type GenerateOpenAPIParams struct {
+	Output    string `json:"output,omitempty"`    // Default .zap/openapi.yaml
+	Title     string `json:"title,omitempty"`     // info.title
+	Version   string `json:"version,omitempty"`   // info.version
+	Server    string `json:"server,omitempty"`    // Only use calls whose URL contains this
+	Source    string `json:"source,omitempty"`    // "code", "traffic" or "both" (default)
+	Framework string `json:"framework,omitempty"` // Route patterns to use (default: the configured framework)
+	Overwrite bool   `json:"overwrite,omitempty"` // Replace an existing file
+}
+
+// DefaultOpenAPIDraftPath is where generate_openapi writes by default
+var DefaultOpenAPIDraftPath = filepath.Join(core.ZapFolderName, "openapi.yaml")
+
+// Name returns the tool name
+func (t *GenerateOpenAPITool) Name() string {
+	return "generate_openapi"
+}
+
+// Description returns the tool description
+func (t *GenerateOpenAPITool) Description() string {
+	return "Generate a draft OpenAPI 3.0 spec (YAML) from the routes declared in code and the calls in the response history: paths, methods, parameters and request/response schemas inferred from observed bodies. Written to .zap/openapi.yaml for the user to hand-edit; existing files are kept unless overwrite is true"
+}
+
+// Parameters returns the tool parameter description
+func (t *GenerateOpenAPITool) Parameters() string {
+	return `{
+  "output": ".zap/openapi.yaml",
+  "title": "Orders API",
+  "version": "0.1.0",
+  "server": "localhost:8080 (only use calls to this host, optional)",
+  "source": "both|code|traffic",
+  "overwrite": false
+}`
+}
+
+// Execute generates and writes the draft
+func (t *GenerateOpenAPITool) Execute(args string) (string, error) {
+	var params GenerateOpenAPIParams
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", fmt.Errorf("failed to parse arguments: %w", err)
+		}
+	}
+	if params.Output == "" {
+		params.Output = DefaultOpenAPIDraftPath
+	}
+	if params.Framework == "" {
+		params.Framework = t.framework
+	}
+
+	path, err := ValidatePathWithinWorkDir(params.Output, t.workDir)
+	if err != nil {
+		return "", err
+	}
+	result, err := BuildOpenAPIDraft(t.workDir, t.history, params)
+	if err != nil {
+		return "", err
+	}
+	if err := WriteOpenAPIDraft(path, result.YAML, params.Overwrite); err != nil {
+		return "", err
+	}
+	return result.Format(params.Output) + fmt.Sprintf("Check responses against it with validate_openapi {\"spec\": %q}\n", params.Output), nil
+}
+
+// BuildOpenAPIDraft scans workDir for routes and reads history, as
+// params.Source asks, and generates a draft from them
+func BuildOpenAPIDraft(workDir string, history *ResponseHistory, params GenerateOpenAPIParams) (*OpenAPIDraftResult, error) {
+	var routes []Route
+	var records []HistoryRecord
+	switch params.Source {
+	case "", "both", "code", "traffic":
+	default:
+		return nil, fmt.Errorf("source must be 'both', 'code' or 'traffic', got '%s'", params.Source)
+	}
+	if params.Source != "traffic" {
+		var err error
+		if routes, _, err = ScanRoutes(workDir, workDir, params.Framework); err != nil {
+			return nil, err
+		}
+	}
+	if params.Source != "code" {
+		var err error
+		if records, err = history.List(0, ""); err != nil {
+			return nil, err
+		}
+	}
+	if len(routes) == 0 && len(records) == 0 {
+		return nil, fmt.Errorf("nothing to generate from: no routes found in code and no calls in .zap/history (send requests with http_request first)")
+	}
+	return GenerateOpenAPIDraft(routes, records, OpenAPIDraftOptions{Title: params.Title, Version: params.Version, Server: params.Server})
+}
+
+// WriteOpenAPIDraft writes a draft to path, refusing to replace an
+// existing file (and its hand edits) unless overwrite is set
+func WriteOpenAPIDraft(path string, data []byte, overwrite bool) error {
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return fmt.Errorf("%s already exists; set overwrite to replace it (hand edits will be lost)", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write spec: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+)
+
+// openAPI30DocumentSchema is the part of the OpenAPI 3.0 document schema
+// that drafts use, stricter where the spec is (path parameters are
+// required, arrays have items, required lists are not empty)
+const openAPI30DocumentSchema = `{
+  "type": "object",
+  "required": ["openapi", "info", "paths"],
+  "additionalProperties": false,
+  "properties": {
+    "openapi": {"type": "string", "pattern": "^3\\.0\\.\\d+$"},
+    "info": {
+      "type": "object",
+      "required": ["title", "version"],
+      "additionalProperties": false,
+      "properties": {"title": {"type": "string"}, "version": {"type": "string"}, "description": {"type": "string"}}
+    },
+    "servers": {
+      "type": "array",
+      "items": {"type": "object", "required": ["url"], "additionalProperties": false, "properties": {"url": {"type": "string", "format": "uri"}}}
+    },
+    "paths": {
+      "type": "object",
+      "additionalProperties": false,
+      "patternProperties": {"^/": {"$ref": "#/definitions/pathItem"}}
+    }
+  },
+  "definitions": {
+    "pathItem": {
+      "type": "object",
+      "additionalProperties": false,
+      "patternProperties": {"^(get|put|post|delete|options|head|patch|trace)$": {"$ref": "#/definitions/operation"}}
+    },
+    "operation": {
+      "type": "object",
+      "required": ["responses"],
+      "additionalProperties": false,
+      "properties": {
+        "tags": {"type": "array", "items": {"type": "string"}},
+        "operationId": {"type": "string", "minLength": 1},
+        "description": {"type": "string"},
+        "parameters": {"type": "array", "items": {"$ref": "#/definitions/parameter"}},
+        "requestBody": {
+          "type": "object",
+          "required": ["content"],
+          "additionalProperties": false,
+          "properties": {"required": {"type": "boolean"}, "content": {"$ref": "#/definitions/content"}}
+        },
+        "responses": {
+          "type": "object",
+          "minProperties": 1,
+          "additionalProperties": false,
+          "patternProperties": {"^([1-5](\\d\\d|XX)|default)$": {"$ref": "#/definitions/response"}}
+        }
+      }
+    },
+    "parameter": {
+      "type": "object",
+      "required": ["name", "in", "schema"],
+      "additionalProperties": false,
+      "properties": {
+        "name": {"type": "string"},
+        "in": {"enum": ["path", "query", "header", "cookie"]},
+        "required": {"type": "boolean"},
+        "schema": {"$ref": "#/definitions/schema"},
+        "example": {}
+      },
+      "anyOf": [
+        {"properties": {"in": {"not": {"enum": ["path"]}}}},
+        {"required": ["required"], "properties": {"required": {"enum": [true]}}}
+      ]
+    },
+    "response": {
+      "type": "object",
+      "required": ["description"],
+      "additionalProperties": false,
+      "properties": {"description": {"type": "string"}, "content": {"$ref": "#/definitions/content"}}
+    },
+    "content": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": {"schema": {"$ref": "#/definitions/schema"}, "example": {}}
+      }
+    },
+    "schema": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "type": {"enum": ["string", "number", "integer", "boolean", "array", "object"]},
+        "format": {"type": "string"},
+        "nullable": {"type": "boolean"},
+        "oneOf": {"type": "array", "minItems": 1, "items": {"$ref": "#/definitions/schema"}},
+        "items": {"$ref": "#/definitions/schema"},
+        "properties": {"type": "object", "additionalProperties": {"$ref": "#/definitions/schema"}},
+        "required": {"type": "array", "minItems": 1, "items": {"type": "string"}}
+      },
+      "anyOf": [
+        {"properties": {"type": {"not": {"enum": ["array"]}}}},
+        {"required": ["items"]}
+      ]
+    }
+  }
+}`
+
+// checkOpenAPI30Draft validates a generated draft against
+// openAPI30DocumentSchema and checks every path parameter is declared
+func checkOpenAPI30Draft(t *testing.T, data []byte) {
+	t.Helper()
+	schemaDoc, err := decodeJSONNumbers([]byte(openAPI30DocumentSchema))
+	if err != nil {
+		t.Fatalf("invalid document schema: %v", err)
+	}
+	const uri = "urn:zap:test:openapi-3.0"
+	noRefs := func(uri string) (interface{}, error) { return nil, fmt.Errorf("no external $refs") }
+	schema, err := compileJSONSchema(schemaDoc, uri, jsonschema.Draft4, noRefs)
+	if err != nil {
+		t.Fatalf("failed to compile document schema: %v", err)
+	}
+
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("draft is not YAML: %v", err)
+	}
+	asJSON, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatalf("draft does not convert to JSON: %v", err)
+	}
+	doc, err := decodeJSONNumbers(asJSON)
+	if err != nil {
+		t.Fatalf("failed to decode draft: %v", err)
+	}
+	errs, err := validateJSONSchema(schema, doc, uri)
+	if err != nil {
+		t.Fatalf("failed to validate: %v", err)
+	}
+	for _, e := range errs {
+		t.Errorf("draft is not a valid OpenAPI 3.0 document:\n%s", formatSchemaError(e, "  "))
+	}
+
+	// Every {param} of a path template is declared by each of its operations
+	for path, item := range doc.(map[string]interface{})["paths"].(map[string]interface{}) {
+		for method, op := range item.(map[string]interface{}) {
+			declared := make(map[string]bool)
+			params, _ := op.(map[string]interface{})["parameters"].([]interface{})
+			for _, p := range params {
+				if p := p.(map[string]interface{}); p["in"] == "path" {
+					declared[p["name"].(string)] = true
+				}
+			}
+			for _, m := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+				if !declared[m[1]] {
+					t.Errorf("%s %s does not declare path parameter %s", method, path, m[1])
+				}
+			}
+		}
+	}
+}
+
+// openAPIDraftRecords are history calls to the Express fixture, newest first
+func openAPIDraftRecords() []HistoryRecord {
+	jsonHeaders := map[string]string{"Content-Type": "application/json; charset=utf-8"}
+	return []HistoryRecord{
+		{
+			Request:    HTTPRequest{Method: "GET", URL: "{{BASE_URL}}/api/orders/9f3c2a1e-5b7d-4c8e-9a0b-1c2d3e4f5a6b"},
+			URL:        "http://localhost:3000/api/orders/9f3c2a1e-5b7d-4c8e-9a0b-1c2d3e4f5a6b",
+			StatusCode: 200, Headers: jsonHeaders,
+			Body: `{"id": "9f3c2a1e-5b7d-4c8e-9a0b-1c2d3e4f5a6b", "total": 12.5, "items": [{"sku": "A1", "qty": 2}]}`,
+		},
+		{
+			Request:    HTTPRequest{Method: "GET", URL: "{{BASE_URL}}/api/users/7"},
+			URL:        "http://localhost:3000/api/users/7",
+			StatusCode: 404, Headers: jsonHeaders,
+			Body: `{"error": "not found"}`,
+		},
+		{
+			Request:    HTTPRequest{Method: "GET", URL: "{{BASE_URL}}/api/users/42"},
+			URL:        "http://localhost:3000/api/users/42",
+			StatusCode: 200, Headers: jsonHeaders,
+			Body: `{"id": 42, "name": "Ada", "email": "ada@example.com", "manager": null, "tags": []}`,
+		},
+		{
+			Request:    HTTPRequest{Method: "POST", URL: "{{BASE_URL}}/api/users", Body: map[string]interface{}{"name": "Ada", "email": "ada@example.com"}},
+			URL:        "http://localhost:3000/api/users",
+			StatusCode: 201, Headers: jsonHeaders,
+			Body: `{"id": 42, "name": "Ada", "email": "ada@example.com", "created_at": "2026-10-15T09:00:00Z"}`,
+		},
+		{
+			Request:    HTTPRequest{Method: "GET", URL: "{{BASE_URL}}/api/users?page=2&limit=10"},
+			URL:        "http://localhost:3000/api/users?page=2&limit=10",
+			StatusCode: 200, Headers: jsonHeaders,
+			Body: `[{"id": 41, "name": "Grace", "email": "grace@example.com"}, {"id": 42, "name": "Ada", "email": "ada@example.com", "manager": 41}]`,
+		},
+	}
+}
+
+func TestGenerateOpenAPIDraft_Express(t *testing.T) {
+	dir := writeTestFiles(t, routeFixtures["express"])
+	routes, _, err := ScanRoutes(dir, dir, "express")
+	if err != nil {
+		t.Fatalf("failed to scan routes: %v", err)
+	}
+	records := openAPIDraftRecords()
+	result, err := GenerateOpenAPIDraft(routes, records, OpenAPIDraftOptions{Title: "Users API"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkOpenAPI30Draft(t, result.YAML)
+
+	if result.Routes != 7 || result.Calls != 5 || result.Paths != 5 || result.Operations != 8 ||
+		result.Observed != 3 || result.CodeOnly != 4 || result.TrafficOnly != 1 {
+		t.Errorf("counts = %+v", *result)
+	}
+	if strings.Join(result.Servers, ",") != "http://localhost:3000" {
+		t.Errorf("servers = %v", result.Servers)
+	}
+
+	spec, err := ParseOpenAPISpec(result.YAML)
+	if err != nil {
+		t.Fatalf("draft does not parse as a spec: %v", err)
+	}
+	var ops []string
+	for _, op := range spec.Operations {
+		ops = append(ops, op.Method+" "+op.Path+" "+op.ID)
+	}
+	for _, want := range []string{
+		"GET / get",
+		"GET /api/users list",
+		"POST /api/users create",
+		"GET /api/users/{id} show",
+		"PUT /api/users/{id} update",
+		"DELETE /api/users/{id} destroy",
+		"DELETE /api/admin/cache clearCache",
+		"GET /api/orders/{orderId} getApiOrdersByOrderId",
+	} {
+		if !strings.Contains(strings.Join(ops, "\n"), want) {
+			t.Errorf("no operation %q in:\n%s", want, strings.Join(ops, "\n"))
+		}
+	}
+
+	// Every call the draft was built from validates against it
+	for _, r := range records {
+		op := spec.FindOperation(r.Request.Method, r.URL)
+		if op == nil {
+			t.Errorf("%s %s: no operation in the draft", r.Request.Method, r.URL)
+			continue
+		}
+		resp := &HTTPResponse{StatusCode: r.StatusCode, Headers: r.Headers, Body: r.Body}
+		if mismatches, _ := spec.ValidateResponse(op, resp); len(mismatches) > 0 {
+			t.Errorf("%s %s (%d) does not match the draft: %v", r.Request.Method, r.URL, r.StatusCode, mismatches)
+		}
+	}
+
+	// ...and a body that differs from what was seen doesn't
+	op := spec.FindOperation("GET", "http://localhost:3000/api/users/42")
+	resp := &HTTPResponse{StatusCode: 200, Headers: records[0].Headers, Body: `{"id": "42", "name": "Ada", "email": "not an email", "tags": []}`}
+	mismatches, _ := spec.ValidateResponse(op, resp)
+	if got := strings.Join(mismatches, "\n"); !strings.Contains(got, "body.id: expected integer, got string") || !strings.Contains(got, "body.email:") {
+		t.Errorf("mismatches = %v", mismatches)
+	}
+}
+
+func TestGenerateOpenAPIDraft_NetHTTP(t *testing.T) {
+	dir := writeTestFiles(t, routeFixtures["net/http"])
+	routes, _, err := ScanRoutes(dir, dir, "other")
+	if err != nil {
+		t.Fatalf("failed to scan routes: %v", err)
+	}
+	records := []HistoryRecord{{
+		Request:    HTTPRequest{Method: "HEAD", URL: "http://localhost:8080/healthz"},
+		URL:        "http://localhost:8080/healthz",
+		StatusCode: 200,
+	}}
+	result, err := GenerateOpenAPIDraft(routes, records, OpenAPIDraftOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkOpenAPI30Draft(t, result.YAML)
+
+	yamlText := string(result.YAML)
+	for _, want := range []string{
+		"openapi: 3.0.3\ninfo:\n  title: API\n  version: 0.1.0\n",
+		"  /users/{id}:\n    get:\n",
+		"        - name: id\n          in: path\n          required: true\n          schema:\n            type: string\n",
+		// The ANY route that was called gets the called method only
+		"  /healthz:\n    head:\n",
+	} {
+		if !strings.Contains(yamlText, want) {
+			t.Errorf("draft does not contain %q:\n%s", want, yamlText)
+		}
+	}
+	if strings.Contains(yamlText, "  /healthz:\n    get:") {
+		t.Errorf("called ANY route also listed as GET:\n%s", yamlText)
+	}
+	if !strings.Contains(strings.Join(result.Notes, "\n"), "1 route(s) registered for any method are listed as GET") {
+		t.Errorf("notes = %v", result.Notes)
+	}
+}
+
+func TestGenerateOpenAPITool(t *testing.T) {
+	workDir := writeTestFiles(t, routeFixtures["express"])
+	history := NewResponseHistory(filepath.Join(workDir, ".zap"))
+	tool := NewGenerateOpenAPITool(history, workDir, "express")
+
+	got, err := tool.Execute(`{"source": "code", "title": "Users API"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !regexp.MustCompile(`4 path\(s\), 7 operation\(s\) from 7 route\(s\) in code and 0 call\(s\) in history`).MatchString(got) {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, DefaultOpenAPIDraftPath))
+	if err != nil {
+		t.Fatalf("draft not written: %v", err)
+	}
+	checkOpenAPI30Draft(t, data)
+	if !strings.Contains(string(data), "title: Users API") {
+		t.Errorf("title not set:\n%s", data)
+	}
+
+	if _, err := tool.Execute(`{"source": "code"}`); err == nil || !strings.Contains(err.Error(), "already exists; set overwrite") {
+		t.Errorf("second run: error = %v", err)
+	}
+	if _, err := tool.Execute(`{"source": "code", "overwrite": true}`); err != nil {
+		t.Errorf("overwrite: %v", err)
+	}
+	if _, err := tool.Execute(`{"source": "traffic", "output": "other.yaml"}`); err == nil || !strings.Contains(err.Error(), "nothing to generate from") {
+		t.Errorf("empty history: error = %v", err)
+	}
+	if _, err := tool.Execute(`{"source": "logs"}`); err == nil || !strings.Contains(err.Error(), "source must be") {
+		t.Errorf("bad source: error = %v", err)
+	}
+	if _, err := tool.Execute(`{"output": "../spec.yaml"}`); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("output outside the project: error = %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/blackcoderx/zap/pkg/storage"
+)
+
+// OpenAPIImportOptions controls ImportOpenAPI
+type OpenAPIImportOptions struct {
+	Env       string // Environment that gets BASE_URL and path parameter examples
+	Suite     bool   // Also write a smoke-test suite to .zap/suites/
+	Overwrite bool   // Replace existing saved requests and environment variables
+}
+
+// OpenAPIImportResult lists what ImportOpenAPI wrote
+type OpenAPIImportResult struct {
+	Requests  []string // Saved requests
+	Skipped   []string // Existing requests that were left alone
+	EnvPath   string
+	EnvVars   []string // Variables written to the environment
+	AuthVars  []string // Credential variables used by the requests, to be set by the user
+	SuitePath string
+	Notes     []string
+}
+
+// defaultImportBaseURL is used when a spec has no absolute server URL
+const defaultImportBaseURL = "http://localhost:8080"
+
+// pathParamPattern matches {param} in a path template
+var pathParamPattern = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// ImportOpenAPI scaffolds a project from spec: a saved request per operation
+// with example parameters and bodies, BASE_URL and path parameters in an
+// environment, and optionally a smoke-test suite asserting the documented
+// success status of every read-only operation.
+func ImportOpenAPI(zapDir string, spec *OpenAPISpec, opts OpenAPIImportOptions) (*OpenAPIImportResult, error) {
+	if len(spec.Operations) == 0 {
+		return nil, fmt.Errorf("spec has no operations under 'paths'")
+	}
+	if opts.Env == "" {
+		opts.Env = "dev"
+	}
+
+	result := &OpenAPIImportResult{}
+	envVars := make(map[string]string)
+	authVars := make(map[string]bool)
+
+	baseURL, notes := spec.baseURL()
+	result.Notes = append(result.Notes, notes...)
+	envVars["BASE_URL"] = baseURL
+
+	var suite TestSuiteParams
+	requestsDir := storage.GetRequestsDir(zapDir)
+	seen := make(map[string]bool)
+	for _, op := range spec.Operations {
+		req := spec.requestFor(op, envVars, authVars)
+
+		// operationIds should be unique, but specs don't always comply
+		name := op.Name()
+		for i := 2; seen[name]; i++ {
+			name = fmt.Sprintf("%s-%d", op.Name(), i)
+		}
+		seen[name] = true
+		req.Name = name
+
+		path := filepath.Join(requestsDir, name+".yaml")
+		if _, err := os.Stat(path); err == nil && !opts.Overwrite {
+			result.Skipped = append(result.Skipped, name)
+		} else {
+			if err := storage.SaveRequest(req, path); err != nil {
+				return result, fmt.Errorf("failed to save request '%s': %w", name, err)
+			}
+			result.Requests = append(result.Requests, name)
+		}
+
+		if status := op.SuccessStatus(); opts.Suite && status != 0 && (op.Method == "GET" || op.Method == "HEAD") {
+			suite.Tests = append(suite.Tests, TestDefinition{
+				Name:       fmt.Sprintf("%s %s", op.Method, op.Path),
+				Request:    suiteRequest(req),
+				Assertions: &AssertParams{StatusCode: &status},
+			})
+		}
+	}
+	core.UpdateManifestCounts(zapDir)
+
+	result.EnvPath = filepath.Join(storage.GetEnvironmentsDir(zapDir), opts.Env+".yaml")
+	written, err := storage.SetEnvironmentVariables(result.EnvPath, envVars, opts.Overwrite)
+	if err != nil {
+		return result, err
+	}
+	result.EnvVars = written
+	if len(written) < len(envVars) {
+		result.Notes = append(result.Notes, fmt.Sprintf("Kept %d existing variable(s) in %s (use --overwrite to replace them)", len(envVars)-len(written), result.EnvPath))
+	}
+
+	for name := range authVars {
+		result.AuthVars = append(result.AuthVars, name)
+	}
+	sort.Strings(result.AuthVars)
+
+	if opts.Suite {
+		if len(suite.Tests) == 0 {
+			result.Notes = append(result.Notes, "No smoke-test suite written: no GET operations document a 2xx response")
+		} else {
+			title := spec.Title
+			if title == "" {
+				title = "OpenAPI"
+			}
+			suite.Name = title + " smoke tests"
+			suite.OnFailure = "continue"
+			if result.SuitePath, err = SaveSuite(zapDir, kebabCase(title)+"-smoke", suite); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// baseURL returns the BASE_URL for the spec's first server, with notes
+// on how it was chosen
+func (s *OpenAPISpec) baseURL() (string, []string) {
+	baseURL := defaultImportBaseURL
+	var notes []string
+	switch {
+	case len(s.Servers) == 0:
+		notes = append(notes, fmt.Sprintf("The spec lists no servers; BASE_URL defaults to %s", baseURL))
+	case strings.HasPrefix(s.Servers[0], "http://") || strings.HasPrefix(s.Servers[0], "https://"):
+		baseURL = s.Servers[0]
+	default:
+		baseURL += s.Servers[0]
+		notes = append(notes, fmt.Sprintf("The server URL %q is relative; BASE_URL is %s", s.Servers[0], baseURL))
+	}
+	if len(s.Servers) > 1 {
+		notes = append(notes, fmt.Sprintf("Using the first of %d servers; others: %s", len(s.Servers), strings.Join(s.Servers[1:], ", ")))
+	}
+	return baseURL, notes
+}
+
+// Format describes the import for the user
+func (r *OpenAPIImportResult) Format() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Imported %d request(s) into .zap/requests/\n", len(r.Requests)))
+	if len(r.Skipped) > 0 {
+		sb.WriteString(fmt.Sprintf("Skipped %d existing request(s) (use --overwrite to replace): %s\n", len(r.Skipped), strings.Join(r.Skipped, ", ")))
+	}
+	if len(r.EnvVars) > 0 {
+		sb.WriteString(fmt.Sprintf("Set %s in %s\n", strings.Join(r.EnvVars, ", "), r.EnvPath))
+	}
+	if r.SuitePath != "" {
+		sb.WriteString(fmt.Sprintf("Wrote smoke-test suite %s\n", r.SuitePath))
+	}
+	if len(r.AuthVars) > 0 {
+		sb.WriteString(fmt.Sprintf("Requests use credential variables %s: set them with the variable tool (\"secret\": true) or in .env\n", strings.Join(r.AuthVars, ", ")))
+	}
+	for _, note := range r.Notes {
+		sb.WriteString("Note: " + note + "\n")
+	}
+	return sb.String()
+}
+
+// requestFor builds the saved request of an operation. Path parameter
+// examples are added to envVars, credential placeholders to authVars.
+func (s *OpenAPISpec) requestFor(op OpenAPIOperation, envVars map[string]string, authVars map[string]bool) storage.Request {
+	req := storage.Request{
+		Method:  op.Method,
+		URL:     "{{BASE_URL}}" + pathParamPattern.ReplaceAllString(op.Path, "{{$1}}"),
+		Headers: make(map[string]string),
+		Query:   make(map[string]string),
+	}
+
+	for _, param := range op.Parameters {
+		value := param.Example
+		if value == nil {
+			value = s.Example(param.Schema, true)
+		}
+		example := fmt.Sprint(value)
+		if value == nil {
+			example = "1"
+		}
+
+		switch param.In {
+		case "path":
+			if _, ok := envVars[param.Name]; !ok {
+				envVars[param.Name] = example
+			}
+		case "query":
+			if param.Required || param.Example != nil {
+				req.Query[param.Name] = example
+			}
+		case "header":
+			if param.Required && !strings.EqualFold(param.Name, "Authorization") {
+				req.Headers[param.Name] = example
+			}
+		}
+	}
+
+	for _, name := range op.Security {
+		scheme, ok := s.SecuritySchemes[name]
+		if !ok {
+			continue
+		}
+		switch {
+		case scheme.Type == "http" && scheme.Scheme == "basic":
+			req.Headers["Authorization"] = "Basic {{BASIC_AUTH}}"
+			authVars["BASIC_AUTH"] = true
+		case scheme.Type == "http", scheme.Type == "oauth2", scheme.Type == "openIdConnect":
+			req.Headers["Authorization"] = "Bearer {{API_TOKEN}}"
+			authVars["API_TOKEN"] = true
+		case scheme.Type == "apiKey" && scheme.In == "query":
+			req.Query[scheme.Name] = "{{API_KEY}}"
+			authVars["API_KEY"] = true
+		case scheme.Type == "apiKey" && scheme.In == "cookie":
+			req.Headers["Cookie"] = scheme.Name + "={{API_KEY}}"
+			authVars["API_KEY"] = true
+		case scheme.Type == "apiKey":
+			req.Headers[scheme.Name] = "{{API_KEY}}"
+			authVars["API_KEY"] = true
+		}
+	}
+
+	if op.BodySchema != nil || op.BodyExample != nil {
+		body := op.BodyExample
+		if body == nil {
+			body = s.Example(op.BodySchema, true)
+		}
+		req.Headers["Content-Type"] = op.BodyContentType
+		if fields, ok := body.(map[string]interface{}); ok && op.BodyContentType == "application/x-www-form-urlencoded" {
+			form := url.Values{}
+			for key, value := range fields {
+				form.Set(key, fmt.Sprint(value))
+			}
+			body = form.Encode()
+		}
+		req.Body = body
+	}
+
+	if len(req.Headers) == 0 {
+		req.Headers = nil
+	}
+	if len(req.Query) == 0 {
+		req.Query = nil
+	}
+	return req
+}
+
+// suiteRequest converts a saved request into a suite test request
+func suiteRequest(req storage.Request) HTTPRequest {
+	httpReq := HTTPRequest{Method: req.Method, URL: req.URL, Headers: req.Headers, Body: req.Body}
+	if len(req.Query) > 0 {
+		httpReq.Query = make(map[string]interface{}, len(req.Query))
+		for key, value := range req.Query {
+			httpReq.Query[key] = value
+		}
+	}
+	return httpReq
+}
@@ -0,0 +1,450 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+)
+
+// OpenAPIValidationTool checks the last response against an operation of an OpenAPI spec
+type OpenAPIValidationTool struct {
+	httpTool        *HTTPTool
+	responseManager *ResponseManager
+	varStore        *VariableStore
+}
+
+// NewOpenAPIValidationTool creates a new OpenAPI contract validation tool
+func NewOpenAPIValidationTool(httpTool *HTTPTool, responseManager *ResponseManager, varStore *VariableStore) *OpenAPIValidationTool {
+	return &OpenAPIValidationTool{
+		httpTool:        httpTool,
+		responseManager: responseManager,
+		varStore:        varStore,
+	}
+}
+
+// OpenAPIValidationParams defines contract validation parameters
+type OpenAPIValidationParams struct {
+	Spec        string `json:"spec"`                   // Spec file within the project (YAML or JSON)
+	OperationID string `json:"operation_id,omitempty"` // Defaults to the operation matching the last request
+	Method      string `json:"method,omitempty"`       // Overrides the last request's method
+	Path        string `json:"path,omitempty"`         // Overrides the last request's URL, e.g. /pets/123
+}
+
+// Name returns the tool name
+func (t *OpenAPIValidationTool) Name() string {
+	return "validate_openapi"
+}
+
+// Description returns the tool description
+func (t *OpenAPIValidationTool) Description() string {
+	return "Check the last HTTP response against its operation in an OpenAPI 3.x/Swagger 2.0 spec: documented status code, required response headers, content type, and body schema. The operation is found from the last request's method and path, or given by operation_id. Reports each mismatched field."
+}
+
+// Parameters returns the tool parameter description
+func (t *OpenAPIValidationTool) Parameters() string {
+	return `{
+  "spec": "openapi.yaml",
+  "operation_id": "optional, e.g. getPetById (default: match the last request)",
+  "method": "optional, overrides the last request's method",
+  "path": "optional, overrides the last request's path, e.g. /pets/123"
+}`
+}
+
+// Execute validates the last response
+func (t *OpenAPIValidationTool) Execute(args string) (string, error) {
+	if t.varStore != nil {
+		args = t.varStore.Substitute(args)
+	}
+
+	var params OpenAPIValidationParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	if params.Spec == "" {
+		return "", fmt.Errorf("'spec' is required")
+	}
+
+	resp := t.responseManager.GetHTTPResponse()
+	if resp == nil {
+		return "", fmt.Errorf("no HTTP response available - make an http_request first")
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	specPath, err := ValidatePathWithinWorkDir(params.Spec, workDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid spec: %w", err)
+	}
+	spec, err := LoadOpenAPISpec(specPath)
+	if err != nil {
+		return "", err
+	}
+
+	var op *OpenAPIOperation
+	if params.OperationID != "" {
+		op = spec.OperationByID(params.OperationID)
+		if op == nil {
+			return "", fmt.Errorf("operation '%s' not found in %s", params.OperationID, params.Spec)
+		}
+	} else {
+		method, path := params.Method, params.Path
+		if last := t.lastRequest(); last != nil {
+			if method == "" {
+				method = last.Method
+			}
+			if path == "" {
+				path = last.URL
+			}
+		}
+		if path == "" {
+			return "", fmt.Errorf("no request has been executed yet; pass 'operation_id' or 'method' and 'path'")
+		}
+		if method == "" {
+			method = "GET"
+		}
+		if op = spec.FindOperation(method, path); op == nil {
+			return "", fmt.Errorf("no operation in %s matches %s %s; pass 'operation_id'", params.Spec, strings.ToUpper(method), path)
+		}
+	}
+
+	mismatches, notes := spec.ValidateResponse(op, resp)
+	return formatOpenAPIValidation(op, resp.StatusCode, mismatches, notes), nil
+}
+
+// lastRequest returns the last request sent by http_request, if any
+func (t *OpenAPIValidationTool) lastRequest() *HTTPRequest {
+	if t.httpTool == nil {
+		return nil
+	}
+	return t.httpTool.LastRequest()
+}
+
+// OperationByID returns the operation with the given operationId
+func (s *OpenAPISpec) OperationByID(id string) *OpenAPIOperation {
+	for i := range s.Operations {
+		if s.Operations[i].ID == id || s.Operations[i].Name() == kebabCase(id) {
+			return &s.Operations[i]
+		}
+	}
+	return nil
+}
+
+// FindOperation returns the operation whose path template matches the end
+// of rawURL, which may be a full URL or a path with {{VAR}} placeholders.
+// The server's base path may precede the template. When several templates
+// match, the longest one with the most literal segments wins, so /pets/mine
+// is preferred over /pets/{petId}.
+func (s *OpenAPISpec) FindOperation(method, rawURL string) *OpenAPIOperation {
+	actual := urlPathSegments(rawURL)
+	method = strings.ToUpper(method)
+
+	var best *OpenAPIOperation
+	bestLen, bestLiterals := -1, -1
+	for i := range s.Operations {
+		op := &s.Operations[i]
+		if op.Method != method {
+			continue
+		}
+		template := strings.FieldsFunc(op.Path, func(r rune) bool { return r == '/' })
+		if len(template) > len(actual) {
+			continue
+		}
+		literals, ok := matchPathSegments(template, actual[len(actual)-len(template):])
+		if !ok {
+			continue
+		}
+		if len(template) > bestLen || (len(template) == bestLen && literals > bestLiterals) {
+			best, bestLen, bestLiterals = op, len(template), literals
+		}
+	}
+	return best
+}
+
+// urlPathSegments returns the path segments of a URL, dropping the scheme,
+// host, query and a leading {{BASE_URL}}-style placeholder
+func urlPathSegments(rawURL string) []string {
+	if i := strings.IndexAny(rawURL, "?#"); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		rawURL = rawURL[i+3:]
+		if j := strings.Index(rawURL, "/"); j >= 0 {
+			rawURL = rawURL[j:]
+		} else {
+			rawURL = ""
+		}
+	} else if strings.HasPrefix(rawURL, "{{") {
+		if end := strings.Index(rawURL, "}}"); end >= 0 {
+			rawURL = rawURL[end+2:]
+		}
+	}
+	return strings.FieldsFunc(rawURL, func(r rune) bool { return r == '/' })
+}
+
+// matchPathSegments matches actual segments against a path template and
+// returns how many template segments are literal
+func matchPathSegments(template, actual []string) (int, bool) {
+	literals := 0
+	for i, segment := range template {
+		if !strings.Contains(segment, "{") {
+			if segment != actual[i] {
+				return 0, false
+			}
+			literals++
+			continue
+		}
+		// An unresolved {{VAR}} fills any parameter
+		if strings.Contains(actual[i], "{{") {
+			continue
+		}
+		literal := pathParamPattern.Split(segment, -1)
+		for j := range literal {
+			literal[j] = regexp.QuoteMeta(literal[j])
+		}
+		pattern := "^" + strings.Join(literal, "[^/]+") + "$"
+		if ok, _ := regexp.MatchString(pattern, actual[i]); !ok {
+			return 0, false
+		}
+	}
+	return literals, true
+}
+
+// ValidateResponse checks a response against an operation. It returns the
+// mismatches and notes about checks that were skipped.
+func (s *OpenAPISpec) ValidateResponse(op *OpenAPIOperation, resp *HTTPResponse) (mismatches []string, notes []string) {
+	documented, key := op.responseFor(resp.StatusCode)
+	if key == "" {
+		statuses := make([]string, 0, len(op.Responses))
+		for status := range op.Responses {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+		mismatches = append(mismatches, fmt.Sprintf("status: %d is not documented (documented: %s)", resp.StatusCode, strings.Join(statuses, ", ")))
+		return mismatches, notes
+	}
+
+	headerNames := make([]string, 0, len(documented.Headers))
+	for name := range documented.Headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	for _, name := range headerNames {
+		header := documented.Headers[name]
+		value := headerValue(resp.Headers, name)
+		if value == "" {
+			if header.Required {
+				mismatches = append(mismatches, fmt.Sprintf("header %s: required by the spec but missing", name))
+			}
+			continue
+		}
+		if header.Schema != nil {
+			mismatches = append(mismatches, s.validateValue(header.Schema, coerceHeaderValue(value, schemaType(header.Schema)), "header "+name)...)
+		}
+	}
+
+	if documented.Schema == nil {
+		if strings.TrimSpace(resp.Body) != "" && documented.ContentType == "" {
+			notes = append(notes, fmt.Sprintf("response %s documents no body", key))
+		}
+		return mismatches, notes
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(headerValue(resp.Headers, "Content-Type"))
+	if documented.ContentType != "" && mediaType != "" && mediaType != documented.ContentType &&
+		!(isJSONMediaType(documented.ContentType) && isJSONMediaType(mediaType)) {
+		mismatches = append(mismatches, fmt.Sprintf("Content-Type: expected %s, got %s", documented.ContentType, mediaType))
+	}
+
+	switch {
+	case resp.Binary || resp.Truncated || resp.BodyFile != "":
+		notes = append(notes, "body not validated: it is binary, truncated or saved to a file")
+	case !isJSONMediaType(documented.ContentType):
+		notes = append(notes, fmt.Sprintf("body not validated: only JSON bodies are checked (%s documented)", documented.ContentType))
+	default:
+		var body interface{}
+		if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+			mismatches = append(mismatches, fmt.Sprintf("body: not valid JSON: %v", err))
+			break
+		}
+		mismatches = append(mismatches, s.validateValue(documented.Schema, body, "body")...)
+	}
+	return mismatches, notes
+}
+
+// responseFor returns the documented response for a status code: the exact
+// code, then its range ("2XX"), then "default". key is empty when none is documented.
+func (op *OpenAPIOperation) responseFor(status int) (OpenAPIResponse, string) {
+	for _, key := range []string{strconv.Itoa(status), fmt.Sprintf("%dXX", status/100), "DEFAULT"} {
+		if resp, ok := op.Responses[key]; ok {
+			return resp, key
+		}
+	}
+	return OpenAPIResponse{}, ""
+}
+
+// openAPISchemaURI names the JSON Schema converted from a spec's schema
+const openAPISchemaURI = "urn:zap:openapi-schema"
+
+// validateValue validates value against an OpenAPI schema and returns one
+// message per violation, prefixed with the path of the offending field
+// below name (e.g. "body.items.0.id")
+func (s *OpenAPISpec) validateValue(schema map[string]interface{}, value interface{}, name string) []string {
+	// OpenAPI 3.0 and Swagger 2.0 schemas follow draft-04 (boolean
+	// exclusiveMinimum); 3.1 uses draft 2020-12
+	draft := jsonschema.Draft4
+	if strings.HasPrefix(s.Version, "3.1") {
+		draft = jsonschema.Draft2020
+	}
+	noRefs := func(uri string) (interface{}, error) {
+		return nil, fmt.Errorf("external $ref '%s' is not supported", uri)
+	}
+	compiled, err := compileJSONSchema(s.JSONSchema(schema), openAPISchemaURI, draft, noRefs)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: invalid schema: %v", name, err)}
+	}
+	errs, err := validateJSONSchema(compiled, value, openAPISchemaURI)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", name, err)}
+	}
+
+	var problems []string
+	for _, schemaErr := range errs {
+		field := name + strings.ReplaceAll(schemaErr.InstancePath, "/", ".")
+		switch k := schemaErr.Kind.(type) {
+		case *kind.Required:
+			for _, missing := range k.Missing {
+				problems = append(problems, fmt.Sprintf("%s.%s: required by the spec but missing", field, missing))
+			}
+			continue
+		case *kind.Type:
+			schemaErr.Message = fmt.Sprintf("expected %s, got %s", strings.Join(k.Want, " or "), k.Got)
+		}
+		problems = append(problems, fmt.Sprintf("%s: %s", field, schemaErr.Message))
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// JSONSchema converts an OpenAPI schema into a standalone JSON Schema:
+// local $refs are inlined, "nullable" becomes a null type, and writeOnly
+// properties (never sent in responses) are no longer required.
+func (s *OpenAPISpec) JSONSchema(schema map[string]interface{}) map[string]interface{} {
+	return s.jsonSchema(schema, 0)
+}
+
+func (s *OpenAPISpec) jsonSchema(schema map[string]interface{}, depth int) map[string]interface{} {
+	schema = s.resolve(schema)
+	if schema == nil || depth > maxSchemaDepth {
+		return map[string]interface{}{}
+	}
+
+	out := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		switch key {
+		case "properties", "patternProperties", "definitions", "$defs":
+			props := make(map[string]interface{})
+			for name, sub := range mapField(schema, key) {
+				props[name] = s.jsonSchema(s.resolve(sub), depth+1)
+			}
+			out[key] = props
+		case "items", "additionalProperties", "not":
+			if sub, ok := value.(map[string]interface{}); ok {
+				out[key] = s.jsonSchema(sub, depth+1)
+			} else {
+				out[key] = value
+			}
+		case "allOf", "anyOf", "oneOf":
+			var list []interface{}
+			for _, sub := range listField(schema, key) {
+				list = append(list, s.jsonSchema(s.resolve(sub), depth+1))
+			}
+			out[key] = list
+		case "nullable", "x-nullable", "discriminator", "xml", "example", "externalDocs", "deprecated", "readOnly", "writeOnly":
+			// OpenAPI keywords, not JSON Schema
+		default:
+			out[key] = value
+		}
+	}
+
+	if schema["nullable"] == true || schema["x-nullable"] == true {
+		if t, ok := out["type"].(string); ok {
+			out["type"] = []interface{}{t, "null"}
+		}
+		if enum, ok := out["enum"].([]interface{}); ok {
+			out["enum"] = append(enum, nil)
+		}
+	}
+
+	if required := listField(schema, "required"); len(required) > 0 {
+		var kept []interface{}
+		for _, name := range required {
+			if prop := s.resolve(mapField(schema, "properties")[fmt.Sprint(name)]); prop == nil || prop["writeOnly"] != true {
+				kept = append(kept, name)
+			}
+		}
+		out["required"] = kept
+		if len(kept) == 0 {
+			delete(out, "required")
+		}
+	}
+	return out
+}
+
+// coerceHeaderValue converts a header value to the type its schema expects, so
+// "60" validates as an integer. Unparseable values stay strings and fail
+// the type check.
+func coerceHeaderValue(value, schemaType string) interface{} {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// isJSONMediaType reports whether a media type is JSON
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// formatOpenAPIValidation formats the result of validate_openapi
+func formatOpenAPIValidation(op *OpenAPIOperation, status int, mismatches, notes []string) string {
+	var sb strings.Builder
+	operation := fmt.Sprintf("%s %s", op.Method, op.Path)
+	if op.ID != "" {
+		operation += " (" + op.ID + ")"
+	}
+
+	if len(mismatches) == 0 {
+		sb.WriteString(fmt.Sprintf("✓ Response %d matches the OpenAPI contract of %s\n", status, operation))
+	} else {
+		sb.WriteString(fmt.Sprintf("✗ Response %d does not match the OpenAPI contract of %s\n\n", status, operation))
+		sb.WriteString(fmt.Sprintf("Found %d mismatch(es):\n", len(mismatches)))
+		for i, mismatch := range mismatches {
+			sb.WriteString(fmt.Sprintf("  %d. %s\n", i+1, mismatch))
+		}
+	}
+	for _, note := range notes {
+		sb.WriteString(fmt.Sprintf("\nNote: %s", note))
+	}
+	return sb.String()
+}
@@ -5,25 +5,31 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"os"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/blackcoderx/zap/pkg/core"
 	"golang.org/x/time/rate"
 )
 
 // PerformanceTool provides load testing capabilities
 type PerformanceTool struct {
+	progressReporter
 	httpTool *HTTPTool
 	varStore *VariableStore
+	zapDir   string // Runs are saved under zapDir/perf-results
 }
 
 // NewPerformanceTool creates a new performance testing tool
-func NewPerformanceTool(httpTool *HTTPTool, varStore *VariableStore) *PerformanceTool {
+func NewPerformanceTool(httpTool *HTTPTool, varStore *VariableStore, zapDir string) *PerformanceTool {
 	return &PerformanceTool{
 		httpTool: httpTool,
 		varStore: varStore,
+		zapDir:   zapDir,
 	}
 }
 
@@ -34,7 +40,7 @@ func (t *PerformanceTool) Name() string {
 
 // Description returns the tool description
 func (t *PerformanceTool) Description() string {
-	return "Run load tests against API endpoints with concurrent users and measure latency metrics (p50/p95/p99)"
+	return "Run load tests against API endpoints with concurrent users and measure latency metrics (p50/p95/p99). Each run is saved to .zap/perf-results/; compare a run with a baseline run to get p95/p99/throughput regression percentages."
 }
 
 // Parameters returns the tool parameter description
@@ -44,7 +50,16 @@ func (t *PerformanceTool) Parameters() string {
   "duration_seconds": 30,
   "requests_per_second": 10,
   "concurrent_users": 5,
-  "ramp_up_seconds": 5
+  "ramp_up_seconds": 5,
+  "profile": "constant|ramp|spike|soak",
+  "stages": [{"duration_seconds": 30, "target_rps": 50}, {"duration_seconds": 60, "target_rps": 50}, {"duration_seconds": 30, "target_rps": 0}],
+  "report_interval_seconds": 60,
+  "samples_csv": "perf/samples.csv (optional raw samples for offline analysis)",
+  "name": "before-cache (optional label for the saved run)",
+  "baseline": "previous|<run id or name> (optional: compare with a saved run)",
+  "threshold": 10,
+  "action": "run|list|compare (compare: 'run' against 'baseline' without a new load test)",
+  "run": "last"
 }`
 }
 
@@ -55,6 +70,17 @@ type PerformanceTestParams struct {
 	RequestsPerSecond int         `json:"requests_per_second"`
 	ConcurrentUsers   int         `json:"concurrent_users"`
 	RampUpSeconds     int         `json:"ramp_up_seconds"`
+
+	Profile               string      `json:"profile,omitempty"`                 // constant (default), ramp, spike or soak
+	Stages                []LoadStage `json:"stages,omitempty"`                  // Custom profile; sets the duration
+	ReportIntervalSeconds int         `json:"report_interval_seconds,omitempty"` // Interim reports; default on for soak and staged runs
+	SamplesCSV            string      `json:"samples_csv,omitempty"`             // Write every request's timing to this CSV file
+
+	Action    string  `json:"action,omitempty"`    // "run" (default), "list" or "compare"
+	Name      string  `json:"name,omitempty"`      // Label for the saved run
+	Baseline  string  `json:"baseline,omitempty"`  // Saved run to compare with: id, name, position or "previous"
+	Run       string  `json:"run,omitempty"`       // For compare: the run to check (default: the newest)
+	Threshold float64 `json:"threshold,omitempty"` // Regression percent that counts (default 10)
 }
 
 // PerformanceResult holds the results of a performance test
@@ -72,10 +98,24 @@ type PerformanceResult struct {
 	AvgLatency       time.Duration `json:"avg_latency_ms"`
 	ErrorRate        float64       `json:"error_rate_percent"`
 	StatusCodeCounts map[int]int64 `json:"status_codes"`
+
+	Profile   string         `json:"profile,omitempty"`
+	Intervals []PerfInterval `json:"intervals,omitempty"` // Interim reports, in order
+
+	Histogram       []LatencyBucket       `json:"histogram,omitempty"`        // Log-linear latency buckets
+	StatusLatencies map[int]StatusLatency `json:"status_latencies,omitempty"` // Latency per status code
+
+	samples []perfSample
 }
 
 // Execute runs the performance test
 func (t *PerformanceTool) Execute(args string) (string, error) {
+	// Keep the request as called, with {{VAR}} placeholders, for the saved run
+	var raw PerformanceTestParams
+	if err := json.Unmarshal([]byte(args), &raw); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
 	// Substitute variables if available
 	if t.varStore != nil {
 		args = t.varStore.Substitute(args)
@@ -86,6 +126,23 @@ func (t *PerformanceTool) Execute(args string) (string, error) {
 		return "", fmt.Errorf("failed to parse arguments: %w", err)
 	}
 
+	if params.Threshold <= 0 {
+		params.Threshold = defaultPerfThreshold
+	}
+	switch params.Action {
+	case "", "run":
+	case "list":
+		runs, err := loadPerfRuns(t.zapDir)
+		if err != nil {
+			return "", err
+		}
+		return formatPerfRuns(runs, 20), nil
+	case "compare":
+		return t.compareRuns(params)
+	default:
+		return "", fmt.Errorf("unknown action: %s (use 'run', 'list', or 'compare')", params.Action)
+	}
+
 	// Validate parameters
 	if err := t.validateParams(&params); err != nil {
 		return "", err
@@ -96,12 +153,103 @@ func (t *PerformanceTool) Execute(args string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	output := t.formatResult(result)
+	if params.SamplesCSV != "" {
+		path, err := writeSamplesCSV(params.SamplesCSV, result.samples)
+		if err != nil {
+			output += fmt.Sprintf("\n\n⚠ Samples not written: %v", err)
+		} else {
+			output += fmt.Sprintf("\n\nWrote %d sample(s) to %s", len(result.samples), path)
+		}
+	}
+
+	run := newPerfRun(params.Name, raw.Request, params, result)
+	path, err := savePerfRun(t.zapDir, &run)
+	if err != nil {
+		return output + fmt.Sprintf("\n\n⚠ Run not saved: %v", err), nil
+	}
+	output += fmt.Sprintf("\n\nSaved as run %s (%s)", run.ID, path)
+
+	if params.Baseline != "" {
+		comparison, err := t.compareWithBaseline(&run, params.Baseline, params.Threshold)
+		if err != nil {
+			return output + fmt.Sprintf("\n\n⚠ No comparison: %v", err), nil
+		}
+		output += "\n\n" + comparison
+	}
+	return output, nil
+}
+
+// compareRuns compares two saved runs
+func (t *PerformanceTool) compareRuns(params PerformanceTestParams) (string, error) {
+	runs, err := loadPerfRuns(t.zapDir)
+	if err != nil {
+		return "", err
+	}
+	run, err := findPerfRun(runs, params.Run)
+	if err != nil {
+		return "", err
+	}
+	if params.Baseline == "" {
+		params.Baseline = "previous"
+	}
+	comparison, err := t.compareWithBaseline(run, params.Baseline, params.Threshold)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Run %s: %s %s\n%s", run.ID, run.Method, run.URL, comparison), nil
+}
 
-	return t.formatResult(result), nil
+// compareWithBaseline compares run with a saved run; "previous" is the last
+// run of the same request before it
+func (t *PerformanceTool) compareWithBaseline(run *PerfRun, ref string, threshold float64) (string, error) {
+	runs, err := loadPerfRuns(t.zapDir)
+	if err != nil {
+		return "", err
+	}
+	var baseline *PerfRun
+	if ref == "previous" {
+		if baseline = previousPerfRun(runs, run); baseline == nil {
+			return "", fmt.Errorf("no earlier run of %s %s to compare with", run.Method, run.URL)
+		}
+	} else if baseline, err = findPerfRun(runs, ref); err != nil {
+		return "", err
+	}
+	if baseline.ID == run.ID {
+		return "", fmt.Errorf("the baseline is the run itself")
+	}
+	return comparePerfRuns(baseline, run, threshold), nil
 }
 
 // validateParams validates performance test parameters
 func (t *PerformanceTool) validateParams(params *PerformanceTestParams) error {
+	if len(params.Stages) > 0 {
+		if params.Profile != "" {
+			return fmt.Errorf("use either profile or stages, not both")
+		}
+		// Stages set the duration and the peak rate
+		params.DurationSeconds, params.RequestsPerSecond = 0, 0
+		for i, stage := range params.Stages {
+			if stage.DurationSeconds <= 0 || stage.TargetRPS < 0 {
+				return fmt.Errorf("stage %d: duration_seconds must be greater than 0 and target_rps not negative", i+1)
+			}
+			params.DurationSeconds += stage.DurationSeconds
+			params.RequestsPerSecond = max(params.RequestsPerSecond, stage.TargetRPS)
+		}
+	}
+	if params.SamplesCSV != "" {
+		// Checked up front so a long run isn't lost to a bad path
+		workDir, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		if _, err := ValidatePathWithinWorkDir(params.SamplesCSV, workDir); err != nil {
+			return fmt.Errorf("invalid samples_csv: %w", err)
+		}
+	}
+	if params.ReportIntervalSeconds < 0 {
+		return fmt.Errorf("report_interval_seconds cannot be negative")
+	}
 	if params.DurationSeconds <= 0 {
 		return fmt.Errorf("duration_seconds must be greater than 0")
 	}
@@ -125,11 +273,17 @@ func (t *PerformanceTool) validateParams(params *PerformanceTestParams) error {
 
 // runTest executes the performance test
 func (t *PerformanceTool) runTest(params PerformanceTestParams) (*PerformanceResult, error) {
+	plan, err := newLoadPlan(params.Profile, params.Stages, params.DurationSeconds, params.RequestsPerSecond)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(params.DurationSeconds)*time.Second)
 	defer cancel()
 
-	// Create rate limiter
-	limiter := rate.NewLimiter(rate.Limit(params.RequestsPerSecond), params.RequestsPerSecond)
+	// Create rate limiter; a staged plan moves its limit as the run goes
+	initial := max(1, plan.rateAt(0))
+	limiter := rate.NewLimiter(rate.Limit(initial), max(1, int(initial)))
 
 	// Shared state
 	var (
@@ -137,6 +291,7 @@ func (t *PerformanceTool) runTest(params PerformanceTestParams) (*PerformanceRes
 		successfulReqs int64
 		failedReqs     int64
 		latencies      []time.Duration
+		samples        []perfSample
 		latenciesMu    sync.Mutex
 		statusCodes    = make(map[int]int64)
 		statusCodesMu  sync.Mutex
@@ -144,6 +299,72 @@ func (t *PerformanceTool) runTest(params PerformanceTestParams) (*PerformanceRes
 	)
 
 	startTime := time.Now()
+	recorder := newIntervalRecorder(startTime)
+
+	if plan.staged() {
+		go func() {
+			ticker := time.NewTicker(200 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case now := <-ticker.C:
+					// Below 1 req/s the limiter would starve; ramps to 0 end at 1
+					target := max(1, plan.rateAt(now.Sub(startTime)))
+					limiter.SetLimit(rate.Limit(target))
+					limiter.SetBurst(max(1, int(target)))
+				}
+			}
+		}()
+	}
+
+	interval := time.Duration(params.ReportIntervalSeconds) * time.Second
+	if interval == 0 {
+		interval = defaultReportInterval(params.Profile, plan, params.DurationSeconds)
+	}
+	reporterDone := make(chan struct{})
+	if interval > 0 {
+		go func() {
+			defer close(reporterDone)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case now := <-ticker.C:
+					recorder.flush(now, plan.rateAt(now.Sub(startTime)))
+				}
+			}
+		}()
+	}
+
+	// Report progress so the run doesn't look stuck
+	go func() {
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		var last int64
+		lastTime := startTime
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				done := atomic.LoadInt64(&totalReqs)
+				elapsed := now.Sub(startTime)
+				t.reportProgress(core.ProgressEvent{
+					Tool:      t.Name(),
+					Completed: done,
+					Unit:      "requests",
+					Elapsed:   elapsed,
+					Remaining: max(0, time.Duration(params.DurationSeconds)*time.Second-elapsed),
+					RPS:       float64(done-last) / now.Sub(lastTime).Seconds(),
+				})
+				last, lastTime = done, now
+			}
+		}
+	}()
 
 	// Launch concurrent workers with ramp-up
 	for i := 0; i < params.ConcurrentUsers; i++ {
@@ -184,6 +405,17 @@ func (t *PerformanceTool) runTest(params PerformanceTestParams) (*PerformanceRes
 					reqDuration := time.Since(reqStart)
 
 					atomic.AddInt64(&totalReqs, 1)
+					recorder.record(reqDuration, err != nil || resp.StatusCode >= 500)
+
+					sample := perfSample{offset: reqStart.Sub(startTime), latency: reqDuration}
+					if err != nil {
+						sample.err = err.Error()
+					} else {
+						sample.status = resp.StatusCode
+					}
+					latenciesMu.Lock()
+					samples = append(samples, sample)
+					latenciesMu.Unlock()
 
 					if err != nil {
 						atomic.AddInt64(&failedReqs, 1)
@@ -208,6 +440,10 @@ func (t *PerformanceTool) runTest(params PerformanceTestParams) (*PerformanceRes
 	// Wait for all workers to complete
 	wg.Wait()
 	totalDuration := time.Since(startTime)
+	if interval > 0 {
+		<-reporterDone
+		recorder.finish(startTime.Add(totalDuration), plan.rateAt(totalDuration))
+	}
 
 	// Calculate statistics
 	result := &PerformanceResult{
@@ -216,6 +452,13 @@ func (t *PerformanceTool) runTest(params PerformanceTestParams) (*PerformanceRes
 		FailedReqs:       failedReqs,
 		Duration:         totalDuration,
 		StatusCodeCounts: statusCodes,
+		Profile:          params.Profile,
+		Intervals:        recorder.intervals,
+		StatusLatencies:  statusLatencies(samples),
+		samples:          samples,
+	}
+	if len(params.Stages) > 0 {
+		result.Profile = "stages"
 	}
 
 	if totalReqs > 0 {
@@ -241,6 +484,7 @@ func (t *PerformanceTool) runTest(params PerformanceTestParams) (*PerformanceRes
 			sum += lat
 		}
 		result.AvgLatency = sum / time.Duration(len(latencies))
+		result.Histogram = latencyHistogram(latencies)
 	}
 
 	return result, nil
@@ -297,11 +541,25 @@ Status Code Distribution:`,
 		result.MaxLatency,
 	)
 
+	if result.Profile != "" {
+		output = strings.Replace(output, "\nDuration:", fmt.Sprintf("\nProfile: %s\nDuration:", result.Profile), 1)
+	}
+
 	// Add status code distribution
 	for code, count := range result.StatusCodeCounts {
 		percentage := float64(count) / float64(result.SuccessfulReqs) * 100
 		output += fmt.Sprintf("\n  %d: %d (%.1f%%)", code, count, percentage)
 	}
 
+	if len(result.StatusLatencies) > 0 {
+		output += formatStatusLatencies(result.StatusLatencies)
+	}
+	if len(result.Histogram) > 0 {
+		output += formatHistogram(result.Histogram)
+	}
+	if len(result.Intervals) > 0 {
+		output += formatIntervals(result.Intervals)
+	}
+
 	return output
 }
@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxHistogramBuckets caps the buckets shown; neighbours are merged beyond it
+const maxHistogramBuckets = 20
+
+// perfSample is one request of a performance run
+type perfSample struct {
+	offset  time.Duration // Since the start of the run
+	latency time.Duration
+	status  int    // 0 when the request failed
+	err     string // Why it failed
+}
+
+// LatencyBucket is a histogram bucket: requests that took at most UpperMs
+type LatencyBucket struct {
+	UpperMs    float64 `json:"le_ms"`
+	Count      int64   `json:"count"`
+	Cumulative float64 `json:"cumulative_percent"`
+}
+
+// StatusLatency summarizes the latency of the responses with one status code
+type StatusLatency struct {
+	Count int64   `json:"count"`
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	P99Ms float64 `json:"p99_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+// bucketBound returns the upper bound of d's bucket. Like HDR histograms,
+// every power of two is split into four equal buckets, so a bucket's width is
+// at most a quarter of its values whatever the scale.
+func bucketBound(d time.Duration) time.Duration {
+	us := max(1, (d.Microseconds()))
+	if us <= 4 {
+		return time.Duration(us) * time.Microsecond
+	}
+	base := int64(1) << (bits.Len64(uint64(us)) - 1)
+	width := base / 4
+	upper := base + (us-base+width-1)/width*width
+	return time.Duration(upper) * time.Microsecond
+}
+
+// latencyHistogram buckets sorted latencies, merging neighbours so there
+// are at most maxHistogramBuckets
+func latencyHistogram(sorted []time.Duration) []LatencyBucket {
+	if len(sorted) == 0 {
+		return nil
+	}
+	type bucket struct {
+		upper time.Duration
+		count int64
+	}
+	var buckets []bucket
+	for _, d := range sorted {
+		upper := bucketBound(d)
+		if n := len(buckets); n > 0 && buckets[n-1].upper == upper {
+			buckets[n-1].count++
+			continue
+		}
+		buckets = append(buckets, bucket{upper: upper, count: 1})
+	}
+	for len(buckets) > maxHistogramBuckets {
+		merged := make([]bucket, 0, (len(buckets)+1)/2)
+		for i := 0; i < len(buckets); i += 2 {
+			b := buckets[i]
+			if i+1 < len(buckets) {
+				b = bucket{upper: buckets[i+1].upper, count: b.count + buckets[i+1].count}
+			}
+			merged = append(merged, b)
+		}
+		buckets = merged
+	}
+
+	histogram := make([]LatencyBucket, len(buckets))
+	var cumulative int64
+	for i, b := range buckets {
+		cumulative += b.count
+		histogram[i] = LatencyBucket{
+			UpperMs:    durationMs(b.upper),
+			Count:      b.count,
+			Cumulative: float64(cumulative) * 100 / float64(len(sorted)),
+		}
+	}
+	return histogram
+}
+
+// statusLatencies summarizes latency per response status code
+func statusLatencies(samples []perfSample) map[int]StatusLatency {
+	byStatus := make(map[int][]time.Duration)
+	for _, s := range samples {
+		if s.status != 0 {
+			byStatus[s.status] = append(byStatus[s.status], s.latency)
+		}
+	}
+	result := make(map[int]StatusLatency, len(byStatus))
+	for status, latencies := range byStatus {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		result[status] = StatusLatency{
+			Count: int64(len(latencies)),
+			P50Ms: durationMs(latencies[percentileIndex(len(latencies), 50)]),
+			P95Ms: durationMs(latencies[percentileIndex(len(latencies), 95)]),
+			P99Ms: durationMs(latencies[percentileIndex(len(latencies), 99)]),
+			MaxMs: durationMs(latencies[len(latencies)-1]),
+		}
+	}
+	return result
+}
+
+// writeSamplesCSV writes the raw samples, in the order they were sent, to a
+// CSV file within the project
+func writeSamplesCSV(name string, samples []perfSample) (string, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	path, err := ValidatePathWithinWorkDir(name, workDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid samples_csv: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create samples folder: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create samples file: %w", err)
+	}
+	defer f.Close()
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].offset < samples[j].offset })
+	w := csv.NewWriter(f)
+	w.Write([]string{"offset_ms", "latency_ms", "status", "error"})
+	for _, s := range samples {
+		w.Write([]string{
+			strconv.FormatFloat(durationMs(s.offset), 'f', 3, 64),
+			strconv.FormatFloat(durationMs(s.latency), 'f', 3, 64),
+			strconv.Itoa(s.status),
+			s.err,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to write samples file: %w", err)
+	}
+	return path, nil
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// formatHistogram renders the histogram with bars
+func formatHistogram(histogram []LatencyBucket) string {
+	var largest int64
+	for _, b := range histogram {
+		largest = max(largest, b.Count)
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\nLatency Histogram:")
+	for _, b := range histogram {
+		bar := strings.Repeat("█", max(1, int(b.Count*30/largest)))
+		sb.WriteString(fmt.Sprintf("\n  ≤ %-10s %-30s %d (%.1f%%)", formatMs(b.UpperMs), bar, b.Count, b.Cumulative))
+	}
+	return sb.String()
+}
+
+// formatStatusLatencies renders latency per status code
+func formatStatusLatencies(byStatus map[int]StatusLatency) string {
+	statuses := make([]int, 0, len(byStatus))
+	for status := range byStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	var sb strings.Builder
+	sb.WriteString("\n\nLatency by Status:")
+	for _, status := range statuses {
+		s := byStatus[status]
+		sb.WriteString(fmt.Sprintf("\n  %d: %d req  p50 %s  p95 %s  p99 %s  max %s",
+			status, s.Count, formatMs(s.P50Ms), formatMs(s.P95Ms), formatMs(s.P99Ms), formatMs(s.MaxMs)))
+	}
+	return sb.String()
+}
+
+// formatMs renders milliseconds compactly
+func formatMs(ms float64) string {
+	if ms < 1 {
+		return fmt.Sprintf("%.0fµs", ms*1000)
+	}
+	if ms < 1000 {
+		return strconv.FormatFloat(ms, 'f', -1, 64) + "ms"
+	}
+	return strconv.FormatFloat(ms/1000, 'f', 2, 64) + "s"
+}
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Load profiles of performance_test
+const (
+	ProfileConstant = "constant" // requests_per_second for the whole run
+	ProfileRamp     = "ramp"     // Ramp up over a quarter, hold for half, ramp down
+	ProfileSpike    = "spike"    // A tenth of the rate, a burst at the full rate, back down
+	ProfileSoak     = "soak"     // Constant rate over a long run, with interim reports
+)
+
+var loadProfiles = []string{ProfileConstant, ProfileRamp, ProfileSpike, ProfileSoak}
+
+// LoadStage is a step of a load profile: the rate moves linearly from the
+// previous stage's target (0 before the first) to target_rps over the stage
+type LoadStage struct {
+	DurationSeconds int `json:"duration_seconds"`
+	TargetRPS       int `json:"target_rps"`
+}
+
+// loadPlan is the request rate over a run
+type loadPlan struct {
+	start  float64
+	stages []LoadStage // Empty: start for the whole run
+}
+
+// newLoadPlan builds the plan of a profile or custom stages
+func newLoadPlan(profile string, stages []LoadStage, duration, rps int) (loadPlan, error) {
+	if len(stages) > 0 {
+		return loadPlan{stages: stages}, nil
+	}
+	switch profile {
+	case "", ProfileConstant, ProfileSoak:
+		return loadPlan{start: float64(rps)}, nil
+	case ProfileRamp:
+		up, hold := duration/4, duration/2
+		return loadPlan{stages: []LoadStage{
+			{DurationSeconds: up, TargetRPS: rps},
+			{DurationSeconds: hold, TargetRPS: rps},
+			{DurationSeconds: duration - up - hold, TargetRPS: 0},
+		}}, nil
+	case ProfileSpike:
+		if duration < 10 {
+			return loadPlan{}, fmt.Errorf("a spike profile needs duration_seconds of at least 10")
+		}
+		base := max(1, rps/10)
+		before, spike := duration*2/5, max(1, duration/5)
+		return loadPlan{start: float64(base), stages: []LoadStage{
+			{DurationSeconds: before, TargetRPS: base},
+			{DurationSeconds: 1, TargetRPS: rps},
+			{DurationSeconds: spike - 1, TargetRPS: rps},
+			{DurationSeconds: 1, TargetRPS: base},
+			{DurationSeconds: duration - before - spike - 1, TargetRPS: base},
+		}}, nil
+	}
+	return loadPlan{}, fmt.Errorf("unknown profile '%s' (use %s, or stages)", profile, strings.Join(loadProfiles, ", "))
+}
+
+// rateAt returns the target rate at a point of the run
+func (p loadPlan) rateAt(elapsed time.Duration) float64 {
+	rate, t := p.start, elapsed.Seconds()
+	for _, stage := range p.stages {
+		d := float64(stage.DurationSeconds)
+		if t < d {
+			return rate + (float64(stage.TargetRPS)-rate)*t/d
+		}
+		t -= d
+		rate = float64(stage.TargetRPS)
+	}
+	return rate
+}
+
+// staged reports whether the rate changes during the run
+func (p loadPlan) staged() bool {
+	return len(p.stages) > 0
+}
+
+// PerfInterval is an interim report: what happened in one slice of a run
+type PerfInterval struct {
+	Elapsed    time.Duration `json:"elapsed"`
+	Requests   int64         `json:"requests"`
+	Errors     int64         `json:"errors"` // Failed requests and 5xx responses
+	RPS        float64       `json:"rps"`
+	TargetRPS  float64       `json:"target_rps"`
+	LatencyP50 time.Duration `json:"latency_p50"`
+	LatencyP95 time.Duration `json:"latency_p95"`
+}
+
+// intervalRecorder collects samples for the current interval
+type intervalRecorder struct {
+	mu        sync.Mutex
+	started   time.Time
+	last      time.Time
+	requests  int64
+	errors    int64
+	latencies []time.Duration
+	intervals []PerfInterval
+}
+
+func newIntervalRecorder(start time.Time) *intervalRecorder {
+	return &intervalRecorder{started: start, last: start}
+}
+
+// record adds a request to the current interval
+func (r *intervalRecorder) record(latency time.Duration, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	if failed {
+		r.errors++
+		return
+	}
+	r.latencies = append(r.latencies, latency)
+}
+
+// flush closes the current interval
+func (r *intervalRecorder) flush(now time.Time, target float64) PerfInterval {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	interval := PerfInterval{
+		Elapsed:   now.Sub(r.started),
+		Requests:  r.requests,
+		Errors:    r.errors,
+		TargetRPS: target,
+	}
+	if secs := now.Sub(r.last).Seconds(); secs > 0 {
+		interval.RPS = float64(r.requests) / secs
+	}
+	if len(r.latencies) > 0 {
+		sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+		interval.LatencyP50 = r.latencies[percentileIndex(len(r.latencies), 50)]
+		interval.LatencyP95 = r.latencies[percentileIndex(len(r.latencies), 95)]
+	}
+	r.intervals = append(r.intervals, interval)
+	r.last, r.requests, r.errors, r.latencies = now, 0, 0, nil
+	return interval
+}
+
+// finish closes the last, partial interval, unless it is empty and short
+func (r *intervalRecorder) finish(end time.Time, target float64) {
+	r.mu.Lock()
+	pending := r.requests > 0 || end.Sub(r.last) >= time.Second
+	r.mu.Unlock()
+	if pending {
+		r.flush(end, target)
+	}
+}
+
+// defaultReportInterval picks the interim report interval: about a dozen
+// reports for soak and staged runs, none for short constant runs
+func defaultReportInterval(profile string, plan loadPlan, duration int) time.Duration {
+	if profile != ProfileSoak && !plan.staged() {
+		return 0
+	}
+	return time.Duration(max(5, duration/12)) * time.Second
+}
+
+// formatIntervals renders the interim reports as a table
+func formatIntervals(intervals []PerfInterval) string {
+	var sb strings.Builder
+	sb.WriteString("\n\nInterim Reports:\n")
+	sb.WriteString(fmt.Sprintf("  %-8s %8s %8s %10s %10s %7s\n", "Time", "Target", "RPS", "P50", "P95", "Errors"))
+	for _, in := range intervals {
+		sb.WriteString(fmt.Sprintf("  %-8s %8.1f %8.1f %10s %10s %7d\n",
+			formatElapsed(in.Elapsed), in.TargetRPS, in.RPS, in.LatencyP50.Round(time.Microsecond*100), in.LatencyP95.Round(time.Microsecond*100), in.Errors))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatElapsed renders a duration as m:ss
+func formatElapsed(d time.Duration) string {
+	secs := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
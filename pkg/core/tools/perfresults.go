@@ -0,0 +1,250 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/core"
+)
+
+// PerfResultsDir is the folder (inside .zap) holding saved performance runs
+const PerfResultsDir = "perf-results"
+
+// defaultPerfThreshold is the regression, in percent, that fails a comparison
+const defaultPerfThreshold = 10.0
+
+// PerfRun is a saved performance_test run
+type PerfRun struct {
+	ID                string        `json:"id"` // e.g. 20261015-153012
+	Name              string        `json:"name,omitempty"`
+	Timestamp         time.Time     `json:"timestamp"`
+	Method            string        `json:"method"`
+	URL               string        `json:"url"` // As called, with {{VAR}} placeholders
+	DurationSeconds   int           `json:"duration_seconds"`
+	RequestsPerSecond int           `json:"requests_per_second"`
+	ConcurrentUsers   int           `json:"concurrent_users"`
+	Profile           string        `json:"profile,omitempty"`
+	TotalRequests     int64         `json:"total_requests"`
+	FailedRequests    int64         `json:"failed_requests"`
+	Throughput        float64       `json:"throughput_rps"`
+	ErrorRate         float64       `json:"error_rate_percent"`
+	P50Ms             float64       `json:"p50_ms"`
+	P95Ms             float64       `json:"p95_ms"`
+	P99Ms             float64       `json:"p99_ms"`
+	AvgMs             float64       `json:"avg_ms"`
+	MaxMs             float64       `json:"max_ms"`
+	StatusCodes       map[int]int64 `json:"status_codes,omitempty"`
+}
+
+// newPerfRun records a result with the request as called
+func newPerfRun(name string, raw HTTPRequest, params PerformanceTestParams, result *PerformanceResult) PerfRun {
+	now := time.Now()
+	return PerfRun{
+		ID:                now.Format("20060102-150405"),
+		Name:              name,
+		Timestamp:         now,
+		Method:            strings.ToUpper(raw.Method),
+		URL:               core.RedactSecrets(raw.URL),
+		DurationSeconds:   params.DurationSeconds,
+		RequestsPerSecond: params.RequestsPerSecond,
+		ConcurrentUsers:   params.ConcurrentUsers,
+		Profile:           result.Profile,
+		TotalRequests:     result.TotalRequests,
+		FailedRequests:    result.FailedReqs,
+		Throughput:        result.Throughput,
+		ErrorRate:         result.ErrorRate,
+		P50Ms:             durationMs(result.LatencyP50),
+		P95Ms:             durationMs(result.LatencyP95),
+		P99Ms:             durationMs(result.LatencyP99),
+		AvgMs:             durationMs(result.AvgLatency),
+		MaxMs:             durationMs(result.MaxLatency),
+		StatusCodes:       result.StatusCodeCounts,
+	}
+}
+
+// savePerfRun writes a run to zapDir/perf-results, making its ID unique
+func savePerfRun(zapDir string, run *PerfRun) (string, error) {
+	dir := filepath.Join(zapDir, PerfResultsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create perf-results folder: %w", err)
+	}
+	id := run.ID
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(dir, id+".json")); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", run.ID, n)
+	}
+	run.ID = id
+
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal perf run: %w", err)
+	}
+	path := filepath.Join(dir, id+".json")
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write perf run: %w", err)
+	}
+	return path, nil
+}
+
+// loadPerfRuns returns the saved runs, newest first
+func loadPerfRuns(zapDir string) ([]PerfRun, error) {
+	files, err := filepath.Glob(filepath.Join(zapDir, PerfResultsDir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list perf runs: %w", err)
+	}
+	var runs []PerfRun
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read perf run: %w", err)
+		}
+		var run PerfRun
+		if json.Unmarshal(data, &run) != nil {
+			continue // Not a run file
+		}
+		runs = append(runs, run)
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Timestamp.After(runs[j].Timestamp)
+	})
+	return runs, nil
+}
+
+// findPerfRun looks a run up by position ("1" or "last" is the newest), ID,
+// unique ID prefix, or name (the newest run with it)
+func findPerfRun(runs []PerfRun, ref string) (*PerfRun, error) {
+	ref = strings.TrimSpace(ref)
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no saved performance runs yet")
+	}
+	if ref == "" || ref == "last" {
+		ref = "1"
+	}
+	if n, err := strconv.Atoi(ref); err == nil && n > 0 && n < 10000 {
+		if n > len(runs) {
+			return nil, fmt.Errorf("there are only %d saved run(s)", len(runs))
+		}
+		return &runs[n-1], nil
+	}
+
+	var found *PerfRun
+	for i := range runs {
+		if runs[i].ID == ref {
+			return &runs[i], nil
+		}
+		if strings.HasPrefix(runs[i].ID, ref) {
+			if found != nil {
+				return nil, fmt.Errorf("'%s' matches more than one run; use the full id", ref)
+			}
+			found = &runs[i]
+		}
+	}
+	if found != nil {
+		return found, nil
+	}
+	for i := range runs {
+		if runs[i].Name == ref {
+			return &runs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no saved run '%s' (use action 'list')", ref)
+}
+
+// previousPerfRun returns the newest run of the same request saved before run
+func previousPerfRun(runs []PerfRun, run *PerfRun) *PerfRun {
+	for i := range runs {
+		r := &runs[i]
+		if r.ID != run.ID && r.Timestamp.Before(run.Timestamp) && r.Method == run.Method && r.URL == run.URL {
+			return r
+		}
+	}
+	return nil
+}
+
+// formatPerfRuns lists saved runs
+func formatPerfRuns(runs []PerfRun, limit int) string {
+	if len(runs) == 0 {
+		return "No saved performance runs yet."
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Saved performance runs (%d, newest first):\n", len(runs)))
+	for i, run := range runs {
+		if i == limit {
+			sb.WriteString(fmt.Sprintf("... and %d older\n", len(runs)-limit))
+			break
+		}
+		label := run.ID
+		if run.Name != "" {
+			label += " (" + run.Name + ")"
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s  %s %s  p95 %.1fms  p99 %.1fms  %.1f req/s  %.1f%% errors\n",
+			i+1, label, run.Method, run.URL, run.P95Ms, run.P99Ms, run.Throughput, run.ErrorRate))
+	}
+	return sb.String()
+}
+
+// comparePerfRuns reports how current moved against baseline. Higher
+// latency and lower throughput beyond threshold percent are regressions.
+func comparePerfRuns(baseline, current *PerfRun, threshold float64) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Comparison with baseline %s", baseline.ID))
+	if baseline.Name != "" {
+		sb.WriteString(" (" + baseline.Name + ")")
+	}
+	sb.WriteString(fmt.Sprintf(", threshold %.0f%%\n", threshold))
+	if baseline.Method != current.Method || baseline.URL != current.URL {
+		sb.WriteString(fmt.Sprintf("⚠ Different request: baseline was %s %s\n", baseline.Method, baseline.URL))
+	}
+	if baseline.RequestsPerSecond != current.RequestsPerSecond || baseline.ConcurrentUsers != current.ConcurrentUsers || baseline.DurationSeconds != current.DurationSeconds || baseline.Profile != current.Profile {
+		sb.WriteString(fmt.Sprintf("⚠ Different load: baseline ran %d req/s with %d users for %ds\n", baseline.RequestsPerSecond, baseline.ConcurrentUsers, baseline.DurationSeconds))
+	}
+	sb.WriteString("\n")
+
+	metrics := []struct {
+		name           string
+		before, after  float64
+		unit           string
+		higherIsBetter bool
+	}{
+		{"P50", baseline.P50Ms, current.P50Ms, "ms", false},
+		{"P95", baseline.P95Ms, current.P95Ms, "ms", false},
+		{"P99", baseline.P99Ms, current.P99Ms, "ms", false},
+		{"Throughput", baseline.Throughput, current.Throughput, " req/s", true},
+	}
+	var regressions []string
+	for _, m := range metrics {
+		change := 0.0
+		if m.before > 0 {
+			change = (m.after - m.before) / m.before * 100
+		}
+		worse := change
+		if m.higherIsBetter {
+			worse = -change
+		}
+		mark := ""
+		if worse > threshold {
+			mark = "  ✗ regression"
+			regressions = append(regressions, fmt.Sprintf("%s %+.1f%%", m.name, change))
+		} else if worse < -threshold {
+			mark = "  ✓ improved"
+		}
+		sb.WriteString(fmt.Sprintf("  %-11s %.1f%s -> %.1f%s  (%+.1f%%)%s\n", m.name+":", m.before, m.unit, m.after, m.unit, change, mark))
+	}
+	sb.WriteString(fmt.Sprintf("  %-11s %.2f%% -> %.2f%%  (%+.2f points)\n", "Errors:", baseline.ErrorRate, current.ErrorRate, current.ErrorRate-baseline.ErrorRate))
+
+	sb.WriteString("\n")
+	if len(regressions) > 0 {
+		sb.WriteString(fmt.Sprintf("✗ Regression: %s\n", strings.Join(regressions, ", ")))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("✓ No regression beyond %.0f%%\n", threshold))
+	return sb.String()
+}
@@ -2,7 +2,9 @@ package tools
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -15,8 +17,13 @@ type PersistenceTool struct {
 	baseDir     string
 	currentEnv  string
 	environment map[string]string
+	httpTool    *HTTPTool // Receives the environment's TLS settings and auth profiles
+	libraryDir  string    // ~/.zap, whose requests/ are shared by every project ("" = none)
 }
 
+// libraryPrefix marks request names in the shared library: ~/get-user
+const libraryPrefix = "~/"
+
 // NewPersistenceTool creates a new persistence tool
 func NewPersistenceTool(baseDir string) *PersistenceTool {
 	return &PersistenceTool{
@@ -33,11 +40,219 @@ func (t *PersistenceTool) SetEnvironment(name string) error {
 	if err != nil {
 		return err
 	}
+	envTLS, err := storage.LoadEnvironmentTLS(envPath)
+	if err != nil {
+		return err
+	}
+	envAuth, err := storage.LoadEnvironmentAuth(envPath)
+	if err != nil {
+		return err
+	}
 	t.currentEnv = name
 	t.environment = env
+	for key, value := range env {
+		registerSecret(key, value)
+	}
+
+	if t.httpTool != nil {
+		var tlsCfg *TLSConfig
+		if envTLS != nil {
+			tlsCfg = &TLSConfig{InsecureSkipVerify: envTLS.InsecureSkipVerify, CAFile: envTLS.CAFile}
+		}
+		t.httpTool.SetDefaultTLS(tlsCfg)
+		t.httpTool.SetAuthProfiles(name, envAuth, env)
+	}
 	return nil
 }
 
+// SaveRequest writes req to .zap/requests/<collection>/<name>.yaml after
+// checking it for plaintext secrets, and returns the file path. An empty
+// collection saves it at the top level.
+func (t *PersistenceTool) SaveRequest(req storage.Request, collection string) (string, error) {
+	return t.saveRequest(t.baseDir, req, collection)
+}
+
+// SaveLibraryRequest is SaveRequest for the shared library in ~/.zap/requests
+func (t *PersistenceTool) SaveLibraryRequest(req storage.Request, collection string) (string, error) {
+	if t.libraryDir == "" {
+		return "", fmt.Errorf("no user request library: there is no home directory")
+	}
+	return t.saveRequest(t.libraryDir, req, collection)
+}
+
+func (t *PersistenceTool) saveRequest(baseDir string, req storage.Request, collection string) (string, error) {
+	// Validate for plaintext secrets
+	if secretErr := core.ValidateRequestForSecrets(req.URL, req.Headers, req.Body); secretErr != "" {
+		return "", fmt.Errorf("cannot save request: %s", secretErr)
+	}
+
+	collection, err := storage.CleanCollection(collection)
+	if err != nil {
+		return "", err
+	}
+	filePath := filepath.Join(storage.GetRequestsDir(baseDir), collection, requestFileName(req.Name))
+
+	if err := storage.SaveRequest(req, filePath); err != nil {
+		return "", err
+	}
+
+	// Update manifest counts
+	if baseDir == t.baseDir {
+		core.UpdateManifestCounts(t.baseDir)
+	}
+
+	return filePath, nil
+}
+
+// SaveHTTPRequest adds req to a REST Client .http file in .zap/requests,
+// replacing a request of the same name, and returns the file path.
+func (t *PersistenceTool) SaveHTTPRequest(req storage.Request, file string) (string, error) {
+	if secretErr := core.ValidateRequestForSecrets(req.URL, req.Headers, req.Body); secretErr != "" {
+		return "", fmt.Errorf("cannot save request: %s", secretErr)
+	}
+	if !storage.IsHTTPFile(file) {
+		file += ".http"
+	}
+	if filepath.IsAbs(file) || strings.Contains(file, "..") {
+		return "", fmt.Errorf("file must be a path inside .zap/requests")
+	}
+
+	filePath := filepath.Join(storage.GetRequestsDir(t.baseDir), file)
+	if err := storage.SaveHTTPRequest(req, filePath); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// LoadRequest reads a saved request by name or filename, without
+// substituting variables. Requests in .http files are found by their
+// @name, or as file.http#name; only the file's own variables are applied.
+// Names not found in the project are looked up in the shared library,
+// which "~/name" refers to directly.
+func (t *PersistenceTool) LoadRequest(name string) (*storage.Request, error) {
+	if rest, ok := strings.CutPrefix(name, libraryPrefix); ok && t.libraryDir != "" {
+		return loadRequestFrom(t.libraryDir, rest)
+	}
+
+	req, err := loadRequestFrom(t.baseDir, name)
+	if err != nil && errors.Is(err, os.ErrNotExist) && t.libraryDir != "" {
+		if libraryReq, libraryErr := loadRequestFrom(t.libraryDir, name); libraryErr == nil {
+			return libraryReq, nil
+		}
+	}
+	return req, err
+}
+
+// loadRequestFrom loads a request from the requests/ folder of baseDir
+func loadRequestFrom(baseDir, name string) (*storage.Request, error) {
+	requestsDir := storage.GetRequestsDir(baseDir)
+	if file, reqName, ok := strings.Cut(name, "#"); ok && storage.IsHTTPFile(file) {
+		httpFile, err := storage.LoadHTTPFile(filepath.Join(requestsDir, file))
+		if err != nil {
+			return nil, err
+		}
+		req, found := httpFile.Request(reqName)
+		if !found {
+			return nil, fmt.Errorf("request '%s' not found in %s", reqName, file)
+		}
+		return req, nil
+	}
+
+	filePath, err := requestFile(baseDir, name)
+	if err != nil {
+		return nil, err
+	}
+	req, err := storage.LoadRequest(filePath)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		if httpReq, httpErr := storage.FindHTTPRequest(requestsDir, name); httpErr == nil {
+			return httpReq, nil
+		}
+	}
+	return req, err
+}
+
+// MoveRequest moves a saved request, or a whole .http file, into collection
+// ("" for the top level) and returns its new path.
+func (t *PersistenceTool) MoveRequest(name, collection string) (string, error) {
+	collection, err := storage.CleanCollection(collection)
+	if err != nil {
+		return "", err
+	}
+	filePath, err := requestFile(t.baseDir, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return "", fmt.Errorf("request '%s' not found", name)
+	}
+
+	newPath, err := storage.MoveRequest(storage.GetRequestsDir(t.baseDir), filePath, collection)
+	if err != nil {
+		return "", err
+	}
+	core.UpdateManifestCounts(t.baseDir)
+	return newPath, nil
+}
+
+// requestFile resolves a saved request name to its file in the requests/
+// folder of baseDir. The name may include its collection ("users/get-user");
+// a bare name is also looked up in every collection.
+func requestFile(baseDir, name string) (string, error) {
+	requestsDir := storage.GetRequestsDir(baseDir)
+	filename := filepath.FromSlash(name)
+	if !strings.HasSuffix(filename, ".yaml") && !strings.HasSuffix(filename, ".yml") && !storage.IsHTTPFile(filename) {
+		filename = filepath.Join(filepath.Dir(filename), requestFileName(filepath.Base(filename)))
+	}
+	if strings.Contains(filename, "..") {
+		return "", fmt.Errorf("invalid request name '%s'", name)
+	}
+
+	filePath := filepath.Join(requestsDir, filename)
+	if _, err := os.Stat(filePath); err == nil || filepath.Base(filename) != filename {
+		return filePath, nil
+	}
+	found, err := storage.FindRequestFile(requestsDir, filename)
+	if err != nil || found == "" {
+		return filePath, err
+	}
+	return found, nil
+}
+
+// requestFileName returns the file name a request is saved under
+func requestFileName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", "-")) + ".yaml"
+}
+
+// SearchRequests searches the project's saved requests and then the shared
+// library, whose matches get the ~/ prefix
+func (t *PersistenceTool) SearchRequests(query string, tags []string) ([]storage.RequestMatch, error) {
+	matches, err := storage.SearchRequests(t.baseDir, query, tags)
+	if err != nil || t.libraryDir == "" {
+		return matches, err
+	}
+	libraryMatches, err := storage.SearchRequests(t.libraryDir, query, tags)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range libraryMatches {
+		m.Path = libraryPrefix + m.Path
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
+
+// SetLibraryDir sets the user-level ~/.zap folder whose requests/ are shared
+// by every project
+func (t *PersistenceTool) SetLibraryDir(dir string) {
+	t.libraryDir = dir
+}
+
+// SetHTTPTool makes environment switches apply the environment's TLS
+// settings and auth profiles to httpTool.
+func (t *PersistenceTool) SetHTTPTool(httpTool *HTTPTool) {
+	t.httpTool = httpTool
+}
+
 // GetEnvironment returns the current environment variables
 func (t *PersistenceTool) GetEnvironment() map[string]string {
 	return t.environment
@@ -55,7 +270,7 @@ func NewSaveRequestTool(p *PersistenceTool) *SaveRequestTool {
 func (t *SaveRequestTool) Name() string { return "save_request" }
 
 func (t *SaveRequestTool) Description() string {
-	return "Save an API request to a YAML file for later use. Saved requests can be loaded and executed with load_request."
+	return "Save an API request to a YAML file, or to a REST Client .http file, for later use. Saved requests can be loaded and executed with load_request."
 }
 
 func (t *SaveRequestTool) Parameters() string {
@@ -63,18 +278,30 @@ func (t *SaveRequestTool) Parameters() string {
   "name": "string (required) - Name for the request",
   "method": "string (required) - HTTP method (GET, POST, PUT, DELETE)",
   "url": "string (required) - Request URL (can use {{VAR}} placeholders)",
+  "query": "object (optional) - Query parameters (URL-encoded when sent)",
   "headers": "object (optional) - Request headers",
-  "body": "object (optional) - Request body for POST/PUT"
+  "body": "object (optional) - Request body for POST/PUT",
+  "use_auth": "string (optional) - Auth profile of the active environment",
+  "tags": "array (optional) - Labels to find it by with search_requests, e.g. [\"users\", \"smoke\"]",
+  "collection": "string (optional) - Collection (subfolder of .zap/requests) to save it in, nested with slashes (e.g. users/admin)",
+  "library": "boolean (optional) - Save it to the shared library in ~/.zap/requests, available in every project as ~/<name>",
+  "file": "string (optional) - Add the request to this REST Client .http file in .zap/requests (e.g. users.http) instead of its own YAML file"
 }`
 }
 
 func (t *SaveRequestTool) Execute(args string) (string, error) {
 	var params struct {
-		Name    string            `json:"name"`
-		Method  string            `json:"method"`
-		URL     string            `json:"url"`
-		Headers map[string]string `json:"headers"`
-		Body    interface{}       `json:"body"`
+		Name       string            `json:"name"`
+		Method     string            `json:"method"`
+		URL        string            `json:"url"`
+		Query      map[string]string `json:"query"`
+		Headers    map[string]string `json:"headers"`
+		Body       interface{}       `json:"body"`
+		UseAuth    string            `json:"use_auth"`
+		Tags       []string          `json:"tags"`
+		Collection string            `json:"collection"`
+		Library    bool              `json:"library"`
+		File       string            `json:"file"`
 	}
 
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
@@ -91,30 +318,32 @@ func (t *SaveRequestTool) Execute(args string) (string, error) {
 		return "", fmt.Errorf("url is required")
 	}
 
-	// Validate for plaintext secrets
-	if secretErr := core.ValidateRequestForSecrets(params.URL, params.Headers, params.Body); secretErr != "" {
-		return "", fmt.Errorf("cannot save request: %s", secretErr)
-	}
-
 	req := storage.Request{
 		Name:    params.Name,
 		Method:  strings.ToUpper(params.Method),
 		URL:     params.URL,
+		Query:   params.Query,
 		Headers: params.Headers,
 		Body:    params.Body,
+		UseAuth: params.UseAuth,
+		Tags:    params.Tags,
 	}
-
-	// Generate filename from name
-	filename := strings.ToLower(strings.ReplaceAll(params.Name, " ", "-")) + ".yaml"
-	filePath := filepath.Join(storage.GetRequestsDir(t.persistence.baseDir), filename)
-
-	if err := storage.SaveRequest(req, filePath); err != nil {
+	var filePath string
+	var err error
+	switch {
+	case params.Library && params.File != "":
+		return "", fmt.Errorf("library and file can't be combined")
+	case params.Library:
+		filePath, err = t.persistence.SaveLibraryRequest(req, params.Collection)
+	case params.File != "":
+		filePath, err = t.persistence.SaveHTTPRequest(req, filepath.Join(params.Collection, params.File))
+	default:
+		filePath, err = t.persistence.SaveRequest(req, params.Collection)
+	}
+	if err != nil {
 		return "", err
 	}
 
-	// Update manifest counts
-	core.UpdateManifestCounts(t.persistence.baseDir)
-
 	return fmt.Sprintf("Request saved to %s", filePath), nil
 }
 
@@ -134,7 +363,7 @@ func (t *LoadRequestTool) Description() string {
 }
 
 func (t *LoadRequestTool) Parameters() string {
-	return `{"name": "string (required) - Name or filename of the saved request"}`
+	return `{"name": "string (required) - Name or filename of the saved request, optionally with its collection (users/get-user), file.http#name for a request in a .http file, or ~/name for the shared library"}`
 }
 
 func (t *LoadRequestTool) Execute(args string) (string, error) {
@@ -150,14 +379,7 @@ func (t *LoadRequestTool) Execute(args string) (string, error) {
 		return "", fmt.Errorf("name is required")
 	}
 
-	// Try to find the file
-	filename := params.Name
-	if !strings.HasSuffix(filename, ".yaml") && !strings.HasSuffix(filename, ".yml") {
-		filename = strings.ToLower(strings.ReplaceAll(filename, " ", "-")) + ".yaml"
-	}
-
-	filePath := filepath.Join(storage.GetRequestsDir(t.persistence.baseDir), filename)
-	req, err := storage.LoadRequest(filePath)
+	req, err := t.persistence.LoadRequest(params.Name)
 	if err != nil {
 		return "", err
 	}
@@ -166,13 +388,21 @@ func (t *LoadRequestTool) Execute(args string) (string, error) {
 	applied := storage.ApplyEnvironment(req, t.persistence.environment)
 
 	// Format output
-	result, _ := json.MarshalIndent(map[string]interface{}{
+	output := map[string]interface{}{
 		"name":    applied.Name,
 		"method":  applied.Method,
 		"url":     applied.URL,
+		"query":   applied.Query,
 		"headers": applied.Headers,
 		"body":    applied.Body,
-	}, "", "  ")
+	}
+	if applied.UseAuth != "" {
+		output["use_auth"] = applied.UseAuth
+	}
+	if len(applied.Tags) > 0 {
+		output["tags"] = applied.Tags
+	}
+	result, _ := json.MarshalIndent(output, "", "  ")
 
 	return string(result), nil
 }
@@ -189,32 +419,189 @@ func NewListRequestsTool(p *PersistenceTool) *ListRequestsTool {
 func (t *ListRequestsTool) Name() string { return "list_requests" }
 
 func (t *ListRequestsTool) Description() string {
-	return "List all saved API requests in the .zap/requests directory."
+	return "List saved API requests in the .zap/requests directory, optionally only those in one collection (subfolder)."
 }
 
 func (t *ListRequestsTool) Parameters() string {
-	return `{}`
+	return `{"collection": "string (optional) - Only list requests in this collection and the collections nested in it (e.g. users)"}`
 }
 
 func (t *ListRequestsTool) Execute(args string) (string, error) {
-	requests, err := storage.ListRequests(t.persistence.baseDir)
+	var params struct {
+		Collection string `json:"collection"`
+	}
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", fmt.Errorf("invalid parameters: %w", err)
+		}
+	}
+	collection, err := storage.CleanCollection(params.Collection)
+	if err != nil {
+		return "", err
+	}
+
+	requests, err := listCollection(t.persistence.baseDir, collection, "")
 	if err != nil {
 		return "", err
 	}
+	var library []string
+	if t.persistence.libraryDir != "" {
+		if library, err = listCollection(t.persistence.libraryDir, collection, libraryPrefix); err != nil {
+			return "", err
+		}
+	}
 
-	if len(requests) == 0 {
+	if len(requests) == 0 && len(library) == 0 {
+		if collection != "" {
+			return fmt.Sprintf("No saved requests in collection '%s'. Use save_request with \"collection\" or move_request to add some.", collection), nil
+		}
 		return "No saved requests found. Use save_request to save a request.", nil
 	}
 
 	var sb strings.Builder
-	sb.WriteString("Saved requests:\n")
+	if collection != "" {
+		sb.WriteString(fmt.Sprintf("Saved requests in '%s':\n", collection))
+	} else {
+		sb.WriteString("Saved requests:\n")
+	}
 	for _, req := range requests {
 		sb.WriteString("  - " + req + "\n")
 	}
-
+	if len(library) > 0 {
+		sb.WriteString("Shared library (~/.zap/requests):\n")
+		for _, req := range library {
+			sb.WriteString("  - " + req + "\n")
+		}
+	}
 	return sb.String(), nil
 }
 
+// listCollection lists the saved requests of baseDir in collection ("" for
+// all), each prefixed with prefix
+func listCollection(baseDir, collection, prefix string) ([]string, error) {
+	requests, err := storage.ListRequests(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	var listed []string
+	for _, req := range requests {
+		req = filepath.ToSlash(req)
+		if collection != "" && !strings.HasPrefix(req, collection+"/") {
+			continue
+		}
+		listed = append(listed, prefix+req)
+	}
+	return listed, nil
+}
+
+// SearchRequestsTool searches saved requests by name, URL, tags and body
+type SearchRequestsTool struct {
+	persistence *PersistenceTool
+}
+
+func NewSearchRequestsTool(p *PersistenceTool) *SearchRequestsTool {
+	return &SearchRequestsTool{persistence: p}
+}
+
+func (t *SearchRequestsTool) Name() string { return "search_requests" }
+
+func (t *SearchRequestsTool) Description() string {
+	return "Search saved requests in every collection and .http file by name, URL, tags and body, optionally only those with given tags. Use it instead of list_requests once there are more than a few saved requests."
+}
+
+func (t *SearchRequestsTool) Parameters() string {
+	return `{
+  "query": "string (optional) - Words that must all appear in the name, URL, tags or body (case-insensitive)",
+  "tags": "array (optional) - Only requests carrying all of these tags, e.g. [\"smoke\"]"
+}`
+}
+
+func (t *SearchRequestsTool) Execute(args string) (string, error) {
+	var params struct {
+		Query string   `json:"query"`
+		Tags  []string `json:"tags"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if strings.TrimSpace(params.Query) == "" && len(params.Tags) == 0 {
+		return "", fmt.Errorf("query or tags is required")
+	}
+
+	matches, err := t.persistence.SearchRequests(params.Query, params.Tags)
+	if err != nil {
+		return "", err
+	}
+	return FormatRequestMatches(matches), nil
+}
+
+// FormatRequestMatches lists search results one per line, with method, URL,
+// tags and the fields that matched
+func FormatRequestMatches(matches []storage.RequestMatch) string {
+	if len(matches) == 0 {
+		return "No saved requests match."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d saved request(s):\n", len(matches)))
+	for _, m := range matches {
+		sb.WriteString(fmt.Sprintf("  - %s  %s %s", m.Path, m.Request.Method, m.Request.URL))
+		if len(m.Request.Tags) > 0 {
+			sb.WriteString("  [" + strings.Join(m.Request.Tags, ", ") + "]")
+		}
+		if len(m.Fields) > 0 {
+			sb.WriteString("  (matched " + strings.Join(m.Fields, ", ") + ")")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// MoveRequestTool moves saved requests between collections
+type MoveRequestTool struct {
+	persistence *PersistenceTool
+}
+
+func NewMoveRequestTool(p *PersistenceTool) *MoveRequestTool {
+	return &MoveRequestTool{persistence: p}
+}
+
+func (t *MoveRequestTool) Name() string { return "move_request" }
+
+func (t *MoveRequestTool) Description() string {
+	return "Move a saved request (or a whole .http file) into another collection, a subfolder of .zap/requests. Use an empty collection to move it to the top level."
+}
+
+func (t *MoveRequestTool) Parameters() string {
+	return `{
+  "name": "string (required) - Name of the saved request, optionally with its current collection (users/get-user), or a .http file",
+  "collection": "string (required) - Collection to move it to, nested with slashes (e.g. users/admin); \"\" for the top level"
+}`
+}
+
+func (t *MoveRequestTool) Execute(args string) (string, error) {
+	var params struct {
+		Name       string `json:"name"`
+		Collection string `json:"collection"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if params.Name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+
+	newPath, err := t.persistence.MoveRequest(params.Name, params.Collection)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Request moved to %s", newPath), nil
+}
+
 // ListEnvironmentsTool lists available environments
 type ListEnvironmentsTool struct {
 	persistence *PersistenceTool
@@ -293,5 +680,11 @@ func (t *SetEnvironmentTool) Execute(args string) (string, error) {
 		return "", err
 	}
 
-	return fmt.Sprintf("Environment set to '%s'", params.Name), nil
+	result := fmt.Sprintf("Environment set to '%s'", params.Name)
+	if t.persistence.httpTool != nil {
+		if names := t.persistence.httpTool.AuthProfileNames(); len(names) > 0 {
+			result += fmt.Sprintf("\nAuth profiles: %s (use with \"use_auth\")", strings.Join(names, ", "))
+		}
+	}
+	return result, nil
 }
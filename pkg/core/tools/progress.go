@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"sync"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/core"
+)
+
+// progressInterval is how often long-running tools report progress
+const progressInterval = time.Second
+
+// progressReporter lets a long-running tool report progress to the agent.
+// Embedding it implements core.ProgressTool.
+type progressReporter struct {
+	progressMu sync.Mutex
+	progress   chan<- core.ProgressEvent
+}
+
+// SetProgressChannel sets the channel progress is sent on (nil to stop)
+func (p *progressReporter) SetProgressChannel(ch chan<- core.ProgressEvent) {
+	p.progressMu.Lock()
+	defer p.progressMu.Unlock()
+	p.progress = ch
+}
+
+// reportProgress sends an update without blocking; it is dropped when the
+// receiver is busy or nobody is listening
+func (p *progressReporter) reportProgress(event core.ProgressEvent) {
+	p.progressMu.Lock()
+	defer p.progressMu.Unlock()
+	if p.progress == nil {
+		return
+	}
+	select {
+	case p.progress <- event:
+	default:
+	}
+}
@@ -0,0 +1,385 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxRepoFiles    = 5000
+	maxRepoFileSize = 512 * 1024
+	// maxRepoMapLines keeps the map within a tool result's budget; beyond
+	// it only file names and symbol counts are listed
+	maxRepoMapLines = 400
+)
+
+// repoSymbolRule finds one kind of declaration on a line. The pattern's
+// name group is the symbol; a kind group overrides kind, and recv names
+// a Go method's receiver.
+type repoSymbolRule struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// symbolRule compiles a repoSymbolRule
+func symbolRule(kind, pattern string) repoSymbolRule {
+	return repoSymbolRule{kind: kind, pattern: regexp.MustCompile(pattern)}
+}
+
+// javaModifiers are the modifiers before Java, Kotlin and C# declarations
+const javaModifiers = `(?:(?:public|private|protected|internal|static|final|abstract|sealed|partial|data|open|override|virtual|async|synchronized|suspend)\s+)*`
+
+// repoSymbolRules are the declarations indexed per file extension
+var repoSymbolRules = map[string][]repoSymbolRule{
+	".go": {
+		symbolRule("method", `^func\s+\(\s*\w*\s*\*?(?P<recv>\w+)[^)]*\)\s*(?P<name>\w+)`),
+		symbolRule("func", `^func\s+(?P<name>\w+)`),
+		symbolRule("type", `^type\s+(?P<name>\w+)\s+(?P<kind>struct|interface)\b`),
+	},
+	".js":  jsSymbolRules,
+	".jsx": jsSymbolRules,
+	".mjs": jsSymbolRules,
+	".cjs": jsSymbolRules,
+	".ts":  jsSymbolRules,
+	".tsx": jsSymbolRules,
+	".py": {
+		symbolRule("class", `^\s*class\s+(?P<name>\w+)`),
+		symbolRule("func", `^\s*(?:async\s+)?def\s+(?P<name>\w+)`),
+	},
+	".rb": {
+		symbolRule("class", `^\s*(?P<kind>class|module)\s+(?P<name>[\w:]+)`),
+		symbolRule("func", `^\s*def\s+(?P<name>[\w.?!]+)`),
+	},
+	".php": {
+		symbolRule("class", `^\s*(?:abstract\s+|final\s+)?(?P<kind>class|interface|trait)\s+(?P<name>\w+)`),
+		symbolRule("func", `^\s*(?:(?:public|private|protected|static|abstract|final)\s+)*function\s+(?P<name>\w+)`),
+	},
+	".java": javaSymbolRules,
+	".cs":   javaSymbolRules,
+	".kt": {
+		symbolRule("class", `^\s*`+javaModifiers+`(?P<kind>class|interface|object|enum class)\s+(?P<name>\w+)`),
+		symbolRule("func", `^\s*`+javaModifiers+`fun\s+(?:<[^>]+>\s*)?(?:\w+\.)?(?P<name>\w+)\s*\(`),
+	},
+	".rs": {
+		symbolRule("type", `^\s*(?:pub(?:\([^)]*\))?\s+)?(?P<kind>struct|enum|trait)\s+(?P<name>\w+)`),
+		symbolRule("impl", `^\s*impl(?:<[^>]*>)?\s+(?:[\w:]+(?:<[^>]*>)?\s+for\s+)?(?P<name>\w+)`),
+		symbolRule("func", `^\s*(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?fn\s+(?P<name>\w+)`),
+	},
+}
+
+var jsSymbolRules = []repoSymbolRule{
+	symbolRule("class", `^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(?P<name>\w+)`),
+	symbolRule("func", `^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>\w+)`),
+	symbolRule("func", `^\s*(?:export\s+)?(?:const|let|var)\s+(?P<name>\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:\([^)]*\)|\w+)\s*(?::[^=]+)?=>`),
+	symbolRule("interface", `^\s*(?:export\s+)?interface\s+(?P<name>\w+)`),
+	symbolRule("type", `^\s*(?:export\s+)?type\s+(?P<name>\w+)\s*(?:<[^>]*>)?\s*=`),
+	symbolRule("method", `^\s+(?:(?:public|private|protected|static|async|readonly)\s+)*(?P<name>\w+)\s*\([^)]*\)\s*(?::\s*[^{]+)?\{\s*$`),
+}
+
+var javaSymbolRules = []repoSymbolRule{
+	symbolRule("class", `^\s*`+javaModifiers+`(?P<kind>class|interface|enum|record)\s+(?P<name>\w+)`),
+	symbolRule("method", `^\s+`+javaModifiers+`[\w<>\[\],.?]+(?:\s*<[^>]*>)?\s+(?P<name>\w+)\s*\([^;]*$`),
+}
+
+// repoKeywords look like method declarations to the rules above
+var repoKeywords = []string{"if", "for", "while", "switch", "catch", "return", "function", "new", "else", "throw", "await"}
+
+// repoSymbol is a declaration or route in a file
+type repoSymbol struct {
+	line     int
+	kind     string
+	name     string
+	indented bool
+}
+
+// repoFile is an indexed file's outline
+type repoFile struct {
+	rel     string
+	symbols []repoSymbol
+}
+
+// RepoIndex is an outline of the project's source files: their functions,
+// classes and types, and the routes they declare. It is built once in the
+// background at startup and rebuilt on request.
+type RepoIndex struct {
+	workDir   string
+	framework string
+
+	mu      sync.Mutex
+	built   bool
+	builtAt time.Time
+	files   []repoFile
+	scanned int
+}
+
+// NewRepoIndex creates an index of workDir; routes use framework's patterns
+func NewRepoIndex(workDir, framework string) *RepoIndex {
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	return &RepoIndex{workDir: workDir, framework: framework}
+}
+
+// Build indexes the project unless it already is. Callers wait for a
+// build in progress.
+func (x *RepoIndex) Build() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.built {
+		x.build()
+	}
+}
+
+// Refresh indexes the project again
+func (x *RepoIndex) Refresh() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.build()
+}
+
+// build walks the project and outlines each source file. x.mu is held.
+func (x *RepoIndex) build() {
+	byRel := make(map[string]*repoFile)
+	var files []*repoFile
+	ignore := newGitignore(x.workDir)
+	scanned := 0
+	_ = filepath.WalkDir(x.workDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(x.workDir, path)
+		if d.IsDir() {
+			name := d.Name()
+			if path != x.workDir && (strings.HasPrefix(name, ".") || slices.Contains(routeSkipDirs, name) || ignore.ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			ignore.loadDir(rel)
+			return nil
+		}
+		rules := repoSymbolRules[strings.ToLower(filepath.Ext(path))]
+		if rules == nil || isTestSourceFile(d.Name()) || ignore.ignored(rel, false) {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxRepoFileSize {
+			return nil
+		}
+		scanned++
+		f := &repoFile{rel: filepath.ToSlash(rel), symbols: outlineFile(path, rules)}
+		files = append(files, f)
+		byRel[f.rel] = f
+		if scanned >= maxRepoFiles {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+
+	if routes, _, err := ScanRoutes(x.workDir, x.workDir, x.framework); err == nil {
+		for _, r := range routes {
+			f := byRel[filepath.ToSlash(r.File)]
+			if f == nil {
+				continue
+			}
+			name := r.Method + " " + r.Path
+			if r.Handler != "" {
+				name += " -> " + r.Handler
+			}
+			f.symbols = append(f.symbols, repoSymbol{line: r.Line, kind: "route", name: name})
+		}
+	}
+
+	x.files = x.files[:0]
+	for _, f := range files {
+		if len(f.symbols) == 0 {
+			continue
+		}
+		sort.SliceStable(f.symbols, func(i, j int) bool { return f.symbols[i].line < f.symbols[j].line })
+		x.files = append(x.files, *f)
+	}
+	x.scanned = scanned
+	x.built = true
+	x.builtAt = time.Now()
+}
+
+// outlineFile returns the declarations in a file
+func outlineFile(path string, rules []repoSymbolRule) []repoSymbol {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var symbols []repoSymbol
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxRepoFileSize)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		for _, rule := range rules {
+			m := rule.pattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			sym := repoSymbol{line: n, kind: rule.kind, indented: line != "" && (line[0] == ' ' || line[0] == '\t')}
+			recv := ""
+			for i, group := range rule.pattern.SubexpNames() {
+				switch group {
+				case "name":
+					sym.name = m[i]
+				case "kind":
+					sym.kind = strings.TrimSuffix(m[i], " class")
+				case "recv":
+					recv = m[i]
+				}
+			}
+			if slices.Contains(repoKeywords, sym.name) {
+				break
+			}
+			if sym.kind == "func" && sym.indented {
+				sym.kind = "method"
+			}
+			if recv != "" {
+				sym.name = recv + "." + sym.name
+			}
+			symbols = append(symbols, sym)
+			break
+		}
+	}
+	return symbols
+}
+
+// RepoMapTool shows the index so the agent can find code without listing
+// and reading files one by one
+type RepoMapTool struct {
+	index *RepoIndex
+}
+
+// NewRepoMapTool creates a new repository map tool backed by index
+func NewRepoMapTool(index *RepoIndex) *RepoMapTool {
+	return &RepoMapTool{index: index}
+}
+
+// RepoMapParams narrows the map
+type RepoMapParams struct {
+	Path    string `json:"path,omitempty"`    // Only files under this directory (or this file)
+	Query   string `json:"query,omitempty"`   // Only symbols and routes containing this (case-insensitive)
+	Refresh bool   `json:"refresh,omitempty"` // Re-index first, e.g. after files changed
+}
+
+// Name returns the tool name
+func (t *RepoMapTool) Name() string {
+	return "repo_map"
+}
+
+// Description returns the tool description
+func (t *RepoMapTool) Description() string {
+	return "Outline of the project's source files: functions, methods, classes/types and routes with their line numbers. Use it first to find where code lives instead of listing and reading files; narrow with path or query"
+}
+
+// Parameters returns the tool parameter description
+func (t *RepoMapTool) Parameters() string {
+	return `{"path": "string - only files under this directory or this file", "query": "string - only symbols and routes containing this, e.g. user", "refresh": "boolean - re-index first (after files changed)"}`
+}
+
+// Execute renders the map, filtered by params
+func (t *RepoMapTool) Execute(args string) (string, error) {
+	var params RepoMapParams
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", fmt.Errorf("failed to parse arguments: %w", err)
+		}
+	}
+	prefix := ""
+	if params.Path != "" {
+		absPath, err := ValidatePathWithinWorkDir(params.Path, t.index.workDir)
+		if err != nil {
+			return "", err
+		}
+		absWorkDir, _ := filepath.Abs(t.index.workDir)
+		rel, _ := filepath.Rel(absWorkDir, absPath)
+		if rel != "." {
+			prefix = filepath.ToSlash(rel)
+		}
+	}
+
+	if params.Refresh {
+		t.index.Refresh()
+	} else {
+		t.index.Build()
+	}
+	t.index.mu.Lock()
+	defer t.index.mu.Unlock()
+
+	query := strings.ToLower(params.Query)
+	var files []repoFile
+	symbols, routes := 0, 0
+	for _, f := range t.index.files {
+		if prefix != "" && f.rel != prefix && !strings.HasPrefix(f.rel, prefix+"/") {
+			continue
+		}
+		kept := f
+		if query != "" {
+			kept.symbols = nil
+			for _, sym := range f.symbols {
+				if strings.Contains(strings.ToLower(sym.name), query) {
+					kept.symbols = append(kept.symbols, sym)
+				}
+			}
+			if len(kept.symbols) == 0 {
+				continue
+			}
+		}
+		for _, sym := range kept.symbols {
+			if sym.kind == "route" {
+				routes++
+			} else {
+				symbols++
+			}
+		}
+		files = append(files, kept)
+	}
+
+	if len(files) == 0 {
+		return fmt.Sprintf("No symbols found (%d source file(s) indexed)", t.index.scanned), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d file(s), %d symbol(s), %d route(s) (%d source file(s) indexed %s ago)\n",
+		len(files), symbols, routes, t.index.scanned, time.Since(t.index.builtAt).Round(time.Second)))
+
+	lines := 0
+	for _, f := range files {
+		lines += 1 + len(f.symbols)
+	}
+	if lines > maxRepoMapLines {
+		sb.WriteString("Too large to outline; symbol counts per file (narrow with path or query):\n\n")
+		for i, f := range files {
+			if i == maxRepoMapLines {
+				sb.WriteString(fmt.Sprintf("... and %d more file(s)\n", len(files)-i))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("%s (%d)\n", f.rel, len(f.symbols)))
+		}
+		return sb.String(), nil
+	}
+
+	for _, f := range files {
+		sb.WriteString("\n" + f.rel + "\n")
+		for _, sym := range f.symbols {
+			indent := "  "
+			if sym.indented && sym.kind != "route" {
+				indent = "    "
+			}
+			sb.WriteString(fmt.Sprintf("%s%d: %s %s\n", indent, sym.line, sym.kind, sym.name))
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers injected by request ID generation
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	RequestIDHeader      = "X-Request-Id"
+)
+
+// Variables holding the last generated values
+const (
+	idempotencyKeyVar = "idempotency_key"
+	requestIDVar      = "request_id"
+)
+
+// defaultIDTemplate is used when a template is left empty
+const defaultIDTemplate = "{uuid}"
+
+// RequestIDConfig controls automatic Idempotency-Key and X-Request-Id headers.
+// Templates may use {uuid}, {timestamp}, {unix_ms}, {counter} and {random};
+// an empty template uses "{uuid}" and "off" disables that header.
+type RequestIDConfig struct {
+	Enabled        bool   // Inject on every request unless the request opts out
+	IdempotencyKey string // Sent on POST and PATCH
+	RequestID      string // Sent on every request
+}
+
+// requestIDGenerator renders request ID templates
+type requestIDGenerator struct {
+	mu      sync.Mutex
+	cfg     RequestIDConfig
+	counter uint64 // Requests with IDs injected, for {counter}
+}
+
+// SetRequestIDs configures automatic Idempotency-Key / X-Request-Id headers,
+// typically from the request_ids block of config.json.
+func (t *HTTPTool) SetRequestIDs(cfg RequestIDConfig) {
+	t.requestIDs.mu.Lock()
+	defer t.requestIDs.mu.Unlock()
+	t.requestIDs.cfg = cfg
+}
+
+// inject adds the configured ID headers that the request doesn't set itself
+// and returns the generated values by header name. inject overrides the
+// configured Enabled flag when non-nil.
+func (g *requestIDGenerator) inject(req *http.Request, inject *bool) map[string]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	enabled := g.cfg.Enabled
+	if inject != nil {
+		enabled = *inject
+	}
+	if !enabled {
+		return nil
+	}
+
+	g.counter++
+	generated := make(map[string]string)
+	add := func(header, template string) {
+		if template == "off" || req.Header.Get(header) != "" {
+			return
+		}
+		if template == "" {
+			template = defaultIDTemplate
+		}
+		value := renderIDTemplate(template, g.counter, time.Now())
+		req.Header.Set(header, value)
+		generated[header] = value
+	}
+
+	if req.Method == http.MethodPost || req.Method == http.MethodPatch {
+		add(IdempotencyKeyHeader, g.cfg.IdempotencyKey)
+	}
+	add(RequestIDHeader, g.cfg.RequestID)
+
+	if len(generated) == 0 {
+		return nil
+	}
+	return generated
+}
+
+// renderIDTemplate fills the placeholders of an ID template
+func renderIDTemplate(template string, counter uint64, now time.Time) string {
+	replacer := strings.NewReplacer(
+		"{uuid}", newUUID(),
+		"{timestamp}", strconv.FormatInt(now.Unix(), 10),
+		"{unix_ms}", strconv.FormatInt(now.UnixMilli(), 10),
+		"{counter}", strconv.FormatUint(counter, 10),
+		"{random}", randomHex(8),
+	)
+	return replacer.Replace(template)
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// formatRequestIDs renders the request ID line of FormatResponse
+func formatRequestIDs(r *HTTPResponse) string {
+	var parts []string
+	if value, ok := r.RequestIDs[IdempotencyKeyHeader]; ok {
+		parts = append(parts, fmt.Sprintf("%s %s ({{%s}})", IdempotencyKeyHeader, value, idempotencyKeyVar))
+	}
+	if value, ok := r.RequestIDs[RequestIDHeader]; ok {
+		parts = append(parts, fmt.Sprintf("%s %s ({{%s}})", RequestIDHeader, value, requestIDVar))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// runPreScript runs req's pre_script and returns the request as the script
+// left it, without the pre_script. Variables it sets can be used as {{NAME}}
+// in the request, since substitution happens afterwards.
+func (t *HTTPTool) runPreScript(req HTTPRequest) (HTTPRequest, error) {
+	script := req.PreScript
+	req.PreScript = ""
+	fields, err := requestFields(req)
+	if err != nil {
+		return req, err
+	}
+
+	env := newScriptEnv(stagePreScript, t.varStore)
+	env.request = fields
+	if err := runScript(script, env); err != nil {
+		return req, err
+	}
+
+	data, err := json.Marshal(env.request)
+	if err != nil {
+		return req, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	var prepared HTTPRequest
+	if err := json.Unmarshal(data, &prepared); err != nil {
+		return req, fmt.Errorf("pre_script left an invalid request: %w", err)
+	}
+	return prepared, nil
+}
+
+// runPostScript runs req's post_script on resp and records its asserts in
+// resp. An error stopping the script is recorded as a failure, so the
+// response stays inspectable.
+func (t *HTTPTool) runPostScript(req HTTPRequest, resp *HTTPResponse) {
+	env := newScriptEnv(stagePostScript, t.varStore)
+	if fields, err := requestFields(req); err == nil {
+		env.request = fields
+	}
+	env.response = responseFields(resp)
+	err := runScript(req.PostScript, env)
+	resp.ScriptChecks = env.checks
+	resp.ScriptFailures = env.failures
+	if err != nil {
+		resp.ScriptFailures = append(resp.ScriptFailures, err.Error())
+	}
+}
+
+// joinScripts runs b after a
+func joinScripts(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n" + b
+}
+
+func newScriptEnv(stage string, vars *VariableStore) *scriptEnv {
+	return &scriptEnv{stage: stage, vars: vars, locals: map[string]interface{}{}}
+}
+
+// requestFields returns req as the object scripts see
+func requestFields(req HTTPRequest) (map[string]interface{}, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	return fields, nil
+}
+
+// responseFields returns resp as the object scripts see: status, status_text,
+// headers, body (parsed when it is JSON), text and duration_ms
+func responseFields(resp *HTTPResponse) map[string]interface{} {
+	headers := make(map[string]interface{}, len(resp.Headers))
+	for key, value := range resp.Headers {
+		headers[key] = value
+	}
+	var body interface{} = resp.Body
+	var parsed interface{}
+	if json.Unmarshal([]byte(resp.Body), &parsed) == nil {
+		body = parsed
+	}
+	return map[string]interface{}{
+		"status":      float64(resp.StatusCode),
+		"status_text": resp.Status,
+		"headers":     headers,
+		"body":        body,
+		"text":        resp.Body,
+		"duration_ms": float64(resp.Duration.Milliseconds()),
+	}
+}
+
+// formatScriptResult summarizes a post_script's asserts for the response
+func formatScriptResult(r *HTTPResponse) string {
+	if len(r.ScriptFailures) == 0 {
+		return fmt.Sprintf("Script: ✓ %d assertion(s) passed\n", r.ScriptChecks)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Script: ✗ %d problem(s) in post_script (%d assertion(s) ran)\n", len(r.ScriptFailures), r.ScriptChecks))
+	for _, failure := range r.ScriptFailures {
+		sb.WriteString(fmt.Sprintf("        - %s\n", failure))
+	}
+	return sb.String()
+}
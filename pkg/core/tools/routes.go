@@ -0,0 +1,875 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Route is an endpoint declared in the project's code
+type Route struct {
+	Method  string // GET, POST, ... or ANY
+	Path    string
+	Handler string // Function or controller action, when it can be told
+	File    string // Relative to the work directory
+	Line    int
+}
+
+// routeRuleKind is what a routeRule's match declares
+type routeRuleKind int
+
+const (
+	ruleRoute     routeRuleKind = iota // An endpoint
+	ruleBlock                          // A prefix for the block opened on the line (chi Route, Laravel groups, Rails namespaces)
+	ruleClass                          // A prefix for the rest of the file (Spring and NestJS controllers)
+	ruleResources                      // Rails/Laravel resources, expanding to CRUD routes
+	ruleGroup                          // A router variable with a prefix: var, optional parent, path
+	ruleImport                         // A variable bound to another file: var, module
+	ruleMount                          // Another file's routes mounted at a prefix: optional parent, path, module
+)
+
+// routeRule finds one kind of declaration on a line. Its pattern's named
+// groups are among method, methods (a quoted list), path, handler, recv
+// (the router variable), var, parent, module, name, kind and chain.
+type routeRule struct {
+	kind    routeRuleKind
+	pattern *regexp.Regexp
+	method  string         // Method when the pattern has none
+	below   bool           // The handler is the function declared below (decorators, annotations)
+	chain   *regexp.Regexp // Finds method(handler) pairs in the chain group
+}
+
+// routeLanguage is how the frameworks of one language declare routes
+type routeLanguage struct {
+	name       string
+	frameworks []string
+	exts       []string
+	braces     bool                  // Blocks are { }, otherwise do ... end
+	include    func(rel string) bool // Only these files, when set
+	rules      []routeRule
+}
+
+// rule compiles a routeRule
+func rule(kind routeRuleKind, pattern string) routeRule {
+	return routeRule{kind: kind, pattern: regexp.MustCompile(pattern)}
+}
+
+// withMethod sets the method for patterns without one
+func (r routeRule) withMethod(method string) routeRule {
+	r.method = method
+	return r
+}
+
+// handlerBelow marks decorator and annotation rules
+func (r routeRule) handlerBelow() routeRule {
+	r.below = true
+	return r
+}
+
+// withChain sets how a chain of method(handler) calls is read
+func (r routeRule) withChain(pattern string) routeRule {
+	r.chain = regexp.MustCompile(pattern)
+	return r
+}
+
+var routeLanguages = []routeLanguage{
+	{
+		name:       "Go",
+		frameworks: []string{"gin", "echo", "chi", "fiber"},
+		exts:       []string{".go"},
+		braces:     true,
+		rules: []routeRule{
+			rule(ruleGroup, `\b(?P<var>\w+)\s*:?=\s*(?P<parent>\w+)\.Group\(\s*"(?P<path>[^"]*)"`),
+			rule(ruleGroup, `\b(?P<parent>\w+)\.Mount\(\s*"(?P<path>[^"]*)"\s*,\s*(?P<var>\w+)\s*\)`),
+			rule(ruleBlock, `\.Route\(\s*"(?P<path>[^"]*)"\s*,\s*func`),
+			rule(ruleRoute, `(?:\b(?P<recv>\w+)|\))\.(?P<method>GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|Any|Get|Post|Put|Patch|Delete|Head|Options|All)\(\s*"(?P<path>/[^"]*|)"(?:\s*,\s*(?P<handler>.*))?`),
+			rule(ruleRoute, `\b(?P<recv>\w+)\.(?:HandleFunc|Handle)\(\s*"(?:(?P<method>[A-Z]+)\s+)?(?P<path>/[^"]*)"(?:\s*,\s*(?P<handler>.*))?`).withMethod("ANY"),
+		},
+	},
+	{
+		name:       "Python",
+		frameworks: []string{"fastapi", "flask", "django"},
+		exts:       []string{".py"},
+		rules: []routeRule{
+			rule(ruleGroup, `\b(?P<var>\w+)\s*=\s*APIRouter\(.*\bprefix\s*=\s*["'](?P<path>[^"']*)["']`),
+			rule(ruleGroup, `\b(?P<var>\w+)\s*=\s*Blueprint\(.*\burl_prefix\s*=\s*["'](?P<path>[^"']*)["']`),
+			rule(ruleMount, `\b(?:re_)?path\(\s*r?["'](?P<path>[^"']*)["']\s*,\s*include\(\s*["'](?P<module>[\w.]+)["']`),
+			rule(ruleRoute, `@(?P<recv>\w+)\.(?P<method>get|post|put|patch|delete|head|options)\(\s*(?:path\s*=\s*)?["'](?P<path>[^"']*)["']`).handlerBelow(),
+			rule(ruleRoute, `@(?P<recv>\w+)\.(?:route|api_route)\(\s*["'](?P<path>[^"']*)["'](?:.*\bmethods\s*=\s*[\[(](?P<methods>[^\])]*)[\])])?`).withMethod("GET").handlerBelow(),
+			rule(ruleRoute, `\b(?:re_)?path\(\s*r?["'](?P<path>[^"']*)["']\s*,\s*(?P<handler>[\w.]+)`).withMethod("ANY"),
+		},
+	},
+	{
+		name:       "JavaScript/TypeScript",
+		frameworks: []string{"express", "nestjs", "hono"},
+		exts:       []string{".js", ".ts", ".mjs", ".cjs"},
+		braces:     true,
+		rules: []routeRule{
+			rule(ruleImport, `\b(?:const|let|var)\s+(?P<var>\w+)\s*=\s*require\(\s*["'](?P<module>\.[^"']*)["']\s*\)`),
+			rule(ruleImport, `\bimport\s+(?P<var>\w+)\s+from\s+["'](?P<module>\.[^"']*)["']`),
+			rule(ruleMount, `\b(?P<parent>\w+)\.(?:use|route)\(\s*["'\x60](?P<path>/[^"'\x60]*)["'\x60]\s*,\s*(?:[\w.]+\s*,\s*)*require\(\s*["'](?P<module>\.[^"']*)["']\s*\)`),
+			rule(ruleGroup, `\b(?P<parent>\w+)\.(?:use|route)\(\s*["'\x60](?P<path>/[^"'\x60]*)["'\x60]\s*,\s*(?:[\w.]+\s*,\s*)*(?P<var>\w+)\s*\)`),
+			rule(ruleGroup, `\b(?P<var>\w+)\s*=\s*new Hono\([^)]*\)\.basePath\(\s*["'\x60](?P<path>[^"'\x60]*)["'\x60]`),
+			rule(ruleClass, `@Controller\(\s*(?:["'\x60](?P<path>[^"'\x60]*)["'\x60])?`),
+			rule(ruleRoute, `@(?P<method>Get|Post|Put|Patch|Delete|Head|Options|All)\(\s*(?:["'\x60](?P<path>[^"'\x60]*)["'\x60])?\s*\)`).handlerBelow(),
+			rule(ruleRoute, `(?:\b(?P<recv>\w+)|\))\.route\(\s*["'\x60](?P<path>/[^"'\x60]*)["'\x60]\s*\)(?P<chain>.*)`).withChain(`\.(get|post|put|patch|delete|head|options|all)\(\s*([\w.]*)`),
+			rule(ruleRoute, `(?:\b(?P<recv>\w+)|\))\.(?P<method>get|post|put|patch|delete|head|options|all)\(\s*["'\x60](?P<path>/[^"'\x60]*)["'\x60](?:\s*,\s*(?P<handler>.*))?`),
+		},
+	},
+	{
+		name:       "Java/Kotlin",
+		frameworks: []string{"spring"},
+		exts:       []string{".java", ".kt"},
+		braces:     true,
+		rules: []routeRule{
+			rule(ruleClass, `^@RequestMapping\(\s*(?:(?:value|path)\s*=\s*)?[{\[]?\s*"(?P<path>[^"]*)"`),
+			rule(ruleRoute, `@(?P<method>Get|Post|Put|Patch|Delete)Mapping(?:\(\s*(?:(?:value|path)\s*=\s*)?[{\[]?\s*"(?P<path>[^"]*)")?`).handlerBelow(),
+			rule(ruleRoute, `^\s+@RequestMapping\(\s*(?:(?:value|path)\s*=\s*)?[{\[]?\s*"(?P<path>[^"]*)"(?:.*RequestMethod\.(?P<method>[A-Z]+))?`).withMethod("ANY").handlerBelow(),
+		},
+	},
+	{
+		name:       "PHP",
+		frameworks: []string{"laravel"},
+		exts:       []string{".php"},
+		braces:     true,
+		rules: []routeRule{
+			rule(ruleBlock, `Route::(?:prefix\(\s*['"](?P<path>[^'"]*)['"]\s*\)|group\(\s*\[\s*['"]prefix['"]\s*=>\s*['"](?P<name>[^'"]*)['"])`),
+			rule(ruleResources, `Route::(?P<kind>resource|apiResource)\(\s*['"](?P<name>[^'"]*)['"]\s*,\s*(?P<handler>[\w\\]+)`),
+			rule(ruleRoute, `Route::match\(\s*\[(?P<methods>[^\]]*)\]\s*,\s*['"](?P<path>[^'"]*)['"]\s*,\s*(?P<handler>.*)`),
+			rule(ruleRoute, `Route::(?P<method>get|post|put|patch|delete|options|any)\(\s*['"](?P<path>[^'"]*)['"]\s*,\s*(?P<handler>.*)`),
+		},
+	},
+	{
+		name:       "Ruby",
+		frameworks: []string{"rails"},
+		exts:       []string{".rb"},
+		include: func(rel string) bool {
+			return filepath.Base(rel) == "routes.rb" || strings.Contains(filepath.ToSlash(rel), "config/routes/")
+		},
+		rules: []routeRule{
+			rule(ruleBlock, `^\s*(?:namespace\s+:(?P<name>\w+)|scope\s+(?:path:\s*)?['"](?P<path>[^'"]*)['"])`),
+			rule(ruleResources, `^\s*(?P<kind>resources|resource)\s+:(?P<name>\w+)`),
+			rule(ruleRoute, `^\s*(?P<method>get|post|put|patch|delete|match)\s+['"](?P<path>[^'"]*)['"](?:.*(?:\bto:|=>)\s*['"](?P<handler>[^'"]*)['"])?`),
+			rule(ruleRoute, `^\s*root\s+(?:to:\s*)?['"](?P<handler>[^'"]*)['"]`).withMethod("GET"),
+		},
+	},
+	{
+		name:       "Rust",
+		frameworks: []string{"actix", "axum"},
+		exts:       []string{".rs"},
+		braces:     true,
+		rules: []routeRule{
+			rule(ruleRoute, `#\[(?P<method>get|post|put|patch|delete|head)\(\s*"(?P<path>[^"]*)"`).handlerBelow(),
+			rule(ruleRoute, `\.route\(\s*"(?P<path>[^"]*)"\s*,\s*web::(?P<method>get|post|put|patch|delete|head)\(\)\.to\((?P<handler>[\w:]+)`),
+			rule(ruleRoute, `\.route\(\s*"(?P<path>[^"]*)"\s*,\s*(?P<chain>(?:\w+::)*(?:get|post|put|patch|delete|head|options|any)\(.*)`).withChain(`(?:^|[.:])(get|post|put|patch|delete|head|options|any)\(\s*([\w:]*)`),
+		},
+	},
+}
+
+// routeClientNames are JavaScript receivers that make requests rather than
+// declare routes
+var routeClientNames = []string{"axios", "http", "https", "fetch", "client", "request", "superagent", "$http", "cy"}
+
+// routeSkipDirs are directories never scanned for routes
+var routeSkipDirs = []string{"node_modules", "vendor", "target", "dist", "build", "venv", "__pycache__", "testdata"}
+
+const (
+	maxRouteFiles    = 5000
+	maxRouteFileSize = 1024 * 1024
+)
+
+var (
+	routeHandlerBelowPattern = regexp.MustCompile(`(?:\bdef|\bfn|\bfunction)\s+(\w+)|(\w+)\s*\(`)
+	routeIdentifierPattern   = regexp.MustCompile(`^[\w.:@#\\$]+`)
+	laravelActionPattern     = regexp.MustCompile(`^\[\s*([\w\\]+)::class\s*,\s*['"](\w+)['"]\s*\]`)
+	quotedWordPattern        = regexp.MustCompile(`["'](\w+)["']`)
+	rubyOptionListPattern    = regexp.MustCompile(`\b(only|except):\s*\[([^\]]*)\]`)
+	rubySymbolPattern        = regexp.MustCompile(`:(\w+)`)
+	rubyDoPattern            = regexp.MustCompile(`\bdo\s*(\|[^|]*\|)?\s*$`)
+	rubyEndPattern           = regexp.MustCompile(`^\s*end\b`)
+)
+
+// routeLanguagesFor returns the languages to scan for a framework; every
+// language when it is empty, "other" or unknown
+func routeLanguagesFor(framework string) []routeLanguage {
+	for _, lang := range routeLanguages {
+		if slices.Contains(lang.frameworks, strings.ToLower(framework)) {
+			return []routeLanguage{lang}
+		}
+	}
+	return routeLanguages
+}
+
+// routeFile is a source file being scanned
+type routeFile struct {
+	rel     string // Relative to the work directory
+	lang    *routeLanguage
+	lines   []string
+	groups  map[string][2]string // var -> parent, path
+	imports map[string]string    // var -> file (relative)
+}
+
+// routeMountPoint is a file whose routes another file mounts at a prefix
+type routeMountPoint struct {
+	from   string // The mounting file
+	parent string // Its router variable
+	path   string
+}
+
+// ScanRoutes statically finds the routes declared under dir, using the
+// patterns of framework's language (or every language when framework is ""
+// or "other"). It also returns the number of files scanned.
+func ScanRoutes(workDir, dir, framework string) ([]Route, int, error) {
+	langs := routeLanguagesFor(framework)
+	files, err := collectRouteFiles(workDir, dir, langs)
+	if err != nil {
+		return nil, 0, err
+	}
+	byRel := make(map[string]*routeFile, len(files))
+	for _, f := range files {
+		byRel[f.rel] = f
+	}
+
+	// First pass: router variables, imports and mounts, which may come
+	// after the routes they prefix
+	mounts := make(map[string]routeMountPoint)
+	for _, f := range files {
+		var pending []routeMountPoint
+		var pendingVars []string
+		for _, line := range f.lines {
+			for _, r := range f.lang.rules {
+				m := r.pattern.FindStringSubmatch(line)
+				if m == nil {
+					continue
+				}
+				switch r.kind {
+				case ruleGroup:
+					f.groups[routeGroup(r, m, "var")] = [2]string{routeGroup(r, m, "parent"), routeGroup(r, m, "path")}
+					pending = append(pending, routeMountPoint{from: f.rel, parent: routeGroup(r, m, "parent"), path: routeGroup(r, m, "path")})
+					pendingVars = append(pendingVars, routeGroup(r, m, "var"))
+				case ruleImport:
+					if target := resolveRouteModule(f, routeGroup(r, m, "module"), byRel); target != "" {
+						f.imports[routeGroup(r, m, "var")] = target
+					}
+				case ruleMount:
+					if target := resolveRouteModule(f, routeGroup(r, m, "module"), byRel); target != "" && target != f.rel {
+						if _, ok := mounts[target]; !ok {
+							mounts[target] = routeMountPoint{from: f.rel, parent: routeGroup(r, m, "parent"), path: routeGroup(r, m, "path")}
+						}
+					}
+				}
+				if r.kind >= ruleGroup {
+					break
+				}
+			}
+		}
+		// A router variable imported from another file mounts that file
+		for i, name := range pendingVars {
+			if target, ok := f.imports[name]; ok && target != f.rel {
+				if _, ok := mounts[target]; !ok {
+					mounts[target] = pending[i]
+				}
+			}
+		}
+	}
+
+	var routes []Route
+	for _, f := range files {
+		routes = append(routes, f.scan(routeFilePrefix(f.rel, mounts, byRel, 0))...)
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routeMethodOrder(routes[i].Method) < routeMethodOrder(routes[j].Method)
+	})
+	return routes, len(files), nil
+}
+
+// collectRouteFiles reads the source files of langs under dir
+func collectRouteFiles(workDir, dir string, langs []routeLanguage) ([]*routeFile, error) {
+	var files []*routeFile
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || slices.Contains(routeSkipDirs, name)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Size() > maxRouteFileSize || isTestSourceFile(info.Name()) {
+			return nil
+		}
+		rel, _ := filepath.Rel(workDir, path)
+		for i := range langs {
+			lang := &langs[i]
+			if !slices.Contains(lang.exts, strings.ToLower(filepath.Ext(path))) || (lang.include != nil && !lang.include(rel)) {
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			files = append(files, &routeFile{
+				rel:     rel,
+				lang:    lang,
+				lines:   strings.Split(string(data), "\n"),
+				groups:  make(map[string][2]string),
+				imports: make(map[string]string),
+			})
+			if len(files) >= maxRouteFiles {
+				return filepath.SkipAll
+			}
+			break
+		}
+		return nil
+	})
+	if err != nil && err != filepath.SkipAll {
+		return nil, fmt.Errorf("failed to scan for routes: %w", err)
+	}
+	return files, nil
+}
+
+// isTestSourceFile reports whether name is a test file, whose routes are
+// fixtures rather than the API's
+func isTestSourceFile(name string) bool {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	lower := strings.ToLower(base)
+	return strings.HasSuffix(lower, "_test") || strings.HasSuffix(lower, "_spec") ||
+		strings.HasSuffix(lower, ".test") || strings.HasSuffix(lower, ".spec") ||
+		strings.HasPrefix(lower, "test_") || strings.HasSuffix(base, "Test") || strings.HasSuffix(base, "Tests")
+}
+
+// resolveRouteModule finds the scanned file a module reference names: a
+// relative JavaScript import or a dotted Python module
+func resolveRouteModule(f *routeFile, module string, byRel map[string]*routeFile) string {
+	if module == "" {
+		return ""
+	}
+	if strings.HasPrefix(module, ".") {
+		base := filepath.Join(filepath.Dir(f.rel), filepath.FromSlash(module))
+		candidates := []string{base}
+		for _, ext := range f.lang.exts {
+			candidates = append(candidates, base+ext, filepath.Join(base, "index"+ext))
+		}
+		for _, candidate := range candidates {
+			if _, ok := byRel[candidate]; ok {
+				return candidate
+			}
+		}
+		return ""
+	}
+	suffix := filepath.FromSlash(strings.ReplaceAll(module, ".", "/")) + ".py"
+	for _, rel := range sortedKeys(byRel) {
+		if rel == suffix || strings.HasSuffix(rel, string(filepath.Separator)+suffix) {
+			return rel
+		}
+	}
+	return ""
+}
+
+// routeFilePrefix is the prefix another file mounts rel's routes at
+func routeFilePrefix(rel string, mounts map[string]routeMountPoint, byRel map[string]*routeFile, depth int) string {
+	mount, ok := mounts[rel]
+	if !ok || depth > 10 {
+		return ""
+	}
+	from := byRel[mount.from]
+	return joinRoutePath(routeFilePrefix(mount.from, mounts, byRel, depth+1), from.groupPrefix(mount.parent, 0), mount.path)
+}
+
+// groupPrefix is the prefix of a router variable, following its parents
+func (f *routeFile) groupPrefix(name string, depth int) string {
+	group, ok := f.groups[name]
+	if !ok || depth > 10 {
+		return ""
+	}
+	return joinRoutePath(f.groupPrefix(group[0], depth+1), group[1])
+}
+
+// routeBlock is a prefix for the lines until its block closes
+type routeBlock struct {
+	prefix string
+	depth  int
+}
+
+// scan finds the routes of a file whose routes are mounted at prefix
+func (f *routeFile) scan(prefix string) []Route {
+	var routes []Route
+	var blocks []routeBlock
+	classPrefix := ""
+	depth := 0
+
+	for i, line := range f.lines {
+		var blockPrefix []string
+		for _, b := range blocks {
+			blockPrefix = append(blockPrefix, b.prefix)
+		}
+		base := func(recv string) string {
+			return joinRoutePath(prefix, classPrefix, joinRoutePath(blockPrefix...), f.groupPrefix(recv, 0))
+		}
+
+	rules:
+		for _, r := range f.lang.rules {
+			m := r.pattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			switch r.kind {
+			case ruleBlock:
+				path := routeGroup(r, m, "path")
+				if name := routeGroup(r, m, "name"); name != "" {
+					path = name
+				}
+				blocks = append(blocks, routeBlock{prefix: path, depth: depth})
+			case ruleClass:
+				classPrefix = routeGroup(r, m, "path")
+			case ruleResources:
+				resources, nested := f.resourceRoutes(r, m, line, base(""), i+1)
+				routes = append(routes, resources...)
+				if nested != "" {
+					blocks = append(blocks, routeBlock{prefix: nested, depth: depth})
+				}
+			case ruleRoute:
+				recv := routeGroup(r, m, "recv")
+				if f.lang.name == "JavaScript/TypeScript" && slices.Contains(routeClientNames, recv) {
+					continue
+				}
+				path := joinRoutePath(base(recv), routeGroup(r, m, "path"))
+				handler := cleanRouteHandler(routeGroup(r, m, "handler"))
+				if r.below {
+					handler = f.handlerBelow(i)
+				}
+				for _, mh := range routeMethods(r, m) {
+					h := handler
+					if mh[1] != "" {
+						h = cleanRouteHandler(mh[1])
+					}
+					routes = append(routes, Route{Method: mh[0], Path: path, Handler: h, File: f.rel, Line: i + 1})
+				}
+			}
+			// Router variables, imports and mounts were read in the first pass
+			break rules
+		}
+
+		// Close the blocks that ended on this line
+		depth += routeDepthChange(line, f.lang.braces)
+		for len(blocks) > 0 && blocks[len(blocks)-1].depth >= depth {
+			blocks = blocks[:len(blocks)-1]
+		}
+	}
+	return routes
+}
+
+// routeGroup returns a named group of a match, "" when the rule has none
+func routeGroup(r routeRule, m []string, name string) string {
+	if i := r.pattern.SubexpIndex(name); i >= 0 && i < len(m) {
+		return m[i]
+	}
+	return ""
+}
+
+// routeMethods returns the method (and handler, for chains) of each route
+// a match declares
+func routeMethods(r routeRule, m []string) [][2]string {
+	if r.chain != nil {
+		var methods [][2]string
+		for _, c := range r.chain.FindAllStringSubmatch(routeGroup(r, m, "chain"), -1) {
+			methods = append(methods, [2]string{normalizeRouteMethod(c[1]), c[2]})
+		}
+		return methods
+	}
+	if list := routeGroup(r, m, "methods"); list != "" {
+		var methods [][2]string
+		for _, q := range quotedWordPattern.FindAllStringSubmatch(list, -1) {
+			methods = append(methods, [2]string{normalizeRouteMethod(q[1]), ""})
+		}
+		if len(methods) > 0 {
+			return methods
+		}
+	}
+	method := routeGroup(r, m, "method")
+	if method == "" {
+		method = r.method
+	}
+	return [][2]string{{normalizeRouteMethod(method), ""}}
+}
+
+// normalizeRouteMethod upper-cases a method; "any", "all" and "match" are ANY
+func normalizeRouteMethod(method string) string {
+	method = strings.ToUpper(method)
+	switch method {
+	case "", "ALL", "MATCH":
+		return "ANY"
+	}
+	return method
+}
+
+// routeMethodOrder sorts methods the way API docs list them
+func routeMethodOrder(method string) int {
+	order := []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "ANY"}
+	if i := slices.Index(order, method); i >= 0 {
+		return i
+	}
+	return len(order)
+}
+
+// cleanRouteHandler shortens the rest of a route call to its handler: the
+// last argument, an identifier, or "(inline)" for a closure
+func cleanRouteHandler(args string) string {
+	args = strings.TrimSpace(args)
+	if args == "" {
+		return ""
+	}
+	if m := laravelActionPattern.FindStringSubmatch(args); m != nil {
+		return m[1] + "@" + m[2]
+	}
+	parts := splitRouteArgs(args)
+	handler := strings.Trim(strings.TrimSpace(parts[len(parts)-1]), `'"`)
+	for _, closure := range []string{"func", "function", "fn", "async", "(", "|"} {
+		if strings.HasPrefix(handler, closure) {
+			return "(inline)"
+		}
+	}
+	if strings.Contains(handler, "=>") {
+		return "(inline)"
+	}
+	return strings.TrimSuffix(routeIdentifierPattern.FindString(handler), ".as_view")
+}
+
+// splitRouteArgs splits the arguments of a call at its top-level commas,
+// stopping at the parenthesis that closes the call
+func splitRouteArgs(args string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range args {
+		switch c {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				return append(parts, args[start:i])
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, args[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, args[start:])
+}
+
+// handlerBelow finds the function declared after a decorator or annotation
+func (f *routeFile) handlerBelow(line int) string {
+	for i := line + 1; i < len(f.lines) && i <= line+8; i++ {
+		text := strings.TrimSpace(f.lines[i])
+		if text == "" || strings.HasPrefix(text, "@") || strings.HasPrefix(text, "#[") || strings.HasPrefix(text, "//") {
+			continue
+		}
+		if m := routeHandlerBelowPattern.FindStringSubmatch(text); m != nil {
+			if m[1] != "" {
+				return m[1]
+			}
+			return m[2]
+		}
+		return ""
+	}
+	return ""
+}
+
+// routeDepthChange is how a line changes the block depth: braces outside
+// string literals, or do/end
+func routeDepthChange(line string, braces bool) int {
+	if !braces {
+		change := 0
+		if rubyDoPattern.MatchString(line) {
+			change++
+		}
+		if rubyEndPattern.MatchString(line) {
+			change--
+		}
+		return change
+	}
+	change := 0
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote && (i == 0 || line[i-1] != '\\') {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '/' && strings.HasPrefix(line[i:], "//"):
+			return change
+		case c == '{':
+			change++
+		case c == '}':
+			change--
+		}
+	}
+	return change
+}
+
+// resourceAction is one of the routes a resource declaration expands to
+type resourceAction struct {
+	action string
+	method string
+	suffix string
+	member bool // On a single resource (/photos/:id)
+}
+
+var railsResourceActions = []resourceAction{
+	{"index", "GET", "", false},
+	{"new", "GET", "/new", false},
+	{"create", "POST", "", false},
+	{"show", "GET", "", true},
+	{"edit", "GET", "/edit", true},
+	{"update", "PATCH", "", true},
+	{"destroy", "DELETE", "", true},
+}
+
+var laravelResourceActions = []resourceAction{
+	{"index", "GET", "", false},
+	{"create", "GET", "/create", false},
+	{"store", "POST", "", false},
+	{"show", "GET", "", true},
+	{"edit", "GET", "/edit", true},
+	{"update", "PUT", "", true},
+	{"destroy", "DELETE", "", true},
+}
+
+// resourceRoutes expands a Rails or Laravel resource declaration. For a
+// Rails "resources ... do" it also returns the prefix of the nested routes.
+func (f *routeFile) resourceRoutes(r routeRule, m []string, line, prefix string, lineNum int) ([]Route, string) {
+	name := routeGroup(r, m, "name")
+	kind := routeGroup(r, m, "kind")
+	path := joinRoutePath(prefix, name)
+	singular := singularRouteName(name)
+
+	actions := railsResourceActions
+	member, nested := "/:id", ""
+	handler := func(action string) string { return name + "#" + action }
+	if f.lang.name == "PHP" {
+		actions = laravelResourceActions
+		member = "/{" + singular + "}"
+		controller := routeGroup(r, m, "handler")
+		handler = func(action string) string { return controller + "@" + action }
+	} else {
+		if kind == "resource" {
+			// A singular resource has no id and no index
+			member = ""
+			handler = func(action string) string { return name + "s#" + action }
+		}
+		if rubyDoPattern.MatchString(line) {
+			nested = name
+			if kind == "resources" {
+				nested = name + "/:" + singular + "_id"
+			}
+		}
+	}
+
+	only, except := resourceOptions(line)
+	var routes []Route
+	for _, a := range actions {
+		if (kind == "apiResource" && (a.action == "create" || a.action == "edit")) ||
+			(kind == "resource" && a.action == "index") ||
+			(len(only) > 0 && !slices.Contains(only, a.action)) || slices.Contains(except, a.action) {
+			continue
+		}
+		p := path
+		if a.member {
+			p += member
+		}
+		routes = append(routes, Route{Method: a.method, Path: p + a.suffix, Handler: handler(a.action), File: f.rel, Line: lineNum})
+	}
+	return routes, nested
+}
+
+// resourceOptions reads Rails only:/except: lists
+func resourceOptions(line string) (only, except []string) {
+	for _, m := range rubyOptionListPattern.FindAllStringSubmatch(line, -1) {
+		var actions []string
+		for _, s := range rubySymbolPattern.FindAllStringSubmatch(m[2], -1) {
+			actions = append(actions, s[1])
+		}
+		if m[1] == "only" {
+			only = actions
+		} else {
+			except = actions
+		}
+	}
+	return only, except
+}
+
+// singularRouteName naively singularizes a resource name (photos -> photo)
+func singularRouteName(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "ses"), strings.HasSuffix(name, "xes"):
+		return strings.TrimSuffix(name, "es")
+	}
+	return strings.TrimSuffix(name, "s")
+}
+
+// joinRoutePath joins path segments with single slashes, keeping a
+// trailing slash of the last one
+func joinRoutePath(parts ...string) string {
+	var segments []string
+	trailing := false
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if trimmed := strings.Trim(part, "/"); trimmed != "" {
+			segments = append(segments, trimmed)
+			trailing = strings.HasSuffix(part, "/")
+		}
+	}
+	path := "/" + strings.Join(segments, "/")
+	if trailing && path != "/" {
+		path += "/"
+	}
+	return path
+}
+
+// ListRoutesTool lists the endpoints declared in the project's code
+type ListRoutesTool struct {
+	workDir   string
+	framework string
+}
+
+// NewListRoutesTool creates a new route discovery tool using the patterns
+// of the configured framework
+func NewListRoutesTool(workDir, framework string) *ListRoutesTool {
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	return &ListRoutesTool{workDir: workDir, framework: framework}
+}
+
+// ListRoutesParams narrows the routes listed
+type ListRoutesParams struct {
+	Framework string `json:"framework,omitempty"` // Overrides the configured framework
+	Path      string `json:"path,omitempty"`      // Directory to scan (default: the project)
+	Filter    string `json:"filter,omitempty"`    // Only paths or handlers containing this
+	Method    string `json:"method,omitempty"`    // Only this method
+}
+
+// Name returns the tool name
+func (t *ListRoutesTool) Name() string {
+	return "list_routes"
+}
+
+// Description returns the tool description
+func (t *ListRoutesTool) Description() string {
+	return "List the API's endpoints found in the code (method, path, handler, file:line) using the framework's route patterns. Use it instead of guessing URLs"
+}
+
+// Parameters returns the tool parameter description
+func (t *ListRoutesTool) Parameters() string {
+	return `{"filter": "string - only paths or handlers containing this, e.g. users", "method": "string - e.g. POST", "path": "string - directory to scan (default: the project)", "framework": "string - override the configured framework"}`
+}
+
+// Execute scans the project and lists its routes
+func (t *ListRoutesTool) Execute(args string) (string, error) {
+	var params ListRoutesParams
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", fmt.Errorf("failed to parse arguments: %w", err)
+		}
+	}
+	framework := params.Framework
+	if framework == "" {
+		framework = t.framework
+	}
+
+	dir := t.workDir
+	if params.Path != "" {
+		absPath, err := ValidatePathWithinWorkDir(params.Path, t.workDir)
+		if err != nil {
+			return "", err
+		}
+		dir = absPath
+	}
+
+	routes, scanned, err := ScanRoutes(t.workDir, dir, framework)
+	if err != nil {
+		return "", err
+	}
+	total := len(routes)
+	routes = filterRoutes(routes, params.Filter, params.Method)
+
+	patterns := "every framework's"
+	if langs := routeLanguagesFor(framework); len(langs) == 1 {
+		patterns = strings.ToLower(framework)
+	}
+	if len(routes) == 0 {
+		if total > 0 {
+			return fmt.Sprintf("No routes match (found %d route(s) in total)", total), nil
+		}
+		return fmt.Sprintf("No routes found in %d file(s) using %s route patterns. Routes built dynamically or registered in other ways aren't found; try search_code.", scanned, patterns), nil
+	}
+	return formatRoutes(routes, total, scanned, patterns), nil
+}
+
+// filterRoutes keeps the routes matching filter and method
+func filterRoutes(routes []Route, filter, method string) []Route {
+	if filter == "" && method == "" {
+		return routes
+	}
+	filter = strings.ToLower(filter)
+	var kept []Route
+	for _, r := range routes {
+		if method != "" && !strings.EqualFold(r.Method, method) && r.Method != "ANY" {
+			continue
+		}
+		if filter != "" && !strings.Contains(strings.ToLower(r.Path), filter) && !strings.Contains(strings.ToLower(r.Handler), filter) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// maxListedRoutes caps the routes listed at once
+const maxListedRoutes = 300
+
+// formatRoutes lists routes in aligned columns
+func formatRoutes(routes []Route, total, scanned int, patterns string) string {
+	var sb strings.Builder
+	if len(routes) == total {
+		sb.WriteString(fmt.Sprintf("Found %d route(s) in %d file(s) using %s route patterns:\n\n", total, scanned, patterns))
+	} else {
+		sb.WriteString(fmt.Sprintf("%d of %d route(s) match:\n\n", len(routes), total))
+	}
+
+	shown := routes[:min(len(routes), maxListedRoutes)]
+	pathWidth, handlerWidth := 0, 0
+	for _, r := range shown {
+		pathWidth = max(pathWidth, min(len(r.Path), 60))
+		handlerWidth = max(handlerWidth, min(len(r.Handler), 40))
+	}
+	for _, r := range shown {
+		handler := r.Handler
+		if handler == "" {
+			handler = "-"
+		}
+		sb.WriteString(fmt.Sprintf("%-7s %-*s  %-*s  %s:%d\n", r.Method, pathWidth, r.Path, handlerWidth, handler, r.File, r.Line))
+	}
+	if len(routes) > len(shown) {
+		sb.WriteString(fmt.Sprintf("... and %d more (narrow with filter or method)\n", len(routes)-len(shown)))
+	}
+	return sb.String()
+}
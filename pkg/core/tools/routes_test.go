@@ -0,0 +1,293 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFiles writes files (slash-separated path -> content) under a
+// new temporary directory and returns it
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// routeFixtures are small projects in the frameworks list_routes knows
+var routeFixtures = map[string]map[string]string{
+	"net/http": {
+		"main.go": `package main
+
+import "net/http"
+
+func main() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", listUsers)
+	mux.HandleFunc("POST /users", createUser)
+	mux.HandleFunc("GET /users/{id}", getUser)
+	mux.Handle("/static/", http.StripPrefix("/static/", files))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	http.ListenAndServe(":8080", mux)
+}
+`,
+		"main_test.go": `package main
+
+func TestRoutes(t *testing.T) {
+	mux.HandleFunc("GET /fixture", nil)
+}
+`,
+	},
+	"chi": {
+		"router.go": `package api
+
+func Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", index)
+	r.Route("/articles", func(r chi.Router) {
+		r.Get("/", listArticles)
+		r.Post("/", createArticle)
+		r.Route("/{articleID}", func(r chi.Router) {
+			r.Get("/", getArticle)
+			r.Delete("/", deleteArticle)
+		})
+	})
+	r.Get("/about", about)
+	return r
+}
+`,
+	},
+	"gin": {
+		"main.go": `package main
+
+func main() {
+	r := gin.Default()
+	v1 := r.Group("/api/v1")
+	users := v1.Group("/users")
+	users.GET("/:id", handlers.GetUser)
+	users.PUT("/:id", handlers.UpdateUser)
+	v1.POST("/login", auth.Login)
+	r.Run()
+}
+`,
+	},
+	"express": {
+		"app.js": `const express = require("express");
+const users = require("./routes/users");
+const app = express();
+
+app.get("/", (req, res) => res.send("hi"));
+app.use("/api/users", users);
+app.use("/api/admin", require("./routes/admin"));
+app.listen(3000);
+`,
+		"routes/users.js": `const router = require("express").Router();
+
+router.get("/", list);
+router.post("/", auth, create);
+router.route("/:id").get(show).put(update).delete(destroy);
+
+async function load() {
+  const res = await axios.get("/users/remote");
+}
+
+module.exports = router;
+`,
+		"routes/admin.js": `const router = require("express").Router();
+router.delete("/cache", clearCache);
+module.exports = router;
+`,
+		"node_modules/lib/index.js": `app.get("/vendored", x);`,
+	},
+	"fastapi": {
+		"app/main.py": `from fastapi import FastAPI, APIRouter
+
+app = FastAPI()
+router = APIRouter(prefix="/items")
+
+@router.get("/{item_id}")
+async def read_item(item_id: int):
+    return {}
+
+@app.api_route("/ping", methods=["GET", "HEAD"])
+def ping():
+    return "pong"
+`,
+	},
+	"rails": {
+		"config/routes.rb": `Rails.application.routes.draw do
+  root "home#index"
+  namespace :api do
+    resources :photos, only: [:index, :show] do
+      resources :comments, only: [:create]
+    end
+    resource :profile, except: [:new, :edit, :destroy]
+  end
+  get "/status", to: "status#show"
+end
+`,
+	},
+}
+
+func TestScanRoutes(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		framework string
+		want      []string // "METHOD path handler file:line"
+	}{
+		{
+			fixture:   "net/http",
+			framework: "other",
+			want: []string{
+				"ANY /healthz (inline) main.go:11",
+				"ANY /static/ http.StripPrefix main.go:10",
+				"GET /users listUsers main.go:7",
+				"POST /users createUser main.go:8",
+				"GET /users/{id} getUser main.go:9",
+			},
+		},
+		{
+			fixture:   "chi",
+			framework: "chi",
+			want: []string{
+				"GET / index router.go:5",
+				"GET /about about router.go:14",
+				"GET /articles listArticles router.go:7",
+				"POST /articles createArticle router.go:8",
+				"GET /articles/{articleID} getArticle router.go:10",
+				"DELETE /articles/{articleID} deleteArticle router.go:11",
+			},
+		},
+		{
+			fixture:   "gin",
+			framework: "gin",
+			want: []string{
+				"POST /api/v1/login auth.Login main.go:9",
+				"GET /api/v1/users/:id handlers.GetUser main.go:7",
+				"PUT /api/v1/users/:id handlers.UpdateUser main.go:8",
+			},
+		},
+		{
+			fixture:   "express",
+			framework: "express",
+			want: []string{
+				"GET / (inline) app.js:5",
+				"DELETE /api/admin/cache clearCache routes/admin.js:2",
+				"GET /api/users list routes/users.js:3",
+				"POST /api/users create routes/users.js:4",
+				"GET /api/users/:id show routes/users.js:5",
+				"PUT /api/users/:id update routes/users.js:5",
+				"DELETE /api/users/:id destroy routes/users.js:5",
+			},
+		},
+		{
+			fixture:   "fastapi",
+			framework: "fastapi",
+			want: []string{
+				"GET /items/{item_id} read_item app/main.py:6",
+				"GET /ping ping app/main.py:10",
+				"HEAD /ping ping app/main.py:10",
+			},
+		},
+		{
+			fixture:   "rails",
+			framework: "rails",
+			want: []string{
+				"GET / home#index config/routes.rb:2",
+				"GET /api/photos photos#index config/routes.rb:4",
+				"GET /api/photos/:id photos#show config/routes.rb:4",
+				"POST /api/photos/:photo_id/comments comments#create config/routes.rb:5",
+				"GET /api/profile profiles#show config/routes.rb:7",
+				"POST /api/profile profiles#create config/routes.rb:7",
+				"PATCH /api/profile profiles#update config/routes.rb:7",
+				"GET /status status#show config/routes.rb:9",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			dir := writeTestFiles(t, routeFixtures[tt.fixture])
+			routes, _, err := ScanRoutes(dir, dir, tt.framework)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, r := range routes {
+				got = append(got, fmt.Sprintf("%s %s %s %s:%d", r.Method, r.Path, r.Handler, filepath.ToSlash(r.File), r.Line))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("routes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestListRoutesTool(t *testing.T) {
+	dir := writeTestFiles(t, routeFixtures["express"])
+	tool := NewListRoutesTool(dir, "express")
+
+	tests := []struct {
+		name   string
+		args   string
+		want   []string
+		errMsg string
+	}{
+		{
+			name: "all",
+			args: `{}`,
+			want: []string{"Found 7 route(s) in 3 file(s) using express route patterns:", "DELETE  /api/users/:id    destroy     routes/users.js:5"},
+		},
+		{
+			name: "filter and method",
+			args: `{"filter": "users", "method": "put"}`,
+			want: []string{"1 of 7 route(s) match:", "PUT     /api/users/:id  update  routes/users.js:5"},
+		},
+		{
+			name: "nothing matches",
+			args: `{"filter": "orders"}`,
+			want: []string{"No routes match (found 7 route(s) in total)"},
+		},
+		{
+			name: "another framework",
+			args: `{"framework": "rails"}`,
+			want: []string{"No routes found in 0 file(s) using rails route patterns"},
+		},
+		{
+			name:   "outside the work directory",
+			args:   `{"path": "../"}`,
+			errMsg: "access denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.Execute(tt.args)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("output does not contain %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/core"
+)
+
+const (
+	// defaultCommandTimeout applies when commands.timeout isn't set
+	defaultCommandTimeout = 5 * time.Minute
+	// maxCommandOutputLines keeps the end of long outputs, where test
+	// runners put their summary
+	maxCommandOutputLines = 200
+	// backgroundStartupWait is how long a background command is watched
+	// for an early exit
+	backgroundStartupWait = 2 * time.Second
+	// backgroundStopWait is how long a stopped command gets to exit after
+	// an interrupt before it is killed
+	backgroundStopWait = 5 * time.Second
+	// maxBackgroundOutput is how much of a background command's latest
+	// output is kept in memory for list and stop
+	maxBackgroundOutput = 64 * 1024
+)
+
+// CommandConfig is the commands block of config.json
+type CommandConfig struct {
+	Allow   []string // Allowed command prefixes, e.g. "go test", "npm run dev"
+	Timeout int      // Seconds before a foreground command is stopped (default 300)
+}
+
+// RunCommandTool runs allowlisted commands in the project after the user
+// confirms each one
+type RunCommandTool struct {
+	workDir        string
+	zapDir         string
+	allow          [][]string
+	timeout        time.Duration
+	confirmManager *ConfirmationManager
+	eventCallback  core.EventCallback
+
+	mu         sync.Mutex
+	background []*backgroundProcess // In start order, exited ones included
+}
+
+// backgroundProcess is a command left running by background: true
+type backgroundProcess struct {
+	command string
+	logPath string
+	started time.Time
+	cmd     *exec.Cmd
+	output  *outputTail
+	done    chan struct{} // Closed when the command exits
+	err     error         // Set before done is closed
+}
+
+// outputTail keeps the last maxBackgroundOutput bytes written to it
+type outputTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write implements io.Writer
+func (o *outputTail) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buf = append(o.buf, p...)
+	if over := len(o.buf) - maxBackgroundOutput; over > 0 {
+		o.buf = append(o.buf[:0], o.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+// lastLines returns up to n of the last complete lines
+func (o *outputTail) lastLines(n int) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	lines := splitLogLines(string(o.buf))
+	if len(o.buf) == maxBackgroundOutput && len(lines) > 0 {
+		lines = lines[1:] // Probably cut
+	}
+	return lines[max(0, len(lines)-n):]
+}
+
+// NewRunCommandTool creates a new command tool allowing the commands in cfg
+func NewRunCommandTool(workDir, zapDir string, cfg CommandConfig, confirmManager *ConfirmationManager) *RunCommandTool {
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	t := &RunCommandTool{
+		workDir:        workDir,
+		zapDir:         zapDir,
+		timeout:        defaultCommandTimeout,
+		confirmManager: confirmManager,
+	}
+	if cfg.Timeout > 0 {
+		t.timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	for _, entry := range cfg.Allow {
+		if words, err := splitShellWords(entry); err == nil && len(words) > 0 {
+			t.allow = append(t.allow, words)
+		}
+	}
+	return t
+}
+
+// RunCommandParams defines the command to run
+type RunCommandParams struct {
+	Action     string `json:"action,omitempty"` // run (default), list or stop
+	Command    string `json:"command"`
+	Dir        string `json:"dir,omitempty"`        // Working directory in the project
+	Background bool   `json:"background,omitempty"` // Leave running (dev servers); output goes to a log file
+	PID        int    `json:"pid,omitempty"`        // Background command to stop
+}
+
+// Name returns the tool name
+func (t *RunCommandTool) Name() string {
+	return "run_command"
+}
+
+// Description returns the tool description
+func (t *RunCommandTool) Description() string {
+	return "Run a shell command from the allowlist in config (commands.allow), e.g. the project's tests or a dev server restart. The user confirms every run. background leaves long-running commands such as dev servers running; list shows them with their latest output and stop ends one. They are all stopped when zap exits"
+}
+
+// Parameters returns the tool parameter description
+func (t *RunCommandTool) Parameters() string {
+	return `{"action": "string - run (default), list (background commands) or stop", "command": "string (required to run) - e.g. go test ./..., starting with an allowed command", "dir": "string - working directory in the project", "background": "boolean - leave it running (dev servers) and write its output to .zap/logs/", "pid": "number (required to stop) - pid of the background command"}`
+}
+
+// SetEventCallback sets the callback for emitting events to the TUI.
+// This implements the ConfirmableTool interface.
+func (t *RunCommandTool) SetEventCallback(callback core.EventCallback) {
+	t.eventCallback = callback
+}
+
+// Execute checks the command against the allowlist, asks the user and runs it
+func (t *RunCommandTool) Execute(args string) (string, error) {
+	var params RunCommandParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	switch params.Action {
+	case "", "run":
+	case "list":
+		return t.list(), nil
+	case "stop":
+		return t.stop(params.PID)
+	default:
+		return "", fmt.Errorf("unknown action '%s' (use run, list or stop)", params.Action)
+	}
+	if len(t.allow) == 0 {
+		return "", fmt.Errorf("run_command is disabled: list the allowed commands in commands.allow in .zap/config.json")
+	}
+	words, err := splitShellWords(params.Command)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse command: %w", err)
+	}
+	if len(words) == 0 {
+		return "", fmt.Errorf("command is required")
+	}
+	for _, word := range words {
+		switch word {
+		case "&&", "||", ";", "|", "&", ">", ">>", "<", "2>", "2>&1":
+			return "", fmt.Errorf("%q is not supported: commands run without a shell, so pipes, redirects and chaining don't work", word)
+		}
+	}
+	if !t.allowed(words) {
+		return "", fmt.Errorf("%q is not in the allowlist (commands.allow in .zap/config.json): %s", words[0], t.allowlist())
+	}
+
+	dir := t.workDir
+	if params.Dir != "" {
+		if dir, err = ValidatePathWithinWorkDir(params.Dir, t.workDir); err != nil {
+			return "", err
+		}
+	}
+
+	if t.eventCallback != nil {
+		t.eventCallback(core.AgentEvent{
+			Type: "confirmation_required",
+			CommandConfirmation: &core.CommandConfirmation{
+				Command:    params.Command,
+				Dir:        params.Dir,
+				Background: params.Background,
+			},
+		})
+	}
+	if !t.confirmManager.RequestConfirmation() {
+		return "User rejected the command. It was not run.", nil
+	}
+
+	if params.Background {
+		return t.start(words, dir, params.Command)
+	}
+	return t.run(words, dir, params.Command)
+}
+
+// allowed reports whether words start with the words of an allowlist entry
+func (t *RunCommandTool) allowed(words []string) bool {
+	for _, entry := range t.allow {
+		if len(entry) > len(words) {
+			continue
+		}
+		match := true
+		for i, word := range entry {
+			if words[i] != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// allowlist lists the allowed commands for error messages
+func (t *RunCommandTool) allowlist() string {
+	entries := make([]string, len(t.allow))
+	for i, entry := range t.allow {
+		quoted := make([]string, len(entry))
+		for j, word := range entry {
+			quoted[j] = shellQuote(word)
+		}
+		entries[i] = strings.Join(quoted, " ")
+	}
+	return strings.Join(entries, ", ")
+}
+
+// run waits for the command and reports its exit code and output
+func (t *RunCommandTool) run(words []string, dir, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	var sb strings.Builder
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		sb.WriteString(fmt.Sprintf("%s stopped after the %s timeout (commands.timeout in config)\n", command, t.timeout))
+	case err != nil:
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return "", fmt.Errorf("failed to run command: %w", err)
+		}
+		sb.WriteString(fmt.Sprintf("%s exited with code %d after %s\n", command, exitErr.ExitCode(), elapsed))
+	default:
+		sb.WriteString(fmt.Sprintf("%s succeeded in %s\n", command, elapsed))
+	}
+
+	lines := splitLogLines(output.String())
+	if len(lines) == 0 {
+		sb.WriteString("(no output)\n")
+		return sb.String(), nil
+	}
+	if len(lines) > maxCommandOutputLines {
+		sb.WriteString(fmt.Sprintf("(first %d line(s) of output left out)\n", len(lines)-maxCommandOutputLines))
+		lines = lines[len(lines)-maxCommandOutputLines:]
+	}
+	sb.WriteString("\n" + strings.Join(lines, "\n") + "\n")
+	return sb.String(), nil
+}
+
+// start leaves the command running with its output in .zap/logs/, after
+// checking it doesn't exit straight away
+func (t *RunCommandTool) start(words []string, dir, command string) (string, error) {
+	logDir := filepath.Join(t.zapDir, "logs")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create logs folder: %w", err)
+	}
+	logPath := filepath.Join(logDir, fmt.Sprintf("%s-%s.log", filepath.Base(words[0]), time.Now().Format("20060102-150405")))
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create log file: %w", err)
+	}
+
+	proc := &backgroundProcess{
+		command: command,
+		logPath: logPath,
+		started: time.Now(),
+		cmd:     exec.Command(words[0], words[1:]...),
+		output:  &outputTail{},
+		done:    make(chan struct{}),
+	}
+	// One writer for both, so exec writes to it from one goroutine at a time
+	out := io.MultiWriter(logFile, proc.output)
+	proc.cmd.Dir = dir
+	proc.cmd.Stdout = out
+	proc.cmd.Stderr = out
+	if err := proc.cmd.Start(); err != nil {
+		logFile.Close()
+		return "", fmt.Errorf("failed to start command: %w", err)
+	}
+	go func() {
+		proc.err = proc.cmd.Wait()
+		logFile.Close()
+		close(proc.done)
+	}()
+
+	select {
+	case <-proc.done:
+		return fmt.Sprintf("%s exited right away with %s\n\n%s\n", command, proc.status(), strings.Join(proc.output.lastLines(50), "\n")), nil
+	case <-time.After(backgroundStartupWait):
+	}
+
+	t.mu.Lock()
+	t.background = append(t.background, proc)
+	t.mu.Unlock()
+	return fmt.Sprintf("%s is running in the background (pid %d). Its output goes to %s; read it with tail_logs {\"file\": %q}, see it with action list and end it with action stop.\n", command, proc.cmd.Process.Pid, logPath, logPath), nil
+}
+
+// running reports whether the command is still running
+func (p *backgroundProcess) running() bool {
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// status describes an exited command's exit code
+func (p *backgroundProcess) status() string {
+	if exitErr, ok := p.err.(*exec.ExitError); ok {
+		if exitErr.ExitCode() < 0 {
+			return exitErr.String()
+		}
+		return fmt.Sprintf("code %d", exitErr.ExitCode())
+	}
+	if p.err != nil {
+		return p.err.Error()
+	}
+	return "code 0"
+}
+
+// list describes the background commands and their latest output
+func (t *RunCommandTool) list() string {
+	t.mu.Lock()
+	procs := append([]*backgroundProcess(nil), t.background...)
+	t.mu.Unlock()
+	if len(procs) == 0 {
+		return "No background commands. Start one with background: true."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d background command(s):\n", len(procs)))
+	for _, p := range procs {
+		state := fmt.Sprintf("running for %s", time.Since(p.started).Round(time.Second))
+		if !p.running() {
+			state = "exited with " + p.status()
+		}
+		sb.WriteString(fmt.Sprintf("\npid %d: %s (%s)\n  log: %s\n", p.cmd.Process.Pid, p.command, state, p.logPath))
+		for _, line := range p.output.lastLines(5) {
+			sb.WriteString("  | " + line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// stop interrupts the background command with the given pid, kills it if
+// it is still running after backgroundStopWait, and forgets it
+func (t *RunCommandTool) stop(pid int) (string, error) {
+	if pid == 0 {
+		return "", fmt.Errorf("pid is required to stop a background command (see action list)")
+	}
+	t.mu.Lock()
+	var proc *backgroundProcess
+	for i, p := range t.background {
+		if p.cmd.Process.Pid == pid {
+			proc = p
+			t.background = append(t.background[:i], t.background[i+1:]...)
+			break
+		}
+	}
+	t.mu.Unlock()
+	if proc == nil {
+		return "", fmt.Errorf("no background command with pid %d (see action list)", pid)
+	}
+
+	if !proc.running() {
+		return fmt.Sprintf("%s (pid %d) had already exited with %s\n", proc.command, pid, proc.status()), nil
+	}
+	proc.terminate()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Stopped %s (pid %d) after %s\n", proc.command, pid, time.Since(proc.started).Round(time.Second)))
+	if lines := proc.output.lastLines(20); len(lines) > 0 {
+		sb.WriteString("\nLast output:\n" + strings.Join(lines, "\n") + "\n")
+	}
+	return sb.String(), nil
+}
+
+// terminate interrupts the command, or kills it where interrupts aren't
+// supported (Windows) or don't stop it in time, and waits for it to exit
+func (p *backgroundProcess) terminate() {
+	if err := p.cmd.Process.Signal(os.Interrupt); err != nil {
+		p.cmd.Process.Kill()
+	}
+	select {
+	case <-p.done:
+	case <-time.After(backgroundStopWait):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+}
+
+// Cleanup stops all background commands (call on shutdown)
+func (t *RunCommandTool) Cleanup() {
+	t.mu.Lock()
+	procs := t.background
+	t.background = nil
+	t.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range procs {
+		if !p.running() {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.terminate()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/core"
+)
+
+// approveAll returns an event callback that confirms every request
+func approveAll(confirmManager *ConfirmationManager) core.EventCallback {
+	return func(event core.AgentEvent) {
+		if event.Type != "confirmation_required" {
+			return
+		}
+		go func() {
+			for !confirmManager.IsPending() {
+				time.Sleep(5 * time.Millisecond)
+			}
+			confirmManager.SendResponse(true)
+		}()
+	}
+}
+
+// newApprovingRunCommandTool returns a run_command tool whose runs are all
+// confirmed
+func newApprovingRunCommandTool(t *testing.T, allow ...string) *RunCommandTool {
+	t.Helper()
+	dir := t.TempDir()
+	confirmManager := NewConfirmationManager()
+	tool := NewRunCommandTool(dir, dir, CommandConfig{Allow: allow}, confirmManager)
+	tool.SetEventCallback(approveAll(confirmManager))
+	t.Cleanup(tool.Cleanup)
+	return tool
+}
+
+var backgroundPID = regexp.MustCompile(`\(pid (\d+)\)`)
+
+func TestRunCommandTool_Background(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tool := newApprovingRunCommandTool(t, "sh -c")
+
+	got, err := tool.Execute(`{"command": "sh -c 'echo ready; exec sleep 30'", "background": true}`)
+	if err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	m := backgroundPID.FindStringSubmatch(got)
+	if m == nil {
+		t.Fatalf("no pid in %q", got)
+	}
+	pid, _ := strconv.Atoi(m[1])
+
+	list, err := tool.Execute(`{"action": "list"}`)
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	for _, want := range []string{"1 background command(s)", fmt.Sprintf("pid %d: sh -c 'echo ready; exec sleep 30' (running for", pid), "| ready"} {
+		if !strings.Contains(list, want) {
+			t.Errorf("list does not contain %q:\n%s", want, list)
+		}
+	}
+
+	stopped, err := tool.Execute(fmt.Sprintf(`{"action": "stop", "pid": %d}`, pid))
+	if err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if !strings.Contains(stopped, fmt.Sprintf("Stopped sh -c 'echo ready; exec sleep 30' (pid %d)", pid)) || !strings.Contains(stopped, "ready") {
+		t.Errorf("unexpected stop output:\n%s", stopped)
+	}
+	if list, _ := tool.Execute(`{"action": "list"}`); !strings.Contains(list, "No background commands") {
+		t.Errorf("stopped command still listed:\n%s", list)
+	}
+	if _, err := tool.Execute(fmt.Sprintf(`{"action": "stop", "pid": %d}`, pid)); err == nil || !strings.Contains(err.Error(), "no background command with pid") {
+		t.Errorf("second stop: error = %v", err)
+	}
+}
+
+func TestRunCommandTool_BackgroundExitsRightAway(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tool := newApprovingRunCommandTool(t, "sh -c")
+
+	got, err := tool.Execute(`{"command": "sh -c 'echo boom; exit 3'", "background": true}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(got, "exited right away with code 3") || !strings.Contains(got, "boom") {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+	if list, _ := tool.Execute(`{"action": "list"}`); !strings.Contains(list, "No background commands") {
+		t.Errorf("exited command listed:\n%s", list)
+	}
+}
+
+func TestRunCommandTool_Cleanup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	tool := newApprovingRunCommandTool(t, "sleep")
+
+	if _, err := tool.Execute(`{"command": "sleep 30", "background": true}`); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	tool.mu.Lock()
+	proc := tool.background[0]
+	tool.mu.Unlock()
+
+	start := time.Now()
+	tool.Cleanup()
+	if proc.running() {
+		t.Fatal("command still running after Cleanup")
+	}
+	if elapsed := time.Since(start); elapsed > backgroundStopWait {
+		t.Errorf("Cleanup took %s; sleep should stop on an interrupt", elapsed)
+	}
+	if list, _ := tool.Execute(`{"action": "list"}`); !strings.Contains(list, "No background commands") {
+		t.Errorf("command listed after Cleanup:\n%s", list)
+	}
+}
+
+func TestRunCommandTool_Errors(t *testing.T) {
+	tool := newApprovingRunCommandTool(t, "go test")
+	tests := []struct {
+		name   string
+		args   string
+		errMsg string
+	}{
+		{"unknown action", `{"action": "restart"}`, "unknown action 'restart'"},
+		{"stop without pid", `{"action": "stop"}`, "pid is required"},
+		{"stop unknown pid", `{"action": "stop", "pid": 1}`, "no background command with pid 1"},
+		{"no command", `{"command": ""}`, "command is required"},
+		{"not allowed", `{"command": "rm -rf /"}`, "not in the allowlist"},
+		{"shell operators", `{"command": "go test ./... && rm -rf /"}`, "commands run without a shell"},
+		{"dir outside the project", `{"command": "go test", "dir": "../.."}`, "access denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tool.Execute(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+
+	disabled := NewRunCommandTool(t.TempDir(), "", CommandConfig{}, NewConfirmationManager())
+	if _, err := disabled.Execute(`{"command": "go test"}`); err == nil || !strings.Contains(err.Error(), "run_command is disabled") {
+		t.Errorf("error = %v, want run_command is disabled", err)
+	}
+	if list, err := disabled.Execute(`{"action": "list"}`); err != nil || !strings.Contains(list, "No background commands") {
+		t.Errorf("list on a disabled tool = %q, %v", list, err)
+	}
+}
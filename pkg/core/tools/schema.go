@@ -1,30 +1,54 @@
 package tools
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/xeipuuv/gojsonschema"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
 )
 
+// maxSchemaSize limits schema documents loaded from URLs
+const maxSchemaSize = 5 * 1024 * 1024
+
 // SchemaValidationTool validates JSON responses against JSON Schema
 type SchemaValidationTool struct {
 	responseManager *ResponseManager
+	workDir         string
+	client          *http.Client
 }
 
-// NewSchemaValidationTool creates a new schema validation tool
-func NewSchemaValidationTool(responseManager *ResponseManager) *SchemaValidationTool {
+// NewSchemaValidationTool creates a new schema validation tool; schema files
+// and file $refs are read from within workDir
+func NewSchemaValidationTool(responseManager *ResponseManager, workDir string) *SchemaValidationTool {
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
 	return &SchemaValidationTool{
 		responseManager: responseManager,
+		workDir:         workDir,
+		client:          &http.Client{Timeout: 15 * time.Second},
 	}
 }
 
 // SchemaParams defines schema validation parameters
 type SchemaParams struct {
-	Schema       interface{} `json:"schema"`                  // Inline schema or file path
-	SchemaURL    string      `json:"schema_url,omitempty"`    // Schema from URL
-	ResponseBody string      `json:"response_body,omitempty"` // Or use last_response
+	Schema       interface{} `json:"schema"`                  // Inline schema, or the path of a JSON/YAML schema file
+	SchemaURL    string      `json:"schema_url,omitempty"`    // Schema from an http(s) or file:// URL
+	Response     string      `json:"response,omitempty"`      // Response saved with save_response_as (default: the last one)
+	ResponseBody string      `json:"response_body,omitempty"` // Or validate this body
 }
 
 // Name returns the tool name
@@ -34,7 +58,7 @@ func (t *SchemaValidationTool) Name() string {
 
 // Description returns the tool description
 func (t *SchemaValidationTool) Description() string {
-	return "Validate JSON response body against a JSON Schema specification (draft-07, draft-2020-12)"
+	return "Validate a JSON response body against a JSON Schema (draft-04 to 2020-12 from its $schema, draft-07 without one), given inline, as a file path or a URL. $refs to $defs, anchors, other files and URLs are resolved; each error gives the JSON pointer of the failing value"
 }
 
 // Parameters returns the tool parameter description
@@ -46,10 +70,12 @@ func (t *SchemaValidationTool) Parameters() string {
     "properties": {
       "id": {"type": "integer"},
       "name": {"type": "string"},
-      "email": {"type": "string", "format": "email"}
+      "email": {"type": "string", "format": "email"},
+      "address": {"$ref": "schemas/address.json"}
     }
   }
-}`
+}
+or {"schema": "schemas/user.json"} / {"schema_url": "https://example.com/user.schema.json"}`
 }
 
 // Execute validates the response against the schema
@@ -64,56 +90,73 @@ func (t *SchemaValidationTool) Execute(args string) (string, error) {
 	if params.ResponseBody != "" {
 		responseBody = params.ResponseBody
 	} else {
-		lastResponse := t.responseManager.GetHTTPResponse()
-		if lastResponse == nil {
-			return "", fmt.Errorf("no HTTP response available - make an http_request first")
+		resp, err := t.responseManager.Response(params.Response)
+		if err != nil {
+			return "", err
 		}
-		responseBody = lastResponse.Body
+		responseBody = resp.Body
+	}
+	body, err := decodeJSONNumbers([]byte(responseBody))
+	if err != nil {
+		return "", fmt.Errorf("response body is not valid JSON: %w", err)
 	}
 
-	// Load the schema
-	var schemaLoader gojsonschema.JSONLoader
-
-	if params.SchemaURL != "" {
-		// Load schema from URL
-		schemaLoader = gojsonschema.NewReferenceLoader(params.SchemaURL)
-	} else if params.Schema != nil {
-		// Load inline schema
-		schemaJSON, err := json.Marshal(params.Schema)
+	// Load the schema; relative $refs resolve against where it came from
+	var root interface{}
+	var uri string
+	switch schema := params.Schema.(type) {
+	case nil:
+		if params.SchemaURL == "" {
+			return "", fmt.Errorf("either 'schema' or 'schema_url' must be provided")
+		}
+		uri = params.SchemaURL
+		if parsed, err := url.Parse(uri); err != nil || parsed.Scheme == "" {
+			if uri, err = t.fileURI(uri); err != nil {
+				return "", err
+			}
+		}
+		if root, err = t.loadSchema(uri); err != nil {
+			return "", fmt.Errorf("failed to load schema: %w", err)
+		}
+	case string:
+		if uri, err = t.fileURI(schema); err != nil {
+			return "", err
+		}
+		if root, err = t.loadSchema(uri); err != nil {
+			return "", fmt.Errorf("failed to load schema: %w", err)
+		}
+	default:
+		schemaJSON, err := json.Marshal(schema)
 		if err != nil {
 			return "", fmt.Errorf("failed to marshal schema: %w", err)
 		}
-		schemaLoader = gojsonschema.NewBytesLoader(schemaJSON)
-	} else {
-		return "", fmt.Errorf("either 'schema' or 'schema_url' must be provided")
+		if root, err = decodeJSONNumbers(schemaJSON); err != nil {
+			return "", fmt.Errorf("failed to decode schema: %w", err)
+		}
+		uri = (&url.URL{Scheme: "file", Path: filepath.ToSlash(t.workDir) + "/"}).String()
 	}
 
-	// Load the document to validate
-	documentLoader := gojsonschema.NewStringLoader(responseBody)
-
-	// Validate
-	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
+	compiled, err := compileJSONSchema(root, uri, jsonschema.Draft7, t.loadSchema)
+	if err != nil {
+		return "", fmt.Errorf("invalid schema: %w", err)
+	}
+	errs, err := validateJSONSchema(compiled, body, uri)
 	if err != nil {
-		return "", fmt.Errorf("schema validation error: %w", err)
+		return "", err
 	}
 
 	// Format results
 	var sb strings.Builder
 
-	if result.Valid() {
-		sb.WriteString("✓ JSON Schema validation passed\n\n")
+	if len(errs) == 0 {
+		sb.WriteString(fmt.Sprintf("✓ JSON Schema validation passed (%s)\n\n", schemaDraftName(compiled.DraftVersion)))
 		sb.WriteString("The response body conforms to the provided schema.")
 	} else {
-		sb.WriteString("✗ JSON Schema validation failed\n\n")
-		sb.WriteString(fmt.Sprintf("Found %d validation error(s):\n\n", len(result.Errors())))
-
-		for i, err := range result.Errors() {
-			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, formatValidationError(err)))
-			sb.WriteString(fmt.Sprintf("   Context: %s\n", err.Context().String()))
-			if err.Details() != nil && len(err.Details()) > 0 {
-				sb.WriteString(fmt.Sprintf("   Details: %v\n", err.Details()))
-			}
-			sb.WriteString("\n")
+		sb.WriteString(fmt.Sprintf("✗ JSON Schema validation failed (%s)\n\n", schemaDraftName(compiled.DraftVersion)))
+		sb.WriteString(fmt.Sprintf("Found %d validation error(s):\n\n", len(errs)))
+
+		for i, err := range errs {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, formatSchemaError(err, "   ")))
 		}
 
 		// Add helpful summary
@@ -128,39 +171,190 @@ func (t *SchemaValidationTool) Execute(args string) (string, error) {
 	return sb.String(), nil
 }
 
-// formatValidationError formats a validation error for display
-func formatValidationError(err gojsonschema.ResultError) string {
-	desc := err.Description()
+// fileURI returns the file:// URI of a schema file within the work directory
+func (t *SchemaValidationTool) fileURI(path string) (string, error) {
+	absPath, err := ValidatePathWithinWorkDir(path, t.workDir)
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)}).String(), nil
+}
+
+// loadSchema reads a JSON or YAML schema document from an http(s) URL or a
+// file within the work directory
+func (t *SchemaValidationTool) loadSchema(uri string) (interface{}, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema URI: %w", err)
+	}
+
+	var data []byte
+	switch parsed.Scheme {
+	case "http", "https":
+		resp, err := t.client.Get(uri)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch schema: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch schema: %s", resp.Status)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, maxSchemaSize)); err != nil {
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+	case "file":
+		absPath, err := ValidatePathWithinWorkDir(filepath.FromSlash(parsed.Path), t.workDir)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = os.ReadFile(absPath); err != nil {
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported schema URI '%s' (use a file path, http or https)", uri)
+	}
+
+	if schema, err := decodeJSONNumbers(data); err == nil {
+		return schema, nil
+	}
+	var schema interface{}
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("schema is neither JSON nor YAML: %w", err)
+	}
+	return normalizeYAML(schema), nil
+}
+
+// schemaError is one violation: where in the value, which keyword, and why
+type schemaError struct {
+	InstancePath string // JSON pointer into the value, "" for the root
+	SchemaPath   string // Failing keyword: a #/pointer into the root schema, or the URL of another document
+	Message      string
+	Kind         jsonschema.ErrorKind
+	Causes       []schemaError // For anyOf/oneOf, the errors of each alternative
+}
+
+// schemaMessages renders validation messages in English
+var schemaMessages = message.NewPrinter(language.English)
+
+// schemaLoader loads the documents $refs point to
+type schemaLoader func(uri string) (interface{}, error)
+
+// Load implements jsonschema.URLLoader
+func (l schemaLoader) Load(uri string) (interface{}, error) {
+	return l(uri)
+}
+
+// compileJSONSchema compiles root, found at uri. The draft is read from
+// $schema, defaulting to draft; load fetches the documents $refs point to.
+// Formats (email, date-time, uuid...) are checked in every draft.
+func compileJSONSchema(root interface{}, uri string, draft *jsonschema.Draft, load schemaLoader) (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(draft)
+	c.AssertFormat()
+	c.UseLoader(load)
+	if err := c.AddResource(uri, root); err != nil {
+		return nil, err
+	}
+	return c.Compile(uri)
+}
 
-	// Make error messages more user-friendly
-	switch err.Type() {
-	case "required":
-		return fmt.Sprintf("Required field missing: %s", err.Field())
-	case "invalid_type":
-		if err.Details() != nil {
-			expected := err.Details()["expected"]
-			actual := err.Details()["given"]
-			return fmt.Sprintf("Type mismatch at '%s': expected %v, got %v", err.Field(), expected, actual)
+// validateJSONSchema validates value and returns its violations, with
+// schema paths relative to uri, the root schema's URI
+func validateJSONSchema(schema *jsonschema.Schema, value interface{}, uri string) ([]schemaError, error) {
+	err := schema.Validate(value)
+	if err == nil {
+		return nil, nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return nil, fmt.Errorf("failed to validate: %w", err)
+	}
+	return appendSchemaErrors(nil, verr, uri), nil
+}
+
+// appendSchemaErrors adds one schemaError per failing keyword below e;
+// anyOf and oneOf keep the errors of their alternatives as causes
+func appendSchemaErrors(errs []schemaError, e *jsonschema.ValidationError, uri string) []schemaError {
+	switch e.ErrorKind.(type) {
+	case *kind.AnyOf, *kind.OneOf:
+		if len(e.Causes) > 0 {
+			schemaErr := newSchemaError(e, uri)
+			for _, cause := range e.Causes {
+				schemaErr.Causes = appendSchemaErrors(schemaErr.Causes, cause, uri)
+			}
+			return append(errs, schemaErr)
 		}
-	case "number_any_of", "number_one_of":
-		return fmt.Sprintf("Value at '%s' doesn't match any allowed schemas", err.Field())
-	case "format":
-		return fmt.Sprintf("Format validation failed at '%s': %s", err.Field(), desc)
-	case "enum":
-		return fmt.Sprintf("Value at '%s' not in allowed enum values: %s", err.Field(), desc)
-	case "minimum":
-		return fmt.Sprintf("Value at '%s' below minimum: %s", err.Field(), desc)
-	case "maximum":
-		return fmt.Sprintf("Value at '%s' above maximum: %s", err.Field(), desc)
-	case "min_length":
-		return fmt.Sprintf("String at '%s' too short: %s", err.Field(), desc)
-	case "max_length":
-		return fmt.Sprintf("String at '%s' too long: %s", err.Field(), desc)
-	case "pattern":
-		return fmt.Sprintf("String at '%s' doesn't match pattern: %s", err.Field(), desc)
-	case "additional_properties_false":
-		return fmt.Sprintf("Unexpected property '%s' (additional properties not allowed)", err.Field())
-	}
-
-	return desc
+	}
+	if len(e.Causes) == 0 {
+		return append(errs, newSchemaError(e, uri))
+	}
+	for _, cause := range e.Causes {
+		errs = appendSchemaErrors(errs, cause, uri)
+	}
+	return errs
+}
+
+func newSchemaError(e *jsonschema.ValidationError, uri string) schemaError {
+	location := e.SchemaURL
+	for _, key := range e.ErrorKind.KeywordPath() {
+		location += "/" + jsonPointerEscape(key)
+	}
+	var instance strings.Builder
+	for _, key := range e.InstanceLocation {
+		instance.WriteString("/" + jsonPointerEscape(key))
+	}
+	return schemaError{
+		InstancePath: instance.String(),
+		SchemaPath:   strings.TrimPrefix(location, uri),
+		Message:      e.ErrorKind.LocalizedString(schemaMessages),
+		Kind:         e.ErrorKind,
+	}
+}
+
+// jsonPointerEscape escapes a JSON pointer token
+func jsonPointerEscape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// schemaDraftName names a draft version: 7 is "draft-07", 2020 "draft 2020-12"
+func schemaDraftName(version int) string {
+	switch version {
+	case 2019:
+		return "draft 2019-09"
+	case 2020:
+		return "draft 2020-12"
+	}
+	return fmt.Sprintf("draft-%02d", version)
+}
+
+// decodeJSONNumbers decodes a JSON document keeping numbers exact
+func decodeJSONNumbers(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+// formatSchemaError formats a validation error for display: the JSON pointer
+// of the failing value, the message, the schema keyword and, for anyOf and
+// oneOf, why each alternative did not match
+func formatSchemaError(err schemaError, indent string) string {
+	path := err.InstancePath
+	if path == "" {
+		path = "(root)"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s: %s\n", path, err.Message))
+	sb.WriteString(fmt.Sprintf("%sSchema: %s\n", indent, err.SchemaPath))
+	for _, cause := range err.Causes {
+		sb.WriteString(fmt.Sprintf("%s- %s", indent, formatSchemaError(cause, indent+"  ")))
+	}
+	return sb.String()
 }
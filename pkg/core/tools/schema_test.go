@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaValidationTool(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workDir, "schemas"), 0755); err != nil {
+		t.Fatalf("failed to create schemas dir: %v", err)
+	}
+	files := map[string]string{
+		"schemas/address.json": `{"type": "object", "required": ["city"], "properties": {"city": {"type": "string"}}}`,
+		"schemas/user.yaml":    "type: object\nrequired: [id]\nproperties:\n  id: {type: integer}\n  address: {$ref: address.json}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(workDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	tool := NewSchemaValidationTool(NewResponseManager(), workDir)
+
+	tests := []struct {
+		name   string
+		schema string // JSON of the schema parameter
+		body   string
+		want   []string // Substrings of the output
+		errMsg string
+	}{
+		{
+			name:   "passes",
+			schema: `{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}`,
+			body:   `{"id": 1}`,
+			want:   []string{"✓ JSON Schema validation passed (draft-07)"},
+		},
+		{
+			name:   "type and required errors",
+			schema: `{"type": "object", "required": ["id", "name"], "properties": {"id": {"type": "integer"}}}`,
+			body:   `{"id": "1"}`,
+			want:   []string{"draft-07", "2 validation error(s)", "/id: got string, want integer", "Schema: #/properties/id/type", "(root): missing property 'name'"},
+		},
+		{
+			name:   "draft from $schema",
+			schema: `{"$schema": "https://json-schema.org/draft/2020-12/schema", "prefixItems": [{"type": "string"}]}`,
+			body:   `[1]`,
+			want:   []string{"failed (draft 2020-12)", "/0: got number, want string"},
+		},
+		{
+			name:   "draft-07 ignores 2020-12 keywords",
+			schema: `{"prefixItems": [{"type": "string"}]}`,
+			body:   `[1]`,
+			want:   []string{"passed (draft-07)"},
+		},
+		{
+			name:   "formats are checked",
+			schema: `{"type": "string", "format": "email"}`,
+			body:   `"not an email"`,
+			want:   []string{"is not valid email"},
+		},
+		{
+			name:   "numbers beyond float64 precision compare exactly",
+			schema: `{"type": "integer", "maximum": 9007199254740993}`,
+			body:   `9007199254740994`,
+			want:   []string{"(root): maximum: got"},
+		},
+		{
+			name:   "anyOf lists each alternative",
+			schema: `{"anyOf": [{"type": "string"}, {"type": "integer", "minimum": 10}]}`,
+			body:   `5`,
+			want:   []string{"(root): 'anyOf' failed", "- (root): got number, want string", "- (root): minimum: got 5, want 10", "Schema: #/anyOf/1/minimum"},
+		},
+		{
+			name:   "schema file with a $ref to another file",
+			schema: `"schemas/user.yaml"`,
+			body:   `{"id": 1, "address": {}}`,
+			want:   []string{"/address: missing property 'city'", "Schema: file://" + filepath.ToSlash(workDir) + "/schemas/address.json#/required"},
+		},
+		{
+			name:   "inline schema $ref relative to the work directory",
+			schema: `{"properties": {"address": {"$ref": "schemas/address.json"}}}`,
+			body:   `{"address": {"city": 1}}`,
+			want:   []string{"/address/city: got number, want string"},
+		},
+		{
+			name:   "schema file outside the work directory",
+			schema: `"../user.json"`,
+			body:   `{}`,
+			errMsg: "outside",
+		},
+		{
+			name:   "invalid schema",
+			schema: `{"type": 1}`,
+			body:   `{}`,
+			errMsg: "invalid schema",
+		},
+		{
+			name:   "body is not JSON",
+			schema: `{}`,
+			body:   `{`,
+			errMsg: "response body is not valid JSON",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(map[string]interface{}{"schema": json.RawMessage(tt.schema), "response_body": tt.body})
+			got, err := tool.Execute(string(args))
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("output does not contain %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAPISpec_ValidateValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		schema  string
+		value   string
+		want    []string
+	}{
+		{
+			name:    "valid",
+			version: "3.0.3",
+			schema:  `{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}`,
+			value:   `{"id": 1}`,
+		},
+		{
+			name:    "missing and mistyped fields",
+			version: "3.0.3",
+			schema:  `{"type": "object", "required": ["id", "name"], "properties": {"id": {"type": "integer"}, "tags": {"type": "array", "items": {"type": "string"}}}}`,
+			value:   `{"id": "x", "tags": ["a", 2]}`,
+			want: []string{
+				"body.id: expected integer, got string",
+				"body.name: required by the spec but missing",
+				"body.tags.1: expected string, got number",
+			},
+		},
+		{
+			name:    "3.0 nullable and boolean exclusiveMinimum",
+			version: "3.0.3",
+			schema:  `{"type": "object", "properties": {"n": {"type": "number", "minimum": 0, "exclusiveMinimum": true}, "s": {"type": "string", "nullable": true}}}`,
+			value:   `{"n": 0, "s": null}`,
+			want:    []string{"body.n: exclusiveMinimum: got 0, want 0"},
+		},
+		{
+			name:    "3.1 numeric exclusiveMinimum",
+			version: "3.1.0",
+			schema:  `{"type": "object", "properties": {"n": {"type": "number", "exclusiveMinimum": 0}}}`,
+			value:   `{"n": 0}`,
+			want:    []string{"body.n: exclusiveMinimum: got 0, want 0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema map[string]interface{}
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.schema), &schema); err != nil {
+				t.Fatalf("invalid schema: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("invalid value: %v", err)
+			}
+			spec := &OpenAPISpec{Version: tt.version}
+			got := spec.validateValue(schema, value, "body")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,939 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Scripts are short programs on a request: pre_script runs before it is sent
+// and can compute variables, headers and body fields; post_script runs on the
+// response and can save variables and assert. Statements are separated by
+// newlines or ';', and '#' starts a comment:
+//
+//	sig = hmac_sha256(SECRET, request.method + request.url + timestamp())
+//	request.headers["X-Signature"] = sig
+//	assert response.status == 200 && len(response.body.items) > 0, "no items"
+//	next_cursor = response.body.cursor
+//
+// Bare names are variables ({{NAME}} elsewhere); assigning one saves it.
+
+// Script stages
+const (
+	stagePreScript  = "pre_script"
+	stagePostScript = "post_script"
+)
+
+// maxScriptLength bounds a script, which runs on every request that has one
+const maxScriptLength = 10000
+
+// scriptEnv is what a script can read and change
+type scriptEnv struct {
+	stage    string
+	vars     *VariableStore         // May be nil
+	request  map[string]interface{} // The request as JSON (changeable in pre_script)
+	response map[string]interface{} // Set in post_script
+	locals   map[string]interface{} // Variables assigned while no store is available
+	checks   int
+	failures []string
+}
+
+// runScript runs script's statements in order. A failed assert is recorded in
+// env.failures; any other problem stops the script with an error.
+func runScript(script string, env *scriptEnv) error {
+	if len(script) > maxScriptLength {
+		return fmt.Errorf("%s is too long (max %d characters)", env.stage, maxScriptLength)
+	}
+	tokens, err := lexScript(script)
+	if err != nil {
+		return fmt.Errorf("%s: %w", env.stage, err)
+	}
+	p := &scriptParser{tokens: tokens}
+	for n := 1; !p.at(tokEOF, ""); {
+		if p.at(tokSep, "") {
+			p.next()
+			continue
+		}
+		stmt, err := p.statement()
+		if err != nil {
+			return fmt.Errorf("%s, statement %d: %w", env.stage, n, err)
+		}
+		if err := stmt(env); err != nil {
+			return fmt.Errorf("%s, statement %d: %w", env.stage, n, err)
+		}
+		n++
+	}
+	return nil
+}
+
+// evalScriptExpr evaluates a single expression, such as a suite test's if
+// condition
+func evalScriptExpr(src string, env *scriptEnv) (interface{}, error) {
+	tokens, err := lexScript(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", env.stage, err)
+	}
+	p := &scriptParser{tokens: tokens}
+	for p.at(tokSep, "") {
+		p.next()
+	}
+	expr, err := p.expression()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", env.stage, err)
+	}
+	for p.at(tokSep, "") {
+		p.next()
+	}
+	if !p.at(tokEOF, "") {
+		return nil, fmt.Errorf("%s: unexpected %s", env.stage, p.describe())
+	}
+	value, err := expr(env)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", env.stage, err)
+	}
+	return value, nil
+}
+
+// Token kinds
+const (
+	tokEOF = iota
+	tokSep
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type scriptToken struct {
+	kind int
+	text string
+}
+
+// scriptOps are the operators and punctuation, longest first
+var scriptOps = []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "<", ">", "!", "=", ".", ",", "(", ")", "[", "]", "{", "}", ":"}
+
+// scriptWordOps are the words that can be written for && || and !
+var scriptWordOps = map[string]string{"and": "&&", "or": "||", "not": "!"}
+
+func lexScript(src string) ([]scriptToken, error) {
+	var tokens []scriptToken
+	depth := 0 // Newlines inside brackets don't end a statement
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '\n' || c == ';':
+			if depth == 0 || c == ';' {
+				tokens = append(tokens, scriptToken{kind: tokSep})
+			}
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, scriptToken{kind: tokNumber, text: src[i:j]})
+			i = j
+		case c == '"' || c == '\'':
+			text, n, err := lexString(src[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, scriptToken{kind: tokString, text: text})
+			i += n
+		case isIdentByte(c):
+			j := i
+			for j < len(src) && (isIdentByte(src[j]) || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			if op, ok := scriptWordOps[src[i:j]]; ok {
+				tokens = append(tokens, scriptToken{kind: tokOp, text: op})
+			} else {
+				tokens = append(tokens, scriptToken{kind: tokIdent, text: src[i:j]})
+			}
+			i = j
+		default:
+			op := ""
+			for _, candidate := range scriptOps {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character '%c'", c)
+			}
+			switch op {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth--
+			}
+			tokens = append(tokens, scriptToken{kind: tokOp, text: op})
+			i += len(op)
+		}
+	}
+	return append(tokens, scriptToken{kind: tokEOF}), nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// lexString reads a quoted string at the start of src and returns its value
+// and length. Escapes are those of Go and JSON.
+func lexString(src string) (string, int, error) {
+	quote := src[0]
+	var sb strings.Builder
+	for i := 1; i < len(src); i++ {
+		switch c := src[i]; c {
+		case quote:
+			return sb.String(), i + 1, nil
+		case '\\':
+			if i+1 == len(src) {
+				break
+			}
+			i++
+			switch src[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				sb.WriteByte(src[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// scriptExpr evaluates to a JSON-like value: nil, bool, float64, string,
+// []interface{} or map[string]interface{}
+type scriptExpr func(env *scriptEnv) (interface{}, error)
+
+type scriptStmt func(env *scriptEnv) error
+
+type scriptParser struct {
+	tokens []scriptToken
+	pos    int
+}
+
+func (p *scriptParser) peek() scriptToken {
+	return p.tokens[p.pos]
+}
+
+func (p *scriptParser) next() scriptToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// at reports whether the next token is of kind (and is text, when given)
+func (p *scriptParser) at(kind int, text string) bool {
+	tok := p.peek()
+	return tok.kind == kind && (text == "" || tok.text == text)
+}
+
+func (p *scriptParser) expect(text string) error {
+	if !p.at(tokOp, text) {
+		return fmt.Errorf("expected '%s', got %s", text, p.describe())
+	}
+	p.next()
+	return nil
+}
+
+// describe names the next token for errors
+func (p *scriptParser) describe() string {
+	switch tok := p.peek(); tok.kind {
+	case tokEOF:
+		return "end of script"
+	case tokSep:
+		return "end of statement"
+	case tokString:
+		return strconv.Quote(tok.text)
+	default:
+		return "'" + tok.text + "'"
+	}
+}
+
+// statement parses an assert, an assignment or an expression
+func (p *scriptParser) statement() (scriptStmt, error) {
+	start := p.pos
+	var stmt scriptStmt
+	if p.at(tokIdent, "assert") {
+		p.next()
+		cond, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		var message scriptExpr
+		if p.at(tokOp, ",") {
+			p.next()
+			if message, err = p.expression(); err != nil {
+				return nil, err
+			}
+		}
+		stmt = assertStmt(cond, message)
+	} else {
+		target, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		stmt = func(env *scriptEnv) error {
+			_, err := target(env)
+			return err
+		}
+		if p.at(tokOp, "=") {
+			p.next()
+			lvalue, ok := scriptTarget(p.tokens[start : p.pos-1])
+			if !ok {
+				return nil, fmt.Errorf("can only assign to a variable or a field of request")
+			}
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			stmt = assignStmt(lvalue, value)
+		}
+	}
+	if !p.at(tokSep, "") && !p.at(tokEOF, "") {
+		return nil, fmt.Errorf("unexpected %s", p.describe())
+	}
+	return stmt, nil
+}
+
+// scriptLValue is an assignment target: a variable, or a path into request
+type scriptLValue struct {
+	root string
+	path []scriptExpr
+}
+
+// scriptTarget re-reads the tokens before an '=' as an assignment target
+func scriptTarget(tokens []scriptToken) (scriptLValue, bool) {
+	sub := &scriptParser{tokens: append(append([]scriptToken{}, tokens...), scriptToken{kind: tokEOF})}
+	if !sub.at(tokIdent, "") {
+		return scriptLValue{}, false
+	}
+	target := scriptLValue{root: sub.next().text}
+	for !sub.at(tokEOF, "") {
+		switch {
+		case sub.at(tokOp, "."):
+			sub.next()
+			if !sub.at(tokIdent, "") {
+				return scriptLValue{}, false
+			}
+			key := sub.next().text
+			target.path = append(target.path, func(*scriptEnv) (interface{}, error) { return key, nil })
+		case sub.at(tokOp, "["):
+			sub.next()
+			index, err := sub.expression()
+			if err != nil || sub.expect("]") != nil {
+				return scriptLValue{}, false
+			}
+			target.path = append(target.path, index)
+		default:
+			return scriptLValue{}, false
+		}
+	}
+	return target, true
+}
+
+func assertStmt(cond, message scriptExpr) scriptStmt {
+	return func(env *scriptEnv) error {
+		if env.stage != stagePostScript {
+			return fmt.Errorf("assert only works in post_script")
+		}
+		value, err := cond(env)
+		if err != nil {
+			return err
+		}
+		env.checks++
+		if truthy(value) {
+			return nil
+		}
+		text := "assertion failed"
+		if message != nil {
+			m, err := message(env)
+			if err != nil {
+				return err
+			}
+			text = scriptString(m)
+		}
+		env.failures = append(env.failures, text)
+		return nil
+	}
+}
+
+func assignStmt(target scriptLValue, value scriptExpr) scriptStmt {
+	return func(env *scriptEnv) error {
+		v, err := value(env)
+		if err != nil {
+			return err
+		}
+		switch target.root {
+		case "request":
+			if env.stage != stagePreScript {
+				return fmt.Errorf("request can only be changed in pre_script")
+			}
+			if len(target.path) == 0 {
+				return fmt.Errorf("can't replace request; set its fields")
+			}
+			return setPath(env, env.request, target.path, v)
+		case "response":
+			return fmt.Errorf("response can't be changed")
+		}
+		if len(target.path) > 0 {
+			return fmt.Errorf("variables hold text; assign '%s' as a whole", target.root)
+		}
+		if _, reserved := scriptConstants[target.root]; reserved {
+			return fmt.Errorf("can't assign to '%s'", target.root)
+		}
+		if env.vars == nil {
+			env.locals[target.root] = v
+			return nil
+		}
+		env.vars.Set(target.root, scriptString(v))
+		return nil
+	}
+}
+
+// setPath sets the field at path in obj, creating objects on the way
+func setPath(env *scriptEnv, obj map[string]interface{}, path []scriptExpr, value interface{}) error {
+	key, err := path[0](env)
+	if err != nil {
+		return err
+	}
+	name := scriptString(key)
+	if len(path) == 1 {
+		obj[name] = value
+		return nil
+	}
+	child, ok := obj[name].(map[string]interface{})
+	if !ok {
+		if obj[name] != nil {
+			return fmt.Errorf("'%s' is not an object", name)
+		}
+		child = map[string]interface{}{}
+		obj[name] = child
+	}
+	return setPath(env, child, path[1:], value)
+}
+
+// Binary operators by precedence, loosest first
+var scriptPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *scriptParser) expression() (scriptExpr, error) {
+	return p.binary(0)
+}
+
+func (p *scriptParser) binary(level int) (scriptExpr, error) {
+	if level == len(scriptPrecedence) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, candidate := range scriptPrecedence[level] {
+			if p.at(tokOp, candidate) {
+				op = candidate
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		p.next()
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr(op, left, right)
+	}
+}
+
+func binaryExpr(op string, left, right scriptExpr) scriptExpr {
+	return func(env *scriptEnv) (interface{}, error) {
+		l, err := left(env)
+		if err != nil {
+			return nil, err
+		}
+		// && and || short-circuit and give back one of their operands
+		switch op {
+		case "&&":
+			if !truthy(l) {
+				return l, nil
+			}
+			return right(env)
+		case "||":
+			if truthy(l) {
+				return l, nil
+			}
+			return right(env)
+		}
+		r, err := right(env)
+		if err != nil {
+			return nil, err
+		}
+		value, err := applyOperator(op, l, r)
+		// In a JSONPath filter, as in RFC 9535, values that can't be
+		// ordered compare false, so @ < 2 || @.b == "k" still matches objects
+		if err != nil && env.stage == filterStage && strings.ContainsAny(op, "<>") {
+			return false, nil
+		}
+		return value, err
+	}
+}
+
+func applyOperator(op string, l, r interface{}) (interface{}, error) {
+	switch op {
+	case "==":
+		return scriptEqual(l, r), nil
+	case "!=":
+		return !scriptEqual(l, r), nil
+	case "<", "<=", ">", ">=":
+		var cmp int
+		ln, lok := scriptNumber(l)
+		rn, rok := scriptNumber(r)
+		switch {
+		case lok && rok:
+			cmp = compareFloats(ln, rn)
+		default:
+			ls, lstr := l.(string)
+			rs, rstr := r.(string)
+			if !lstr || !rstr {
+				return nil, fmt.Errorf("can't compare %s and %s", scriptType(l), scriptType(r))
+			}
+			cmp = strings.Compare(ls, rs)
+		}
+		return map[string]bool{"<": cmp < 0, "<=": cmp <= 0, ">": cmp > 0, ">=": cmp >= 0}[op], nil
+	case "+":
+		if la, ok := l.([]interface{}); ok {
+			if ra, ok := r.([]interface{}); ok {
+				return append(append([]interface{}{}, la...), ra...), nil
+			}
+		}
+		// A number plus a number, or numeric text, adds; text joins
+		_, lnum := l.(float64)
+		_, rnum := r.(float64)
+		ln, lok := scriptNumber(l)
+		rn, rok := scriptNumber(r)
+		if (lnum || rnum) && lok && rok {
+			return ln + rn, nil
+		}
+		_, lstr := l.(string)
+		_, rstr := r.(string)
+		if lstr || rstr {
+			return scriptString(l) + scriptString(r), nil
+		}
+		return nil, fmt.Errorf("can't add %s and %s", scriptType(l), scriptType(r))
+	}
+
+	ln, lok := scriptNumber(l)
+	rn, rok := scriptNumber(r)
+	if !lok || !rok {
+		return nil, fmt.Errorf("'%s' needs numbers, got %s and %s", op, scriptType(l), scriptType(r))
+	}
+	switch op {
+	case "-":
+		return ln - rn, nil
+	case "*":
+		return ln * rn, nil
+	case "/":
+		if rn == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return ln / rn, nil
+	default: // %
+		if rn == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(ln, rn), nil
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (p *scriptParser) unary() (scriptExpr, error) {
+	if p.at(tokOp, "!") || p.at(tokOp, "-") {
+		op := p.next().text
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(env *scriptEnv) (interface{}, error) {
+			v, err := operand(env)
+			if err != nil {
+				return nil, err
+			}
+			if op != "-" {
+				return !truthy(v), nil
+			}
+			n, ok := scriptNumber(v)
+			if !ok {
+				return nil, fmt.Errorf("can't negate %s", scriptType(v))
+			}
+			return -n, nil
+		}, nil
+	}
+	return p.postfix()
+}
+
+func (p *scriptParser) postfix() (scriptExpr, error) {
+	expr, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.at(tokOp, "."):
+			p.next()
+			if !p.at(tokIdent, "") {
+				return nil, fmt.Errorf("expected a field name after '.', got %s", p.describe())
+			}
+			key := p.next().text
+			expr = indexExpr(expr, func(*scriptEnv) (interface{}, error) { return key, nil })
+		case p.at(tokOp, "["):
+			p.next()
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			expr = indexExpr(expr, index)
+		default:
+			return expr, nil
+		}
+	}
+}
+
+// indexExpr reads a field or element; a missing one is null
+func indexExpr(base, index scriptExpr) scriptExpr {
+	return func(env *scriptEnv) (interface{}, error) {
+		b, err := base(env)
+		if err != nil {
+			return nil, err
+		}
+		i, err := index(env)
+		if err != nil {
+			return nil, err
+		}
+		switch v := b.(type) {
+		case map[string]interface{}:
+			key := scriptString(i)
+			if value, ok := v[key]; ok {
+				return value, nil
+			}
+			// Header names are case-insensitive
+			for k, value := range v {
+				if strings.EqualFold(k, key) {
+					return value, nil
+				}
+			}
+			return nil, nil
+		case []interface{}:
+			n, ok := scriptNumber(i)
+			if !ok {
+				return nil, fmt.Errorf("list index must be a number, got %s", scriptType(i))
+			}
+			if n < 0 {
+				n += float64(len(v))
+			}
+			if n < 0 || int(n) >= len(v) {
+				return nil, nil
+			}
+			return v[int(n)], nil
+		case string:
+			n, ok := scriptNumber(i)
+			if !ok || n < 0 || int(n) >= len(v) {
+				return nil, nil
+			}
+			return v[int(n) : int(n)+1], nil
+		}
+		return nil, nil
+	}
+}
+
+// scriptConstants are the names that aren't variables
+var scriptConstants = map[string]interface{}{"true": true, "false": false, "null": nil, "request": nil, "response": nil}
+
+func (p *scriptParser) primary() (scriptExpr, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokNumber:
+		p.next()
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", tok.text)
+		}
+		return constExpr(n), nil
+	case tokString:
+		p.next()
+		return constExpr(tok.text), nil
+	case tokIdent:
+		p.next()
+		if p.at(tokOp, "(") {
+			return p.call(tok.text)
+		}
+		return identExpr(tok.text), nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			p.next()
+			expr, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			return expr, p.expect(")")
+		case "[":
+			p.next()
+			items, err := p.list("]")
+			if err != nil {
+				return nil, err
+			}
+			return func(env *scriptEnv) (interface{}, error) {
+				return evalAll(env, items)
+			}, nil
+		case "{":
+			return p.object()
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s", p.describe())
+}
+
+func constExpr(v interface{}) scriptExpr {
+	return func(*scriptEnv) (interface{}, error) { return v, nil }
+}
+
+func identExpr(name string) scriptExpr {
+	return func(env *scriptEnv) (interface{}, error) {
+		switch name {
+		case "request":
+			return env.request, nil
+		case "response":
+			if env.response == nil && env.stage == stagePreScript {
+				return nil, fmt.Errorf("response is only available in post_script")
+			}
+			if env.response == nil {
+				return nil, fmt.Errorf("there is no response yet")
+			}
+			return env.response, nil
+		}
+		if v, ok := scriptConstants[name]; ok {
+			return v, nil
+		}
+		if v, ok := env.locals[name]; ok {
+			return v, nil
+		}
+		if env.vars != nil {
+			if list, ok := env.vars.GetList(name); ok {
+				return list, nil
+			}
+			if v, ok := env.vars.Get(name); ok {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown variable '%s'", name)
+	}
+}
+
+// list parses comma-separated expressions up to end
+func (p *scriptParser) list(end string) ([]scriptExpr, error) {
+	var items []scriptExpr
+	for !p.at(tokOp, end) {
+		item, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if !p.at(tokOp, ",") {
+			break
+		}
+		p.next()
+	}
+	return items, p.expect(end)
+}
+
+func (p *scriptParser) object() (scriptExpr, error) {
+	p.next()
+	var keys []string
+	var values []scriptExpr
+	for !p.at(tokOp, "}") {
+		tok := p.next()
+		if tok.kind != tokIdent && tok.kind != tokString {
+			return nil, fmt.Errorf("expected a key, got '%s'", tok.text)
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		keys, values = append(keys, tok.text), append(values, value)
+		if !p.at(tokOp, ",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return func(env *scriptEnv) (interface{}, error) {
+		obj := make(map[string]interface{}, len(keys))
+		for i, key := range keys {
+			v, err := values[i](env)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = v
+		}
+		return obj, nil
+	}, nil
+}
+
+func (p *scriptParser) call(name string) (scriptExpr, error) {
+	fn, ok := scriptFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%s'", name)
+	}
+	p.next()
+	args, err := p.list(")")
+	if err != nil {
+		return nil, err
+	}
+	return func(env *scriptEnv) (interface{}, error) {
+		values, err := evalAll(env, args)
+		if err != nil {
+			return nil, err
+		}
+		v, err := fn(values)
+		if err != nil {
+			return nil, fmt.Errorf("%s(): %w", name, err)
+		}
+		return v, nil
+	}, nil
+}
+
+func evalAll(env *scriptEnv, exprs []scriptExpr) ([]interface{}, error) {
+	values := make([]interface{}, len(exprs))
+	for i, expr := range exprs {
+		v, err := expr(env)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// truthy is false for null, false, 0, "" and empty lists and objects
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// scriptNumber returns v as a number; numeric text counts, since variables
+// hold text
+func scriptNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// scriptString renders v as text: strings as they are, numbers without a
+// needless fraction, and anything else as JSON
+func scriptString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func scriptEqual(a, b interface{}) bool {
+	if an, ok := a.(float64); ok {
+		bn, ok := scriptNumber(b)
+		return ok && an == bn
+	}
+	if bn, ok := b.(float64); ok {
+		an, ok := scriptNumber(a)
+		return ok && an == bn
+	}
+	switch a.(type) {
+	case []interface{}, map[string]interface{}:
+		return scriptString(a) == scriptString(b)
+	}
+	return a == b
+}
+
+func scriptType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	case string:
+		return "text"
+	case []interface{}:
+		return "a list"
+	}
+	return "an object"
+}
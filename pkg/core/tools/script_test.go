@@ -0,0 +1,377 @@
+package tools
+
+import (
+	"encoding/hex"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// evalTestExpr evaluates src as a post_script expression with the given
+// variables and response
+func evalTestExpr(t *testing.T, src string, vars map[string]string) (interface{}, error) {
+	t.Helper()
+	store := NewVariableStore(t.TempDir())
+	for name, value := range vars {
+		store.Set(name, value)
+	}
+	env := newScriptEnv(stagePostScript, store)
+	env.response = map[string]interface{}{
+		"status":  200.0,
+		"headers": map[string]interface{}{"Content-Type": "application/json"},
+		"body": map[string]interface{}{
+			"items": []interface{}{1.0, 2.0, 3.0},
+			"user":  map[string]interface{}{"name": "Ada"},
+		},
+		"text": `{"items":[1,2,3]}`,
+	}
+	return evalScriptExpr(src, env)
+}
+
+func TestEvalScriptExpr_Precedence(t *testing.T) {
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"10 - 2 - 3", 5.0},
+		{"12 / 2 / 3", 2.0},
+		{"2 * 7 % 4", 2.0},
+		{"-2 * 3", -6.0},
+		{"- -2", 2.0},
+		{"1 + 2 == 3", true},
+		{"1 < 2 == true", true}, // Comparisons share a level and associate left
+		{"1 < 2 && 2 < 3", true},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"!true || true", true},
+		{"!(true || true)", false},
+		{"not false and true", true},
+		{"false or 1 == 1", true},
+		{"'a' + 1 + 2", "a12"},
+		{"1 + 2 + 'a'", "3a"},
+		{"'2' + 3", 5.0},
+		{"'2' + '3'", "23"},
+		{"[1, 2] + [3]", []interface{}{1.0, 2.0, 3.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evalTestExpr(t, tt.expr, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalScriptExpr_Values(t *testing.T) {
+	vars := map[string]string{"count": "5", "name": "ada"}
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"null", nil},
+		{"true", true},
+		{"3.5", 3.5},
+		{`"a\tb\n"`, "a\tb\n"},
+		{`'it\'s'`, "it's"},
+		{"[1, 'a', null]", []interface{}{1.0, "a", nil}},
+		{"{a: 1, 'b c': [true]}", map[string]interface{}{"a": 1.0, "b c": []interface{}{true}}},
+		{"{a: {b: 2}}.a.b", 2.0},
+		{"response.status", 200.0},
+		{"response.body.items[0]", 1.0},
+		{"response.body.items[-1]", 3.0},
+		{"response.body.items[9]", nil},
+		{"response.body.missing.deeper", nil},
+		{"response.body.user['name']", "Ada"},
+		{"response.headers['content-type']", "application/json"},
+		{"'abc'[1]", "b"},
+		{"count", "5"},
+		{"count > 3", true},
+		{"count == 5", true},
+		{"count + 1", 6.0},
+		{"name == 'ada'", true},
+		{"'b' > 'a'", true},
+		{"null || 'fallback'", "fallback"},
+		{"'' || 0", 0.0},
+		{"0 && undefined_name", 0.0},
+		{"1 || undefined_name", 1.0},
+		{"[] == []", true},
+		{"{a: 1} == {a: 1}", true},
+		{"1 != '1'", false},
+		{"7 % 4", 3.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evalTestExpr(t, tt.expr, vars)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalScriptExpr_Errors(t *testing.T) {
+	tests := []struct {
+		expr   string
+		errMsg string
+	}{
+		{`"open`, "unterminated string"},
+		{"1 @ 2", "unexpected character '@'"},
+		{"1 +", "unexpected end of script"},
+		{"(1 + 2", "expected ')'"},
+		{"[1, 2", "expected ']'"},
+		{"{a 1}", "expected ':'"},
+		{"{1: 2}", "expected a key"},
+		{"a.", "expected a field name"},
+		{"1 2", "unexpected '2'"},
+		{"1; 2", "unexpected '2'"},
+		{"nope", "unknown variable 'nope'"},
+		{"nope(1)", "unknown function 'nope'"},
+		{"1 / 0", "division by zero"},
+		{"1 % 0", "division by zero"},
+		{"'a' * 2", "'*' needs numbers"},
+		{"-'a'", "can't negate text"},
+		{"1 < 'a'", "can't compare a number and text"},
+		{"true + null", "can't add a boolean and null"},
+		{"[1][true]", "list index must be a number"},
+		{"len(1, 2)", "len(): takes 1 argument, got 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := evalTestExpr(t, tt.expr, nil)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tt.errMsg)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestRunScript_PreScript(t *testing.T) {
+	store := NewVariableStore(t.TempDir())
+	store.Set("SECRET", "key")
+	env := newScriptEnv(stagePreScript, store)
+	env.request = map[string]interface{}{"method": "POST", "url": "/orders"}
+
+	script := `
+# Sign the request
+sig = hmac_sha256(SECRET, request.method + request.url)
+request.headers["X-Signature"] = sig; request.body.order.qty = 2
+request.body.tags = [
+	"a",
+	"b",
+]
+`
+	if err := runScript(script, env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sig, _ := store.Get("sig")
+	if want := hex.EncodeToString(hmacSHA256([]byte("key"), "POST/orders")); sig != want {
+		t.Errorf("sig = %q, want %q", sig, want)
+	}
+	headers, _ := env.request["headers"].(map[string]interface{})
+	if headers["X-Signature"] != sig {
+		t.Errorf("X-Signature = %v, want %q", headers["X-Signature"], sig)
+	}
+	body, _ := env.request["body"].(map[string]interface{})
+	if !reflect.DeepEqual(body, map[string]interface{}{
+		"order": map[string]interface{}{"qty": 2.0},
+		"tags":  []interface{}{"a", "b"},
+	}) {
+		t.Errorf("body = %#v", body)
+	}
+}
+
+func TestRunScript_PostScript(t *testing.T) {
+	store := NewVariableStore(t.TempDir())
+	env := newScriptEnv(stagePostScript, store)
+	env.response = map[string]interface{}{
+		"status": 201.0,
+		"body":   map[string]interface{}{"id": 42.0, "cursor": "abc"},
+	}
+
+	script := `assert response.status == 201
+assert response.body.id > 100, "id " + response.body.id + " is too small"
+assert len(response.body) == 2
+next_cursor = response.body.cursor
+total = response.body.id * 2`
+	if err := runScript(script, env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.checks != 3 {
+		t.Errorf("checks = %d, want 3", env.checks)
+	}
+	if !reflect.DeepEqual(env.failures, []string{"id 42 is too small"}) {
+		t.Errorf("failures = %q", env.failures)
+	}
+	if v, _ := store.Get("next_cursor"); v != "abc" {
+		t.Errorf("next_cursor = %q, want abc", v)
+	}
+	if v, _ := store.Get("total"); v != "84" {
+		t.Errorf("total = %q, want 84", v)
+	}
+}
+
+func TestRunScript_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		stage  string
+		script string
+		errMsg string
+	}{
+		{"assert in pre_script", stagePreScript, "assert true", "assert only works in post_script"},
+		{"request in post_script", stagePostScript, "request.url = '/x'", "request can only be changed in pre_script"},
+		{"replace request", stagePreScript, "request = {}", "can't replace request"},
+		{"change response", stagePostScript, "response = 1", "response can't be changed"},
+		{"assign constant", stagePreScript, "true = 1", "can't assign to 'true'"},
+		{"assign variable field", stagePreScript, "x.y = 1", "variables hold text"},
+		{"assign call", stagePreScript, "len(x) = 1", "can only assign to a variable or a field of request"},
+		{"field of non-object", stagePreScript, "request.url.path = 1", "'url' is not an object"},
+		{"response in pre_script", stagePreScript, "x = response.status", "response is only available in post_script"},
+		{"statement number", stagePreScript, "a = 1\n\nb = 1 +", "pre_script, statement 2:"},
+		{"too long", stagePreScript, strings.Repeat("x", maxScriptLength+1), "too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newScriptEnv(tt.stage, NewVariableStore(t.TempDir()))
+			env.request = map[string]interface{}{"url": "/"}
+			err := runScript(tt.script, env)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tt.errMsg)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestScriptFuncs(t *testing.T) {
+	tests := []struct {
+		fn   string // Function the case covers
+		expr string
+		want interface{}
+	}{
+		{"len", "len('héllo')", 5.0},
+		{"len", "len([1, 2])", 2.0},
+		{"len", "len({a: 1})", 1.0},
+		{"len", "len(null)", 0.0},
+		{"string", "string(1.5)", "1.5"},
+		{"string", "string([1])", "[1]"},
+		{"number", "number('2.5')", 2.5},
+		{"number", "number(true)", 1.0},
+		{"int", "int('7.9')", 7.0},
+		{"upper", "upper('abc')", "ABC"},
+		{"lower", "lower('ABC')", "abc"},
+		{"trim", "trim('  a  ')", "a"},
+		{"contains", "contains([1, 2], 2)", true},
+		{"contains", "contains({a: 1}, 'a')", true},
+		{"contains", "contains('hello', 'ell')", true},
+		{"contains", "contains('hello', 'xyz')", false},
+		{"starts_with", "starts_with('hello', 'he')", true},
+		{"ends_with", "ends_with('hello', 'lo')", true},
+		{"replace", "replace('a-b-c', '-', '+')", "a+b+c"},
+		{"matches", `matches('2024-01-02', '^\\d{4}-\\d{2}-\\d{2}$')`, true},
+		{"split", "split('a,b', ',')", []interface{}{"a", "b"}},
+		{"join", "join(['a', 1], '-')", "a-1"},
+		{"keys", "keys({b: 1, a: 2})", []interface{}{"a", "b"}},
+		{"json", "json({a: [1, 'x']})", `{"a":[1,"x"]}`},
+		{"parse_json", `parse_json('{"a": [1]}').a[0]`, 1.0},
+		{"md5", "md5('abc')", "900150983cd24fb0d6963f7d28e17f72"},
+		{"sha1", "sha1('abc')", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"sha256", "sha256('abc')", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"sha512", "len(sha512('abc'))", 128.0},
+		{"hmac_sha256", "hmac_sha256('key', 'The quick brown fox jumps over the lazy dog')", "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"hmac_sha256_base64", "hmac_sha256_base64('key', 'The quick brown fox jumps over the lazy dog')", "97yD9DBThCSxMpjmqm+xQ+9NWaFJRhdZl0edvC0aPNg="},
+		{"base64", "base64('hi?')", "aGk/"},
+		{"base64_decode", "base64_decode('aGk/')", "hi?"},
+		{"base64_decode", "base64_decode('aGk_')", "hi?"},
+		{"urlencode", "urlencode('a b&c')", "a+b%26c"},
+		{"random_int", "random_int(3, 3)", 3.0},
+		{"timestamp", "timestamp() > 1600000000", true},
+		{"timestamp_ms", "timestamp_ms() > 1600000000000", true},
+	}
+	covered := map[string]bool{"uuid": true, "now": true} // Checked below
+	for _, tt := range tests {
+		covered[tt.fn] = true
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evalTestExpr(t, tt.expr, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+	for name := range scriptFuncs {
+		if !covered[name] {
+			t.Errorf("no test for script function %s", name)
+		}
+	}
+
+	t.Run("uuid", func(t *testing.T) {
+		got, err := evalTestExpr(t, "uuid()", nil)
+		if err != nil || !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(scriptString(got)) {
+			t.Errorf("uuid() = %v, %v", got, err)
+		}
+	})
+	t.Run("now", func(t *testing.T) {
+		got, err := evalTestExpr(t, "now()", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := time.Parse(time.RFC3339, scriptString(got)); err != nil {
+			t.Errorf("now() = %v, not RFC 3339", got)
+		}
+	})
+	t.Run("random_int range", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			got, err := evalTestExpr(t, "random_int(1, 3)", nil)
+			if n, _ := got.(float64); err != nil || n < 1 || n > 3 {
+				t.Fatalf("random_int(1, 3) = %v, %v", got, err)
+			}
+		}
+	})
+}
+
+func TestScriptFuncs_Errors(t *testing.T) {
+	tests := []struct {
+		expr   string
+		errMsg string
+	}{
+		{"len(1)", "can't take the length of a number"},
+		{"number('abc')", "abc is not a number"},
+		{"upper('a', 'b')", "takes 1 argument(s), got 2"},
+		{"matches('a', '(')", "invalid pattern"},
+		{"join('a', ',')", "first argument must be a list"},
+		{"keys([1])", "argument must be an object"},
+		{"parse_json('{')", "invalid JSON"},
+		{"base64_decode('%%%')", "invalid base64"},
+		{"random_int(5, 1)", "needs a min and a max"},
+		{"random_int(-9000000000000000000, 9000000000000000000)", "too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := evalTestExpr(t, tt.expr, nil)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tt.errMsg)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
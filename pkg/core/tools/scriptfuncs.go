@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math"
+	"math/rand/v2"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scriptFunc is a function scripts can call
+type scriptFunc func(args []interface{}) (interface{}, error)
+
+// scriptFuncs are the functions available to pre_script and post_script
+var scriptFuncs = map[string]scriptFunc{
+	"len":         scriptLen,
+	"string":      textFunc(1, func(s []string) (interface{}, error) { return s[0], nil }),
+	"number":      scriptToNumber,
+	"int":         scriptToInt,
+	"upper":       textFunc(1, func(s []string) (interface{}, error) { return strings.ToUpper(s[0]), nil }),
+	"lower":       textFunc(1, func(s []string) (interface{}, error) { return strings.ToLower(s[0]), nil }),
+	"trim":        textFunc(1, func(s []string) (interface{}, error) { return strings.TrimSpace(s[0]), nil }),
+	"contains":    scriptContains,
+	"starts_with": textFunc(2, func(s []string) (interface{}, error) { return strings.HasPrefix(s[0], s[1]), nil }),
+	"ends_with":   textFunc(2, func(s []string) (interface{}, error) { return strings.HasSuffix(s[0], s[1]), nil }),
+	"replace":     textFunc(3, func(s []string) (interface{}, error) { return strings.ReplaceAll(s[0], s[1], s[2]), nil }),
+	"matches": textFunc(2, func(s []string) (interface{}, error) {
+		re, err := regexp.Compile(s[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		return re.MatchString(s[0]), nil
+	}),
+	"split": textFunc(2, func(s []string) (interface{}, error) {
+		var parts []interface{}
+		for _, part := range strings.Split(s[0], s[1]) {
+			parts = append(parts, part)
+		}
+		return parts, nil
+	}),
+	"join":       scriptJoin,
+	"keys":       scriptKeys,
+	"json":       scriptJSON,
+	"parse_json": scriptParseJSON,
+
+	"md5":    hashScriptFunc(md5.New),
+	"sha1":   hashScriptFunc(sha1.New),
+	"sha256": hashScriptFunc(sha256.New),
+	"sha512": hashScriptFunc(sha512.New),
+	"hmac_sha256": textFunc(2, func(s []string) (interface{}, error) {
+		return hex.EncodeToString(hmacSHA256([]byte(s[0]), s[1])), nil
+	}),
+	"hmac_sha256_base64": textFunc(2, func(s []string) (interface{}, error) {
+		return base64.StdEncoding.EncodeToString(hmacSHA256([]byte(s[0]), s[1])), nil
+	}),
+	"base64": textFunc(1, func(s []string) (interface{}, error) {
+		return base64.StdEncoding.EncodeToString([]byte(s[0])), nil
+	}),
+	"base64_decode": textFunc(1, func(s []string) (interface{}, error) {
+		data, err := base64.StdEncoding.DecodeString(s[0])
+		if err != nil {
+			if data, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(s[0], "=")); err != nil {
+				return nil, fmt.Errorf("invalid base64")
+			}
+		}
+		return string(data), nil
+	}),
+	"urlencode": textFunc(1, func(s []string) (interface{}, error) { return url.QueryEscape(s[0]), nil }),
+
+	"uuid": func(args []interface{}) (interface{}, error) { return newUUID(), nil },
+	"now": func(args []interface{}) (interface{}, error) {
+		layout := time.RFC3339
+		if len(args) > 0 {
+			layout = timeLayout(scriptString(args[0]))
+		}
+		return time.Now().UTC().Format(layout), nil
+	},
+	"timestamp":    func(args []interface{}) (interface{}, error) { return float64(time.Now().Unix()), nil },
+	"timestamp_ms": func(args []interface{}) (interface{}, error) { return float64(time.Now().UnixMilli()), nil },
+	"random_int": func(args []interface{}) (interface{}, error) {
+		low, high := 0.0, 1000.0
+		if len(args) == 2 {
+			var lok, hok bool
+			low, lok = scriptNumber(args[0])
+			high, hok = scriptNumber(args[1])
+			if !lok || !hok || high < low {
+				return nil, fmt.Errorf("needs a min and a max")
+			}
+			if high-low >= math.MaxInt {
+				return nil, fmt.Errorf("range from %v to %v is too large", low, high)
+			}
+		}
+		return low + float64(rand.IntN(int(high-low)+1)), nil
+	},
+}
+
+// textFunc wraps a function of n text arguments
+func textFunc(n int, fn func([]string) (interface{}, error)) scriptFunc {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) != n {
+			return nil, fmt.Errorf("takes %d argument(s), got %d", n, len(args))
+		}
+		texts := make([]string, n)
+		for i, arg := range args {
+			texts[i] = scriptString(arg)
+		}
+		return fn(texts)
+	}
+}
+
+// hashScriptFunc hashes its argument to hex
+func hashScriptFunc(newHash func() hash.Hash) scriptFunc {
+	return textFunc(1, func(s []string) (interface{}, error) {
+		h := newHash()
+		h.Write([]byte(s[0]))
+		return hex.EncodeToString(h.Sum(nil)), nil
+	})
+}
+
+func scriptLen(args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("takes 1 argument, got %d", len(args))
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return 0.0, nil
+	case string:
+		return float64(len([]rune(v))), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	}
+	return nil, fmt.Errorf("can't take the length of %s", scriptType(args[0]))
+}
+
+func scriptToNumber(args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("takes 1 argument, got %d", len(args))
+	}
+	if b, ok := args[0].(bool); ok {
+		if b {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	}
+	n, ok := scriptNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("%s is not a number", scriptString(args[0]))
+	}
+	return n, nil
+}
+
+func scriptToInt(args []interface{}) (interface{}, error) {
+	n, err := scriptToNumber(args)
+	if err != nil {
+		return nil, err
+	}
+	return math.Trunc(n.(float64)), nil
+}
+
+// scriptContains checks a list for an element, an object for a key, or text
+// for a substring
+func scriptContains(args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("takes 2 arguments, got %d", len(args))
+	}
+	switch v := args[0].(type) {
+	case []interface{}:
+		for _, item := range v {
+			if scriptEqual(item, args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		_, ok := v[scriptString(args[1])]
+		return ok, nil
+	}
+	return strings.Contains(scriptString(args[0]), scriptString(args[1])), nil
+}
+
+func scriptJoin(args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("takes 2 arguments, got %d", len(args))
+	}
+	list, ok := args[0].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("first argument must be a list, got %s", scriptType(args[0]))
+	}
+	parts := make([]string, len(list))
+	for i, item := range list {
+		parts[i] = scriptString(item)
+	}
+	return strings.Join(parts, scriptString(args[1])), nil
+}
+
+func scriptKeys(args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("takes 1 argument, got %d", len(args))
+	}
+	obj, ok := args[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("argument must be an object, got %s", scriptType(args[0]))
+	}
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	keys := make([]interface{}, len(names))
+	for i, name := range names {
+		keys[i] = name
+	}
+	return keys, nil
+}
+
+func scriptJSON(args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("takes 1 argument, got %d", len(args))
+	}
+	data, err := json.Marshal(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func scriptParseJSON(args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("takes 1 argument, got %d", len(args))
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(scriptString(args[0])), &v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return v, nil
+}
@@ -1,16 +1,35 @@
 package tools
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"unicode"
 )
 
+const (
+	// defaultSearchResults and maxSearchResults bound the matches returned
+	defaultSearchResults = 50
+	maxSearchResults     = 500
+	// defaultSearchPerFile caps the matches shown per file
+	defaultSearchPerFile = 10
+	// maxSearchContext caps the context lines around each match
+	maxSearchContext = 10
+	// maxSearchLineLength cuts long lines (minified code, data)
+	maxSearchLineLength = 200
+	// maxSearchFileSize skips large files
+	maxSearchFileSize = 1024 * 1024
+)
+
+// searchSkipDirs are never searched, ignored or not
+var searchSkipDirs = []string{"node_modules", "vendor"}
+
 // SearchCodeTool searches for patterns in the codebase
 type SearchCodeTool struct {
 	workDir string
@@ -24,6 +43,40 @@ func NewSearchCodeTool(workDir string) *SearchCodeTool {
 	return &SearchCodeTool{workDir: workDir}
 }
 
+// globList is a list of globs, given as an array or a comma-separated string
+type globList []string
+
+// UnmarshalJSON accepts ["*.go", "*.py"] as well as "*.go,*.py"
+func (l *globList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*l = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("expected a glob or a list of globs")
+	}
+	*l = strings.Split(s, ",")
+	return nil
+}
+
+// SearchCodeParams defines the search parameters
+type SearchCodeParams struct {
+	Pattern       string   `json:"pattern"`
+	Path          string   `json:"path,omitempty"`           // Directory or file to search
+	FilePattern   string   `json:"file_pattern,omitempty"`   // Glob like *.go (added to include)
+	Include       globList `json:"include,omitempty"`        // Only files matching these globs
+	Exclude       globList `json:"exclude,omitempty"`        // Skip files and directories matching these globs
+	Literal       bool     `json:"literal,omitempty"`        // Pattern is plain text, not a regex
+	CaseSensitive *bool    `json:"case_sensitive,omitempty"` // Unset: case-insensitive unless the pattern has capitals
+	Context       int      `json:"context,omitempty"`        // Lines shown before and after each match (max 10)
+	MaxResults    int      `json:"max_results,omitempty"`    // Matches returned (default 50, max 500)
+	MaxPerFile    int      `json:"max_per_file,omitempty"`   // Matches shown per file (default 10)
+	FilesOnly     bool     `json:"files_only,omitempty"`     // Only list the matching files with their match counts
+	NoIgnore      bool     `json:"no_ignore,omitempty"`      // Also search files .gitignore excludes
+}
+
 // Name returns the tool name
 func (t *SearchCodeTool) Name() string {
 	return "search_code"
@@ -31,225 +84,233 @@ func (t *SearchCodeTool) Name() string {
 
 // Description returns the tool description
 func (t *SearchCodeTool) Description() string {
-	return "Search for text/regex patterns in codebase. Returns matching files and lines."
+	return "Search for regex or text patterns in the codebase, skipping .gitignore'd files. Returns file:line matches, with surrounding lines when context is set so the code can often be understood without read_file."
 }
 
 // Parameters returns the tool parameter description
 func (t *SearchCodeTool) Parameters() string {
-	return `{"pattern": "string (required) - search pattern", "path": "string - directory to search", "file_pattern": "string - file glob like *.go"}`
+	return `{"pattern": "string (required) - regex (or text with literal)", "path": "string - directory or file to search", "include": "array - only files matching these globs, e.g. [\"*.go\", \"src/**/*.ts\"]", "exclude": "array - skip matching files/directories, e.g. [\"*_test.go\", \"migrations/\"]", "context": "number - lines before and after each match (max 10)", "case_sensitive": "boolean - default: case-insensitive unless the pattern has capitals", "literal": "boolean - pattern is plain text", "max_results": "number - default 50, max 500", "max_per_file": "number - default 10", "files_only": "boolean - only list matching files", "no_ignore": "boolean - include .gitignore'd files", "file_pattern": "string - file glob like *.go"}`
 }
 
 // Execute searches for patterns in the codebase
 func (t *SearchCodeTool) Execute(args string) (string, error) {
-	var params struct {
-		Pattern     string `json:"pattern"`
-		Path        string `json:"path"`
-		FilePattern string `json:"file_pattern"`
-	}
-
+	var params SearchCodeParams
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
 		return "", fmt.Errorf("failed to parse arguments: %w", err)
 	}
-
 	if params.Pattern == "" {
 		return "", fmt.Errorf("pattern is required")
 	}
 
-	// Resolve search path
-	searchPath := params.Path
-	if searchPath == "" {
-		searchPath = t.workDir
-	} else if !filepath.IsAbs(searchPath) {
-		searchPath = filepath.Join(t.workDir, searchPath)
+	searchPath := t.workDir
+	if params.Path != "" {
+		var err error
+		if searchPath, err = ValidatePathWithinWorkDir(params.Path, t.workDir); err != nil {
+			return "", err
+		}
 	}
 
-	// Security check
-	absPath, err := filepath.Abs(searchPath)
+	re, err := searchRegexp(params)
 	if err != nil {
-		return "", fmt.Errorf("invalid path: %w", err)
+		return "", err
 	}
-
-	absWorkDir, _ := filepath.Abs(t.workDir)
-	if !strings.HasPrefix(absPath, absWorkDir) {
-		return "", fmt.Errorf("access denied: path outside project directory")
+	if params.MaxResults <= 0 {
+		params.MaxResults = defaultSearchResults
 	}
-
-	// Try ripgrep first (faster), fall back to native Go search
-	result, err := t.searchWithRipgrep(params.Pattern, absPath, params.FilePattern)
-	if err != nil {
-		// Fallback to native search
-		result, err = t.searchNative(params.Pattern, absPath, params.FilePattern)
-		if err != nil {
-			return "", err
-		}
+	params.MaxResults = min(params.MaxResults, maxSearchResults)
+	if params.MaxPerFile <= 0 {
+		params.MaxPerFile = defaultSearchPerFile
 	}
-
-	if result == "" {
-		return "No matches found", nil
+	params.Context = min(max(params.Context, 0), maxSearchContext)
+	if params.FilePattern != "" {
+		params.Include = append(params.Include, params.FilePattern)
 	}
 
-	return result, nil
+	return t.search(re, searchPath, params)
 }
 
-// searchWithRipgrep uses ripgrep for fast searching
-func (t *SearchCodeTool) searchWithRipgrep(pattern, searchPath, filePattern string) (string, error) {
-	args := []string{
-		"-n",         // Line numbers
-		"--no-heading", // No file headers
-		"-M", "200",  // Max line length
-		"--max-count", "10", // Max matches per file
+// searchRegexp compiles the pattern: literal when asked or when it isn't
+// a valid regex, and case-insensitive unless asked or it has capitals
+func searchRegexp(params SearchCodeParams) (*regexp.Regexp, error) {
+	expr := params.Pattern
+	if params.Literal {
+		expr = regexp.QuoteMeta(expr)
+	} else if _, err := regexp.Compile(expr); err != nil {
+		expr = regexp.QuoteMeta(expr)
 	}
-
-	// Add file pattern filter
-	if filePattern != "" {
-		args = append(args, "-g", filePattern)
+	ignoreCase := !strings.ContainsFunc(params.Pattern, unicode.IsUpper)
+	if params.CaseSensitive != nil {
+		ignoreCase = !*params.CaseSensitive
 	}
-
-	// Exclude common directories
-	args = append(args, "--glob", "!.git", "--glob", "!node_modules", "--glob", "!vendor")
-
-	args = append(args, pattern, searchPath)
-
-	cmd := exec.Command("rg", args...)
-	output, err := cmd.Output()
-
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
 	if err != nil {
-		// Exit code 1 means no matches (not an error)
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			return "", nil
-		}
-		return "", err
+		return nil, fmt.Errorf("invalid pattern: %w", err)
 	}
+	return re, nil
+}
 
-	return t.formatSearchResults(string(output), searchPath)
+// fileMatches are the matching lines of one file
+type fileMatches struct {
+	rel     string
+	lines   []string
+	matches []int // 0-based line indexes, at most MaxPerFile
+	total   int   // All matches in the file
 }
 
-// searchNative provides a pure Go search fallback
-func (t *SearchCodeTool) searchNative(pattern, searchPath, filePattern string) (string, error) {
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		// Fall back to literal search
-		re = regexp.MustCompile(regexp.QuoteMeta(pattern))
+// search walks searchPath and formats the matches
+func (t *SearchCodeTool) search(re *regexp.Regexp, searchPath string, params SearchCodeParams) (string, error) {
+	include := newGlobMatcher(params.Include)
+	exclude := newGlobMatcher(params.Exclude)
+	var ignore *gitignore
+	if !params.NoIgnore {
+		ignore = newGitignore(t.workDir)
+		if rel, err := filepath.Rel(t.workDir, searchPath); err == nil && rel != "." {
+			ignore.loadAncestors(filepath.Dir(rel))
+		}
 	}
 
-	var results []string
-	maxMatches := 50
-	matchCount := 0
-
-	err = filepath.Walk(searchPath, func(path string, info os.FileInfo, err error) error {
+	var found []fileMatches
+	shown, total, truncated := 0, 0, false
+	err := filepath.WalkDir(searchPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return nil // Skip errors
+			return nil
 		}
-
-		// Skip hidden and common directories
-		if info.IsDir() {
-			name := info.Name()
-			if strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" {
+		rel, _ := filepath.Rel(t.workDir, path)
+		if d.IsDir() {
+			if path == searchPath {
+				if ignore != nil {
+					ignore.loadDir(rel)
+				}
+				return nil
+			}
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || slices.Contains(searchSkipDirs, name) ||
+				exclude.match(rel) || (ignore != nil && ignore.ignored(rel, true)) {
 				return filepath.SkipDir
 			}
+			if ignore != nil {
+				ignore.loadDir(rel)
+			}
 			return nil
 		}
-
-		// Check file pattern
-		if filePattern != "" {
-			matched, _ := filepath.Match(filePattern, info.Name())
-			if !matched {
+		// A file named as path is searched whatever the filters say
+		if path != searchPath {
+			if (!include.empty() && !include.match(rel)) || exclude.match(rel) ||
+				(ignore != nil && ignore.ignored(rel, false)) {
 				return nil
 			}
 		}
-
-		// Skip binary files (basic check)
-		ext := strings.ToLower(filepath.Ext(info.Name()))
-		binaryExts := map[string]bool{
-			".exe": true, ".dll": true, ".so": true, ".dylib": true,
-			".png": true, ".jpg": true, ".jpeg": true, ".gif": true,
-			".pdf": true, ".zip": true, ".tar": true, ".gz": true,
-		}
-		if binaryExts[ext] {
-			return nil
+		if truncated {
+			return filepath.SkipAll
 		}
 
-		// Skip large files (> 1MB)
-		if info.Size() > 1024*1024 {
+		m, ok := searchFile(path, re, params.MaxPerFile)
+		if !ok || m.total == 0 {
 			return nil
 		}
-
-		// Search file
-		file, err := os.Open(path)
-		if err != nil {
-			return nil
+		if shown >= params.MaxResults {
+			truncated = true
+			return filepath.SkipAll
 		}
-		defer file.Close()
-
-		relPath, _ := filepath.Rel(t.workDir, path)
-		scanner := bufio.NewScanner(file)
-		lineNum := 0
-		fileMatches := 0
-
-		for scanner.Scan() {
-			lineNum++
-			line := scanner.Text()
-
-			if re.MatchString(line) {
-				fileMatches++
-				if fileMatches <= 3 { // Max 3 matches per file
-					// Truncate long lines
-					if len(line) > 150 {
-						line = line[:150] + "..."
-					}
-					results = append(results, fmt.Sprintf("%s:%d: %s", relPath, lineNum, line))
-					matchCount++
-
-					if matchCount >= maxMatches {
-						results = append(results, fmt.Sprintf("... (stopped at %d matches)", maxMatches))
-						return filepath.SkipAll
-					}
-				}
-			}
+		m.rel = filepath.ToSlash(rel)
+		total += m.total
+		if remaining := params.MaxResults - shown; len(m.matches) > remaining {
+			m.matches = m.matches[:remaining]
+			truncated = true
 		}
-
+		shown += len(m.matches)
+		found = append(found, m)
 		return nil
 	})
-
 	if err != nil && err != filepath.SkipAll {
-		return "", err
+		return "", fmt.Errorf("failed to search: %w", err)
 	}
 
-	return strings.Join(results, "\n"), nil
+	if len(found) == 0 {
+		return "No matches found", nil
+	}
+	return formatSearchMatches(found, shown, total, truncated, params), nil
+}
+
+// searchFile finds re in a text file. It returns false for files that
+// can't be read, are too large or look binary.
+func searchFile(path string, re *regexp.Regexp, maxPerFile int) (fileMatches, bool) {
+	var m fileMatches
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxSearchFileSize {
+		return m, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return m, false
+	}
+	m.lines = splitLogLines(string(data))
+	for i, line := range m.lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		m.total++
+		if len(m.matches) < maxPerFile {
+			m.matches = append(m.matches, i)
+		}
+	}
+	return m, true
 }
 
-// formatSearchResults formats ripgrep output
-func (t *SearchCodeTool) formatSearchResults(output, searchPath string) (string, error) {
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	if len(lines) == 0 || (len(lines) == 1 && lines[0] == "") {
-		return "", nil
+// formatSearchMatches renders the matches like ripgrep: path:line: text for
+// matches, path-line- text for context lines and -- between separate groups
+func formatSearchMatches(found []fileMatches, shown, total int, truncated bool, params SearchCodeParams) string {
+	var sb strings.Builder
+	if truncated {
+		sb.WriteString(fmt.Sprintf("First %d match(es), in %d file(s)\n\n", shown, len(found)))
+	} else if shown < total {
+		sb.WriteString(fmt.Sprintf("%d match(es) in %d file(s), %d shown\n\n", total, len(found), shown))
+	} else {
+		sb.WriteString(fmt.Sprintf("%d match(es) in %d file(s)\n\n", total, len(found)))
 	}
 
-	var results []string
-	for i, line := range lines {
-		if i >= 50 { // Limit results
-			results = append(results, "... (more results truncated)")
+	if params.FilesOnly {
+		for _, m := range found {
+			sb.WriteString(fmt.Sprintf("%s (%d)\n", m.rel, m.total))
+		}
+	}
+	for i, m := range found {
+		if params.FilesOnly {
 			break
 		}
-
-		// Make paths relative
-		if strings.HasPrefix(line, searchPath) {
-			rel, err := filepath.Rel(t.workDir, strings.SplitN(line, ":", 2)[0])
-			if err == nil {
-				parts := strings.SplitN(line, ":", 3)
-				if len(parts) >= 3 {
-					line = fmt.Sprintf("%s:%s: %s", rel, parts[1], parts[2])
+		if params.Context > 0 && i > 0 {
+			sb.WriteString("--\n")
+		}
+		last := -1 // Last line written
+		for _, idx := range m.matches {
+			start := max(idx-params.Context, last+1)
+			if params.Context > 0 && last >= 0 && start > last+1 {
+				sb.WriteString("--\n")
+			}
+			end := min(idx+params.Context, len(m.lines)-1)
+			for j := start; j <= end; j++ {
+				sep := "-"
+				if slices.Contains(m.matches, j) {
+					sep = ":"
 				}
+				line := m.lines[j]
+				if len(line) > maxSearchLineLength {
+					line = line[:maxSearchLineLength] + "..."
+				}
+				sb.WriteString(fmt.Sprintf("%s%s%d%s %s\n", m.rel, sep, j+1, sep, line))
 			}
+			last = max(last, end)
 		}
-
-		// Truncate long lines
-		if len(line) > 200 {
-			line = line[:200] + "..."
+		if hidden := m.total - len(m.matches); hidden > 0 && !(truncated && i == len(found)-1) {
+			sb.WriteString(fmt.Sprintf("(%d more match(es) in %s; raise max_per_file to see them)\n", hidden, m.rel))
 		}
-
-		results = append(results, line)
 	}
 
-	return strings.Join(results, "\n"), nil
+	if truncated {
+		sb.WriteString(fmt.Sprintf("\n... stopped at %d match(es); narrow with path, include or a more specific pattern, or raise max_results\n", shown))
+	}
+	return sb.String()
 }
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core"
+)
+
+// encryptedValuePrefix marks an encrypted value in variables.json
+const encryptedValuePrefix = "enc:v1:"
+
+// secretVariablesKeyFile holds the encryption key when there is no OS keyring
+const secretVariablesKeyFile = "variables.key"
+
+// maskedSecretValue is shown instead of a secret variable's value
+const maskedSecretValue = "********"
+
+// secretKey returns the AES-256 key encrypting secret global variables,
+// creating it if asked to. It lives in the OS keyring, or in
+// .zap/variables.key without one (see core.LoadOrCreateKey). Callers hold vs.mu.
+func (vs *VariableStore) secretKey(create bool) ([]byte, error) {
+	if vs.key != nil {
+		return vs.key, nil
+	}
+	dir, err := filepath.Abs(vs.zapDir)
+	if err != nil {
+		dir = vs.zapDir
+	}
+	account := fmt.Sprintf("variables.key (%s)", dir)
+	keyFile := filepath.Join(vs.zapDir, secretVariablesKeyFile)
+
+	var key []byte
+	if create {
+		key, err = core.LoadOrCreateKey(account, keyFile)
+	} else {
+		key, err = core.LoadKey(account, keyFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the secret variables key: %w", err)
+	}
+	vs.key = key
+	return key, nil
+}
+
+// sealValue encrypts value with AES-GCM
+func sealValue(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedValuePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openValue decrypts a value encrypted by sealValue
+func openValue(key []byte, sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, encryptedValuePrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted value: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("encrypted value is truncated")
+	}
+	value, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value (wrong key?)")
+	}
+	return string(value), nil
+}
+
+// newGCM creates the AES-GCM cipher for key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
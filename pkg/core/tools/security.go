@@ -0,0 +1,521 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// SecurityScanTool runs basic, non-destructive security checks against an
+// endpoint. It is a first pass, not a penetration test.
+type SecurityScanTool struct {
+	httpTool *HTTPTool
+	varStore *VariableStore
+}
+
+// NewSecurityScanTool creates a new security scan tool
+func NewSecurityScanTool(httpTool *HTTPTool, varStore *VariableStore) *SecurityScanTool {
+	return &SecurityScanTool{
+		httpTool: httpTool,
+		varStore: varStore,
+	}
+}
+
+// SecurityScanParams defines security scan parameters
+type SecurityScanParams struct {
+	Request *HTTPRequest `json:"request,omitempty"` // Request to scan (default: the last http_request)
+	Checks  []string     `json:"checks,omitempty"`  // headers, errors, cors, auth, injection (default: all)
+	Timeout int          `json:"timeout,omitempty"` // Seconds per request (default 10)
+}
+
+// Security checks, in the order they run
+const (
+	scanHeaders   = "headers"
+	scanErrors    = "errors"
+	scanCORS      = "cors"
+	scanAuth      = "auth"
+	scanInjection = "injection"
+)
+
+var securityChecks = []string{scanHeaders, scanErrors, scanCORS, scanAuth, scanInjection}
+
+// Finding severities, most severe first
+const (
+	severityHigh   = "high"
+	severityMedium = "medium"
+	severityLow    = "low"
+)
+
+var severities = []string{severityHigh, severityMedium, severityLow}
+
+// SecurityFinding is one issue found by a scan
+type SecurityFinding struct {
+	Severity string
+	Check    string
+	Title    string
+	Detail   string
+}
+
+// scanOrigin is the foreign origin sent to test CORS
+const scanOrigin = "https://zap-scan.example"
+
+// leakPattern recognizes an internal detail in an error response
+type leakPattern struct {
+	what     string
+	severity string
+	re       *regexp.Regexp
+}
+
+// sqlErrorPattern matches database error messages
+var sqlErrorPattern = regexp.MustCompile(`SQLSTATE\[|syntax error at or near|You have an error in your SQL syntax|ORA-\d{5}|sqlite3?\.OperationalError|Unclosed quotation mark|PG::\w+Error`)
+
+var leakPatterns = []leakPattern{
+	{"stack trace", severityMedium, regexp.MustCompile(`Traceback \(most recent call last\)|goroutine \d+ \[running\]|\bat [\w$.]+\([\w$]+\.java:\d+\)|\bat .+ \(.+\.[jt]s:\d+:\d+\)|File ".+\.py", line \d+|\.php on line \d+|System\.[\w.]+Exception:`)},
+	{"SQL error", severityMedium, sqlErrorPattern},
+	{"debug page", severityMedium, regexp.MustCompile(`Werkzeug Debugger|Whitelabel Error Page|DEBUG = True|Action Controller: Exception caught|Django Version:`)},
+	{"server file path", severityLow, regexp.MustCompile(`(/home/\w+/|/var/www/|/usr/src/app/|/opt/app/|[A-Z]:\\\\?(Users|inetpub)\\)`)},
+}
+
+// Name returns the tool name
+func (t *SecurityScanTool) Name() string {
+	return "security_scan"
+}
+
+// Description returns the tool description
+func (t *SecurityScanTool) Description() string {
+	return "Run basic security checks on an endpoint: missing security headers and insecure cookies, verbose errors (stack traces, SQL errors), permissive CORS, access without credentials, and reflected or evaluated injection strings. Produces a findings report by severity."
+}
+
+// Parameters returns the tool parameter description
+func (t *SecurityScanTool) Parameters() string {
+	return `{
+  "request": {"method": "GET", "url": "http://localhost:8000/api/users/1", "headers": {"Authorization": "Bearer {{TOKEN}}"}},
+  "checks": ["headers", "errors", "cors", "auth", "injection"],
+  "timeout": 10
+}`
+}
+
+// Execute scans the endpoint
+func (t *SecurityScanTool) Execute(args string) (string, error) {
+	var params SecurityScanParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	base := params.Request
+	if base == nil {
+		if base = t.httpTool.LastRequest(); base == nil {
+			return "", fmt.Errorf("no request to scan: pass 'request' or make an http_request first")
+		}
+	}
+	req, err := resolveRequest(t.varStore, *base)
+	if err != nil {
+		return "", err
+	}
+	if req.Method == "" || req.URL == "" {
+		return "", fmt.Errorf("request method and url are required")
+	}
+	checks := params.Checks
+	if len(checks) == 0 {
+		checks = securityChecks
+	}
+	for _, check := range checks {
+		if !slices.Contains(securityChecks, check) {
+			return "", fmt.Errorf("unknown check '%s' (use %s)", check, strings.Join(securityChecks, ", "))
+		}
+	}
+	req.Timeout = params.Timeout
+	if req.Timeout <= 0 {
+		req.Timeout = 10
+	}
+	req.Cache, req.SaveBodyTo = "", ""
+
+	resp, err := t.httpTool.Run(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+
+	s := &securityScan{httpTool: t.httpTool, req: req, baseline: resp}
+	for _, check := range securityChecks {
+		if !slices.Contains(checks, check) {
+			continue
+		}
+		switch check {
+		case scanHeaders:
+			s.checkHeaders()
+		case scanErrors:
+			s.checkErrors()
+		case scanCORS:
+			s.checkCORS()
+		case scanAuth:
+			s.checkAuth()
+		case scanInjection:
+			s.checkInjection()
+		}
+	}
+	return s.format(checks), nil
+}
+
+// securityScan holds the state of one scan
+type securityScan struct {
+	httpTool *HTTPTool
+	req      HTTPRequest
+	baseline *HTTPResponse
+	requests int
+	findings []SecurityFinding
+	notes    []string // Checks skipped or probes that failed
+}
+
+func (s *securityScan) add(severity, check, title, detail string) {
+	for _, f := range s.findings {
+		if f.Check == check && f.Title == title {
+			return
+		}
+	}
+	s.findings = append(s.findings, SecurityFinding{Severity: severity, Check: check, Title: title, Detail: detail})
+}
+
+// probe sends a variant of the scanned request
+func (s *securityScan) probe(req HTTPRequest) *HTTPResponse {
+	s.requests++
+	resp, err := s.httpTool.Run(req)
+	if err != nil {
+		s.notes = append(s.notes, fmt.Sprintf("probe %s %s failed: %v", strings.ToUpper(req.Method), req.URL, err))
+		return nil
+	}
+	return resp
+}
+
+// checkHeaders looks for missing security headers, version disclosure and
+// cookies without protective attributes
+func (s *securityScan) checkHeaders() {
+	resp := s.baseline
+	header := func(name string) string {
+		value, _ := responseHeader(resp, name)
+		return value
+	}
+	https := strings.HasPrefix(strings.ToLower(s.req.URL), "https://")
+
+	if https && header("Strict-Transport-Security") == "" {
+		s.add(severityMedium, scanHeaders, "Missing Strict-Transport-Security", "HTTPS responses should set HSTS so browsers never fall back to plain HTTP")
+	}
+	if !https && !isLocalURL(s.req.URL) {
+		s.add(severityMedium, scanHeaders, "Served over plain HTTP", "Traffic, credentials included, can be read and altered in transit")
+	}
+	if !strings.EqualFold(strings.TrimSpace(header("X-Content-Type-Options")), "nosniff") {
+		s.add(severityLow, scanHeaders, "Missing X-Content-Type-Options: nosniff", "Browsers may sniff the response into an executable content type")
+	}
+	if strings.Contains(strings.ToLower(header("Content-Type")), "text/html") {
+		csp := header("Content-Security-Policy")
+		if csp == "" {
+			s.add(severityMedium, scanHeaders, "Missing Content-Security-Policy", "HTML responses without a CSP make injected scripts easier to exploit")
+		}
+		if header("X-Frame-Options") == "" && !strings.Contains(csp, "frame-ancestors") {
+			s.add(severityMedium, scanHeaders, "Page can be framed (clickjacking)", "Set X-Frame-Options: DENY or a CSP frame-ancestors directive")
+		}
+	}
+	for _, name := range []string{"Server", "X-Powered-By", "X-AspNet-Version", "X-AspNetMvc-Version"} {
+		if value := header(name); value != "" && (name != "Server" || strings.ContainsAny(value, "0123456789")) {
+			s.add(severityLow, scanHeaders, "Version disclosure in "+name, fmt.Sprintf("%s: %s tells attackers which exploits to try", name, value))
+		}
+	}
+	if hasCredentials(s.req) && resp.StatusCode < 300 {
+		cacheControl := strings.ToLower(header("Cache-Control"))
+		if !strings.Contains(cacheControl, "no-store") && !strings.Contains(cacheControl, "private") {
+			s.add(severityLow, scanHeaders, "Authenticated response may be cached", "Set Cache-Control: no-store (or private) on responses with user data")
+		}
+	}
+
+	for _, cookie := range responseHeaderValues(resp, "Set-Cookie") {
+		name, _, _ := strings.Cut(cookie, "=")
+		attrs := strings.ToLower(cookie)
+		var missing []string
+		if !strings.Contains(attrs, "httponly") {
+			missing = append(missing, "HttpOnly")
+		}
+		if https && !strings.Contains(attrs, "secure") {
+			missing = append(missing, "Secure")
+		}
+		if !strings.Contains(attrs, "samesite") {
+			missing = append(missing, "SameSite")
+		}
+		if len(missing) > 0 {
+			s.add(severityLow, scanHeaders, fmt.Sprintf("Cookie '%s' lacks %s", strings.TrimSpace(name), strings.Join(missing, ", ")), "Without them the cookie is readable from scripts, sent over HTTP or sent cross-site")
+		}
+	}
+}
+
+// checkErrors provokes errors and looks for internals in the responses
+func (s *securityScan) checkErrors() {
+	type errorProbe struct {
+		what string
+		req  HTTPRequest
+	}
+	var probes []errorProbe
+
+	notFound := s.req
+	if u, err := url.Parse(s.req.URL); err == nil {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/zap-scan-does-not-exist"
+		notFound.URL = u.String()
+		probes = append(probes, errorProbe{"unknown path", notFound})
+	}
+	if s.req.Body != nil {
+		wrongShape := s.req
+		wrongShape.Body = []interface{}{"zap-scan", nil}
+		probes = append(probes, errorProbe{"body of the wrong shape", wrongShape})
+	}
+	quoted := s.req
+	quoted.Query = map[string]interface{}{"zap_scan": "'\"zap-scan"}
+	for key, value := range s.req.Query {
+		quoted.Query[key] = value
+	}
+	probes = append(probes, errorProbe{"unexpected query parameter", quoted})
+
+	s.findLeaks("the original request", s.baseline)
+	for _, p := range probes {
+		if resp := s.probe(p.req); resp != nil {
+			s.findLeaks(p.what, resp)
+		}
+	}
+}
+
+// findLeaks reports internal details in a response body
+func (s *securityScan) findLeaks(what string, resp *HTTPResponse) {
+	for _, p := range leakPatterns {
+		if match := p.re.FindString(resp.Body); match != "" {
+			s.add(p.severity, scanErrors, "Response leaks a "+p.what, fmt.Sprintf("%s got %d containing %q", what, resp.StatusCode, truncateSnapshotLine(match)))
+		}
+	}
+}
+
+// checkCORS sends a foreign Origin, as a simple request and a preflight
+func (s *securityScan) checkCORS() {
+	simple := withHeader(s.req, "Origin", scanOrigin)
+	preflight := withHeader(simple, "Access-Control-Request-Method", strings.ToUpper(s.req.Method))
+	preflight.Method, preflight.Body, preflight.BodyFile = "OPTIONS", nil, ""
+
+	for _, req := range []HTTPRequest{simple, preflight} {
+		resp := s.probe(req)
+		if resp == nil {
+			continue
+		}
+		origin, _ := responseHeader(resp, "Access-Control-Allow-Origin")
+		allowCredentials, _ := responseHeader(resp, "Access-Control-Allow-Credentials")
+		credentials := strings.EqualFold(strings.TrimSpace(allowCredentials), "true")
+		switch origin = strings.TrimSpace(origin); {
+		case origin == scanOrigin && credentials:
+			s.add(severityHigh, scanCORS, "CORS reflects any origin, with credentials", "Any website can make authenticated requests and read the responses")
+		case origin == scanOrigin:
+			s.add(severityMedium, scanCORS, "CORS reflects any origin", "Any website can read the responses; allow a fixed list of origins")
+		case origin == "null":
+			s.add(severityMedium, scanCORS, "CORS allows the 'null' origin", "Sandboxed iframes and local files get the 'null' origin, so any page can")
+		case origin == "*" && (credentials || hasCredentials(s.req)):
+			s.add(severityLow, scanCORS, "CORS allows any origin on an authenticated endpoint", "Access-Control-Allow-Origin: * on an endpoint that takes credentials")
+		}
+	}
+}
+
+// checkAuth resends the request without its credentials
+func (s *securityScan) checkAuth() {
+	if !hasCredentials(s.req) {
+		s.notes = append(s.notes, "auth: skipped, the request sends no credentials (Authorization, Cookie, API key or use_auth)")
+		return
+	}
+	if s.baseline.StatusCode >= 300 {
+		s.notes = append(s.notes, fmt.Sprintf("auth: skipped, the request itself got %d", s.baseline.StatusCode))
+		return
+	}
+	resp := s.probe(withoutCredentials(s.req))
+	if resp == nil {
+		return
+	}
+	if resp.StatusCode < 300 {
+		detail := fmt.Sprintf("Got %d without credentials (with them: %d)", resp.StatusCode, s.baseline.StatusCode)
+		if resp.Body == s.baseline.Body {
+			detail += "; the body is the same"
+		}
+		s.add(severityHigh, scanAuth, "Accessible without credentials", detail)
+	}
+}
+
+// checkInjection puts probes into string fields and query parameters and
+// looks for them reflected unescaped, evaluated, or breaking a query
+func (s *securityScan) checkInjection() {
+	const (
+		xssProbe      = `zap<script>alert(1)</script>`
+		templateProbe = `zap{{7*7}}${7*7}`
+		sqlProbe      = `zap'"`
+	)
+	notJSON := func(resp *HTTPResponse) bool {
+		contentType, _ := responseHeader(resp, "Content-Type")
+		return !strings.Contains(strings.ToLower(contentType), "json")
+	}
+
+	fields := slices.DeleteFunc(fuzzFields(s.req, nil, nil), func(f fuzzField) bool {
+		return f.typ != "string" && f.location != "query"
+	})
+	if len(fields) == 0 {
+		s.notes = append(s.notes, "injection: skipped, the request has no string body fields or query parameters")
+		return
+	}
+	if len(fields) > 10 {
+		s.notes = append(s.notes, fmt.Sprintf("injection: probed the first 10 of %d fields", len(fields)))
+		fields = fields[:10]
+	}
+
+	for _, field := range fields {
+		name := field.name()
+		if resp := s.probe(withField(s.req, field, xssProbe, false)); resp != nil && strings.Contains(resp.Body, xssProbe) && notJSON(resp) {
+			s.add(severityHigh, scanInjection, "Reflected XSS in "+name, fmt.Sprintf("%s came back unescaped in a non-JSON response", xssProbe))
+		}
+		if resp := s.probe(withField(s.req, field, templateProbe, false)); resp != nil && strings.Contains(resp.Body, "zap49") {
+			s.add(severityHigh, scanInjection, "Template injection in "+name, fmt.Sprintf("%s was evaluated to 49", templateProbe))
+		}
+		if resp := s.probe(withField(s.req, field, sqlProbe, false)); resp != nil {
+			leaked := sqlErrorPattern.FindString(resp.Body)
+			if leaked != "" && !sqlErrorPattern.MatchString(s.baseline.Body) {
+				s.add(severityHigh, scanInjection, "Possible SQL injection in "+name, fmt.Sprintf("A quote in the value produced a SQL error: %q", leaked))
+			} else if resp.StatusCode >= 500 && s.baseline.StatusCode < 500 {
+				s.add(severityMedium, scanInjection, "Quote in "+name+" causes a server error", fmt.Sprintf("%s got %d; unescaped input may reach a query", sqlProbe, resp.StatusCode))
+			}
+		}
+	}
+}
+
+// format renders the findings, most severe first
+func (s *securityScan) format(checks []string) string {
+	var sb strings.Builder
+	counts := make(map[string]int)
+	for _, f := range s.findings {
+		counts[f.Severity]++
+	}
+	sb.WriteString(fmt.Sprintf("Security scan of %s %s (%d request(s); checks: %s)\n\n", strings.ToUpper(s.req.Method), s.req.URL, s.requests+1, strings.Join(checks, ", ")))
+
+	if len(s.findings) == 0 {
+		sb.WriteString("✓ No findings\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("✗ %d finding(s): %d high, %d medium, %d low\n", len(s.findings), counts[severityHigh], counts[severityMedium], counts[severityLow]))
+		n := 0
+		for _, severity := range severities {
+			for _, f := range s.findings {
+				if f.Severity != severity {
+					continue
+				}
+				n++
+				sb.WriteString(fmt.Sprintf("\n  %d. [%s] %s (%s)\n     %s\n", n, strings.ToUpper(f.Severity), f.Title, f.Check, f.Detail))
+			}
+		}
+	}
+
+	if len(s.notes) > 0 {
+		sb.WriteString("\nNotes:\n")
+		for _, note := range s.notes {
+			sb.WriteString("  - " + note + "\n")
+		}
+	}
+	sb.WriteString("\nThese are basic checks; a clean scan is not proof the endpoint is secure.\n")
+	return sb.String()
+}
+
+// credentialHints are parts of header and query parameter names that carry
+// credentials
+var credentialHints = []string{"auth", "token", "key", "session", "cookie", "secret", "signature"}
+
+func isCredentialName(name string) bool {
+	name = strings.ToLower(name)
+	if strings.Contains(name, "idempotency") {
+		return false
+	}
+	for _, hint := range credentialHints {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCredentials reports whether a request sends credentials
+func hasCredentials(req HTTPRequest) bool {
+	if (req.UseAuth != "" && req.UseAuth != UseAuthNone) || req.AWSSigV4 != nil || req.HMAC != nil {
+		return true
+	}
+	names := make([]string, 0, len(req.Headers)+len(req.Query))
+	for key := range req.Headers {
+		names = append(names, key)
+	}
+	for key := range req.Query {
+		names = append(names, key)
+	}
+	if _, rawQuery, ok := strings.Cut(req.URL, "?"); ok {
+		values, _ := url.ParseQuery(rawQuery)
+		for key := range values {
+			names = append(names, key)
+		}
+	}
+	return slices.ContainsFunc(names, isCredentialName)
+}
+
+// withoutCredentials returns a copy of req with its credential headers, query
+// parameters and auth profile removed
+func withoutCredentials(req HTTPRequest) HTTPRequest {
+	headers := make(map[string]string, len(req.Headers))
+	for key, value := range req.Headers {
+		if !isCredentialName(key) {
+			headers[key] = value
+		}
+	}
+	req.Headers = headers
+
+	if req.Query != nil {
+		query := make(map[string]interface{}, len(req.Query))
+		for key, value := range req.Query {
+			if !isCredentialName(key) {
+				query[key] = value
+			}
+		}
+		req.Query = query
+	}
+	if base, rawQuery, ok := strings.Cut(req.URL, "?"); ok {
+		if values, err := url.ParseQuery(rawQuery); err == nil {
+			for key := range values {
+				if isCredentialName(key) {
+					values.Del(key)
+				}
+			}
+			req.URL = base
+			if encoded := values.Encode(); encoded != "" {
+				req.URL += "?" + encoded
+			}
+		}
+	}
+
+	if req.UseAuth != "" && req.UseAuth != UseAuthNone {
+		req.UseAuth = UseAuthNone
+	}
+	req.AWSSigV4, req.HMAC = nil, nil
+	return req
+}
+
+// withHeader returns a copy of req with a header set
+func withHeader(req HTTPRequest, name, value string) HTTPRequest {
+	headers := make(map[string]string, len(req.Headers)+1)
+	for key, v := range req.Headers {
+		headers[key] = v
+	}
+	headers[name] = value
+	req.Headers = headers
+	return req
+}
+
+// isLocalURL reports whether a URL points at this machine
+func isLocalURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	return host == "localhost" || host == "::1" || strings.HasPrefix(host, "127.") || strings.HasSuffix(host, ".localhost")
+}
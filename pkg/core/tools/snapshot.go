@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SnapshotsDir is the folder (inside .zap) holding response snapshots
+const SnapshotsDir = "snapshots"
+
+// Snapshot is a saved response that later responses must match, like a
+// golden file. It is meant to be committed with the project.
+type Snapshot struct {
+	Name         string          `json:"name"`
+	StatusCode   int             `json:"status_code"`
+	IgnoreFields []string        `json:"ignore_fields,omitempty"` // Left out of the body, e.g. timestamps
+	Body         json.RawMessage `json:"body,omitempty"`          // JSON body, keys sorted
+	Text         string          `json:"text,omitempty"`          // Body that isn't JSON
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// SnapshotResult is the outcome of matching a response against a snapshot
+type SnapshotResult struct {
+	Name        string
+	Path        string
+	Created     bool     // There was no snapshot; the response was saved
+	Updated     bool     // The snapshot was rewritten in update mode
+	Differences []string // How the response differs from the snapshot
+}
+
+// Matched reports whether the response matches the snapshot, or was saved as it
+func (r *SnapshotResult) Matched() bool {
+	return r.Created || r.Updated || len(r.Differences) == 0
+}
+
+// Format renders the result for display
+func (r *SnapshotResult) Format() string {
+	var sb strings.Builder
+	switch {
+	case r.Created:
+		sb.WriteString(fmt.Sprintf("✓ Snapshot '%s' created\nPath: %s\n", r.Name, r.Path))
+	case r.Updated:
+		sb.WriteString(fmt.Sprintf("✓ Snapshot '%s' updated (%d difference(s))\nPath: %s\n", r.Name, len(r.Differences), r.Path))
+	case len(r.Differences) == 0:
+		sb.WriteString(fmt.Sprintf("✓ Snapshot '%s' matches\n", r.Name))
+		return sb.String()
+	default:
+		sb.WriteString(fmt.Sprintf("✗ Snapshot '%s' does not match (%d difference(s))\n", r.Name, len(r.Differences)))
+	}
+	for i, diff := range r.Differences {
+		sb.WriteString(fmt.Sprintf("  %d. %s\n", i+1, diff))
+	}
+	if !r.Matched() {
+		sb.WriteString("\nIf the change is intended, update the snapshot (\"update\": true, or zap run --update-snapshots).\n")
+	}
+	return sb.String()
+}
+
+// MatchSnapshot compares resp with the named snapshot in zapDir/snapshots.
+// A missing snapshot is created from resp; with update, a differing one is
+// rewritten. ignoreFields default to the ones the snapshot was saved with.
+func MatchSnapshot(zapDir, name string, resp *HTTPResponse, ignoreFields []string, update bool) (*SnapshotResult, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return nil, fmt.Errorf("invalid snapshot name '%s'", name)
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("no HTTP response available - make an http_request first")
+	}
+	if resp.Binary || resp.Truncated || resp.BodyFile != "" {
+		return nil, fmt.Errorf("can't snapshot a binary, truncated or saved-to-file body")
+	}
+
+	result := &SnapshotResult{Name: name, Path: filepath.Join(zapDir, SnapshotsDir, name+".json")}
+	saved, err := loadSnapshot(result.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if saved != nil && ignoreFields == nil {
+		ignoreFields = saved.IgnoreFields
+	}
+
+	current := Snapshot{Name: name, StatusCode: resp.StatusCode, IgnoreFields: ignoreFields, UpdatedAt: time.Now()}
+	var body interface{}
+	if json.Unmarshal([]byte(resp.Body), &body) == nil {
+		if len(ignoreFields) > 0 {
+			body = (&CompareResponsesTool{}).removeFields(body, ignoreFields)
+		}
+		if current.Body, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to marshal body: %w", err)
+		}
+	} else {
+		current.Text = normalizeSnapshotText(resp.Body)
+	}
+
+	if saved == nil {
+		result.Created = true
+		return result, writeSnapshot(result.Path, current)
+	}
+	result.Differences = diffSnapshots(*saved, current)
+	if len(result.Differences) > 0 && update {
+		result.Updated = true
+		return result, writeSnapshot(result.Path, current)
+	}
+	return result, nil
+}
+
+// diffSnapshots lists how current differs from saved
+func diffSnapshots(saved, current Snapshot) []string {
+	var diffs []string
+	if saved.StatusCode != current.StatusCode {
+		diffs = append(diffs, fmt.Sprintf("Status changed: snapshot=%d, current=%d", saved.StatusCode, current.StatusCode))
+	}
+
+	switch {
+	case saved.Body != nil && current.Body != nil:
+		var before, after interface{}
+		if err := json.Unmarshal(saved.Body, &before); err != nil {
+			return append(diffs, fmt.Sprintf("Snapshot body is not valid JSON: %v", err))
+		}
+		if err := json.Unmarshal(current.Body, &after); err != nil {
+			return append(diffs, fmt.Sprintf("Response body is not valid JSON: %v", err))
+		}
+		diffs = append(diffs, (&CompareResponsesTool{}).compareJSON(before, after, "", CompareParams{}).Differences...)
+	case saved.Body != nil:
+		diffs = append(diffs, "Body was JSON, now it isn't")
+	case current.Body != nil:
+		diffs = append(diffs, "Body is JSON now, it wasn't")
+	case saved.Text != current.Text:
+		diffs = append(diffs, diffSnapshotText(saved.Text, current.Text))
+	}
+	return diffs
+}
+
+// diffSnapshotText describes the first line where two text bodies differ
+func diffSnapshotText(saved, current string) string {
+	before, after := strings.Split(saved, "\n"), strings.Split(current, "\n")
+	for i := 0; i < max(len(before), len(after)); i++ {
+		var was, is string
+		if i < len(before) {
+			was = before[i]
+		}
+		if i < len(after) {
+			is = after[i]
+		}
+		if was != is {
+			return fmt.Sprintf("Body differs at line %d: snapshot=%q, current=%q", i+1, truncateSnapshotLine(was), truncateSnapshotLine(is))
+		}
+	}
+	return "Body differs"
+}
+
+func truncateSnapshotLine(line string) string {
+	if len(line) > 120 {
+		return line[:117] + "..."
+	}
+	return line
+}
+
+// normalizeSnapshotText evens out line endings and trailing whitespace
+func normalizeSnapshotText(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func loadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot file %s: %w", path, err)
+	}
+	return &snapshot, nil
+}
+
+func writeSnapshot(path string, snapshot Snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+	// MarshalIndent also indents the body, so snapshots diff well in git
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// SnapshotTool matches the last response against a saved snapshot
+type SnapshotTool struct {
+	responseManager *ResponseManager
+	zapDir          string
+}
+
+// NewSnapshotTool creates a new snapshot tool
+func NewSnapshotTool(responseManager *ResponseManager, zapDir string) *SnapshotTool {
+	return &SnapshotTool{
+		responseManager: responseManager,
+		zapDir:          zapDir,
+	}
+}
+
+// SnapshotParams defines the parameters of the snapshot tool
+type SnapshotParams struct {
+	Name         string   `json:"name"`
+	IgnoreFields []string `json:"ignore_fields,omitempty"` // Body fields to leave out, e.g. "updated_at"
+	Update       bool     `json:"update,omitempty"`        // Rewrite the snapshot when the response differs
+}
+
+// Name returns the tool name
+func (t *SnapshotTool) Name() string {
+	return "snapshot"
+}
+
+// Description returns the tool description
+func (t *SnapshotTool) Description() string {
+	return "Match the last response against a saved snapshot in .zap/snapshots/ (golden file). The first run saves it; later runs fail on any difference until it is updated."
+}
+
+// Parameters returns the tool parameter description
+func (t *SnapshotTool) Parameters() string {
+	return `{
+  "name": "get-user",
+  "ignore_fields": ["updated_at", "request_id"],
+  "update": false
+}`
+}
+
+// Execute matches the last response against the snapshot
+func (t *SnapshotTool) Execute(args string) (string, error) {
+	var params SnapshotParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	result, err := MatchSnapshot(t.zapDir, params.Name, t.responseManager.GetHTTPResponse(), params.IgnoreFields, params.Update)
+	if err != nil {
+		return "", err
+	}
+	return result.Format(), nil
+}
@@ -0,0 +1,302 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Defaults for capturing Server-Sent Events
+const (
+	defaultSSEDuration  = 10 * time.Second
+	defaultSSEMaxEvents = 50
+)
+
+// SSETool connects to a Server-Sent Events endpoint and captures events
+type SSETool struct {
+	responseManager *ResponseManager
+	varStore        *VariableStore
+}
+
+// NewSSETool creates a new SSE client tool
+func NewSSETool(responseManager *ResponseManager, varStore *VariableStore) *SSETool {
+	return &SSETool{
+		responseManager: responseManager,
+		varStore:        varStore,
+	}
+}
+
+// SSEParams defines the SSE capture parameters
+type SSEParams struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method,omitempty"` // GET by default
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        interface{}       `json:"body,omitempty"`
+	Duration    int               `json:"duration,omitempty"`      // Seconds to listen
+	MaxEvents   int               `json:"max_events,omitempty"`    // Stop after this many events
+	Event       string            `json:"event,omitempty"`         // Only capture this event type
+	LastEventID string            `json:"last_event_id,omitempty"` // Resume from an event ID
+}
+
+// SSEEvent is one dispatched Server-Sent Event
+type SSEEvent struct {
+	ID    string      `json:"id,omitempty"`
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"` // Parsed JSON when the data is JSON, otherwise the raw string
+	Retry int         `json:"retry,omitempty"`
+	At    int64       `json:"at_ms"` // Milliseconds since the connection opened
+}
+
+// Name returns the tool name
+func (t *SSETool) Name() string {
+	return "sse_listen"
+}
+
+// Description returns the tool description
+func (t *SSETool) Description() string {
+	return "Connect to a Server-Sent Events (text/event-stream) endpoint and capture events until 'duration' seconds pass or 'max_events' arrive. Events are stored as {\"events\": [{\"id\", \"event\", \"data\"}]} for assert_response and extract_value (e.g. json_path \"events[0].data.status\")."
+}
+
+// Parameters returns the tool parameter description
+func (t *SSETool) Parameters() string {
+	return `{
+  "url": "{{BASE_URL}}/events",
+  "method": "GET",
+  "headers": {"Authorization": "Bearer {{TOKEN}}"},
+  "body": {},
+  "duration": 10,
+  "max_events": 50,
+  "event": "optional event type filter",
+  "last_event_id": ""
+}`
+}
+
+// Execute connects to the stream and captures events
+func (t *SSETool) Execute(args string) (string, error) {
+	if t.varStore != nil {
+		args = t.varStore.Substitute(args)
+	}
+
+	var params SSEParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	if params.URL == "" {
+		return "", fmt.Errorf("'url' is required")
+	}
+
+	duration := defaultSSEDuration
+	if params.Duration > 0 {
+		duration = time.Duration(params.Duration) * time.Second
+	}
+	maxEvents := defaultSSEMaxEvents
+	if params.MaxEvents > 0 {
+		maxEvents = params.MaxEvents
+	}
+	method := strings.ToUpper(params.Method)
+	if method == "" {
+		method = "GET"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	var bodyReader io.Reader
+	if params.Body != nil {
+		jsonBody, err := json.Marshal(params.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal body: %w", err)
+		}
+		bodyReader = bytes.NewBuffer(jsonBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, params.URL, bodyReader)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if params.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if params.LastEventID != "" {
+		req.Header.Set("Last-Event-ID", params.LastEventID)
+	}
+	for key, value := range params.Headers {
+		req.Header.Set(key, value)
+	}
+
+	start := time.Now()
+	// No client timeout: the context bounds the whole capture
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect: %w", err)
+	}
+	defer resp.Body.Close()
+
+	headers := make(map[string]string)
+	for key, values := range resp.Header {
+		headers[key] = strings.Join(values, ", ")
+	}
+
+	// Non-stream responses (errors, wrong endpoint) are returned like http_request
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		httpResp := &HTTPResponse{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Headers:    headers,
+			Body:       string(body),
+			Duration:   time.Since(start),
+		}
+		if t.responseManager != nil {
+			t.responseManager.SetHTTPResponse(httpResp)
+		}
+		return "Not an event stream (expected 200 with Content-Type: text/event-stream).\n\n" + httpResp.FormatResponse(), nil
+	}
+
+	events, readErr := readSSEEvents(resp.Body, start, maxEvents, params.Event)
+	elapsed := time.Since(start)
+
+	stopReason := "max_events reached"
+	switch {
+	case errors.Is(readErr, context.DeadlineExceeded) || ctx.Err() != nil:
+		stopReason = "duration elapsed"
+		readErr = nil
+	case readErr == io.EOF:
+		stopReason = "server closed the stream"
+		readErr = nil
+	}
+	if readErr != nil {
+		return "", fmt.Errorf("failed to read event stream: %w", readErr)
+	}
+
+	result := map[string]interface{}{
+		"url":    params.URL,
+		"count":  len(events),
+		"events": events,
+	}
+	body, _ := json.Marshal(result)
+	if t.responseManager != nil {
+		t.responseManager.SetHTTPResponse(&HTTPResponse{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Headers:    headers,
+			Body:       string(body),
+			Duration:   elapsed,
+		})
+	}
+
+	return formatSSEEvents(events, elapsed, stopReason), nil
+}
+
+// readSSEEvents parses an event stream per the WHATWG spec until EOF, an
+// error, or maxEvents matching events have been dispatched.
+func readSSEEvents(r io.Reader, start time.Time, maxEvents int, filter string) ([]SSEEvent, error) {
+	events := []SSEEvent{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var id, event string
+	var data []string
+	var retry int
+	hasData := false
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		// A blank line dispatches the buffered event
+		if line == "" {
+			if hasData {
+				name := event
+				if name == "" {
+					name = "message"
+				}
+				if filter == "" || filter == name {
+					events = append(events, SSEEvent{
+						ID:    id,
+						Event: name,
+						Data:  parseSSEData(strings.Join(data, "\n")),
+						Retry: retry,
+						At:    time.Since(start).Milliseconds(),
+					})
+					if len(events) >= maxEvents {
+						return events, nil
+					}
+				}
+			}
+			event, data, retry, hasData = "", nil, 0, false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment / keep-alive
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		case "id":
+			// The last event ID persists across events
+			id = value
+		case "retry":
+			fmt.Sscanf(value, "%d", &retry)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return events, err
+	}
+	return events, io.EOF
+}
+
+// parseSSEData decodes JSON event data so it can be navigated with json_path
+func parseSSEData(data string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err == nil {
+		return v
+	}
+	return data
+}
+
+// formatSSEEvents renders captured events for the agent
+func formatSSEEvents(events []SSEEvent, elapsed time.Duration, stopReason string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Captured %d event(s) in %dms (%s)\n", len(events), elapsed.Milliseconds(), stopReason))
+
+	for i, e := range events {
+		sb.WriteString(fmt.Sprintf("\n[%d] +%dms event=%s", i, e.At, e.Event))
+		if e.ID != "" {
+			sb.WriteString(fmt.Sprintf(" id=%s", e.ID))
+		}
+		sb.WriteString("\n")
+
+		var data string
+		if s, ok := e.Data.(string); ok {
+			data = s
+		} else {
+			b, _ := json.Marshal(e.Data)
+			data = string(b)
+		}
+		if len(data) > 500 {
+			data = data[:500] + "... (truncated)"
+		}
+		sb.WriteString("    " + strings.ReplaceAll(data, "\n", "\n    ") + "\n")
+	}
+
+	if len(events) > 0 {
+		sb.WriteString("\nStored as {\"events\": [...]}; use extract_value with json_path like \"events[0].data\".")
+	}
+	return sb.String()
+}
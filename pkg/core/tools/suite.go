@@ -6,7 +6,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/blackcoderx/zap/pkg/storage"
+	"gopkg.in/yaml.v3"
 )
 
 // TestSuiteTool runs organized test suites
@@ -16,6 +21,9 @@ type TestSuiteTool struct {
 	extractTool     *ExtractTool
 	responseManager *ResponseManager
 	varStore        *VariableStore
+	persistence     *PersistenceTool // Active environment, for {{VAR}}s in requests (optional)
+	executor        ToolExecutor     // Runs tool hooks (optional)
+	updateSnapshots bool             // Rewrite snapshots that don't match instead of failing
 	zapDir          string
 }
 
@@ -31,20 +39,68 @@ func NewTestSuiteTool(httpTool *HTTPTool, assertTool *AssertTool, extractTool *E
 	}
 }
 
+// SetPersistence substitutes the variables of p's active environment into
+// test requests
+func (t *TestSuiteTool) SetPersistence(p *PersistenceTool) {
+	t.persistence = p
+}
+
+// SetUpdateSnapshots makes tests rewrite the snapshots they don't match
+// instead of failing
+func (t *TestSuiteTool) SetUpdateSnapshots(update bool) {
+	t.updateSnapshots = update
+}
+
 // TestDefinition defines a single test in a suite
 type TestDefinition struct {
 	Name       string            `json:"name"`
 	Request    HTTPRequest       `json:"request"`
 	Assertions *AssertParams     `json:"assertions,omitempty"`
-	Extract    map[string]string `json:"extract,omitempty"` // var_name -> json_path
+	Extract    map[string]string `json:"extract,omitempty"`        // var_name -> json_path; "var_name[]" appends to a list
+	Retries    int               `json:"retries,omitempty"`        // Run a failing test again up to this many times
+	RetryDelay int               `json:"retry_delay_ms,omitempty"` // Wait between attempts (default 500)
+	Timeout    int               `json:"timeout,omitempty"`        // Seconds per attempt, when the request sets none
+	Skip       bool              `json:"skip,omitempty"`           // Leave the test out, reported as skipped
+	Only       bool              `json:"only,omitempty"`           // Run only the tests marked only
+	Snapshot   *SnapshotCheck    `json:"snapshot,omitempty"`       // Match the response against a saved snapshot
+	PreScript  string            `json:"pre_script,omitempty"`     // Runs after the request's pre_script, before every attempt
+	PostScript string            `json:"post_script,omitempty"`    // Runs after the request's post_script; a failed assert fails the test
+
+	If          string       `json:"if,omitempty"`           // Run only when this expression holds (variables and the latest response)
+	Unless      string       `json:"unless,omitempty"`       // Skip when this expression holds
+	RepeatUntil *RepeatUntil `json:"repeat_until,omitempty"` // Send the request again until a condition on the response holds
+	ForEach     *ForEach     `json:"for_each,omitempty"`     // Run the test once per element of a list variable
+
+	template json.RawMessage // Test as written, when "{{column}}" placeholders keep it from decoding until a data row fills them
+}
+
+// SnapshotCheck matches a test's response against a snapshot in .zap/snapshots/
+type SnapshotCheck struct {
+	Name         string   `json:"name"`
+	IgnoreFields []string `json:"ignore_fields,omitempty"`
 }
 
 // TestSuiteParams defines a test suite
 type TestSuiteParams struct {
-	Name        string           `json:"name"`
-	Tests       []TestDefinition `json:"tests"`
-	OnFailure   string           `json:"on_failure,omitempty"`   // "stop" or "continue"
-	SaveResults bool             `json:"save_results,omitempty"` // Save to .zap/test-results/
+	Suite          string           `json:"suite,omitempty"` // Saved suite to run (.zap/suites/<suite>.yaml)
+	Name           string           `json:"name"`
+	Tests          []TestDefinition `json:"tests"`
+	OnFailure      string           `json:"on_failure,omitempty"`      // "stop" or "continue"
+	SaveResults    bool             `json:"save_results,omitempty"`    // Save to .zap/test-results/
+	Login          string           `json:"login,omitempty"`           // Saved login flow to run first (.zap/login_flows/)
+	UseAuth        string           `json:"use_auth,omitempty"`        // Default auth profile for tests that don't set one
+	Data           *SuiteData       `json:"data,omitempty"`            // Rows to run every test with (data-driven suite)
+	SaveAs         string           `json:"save_as,omitempty"`         // Also save the suite to .zap/suites/<save_as>.yaml
+	Requires       []string         `json:"requires,omitempty"`        // Saved suites to run first; their extracted variables are available to the tests
+	Parallel       bool             `json:"parallel,omitempty"`        // Run the tests concurrently; only for tests that don't depend on each other
+	MaxConcurrency int              `json:"max_concurrency,omitempty"` // Tests in flight at once when parallel (default 4)
+
+	ExportVariables []string `json:"export_variables,omitempty"` // Suite variables to keep afterwards ("*" for all); the rest are discarded
+
+	BeforeAll  []SuiteHook `json:"before_all,omitempty"`  // Run once before the tests; a failure skips them
+	BeforeEach []SuiteHook `json:"before_each,omitempty"` // Run before every test; a failure fails the test
+	AfterEach  []SuiteHook `json:"after_each,omitempty"`  // Run after every test, even a failed one
+	AfterAll   []SuiteHook `json:"after_all,omitempty"`   // Run once at the end, even after failures
 }
 
 // TestResult represents the result of a single test
@@ -54,6 +110,11 @@ type TestResult struct {
 	Duration   time.Duration `json:"duration"`
 	Error      string        `json:"error,omitempty"`
 	StatusCode int           `json:"status_code,omitempty"`
+	Skipped    bool          `json:"skipped,omitempty"`
+	Attempts   int           `json:"attempts,omitempty"`   // Set when the test was retried
+	Repeats    int           `json:"repeats,omitempty"`    // Requests sent by repeat_until
+	Iterations int           `json:"iterations,omitempty"` // Runs of a for_each test
+	SkipReason string        `json:"skip_reason,omitempty"`
 }
 
 // SuiteResult represents the result of an entire suite
@@ -65,7 +126,12 @@ type SuiteResult struct {
 	TotalTests int           `json:"total_tests"`
 	Passed     int           `json:"passed"`
 	Failed     int           `json:"failed"`
+	Skipped    int           `json:"skipped,omitempty"`
 	Tests      []TestResult  `json:"tests"`
+	Rows       int           `json:"rows,omitempty"`     // Data rows, for a data-driven suite
+	Only       bool          `json:"only,omitempty"`     // Only the tests marked only ran
+	Aborted    string        `json:"aborted,omitempty"`  // Why no test ran (a before_all hook failed)
+	Warnings   []string      `json:"warnings,omitempty"` // after_each/after_all hooks that failed
 }
 
 // Name returns the tool name
@@ -75,7 +141,7 @@ func (t *TestSuiteTool) Name() string {
 
 // Description returns the tool description
 func (t *TestSuiteTool) Description() string {
-	return "Run organized test suites with multiple tests, assertions, and value extraction. Tests run sequentially and can share variables."
+	return "Run organized test suites with multiple tests, assertions, and value extraction. Tests run sequentially and can share variables, or concurrently with parallel: true when they are independent."
 }
 
 // Parameters returns the tool parameter description
@@ -87,15 +153,43 @@ func (t *TestSuiteTool) Parameters() string {
       "name": "Create user",
       "request": {"method": "POST", "url": "http://localhost:8000/api/users", "body": {"name": "Test"}},
       "assertions": {"status_code": 201},
-      "extract": {"user_id": "$.id"}
+      "extract": {"user_id": "$.id", "created_ids[]": "$.id (a name ending in [] adds to a list)"},
+      "pre_script": "request.body.email = 'user_' + uuid() + '@example.com' (optional)",
+      "post_script": "assert response.body.name == 'Test', 'name not saved' (optional)"
     },
     {
       "name": "Get user",
       "request": {"method": "GET", "url": "http://localhost:8000/api/users/{{user_id}}"},
-      "assertions": {"status_code": 200}
+      "assertions": {"status_code": 200},
+      "retries": 2, "retry_delay_ms": 500, "timeout": 10, "skip": false, "only": false,
+      "snapshot": {"name": "get-user", "ignore_fields": ["updated_at"]}
+    },
+    {
+      "name": "Wait for export",
+      "if": "user_id != '' (optional: run only when this holds; unless skips when it does)",
+      "request": {"method": "GET", "url": "http://localhost:8000/api/users/{{user_id}}/export"},
+      "repeat_until": {"condition": "response.body.status == 'done'", "max_attempts": 10, "interval_ms": 1000}
+    },
+    {
+      "name": "Delete created users",
+      "for_each": {"items": "created_ids", "as": "id", "stop_on_failure": false},
+      "request": {"method": "DELETE", "url": "http://localhost:8000/api/users/{{id}}"},
+      "assertions": {"status_code": 204}
     }
   ],
-  "on_failure": "stop"
+  "on_failure": "stop",
+  "parallel": false,
+  "max_concurrency": 4,
+  "suite": "petstore-smoke (optional: run a saved suite from .zap/suites/ instead of tests)",
+  "login": "admin (optional saved login_flow)",
+  "use_auth": "admin (optional auth profile of the active environment)",
+  "data": {"file": "testdata/users.csv (optional: CSV or JSON rows; every test runs once per row with the columns as {{variables}})"},
+  "requires": ["auth-suite (optional: saved suites run first, e.g. to log in or create fixtures; their extracted variables are available)"],
+  "export_variables": ["user_id (optional: suite variables to keep afterwards, or \"*\"; the rest are discarded)"],
+  "save_as": "users-smoke (optional: save the suite to .zap/suites/ so 'zap run users-smoke' runs it in CI)",
+  "before_all": [{"name": "login", "request": {"method": "POST", "url": "...", "body": {}}, "extract": {"token": "$.token"}}],
+  "before_each": [], "after_each": [],
+  "after_all": [{"name": "cleanup", "request": {"method": "DELETE", "url": ".../users/{{user_id}}"}}, {"tool": "variable", "args": {"action": "delete", "name": "token"}}]
 }`
 }
 
@@ -105,21 +199,120 @@ func (t *TestSuiteTool) Execute(args string) (string, error) {
 	if err := json.Unmarshal([]byte(args), &params); err != nil {
 		return "", fmt.Errorf("failed to parse parameters: %w", err)
 	}
+	_, output, err := t.Run(params)
+	return output, err
+}
+
+// Run runs a suite, inline or saved, and returns its result with the
+// formatted report. The result is nil when the suite's login flow fails.
+func (t *TestSuiteTool) Run(params TestSuiteParams) (*SuiteResult, string, error) {
+	return t.run(params, newSuiteDeps())
+}
+
+// run is Run, as part of a run that may already be running required suites
+func (t *TestSuiteTool) run(params TestSuiteParams, deps *suiteDeps) (*SuiteResult, string, error) {
+	if params.Suite != "" {
+		deps.running = append(deps.running, suiteName(params.Suite))
+		defer func() { deps.running = deps.running[:len(deps.running)-1] }()
+
+		saved, err := LoadSuite(t.zapDir, params.Suite)
+		if err != nil {
+			return nil, "", err
+		}
+		// Settings passed alongside override the saved ones
+		if params.OnFailure != "" {
+			saved.OnFailure = params.OnFailure
+		}
+		if params.Login != "" {
+			saved.Login = params.Login
+		}
+		if params.UseAuth != "" {
+			saved.UseAuth = params.UseAuth
+		}
+		if params.Data != nil {
+			saved.Data = params.Data
+		}
+		if params.Requires != nil {
+			saved.Requires = params.Requires
+		}
+		if params.ExportVariables != nil {
+			saved.ExportVariables = params.ExportVariables
+		}
+		saved.SaveResults = saved.SaveResults || params.SaveResults
+		saved.SaveAs = params.SaveAs
+		params = *saved
+	}
 
 	if params.Name == "" {
-		return "", fmt.Errorf("'name' parameter is required")
+		return nil, "", fmt.Errorf("'name' parameter is required")
 	}
 
 	if len(params.Tests) == 0 {
-		return "", fmt.Errorf("'tests' array cannot be empty")
+		return nil, "", fmt.Errorf("'tests' array cannot be empty")
+	}
+
+	// Variables set while the suite runs live in its own scope
+	t.varStore.PushScope()
+	deps.depth++
+	result, output, err := t.runScoped(params, deps)
+	deps.depth--
+	if note := t.endScope(params.ExportVariables, deps.depth > 0); err == nil {
+		output += note
+	}
+	return result, output, err
+}
+
+// runScoped runs a loaded suite inside its variable scope
+func (t *TestSuiteTool) runScoped(params TestSuiteParams, deps *suiteDeps) (*SuiteResult, string, error) {
+
+	savedNote := ""
+	if params.SaveAs != "" {
+		path, err := SaveSuite(t.zapDir, params.SaveAs, params)
+		if err != nil {
+			return nil, "", err
+		}
+		savedNote = fmt.Sprintf("Saved suite to %s (run it with: zap run %s)\n\n", path, params.SaveAs)
 	}
 
 	if params.OnFailure == "" {
 		params.OnFailure = "stop"
 	}
 
+	var rows []map[string]interface{}
+	if params.Data != nil {
+		var err error
+		if rows, err = params.Data.load(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Run the suites this one builds on, for their tokens and created IDs
+	requiredNote, requiredFailed, err := t.runRequired(params.Requires, deps)
+	if err != nil {
+		return nil, "", err
+	}
+	if requiredFailed != "" {
+		result := SuiteResult{Name: params.Name, StartTime: time.Now(), TotalTests: len(params.Tests), Aborted: requiredFailed}
+		result.EndTime = result.StartTime
+		return &result, savedNote + requiredNote + t.formatResults(result), nil
+	}
+
+	// Log in first so every test can send the session cookie
+	loginNote := ""
+	if params.Login != "" {
+		flow, err := LoadLoginFlow(t.zapDir, params.Login)
+		if err != nil {
+			return nil, "", err
+		}
+		login := RunLoginFlow(t.httpTool, t.varStore, *flow)
+		if failed := login.Failed(); failed != nil {
+			return nil, savedNote + requiredNote + fmt.Sprintf("✗ Test Suite: %s - LOGIN FAILED (flow '%s', step '%s')\n\n%s", params.Name, params.Login, failed.Name, login.Format()), nil
+		}
+		loginNote = fmt.Sprintf("Logged in with flow '%s'\n\n", params.Login)
+	}
+
 	// Run the test suite
-	result := t.runSuite(params)
+	result := t.runSuite(params, rows)
 
 	// Save results if requested
 	if params.SaveResults {
@@ -130,46 +323,275 @@ func (t *TestSuiteTool) Execute(args string) (string, error) {
 	}
 
 	// Format output
-	return t.formatResults(result), nil
+	return &result, savedNote + requiredNote + loginNote + t.formatResults(result), nil
 }
 
-// runSuite executes all tests in the suite
-func (t *TestSuiteTool) runSuite(params TestSuiteParams) SuiteResult {
+// runSuite executes all tests in the suite, once per data row when there are rows
+func (t *TestSuiteTool) runSuite(params TestSuiteParams, rows []map[string]interface{}) SuiteResult {
+	passes := rows
+	if len(passes) == 0 {
+		passes = []map[string]interface{}{nil}
+	}
+	tests, only := focusedTests(params.Tests)
+	total := len(tests) * len(passes)
 	result := SuiteResult{
 		Name:       params.Name,
 		StartTime:  time.Now(),
-		TotalTests: len(params.Tests),
-		Tests:      make([]TestResult, 0, len(params.Tests)),
+		TotalTests: total,
+		Rows:       len(rows),
+		Only:       only,
+		Tests:      make([]TestResult, 0, total),
 	}
 
-	for i, test := range params.Tests {
-		testResult := t.runTest(test, i+1, len(params.Tests))
-		result.Tests = append(result.Tests, testResult)
+	if err := t.runHooks(hookBeforeAll, params.BeforeAll, params.UseAuth, t.sharedTools()); err != nil {
+		result.Aborted = err.Error()
+		passes = nil
+	}
 
-		if testResult.Passed {
-			result.Passed++
-		} else {
-			result.Failed++
+	cases := make([]suiteCase, 0, total)
+	for r, row := range passes {
+		for _, test := range tests {
+			c := suiteCase{test: test}
+			if row != nil {
+				c.row, c.rowNum = row, r+1
+			}
+			cases = append(cases, c)
+		}
+	}
+
+	outcomes := make([]caseOutcome, len(cases))
+	if params.Parallel && len(cases) > 1 {
+		t.runParallel(params, cases, outcomes)
+	} else {
+		for i, c := range cases {
+			outcomes[i] = t.runCase(params, c, i+1, total, t.sharedTools())
 			// Stop on failure if configured
-			if params.OnFailure == "stop" {
+			if outcomes[i].failed() && params.OnFailure == "stop" {
 				break
 			}
 		}
 	}
 
+	for _, outcome := range outcomes {
+		if !outcome.ran {
+			continue
+		}
+		result.Tests = append(result.Tests, outcome.result)
+		if outcome.warning != "" {
+			result.Warnings = append(result.Warnings, outcome.warning)
+		}
+		switch {
+		case outcome.result.Skipped:
+			result.Skipped++
+		case outcome.result.Passed:
+			result.Passed++
+		default:
+			result.Failed++
+		}
+	}
+
+	if err := t.runHooks(hookAfterAll, params.AfterAll, params.UseAuth, t.sharedTools()); err != nil {
+		result.Warnings = append(result.Warnings, err.Error())
+	}
+
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
 	return result
 }
 
+// Concurrency of a parallel suite
+const (
+	defaultSuiteConcurrency = 4
+	maxSuiteConcurrency     = 20
+)
+
+// testTools are the response manager a test's request lands in and the
+// assert/extract tools reading it
+type testTools struct {
+	responseManager *ResponseManager
+	assertTool      *AssertTool
+	extractTool     *ExtractTool
+}
+
+// sharedTools returns the suite's own tools, for tests run one at a time
+func (t *TestSuiteTool) sharedTools() testTools {
+	return testTools{responseManager: t.responseManager, assertTool: t.assertTool, extractTool: t.extractTool}
+}
+
+// isolatedTools returns tools with a response manager of their own, so
+// concurrent tests don't assert against each other's responses
+func (t *TestSuiteTool) isolatedTools() testTools {
+	rm := NewResponseManager()
+	return testTools{responseManager: rm, assertTool: NewAssertTool(rm), extractTool: NewExtractTool(rm, t.varStore)}
+}
+
+// runParallel runs the cases on a pool of workers. Outcomes keep the order of
+// the cases; with on_failure "stop", cases not started by a failure don't run.
+func (t *TestSuiteTool) runParallel(params TestSuiteParams, cases []suiteCase, outcomes []caseOutcome) {
+	workers := params.MaxConcurrency
+	if workers <= 0 {
+		workers = defaultSuiteConcurrency
+	}
+	workers = min(workers, maxSuiteConcurrency, len(cases))
+
+	var (
+		stopped atomic.Bool
+		wg      sync.WaitGroup
+		jobs    = make(chan int)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tt := t.isolatedTools()
+			for i := range jobs {
+				outcomes[i] = t.runCase(params, cases[i], i+1, len(cases), tt)
+				if outcomes[i].failed() && params.OnFailure == "stop" {
+					stopped.Store(true)
+				}
+			}
+		}()
+	}
+	for i := range cases {
+		if stopped.Load() {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// suiteCase is a test to run, with the data row it runs with
+type suiteCase struct {
+	test   TestDefinition
+	row    map[string]interface{} // nil without data
+	rowNum int
+}
+
+// caseOutcome is what running a suiteCase gave
+type caseOutcome struct {
+	ran     bool
+	result  TestResult
+	warning string // An after_each hook failed
+}
+
+func (o caseOutcome) failed() bool {
+	return o.ran && !o.result.Passed && !o.result.Skipped
+}
+
+// runCase runs one test with its data row and its before_each/after_each hooks
+func (t *TestSuiteTool) runCase(params TestSuiteParams, c suiteCase, testNum, totalTests int, tt testTools) caseOutcome {
+	test := c.test
+	name := test.Name
+	if c.row != nil {
+		name = fmt.Sprintf("%s [row %d]", name, c.rowNum)
+	}
+	if test.Skip {
+		return caseOutcome{ran: true, result: TestResult{Name: name, Skipped: true}}
+	}
+
+	if c.row != nil {
+		var err error
+		if test, err = applyRow(test, c.row, c.rowNum); err != nil {
+			return caseOutcome{ran: true, result: TestResult{Name: name, Error: err.Error()}}
+		}
+	} else if test.template != nil {
+		return caseOutcome{ran: true, result: TestResult{Name: name, Error: "test has {{placeholders}} in non-string fields but the suite has no data rows"}}
+	}
+	if test.Request.UseAuth == "" {
+		test.Request.UseAuth = params.UseAuth
+	}
+	reason, err := t.skipReason(test, tt)
+	if err != nil {
+		return caseOutcome{ran: true, result: TestResult{Name: test.Name, Error: err.Error()}}
+	}
+	if reason != "" {
+		return caseOutcome{ran: true, result: TestResult{Name: test.Name, Skipped: true, SkipReason: reason}}
+	}
+
+	outcome := caseOutcome{ran: true}
+	if err := t.runHooks(hookBeforeEach, params.BeforeEach, params.UseAuth, tt); err != nil {
+		outcome.result = TestResult{Name: test.Name, Error: err.Error()}
+	} else if test.ForEach != nil {
+		outcome.result = t.runTestForEach(test, testNum, totalTests, tt)
+	} else {
+		outcome.result = t.runTestWithRetries(test, testNum, totalTests, tt)
+	}
+	if err := t.runHooks(hookAfterEach, params.AfterEach, params.UseAuth, tt); err != nil {
+		outcome.warning = fmt.Sprintf("%s (after %s)", err, test.Name)
+	}
+	return outcome
+}
+
+// focusedTests returns the tests marked only, if any, or else all tests
+func focusedTests(tests []TestDefinition) ([]TestDefinition, bool) {
+	var focused []TestDefinition
+	for _, test := range tests {
+		if test.Only {
+			focused = append(focused, test)
+		}
+	}
+	if len(focused) == 0 {
+		return tests, false
+	}
+	return focused, true
+}
+
+// maxTestRetries caps a test's retries, like the retry tool's max_attempts
+const maxTestRetries = 10
+
+// runTestWithRetries runs a test, and again up to test.Retries times while it fails
+func (t *TestSuiteTool) runTestWithRetries(test TestDefinition, testNum, totalTests int, tt testTools) TestResult {
+	if test.Timeout > 0 && test.Request.Timeout == 0 {
+		test.Request.Timeout = test.Timeout
+	}
+	retries := min(test.Retries, maxTestRetries)
+	delay := 500 * time.Millisecond
+	if test.RetryDelay > 0 {
+		delay = time.Duration(test.RetryDelay) * time.Millisecond
+	}
+
+	run := t.runTest
+	if test.RepeatUntil != nil {
+		run = t.runTestUntil
+	}
+
+	var result TestResult
+	for attempt := 1; ; attempt++ {
+		result = run(test, testNum, totalTests, tt)
+		if result.Passed || attempt > retries {
+			if attempt > 1 {
+				result.Attempts = attempt
+			}
+			return result
+		}
+		time.Sleep(delay)
+	}
+}
+
 // runTest executes a single test
-func (t *TestSuiteTool) runTest(test TestDefinition, testNum, totalTests int) TestResult {
+func (t *TestSuiteTool) runTest(test TestDefinition, testNum, totalTests int, tt testTools) TestResult {
 	startTime := time.Now()
 	result := TestResult{
 		Name:   test.Name,
 		Passed: true,
 	}
 
+	// Run the pre_script first, so variables it sets are substituted
+	test.Request.PreScript = joinScripts(test.Request.PreScript, test.PreScript)
+	test.Request.PostScript = joinScripts(test.Request.PostScript, test.PostScript)
+	if test.Request.PreScript != "" {
+		prepared, err := t.httpTool.runPreScript(test.Request)
+		if err != nil {
+			result.Passed = false
+			result.Error = err.Error()
+			result.Duration = time.Since(startTime)
+			return result
+		}
+		test.Request = prepared
+	}
+
 	// Substitute variables in request
 	reqJSON, err := json.Marshal(test.Request)
 	if err != nil {
@@ -181,7 +603,10 @@ func (t *TestSuiteTool) runTest(test TestDefinition, testNum, totalTests int) Te
 
 	// Execute HTTP request
 	reqArgs := t.varStore.Substitute(string(reqJSON))
-	_, err = t.httpTool.Execute(reqArgs)
+	if t.persistence != nil {
+		reqArgs = storage.SubstituteVariables(reqArgs, t.persistence.GetEnvironment())
+	}
+	_, err = t.httpTool.ExecuteInto(reqArgs, tt.responseManager)
 	if err != nil {
 		result.Passed = false
 		result.Error = fmt.Sprintf("Request failed: %v", err)
@@ -190,8 +615,12 @@ func (t *TestSuiteTool) runTest(test TestDefinition, testNum, totalTests int) Te
 	}
 
 	// Get status code from last response
-	if lastResp := t.responseManager.GetHTTPResponse(); lastResp != nil {
+	if lastResp := tt.responseManager.GetHTTPResponse(); lastResp != nil {
 		result.StatusCode = lastResp.StatusCode
+		if len(lastResp.ScriptFailures) > 0 {
+			result.Passed = false
+			result.Error = "post_script: " + strings.Join(lastResp.ScriptFailures, "; ")
+		}
 	}
 
 	// Run assertions if provided
@@ -204,7 +633,7 @@ func (t *TestSuiteTool) runTest(test TestDefinition, testNum, totalTests int) Te
 			return result
 		}
 
-		assertResult, err := t.assertTool.Execute(string(assertJSON))
+		assertResult, err := tt.assertTool.Execute(string(assertJSON))
 		if err != nil {
 			result.Passed = false
 			result.Error = fmt.Sprintf("Assertion failed: %v", err)
@@ -219,6 +648,21 @@ func (t *TestSuiteTool) runTest(test TestDefinition, testNum, totalTests int) Te
 		}
 	}
 
+	// Match the response against its snapshot
+	if test.Snapshot != nil {
+		snapshot, err := MatchSnapshot(t.zapDir, test.Snapshot.Name, tt.responseManager.GetHTTPResponse(), test.Snapshot.IgnoreFields, t.updateSnapshots)
+		var failure string
+		if err != nil {
+			failure = fmt.Sprintf("Snapshot failed: %v", err)
+		} else if !snapshot.Matched() {
+			failure = snapshot.Format()
+		}
+		if failure != "" {
+			result.Passed = false
+			result.Error = strings.TrimSpace(result.Error + "\n" + failure)
+		}
+	}
+
 	// Extract values if provided
 	if len(test.Extract) > 0 {
 		for varName, jsonPath := range test.Extract {
@@ -226,6 +670,11 @@ func (t *TestSuiteTool) runTest(test TestDefinition, testNum, totalTests int) Te
 				JSONPath: jsonPath,
 				SaveAs:   varName,
 			}
+			// "ids[]" adds to the list in ids
+			if name, ok := strings.CutSuffix(varName, "[]"); ok {
+				extractParams.SaveAs = name
+				extractParams.Append = true
+			}
 			extractJSON, err := json.Marshal(extractParams)
 			if err != nil {
 				result.Passed = false
@@ -234,7 +683,7 @@ func (t *TestSuiteTool) runTest(test TestDefinition, testNum, totalTests int) Te
 				return result
 			}
 
-			_, err = t.extractTool.Execute(string(extractJSON))
+			_, err = tt.extractTool.Execute(string(extractJSON))
 			if err != nil {
 				result.Passed = false
 				result.Error = fmt.Sprintf("Extraction failed for '%s': %v", varName, err)
@@ -253,7 +702,17 @@ func (t *TestSuiteTool) formatResults(result SuiteResult) string {
 	var sb strings.Builder
 
 	// Header
-	if result.Passed == result.TotalTests {
+	if result.Aborted != "" {
+		sb.WriteString(fmt.Sprintf("✗ Test Suite: %s - SETUP FAILED\n", result.Name))
+		sb.WriteString(strings.Repeat("=", 60) + "\n\n")
+		sb.WriteString(fmt.Sprintf("No tests ran: %s\n", result.Aborted))
+		for _, warning := range result.Warnings {
+			sb.WriteString(fmt.Sprintf("⚠ %s\n", warning))
+		}
+		return sb.String()
+	}
+	allPassed := result.Passed+result.Skipped == result.TotalTests
+	if allPassed {
 		sb.WriteString(fmt.Sprintf("✓ Test Suite: %s - ALL PASSED\n", result.Name))
 	} else {
 		sb.WriteString(fmt.Sprintf("✗ Test Suite: %s - FAILURES DETECTED\n", result.Name))
@@ -262,9 +721,18 @@ func (t *TestSuiteTool) formatResults(result SuiteResult) string {
 	sb.WriteString(strings.Repeat("=", 60) + "\n\n")
 
 	// Summary
+	if result.Rows > 0 {
+		sb.WriteString(fmt.Sprintf("Data: %d rows x %d tests\n", result.Rows, result.TotalTests/result.Rows))
+	}
 	sb.WriteString(fmt.Sprintf("Total: %d tests\n", result.TotalTests))
 	sb.WriteString(fmt.Sprintf("Passed: %d (%.1f%%)\n", result.Passed, float64(result.Passed)/float64(result.TotalTests)*100))
 	sb.WriteString(fmt.Sprintf("Failed: %d (%.1f%%)\n", result.Failed, float64(result.Failed)/float64(result.TotalTests)*100))
+	if result.Skipped > 0 {
+		sb.WriteString(fmt.Sprintf("Skipped: %d\n", result.Skipped))
+	}
+	if result.Only {
+		sb.WriteString("Only the tests marked \"only\" ran\n")
+	}
 	sb.WriteString(fmt.Sprintf("Duration: %v\n\n", result.Duration))
 
 	// Individual test results
@@ -272,20 +740,41 @@ func (t *TestSuiteTool) formatResults(result SuiteResult) string {
 	sb.WriteString(strings.Repeat("-", 60) + "\n\n")
 
 	for i, test := range result.Tests {
-		if test.Passed {
+		attempts := ""
+		if test.Attempts > 1 {
+			attempts = fmt.Sprintf(" | Attempts: %d", test.Attempts)
+		}
+		if test.Repeats > 1 {
+			attempts += fmt.Sprintf(" | Polled: %d times", test.Repeats)
+		}
+		if test.Iterations > 0 {
+			attempts += fmt.Sprintf(" | Runs: %d", test.Iterations)
+		}
+		if test.Skipped && test.SkipReason != "" {
+			sb.WriteString(fmt.Sprintf("%d. - %s (skipped: %s)\n\n", i+1, test.Name, test.SkipReason))
+		} else if test.Skipped {
+			sb.WriteString(fmt.Sprintf("%d. - %s (skipped)\n\n", i+1, test.Name))
+		} else if test.Passed {
 			sb.WriteString(fmt.Sprintf("%d. ✓ %s\n", i+1, test.Name))
-			sb.WriteString(fmt.Sprintf("   Status: %d | Duration: %v\n\n", test.StatusCode, test.Duration))
+			sb.WriteString(fmt.Sprintf("   Status: %d | Duration: %v%s\n\n", test.StatusCode, test.Duration, attempts))
 		} else {
 			sb.WriteString(fmt.Sprintf("%d. ✗ %s\n", i+1, test.Name))
-			sb.WriteString(fmt.Sprintf("   Status: %d | Duration: %v\n", test.StatusCode, test.Duration))
+			sb.WriteString(fmt.Sprintf("   Status: %d | Duration: %v%s\n", test.StatusCode, test.Duration, attempts))
 			if test.Error != "" {
 				sb.WriteString(fmt.Sprintf("   Error: %s\n\n", test.Error))
 			}
 		}
 	}
 
+	if len(result.Warnings) > 0 {
+		sb.WriteString("\nTeardown problems:\n")
+		for _, warning := range result.Warnings {
+			sb.WriteString(fmt.Sprintf("⚠ %s\n", warning))
+		}
+	}
+
 	// Footer
-	if result.Passed == result.TotalTests {
+	if allPassed {
 		sb.WriteString("\n🎉 All tests passed!\n")
 	} else {
 		sb.WriteString(fmt.Sprintf("\n⚠ %d test(s) failed. Review errors above.\n", result.Failed))
@@ -317,3 +806,101 @@ func (t *TestSuiteTool) saveResults(result SuiteResult) error {
 	// Write to file
 	return os.WriteFile(resultPath, data, 0644)
 }
+
+// suitesDir is the folder (inside .zap) holding saved suites
+const suitesDir = "suites"
+
+// SaveSuite writes suite to .zap/suites/<name>.yaml and returns the path.
+// The YAML uses the same keys as the test_suite tool's JSON.
+func SaveSuite(zapDir, name string, suite TestSuiteParams) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return "", fmt.Errorf("invalid suite name '%s'", name)
+	}
+	dir := filepath.Join(zapDir, suitesDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create suites directory: %w", err)
+	}
+
+	suite.Suite, suite.SaveAs = "", ""
+	data, err := json.Marshal(suite)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal suite: %w", err)
+	}
+	// JSON is valid YAML: decoding it into a node keeps the key order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return "", fmt.Errorf("failed to convert suite to YAML: %w", err)
+	}
+	blockStyle(&node)
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal suite: %w", err)
+	}
+
+	path := filepath.Join(dir, name+".yaml")
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return "", fmt.Errorf("failed to write suite: %w", err)
+	}
+	return path, nil
+}
+
+// ListSuites returns the names of the saved suites, sorted
+func ListSuites(zapDir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(zapDir, suitesDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list suites: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".yaml"); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// LoadSuite reads a saved suite by name
+func LoadSuite(zapDir, name string) (*TestSuiteParams, error) {
+	path := filepath.Join(zapDir, suitesDir, strings.TrimSuffix(name, ".yaml")+".yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("suite '%s' not found in %s", name, filepath.Join(zapDir, suitesDir))
+		}
+		return nil, fmt.Errorf("failed to read suite: %w", err)
+	}
+
+	// Decode generically, then through JSON so the json tags apply
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse suite YAML: %w", err)
+	}
+	jsonData, err := json.Marshal(normalizeYAML(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert suite: %w", err)
+	}
+	var suite TestSuiteParams
+	if err := json.Unmarshal(jsonData, &suite); err != nil {
+		return nil, fmt.Errorf("invalid suite '%s': %w", name, err)
+	}
+	if suite.Name == "" {
+		suite.Name = name
+	}
+	return &suite, nil
+}
+
+// blockStyle switches a node decoded from JSON to YAML block style
+func blockStyle(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style = 0
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		node.Style = 0 // The encoder still quotes strings that need it
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SuiteData drives a data-driven suite: every test runs once per row, with
+// the row's columns available as {{variables}}
+type SuiteData struct {
+	File string                   `json:"file,omitempty"` // CSV with a header row, or a JSON array of objects (within the project)
+	Rows []map[string]interface{} `json:"rows,omitempty"` // Inline rows, instead of a file
+}
+
+// maxDataRows caps the rows a suite runs, so a large fixture can't run for hours
+const maxDataRows = 1000
+
+// load returns the rows of the data block
+func (d *SuiteData) load() ([]map[string]interface{}, error) {
+	if d.File != "" && len(d.Rows) > 0 {
+		return nil, fmt.Errorf("data: use either file or rows, not both")
+	}
+	rows := d.Rows
+	if d.File != "" {
+		var err error
+		if rows, err = loadDataFile(d.File); err != nil {
+			return nil, err
+		}
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("data: no rows")
+	}
+	if len(rows) > maxDataRows {
+		return nil, fmt.Errorf("data: %d rows is more than the limit of %d", len(rows), maxDataRows)
+	}
+	return rows, nil
+}
+
+// loadDataFile reads a CSV or JSON data file, by extension
+func loadDataFile(name string) ([]map[string]interface{}, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	path, err := ValidatePathWithinWorkDir(name, workDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return parseCSVRows(data)
+	case ".json":
+		var rows []map[string]interface{}
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, fmt.Errorf("data file %s must be a JSON array of objects: %w", name, err)
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("data file %s must be .csv or .json", name)
+}
+
+// parseCSVRows reads CSV with a header row. Cells holding a number or
+// true/false become typed values, so "{{status}}" can fill status_code.
+func parseCSVRows(data []byte) ([]map[string]interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	rows := make([]map[string]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			if i < len(record) {
+				row[strings.TrimSpace(column)] = csvValue(record[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// csvValue types a CSV cell: numbers and booleans, anything else a string
+func csvValue(cell string) interface{} {
+	if cell == "true" || cell == "false" {
+		return cell == "true"
+	}
+	if csvNumberPattern.MatchString(cell) {
+		if n, err := strconv.ParseFloat(cell, 64); err == nil {
+			return n
+		}
+	}
+	return cell
+}
+
+// csvNumberPattern matches plain numbers; 0123 stays a string (zip codes, IDs)
+var csvNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+// UnmarshalJSON decodes a test. A test with "{{column}}" in a non-string
+// field, like "status_code": "{{status}}", is kept as written until a data
+// row fills it in.
+func (d *TestDefinition) UnmarshalJSON(data []byte) error {
+	type plain TestDefinition
+	var test plain
+	if err := json.Unmarshal(data, &test); err != nil {
+		var named struct {
+			Name string `json:"name"`
+		}
+		if !bytes.Contains(data, []byte("{{")) || json.Unmarshal(data, &named) != nil {
+			return err
+		}
+		*d = TestDefinition{Name: named.Name, template: append(json.RawMessage(nil), data...)}
+		return nil
+	}
+	*d = TestDefinition(test)
+	return nil
+}
+
+// MarshalJSON writes a test, keeping one that still waits for a data row as written
+func (d TestDefinition) MarshalJSON() ([]byte, error) {
+	if d.template != nil {
+		return d.template, nil
+	}
+	type plain TestDefinition
+	return json.Marshal(plain(d))
+}
+
+// applyRow substitutes a row's values into a test. A string that is just
+// "{{column}}" takes the value with its type; elsewhere it becomes text.
+func applyRow(test TestDefinition, row map[string]interface{}, index int) (TestDefinition, error) {
+	data, err := json.Marshal(test)
+	if err != nil {
+		return test, fmt.Errorf("failed to marshal test: %w", err)
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return test, fmt.Errorf("failed to decode test: %w", err)
+	}
+	tree = substituteRow(tree, row)
+
+	var applied TestDefinition
+	for {
+		if data, err = json.Marshal(tree); err != nil {
+			return test, fmt.Errorf("failed to marshal test: %w", err)
+		}
+		applied = TestDefinition{}
+		err = json.Unmarshal(data, &applied)
+		// A number or boolean cell in a string field, like body_contains, is
+		// used as text
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && stringifyAt(tree, strings.Split(typeErr.Field, ".")) {
+			continue
+		}
+		break
+	}
+	if err != nil || applied.template != nil {
+		if err == nil {
+			err = fmt.Errorf("a placeholder has no column in the row")
+		}
+		return test, fmt.Errorf("row %d doesn't fit the test: %w", index, err)
+	}
+	if applied.Name == test.Name {
+		applied.Name = fmt.Sprintf("%s [row %d]", test.Name, index)
+	}
+	return applied, nil
+}
+
+// substituteRow replaces {{column}} placeholders throughout a decoded value
+func substituteRow(v interface{}, row map[string]interface{}) interface{} {
+	switch value := v.(type) {
+	case string:
+		if name, ok := strings.CutPrefix(value, "{{"); ok {
+			if name, ok = strings.CutSuffix(name, "}}"); ok {
+				if cell, ok := row[strings.TrimSpace(name)]; ok {
+					return cell
+				}
+			}
+		}
+		for name, cell := range row {
+			value = strings.ReplaceAll(value, "{{"+name+"}}", rowText(cell))
+		}
+		return value
+	case map[string]interface{}:
+		for key, child := range value {
+			value[key] = substituteRow(child, row)
+		}
+		return value
+	case []interface{}:
+		for i, child := range value {
+			value[i] = substituteRow(child, row)
+		}
+		return value
+	}
+	return v
+}
+
+// stringifyAt turns the number or boolean at a dotted JSON path into text.
+// It reports whether it changed anything.
+func stringifyAt(tree interface{}, path []string) bool {
+	for ; len(path) > 0; path = path[1:] {
+		var value interface{}
+		switch node := tree.(type) {
+		case map[string]interface{}:
+			value = node[path[0]]
+			if len(path) == 1 && isRowScalar(value) {
+				node[path[0]] = rowText(value)
+				return true
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(path[0])
+			if err != nil || i < 0 || i >= len(node) {
+				return false
+			}
+			value = node[i]
+			if len(path) == 1 && isRowScalar(value) {
+				node[i] = rowText(value)
+				return true
+			}
+		}
+		tree = value
+	}
+	return false
+}
+
+// isRowScalar reports whether v is a number or boolean
+func isRowScalar(v interface{}) bool {
+	switch v.(type) {
+	case float64, bool:
+		return true
+	}
+	return false
+}
+
+// rowText renders a row value inside a longer string
+func rowText(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RepeatUntil sends a test's request again until a condition on the response
+// holds, e.g. polling a job until it is done
+type RepeatUntil struct {
+	Condition   string `json:"condition"`              // Expression, e.g. "response.body.status == 'ready'"
+	MaxAttempts int    `json:"max_attempts,omitempty"` // Default 10
+	IntervalMs  int    `json:"interval_ms,omitempty"`  // Wait between attempts (default 1000)
+}
+
+// Polling limits of repeat_until
+const (
+	defaultRepeatAttempts = 10
+	maxRepeatAttempts     = 100
+	defaultRepeatInterval = time.Second
+)
+
+// conditionEnv returns what if, unless and repeat_until conditions see: the
+// variables and the latest response
+func (t *TestSuiteTool) conditionEnv(stage string, tt testTools) *scriptEnv {
+	env := newScriptEnv(stage, t.varStore)
+	if resp := tt.responseManager.GetHTTPResponse(); resp != nil {
+		env.response = responseFields(resp)
+	}
+	return env
+}
+
+// skipReason evaluates a test's if and unless conditions and returns why the
+// test shouldn't run, or "" when it should
+func (t *TestSuiteTool) skipReason(test TestDefinition, tt testTools) (string, error) {
+	if test.If != "" {
+		value, err := evalScriptExpr(test.If, t.conditionEnv("if", tt))
+		if err != nil {
+			return "", err
+		}
+		if !truthy(value) {
+			return fmt.Sprintf("if %s is false", test.If), nil
+		}
+	}
+	if test.Unless != "" {
+		value, err := evalScriptExpr(test.Unless, t.conditionEnv("unless", tt))
+		if err != nil {
+			return "", err
+		}
+		if truthy(value) {
+			return fmt.Sprintf("unless %s is true", test.Unless), nil
+		}
+	}
+	return "", nil
+}
+
+// runTestUntil runs a test until its repeat_until condition holds on the
+// response. Only the last attempt's assertions count.
+func (t *TestSuiteTool) runTestUntil(test TestDefinition, testNum, totalTests int, tt testTools) TestResult {
+	repeat := test.RepeatUntil
+	attempts := repeat.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultRepeatAttempts
+	}
+	attempts = min(attempts, maxRepeatAttempts)
+	interval := defaultRepeatInterval
+	if repeat.IntervalMs > 0 {
+		interval = time.Duration(repeat.IntervalMs) * time.Millisecond
+	}
+
+	start := time.Now()
+	for repeats := 1; ; repeats++ {
+		result := t.runTest(test, testNum, totalTests, tt)
+		result.Repeats = repeats
+		// A request that got no response (the service is still starting) is polled again
+		if result.StatusCode != 0 {
+			value, err := evalScriptExpr(repeat.Condition, t.conditionEnv("repeat_until", tt))
+			if err != nil {
+				result.Passed = false
+				result.Error = err.Error()
+			}
+			if err != nil || truthy(value) {
+				result.Duration = time.Since(start)
+				return result
+			}
+		}
+		if repeats >= attempts {
+			result.Passed = false
+			result.Error = strings.TrimSpace(fmt.Sprintf("repeat_until %s still false after %d attempts\n%s", repeat.Condition, repeats, result.Error))
+			result.Duration = time.Since(start)
+			return result
+		}
+		time.Sleep(interval)
+	}
+}
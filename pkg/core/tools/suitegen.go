@@ -0,0 +1,456 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/storage"
+)
+
+// SuiteGenOptions controls GenerateSuites
+type SuiteGenOptions struct {
+	Env       string // Environment that gets BASE_URL and path parameter examples (default "dev")
+	Filter    string // Only operations whose id, path or tags contain this
+	Single    bool   // One suite for the whole spec instead of one per tag
+	HappyOnly bool   // Leave out the negative tests
+	Overwrite bool   // Replace existing suites
+}
+
+// SuiteGenResult lists what GenerateSuites wrote
+type SuiteGenResult struct {
+	Suites   []string // Names of the written suites
+	Skipped  []string // Existing suites that were left alone
+	Happy    int      // Happy-path tests
+	Negative int      // Negative tests
+	EnvPath  string
+	EnvVars  []string // Variables written to the environment
+	AuthVars []string // Credential variables used by the tests, to be set by the user
+	Notes    []string
+}
+
+// maxNegativeFields caps the fields a kind of negative test is generated for
+// per operation
+const maxNegativeFields = 3
+
+// suiteFieldPattern matches property names usable in a $.name JSON path
+var suiteFieldPattern = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// GenerateSuites writes test suites for spec's operations to .zap/suites/,
+// one per tag (or first path segment) unless opts.Single is set. Every
+// operation gets a happy-path test asserting its documented success status
+// and required response fields, and negative tests for what the spec says
+// the API must reject: missing credentials, missing required fields and
+// query parameters, wrong types, values outside an enum, malformed path
+// parameters and unknown resources. BASE_URL and path parameter examples go
+// into the opts.Env environment, keeping variables that are already set.
+func GenerateSuites(zapDir string, spec *OpenAPISpec, opts SuiteGenOptions) (*SuiteGenResult, error) {
+	if opts.Env == "" {
+		opts.Env = "dev"
+	}
+	result := &SuiteGenResult{}
+	envVars := make(map[string]string)
+	authVars := make(map[string]bool)
+
+	baseURL, notes := spec.baseURL()
+	result.Notes = append(result.Notes, notes...)
+	envVars["BASE_URL"] = baseURL
+
+	title := spec.Title
+	if title == "" {
+		title = "OpenAPI"
+	}
+	suites := make(map[string]*TestSuiteParams)
+	var order []string
+	deletes := 0
+	for _, op := range spec.Operations {
+		if !operationMatches(op, opts.Filter) {
+			continue
+		}
+		group := "tests"
+		if !opts.Single {
+			group = operationGroup(op)
+		}
+		suite, ok := suites[group]
+		if !ok {
+			name := title + " tests"
+			if !opts.Single {
+				name = fmt.Sprintf("%s %s tests", title, group)
+			}
+			suite = &TestSuiteParams{Name: name, OnFailure: "continue"}
+			suites[group] = suite
+			order = append(order, group)
+		}
+
+		happy, negative := spec.operationTests(op, envVars, authVars, !opts.HappyOnly)
+		if happy.Skip {
+			deletes++
+		}
+		suite.Tests = append(suite.Tests, happy)
+		suite.Tests = append(suite.Tests, negative...)
+		result.Happy++
+		result.Negative += len(negative)
+	}
+	if len(order) == 0 {
+		if opts.Filter != "" {
+			return nil, fmt.Errorf("no operations match '%s'", opts.Filter)
+		}
+		return nil, fmt.Errorf("spec has no operations under 'paths'")
+	}
+
+	if deletes > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d DELETE test(s) are marked skip: remove skip once their path variables point at disposable resources", deletes))
+	}
+
+	for _, group := range order {
+		name := kebabCase(title) + "-" + kebabCase(group)
+		if _, err := os.Stat(filepath.Join(zapDir, suitesDir, name+".yaml")); err == nil && !opts.Overwrite {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		if _, err := SaveSuite(zapDir, name, *suites[group]); err != nil {
+			return result, err
+		}
+		result.Suites = append(result.Suites, name)
+	}
+
+	result.EnvPath = filepath.Join(storage.GetEnvironmentsDir(zapDir), opts.Env+".yaml")
+	written, err := storage.SetEnvironmentVariables(result.EnvPath, envVars, false)
+	if err != nil {
+		return result, err
+	}
+	result.EnvVars = written
+	for name := range authVars {
+		result.AuthVars = append(result.AuthVars, name)
+	}
+	sort.Strings(result.AuthVars)
+	return result, nil
+}
+
+// operationMatches reports whether op's id, path or tags contain filter
+func operationMatches(op OpenAPIOperation, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	filter = strings.ToLower(filter)
+	for _, field := range append([]string{op.ID, op.Path}, op.Tags...) {
+		if strings.Contains(strings.ToLower(field), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// operationGroup names the suite an operation goes in: its first tag, or
+// its path's first word
+func operationGroup(op OpenAPIOperation) string {
+	if len(op.Tags) > 0 && op.Tags[0] != "" {
+		return op.Tags[0]
+	}
+	if tag := draftTag(op.Path); tag != "" {
+		return tag
+	}
+	return "root"
+}
+
+// operationTests builds an operation's happy-path test and, when negative
+// is set, its negative tests
+func (s *OpenAPISpec) operationTests(op OpenAPIOperation, envVars map[string]string, authVars map[string]bool, negative bool) (TestDefinition, []TestDefinition) {
+	name := op.Method + " " + op.Path
+	req := suiteRequest(s.requestFor(op, envVars, authVars))
+	happy := TestDefinition{Name: name, Request: req, Assertions: s.successAssertions(op)}
+	if happy.Assertions == nil {
+		happy.PostScript = `assert response.status >= 200 && response.status < 300, "expected a 2xx"`
+	}
+	// Deleting whatever {{id}} points at is left to the user to enable
+	if op.Method == "DELETE" {
+		happy.Skip = true
+	}
+	if !negative {
+		return happy, nil
+	}
+
+	var tests []TestDefinition
+	add := func(reason string, req HTTPRequest, statuses ...int) {
+		test := TestDefinition{Name: name + ": " + reason, Request: req}
+		expectClientError(&test, op, reason, statuses...)
+		tests = append(tests, test)
+	}
+
+	if len(op.Security) > 0 {
+		anonymous := op
+		anonymous.Security = nil
+		add("without credentials", suiteRequest(s.requestFor(anonymous, envVars, make(map[string]bool))), 401, 403)
+	}
+
+	if body, ok := req.Body.(map[string]interface{}); ok {
+		schema := s.resolve(op.BodySchema)
+		props := mapField(schema, "properties")
+		count := 0
+		for _, raw := range listField(schema, "required") {
+			field := fmt.Sprint(raw)
+			if _, ok := body[field]; !ok || count == maxNegativeFields {
+				continue
+			}
+			count++
+			add(fmt.Sprintf("missing required field '%s'", field), withBody(req, body, field, nil, true), 400, 422)
+		}
+		for _, field := range sortedKeys(props) {
+			if _, ok := body[field]; !ok {
+				continue
+			}
+			if value := wrongTypeValue(schemaType(s.resolve(props[field]))); value != nil {
+				add(fmt.Sprintf("wrong type for '%s'", field), withBody(req, body, field, value, false), 400, 422)
+				break
+			}
+		}
+		for _, field := range sortedKeys(props) {
+			if _, ok := body[field]; ok && len(listField(s.resolve(props[field]), "enum")) > 0 {
+				add(fmt.Sprintf("'%s' outside its enum", field), withBody(req, body, field, "not-a-valid-value", false), 400, 422)
+				break
+			}
+		}
+	}
+
+	count := 0
+	for _, param := range op.Parameters {
+		if param.In != "query" || !param.Required || count == maxNegativeFields {
+			continue
+		}
+		count++
+		missing := cloneSuiteRequest(req)
+		delete(missing.Query, param.Name)
+		add(fmt.Sprintf("missing required query parameter '%s'", param.Name), missing, 400, 422)
+	}
+
+	for _, param := range op.Parameters {
+		if param.In != "path" {
+			continue
+		}
+		schema := s.resolve(param.Schema)
+		placeholder := "{{" + param.Name + "}}"
+		switch {
+		case schemaType(schema) == "integer" || schemaType(schema) == "number":
+			add(fmt.Sprintf("non-numeric '%s'", param.Name), withPathParam(req, placeholder, "not-a-number"), 400, 422)
+		case stringField(schema, "format") == "uuid":
+			add(fmt.Sprintf("malformed '%s'", param.Name), withPathParam(req, placeholder, "not-a-uuid"), 400, 422)
+		}
+		if _, documented := op.Responses["404"]; documented && op.Method != "POST" {
+			add(fmt.Sprintf("unknown '%s'", param.Name), withPathParam(req, placeholder, unknownResourceValue(schema)), 404)
+		}
+		break
+	}
+	return happy, tests
+}
+
+// successAssertions expects the documented success status, content type
+// and required top-level response fields; nil when no 2xx is documented
+func (s *OpenAPISpec) successAssertions(op OpenAPIOperation) *AssertParams {
+	status := op.SuccessStatus()
+	if status == 0 {
+		return nil
+	}
+	assertions := &AssertParams{StatusCode: &status}
+	resp := op.Responses[fmt.Sprint(status)]
+	if resp.ContentType == "" {
+		return assertions
+	}
+	assertions.ContentType = resp.ContentType
+	schema := s.resolve(resp.Schema)
+	for _, raw := range listField(schema, "required") {
+		if field := fmt.Sprint(raw); suiteFieldPattern.MatchString(field) {
+			assertions.JSONPathExists = append(assertions.JSONPathExists, "$."+field)
+		}
+	}
+	return assertions
+}
+
+// expectClientError makes test expect the first of statuses op documents,
+// or any 4xx when it documents none of them
+func expectClientError(test *TestDefinition, op OpenAPIOperation, reason string, statuses ...int) {
+	for _, status := range statuses {
+		if _, ok := op.Responses[fmt.Sprint(status)]; ok {
+			test.Assertions = &AssertParams{StatusCode: &status}
+			return
+		}
+	}
+	reason = strings.ReplaceAll(reason, `"`, `'`)
+	test.PostScript = fmt.Sprintf(`assert response.status >= 400 && response.status < 500, "expected a 4xx: %s"`, reason)
+}
+
+// cloneSuiteRequest copies req so a negative test can change it
+func cloneSuiteRequest(req HTTPRequest) HTTPRequest {
+	clone := req
+	if req.Query != nil {
+		clone.Query = make(map[string]interface{}, len(req.Query))
+		for key, value := range req.Query {
+			clone.Query[key] = value
+		}
+	}
+	if req.Headers != nil {
+		clone.Headers = make(map[string]string, len(req.Headers))
+		for key, value := range req.Headers {
+			clone.Headers[key] = value
+		}
+	}
+	return clone
+}
+
+// withBody returns req with a body field set to value, or removed
+func withBody(req HTTPRequest, body map[string]interface{}, field string, value interface{}, remove bool) HTTPRequest {
+	changed := make(map[string]interface{}, len(body))
+	for key, v := range body {
+		changed[key] = v
+	}
+	if remove {
+		delete(changed, field)
+	} else {
+		changed[field] = value
+	}
+	clone := cloneSuiteRequest(req)
+	clone.Body = changed
+	return clone
+}
+
+// withPathParam returns req with a path parameter's placeholder replaced
+func withPathParam(req HTTPRequest, placeholder, value string) HTTPRequest {
+	clone := cloneSuiteRequest(req)
+	clone.URL = strings.Replace(clone.URL, placeholder, value, 1)
+	return clone
+}
+
+// wrongTypeValue returns a value of a different type than schemaType, or
+// nil for untyped fields
+func wrongTypeValue(schemaType string) interface{} {
+	switch schemaType {
+	case "string":
+		return 12345
+	case "integer", "number":
+		return "not-a-number"
+	case "boolean":
+		return "not-a-boolean"
+	case "array":
+		return "not-an-array"
+	case "object":
+		return "not-an-object"
+	}
+	return nil
+}
+
+// unknownResourceValue returns a path parameter value unlikely to exist
+func unknownResourceValue(schema map[string]interface{}) string {
+	switch {
+	case schemaType(schema) == "integer" || schemaType(schema) == "number":
+		return "999999999"
+	case stringField(schema, "format") == "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	}
+	return "zap-does-not-exist"
+}
+
+// Format describes the generated suites for the user
+func (r *SuiteGenResult) Format(zapDir string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Generated %d happy-path and %d negative test(s)\n", r.Happy, r.Negative))
+	if len(r.Suites) > 0 {
+		sb.WriteString(fmt.Sprintf("Wrote %d suite(s) to %s: %s\n", len(r.Suites), filepath.Join(zapDir, suitesDir), strings.Join(r.Suites, ", ")))
+	}
+	if len(r.Skipped) > 0 {
+		sb.WriteString(fmt.Sprintf("Skipped %d existing suite(s) (overwrite to replace them): %s\n", len(r.Skipped), strings.Join(r.Skipped, ", ")))
+	}
+	if len(r.EnvVars) > 0 {
+		sb.WriteString(fmt.Sprintf("Set %s in %s\n", strings.Join(r.EnvVars, ", "), r.EnvPath))
+	}
+	if len(r.AuthVars) > 0 {
+		sb.WriteString(fmt.Sprintf("Tests use credential variables %s: set them with the variable tool (\"secret\": true) or in .env\n", strings.Join(r.AuthVars, ", ")))
+	}
+	for _, note := range r.Notes {
+		sb.WriteString("Note: " + note + "\n")
+	}
+	if len(r.Suites) > 0 {
+		sb.WriteString(fmt.Sprintf("Run a suite with test_suite {\"suite\": %q} or zap run %s\n", r.Suites[0], r.Suites[0]))
+	}
+	return sb.String()
+}
+
+// GenerateTestsTool writes test suites from an OpenAPI spec
+type GenerateTestsTool struct {
+	zapDir string
+}
+
+// NewGenerateTestsTool creates a new generate_tests tool
+func NewGenerateTestsTool(zapDir string) *GenerateTestsTool {
+	return &GenerateTestsTool{zapDir: zapDir}
+}
+
+// GenerateTestsParams defines the spec and which tests to generate
+type GenerateTestsParams struct {
+	Spec      string `json:"spec"`                 // Spec file within the project (YAML or JSON)
+	Env       string `json:"env,omitempty"`        // Environment for BASE_URL and path parameters (default "dev")
+	Filter    string `json:"filter,omitempty"`     // Only operations whose id, path or tags contain this
+	Single    bool   `json:"single,omitempty"`     // One suite instead of one per tag
+	HappyOnly bool   `json:"happy_only,omitempty"` // No negative tests
+	Overwrite bool   `json:"overwrite,omitempty"`  // Replace existing suites
+}
+
+// Name returns the tool name
+func (t *GenerateTestsTool) Name() string {
+	return "generate_tests"
+}
+
+// Description returns the tool description
+func (t *GenerateTestsTool) Description() string {
+	return "Generate test suites from an OpenAPI 3.x/Swagger 2.0 spec into .zap/suites/ (one per tag): a happy-path test per operation asserting its documented status and required fields, plus negative tests (missing credentials, missing required fields and query parameters, wrong types, invalid enum values, malformed or unknown path parameters). Run them with test_suite {\"suite\": name}"
+}
+
+// Parameters returns the tool parameter description
+func (t *GenerateTestsTool) Parameters() string {
+	return `{
+  "spec": "openapi.yaml",
+  "env": "dev",
+  "filter": "optional, e.g. pets (operation id, path or tag)",
+  "single": false,
+  "happy_only": false,
+  "overwrite": false
+}`
+}
+
+// Execute generates the suites
+func (t *GenerateTestsTool) Execute(args string) (string, error) {
+	var params GenerateTestsParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	if params.Spec == "" {
+		return "", fmt.Errorf("'spec' is required")
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	specPath, err := ValidatePathWithinWorkDir(params.Spec, workDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid spec: %w", err)
+	}
+	spec, err := LoadOpenAPISpec(specPath)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := GenerateSuites(t.zapDir, spec, SuiteGenOptions{
+		Env:       params.Env,
+		Filter:    params.Filter,
+		Single:    params.Single,
+		HappyOnly: params.HappyOnly,
+		Overwrite: params.Overwrite,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Format(t.zapDir), nil
+}
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/blackcoderx/zap/pkg/storage"
+)
+
+// Hook stages of a suite
+const (
+	hookBeforeAll  = "before_all"
+	hookBeforeEach = "before_each"
+	hookAfterEach  = "after_each"
+	hookAfterAll   = "after_all"
+)
+
+// SuiteHook is a step of a suite's before_all, before_each, after_each or
+// after_all section: an HTTP request, with optional assertions and
+// extractions, or a call to another tool
+type SuiteHook struct {
+	Name       string            `json:"name,omitempty"`
+	Request    *HTTPRequest      `json:"request,omitempty"`
+	Assertions *AssertParams     `json:"assertions,omitempty"` // Without them a 4xx/5xx response fails the hook
+	Extract    map[string]string `json:"extract,omitempty"`    // var_name -> json_path
+	Tool       string            `json:"tool,omitempty"`       // e.g. "variable" or "auth_bearer"
+	Args       json.RawMessage   `json:"args,omitempty"`       // Tool arguments, as an object or a JSON string
+}
+
+// SetToolExecutor lets hooks call other tools by name, e.g. the agent
+func (t *TestSuiteTool) SetToolExecutor(executor ToolExecutor) {
+	t.executor = executor
+}
+
+// runHooks runs the hooks of a stage in order and stops at the first that fails
+func (t *TestSuiteTool) runHooks(stage string, hooks []SuiteHook, useAuth string, tt testTools) error {
+	for i, hook := range hooks {
+		name := hook.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if err := t.runHook(hook, useAuth, tt); err != nil {
+			return fmt.Errorf("%s %s: %w", stage, name, err)
+		}
+	}
+	return nil
+}
+
+// runHook runs a single hook
+func (t *TestSuiteTool) runHook(hook SuiteHook, useAuth string, tt testTools) error {
+	switch {
+	case hook.Request != nil && hook.Tool != "":
+		return fmt.Errorf("use either request or tool, not both")
+
+	case hook.Request != nil:
+		req := *hook.Request
+		if req.UseAuth == "" {
+			req.UseAuth = useAuth
+		}
+		result := t.runTest(TestDefinition{Name: hook.Name, Request: req, Assertions: hook.Assertions, Extract: hook.Extract}, 0, 0, tt)
+		if !result.Passed {
+			return fmt.Errorf("%s", result.Error)
+		}
+		if hook.Assertions == nil && result.StatusCode >= 400 {
+			return fmt.Errorf("got status %d", result.StatusCode)
+		}
+		return nil
+
+	case hook.Tool != "":
+		if t.executor == nil {
+			return fmt.Errorf("tool hooks aren't available here; use a request")
+		}
+		args := string(hook.Args)
+		var text string
+		if json.Unmarshal(hook.Args, &text) == nil {
+			args = text // Args given as a JSON string, like retry's
+		}
+		if args == "" {
+			args = "{}"
+		}
+		args = t.varStore.Substitute(args)
+		if t.persistence != nil {
+			args = storage.SubstituteVariables(args, t.persistence.GetEnvironment())
+		}
+		_, err := t.executor.ExecuteTool(hook.Tool, args)
+		return err
+	}
+	return fmt.Errorf("needs a request or a tool")
+}
+
+// ToolSet runs tools by name from a fixed set, for hooks when there is no agent
+type ToolSet map[string]core.Tool
+
+// NewToolSet creates a tool set from tools
+func NewToolSet(tools ...core.Tool) ToolSet {
+	set := make(ToolSet, len(tools))
+	for _, tool := range tools {
+		set[tool.Name()] = tool
+	}
+	return set
+}
+
+// ExecuteTool runs the named tool (implements ToolExecutor)
+func (s ToolSet) ExecuteTool(toolName string, args string) (string, error) {
+	tool, ok := s[toolName]
+	if !ok {
+		return "", fmt.Errorf("tool '%s' is not available outside the agent", toolName)
+	}
+	return tool.Execute(args)
+}
@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Report formats for a suite result, for CI systems
+const (
+	ReportJUnit = "junit" // JUnit XML
+	ReportJSON  = "json"  // JSON with durations in milliseconds
+	ReportTAP   = "tap"   // Test Anything Protocol, version 13
+)
+
+// ReportFormats lists the formats FormatSuiteReport accepts
+var ReportFormats = []string{ReportJUnit, ReportJSON, ReportTAP}
+
+// FormatSuiteReport renders a suite result as a JUnit XML, JSON or TAP report
+func FormatSuiteReport(result SuiteResult, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case ReportJUnit:
+		return junitReport(result)
+	case ReportJSON:
+		return jsonReport(result)
+	case ReportTAP:
+		return tapReport(result), nil
+	}
+	return nil, fmt.Errorf("unknown report format '%s' (use %s)", format, strings.Join(ReportFormats, ", "))
+}
+
+// junitTestSuites is the root element JUnit consumers (Jenkins, GitLab, GitHub
+// test reporters) expect
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",cdata"`
+}
+
+func junitReport(result SuiteResult) ([]byte, error) {
+	suite := junitTestSuite{
+		Name:      result.Name,
+		Tests:     len(result.Tests),
+		Failures:  result.Failed,
+		Skipped:   result.Skipped,
+		Time:      seconds(result.Duration),
+		Timestamp: result.StartTime.Format("2006-01-02T15:04:05"),
+	}
+	for _, test := range result.Tests {
+		tc := junitTestCase{Name: test.Name, ClassName: result.Name, Time: seconds(test.Duration)}
+		if test.Skipped {
+			tc.Skipped = &struct{}{}
+		} else if !test.Passed {
+			tc.Failure = &junitFailure{Message: failureMessage(test), Text: test.Error}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	if result.Aborted != "" {
+		// A failed before_all hook shows up as a failed case, so CI doesn't read "0 tests" as a pass
+		suite.Cases = append(suite.Cases, junitTestCase{
+			Name:      "before_all",
+			ClassName: result.Name,
+			Failure:   &junitFailure{Message: result.Aborted, Text: result.Aborted},
+			Time:      seconds(0),
+		})
+		suite.Tests++
+		suite.Failures++
+	}
+
+	data, err := xml.MarshalIndent(junitTestSuites{
+		Name:     result.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JUnit report: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// jsonReportTest is one test in the JSON report
+type jsonReportTest struct {
+	Name       string `json:"name"`
+	Passed     bool   `json:"passed"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Attempts   int    `json:"attempts,omitempty"`
+	Repeats    int    `json:"repeats,omitempty"`
+	Iterations int    `json:"iterations,omitempty"`
+	SkipReason string `json:"skip_reason,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func jsonReport(result SuiteResult) ([]byte, error) {
+	report := struct {
+		Name       string           `json:"name"`
+		StartTime  time.Time        `json:"start_time"`
+		DurationMs int64            `json:"duration_ms"`
+		Total      int              `json:"total"`
+		Ran        int              `json:"ran"`
+		Passed     int              `json:"passed"`
+		Failed     int              `json:"failed"`
+		Skipped    int              `json:"skipped"`
+		Rows       int              `json:"rows,omitempty"`
+		Aborted    string           `json:"aborted,omitempty"`
+		Warnings   []string         `json:"warnings,omitempty"`
+		Success    bool             `json:"success"`
+		Tests      []jsonReportTest `json:"tests"`
+	}{
+		Name:       result.Name,
+		StartTime:  result.StartTime,
+		DurationMs: result.Duration.Milliseconds(),
+		Total:      result.TotalTests,
+		Ran:        len(result.Tests),
+		Passed:     result.Passed,
+		Failed:     result.Failed,
+		Skipped:    result.Skipped,
+		Rows:       result.Rows,
+		Aborted:    result.Aborted,
+		Warnings:   result.Warnings,
+		Success:    result.Failed == 0 && result.Aborted == "",
+		Tests:      make([]jsonReportTest, 0, len(result.Tests)),
+	}
+	for _, test := range result.Tests {
+		report.Tests = append(report.Tests, jsonReportTest{
+			Name:       test.Name,
+			Passed:     test.Passed,
+			Skipped:    test.Skipped,
+			DurationMs: test.Duration.Milliseconds(),
+			Attempts:   test.Attempts,
+			Repeats:    test.Repeats,
+			Iterations: test.Iterations,
+			SkipReason: test.SkipReason,
+			StatusCode: test.StatusCode,
+			Error:      test.Error,
+		})
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON report: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func tapReport(result SuiteResult) []byte {
+	var sb strings.Builder
+	sb.WriteString("TAP version 13\n")
+	if result.Aborted != "" {
+		sb.WriteString(fmt.Sprintf("Bail out! %s\n", result.Aborted))
+		return []byte(sb.String())
+	}
+	sb.WriteString(fmt.Sprintf("1..%d\n", len(result.Tests)))
+	sb.WriteString(fmt.Sprintf("# %s\n", result.Name))
+	for i, test := range result.Tests {
+		if test.Skipped {
+			sb.WriteString(strings.TrimSpace(fmt.Sprintf("ok %d - %s # SKIP %s", i+1, tapEscape(test.Name), tapEscape(test.SkipReason))) + "\n")
+			continue
+		}
+		if test.Passed {
+			sb.WriteString(fmt.Sprintf("ok %d - %s\n", i+1, tapEscape(test.Name)))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("not ok %d - %s\n", i+1, tapEscape(test.Name)))
+		// YAML diagnostics block
+		sb.WriteString("  ---\n")
+		sb.WriteString(fmt.Sprintf("  message: %q\n", failureMessage(test)))
+		if test.StatusCode != 0 {
+			sb.WriteString(fmt.Sprintf("  status_code: %d\n", test.StatusCode))
+		}
+		sb.WriteString(fmt.Sprintf("  duration_ms: %d\n", test.Duration.Milliseconds()))
+		if strings.Contains(strings.TrimSpace(test.Error), "\n") {
+			sb.WriteString("  error: |\n")
+			for _, line := range strings.Split(strings.TrimSpace(test.Error), "\n") {
+				sb.WriteString("    " + line + "\n")
+			}
+		}
+		sb.WriteString("  ...\n")
+	}
+	for _, warning := range result.Warnings {
+		sb.WriteString(fmt.Sprintf("# warning: %s\n", warning))
+	}
+	if skipped := result.TotalTests - len(result.Tests); skipped > 0 {
+		sb.WriteString(fmt.Sprintf("# %d test(s) not run after a failure\n", skipped))
+	}
+	return []byte(sb.String())
+}
+
+// failureMessage returns the first line of a failed test's error that says
+// what went wrong
+func failureMessage(test TestResult) string {
+	for _, line := range strings.Split(test.Error, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && line != "Failures:" && !strings.HasPrefix(line, "✗ Assertions failed") {
+			return line
+		}
+	}
+	if test.Error != "" {
+		return strings.TrimSpace(strings.SplitN(test.Error, "\n", 2)[0])
+	}
+	return "test failed"
+}
+
+// tapEscape keeps a test name from being read as a TAP directive
+func tapEscape(name string) string {
+	return strings.ReplaceAll(name, "#", `\#`)
+}
+
+// seconds formats a duration the way JUnit's time attribute expects
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
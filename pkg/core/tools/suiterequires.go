@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// suiteDeps tracks the saved suites of one run, so a suite required twice
+// runs once and a cycle of requires is caught
+type suiteDeps struct {
+	running []string        // Saved suites being run, outermost first
+	done    map[string]bool // Required suites that already passed
+	depth   int             // Suites running, counting required ones
+}
+
+func newSuiteDeps() *suiteDeps {
+	return &suiteDeps{done: make(map[string]bool)}
+}
+
+// suiteName normalizes a saved suite reference, like LoadSuite does
+func suiteName(name string) string {
+	return strings.TrimSuffix(name, ".yaml")
+}
+
+// runRequired runs the saved suites a suite requires, in order, before it.
+// Their variables are kept in the requiring suite's scope for its tests.
+// It returns a note on what ran, and the reason the suite can't run when a
+// required suite failed.
+func (t *TestSuiteTool) runRequired(names []string, deps *suiteDeps) (note, failed string, err error) {
+	var sb strings.Builder
+	for _, name := range names {
+		name = suiteName(name)
+		if slices.Contains(deps.running, name) {
+			return "", "", fmt.Errorf("circular requires: %s", strings.Join(append(slices.Clone(deps.running), name), " -> "))
+		}
+		if deps.done[name] {
+			continue
+		}
+
+		result, output, err := t.run(TestSuiteParams{Suite: name}, deps)
+		if err != nil {
+			return "", "", fmt.Errorf("required suite '%s': %w", name, err)
+		}
+		if result == nil || result.Aborted != "" || result.Failed > 0 {
+			sb.WriteString(output + "\n")
+			return sb.String(), fmt.Sprintf("required suite '%s' failed", name), nil
+		}
+		deps.done[name] = true
+		sb.WriteString(fmt.Sprintf("✓ Required suite '%s': %d/%d passed\n", name, result.Passed, result.TotalTests))
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n")
+	}
+	return sb.String(), "", nil
+}
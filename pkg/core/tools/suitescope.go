@@ -0,0 +1,27 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// endScope ends a suite's variable scope. Variables named in export ("*" for
+// all) are kept in the enclosing scope, as are all of a required suite's, for
+// the suite that required it. It returns a note on what was discarded.
+func (t *TestSuiteTool) endScope(export []string, required bool) string {
+	var discarded []string
+	for name, value := range t.varStore.PopScope() {
+		if required || slices.Contains(export, "*") || slices.Contains(export, name) {
+			t.varStore.Set(name, value)
+		} else {
+			discarded = append(discarded, name)
+		}
+	}
+	if len(discarded) == 0 {
+		return ""
+	}
+	sort.Strings(discarded)
+	return fmt.Sprintf("\nSuite variables discarded: %s (list them in export_variables to keep them)\n", strings.Join(discarded, ", "))
+}
@@ -0,0 +1,364 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/core"
+)
+
+const (
+	// defaultTailLines and maxTailLines bound the lines returned
+	defaultTailLines = 100
+	maxTailLines     = 2000
+	// maxFollowSeconds caps how long follow mode waits for new lines
+	maxFollowSeconds = 60
+	// maxLogLineLength cuts long lines (JSON dumps, minified payloads)
+	maxLogLineLength = 500
+	// requestContextLines are kept around each line mentioning a request ID
+	requestContextLines = 5
+	// followPollInterval is how often a followed file is checked for growth
+	followPollInterval = 200 * time.Millisecond
+)
+
+// LogSource is where the server writes its logs: a file or a docker
+// container. It usually comes from the logs block of config.json.
+type LogSource struct {
+	File      string // Log file, relative to the project or absolute
+	Container string // Docker container name or ID
+}
+
+// TailLogsTool reads the server's recent log lines so failed requests can
+// be matched with the errors and stack traces they caused
+type TailLogsTool struct {
+	workDir  string
+	source   LogSource
+	varStore *VariableStore
+}
+
+// NewTailLogsTool creates a new log tailing tool reading source by default
+func NewTailLogsTool(workDir string, source LogSource, varStore *VariableStore) *TailLogsTool {
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	return &TailLogsTool{workDir: workDir, source: source, varStore: varStore}
+}
+
+// TailLogsParams defines the tailing parameters
+type TailLogsParams struct {
+	File          string `json:"file,omitempty"`           // Overrides the configured source (must be in the project)
+	Container     string `json:"container,omitempty"`      // Overrides the configured source
+	Lines         int    `json:"lines,omitempty"`          // Lines to read (default 100, max 2000)
+	FollowSeconds int    `json:"follow_seconds,omitempty"` // Wait this long for new lines instead (max 60)
+	Filter        string `json:"filter,omitempty"`         // Only lines matching this regex (case-insensitive)
+	RequestID     string `json:"request_id,omitempty"`     // Only lines around this ID; "last" for the last X-Request-Id sent
+}
+
+// Name returns the tool name
+func (t *TailLogsTool) Name() string {
+	return "tail_logs"
+}
+
+// Description returns the tool description
+func (t *TailLogsTool) Description() string {
+	return "Read the last lines of the server's log file or docker container logs, or follow them for a few seconds, and summarize the errors and stack frames found. Use after a 5xx to find the server-side cause; request_id narrows the lines to one request"
+}
+
+// Parameters returns the tool parameter description
+func (t *TailLogsTool) Parameters() string {
+	return `{"lines": "number - lines to read (default 100, max 2000)", "follow_seconds": "number - wait for new lines this long instead (max 60)", "filter": "string - only lines matching this regex, e.g. ERROR|panic", "request_id": "string - only lines around this ID, or 'last' for the X-Request-Id of the last request", "file": "string - log file in the project (default: logs.file in config)", "container": "string - docker container (default: logs.container in config)"}`
+}
+
+// Execute reads the log lines and summarizes them
+func (t *TailLogsTool) Execute(args string) (string, error) {
+	var params TailLogsParams
+	if strings.TrimSpace(args) != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", fmt.Errorf("failed to parse arguments: %w", err)
+		}
+	}
+	if params.Lines <= 0 {
+		params.Lines = defaultTailLines
+	}
+	params.Lines = min(params.Lines, maxTailLines)
+	params.FollowSeconds = min(params.FollowSeconds, maxFollowSeconds)
+	follow := time.Duration(params.FollowSeconds) * time.Second
+
+	var filter *regexp.Regexp
+	if params.Filter != "" {
+		var err error
+		if filter, err = regexp.Compile("(?i)" + params.Filter); err != nil {
+			filter = regexp.MustCompile("(?i)" + regexp.QuoteMeta(params.Filter))
+		}
+	}
+	requestID := params.RequestID
+	if requestID == "last" {
+		id, ok := "", false
+		if t.varStore != nil {
+			id, ok = t.varStore.Get(requestIDVar)
+		}
+		if !ok || id == "" {
+			return "", fmt.Errorf("no X-Request-Id was sent yet: enable request_ids in config.json or pass the ID itself")
+		}
+		requestID = id
+	}
+
+	source, lines, err := t.read(params, follow)
+	if err != nil {
+		return "", err
+	}
+	return formatLogLines(source, lines, follow, filter, requestID), nil
+}
+
+// read gets the lines from the source named in params, or the configured one
+func (t *TailLogsTool) read(params TailLogsParams, follow time.Duration) (string, []string, error) {
+	switch {
+	case params.File != "":
+		path, err := ValidatePathWithinWorkDir(params.File, t.workDir)
+		if err != nil {
+			return "", nil, err
+		}
+		lines, err := readLogFile(path, params.Lines, follow)
+		return params.File, lines, err
+	case params.Container != "":
+		lines, err := readContainerLogs(params.Container, params.Lines, follow)
+		return "container " + params.Container, lines, err
+	case t.source.File != "":
+		// The configured file may be outside the project, e.g. /var/log/app.log
+		path := t.source.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(t.workDir, path)
+		}
+		lines, err := readLogFile(path, params.Lines, follow)
+		return t.source.File, lines, err
+	case t.source.Container != "":
+		lines, err := readContainerLogs(t.source.Container, params.Lines, follow)
+		return "container " + t.source.Container, lines, err
+	}
+	return "", nil, fmt.Errorf("no log source: set logs.file or logs.container in .zap/config.json, or pass file or container")
+}
+
+// readLogFile returns the last n lines of a file or, when following, the
+// lines appended to it within follow
+func readLogFile(path string, n int, follow time.Duration) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	if follow <= 0 {
+		lines, err := lastLines(f, info.Size(), n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log file: %w", err)
+		}
+		return lines, nil
+	}
+
+	var added bytes.Buffer
+	offset := info.Size()
+	deadline := time.Now().Add(follow)
+	for time.Now().Before(deadline) {
+		time.Sleep(followPollInterval)
+		info, err := f.Stat()
+		if err != nil {
+			break
+		}
+		// Truncated or rotated in place: start over from the beginning
+		if info.Size() < offset {
+			offset = 0
+		}
+		if info.Size() == offset {
+			continue
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to read log file: %w", err)
+		}
+		read, err := io.Copy(&added, io.LimitReader(f, info.Size()-offset))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log file: %w", err)
+		}
+		offset += read
+	}
+	return tailOf(splitLogLines(added.String()), n), nil
+}
+
+// lastLines reads backwards from the end of f in blocks until it has n lines
+func lastLines(f *os.File, size int64, n int) ([]string, error) {
+	const block = 64 * 1024
+	var data []byte
+	for pos := size; pos > 0; {
+		start := max(pos-block, 0)
+		chunk := make([]byte, pos-start)
+		if _, err := f.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return nil, err
+		}
+		data = append(chunk, data...)
+		pos = start
+		if bytes.Count(data, []byte("\n")) > n {
+			break
+		}
+	}
+	return tailOf(splitLogLines(string(data)), n), nil
+}
+
+// readContainerLogs runs docker logs for the last n lines or, when
+// following, the lines logged within follow. stdout and stderr are merged.
+func readContainerLogs(container string, n int, follow time.Duration) ([]string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, fmt.Errorf("docker not found on PATH")
+	}
+
+	args := []string{"logs", "--tail", fmt.Sprint(n), container}
+	ctx := context.Background()
+	if follow > 0 {
+		args = []string{"logs", "--tail", "0", "--follow", container}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, follow)
+		defer cancel()
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// Following always ends with the timeout killing docker logs
+	if err := cmd.Run(); err != nil && ctx.Err() == nil {
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			return nil, fmt.Errorf("docker logs failed: %s", msg)
+		}
+		return nil, fmt.Errorf("docker logs failed: %w", err)
+	}
+	return tailOf(splitLogLines(output.String()), n), nil
+}
+
+// splitLogLines splits text into lines, dropping a trailing empty one
+func splitLogLines(text string) []string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// tailOf keeps the last n lines
+func tailOf(lines []string, n int) []string {
+	if len(lines) > n {
+		return lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// logErrorPattern marks lines reporting an error
+var logErrorPattern = regexp.MustCompile(`(?i)\b(error|exception|panic|fatal|traceback|critical)\b`)
+
+// formatLogLines filters the lines and appends a summary of the errors and
+// stack frames in them
+func formatLogLines(source string, lines []string, follow time.Duration, filter *regexp.Regexp, requestID string) string {
+	var sb strings.Builder
+	if follow > 0 {
+		sb.WriteString(fmt.Sprintf("%d new line(s) in %s within %s", len(lines), source, follow))
+	} else {
+		sb.WriteString(fmt.Sprintf("Last %d line(s) of %s", len(lines), source))
+	}
+
+	if requestID != "" {
+		around := linesAround(lines, requestID, requestContextLines)
+		if around == nil {
+			sb.WriteString(fmt.Sprintf("\nRequest ID %s not found in them; showing all lines", requestID))
+		} else {
+			sb.WriteString(fmt.Sprintf("\n%d line(s) around request ID %s", len(around), requestID))
+			lines = around
+		}
+	}
+	if filter != nil {
+		var kept []string
+		for _, line := range lines {
+			if filter.MatchString(line) {
+				kept = append(kept, line)
+			}
+		}
+		sb.WriteString(fmt.Sprintf("\n%d line(s) match %q", len(kept), strings.TrimPrefix(filter.String(), "(?i)")))
+		lines = kept
+	}
+	sb.WriteString("\n")
+	if len(lines) == 0 {
+		return sb.String()
+	}
+
+	errorLines := 0
+	sb.WriteString("\n")
+	for _, line := range lines {
+		if logErrorPattern.MatchString(line) {
+			errorLines++
+		}
+		if len(line) > maxLogLineLength {
+			line = line[:maxLogLineLength] + "..."
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	if errorLines > 0 {
+		sb.WriteString(fmt.Sprintf("\n%d line(s) mention errors\n", errorLines))
+	}
+	frames := core.ParseStackTrace(strings.Join(lines, "\n"))
+	if len(frames) > 0 {
+		sb.WriteString("\nStack frames:\n")
+		seen := make(map[string]bool)
+		shown := 0
+		for _, frame := range frames {
+			key := fmt.Sprintf("%s:%d", frame.File, frame.Line)
+			if seen[key] || shown == 10 {
+				continue
+			}
+			seen[key] = true
+			shown++
+			if frame.Function != "" {
+				key += " in " + frame.Function
+			}
+			sb.WriteString("  " + key + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// linesAround returns the lines mentioning id with context lines on each
+// side, or nil when no line does
+func linesAround(lines []string, id string, context int) []string {
+	keep := make([]bool, len(lines))
+	found := false
+	for i, line := range lines {
+		if !strings.Contains(line, id) {
+			continue
+		}
+		found = true
+		for j := max(i-context, 0); j <= min(i+context, len(lines)-1); j++ {
+			keep[j] = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	var around []string
+	for i, line := range lines {
+		if keep[i] {
+			around = append(around, line)
+		} else if i > 0 && keep[i-1] {
+			around = append(around, "...")
+		}
+	}
+	return around
+}
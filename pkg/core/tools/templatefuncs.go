@@ -0,0 +1,277 @@
+package tools
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math"
+	"math/rand/v2"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// templateFuncPattern matches the function calls left once variables are
+// substituted: a lowercase name, an optional time offset and arguments, e.g.
+// {{uuid}}, {{random_int 1 100}}, {{now "RFC3339"}} or {{timestamp+1h}}.
+// Arguments may hold single-level braces, such as a small JSON object.
+var templateFuncPattern = regexp.MustCompile(`\{\{\s*([a-z][a-z0-9_]*)([+-][0-9][0-9a-z.]*)?(\s(?:[^{}]|\{[^{}]*\})*)?\}\}`)
+
+// templateFunc generates a value for a {{name args}} placeholder
+type templateFunc struct {
+	offset bool // Takes a time offset like +1h or -2d
+	// call gets the arguments as written after the name
+	call func(args string, offset time.Duration) (string, error)
+}
+
+// templateFuncs are the built-in functions usable wherever {{VAR}} is.
+// A variable with the same name takes precedence.
+var templateFuncs = map[string]templateFunc{
+	"uuid": {call: func(args string, _ time.Duration) (string, error) {
+		return newUUID(), nil
+	}},
+	"random_int": {call: randomIntFunc},
+	"random_string": {call: func(args string, _ time.Duration) (string, error) {
+		n, err := intArg(splitTemplateArgs(args), 0, 12)
+		if err != nil {
+			return "", err
+		}
+		if n > maxRandomStringLength {
+			return "", fmt.Errorf("random_string: length %d is over the maximum of %d", n, maxRandomStringLength)
+		}
+		return randomString(n), nil
+	}},
+	"random_email": {call: func(args string, _ time.Duration) (string, error) {
+		return fmt.Sprintf("user_%s@example.com", randomString(10)), nil
+	}},
+	"random_name": {call: func(args string, _ time.Duration) (string, error) {
+		return fakeFirstNames[rand.IntN(len(fakeFirstNames))] + " " + fakeLastNames[rand.IntN(len(fakeLastNames))], nil
+	}},
+	"now": {offset: true, call: func(args string, offset time.Duration) (string, error) {
+		layout := time.RFC3339
+		if text := templateText(args); text != "" {
+			layout = timeLayout(text)
+		}
+		return time.Now().Add(offset).UTC().Format(layout), nil
+	}},
+	"timestamp": {offset: true, call: func(args string, offset time.Duration) (string, error) {
+		return strconv.FormatInt(time.Now().Add(offset).Unix(), 10), nil
+	}},
+	"timestamp_ms": {offset: true, call: func(args string, offset time.Duration) (string, error) {
+		return strconv.FormatInt(time.Now().Add(offset).UnixMilli(), 10), nil
+	}},
+
+	// Hashes, HMACs and encodings of the rest of the call, e.g. {{sha256 {{ts}}{{SECRET}}}}
+	"md5":    {call: hashFunc(md5.New)},
+	"sha1":   {call: hashFunc(sha1.New)},
+	"sha256": {call: hashFunc(sha256.New)},
+	"sha512": {call: hashFunc(sha512.New)},
+	"hmac_sha256": {call: func(args string, _ time.Duration) (string, error) {
+		key, message := cutTemplateArg(args)
+		return hex.EncodeToString(hmacSHA256([]byte(key), message)), nil
+	}},
+	"hmac_sha256_base64": {call: func(args string, _ time.Duration) (string, error) {
+		key, message := cutTemplateArg(args)
+		return base64.StdEncoding.EncodeToString(hmacSHA256([]byte(key), message)), nil
+	}},
+	"base64": {call: func(args string, _ time.Duration) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(templateText(args))), nil
+	}},
+	"base64url": {call: func(args string, _ time.Duration) (string, error) {
+		return base64.RawURLEncoding.EncodeToString([]byte(templateText(args))), nil
+	}},
+	"urlencode": {call: func(args string, _ time.Duration) (string, error) {
+		return url.QueryEscape(templateText(args)), nil
+	}},
+}
+
+// hashFunc returns a function hashing its text to hex
+func hashFunc(newHash func() hash.Hash) func(string, time.Duration) (string, error) {
+	return func(args string, _ time.Duration) (string, error) {
+		h := newHash()
+		h.Write([]byte(templateText(args)))
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+}
+
+var (
+	fakeFirstNames = []string{"Ada", "Alan", "Grace", "Linus", "Margaret", "Dennis", "Barbara", "Ken", "Radia", "Tim", "Frances", "Edsger"}
+	fakeLastNames  = []string{"Lovelace", "Turing", "Hopper", "Torvalds", "Hamilton", "Ritchie", "Liskov", "Thompson", "Perlman", "Berners-Lee", "Allen", "Dijkstra"}
+)
+
+// namedTimeLayouts maps layout names to Go layouts; anything else is used as
+// a Go layout itself
+var namedTimeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"ISO8601":     time.RFC3339,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC822":      time.RFC822,
+	"date":        time.DateOnly,
+	"time":        time.TimeOnly,
+	"datetime":    time.DateTime,
+}
+
+func timeLayout(name string) string {
+	if layout, ok := namedTimeLayouts[name]; ok {
+		return layout
+	}
+	return name
+}
+
+// maxTemplateNesting bounds how deeply function calls can be nested
+const maxTemplateNesting = 5
+
+// expandTemplateFuncs replaces built-in function calls in text, innermost
+// first, so {{base64 {{uuid}}}} works. A call that isn't a known function or
+// has bad arguments is left as it is.
+func expandTemplateFuncs(text string) string {
+	for range maxTemplateNesting {
+		if !strings.Contains(text, "{{") {
+			break
+		}
+		expanded := expandTemplateCalls(text)
+		if expanded == text {
+			break
+		}
+		text = expanded
+	}
+	return text
+}
+
+// expandTemplateCalls replaces the function calls that contain no other call
+func expandTemplateCalls(text string) string {
+	return templateFuncPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := templateFuncPattern.FindStringSubmatch(match)
+		fn, ok := templateFuncs[parts[1]]
+		if !ok || (parts[2] != "" && !fn.offset) {
+			return match
+		}
+		var offset time.Duration
+		if parts[2] != "" {
+			d, err := parseOffset(parts[2])
+			if err != nil {
+				return match
+			}
+			offset = d
+		}
+		value, err := fn.call(parts[3], offset)
+		if err != nil {
+			return match
+		}
+		return value
+	})
+}
+
+// parseOffset parses a signed duration like +1h, -30m or +2d
+func parseOffset(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid offset '%s'", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// templateText returns arguments taken as one text: trimmed, and unquoted
+// when the whole text is quoted
+func templateText(args string) string {
+	args = strings.TrimSpace(args)
+	for _, quote := range []string{`\"`, `"`} {
+		if len(args) >= 2*len(quote) && strings.HasPrefix(args, quote) && strings.HasSuffix(args, quote) {
+			return args[len(quote) : len(args)-len(quote)]
+		}
+	}
+	return args
+}
+
+// cutTemplateArg splits off the first argument (which may be quoted) and
+// returns the rest as one text
+func cutTemplateArg(args string) (first, rest string) {
+	args = strings.TrimSpace(args)
+	for _, quote := range []string{`\"`, `"`} {
+		if strings.HasPrefix(args, quote) {
+			first, rest, _ = strings.Cut(args[len(quote):], quote)
+			return first, templateText(rest)
+		}
+	}
+	first, rest, _ = strings.Cut(args, " ")
+	return first, templateText(rest)
+}
+
+// splitTemplateArgs splits arguments on spaces. Quoted arguments keep their
+// spaces; quotes may be escaped, as they are inside a JSON string.
+func splitTemplateArgs(s string) []string {
+	var args []string
+	s = strings.TrimSpace(s)
+	for s != "" {
+		var arg string
+		switch {
+		case strings.HasPrefix(s, `\"`):
+			arg, s, _ = strings.Cut(s[2:], `\"`)
+		case strings.HasPrefix(s, `"`):
+			arg, s, _ = strings.Cut(s[1:], `"`)
+		default:
+			arg, s, _ = strings.Cut(s, " ")
+		}
+		args = append(args, arg)
+		s = strings.TrimSpace(s)
+	}
+	return args
+}
+
+func randomIntFunc(raw string, _ time.Duration) (string, error) {
+	args := splitTemplateArgs(raw)
+	low, err := intArg(args, 0, 0)
+	if err != nil {
+		return "", err
+	}
+	high, err := intArg(args, 1, 1000)
+	if err != nil {
+		return "", err
+	}
+	if high < low {
+		return "", fmt.Errorf("random_int: max is below min")
+	}
+	// The span overflows int when min and max are far apart
+	span := high - low
+	if span < 0 || span == math.MaxInt {
+		return "", fmt.Errorf("random_int: range from %d to %d is too large", low, high)
+	}
+	return strconv.Itoa(low + rand.IntN(span+1)), nil
+}
+
+// maxRandomStringLength bounds random_string so a template can't exhaust memory
+const maxRandomStringLength = 1 << 16
+
+// intArg returns argument i as an int, or def when it is absent
+func intArg(args []string, i, def int) (int, error) {
+	if i >= len(args) {
+		return def, nil
+	}
+	n, err := strconv.Atoi(args[i])
+	if err != nil {
+		return 0, fmt.Errorf("argument %d must be a number, got '%s'", i+1, args[i])
+	}
+	return n, nil
+}
+
+// randomString returns n random lowercase letters and digits
+func randomString(n int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, max(0, n))
+	for i := range b {
+		b[i] = alphabet[rand.IntN(len(alphabet))]
+	}
+	return string(b)
+}
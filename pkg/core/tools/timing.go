@@ -209,3 +209,146 @@ func (t *RetryTool) calculateDelay(baseDelay, attempt int, backoff string) int {
 		return baseDelay
 	}
 }
+
+// Defaults for wait_for_service
+const (
+	defaultServiceWaitTimeout = 30 * time.Second
+	maxServiceWaitTimeout     = 5 * time.Minute
+	defaultServiceInterval    = 500 * time.Millisecond
+	defaultServiceAttemptTime = 5 // Seconds per health check request
+)
+
+// WaitForServiceTool polls a URL until the service reports healthy
+type WaitForServiceTool struct {
+	httpTool        *HTTPTool
+	responseManager *ResponseManager
+	varStore        *VariableStore
+}
+
+// NewWaitForServiceTool creates a new service wait tool
+func NewWaitForServiceTool(httpTool *HTTPTool, responseManager *ResponseManager, varStore *VariableStore) *WaitForServiceTool {
+	return &WaitForServiceTool{
+		httpTool:        httpTool,
+		responseManager: responseManager,
+		varStore:        varStore,
+	}
+}
+
+// WaitForServiceParams defines service wait parameters
+type WaitForServiceParams struct {
+	URL           string            `json:"url"`
+	Method        string            `json:"method,omitempty"`         // GET by default
+	Headers       map[string]string `json:"headers,omitempty"`        // e.g. auth for protected health checks
+	HealthyStatus []int             `json:"healthy_status,omitempty"` // Default: any 2xx
+	BodyContains  string            `json:"body_contains,omitempty"`  // Also require this text in the body
+	TimeoutSec    int               `json:"timeout_seconds,omitempty"`
+	IntervalMs    int               `json:"interval_ms,omitempty"`
+}
+
+// Name returns the tool name
+func (t *WaitForServiceTool) Name() string {
+	return "wait_for_service"
+}
+
+// Description returns the tool description
+func (t *WaitForServiceTool) Description() string {
+	return "Poll a URL until it returns a healthy status (default any 2xx, or 'healthy_status' codes) within 'timeout_seconds'. Connection errors are retried. Use before running requests or test suites against a server that was just started."
+}
+
+// Parameters returns the tool parameter description
+func (t *WaitForServiceTool) Parameters() string {
+	return `{
+  "url": "{{BASE_URL}}/health",
+  "healthy_status": [200, 204],
+  "body_contains": "ok",
+  "timeout_seconds": 30,
+  "interval_ms": 500
+}`
+}
+
+// Execute polls until the service is healthy or the timeout passes
+func (t *WaitForServiceTool) Execute(args string) (string, error) {
+	if t.varStore != nil {
+		args = t.varStore.Substitute(args)
+	}
+
+	var params WaitForServiceParams
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("failed to parse parameters: %w", err)
+	}
+	if params.URL == "" {
+		return "", fmt.Errorf("'url' is required")
+	}
+
+	timeout := defaultServiceWaitTimeout
+	if params.TimeoutSec > 0 {
+		timeout = time.Duration(params.TimeoutSec) * time.Second
+	}
+	if timeout > maxServiceWaitTimeout {
+		return "", fmt.Errorf("timeout_seconds cannot exceed %d", int(maxServiceWaitTimeout.Seconds()))
+	}
+	interval := defaultServiceInterval
+	if params.IntervalMs > 0 {
+		interval = time.Duration(params.IntervalMs) * time.Millisecond
+	}
+	method := strings.ToUpper(params.Method)
+	if method == "" {
+		method = "GET"
+	}
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+	attempts := 0
+	var lastProblem string
+
+	for {
+		attempts++
+		attemptTimeout := defaultServiceAttemptTime
+		if remaining := time.Until(deadline); remaining < time.Duration(attemptTimeout)*time.Second {
+			attemptTimeout = int(remaining.Seconds()) + 1
+		}
+
+		resp, err := t.httpTool.Run(HTTPRequest{
+			Method:  method,
+			URL:     params.URL,
+			Headers: params.Headers,
+			Timeout: attemptTimeout,
+		})
+		switch {
+		case err != nil:
+			lastProblem = err.Error()
+		case !isHealthyStatus(resp.StatusCode, params.HealthyStatus):
+			lastProblem = fmt.Sprintf("status %s", resp.Status)
+		case params.BodyContains != "" && !strings.Contains(resp.Body, params.BodyContains):
+			lastProblem = fmt.Sprintf("status %s but body does not contain %q", resp.Status, params.BodyContains)
+		default:
+			if t.responseManager != nil {
+				t.responseManager.SetHTTPResponse(resp)
+			}
+			return fmt.Sprintf("Service is healthy: %s returned %s after %d attempt(s) in %s",
+				params.URL, resp.Status, attempts, time.Since(start).Round(time.Millisecond)), nil
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			break
+		}
+		time.Sleep(interval)
+	}
+
+	return "", fmt.Errorf("service at %s not healthy after %s (%d attempts); last result: %s",
+		params.URL, time.Since(start).Round(time.Millisecond), attempts, lastProblem)
+}
+
+// isHealthyStatus reports whether code is one of healthy, or any 2xx when
+// healthy is empty
+func isHealthyStatus(code int, healthy []int) bool {
+	if len(healthy) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, c := range healthy {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// tokenExpiryLeeway renews tokens slightly before they expire
+const tokenExpiryLeeway = 30 * time.Second
+
+// OAuthToken is an access token saved as a variable, together with what is
+// needed to renew it. Tokens can be renewed when they have a refresh token or
+// came from the client_credentials flow.
+type OAuthToken struct {
+	Variable     string
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+
+	// Settings of the flow that issued the token
+	Flow         string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// renewable reports whether the token can be renewed without the user
+func (tok *OAuthToken) renewable() bool {
+	return tok.TokenURL != "" && (tok.RefreshToken != "" || tok.Flow == "client_credentials")
+}
+
+// expired reports whether the token is expired or about to expire
+func (tok *OAuthToken) expired() bool {
+	return !tok.Expiry.IsZero() && time.Now().Add(tokenExpiryLeeway).After(tok.Expiry)
+}
+
+// SetToken saves an OAuth2 token: the access token as {{name}}, a Bearer
+// header as {{name_header}}, and the refresh token as {{name_refresh_token}}.
+// http_request renews it automatically once it expires or a request gets a 401.
+func (vs *VariableStore) SetToken(tok OAuthToken) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	vs.session[tok.Variable] = tok.AccessToken
+	vs.session[tok.Variable+"_header"] = "Bearer " + tok.AccessToken
+	if tok.RefreshToken != "" {
+		vs.session[tok.Variable+"_refresh_token"] = tok.RefreshToken
+	}
+	if vs.tokens == nil {
+		vs.tokens = make(map[string]*OAuthToken)
+	}
+	vs.tokens[tok.Variable] = &tok
+
+	core.RegisterSecretValue(tok.AccessToken)
+	core.RegisterSecretValue(tok.RefreshToken)
+	core.RegisterSecretValue(tok.ClientSecret)
+}
+
+// token returns a copy of the saved token in variable name, or nil
+func (vs *VariableStore) token(name string) *OAuthToken {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	tok, ok := vs.tokens[name]
+	if !ok {
+		return nil
+	}
+	copied := *tok
+	return &copied
+}
+
+// tokenUsedBy finds the saved token whose access token appears in the
+// request's headers or URL
+func (vs *VariableStore) tokenUsedBy(req HTTPRequest) *OAuthToken {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	for _, tok := range vs.tokens {
+		if tok.AccessToken == "" {
+			continue
+		}
+		if strings.Contains(req.URL, tok.AccessToken) {
+			copied := *tok
+			return &copied
+		}
+		for _, value := range req.Headers {
+			if strings.Contains(value, tok.AccessToken) {
+				copied := *tok
+				return &copied
+			}
+		}
+	}
+	return nil
+}
+
+// renewToken renews stale (as last seen by the caller) and saves the result.
+// Concurrent callers holding the same stale token share one renewal.
+func (vs *VariableStore) renewToken(stale *OAuthToken) (*OAuthToken, error) {
+	vs.renewMu.Lock()
+	defer vs.renewMu.Unlock()
+
+	vs.mu.RLock()
+	current, ok := vs.tokens[stale.Variable]
+	vs.mu.RUnlock()
+	if ok && current.AccessToken != stale.AccessToken {
+		// Another request already renewed it
+		copied := *current
+		return &copied, nil
+	}
+
+	renewed, err := fetchRenewedToken(stale)
+	if err != nil {
+		return nil, err
+	}
+	vs.SetToken(*renewed)
+	return renewed, nil
+}
+
+// fetchRenewedToken asks the token endpoint for a new access token
+func fetchRenewedToken(tok *OAuthToken) (*OAuthToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultHTTPTimeout)
+	defer cancel()
+
+	var token *oauth2.Token
+	var err error
+	if tok.RefreshToken != "" {
+		config := oauth2.Config{
+			ClientID:     tok.ClientID,
+			ClientSecret: tok.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: tok.TokenURL},
+			Scopes:       tok.Scopes,
+		}
+		// An expired token makes the source use the refresh_token grant
+		token, err = config.TokenSource(ctx, &oauth2.Token{
+			RefreshToken: tok.RefreshToken,
+			Expiry:       time.Unix(1, 0),
+		}).Token()
+	} else {
+		config := clientcredentials.Config{
+			ClientID:     tok.ClientID,
+			ClientSecret: tok.ClientSecret,
+			TokenURL:     tok.TokenURL,
+			Scopes:       tok.Scopes,
+		}
+		token, err = config.Token(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh OAuth2 token '%s': %w", tok.Variable, err)
+	}
+
+	renewed := *tok
+	renewed.AccessToken = token.AccessToken
+	renewed.Expiry = token.Expiry
+	if token.RefreshToken != "" {
+		// Providers that rotate refresh tokens send a new one
+		renewed.RefreshToken = token.RefreshToken
+	}
+	return &renewed, nil
+}
+
+// withRenewedToken replaces the old access token in the request's URL and headers
+func withRenewedToken(req HTTPRequest, oldToken, newToken string) HTTPRequest {
+	req.URL = strings.ReplaceAll(req.URL, oldToken, newToken)
+	headers := make(map[string]string, len(req.Headers))
+	for key, value := range req.Headers {
+		headers[key] = strings.ReplaceAll(value, oldToken, newToken)
+	}
+	req.Headers = headers
+	return req
+}
@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestTiming breaks a request's latency down by phase. Connection phases
+// are zero when a pooled connection was reused. With redirects, the phases
+// describe the final request.
+type RequestTiming struct {
+	DNS          time.Duration `json:"dns"`
+	Connect      time.Duration `json:"connect"`
+	TLSHandshake time.Duration `json:"tls_handshake"`
+	TTFB         time.Duration `json:"ttfb"`     // Request written to first response byte (server time)
+	Download     time.Duration `json:"download"` // First byte to end of body
+	Reused       bool          `json:"reused"`   // Connection came from the pool
+}
+
+// timingTracer records phase timestamps from httptrace callbacks, which
+// may fire on other goroutines.
+type timingTracer struct {
+	mu           sync.Mutex
+	timing       RequestTiming
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+// withTrace returns a context that reports request phases to the tracer
+func (tt *timingTracer) withTrace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.timing.DNS = time.Since(tt.dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.connectStart = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			if err == nil {
+				tt.timing.Connect = time.Since(tt.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.timing.TLSHandshake = time.Since(tt.tlsStart)
+		},
+		GetConn: func(hostPort string) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			// Each hop (redirect) starts here; drop the previous hop's phases
+			tt.timing = RequestTiming{}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.timing.Reused = info.Reused
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.wroteRequest = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.firstByte = time.Now()
+			tt.timing.TTFB = tt.firstByte.Sub(tt.wroteRequest)
+		},
+	})
+}
+
+// finish records the download phase once the body has been read
+func (tt *timingTracer) finish() *RequestTiming {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	if !tt.firstByte.IsZero() {
+		tt.timing.Download = time.Since(tt.firstByte)
+	}
+	timing := tt.timing
+	return &timing
+}
+
+// String renders the timing as a single line, e.g.
+// "DNS 2ms | Connect 1ms | TLS 12ms | TTFB 40ms | Download 3ms"
+func (rt *RequestTiming) String() string {
+	var parts []string
+	if rt.Reused {
+		parts = append(parts, "connection reused")
+	} else {
+		parts = append(parts,
+			"DNS "+formatPhase(rt.DNS),
+			"Connect "+formatPhase(rt.Connect),
+		)
+		if rt.TLSHandshake > 0 {
+			parts = append(parts, "TLS "+formatPhase(rt.TLSHandshake))
+		}
+	}
+	parts = append(parts, "TTFB "+formatPhase(rt.TTFB), "Download "+formatPhase(rt.Download))
+	return strings.Join(parts, " | ")
+}
+
+// formatPhase formats a phase duration, keeping sub-millisecond precision
+func formatPhase(d time.Duration) string {
+	if d < time.Millisecond {
+		return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+	}
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
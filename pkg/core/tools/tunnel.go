@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Tunnel providers for webhook_listener
+const (
+	TunnelNgrok       = "ngrok"
+	TunnelCloudflared = "cloudflared"
+)
+
+// tunnelStartTimeout is how long a tunnel client gets to print its public URL
+const tunnelStartTimeout = 30 * time.Second
+
+// cloudflaredURLPattern matches the quick tunnel URL cloudflared logs
+var cloudflaredURLPattern = regexp.MustCompile(`https://[a-z0-9-]+\.trycloudflare\.com`)
+
+// selfSignedCert generates a certificate for localhost and 127.0.0.1, valid
+// for validFor. It returns the certificate and its SHA-256 fingerprint.
+func selfSignedCert(validFor time.Duration) (tls.Certificate, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, "", fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost", Organization: []string{"ZAP webhook listener"}},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(validFor),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, "", fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	sum := sha256.Sum256(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, hex.EncodeToString(sum[:]), nil
+}
+
+// tunnel is a running ngrok or cloudflared process forwarding a public URL
+// to a local port
+type tunnel struct {
+	provider  string
+	publicURL string // Without the listener's path
+	cmd       *exec.Cmd
+}
+
+// startTunnel runs the provider's client against the local port and waits
+// for it to report its public URL. localTLS tells the client the port
+// serves HTTPS with a self-signed certificate.
+func startTunnel(provider string, port int, localTLS bool) (*tunnel, error) {
+	local := fmt.Sprintf("http://localhost:%d", port)
+	if localTLS {
+		local = fmt.Sprintf("https://localhost:%d", port)
+	}
+
+	var args []string
+	var findURL func(line string) string
+	switch provider {
+	case TunnelNgrok:
+		args = []string{"http", local, "--log", "stdout", "--log-format", "json"}
+		findURL = ngrokURL
+	case TunnelCloudflared:
+		args = []string{"tunnel", "--no-autoupdate", "--url", local}
+		if localTLS {
+			args = append(args, "--no-tls-verify")
+		}
+		findURL = func(line string) string {
+			return cloudflaredURLPattern.FindString(line)
+		}
+	default:
+		return nil, fmt.Errorf("unknown tunnel '%s' (use '%s' or '%s')", provider, TunnelNgrok, TunnelCloudflared)
+	}
+
+	path, err := exec.LookPath(provider)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not installed or not on PATH (install it, or use the other tunnel provider)", provider)
+	}
+
+	cmd := exec.Command(path, args...)
+	// ngrok logs to stdout, cloudflared to stderr
+	output, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", provider, err)
+	}
+
+	found := make(chan string, 1)
+	exited := make(chan error, 1)
+	scanned := make(chan struct{})
+	var lastLines []string
+	go func() {
+		defer close(scanned)
+		scanner := bufio.NewScanner(output)
+		reported := false
+		for scanner.Scan() {
+			line := scanner.Text()
+			if reported {
+				continue // Keep draining so the client never blocks
+			}
+			if url := findURL(line); url != "" {
+				found <- url
+				reported = true
+				continue
+			}
+			lastLines = append(lastLines, line)
+			if len(lastLines) > 5 {
+				lastLines = lastLines[1:]
+			}
+		}
+	}()
+	go func() {
+		exited <- cmd.Wait()
+		writer.Close()
+	}()
+
+	select {
+	case url := <-found:
+		return &tunnel{provider: provider, publicURL: url, cmd: cmd}, nil
+	case err := <-exited:
+		<-scanned
+		return nil, fmt.Errorf("%s exited before the tunnel was up: %v\n%s", provider, err, strings.Join(lastLines, "\n"))
+	case <-time.After(tunnelStartTimeout):
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("%s did not report a public URL within %s", provider, tunnelStartTimeout)
+	}
+}
+
+// ngrokURL returns the public URL from an ngrok JSON log line, if it has one
+func ngrokURL(line string) string {
+	var entry struct {
+		Msg string `json:"msg"`
+		URL string `json:"url"`
+	}
+	if json.Unmarshal([]byte(line), &entry) != nil || entry.Msg != "started tunnel" {
+		return ""
+	}
+	return entry.URL
+}
+
+// Stop ends the tunnel client
+func (t *tunnel) Stop() {
+	if t.cmd.Process != nil {
+		t.cmd.Process.Kill()
+	}
+}
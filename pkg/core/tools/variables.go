@@ -11,12 +11,21 @@ import (
 	"github.com/blackcoderx/zap/pkg/core"
 )
 
-// VariableStore manages session and global variables
+// VariableStore manages session and global variables, and the scopes of
+// running test suites
 type VariableStore struct {
-	session map[string]string // In-memory session variables
-	global  map[string]string // Persistent global variables
+	session map[string]string   // In-memory session variables
+	global  map[string]string   // Persistent global variables
+	scopes  []map[string]string // Suite scopes, innermost last; they shadow session and global variables
 	mu      sync.RWMutex
 	zapDir  string // Path to .zap directory
+
+	tokens  map[string]*OAuthToken // OAuth2 tokens by variable name, for renewal
+	renewMu sync.Mutex             // Serializes token renewals
+
+	secret map[string]bool   // Variables marked secret: masked in output, encrypted on disk
+	sealed map[string]string // Encrypted globals that couldn't be decrypted, kept as-is
+	key    []byte            // Key for secret globals, loaded on first use
 }
 
 // NewVariableStore creates a new variable store
@@ -25,16 +34,45 @@ func NewVariableStore(zapDir string) *VariableStore {
 		session: make(map[string]string),
 		global:  make(map[string]string),
 		zapDir:  zapDir,
+		secret:  make(map[string]bool),
+		sealed:  make(map[string]string),
 	}
 	store.loadGlobalVariables()
 	return store
 }
 
-// Set stores a variable (default: session scope)
+// Set stores a variable (default: session scope, or the innermost suite
+// scope while a suite runs)
 func (vs *VariableStore) Set(name, value string) {
 	vs.mu.Lock()
 	defer vs.mu.Unlock()
-	vs.session[name] = value
+	if n := len(vs.scopes); n > 0 {
+		vs.scopes[n-1][name] = value
+	} else {
+		vs.session[name] = value
+	}
+	vs.registerSecret(name, value)
+}
+
+// PushScope starts a suite scope: until PopScope, Set writes to it and its
+// variables shadow session and global ones
+func (vs *VariableStore) PushScope() {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.scopes = append(vs.scopes, make(map[string]string))
+}
+
+// PopScope ends the innermost suite scope and returns its variables
+func (vs *VariableStore) PopScope() map[string]string {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	n := len(vs.scopes)
+	if n == 0 {
+		return nil
+	}
+	scope := vs.scopes[n-1]
+	vs.scopes = vs.scopes[:n-1]
+	return scope
 }
 
 // SetGlobal stores a global variable (persisted to disk)
@@ -44,19 +82,51 @@ func (vs *VariableStore) SetGlobal(name, value string) (warning string, err erro
 	defer vs.mu.Unlock()
 
 	// Warn on potential secrets
-	if core.IsSecret(name, value) {
-		warning = fmt.Sprintf("WARNING: '%s' appears to be a secret. Consider using session scope instead (secrets are cleared on exit for security).", name)
+	if !vs.secret[name] && core.IsSecret(name, value) {
+		warning = fmt.Sprintf("WARNING: '%s' appears to be a secret. Set it with \"secret\": true to encrypt it on disk, or use session scope (cleared on exit).", name)
 	}
 
 	vs.global[name] = value
+	delete(vs.sealed, name)
+	vs.registerSecret(name, value)
 	return warning, vs.saveGlobalVariables()
 }
 
-// Get retrieves a variable (checks session first, then global)
+// SetSecret stores a secret variable. Its value is masked in variable output
+// and everywhere ZAP displays or logs text; global secrets are encrypted on disk.
+func (vs *VariableStore) SetSecret(name, value string, global bool) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	vs.secret[name] = true
+	core.RegisterSecretValue(value)
+	if !global {
+		vs.session[name] = value
+		return nil
+	}
+	vs.global[name] = value
+	delete(vs.sealed, name)
+	return vs.saveGlobalVariables()
+}
+
+// IsSecret reports whether a variable is marked secret
+func (vs *VariableStore) IsSecret(name string) bool {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	return vs.secret[name]
+}
+
+// Get retrieves a variable (checks suite scopes first, then session, then global)
 func (vs *VariableStore) Get(name string) (string, bool) {
 	vs.mu.RLock()
 	defer vs.mu.RUnlock()
 
+	for i := len(vs.scopes) - 1; i >= 0; i-- {
+		if value, ok := vs.scopes[i][name]; ok {
+			return value, true
+		}
+	}
+
 	// Check session first
 	if value, ok := vs.session[name]; ok {
 		return value, true
@@ -70,12 +140,31 @@ func (vs *VariableStore) Get(name string) (string, bool) {
 	return "", false
 }
 
+// GetList returns a list variable: one holding a JSON array, as extract_value
+// saves when it extracts several values
+func (vs *VariableStore) GetList(name string) ([]interface{}, bool) {
+	value, ok := vs.Get(name)
+	if !ok || !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		return nil, false
+	}
+	var list []interface{}
+	if err := json.Unmarshal([]byte(value), &list); err != nil {
+		return nil, false
+	}
+	return list, true
+}
+
 // Delete removes a variable
 func (vs *VariableStore) Delete(name string) {
 	vs.mu.Lock()
 	defer vs.mu.Unlock()
+	for _, scope := range vs.scopes {
+		delete(scope, name)
+	}
 	delete(vs.session, name)
 	delete(vs.global, name)
+	delete(vs.sealed, name)
+	delete(vs.secret, name)
 	vs.saveGlobalVariables()
 }
 
@@ -87,21 +176,46 @@ func (vs *VariableStore) List() map[string]string {
 	result := make(map[string]string)
 	// Global first
 	for k, v := range vs.global {
+		if vs.secret[k] {
+			v = maskedSecretValue
+		}
 		result[k] = v + " (global)"
 	}
+	for k := range vs.sealed {
+		result[k] = maskedSecretValue + " (global, can't decrypt: check the OS keyring or .zap/" + secretVariablesKeyFile + ")"
+	}
 	// Session overrides global
 	for k, v := range vs.session {
+		if vs.secret[k] {
+			v = maskedSecretValue
+		}
 		result[k] = v + " (session)"
 	}
+	// Suite scopes override both
+	for _, scope := range vs.scopes {
+		for k, v := range scope {
+			if vs.secret[k] {
+				v = maskedSecretValue
+			}
+			result[k] = v + " (suite)"
+		}
+	}
 	return result
 }
 
-// Substitute replaces {{VAR}} placeholders in text with variable values
+// Substitute replaces {{VAR}} placeholders in text with variable values,
+// then built-in functions like {{uuid}} with generated values
 func (vs *VariableStore) Substitute(text string) string {
 	vs.mu.RLock()
 	defer vs.mu.RUnlock()
 
 	result := text
+	// Replace suite variables, innermost scope first
+	for i := len(vs.scopes) - 1; i >= 0; i-- {
+		for name, value := range vs.scopes[i] {
+			result = strings.ReplaceAll(result, "{{"+name+"}}", value)
+		}
+	}
 	// Replace session variables
 	for name, value := range vs.session {
 		placeholder := "{{" + name + "}}"
@@ -112,7 +226,7 @@ func (vs *VariableStore) Substitute(text string) string {
 		placeholder := "{{" + name + "}}"
 		result = strings.ReplaceAll(result, placeholder, value)
 	}
-	return result
+	return expandTemplateFuncs(result)
 }
 
 // loadGlobalVariables reads global variables from disk
@@ -126,13 +240,75 @@ func (vs *VariableStore) loadGlobalVariables() error {
 		return err
 	}
 
-	return json.Unmarshal(data, &vs.global)
+	var stored map[string]string
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	for name, value := range stored {
+		if strings.HasPrefix(value, encryptedValuePrefix) {
+			vs.secret[name] = true
+			plain, err := vs.openSecret(value)
+			if err != nil {
+				// Keep it so saving other variables doesn't drop it
+				vs.sealed[name] = value
+				continue
+			}
+			value = plain
+		}
+		vs.global[name] = value
+		vs.registerSecret(name, value)
+	}
+	return nil
+}
+
+// openSecret decrypts an encrypted global variable. Callers hold vs.mu.
+func (vs *VariableStore) openSecret(sealed string) (string, error) {
+	key, err := vs.secretKey(false)
+	if err != nil {
+		return "", err
+	}
+	return openValue(key, sealed)
+}
+
+// registerSecret masks a variable's value in displayed output and logs when
+// it is marked secret or has a secret-sounding name. Callers hold vs.mu.
+func (vs *VariableStore) registerSecret(name, value string) {
+	if vs.secret[name] {
+		core.RegisterSecretValue(value)
+		return
+	}
+	registerSecret(name, value)
+}
+
+// registerSecret masks the value of a variable with a secret-sounding name
+// (token, password, api_key, ...) in displayed output and logs
+func registerSecret(name, value string) {
+	if core.IsSensitiveKey(name) {
+		core.RegisterSecretValue(value)
+	}
 }
 
 // saveGlobalVariables writes global variables to disk
 func (vs *VariableStore) saveGlobalVariables() error {
+	stored := make(map[string]string, len(vs.global)+len(vs.sealed))
+	for name, value := range vs.global {
+		if vs.secret[name] {
+			key, err := vs.secretKey(true)
+			if err != nil {
+				return err
+			}
+			if value, err = sealValue(key, value); err != nil {
+				return err
+			}
+		}
+		stored[name] = value
+	}
+	for name, value := range vs.sealed {
+		stored[name] = value
+	}
+
 	varFile := filepath.Join(vs.zapDir, "variables.json")
-	data, err := json.MarshalIndent(vs.global, "", "  ")
+	data, err := json.MarshalIndent(stored, "", "  ")
 	if err != nil {
 		return err
 	}
@@ -161,7 +337,8 @@ type VariableParams struct {
 	Action string `json:"action"` // "set", "get", "delete", "list"
 	Name   string `json:"name,omitempty"`
 	Value  string `json:"value,omitempty"`
-	Scope  string `json:"scope,omitempty"` // "session" (default) or "global"
+	Scope  string `json:"scope,omitempty"`  // "session" (default) or "global"
+	Secret bool   `json:"secret,omitempty"` // Mask the value; encrypt it on disk if global
 }
 
 // Name returns the tool name
@@ -171,7 +348,7 @@ func (t *VariableTool) Name() string {
 
 // Description returns the tool description
 func (t *VariableTool) Description() string {
-	return "Manage session and global variables for storing values across requests. Actions: set, get, delete, list"
+	return "Manage session and global variables for storing values across requests. Actions: set, get, delete, list. Set \"secret\": true for credentials: the value is masked in all output and encrypted on disk"
 }
 
 // Parameters returns the tool parameter description
@@ -180,7 +357,8 @@ func (t *VariableTool) Parameters() string {
   "action": "set|get|delete|list",
   "name": "variable_name",
   "value": "variable_value",
-  "scope": "session|global"
+  "scope": "session|global",
+  "secret": true
 }`
 }
 
@@ -200,6 +378,16 @@ func (t *VariableTool) Execute(args string) (string, error) {
 			return "", fmt.Errorf("'value' is required for set action")
 		}
 
+		if params.Secret {
+			if err := t.store.SetSecret(params.Name, params.Value, params.Scope == "global"); err != nil {
+				return "", fmt.Errorf("failed to set secret variable: %w", err)
+			}
+			if params.Scope == "global" {
+				return fmt.Sprintf("Set secret global variable: {{%s}} = '%s'\n(Encrypted on disk, masked in all output)", params.Name, maskedSecretValue), nil
+			}
+			return fmt.Sprintf("Set secret session variable: {{%s}} = '%s'\n(Available until ZAP exits, masked in all output)", params.Name, maskedSecretValue), nil
+		}
+
 		if params.Scope == "global" {
 			warning, err := t.store.SetGlobal(params.Name, params.Value)
 			if err != nil {
@@ -224,6 +412,9 @@ func (t *VariableTool) Execute(args string) (string, error) {
 		if !ok {
 			return "", fmt.Errorf("variable '{{%s}}' not found", params.Name)
 		}
+		if t.store.IsSecret(params.Name) {
+			return fmt.Sprintf("Variable {{%s}} = '%s' (secret; use {{%s}} in requests)", params.Name, maskedSecretValue, params.Name), nil
+		}
 		return fmt.Sprintf("Variable {{%s}} = '%s'", params.Name, value), nil
 
 	case "delete":
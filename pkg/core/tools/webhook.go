@@ -2,17 +2,24 @@ package tools
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/blackcoderx/zap/pkg/core"
 )
 
 // WebhookListenerTool provides webhook capture capabilities
 type WebhookListenerTool struct {
+	progressReporter
 	varStore *VariableStore
 	mu       sync.Mutex
 	servers  map[string]*webhookServer
@@ -20,20 +27,27 @@ type WebhookListenerTool struct {
 
 // webhookServer represents a running webhook listener
 type webhookServer struct {
-	server   *http.Server
-	requests []CapturedRequest
-	url      string
-	mu       sync.Mutex
-	done     chan struct{}
+	server    *http.Server
+	requests  []CapturedRequest
+	url       string
+	mu        sync.Mutex
+	done      chan struct{}
+	received  chan struct{} // Signalled on each captured request
+	stopsAt   time.Time     // When the listener shuts itself down
+	tunnel    *tunnel       // Public tunnel (nil when not requested)
+	path      string        // Path the listener serves (sub-paths included)
+	forwardTo string        // Pass captured requests on to this URL (empty: answer 200 itself)
 }
 
 // CapturedRequest represents a captured webhook request
 type CapturedRequest struct {
 	Method    string            `json:"method"`
 	Path      string            `json:"path"`
+	Query     string            `json:"query,omitempty"`
 	Headers   map[string]string `json:"headers"`
 	Body      string            `json:"body"`
 	Timestamp time.Time         `json:"timestamp"`
+	Forward   *WebhookForward   `json:"forward,omitempty"` // Set when the listener forwarded the request
 }
 
 // NewWebhookListenerTool creates a new webhook listener tool
@@ -51,17 +65,26 @@ func (t *WebhookListenerTool) Name() string {
 
 // Description returns the tool description
 func (t *WebhookListenerTool) Description() string {
-	return "Start a temporary HTTP server to capture incoming webhook requests. Returns the URL to use for webhooks and captures all incoming requests."
+	return "Start a temporary HTTP server to capture incoming webhook requests. Returns the URL to use for webhooks and captures all incoming requests. Use 'wait_for_request' to block until a request matching a method/path/body predicate arrives, and 'assert' to check the captured requests. With forward_to it passes requests on to a local handler and relays its response; 'replay' resends any captured request. Can serve HTTPS with a self-signed certificate and expose the listener on a public HTTPS URL through ngrok or cloudflared, for services that only call public HTTPS endpoints."
 }
 
 // Parameters returns the tool parameter description
 func (t *WebhookListenerTool) Parameters() string {
 	return `{
-  "action": "start|stop|get_requests",
+  "action": "start|wait|stop|get_requests",
   "port": 0,
   "path": "/webhook",
   "timeout_seconds": 60,
-  "listener_id": "webhook_1"
+  "listener_id": "webhook_1",
+  "tls": false,
+  "tunnel": "ngrok|cloudflared (optional public HTTPS URL)",
+  "forward_to": "http://localhost:3000/webhooks (optional: pass requests on to your handler)",
+  "request_number": 1,
+  "target": "http://localhost:3000/webhooks (for replay; default forward_to)",
+  "count": 1,
+  "wait_seconds": 30,
+  "match": {"method": "POST", "path": "/webhook", "headers": {"X-Event": "order.paid"}, "body_contains": ["order_id"], "json_path": {"$.status": "paid"}},
+  "expect": {"headers": {"X-Signature": "sha256="}, "json_path_exists": ["$.order_id"]}
 }`
 }
 
@@ -72,6 +95,17 @@ type WebhookListenerParams struct {
 	Path           string `json:"path,omitempty"`
 	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
 	ListenerID     string `json:"listener_id,omitempty"`
+	TLS            bool   `json:"tls,omitempty"`          // Serve HTTPS with a self-signed certificate
+	Tunnel         string `json:"tunnel,omitempty"`       // Expose publicly via "ngrok" or "cloudflared"
+	ForwardTo      string `json:"forward_to,omitempty"`   // Pass captured requests on to this URL and relay its response
+	Count          int    `json:"count,omitempty"`        // For wait: requests to wait for (default 1); for assert: exact number expected
+	WaitSeconds    int    `json:"wait_seconds,omitempty"` // For wait: give up after this long (default: until the listener stops)
+
+	Match  *RequestMatch `json:"match,omitempty"`  // For wait and assert: which requests count
+	Expect *AssertParams `json:"expect,omitempty"` // For assert: criteria every matching request must meet
+
+	RequestNumber int    `json:"request_number,omitempty"` // For replay: the captured request (from 1)
+	Target        string `json:"target,omitempty"`         // For replay: where to send it (default: forward_to)
 }
 
 // Execute runs the webhook listener command
@@ -95,12 +129,18 @@ func (t *WebhookListenerTool) Execute(args string) (string, error) {
 	switch params.Action {
 	case "start":
 		return t.startListener(params)
+	case "wait", "wait_for_request":
+		return t.waitForRequests(params)
+	case "assert":
+		return t.assertRequests(params)
+	case "replay":
+		return t.replayRequest(params)
 	case "stop":
 		return t.stopListener(params.ListenerID)
 	case "get_requests":
 		return t.getRequests(params.ListenerID)
 	default:
-		return "", fmt.Errorf("unknown action: %s (use 'start', 'stop', or 'get_requests')", params.Action)
+		return "", fmt.Errorf("unknown action: %s (use 'start', 'wait', 'wait_for_request', 'assert', 'replay', 'stop', or 'get_requests')", params.Action)
 	}
 }
 
@@ -113,6 +153,11 @@ func (t *WebhookListenerTool) startListener(params WebhookListenerParams) (strin
 	if _, exists := t.servers[params.ListenerID]; exists {
 		return "", fmt.Errorf("listener '%s' already running. Stop it first or use a different listener_id", params.ListenerID)
 	}
+	if params.ForwardTo != "" {
+		if u, err := url.Parse(params.ForwardTo); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return "", fmt.Errorf("forward_to must be an http(s) URL, got '%s'", params.ForwardTo)
+		}
+	}
 
 	// Create listener
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", params.Port))
@@ -124,50 +169,68 @@ func (t *WebhookListenerTool) startListener(params WebhookListenerParams) (strin
 	addr := listener.Addr().(*net.TCPAddr)
 	actualPort := addr.Port
 
+	scheme, fingerprint := "http", ""
+	if params.TLS {
+		cert, sum, err := selfSignedCert(time.Duration(params.TimeoutSeconds)*time.Second + time.Hour)
+		if err != nil {
+			listener.Close()
+			return "", fmt.Errorf("failed to create TLS certificate: %w", err)
+		}
+		listener = tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{cert}})
+		scheme, fingerprint = "https", sum
+	}
+
 	// Create webhook server
 	ws := &webhookServer{
-		requests: make([]CapturedRequest, 0),
-		url:      fmt.Sprintf("http://localhost:%d%s", actualPort, params.Path),
-		done:     make(chan struct{}),
+		requests:  make([]CapturedRequest, 0),
+		url:       fmt.Sprintf("%s://localhost:%d%s", scheme, actualPort, params.Path),
+		done:      make(chan struct{}),
+		received:  make(chan struct{}, 1),
+		stopsAt:   time.Now().Add(time.Duration(params.TimeoutSeconds) * time.Second),
+		path:      params.Path,
+		forwardTo: params.ForwardTo,
 	}
 
 	// Create HTTP handler
 	mux := http.NewServeMux()
-	mux.HandleFunc(params.Path, func(w http.ResponseWriter, r *http.Request) {
-		// Read body
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			body = []byte(fmt.Sprintf("Error reading body: %v", err))
-		}
-		defer r.Body.Close()
-
-		// Capture headers
-		headers := make(map[string]string)
-		for key, values := range r.Header {
-			if len(values) > 0 {
-				headers[key] = values[0]
-			}
-		}
+	capture := func(w http.ResponseWriter, r *http.Request) {
+		captured := captureRequest(r)
 
 		// Store request
 		ws.mu.Lock()
-		ws.requests = append(ws.requests, CapturedRequest{
-			Method:    r.Method,
-			Path:      r.URL.Path,
-			Headers:   headers,
-			Body:      string(body),
-			Timestamp: time.Now(),
-		})
+		ws.requests = append(ws.requests, captured)
+		index := len(ws.requests) - 1
 		ws.mu.Unlock()
+		select {
+		case ws.received <- struct{}{}:
+		default:
+		}
+
+		if ws.forwardTo != "" {
+			resp, record := forwardRequest(forwardTarget(ws.forwardTo, ws.path, captured), captured)
+			ws.mu.Lock()
+			ws.requests[index].Forward = record
+			ws.mu.Unlock()
+			writeForwarded(w, resp, record)
+			return
+		}
 
 		// Send success response
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"status":"received"}`))
-	})
+	}
+	mux.HandleFunc(params.Path, capture)
+	if !strings.HasSuffix(params.Path, "/") {
+		// Sub-paths too, e.g. /webhook/orders
+		mux.HandleFunc(params.Path+"/", capture)
+	}
 
 	// Create server
+	// Discard handshake errors (e.g. clients rejecting the self-signed
+	// certificate), which would otherwise be printed over the TUI
 	ws.server = &http.Server{
-		Handler: mux,
+		Handler:  mux,
+		ErrorLog: log.New(io.Discard, "", 0),
 	}
 
 	// Start server in background
@@ -175,6 +238,15 @@ func (t *WebhookListenerTool) startListener(params WebhookListenerParams) (strin
 		ws.server.Serve(listener)
 	}()
 
+	if params.Tunnel != "" {
+		tun, err := startTunnel(params.Tunnel, actualPort, params.TLS)
+		if err != nil {
+			ws.server.Close()
+			return "", fmt.Errorf("failed to open tunnel: %w", err)
+		}
+		ws.tunnel = tun
+	}
+
 	// Auto-shutdown after timeout
 	go func() {
 		select {
@@ -191,23 +263,44 @@ func (t *WebhookListenerTool) startListener(params WebhookListenerParams) (strin
 	// Save URL to variables if varStore available
 	if t.varStore != nil {
 		t.varStore.Set(fmt.Sprintf("%s_url", params.ListenerID), ws.url)
+		if ws.tunnel != nil {
+			t.varStore.Set(fmt.Sprintf("%s_public_url", params.ListenerID), ws.publicURL())
+		}
 	}
 
-	return fmt.Sprintf(`Webhook listener started!
-
-Listener ID: %s
-URL: %s
-Timeout: %d seconds
-Port: %d
+	var sb strings.Builder
+	sb.WriteString("Webhook listener started!\n\n")
+	sb.WriteString(fmt.Sprintf("Listener ID: %s\n", params.ListenerID))
+	sb.WriteString(fmt.Sprintf("URL: %s\n", ws.url))
+	if ws.tunnel != nil {
+		sb.WriteString(fmt.Sprintf("Public URL: %s (via %s)\n", ws.publicURL(), ws.tunnel.provider))
+	}
+	sb.WriteString(fmt.Sprintf("Timeout: %d seconds\n", params.TimeoutSeconds))
+	sb.WriteString(fmt.Sprintf("Port: %d\n", actualPort))
+	if ws.forwardTo != "" {
+		sb.WriteString(fmt.Sprintf("Forwarding to: %s (senders get its response)\n", ws.forwardTo))
+	}
+	if params.TLS {
+		sb.WriteString(fmt.Sprintf("TLS: self-signed certificate, SHA-256 %s\n", fingerprint))
+		sb.WriteString("Clients must skip verification for the local URL, e.g. http_request with \"tls\": {\"insecure_skip_verify\": true}.\n")
+	}
+	if ws.tunnel != nil {
+		sb.WriteString(fmt.Sprintf("\nRegister {{%s_public_url}} with the third-party service. ", params.ListenerID))
+	} else {
+		sb.WriteString("\nSend webhooks to this URL. ")
+	}
+	sb.WriteString("Use 'wait' to block until they arrive, or 'get_requests' to retrieve captured requests.\n")
+	sb.WriteString(fmt.Sprintf("The listener will automatically stop after %d seconds.", params.TimeoutSeconds))
+	return sb.String(), nil
+}
 
-Send webhooks to this URL. Use 'get_requests' to retrieve captured requests.
-The listener will automatically stop after %d seconds.`,
-		params.ListenerID,
-		ws.url,
-		params.TimeoutSeconds,
-		actualPort,
-		params.TimeoutSeconds,
-	), nil
+// publicURL returns the tunnel's public URL for the listener's path
+func (ws *webhookServer) publicURL() string {
+	u, err := url.Parse(ws.url)
+	if err != nil {
+		return ws.tunnel.publicURL
+	}
+	return strings.TrimSuffix(ws.tunnel.publicURL, "/") + u.Path
 }
 
 // stopListener stops a running webhook listener
@@ -224,6 +317,9 @@ func (t *WebhookListenerTool) stopListener(listenerID string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if ws.tunnel != nil {
+		ws.tunnel.Stop()
+	}
 	if err := ws.server.Shutdown(ctx); err != nil {
 		return "", fmt.Errorf("failed to shutdown listener: %w", err)
 	}
@@ -242,6 +338,90 @@ func (t *WebhookListenerTool) stopListener(listenerID string) (string, error) {
 	return fmt.Sprintf("Listener '%s' stopped. Captured %d request(s).", listenerID, requestCount), nil
 }
 
+// waitForRequests blocks until a listener has captured params.Count
+// requests matching params.Match (earlier ones included), reporting progress
+// meanwhile, then returns them
+func (t *WebhookListenerTool) waitForRequests(params WebhookListenerParams) (string, error) {
+	ws, err := t.listener(params.ListenerID)
+	if err != nil {
+		return "", err
+	}
+	if params.Count <= 0 {
+		params.Count = 1
+	}
+
+	start := time.Now()
+	deadline := ws.stopsAt
+	if params.WaitSeconds > 0 {
+		deadline = start.Add(time.Duration(params.WaitSeconds) * time.Second)
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for {
+		matched := ws.matchingRequests(params.Match)
+		if len(matched) >= params.Count {
+			return t.formatMatched(params, matched), nil
+		}
+		select {
+		case <-ws.received:
+		case now := <-ticker.C:
+			t.reportProgress(core.ProgressEvent{
+				Tool:      t.Name(),
+				Completed: int64(len(matched)),
+				Total:     int64(params.Count),
+				Unit:      "webhooks",
+				Elapsed:   now.Sub(start),
+				Remaining: max(0, deadline.Sub(now)),
+			})
+		case <-timer.C:
+			return "", fmt.Errorf("received %d of %d %s on %s within %s (%d request(s) captured in total)",
+				len(matched), params.Count, params.Match.describe(), ws.url, time.Since(start).Round(time.Second), ws.requestCount())
+		case <-ws.done:
+			return "", fmt.Errorf("listener '%s' stopped after receiving %d of %d %s",
+				params.ListenerID, len(ws.matchingRequests(params.Match)), params.Count, params.Match.describe())
+		}
+	}
+}
+
+// formatMatched renders the requests a wait was for and saves them as
+// {{<listener_id>_matched}}
+func (t *WebhookListenerTool) formatMatched(params WebhookListenerParams, matched []numberedRequest) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Received %d %s on listener '%s':\n\n", len(matched), params.Match.describe(), params.ListenerID))
+	requests := make([]CapturedRequest, len(matched))
+	for i, req := range matched {
+		sb.WriteString(formatCapturedRequest(req))
+		requests[i] = req.CapturedRequest
+	}
+	if t.varStore != nil {
+		if data, err := json.Marshal(requests); err == nil {
+			t.varStore.Set(fmt.Sprintf("%s_matched", params.ListenerID), string(data))
+		}
+	}
+	return sb.String()
+}
+
+// listener returns a running listener by ID
+func (t *WebhookListenerTool) listener(listenerID string) (*webhookServer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ws, exists := t.servers[listenerID]
+	if !exists {
+		return nil, fmt.Errorf("listener '%s' not found. Start it first", listenerID)
+	}
+	return ws, nil
+}
+
+// requestCount returns how many requests the listener has captured
+func (ws *webhookServer) requestCount() int {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return len(ws.requests)
+}
+
 // getRequests retrieves captured requests from a listener
 func (t *WebhookListenerTool) getRequests(listenerID string) (string, error) {
 	t.mu.Lock()
@@ -263,22 +443,7 @@ func (t *WebhookListenerTool) getRequests(listenerID string) (string, error) {
 	output := fmt.Sprintf("Captured %d request(s) for listener '%s':\n\n", len(ws.requests), listenerID)
 
 	for i, req := range ws.requests {
-		output += fmt.Sprintf("Request #%d (%s)\n", i+1, req.Timestamp.Format("15:04:05"))
-		output += fmt.Sprintf("  Method: %s\n", req.Method)
-		output += fmt.Sprintf("  Path: %s\n", req.Path)
-
-		if len(req.Headers) > 0 {
-			output += "  Headers:\n"
-			for key, value := range req.Headers {
-				output += fmt.Sprintf("    %s: %s\n", key, value)
-			}
-		}
-
-		if req.Body != "" {
-			output += fmt.Sprintf("  Body: %s\n", req.Body)
-		}
-
-		output += "\n"
+		output += formatCapturedRequest(numberedRequest{number: i + 1, CapturedRequest: req})
 	}
 
 	// Store requests in variables if varStore available
@@ -301,3 +466,100 @@ func (t *WebhookListenerTool) Cleanup() {
 		t.stopListener(id)
 	}
 }
+
+// captureRequest records an incoming request for later inspection
+func captureRequest(r *http.Request) CapturedRequest {
+	// Read body
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		body = []byte(fmt.Sprintf("Error reading body: %v", err))
+	}
+	defer r.Body.Close()
+
+	// Capture headers
+	headers := make(map[string]string)
+	for key, values := range r.Header {
+		if len(values) > 0 {
+			headers[key] = values[0]
+		}
+	}
+
+	return CapturedRequest{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Query:     r.URL.RawQuery,
+		Headers:   headers,
+		Body:      string(body),
+		Timestamp: time.Now(),
+	}
+}
+
+// CallbackListener is a one-shot local listener that captures the first
+// request to its URL, such as an OAuth2 redirect back from the browser.
+type CallbackListener struct {
+	URL      string
+	server   *http.Server
+	received chan CapturedRequest
+}
+
+// StartCallbackListener listens on the loopback interface for rawURL's port
+// and path; an empty rawURL picks a free port and /callback. response is the
+// HTML page shown to whoever makes the request.
+func StartCallbackListener(rawURL, response string) (*CallbackListener, error) {
+	port, path := "0", "/callback"
+	if rawURL != "" {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid callback URL: %w", err)
+		}
+		if u.Scheme != "http" || (u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1") {
+			return nil, fmt.Errorf("callback URL must be http://localhost:<port>/<path>, got %s", rawURL)
+		}
+		if port = u.Port(); port == "" {
+			port = "80"
+		}
+		if u.Path != "" {
+			path = u.Path
+		}
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start callback listener: %w", err)
+	}
+
+	l := &CallbackListener{
+		URL:      fmt.Sprintf("http://localhost:%d%s", listener.Addr().(*net.TCPAddr).Port, path),
+		received: make(chan CapturedRequest, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case l.received <- captureRequest(r):
+		default:
+			// Only the first callback counts
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(response))
+	})
+	l.server = &http.Server{Handler: mux}
+	go l.server.Serve(listener)
+	return l, nil
+}
+
+// Wait blocks until the callback arrives or the timeout passes
+func (l *CallbackListener) Wait(timeout time.Duration) (*CapturedRequest, error) {
+	select {
+	case req := <-l.received:
+		return &req, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("no callback received on %s within %s", l.URL, timeout)
+	}
+}
+
+// Close shuts the listener down
+func (l *CallbackListener) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	l.server.Shutdown(ctx)
+}
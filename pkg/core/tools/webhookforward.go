@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// webhookForwardTimeout bounds a forwarded or replayed request
+const webhookForwardTimeout = 30 * time.Second
+
+// webhookSkipHeaders are not copied when forwarding: hop-by-hop headers and
+// those the client sets itself
+var webhookSkipHeaders = map[string]bool{
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"Te":                true,
+	"Trailer":           true,
+	"Host":              true,
+	"Content-Length":    true,
+	"Accept-Encoding":   true,
+}
+
+var webhookClient = &http.Client{Timeout: webhookForwardTimeout}
+
+// WebhookForward records what happened when a request was passed on
+type WebhookForward struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// forwardedResponse is the target's answer to a forwarded request
+type forwardedResponse struct {
+	status  int
+	headers http.Header
+	body    []byte
+}
+
+// forwardTarget maps a captured path onto target: whatever follows the
+// listener's path is appended to it, and the query is kept
+func forwardTarget(target, listenerPath string, req CapturedRequest) string {
+	u := strings.TrimSuffix(target, "/") + strings.TrimPrefix(req.Path, strings.TrimSuffix(listenerPath, "/"))
+	if req.Query != "" {
+		if strings.Contains(u, "?") {
+			u += "&" + req.Query
+		} else {
+			u += "?" + req.Query
+		}
+	}
+	return u
+}
+
+// forwardRequest sends a captured request to target
+func forwardRequest(target string, req CapturedRequest) (*forwardedResponse, *WebhookForward) {
+	record := &WebhookForward{URL: target}
+	start := time.Now()
+	defer func() {
+		record.DurationMs = time.Since(start).Milliseconds()
+	}()
+
+	httpReq, err := http.NewRequest(req.Method, target, strings.NewReader(req.Body))
+	if err != nil {
+		record.Error = fmt.Sprintf("failed to create request: %v", err)
+		return nil, record
+	}
+	for key, value := range req.Headers {
+		if !webhookSkipHeaders[http.CanonicalHeaderKey(key)] {
+			httpReq.Header.Set(key, value)
+		}
+	}
+
+	resp, err := webhookClient.Do(httpReq)
+	if err != nil {
+		record.Error = err.Error()
+		return nil, record
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		record.Error = fmt.Sprintf("failed to read response: %v", err)
+		return nil, record
+	}
+	record.StatusCode = resp.StatusCode
+	return &forwardedResponse{status: resp.StatusCode, headers: resp.Header, body: body}, record
+}
+
+// writeForwarded relays the target's answer to the webhook sender, or a 502
+// when the target couldn't be reached
+func writeForwarded(w http.ResponseWriter, resp *forwardedResponse, record *WebhookForward) {
+	if resp == nil {
+		http.Error(w, fmt.Sprintf("forwarding to %s failed: %s", record.URL, record.Error), http.StatusBadGateway)
+		return
+	}
+	for key, values := range resp.headers {
+		if webhookSkipHeaders[key] {
+			continue
+		}
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.status)
+	w.Write(resp.body)
+}
+
+// replayRequest sends a captured request again, to params.Target or the
+// listener's forward_to URL
+func (t *WebhookListenerTool) replayRequest(params WebhookListenerParams) (string, error) {
+	ws, err := t.listener(params.ListenerID)
+	if err != nil {
+		return "", err
+	}
+	ws.mu.Lock()
+	count := len(ws.requests)
+	var req CapturedRequest
+	if params.RequestNumber >= 1 && params.RequestNumber <= count {
+		req = ws.requests[params.RequestNumber-1]
+	}
+	ws.mu.Unlock()
+	if count == 0 {
+		return "", fmt.Errorf("listener '%s' has not captured any requests yet", params.ListenerID)
+	}
+	if params.RequestNumber < 1 || params.RequestNumber > count {
+		return "", fmt.Errorf("request_number must be between 1 and %d", count)
+	}
+
+	target := params.Target
+	if target == "" {
+		target = ws.forwardTo
+	}
+	if target == "" {
+		return "", fmt.Errorf("target is required (the listener has no forward_to URL)")
+	}
+	if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("target must be an http(s) URL, got '%s'", target)
+	}
+	target = forwardTarget(target, ws.path, req)
+
+	resp, record := forwardRequest(target, req)
+	if resp == nil {
+		return "", fmt.Errorf("replay of request #%d to %s failed: %s", params.RequestNumber, target, record.Error)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Replayed request #%d (%s %s) to %s\n", params.RequestNumber, req.Method, req.Path, target))
+	sb.WriteString(fmt.Sprintf("Status: %d %s (%dms)\n", resp.status, http.StatusText(resp.status), record.DurationMs))
+	if len(resp.body) > 0 {
+		sb.WriteString(fmt.Sprintf("Body: %s\n", truncateBody(string(resp.body), 2000)))
+	}
+	return sb.String(), nil
+}
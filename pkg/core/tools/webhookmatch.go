@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RequestMatch selects captured webhook requests. Empty fields match any
+// request; the embedded criteria are those of assert_response (headers,
+// body_contains, json_path, ...) applied to the request's headers and body.
+type RequestMatch struct {
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"` // Exact, or a pattern with :params and a trailing *
+	AssertParams
+}
+
+// numberedRequest is a captured request with its position (from 1) in the
+// order the listener received them
+type numberedRequest struct {
+	number int
+	CapturedRequest
+}
+
+// check returns the reasons req doesn't match (none when it does)
+func (m *RequestMatch) check(req CapturedRequest) []string {
+	if m == nil {
+		return nil
+	}
+	if m.Method != "" && !strings.EqualFold(m.Method, req.Method) {
+		return []string{fmt.Sprintf("method is %s, not %s", req.Method, strings.ToUpper(m.Method))}
+	}
+	if m.Path != "" {
+		if _, ok := matchMockPath(m.Path, req.Path); !ok {
+			return []string{fmt.Sprintf("path is %s, not %s", req.Path, m.Path)}
+		}
+	}
+	result := NewAssertTool(nil).runAssertions(m.AssertParams, requestAsResponse(req))
+	return result.Failures
+}
+
+// describe summarizes the match for messages
+func (m *RequestMatch) describe() string {
+	if m == nil {
+		return "any request"
+	}
+	var parts []string
+	if m.Method != "" {
+		parts = append(parts, strings.ToUpper(m.Method))
+	}
+	if m.Path != "" {
+		parts = append(parts, m.Path)
+	}
+	if len(parts) == 0 {
+		return "requests matching the criteria"
+	}
+	return strings.Join(parts, " ") + " requests"
+}
+
+// requestAsResponse lets assert_response's checks run on a captured request
+func requestAsResponse(req CapturedRequest) *HTTPResponse {
+	return &HTTPResponse{Headers: req.Headers, Body: req.Body}
+}
+
+// matchingRequests returns the captured requests match selects
+func (ws *webhookServer) matchingRequests(match *RequestMatch) []numberedRequest {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	var matched []numberedRequest
+	for i, req := range ws.requests {
+		if len(match.check(req)) == 0 {
+			matched = append(matched, numberedRequest{number: i + 1, CapturedRequest: req})
+		}
+	}
+	return matched
+}
+
+// assertRequests checks the captured requests: how many match, and that each
+// of them meets expect
+func (t *WebhookListenerTool) assertRequests(params WebhookListenerParams) (string, error) {
+	ws, err := t.listener(params.ListenerID)
+	if err != nil {
+		return "", err
+	}
+
+	captured := ws.requestCount()
+	matched := ws.matchingRequests(params.Match)
+	var failures []string
+	checks := 1
+	switch {
+	case params.Count > 0 && len(matched) != params.Count:
+		failures = append(failures, fmt.Sprintf("Expected %d %s, got %d (of %d captured)", params.Count, params.Match.describe(), len(matched), captured))
+	case params.Count == 0 && len(matched) == 0:
+		failures = append(failures, fmt.Sprintf("No %s captured (%d captured in total)", params.Match.describe(), captured))
+	}
+
+	if params.Expect != nil {
+		for _, req := range matched {
+			result := NewAssertTool(nil).runAssertions(*params.Expect, requestAsResponse(req.CapturedRequest))
+			checks += result.TotalChecks
+			for _, failure := range result.Failures {
+				failures = append(failures, fmt.Sprintf("Request #%d: %s", req.number, failure))
+			}
+		}
+	}
+
+	var sb strings.Builder
+	if len(failures) == 0 {
+		sb.WriteString(fmt.Sprintf("✓ All webhook assertions passed (%d matching request(s), %d checks)\n", len(matched), checks))
+		return sb.String(), nil
+	}
+	sb.WriteString(fmt.Sprintf("✗ Webhook assertions failed (%d/%d checks passed)\n\n", checks-len(failures), checks))
+	sb.WriteString("Failures:\n")
+	for i, failure := range failures {
+		sb.WriteString(fmt.Sprintf("  %d. %s\n", i+1, failure))
+	}
+	if len(matched) == 0 && captured > 0 && params.Match != nil {
+		// Say why the requests that did arrive were rejected
+		ws.mu.Lock()
+		sb.WriteString("\nCaptured requests:\n")
+		for i, req := range ws.requests {
+			sb.WriteString(fmt.Sprintf("  #%d %s %s: %s\n", i+1, req.Method, req.Path, strings.Join(params.Match.check(req), "; ")))
+		}
+		ws.mu.Unlock()
+	}
+	return sb.String(), nil
+}
+
+// formatCapturedRequest renders a captured request for the agent
+func formatCapturedRequest(req numberedRequest) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Request #%d (%s)\n", req.number, req.Timestamp.Format("15:04:05")))
+	sb.WriteString(fmt.Sprintf("  Method: %s\n", req.Method))
+	sb.WriteString(fmt.Sprintf("  Path: %s\n", req.Path))
+	if req.Query != "" {
+		sb.WriteString(fmt.Sprintf("  Query: %s\n", req.Query))
+	}
+	if len(req.Headers) > 0 {
+		sb.WriteString("  Headers:\n")
+		for key, value := range req.Headers {
+			sb.WriteString(fmt.Sprintf("    %s: %s\n", key, value))
+		}
+	}
+	if req.Body != "" {
+		sb.WriteString(fmt.Sprintf("  Body: %s\n", req.Body))
+	}
+	if f := req.Forward; f != nil {
+		if f.Error != "" {
+			sb.WriteString(fmt.Sprintf("  Forwarded to %s: failed after %dms: %s\n", f.URL, f.DurationMs, f.Error))
+		} else {
+			sb.WriteString(fmt.Sprintf("  Forwarded to %s: %d (%dms)\n", f.URL, f.StatusCode, f.DurationMs))
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
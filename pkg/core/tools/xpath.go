@@ -0,0 +1,713 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// This is the part of XPath 1.0 that extraction needs: location paths with
+// /, //, *, ., .., @attr, text() and node(), predicates, unions, comparisons,
+// and/or, and the common functions. Axes (child::) and arithmetic are not
+// supported.
+
+// xpathItem is a node, or one of a node's attributes
+type xpathItem struct {
+	node *html.Node
+	attr string
+}
+
+// value is the item's string value
+func (i xpathItem) value() string {
+	if i.attr != "" {
+		v, _ := nodeAttr(i.node, i.attr)
+		return v
+	}
+	return nodeText(i.node)
+}
+
+// xpathCtx is what an expression is evaluated against
+type xpathCtx struct {
+	item      xpathItem
+	pos, size int
+}
+
+// xpathFn is a compiled expression. It returns []xpathItem, string, float64
+// or bool.
+type xpathFn func(ctx *xpathCtx) interface{}
+
+// evalXPath evaluates expr against doc and returns the string value of each
+// matched node or attribute, or of the single value expr computes
+func evalXPath(expr string, doc *html.Node) ([]string, error) {
+	fn, err := compileXPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	result := fn(&xpathCtx{item: xpathItem{node: doc}, pos: 1, size: 1})
+	items, ok := result.([]xpathItem)
+	if !ok {
+		return []string{xpathString(result)}, nil
+	}
+	values := make([]string, len(items))
+	for i, item := range items {
+		values[i] = item.value()
+	}
+	return values, nil
+}
+
+func compileXPath(expr string) (xpathFn, error) {
+	tokens, err := lexXPath(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath %q: %w", expr, err)
+	}
+	p := &xpathParser{tokens: tokens}
+	fn, err := p.parseOr()
+	if err == nil && p.peek().text != "" {
+		err = fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath %q: %w", expr, err)
+	}
+	return fn, nil
+}
+
+// Token kinds of XPath expressions
+const (
+	xpSymbol = iota
+	xpName
+	xpString
+	xpNumber
+)
+
+type xpToken struct {
+	kind int
+	text string
+}
+
+func lexXPath(src string) ([]xpToken, error) {
+	var tokens []xpToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(src[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, xpToken{xpString, src[i+1 : i+1+end]})
+			i += end + 2
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, xpToken{xpNumber, src[start:i]})
+		case isXPathNameStart(c):
+			start := i
+			for i < len(src) && (isXPathNameStart(src[i]) || src[i] >= '0' && src[i] <= '9' || src[i] == '-' || src[i] == '.' || src[i] == ':') {
+				i++
+			}
+			tokens = append(tokens, xpToken{xpName, src[start:i]})
+		default:
+			symbol := ""
+			for _, s := range []string{"//", "..", "!=", "<=", ">=", "/", ".", "[", "]", "(", ")", "@", ",", "|", "=", "<", ">", "*"} {
+				if strings.HasPrefix(src[i:], s) {
+					symbol = s
+					break
+				}
+			}
+			if symbol == "" {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, xpToken{xpSymbol, symbol})
+			i += len(symbol)
+		}
+	}
+	return tokens, nil
+}
+
+func isXPathNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+type xpathParser struct {
+	tokens []xpToken
+	pos    int
+}
+
+func (p *xpathParser) peek() xpToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return xpToken{}
+}
+
+func (p *xpathParser) next() xpToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *xpathParser) isSymbol(s string) bool {
+	tok := p.peek()
+	return tok.kind == xpSymbol && tok.text == s
+}
+
+func (p *xpathParser) expect(s string) error {
+	if !p.isSymbol(s) {
+		if p.peek().text == "" {
+			return fmt.Errorf("expected %q at the end", s)
+		}
+		return fmt.Errorf("expected %q, got %q", s, p.peek().text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *xpathParser) parseOr() (xpathFn, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == xpName && p.peek().text == "or" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(ctx *xpathCtx) interface{} { return xpathBool(l(ctx)) || xpathBool(right(ctx)) }
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseAnd() (xpathFn, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == xpName && p.peek().text == "and" {
+		p.pos++
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(ctx *xpathCtx) interface{} { return xpathBool(l(ctx)) && xpathBool(right(ctx)) }
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseComparison() (xpathFn, error) {
+	left, err := p.parseUnion()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if tok.kind != xpSymbol {
+		return left, nil
+	}
+	switch tok.text {
+	case "=", "!=", "<", "<=", ">", ">=":
+		p.pos++
+		right, err := p.parseUnion()
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx *xpathCtx) interface{} { return xpathCompare(tok.text, left(ctx), right(ctx)) }, nil
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parseUnion() (xpathFn, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isSymbol("|") {
+		p.pos++
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(ctx *xpathCtx) interface{} {
+			a, _ := l(ctx).([]xpathItem)
+			b, _ := right(ctx).([]xpathItem)
+			return appendUnique(a, b)
+		}
+	}
+	return left, nil
+}
+
+func (p *xpathParser) parsePrimary() (xpathFn, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == xpString:
+		p.pos++
+		return func(*xpathCtx) interface{} { return tok.text }, nil
+	case tok.kind == xpNumber:
+		p.pos++
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok.text)
+		}
+		return func(*xpathCtx) interface{} { return n }, nil
+	case tok.kind == xpSymbol && tok.text == "(":
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case tok.kind == xpName && tok.text != "text" && tok.text != "node" &&
+		p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "(":
+		return p.parseCall()
+	}
+	return p.parsePath()
+}
+
+// xpathAxis says which items a step looks at from its context item
+type xpathAxis int
+
+const (
+	axisChild xpathAxis = iota
+	axisDescendantOrSelf
+	axisSelf
+	axisParent
+	axisAttribute
+	axisText
+	axisNode
+)
+
+type xpathStep struct {
+	axis  xpathAxis
+	name  string
+	preds []xpathFn
+}
+
+func (p *xpathParser) parsePath() (xpathFn, error) {
+	absolute := false
+	var steps []xpathStep
+	switch {
+	case p.isSymbol("/"):
+		p.pos++
+		absolute = true
+		// "/" alone selects the document
+		if !p.startsStep() {
+			return pathFn(absolute, steps), nil
+		}
+	case p.isSymbol("//"):
+		p.pos++
+		absolute = true
+		steps = append(steps, xpathStep{axis: axisDescendantOrSelf})
+	}
+
+	for {
+		step, err := p.parseStep()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+		if p.isSymbol("/") {
+			p.pos++
+		} else if p.isSymbol("//") {
+			p.pos++
+			steps = append(steps, xpathStep{axis: axisDescendantOrSelf})
+		} else {
+			return pathFn(absolute, steps), nil
+		}
+	}
+}
+
+func (p *xpathParser) startsStep() bool {
+	tok := p.peek()
+	if tok.kind == xpName {
+		return true
+	}
+	return tok.kind == xpSymbol && (tok.text == "." || tok.text == ".." || tok.text == "@" || tok.text == "*")
+}
+
+func (p *xpathParser) parseStep() (xpathStep, error) {
+	var step xpathStep
+	tok := p.next()
+	switch {
+	case tok.kind == xpSymbol && tok.text == ".":
+		return xpathStep{axis: axisSelf}, nil
+	case tok.kind == xpSymbol && tok.text == "..":
+		return xpathStep{axis: axisParent}, nil
+	case tok.kind == xpSymbol && tok.text == "@":
+		name := p.next()
+		if name.kind != xpName && name.text != "*" {
+			return step, fmt.Errorf("expected an attribute name after @")
+		}
+		step = xpathStep{axis: axisAttribute, name: name.text}
+	case tok.kind == xpSymbol && tok.text == "*":
+		step = xpathStep{axis: axisChild, name: "*"}
+	case tok.kind == xpName && (tok.text == "text" || tok.text == "node") && p.isSymbol("("):
+		p.pos++
+		if err := p.expect(")"); err != nil {
+			return step, err
+		}
+		step = xpathStep{axis: axisText}
+		if tok.text == "node" {
+			step.axis = axisNode
+		}
+	case tok.kind == xpName:
+		step = xpathStep{axis: axisChild, name: tok.text}
+	case tok.text == "":
+		return step, fmt.Errorf("expected a step at the end")
+	default:
+		return step, fmt.Errorf("unexpected %q", tok.text)
+	}
+
+	for p.isSymbol("[") {
+		p.pos++
+		pred, err := p.parseOr()
+		if err != nil {
+			return step, err
+		}
+		if err := p.expect("]"); err != nil {
+			return step, err
+		}
+		step.preds = append(step.preds, pred)
+	}
+	return step, nil
+}
+
+func pathFn(absolute bool, steps []xpathStep) xpathFn {
+	return func(ctx *xpathCtx) interface{} {
+		items := []xpathItem{ctx.item}
+		if absolute {
+			root := ctx.item.node
+			for root.Parent != nil {
+				root = root.Parent
+			}
+			items = []xpathItem{{node: root}}
+		}
+		for _, step := range steps {
+			items = step.apply(items)
+		}
+		return items
+	}
+}
+
+// apply returns the items step selects from each of items, in order
+func (s xpathStep) apply(items []xpathItem) []xpathItem {
+	var out []xpathItem
+	for _, item := range items {
+		out = appendUnique(out, s.filter(s.candidates(item)))
+	}
+	return out
+}
+
+func (s xpathStep) candidates(item xpathItem) []xpathItem {
+	var out []xpathItem
+	n := item.node
+	switch s.axis {
+	case axisSelf:
+		return []xpathItem{item}
+	case axisParent:
+		if item.attr != "" {
+			return []xpathItem{{node: n}}
+		}
+		if n.Parent != nil {
+			return []xpathItem{{node: n.Parent}}
+		}
+		return nil
+	case axisDescendantOrSelf:
+		if item.attr != "" {
+			return nil
+		}
+		var walk func(*html.Node)
+		walk = func(n *html.Node) {
+			out = append(out, xpathItem{node: n})
+			for _, c := range elementChildren(n) {
+				walk(c)
+			}
+		}
+		walk(n)
+		return out
+	case axisAttribute:
+		if item.attr != "" {
+			return nil
+		}
+		for _, attr := range n.Attr {
+			if s.name == "*" || strings.EqualFold(localName(s.name), attr.Key) {
+				out = append(out, xpathItem{node: n, attr: attr.Key})
+			}
+		}
+		return out
+	}
+	if item.attr != "" {
+		return nil
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case s.axis == axisText && c.Type == html.TextNode,
+			s.axis == axisNode && (c.Type == html.TextNode || c.Type == html.ElementNode),
+			s.axis == axisChild && c.Type == html.ElementNode && (s.name == "*" || strings.EqualFold(localName(s.name), c.Data)):
+			out = append(out, xpathItem{node: c})
+		}
+	}
+	return out
+}
+
+// filter applies the step's predicates. A number keeps the item at that
+// position; anything else keeps the items it is true for.
+func (s xpathStep) filter(items []xpathItem) []xpathItem {
+	for _, pred := range s.preds {
+		var kept []xpathItem
+		for i, item := range items {
+			v := pred(&xpathCtx{item: item, pos: i + 1, size: len(items)})
+			if n, ok := v.(float64); ok {
+				if n == float64(i+1) {
+					kept = append(kept, item)
+				}
+			} else if xpathBool(v) {
+				kept = append(kept, item)
+			}
+		}
+		items = kept
+	}
+	return items
+}
+
+func appendUnique(items, more []xpathItem) []xpathItem {
+	for _, m := range more {
+		found := false
+		for _, item := range items {
+			if item == m {
+				found = true
+				break
+			}
+		}
+		if !found {
+			items = append(items, m)
+		}
+	}
+	return items
+}
+
+func (p *xpathParser) parseCall() (xpathFn, error) {
+	name := p.next().text
+	p.pos++ // (
+	var args []xpathFn
+	for !p.isSymbol(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.pos++
+
+	fn, ok := xpathFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s()", name)
+	}
+	if len(args) < fn.minArgs || len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("%s() takes %d to %d argument(s), got %d", name, fn.minArgs, fn.maxArgs, len(args))
+	}
+	return func(ctx *xpathCtx) interface{} {
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			values[i] = arg(ctx)
+		}
+		return fn.call(ctx, values)
+	}, nil
+}
+
+type xpathFunc struct {
+	minArgs, maxArgs int
+	call             func(ctx *xpathCtx, args []interface{}) interface{}
+}
+
+// contextString is a function's first argument as text, or the context item's
+// text when there is none
+func contextString(ctx *xpathCtx, args []interface{}) string {
+	if len(args) == 0 {
+		return ctx.item.value()
+	}
+	return xpathString(args[0])
+}
+
+var xpathFuncs = map[string]xpathFunc{
+	"position": {0, 0, func(ctx *xpathCtx, _ []interface{}) interface{} { return float64(ctx.pos) }},
+	"last":     {0, 0, func(ctx *xpathCtx, _ []interface{}) interface{} { return float64(ctx.size) }},
+	"count": {1, 1, func(_ *xpathCtx, args []interface{}) interface{} {
+		items, _ := args[0].([]xpathItem)
+		return float64(len(items))
+	}},
+	"not":   {1, 1, func(_ *xpathCtx, args []interface{}) interface{} { return !xpathBool(args[0]) }},
+	"true":  {0, 0, func(*xpathCtx, []interface{}) interface{} { return true }},
+	"false": {0, 0, func(*xpathCtx, []interface{}) interface{} { return false }},
+	"contains": {2, 2, func(_ *xpathCtx, args []interface{}) interface{} {
+		return strings.Contains(xpathString(args[0]), xpathString(args[1]))
+	}},
+	"starts-with": {2, 2, func(_ *xpathCtx, args []interface{}) interface{} {
+		return strings.HasPrefix(xpathString(args[0]), xpathString(args[1]))
+	}},
+	"ends-with": {2, 2, func(_ *xpathCtx, args []interface{}) interface{} {
+		return strings.HasSuffix(xpathString(args[0]), xpathString(args[1]))
+	}},
+	"concat": {2, 100, func(_ *xpathCtx, args []interface{}) interface{} {
+		var sb strings.Builder
+		for _, arg := range args {
+			sb.WriteString(xpathString(arg))
+		}
+		return sb.String()
+	}},
+	"string": {0, 1, func(ctx *xpathCtx, args []interface{}) interface{} { return contextString(ctx, args) }},
+	"number": {0, 1, func(ctx *xpathCtx, args []interface{}) interface{} {
+		if len(args) == 0 {
+			return xpathNumber(ctx.item.value())
+		}
+		return xpathNumber(args[0])
+	}},
+	"string-length": {0, 1, func(ctx *xpathCtx, args []interface{}) interface{} {
+		return float64(len([]rune(contextString(ctx, args))))
+	}},
+	"normalize-space": {0, 1, func(ctx *xpathCtx, args []interface{}) interface{} {
+		return strings.Join(strings.Fields(contextString(ctx, args)), " ")
+	}},
+	"name":       {0, 1, xpathName},
+	"local-name": {0, 1, xpathName},
+}
+
+// xpathName returns the name of the first item, or of the context item
+func xpathName(ctx *xpathCtx, args []interface{}) interface{} {
+	item := ctx.item
+	if len(args) > 0 {
+		items, _ := args[0].([]xpathItem)
+		if len(items) == 0 {
+			return ""
+		}
+		item = items[0]
+	}
+	if item.attr != "" {
+		return item.attr
+	}
+	if item.node.Type == html.ElementNode {
+		return item.node.Data
+	}
+	return ""
+}
+
+func xpathString(v interface{}) string {
+	switch v := v.(type) {
+	case []xpathItem:
+		if len(v) == 0 {
+			return ""
+		}
+		return v[0].value()
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return strconv.FormatFloat(v, 'f', 0, 64)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+	return ""
+}
+
+func xpathNumber(v interface{}) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(xpathString(v)), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return n
+}
+
+func xpathBool(v interface{}) bool {
+	switch v := v.(type) {
+	case []xpathItem:
+		return len(v) > 0
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+	return false
+}
+
+// xpathCompare compares two values the XPath way: a node set compares true
+// when any of its items does
+func xpathCompare(op string, a, b interface{}) bool {
+	if items, ok := a.([]xpathItem); ok {
+		if _, isBool := b.(bool); isBool {
+			return compareXPathAtoms(op, xpathBool(a), b)
+		}
+		for _, item := range items {
+			if xpathCompare(op, item.value(), b) {
+				return true
+			}
+		}
+		return false
+	}
+	if items, ok := b.([]xpathItem); ok {
+		if _, isBool := a.(bool); isBool {
+			return compareXPathAtoms(op, a, xpathBool(b))
+		}
+		for _, item := range items {
+			if xpathCompare(op, a, item.value()) {
+				return true
+			}
+		}
+		return false
+	}
+	return compareXPathAtoms(op, a, b)
+}
+
+func compareXPathAtoms(op string, a, b interface{}) bool {
+	if op == "=" || op == "!=" {
+		var equal bool
+		_, aBool := a.(bool)
+		_, bBool := b.(bool)
+		_, aNum := a.(float64)
+		_, bNum := b.(float64)
+		switch {
+		case aBool || bBool:
+			equal = xpathBool(a) == xpathBool(b)
+		case aNum || bNum:
+			equal = xpathNumber(a) == xpathNumber(b)
+		default:
+			equal = xpathString(a) == xpathString(b)
+		}
+		return equal == (op == "=")
+	}
+	x, y := xpathNumber(a), xpathNumber(b)
+	switch op {
+	case "<":
+		return x < y
+	case "<=":
+		return x <= y
+	case ">":
+		return x > y
+	}
+	return x >= y
+}
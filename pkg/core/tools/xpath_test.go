@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const xpathTestXML = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <orders count="3">
+      <order id="1" status="paid"><total>10</total><note>first</note></order>
+      <order id="2" status="open"><total>25.5</total></order>
+      <order id="3" status="paid"><total>40</total><note>  late   order </note></order>
+    </orders>
+  </soap:Body>
+</soap:Envelope>`
+
+// markupResponse is a response with the given Content-Type and body
+func markupResponse(contentType, body string) *HTTPResponse {
+	return &HTTPResponse{Headers: map[string]string{"Content-Type": contentType}, Body: body}
+}
+
+func TestEvalXPath(t *testing.T) {
+	doc, err := parseMarkup(markupResponse("text/xml", xpathTestXML))
+	if err != nil {
+		t.Fatalf("failed to parse XML: %v", err)
+	}
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		// Paths and namespace prefixes
+		{"/Envelope/Body/orders/order/total", []string{"10", "25.5", "40"}},
+		{"//soap:Body/orders/order/@id", []string{"1", "2", "3"}},
+		{"//order/total/text()", []string{"10", "25.5", "40"}},
+		{"//orders/*/@id", []string{"1", "2", "3"}},
+		{"//order[1]/@*", []string{"1", "paid"}},
+		{"//note/..//total", []string{"10", "40"}},
+		{"//total/./text()", []string{"10", "25.5", "40"}},
+		{"//order/@id/..", []string{"10first", "25.5", "40  late   order "}},
+		{"//orders/node()[2]/@id", []string{"1"}}, // node() counts the whitespace text first
+		{"//missing", []string{}},
+
+		// Predicates
+		{"//order[2]/@id", []string{"2"}},
+		{"//order[last()]/@id", []string{"3"}},
+		{"//order[position() > 1]/@id", []string{"2", "3"}},
+		{"//order[@status='paid']/@id", []string{"1", "3"}},
+		{"//order[@status!='paid']/@id", []string{"2"}},
+		{"//order[@status='paid'][2]/@id", []string{"3"}},
+		{"//order[total > 20]/@id", []string{"2", "3"}},
+		{"//order[total >= 25.5 and total <= 40]/@id", []string{"2", "3"}},
+		{"//order[total < 20 or @id = 3]/@id", []string{"1", "3"}},
+		{"//order[note]/@id", []string{"1", "3"}},
+		{"//order[not(note)]/@id", []string{"2"}},
+		{"//order[contains(note, 'late')]/@id", []string{"3"}},
+		{"//order[starts-with(@status, 'op')]/@id", []string{"2"}},
+		{"//order[ends-with(@status, 'id')]/@id", []string{"1", "3"}},
+		{"//order[@id = //orders/@count]/@id", []string{"3"}},
+
+		// Unions keep document order of each part, without duplicates
+		{"//order[1]/@id | //order[3]/@id | //order[1]/@id", []string{"1", "3"}},
+
+		// Functions returning a single value
+		{"count(//order)", []string{"3"}},
+		{"count(//order[@status='paid']) = 2", []string{"true"}},
+		{"string(//order[2]/total)", []string{"25.5"}},
+		{"number(//order[2]/total)", []string{"25.5"}},
+		{"string-length(//order[1]/note)", []string{"5"}},
+		{"normalize-space(//order[3]/note)", []string{"late order"}},
+		{"concat(//order[1]/@id, '-', //order[1]/@status)", []string{"1-paid"}},
+		{"name(//orders/*)", []string{"order"}},
+		{"local-name(//order/@status)", []string{"status"}},
+		{"true()", []string{"true"}},
+		{"false() or 1", []string{"true"}},
+		{"'a' = 'a'", []string{"true"}},
+		{"1 = 1.0", []string{"true"}},
+		{"(//total)", []string{"10", "25.5", "40"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evalXPath(tt.expr, doc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got == nil {
+				got = []string{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalXPath_HTML(t *testing.T) {
+	body := `<!DOCTYPE html><html><body>
+<form id="login"><input name="csrf" value="tok123"><input name="user"></form>
+<ul><li class="a">One</li><li>Two</li></ul>
+</body></html>`
+	doc, err := parseMarkup(markupResponse("text/html; charset=utf-8", body))
+	if err != nil {
+		t.Fatalf("failed to parse HTML: %v", err)
+	}
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"//form[@id='login']/input[@name='csrf']/@value", []string{"tok123"}},
+		{"//LI[@CLASS='a']", []string{"One"}},
+		{"//ul/li[last()]/text()", []string{"Two"}},
+		{"count(//input)", []string{"2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evalXPath(tt.expr, doc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalXPath_Errors(t *testing.T) {
+	doc, err := parseMarkup(markupResponse("application/xml", "<a><b/></a>"))
+	if err != nil {
+		t.Fatalf("failed to parse XML: %v", err)
+	}
+	tests := []struct {
+		expr   string
+		errMsg string
+	}{
+		{"//a[@x='open]", "unterminated string"},
+		{"//a#b", "unexpected character"},
+		{"//a[1", "expected"},
+		{"//a/", "expected a step at the end"},
+		{"//@", "expected an attribute name"},
+		{"//a)", `unexpected ")"`},
+		{"nope()", "unknown function nope()"},
+		{"count()", "count() takes 1 to 1 argument(s), got 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := evalXPath(tt.expr, doc)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", tt.errMsg)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestParseMarkup(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		errMsg      string
+	}{
+		{"xml by content type", "application/soap+xml", "<a>1</a>", ""},
+		{"html by content type", "text/html", "<p>1</p>", ""},
+		{"xml sniffed", "", "<a>1</a>", ""},
+		{"html sniffed", "", "<!doctype html><p>1</p>", ""},
+		{"invalid xml", "text/xml", "<a><b></a>", "invalid XML"},
+		{"not markup", "application/json", `{"a": 1}`, "not XML or HTML"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseMarkup(markupResponse(tt.contentType, tt.body))
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@
 // implementation for the ZAP API debugging assistant.
 package core
 
+import "time"
+
 // Tool represents an agent capability that can be executed.
 // Each tool has a name, description, parameters schema, and execution logic.
 // Tools are registered with the Agent and can be invoked during the ReAct loop.
@@ -20,7 +22,8 @@ type Tool interface {
 // Events are emitted via callbacks to enable real-time UI updates.
 type AgentEvent struct {
 	// Type indicates the event type: "thinking", "tool_call", "observation",
-	// "answer", "error", "streaming", "tool_usage", "confirmation_required"
+	// "answer", "error", "streaming", "tool_usage", "confirmation_required",
+	// "fallback", "retry", "budget_exceeded", "notice", "progress"
 	Type string
 	// Content holds the main event payload (varies by type)
 	Content string
@@ -30,6 +33,10 @@ type AgentEvent struct {
 	ToolUsage *ToolUsageEvent
 	// FileConfirmation contains file write info (present only for "confirmation_required" events)
 	FileConfirmation *FileConfirmation
+	// CommandConfirmation contains the command to run (present only for "confirmation_required" events)
+	CommandConfirmation *CommandConfirmation
+	// Progress contains a running tool's progress (present only for "progress" events)
+	Progress *ProgressEvent
 }
 
 // FileConfirmation contains information for file write confirmation prompts.
@@ -43,6 +50,17 @@ type FileConfirmation struct {
 	Diff string
 }
 
+// CommandConfirmation contains information for run_command confirmation prompts.
+// Every command is approved by the user before it runs.
+type CommandConfirmation struct {
+	// Command is the command line as the agent wrote it
+	Command string
+	// Dir is the working directory relative to the project (empty for the root)
+	Dir string
+	// Background is true when the command is left running, e.g. a dev server
+	Background bool
+}
+
 // ToolUsageEvent contains tool usage statistics for display in the TUI.
 // This enables visualization of how many tool calls have been made.
 type ToolUsageEvent struct {
@@ -73,6 +91,47 @@ type ConfirmableTool interface {
 	SetEventCallback(callback EventCallback)
 }
 
+// ProgressEvent reports how far a long-running tool has got.
+// Tools send these while they execute so the TUI can show a progress bar.
+type ProgressEvent struct {
+	// Tool is the name of the reporting tool
+	Tool string
+	// Completed is the number of units done so far, e.g. requests sent
+	Completed int64
+	// Total is the number of units expected (0 when unknown)
+	Total int64
+	// Unit names what is counted, e.g. "requests"
+	Unit string
+	// Elapsed is the time since the tool started
+	Elapsed time.Duration
+	// Remaining is the time left until the tool stops (0 when unknown)
+	Remaining time.Duration
+	// RPS is the current rate in units per second (0 when not meaningful)
+	RPS float64
+}
+
+// Fraction returns how much of the work is done, from 0 to 1, based on the
+// unit count when the total is known and on time otherwise. It returns -1
+// when neither is known.
+func (p ProgressEvent) Fraction() float64 {
+	if p.Total > 0 {
+		return min(1, float64(p.Completed)/float64(p.Total))
+	}
+	if p.Remaining > 0 {
+		return float64(p.Elapsed) / float64(p.Elapsed+p.Remaining)
+	}
+	return -1
+}
+
+// ProgressTool is a long-running tool that reports progress while it executes.
+// The agent sets a channel before calling Execute and forwards everything sent
+// on it as "progress" events; after Execute returns it sets the channel to nil.
+type ProgressTool interface {
+	Tool
+	// SetProgressChannel sets the channel to send progress on (nil to stop)
+	SetProgressChannel(ch chan<- ProgressEvent)
+}
+
 // ToolUsageStats represents the usage statistics for a single tool.
 // Used for displaying tool call limits and usage in the TUI.
 type ToolUsageStats struct {
@@ -0,0 +1,300 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAnthropicBaseURL is the Anthropic API root.
+	DefaultAnthropicBaseURL = "https://api.anthropic.com"
+	// DefaultAnthropicModel is used when no model is configured.
+	DefaultAnthropicModel = "claude-sonnet-4-5"
+	// anthropicVersion is the API version sent with every request.
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens is the response token cap (required by the Messages API).
+	anthropicMaxTokens = 8192
+)
+
+// anthropicMessage is a single turn in the Messages API format.
+type anthropicMessage struct {
+	Role    string `json:"role"` // "user" or "assistant"
+	Content string `json:"content"`
+}
+
+// anthropicRequest is the request body for POST /v1/messages.
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Stream      bool               `json:"stream"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	TopP        *float64           `json:"top_p,omitempty"`
+}
+
+// anthropicResponse is the non-streaming response from /v1/messages.
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// anthropicStreamEvent covers the SSE event payloads we care about
+// (content_block_delta, message_stop and error).
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// AnthropicClient handles communication with Anthropic's Claude Messages API.
+type AnthropicClient struct {
+	BaseURL         string
+	Model           string
+	APIKey          string
+	HTTPClient      *http.Client // Client with timeout for regular requests
+	StreamingClient *http.Client // Client without timeout for streaming
+	Options         GenerationOptions
+}
+
+// NewAnthropicClient creates a new Claude client.
+// The default model is DefaultAnthropicModel if none is specified.
+func NewAnthropicClient(apiKey, model string) *AnthropicClient {
+	if model == "" {
+		model = DefaultAnthropicModel
+	}
+	return &AnthropicClient{
+		BaseURL: DefaultAnthropicBaseURL,
+		Model:   model,
+		APIKey:  apiKey,
+		HTTPClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+		StreamingClient: &http.Client{
+			Timeout: 0, // No timeout for streaming - responses can take a while
+		},
+	}
+}
+
+// convertMessages splits out the system prompt and converts the remaining
+// messages to the Messages API format. Claude requires strictly alternating
+// user/assistant turns, so consecutive messages with the same role are merged.
+func (c *AnthropicClient) convertMessages(messages []Message) (string, []anthropicMessage) {
+	var system string
+	var converted []anthropicMessage
+
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			if system != "" {
+				system += "\n\n"
+			}
+			system += msg.Content
+			continue
+		}
+
+		role := msg.Role
+		if role != "assistant" {
+			role = "user"
+		}
+
+		if n := len(converted); n > 0 && converted[n-1].Role == role {
+			converted[n-1].Content += "\n\n" + msg.Content
+			continue
+		}
+		converted = append(converted, anthropicMessage{Role: role, Content: msg.Content})
+	}
+
+	return system, converted
+}
+
+// newRequest builds an authenticated POST request to /v1/messages.
+func (c *AnthropicClient) newRequest(messages []Message, stream bool) (*http.Request, error) {
+	system, converted := c.convertMessages(messages)
+	body := anthropicRequest{
+		Model:       c.Model,
+		System:      system,
+		Messages:    converted,
+		MaxTokens:   anthropicMaxTokens,
+		Stream:      stream,
+		Temperature: c.Options.Temperature,
+		TopP:        c.Options.TopP,
+	}
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.BaseURL+"/v1/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuthHeaders(req)
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	return req, nil
+}
+
+// setAuthHeaders adds the API key and version headers required by Anthropic.
+func (c *AnthropicClient) setAuthHeaders(req *http.Request) {
+	req.Header.Set("x-api-key", c.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+}
+
+// Chat sends a non-streaming request and returns the concatenated text blocks.
+func (c *AnthropicClient) Chat(messages []Message) (string, error) {
+	req, err := c.newRequest(messages, false)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", newStatusError(resp.StatusCode, "anthropic (model: %s) returned status %d: %s", c.Model, resp.StatusCode, string(body))
+	}
+
+	var msgResp anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&msgResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range msgResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	return text.String(), nil
+}
+
+// ChatStream sends a streaming request and calls callback for each text delta.
+// Anthropic streams server-sent events; text arrives in content_block_delta
+// events and the stream ends with message_stop.
+func (c *AnthropicClient) ChatStream(messages []Message, callback StreamCallback) (string, error) {
+	req, err := c.newRequest(messages, true)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.StreamingClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", newStatusError(resp.StatusCode, "anthropic returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var fullContent strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			// "event:" lines duplicate the payload's type field
+			continue
+		}
+
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			continue
+		}
+
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				continue
+			}
+			fullContent.WriteString(event.Delta.Text)
+			if callback != nil {
+				callback(event.Delta.Text)
+			}
+		case "error":
+			if event.Error != nil {
+				if event.Error.Type == "overloaded_error" {
+					// Mirrors the HTTP 529 status so the request is retried
+					return fullContent.String(), newStatusError(529, "anthropic stream error (%s): %s", event.Error.Type, event.Error.Message)
+				}
+				return fullContent.String(), fmt.Errorf("anthropic stream error (%s): %s", event.Error.Type, event.Error.Message)
+			}
+			return fullContent.String(), fmt.Errorf("anthropic stream error")
+		case "message_stop":
+			return fullContent.String(), nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fullContent.String(), fmt.Errorf("error reading stream: %w", err)
+	}
+
+	return fullContent.String(), nil
+}
+
+// CheckConnection verifies the API key by listing available models.
+func (c *AnthropicClient) CheckConnection() error {
+	req, err := http.NewRequest("GET", c.BaseURL+"/v1/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setAuthHeaders(req)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Anthropic: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp.StatusCode, "anthropic returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GetModel returns the name of the model being used.
+func (c *AnthropicClient) GetModel() string {
+	return c.Model
+}
+
+// SetModel switches the model used for subsequent requests.
+func (c *AnthropicClient) SetModel(model string) {
+	c.Model = model
+}
+
+// SetOptions sets the generation options sent with each request.
+// NumCtx is ignored; Claude's context window is fixed per model.
+func (c *AnthropicClient) SetOptions(opts GenerationOptions) {
+	c.Options = opts
+}
+
+// ModelInfo returns the provider, model and endpoint this client talks to.
+func (c *AnthropicClient) ModelInfo() ModelInfo {
+	return ModelInfo{Provider: ProviderAnthropic, Model: c.Model, BaseURL: c.BaseURL}
+}
@@ -1,8 +1,11 @@
 // Package llm provides client implementations for Large Language Models.
 // It defines a common interface (LLMClient) that all providers must implement,
-// enabling easy switching between different LLM backends like Ollama and Gemini.
+// enabling easy switching between different LLM backends like Ollama, Gemini
+// and any OpenAI-compatible API.
 package llm
 
+import "strings"
+
 // LLMClient defines the interface that all LLM providers must implement.
 // This allows the agent to work with any LLM backend without tight coupling.
 type LLMClient interface {
@@ -18,4 +21,41 @@ type LLMClient interface {
 
 	// GetModel returns the name of the model being used.
 	GetModel() string
+
+	// SetModel switches the model used for subsequent requests.
+	SetModel(model string)
+
+	// ModelInfo returns the provider, model and endpoint this client talks to.
+	ModelInfo() ModelInfo
+}
+
+// GenerationOptions are sampling parameters passed to the model.
+// Nil/zero fields are omitted so the provider's defaults apply.
+// The JSON tags match Ollama's "options" object.
+type GenerationOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"` // Lower = more deterministic
+	TopP        *float64 `json:"top_p,omitempty"`       // Nucleus sampling cutoff
+	NumCtx      int      `json:"num_ctx,omitempty"`     // Context window size (Ollama only)
+}
+
+// IsZero reports whether no option is set.
+func (o GenerationOptions) IsZero() bool {
+	return o.Temperature == nil && o.TopP == nil && o.NumCtx == 0
+}
+
+// ModelLister is implemented by clients that can list the models available
+// on their backend (currently Ollama via /api/tags).
+type ModelLister interface {
+	ListModels() ([]string, error)
+}
+
+// HasModel reports whether model is in models. A name without a tag matches
+// its ":latest" variant, the way Ollama resolves "llama3" to "llama3:latest".
+func HasModel(models []string, model string) bool {
+	for _, m := range models {
+		if m == model || (!strings.Contains(model, ":") && m == model+":latest") {
+			return true
+		}
+	}
+	return false
 }
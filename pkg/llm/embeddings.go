@@ -0,0 +1,236 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+
+	"google.golang.org/genai"
+)
+
+// Default embedding models used when ProviderConfig.Model is empty in NewEmbedder.
+const (
+	DefaultOllamaEmbeddingModel = "nomic-embed-text"
+	DefaultGeminiEmbeddingModel = "text-embedding-004"
+	DefaultOpenAIEmbeddingModel = "text-embedding-3-small"
+)
+
+// Embedder turns texts into embedding vectors for semantic search.
+type Embedder interface {
+	// Embed returns one vector per input text, in the same order.
+	Embed(texts []string) ([][]float64, error)
+
+	// GetModel returns the name of the embedding model.
+	GetModel() string
+}
+
+// NewEmbedder builds an Embedder for cfg.Provider. cfg.Model is the
+// embedding model (provider default if empty), not the chat model.
+// Anthropic has no embeddings API and is rejected.
+func NewEmbedder(cfg ProviderConfig) (Embedder, error) {
+	provider := cfg.Provider
+	if provider == "" {
+		provider = ProviderOllama
+	}
+
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		if envVar, ok := apiKeyEnvVars[provider]; ok {
+			apiKey = os.Getenv(envVar)
+		}
+	}
+
+	switch provider {
+	case ProviderOllama:
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = DefaultOllamaURL
+		}
+		model := cfg.Model
+		if model == "" {
+			model = DefaultOllamaEmbeddingModel
+		}
+		return NewOllamaClient(baseURL, model, apiKey), nil
+
+	case ProviderGemini:
+		if apiKey == "" {
+			return nil, fmt.Errorf("gemini embeddings selected but no API key configured (set gemini.api_key or GEMINI_API_KEY)")
+		}
+		model := cfg.Model
+		if model == "" {
+			model = DefaultGeminiEmbeddingModel
+		}
+		return NewGeminiClient(apiKey, model)
+
+	case ProviderOpenAI:
+		model := cfg.Model
+		if model == "" {
+			model = DefaultOpenAIEmbeddingModel
+		}
+		return NewOpenAIClient(cfg.BaseURL, model, apiKey), nil
+
+	case ProviderAnthropic:
+		return nil, fmt.Errorf("anthropic has no embeddings API; set memory.embedding_provider to ollama, gemini or openai")
+
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q (supported: ollama, gemini, openai)", provider)
+	}
+}
+
+// CosineSimilarity returns the cosine similarity of a and b in [-1, 1].
+// Returns 0 if the vectors differ in length or either is all zeros.
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// ollamaEmbedRequest is the request body for POST /api/embed.
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// ollamaEmbedResponse is the response body of POST /api/embed.
+type ollamaEmbedResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// Embed returns embeddings for texts using Ollama's /api/embed endpoint.
+func (c *OllamaClient) Embed(texts []string) ([][]float64, error) {
+	jsonData, err := json.Marshal(ollamaEmbedRequest{Model: c.Model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequest("POST", fmt.Sprintf("%s/api/embed", c.BaseURL), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
+	}
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newStatusError(resp.StatusCode, "ollama embeddings (model: %s) returned status %d: %s", c.Model, resp.StatusCode, string(body))
+	}
+
+	var embedResp ollamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d inputs", len(embedResp.Embeddings), len(texts))
+	}
+	return embedResp.Embeddings, nil
+}
+
+// openAIEmbedRequest is the request body for POST /embeddings.
+type openAIEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// openAIEmbedResponse is the response body of POST /embeddings.
+type openAIEmbedResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed returns embeddings for texts using the /embeddings endpoint.
+func (c *OpenAIClient) Embed(texts []string) ([][]float64, error) {
+	jsonData, err := json.Marshal(openAIEmbedRequest{Model: c.Model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.BaseURL+"/embeddings", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newStatusError(resp.StatusCode, "openai embeddings (url: %s, model: %s) returned status %d: %s", c.BaseURL, c.Model, resp.StatusCode, string(body))
+	}
+
+	var embedResp openAIEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(embedResp.Data) != len(texts) {
+		return nil, fmt.Errorf("openai returned %d embeddings for %d inputs", len(embedResp.Data), len(texts))
+	}
+
+	// Results carry their input index; don't rely on response order
+	vectors := make([][]float64, len(texts))
+	for _, d := range embedResp.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("openai returned embedding with invalid index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// Embed returns embeddings for texts using the Gemini embedding API.
+func (c *GeminiClient) Embed(texts []string) ([][]float64, error) {
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+	}
+
+	resp, err := c.client.Models.EmbedContent(context.Background(), c.model, contents, nil)
+	if err != nil {
+		return nil, fmt.Errorf("gemini embeddings (model: %s) request failed: %w", c.model, err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("gemini returned %d embeddings for %d inputs", len(resp.Embeddings), len(texts))
+	}
+
+	vectors := make([][]float64, len(texts))
+	for i, embedding := range resp.Embeddings {
+		vector := make([]float64, len(embedding.Values))
+		for j, v := range embedding.Values {
+			vector[j] = float64(v)
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
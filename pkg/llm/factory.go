@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+)
+
+// Supported provider names for ProviderConfig.Provider.
+const (
+	ProviderOllama    = "ollama"
+	ProviderGemini    = "gemini"
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+// Default endpoints and models used when ProviderConfig leaves them empty.
+const (
+	DefaultOllamaURL   = "http://localhost:11434"
+	DefaultOllamaModel = "llama3"
+	DefaultGeminiModel = "gemini-2.5-flash-lite"
+	DefaultOpenAIModel = "gpt-4o-mini"
+)
+
+// ProviderConfig describes which LLM backend to build and how to reach it.
+// It is deliberately independent of the on-disk config format so callers
+// (TUI, CLI, tests) can fill it from whatever source they use.
+type ProviderConfig struct {
+	Provider string // "ollama", "gemini", "openai" or "anthropic" (empty means ollama)
+	Model    string // Model name (provider default if empty)
+	BaseURL  string // API root (Ollama and OpenAI-compatible only)
+	APIKey   string // API key (falls back to the provider's environment variable)
+	Options  GenerationOptions
+}
+
+// ModelInfo describes the model a client is talking to.
+type ModelInfo struct {
+	Provider string // Provider name, e.g. "ollama"
+	Model    string // Model name
+	BaseURL  string // API root (empty for SDK-backed providers)
+}
+
+// apiKeyEnvVars maps each provider to the environment variable used
+// when no API key is configured.
+var apiKeyEnvVars = map[string]string{
+	ProviderOllama:    "OLLAMA_API_KEY",
+	ProviderGemini:    "GEMINI_API_KEY",
+	ProviderOpenAI:    "OPENAI_API_KEY",
+	ProviderAnthropic: "ANTHROPIC_API_KEY",
+}
+
+// NewClient builds the LLMClient for cfg.Provider.
+// Adding a provider only requires a new case here; the agent works with
+// any LLMClient and never references concrete client types.
+func NewClient(cfg ProviderConfig) (LLMClient, error) {
+	provider := cfg.Provider
+	if provider == "" {
+		provider = ProviderOllama
+	}
+
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		if envVar, ok := apiKeyEnvVars[provider]; ok {
+			apiKey = os.Getenv(envVar)
+		}
+	}
+
+	switch provider {
+	case ProviderOllama:
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = DefaultOllamaURL
+		}
+		model := cfg.Model
+		if model == "" {
+			model = DefaultOllamaModel
+		}
+		client := NewOllamaClient(baseURL, model, apiKey)
+		client.SetOptions(cfg.Options)
+		return client, nil
+
+	case ProviderGemini:
+		if apiKey == "" {
+			return nil, fmt.Errorf("gemini provider selected but no API key configured (set gemini.api_key or GEMINI_API_KEY)")
+		}
+		client, err := NewGeminiClient(apiKey, cfg.Model)
+		if err != nil {
+			return nil, err
+		}
+		client.SetOptions(cfg.Options)
+		return client, nil
+
+	case ProviderOpenAI:
+		model := cfg.Model
+		if model == "" {
+			model = DefaultOpenAIModel
+		}
+		client := NewOpenAIClient(cfg.BaseURL, model, apiKey)
+		client.SetOptions(cfg.Options)
+		return client, nil
+
+	case ProviderAnthropic:
+		if apiKey == "" {
+			return nil, fmt.Errorf("anthropic provider selected but no API key configured (set anthropic.api_key or ANTHROPIC_API_KEY)")
+		}
+		client := NewAnthropicClient(apiKey, cfg.Model)
+		client.SetOptions(cfg.Options)
+		return client, nil
+
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (supported: ollama, gemini, openai, anthropic)", provider)
+	}
+}
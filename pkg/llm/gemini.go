@@ -10,16 +10,17 @@ import (
 
 // GeminiClient handles communication with Google's Gemini API.
 type GeminiClient struct {
-	client *genai.Client
-	model  string
-	apiKey string
+	client  *genai.Client
+	model   string
+	apiKey  string
+	options GenerationOptions
 }
 
 // NewGeminiClient creates a new Gemini client with the given API key and model.
-// The default model is "gemini-2.5-flash-lite" if none is specified.
+// The default model is DefaultGeminiModel if none is specified.
 func NewGeminiClient(apiKey, model string) (*GeminiClient, error) {
 	if model == "" {
-		model = "gemini-2.5-flash-lite"
+		model = DefaultGeminiModel
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -82,6 +83,28 @@ func (c *GeminiClient) extractSystemInstruction(messages []Message) (string, []M
 	return systemInstruction, remaining
 }
 
+// buildConfig returns the request config for the system instruction and
+// generation options, or nil if neither is set.
+func (c *GeminiClient) buildConfig(systemInstruction string) *genai.GenerateContentConfig {
+	if systemInstruction == "" && c.options.IsZero() {
+		return nil
+	}
+
+	config := &genai.GenerateContentConfig{}
+	if systemInstruction != "" {
+		config.SystemInstruction = &genai.Content{
+			Parts: []*genai.Part{genai.NewPartFromText(systemInstruction)},
+		}
+	}
+	if c.options.Temperature != nil {
+		config.Temperature = genai.Ptr(float32(*c.options.Temperature))
+	}
+	if c.options.TopP != nil {
+		config.TopP = genai.Ptr(float32(*c.options.TopP))
+	}
+	return config
+}
+
 // Chat sends a non-streaming chat request and returns the complete response.
 func (c *GeminiClient) Chat(messages []Message) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
@@ -93,15 +116,8 @@ func (c *GeminiClient) Chat(messages []Message) (string, error) {
 	// Convert messages to Gemini format
 	contents := c.convertMessages(conversationMessages)
 
-	// Build config with system instruction
-	var config *genai.GenerateContentConfig
-	if systemInstruction != "" {
-		config = &genai.GenerateContentConfig{
-			SystemInstruction: &genai.Content{
-				Parts: []*genai.Part{genai.NewPartFromText(systemInstruction)},
-			},
-		}
-	}
+	// Build config with system instruction and generation options
+	config := c.buildConfig(systemInstruction)
 
 	// Generate content
 	response, err := c.client.Models.GenerateContent(ctx, c.model, contents, config)
@@ -125,15 +141,8 @@ func (c *GeminiClient) ChatStream(messages []Message, callback StreamCallback) (
 	// Convert messages to Gemini format
 	contents := c.convertMessages(conversationMessages)
 
-	// Build config with system instruction
-	var config *genai.GenerateContentConfig
-	if systemInstruction != "" {
-		config = &genai.GenerateContentConfig{
-			SystemInstruction: &genai.Content{
-				Parts: []*genai.Part{genai.NewPartFromText(systemInstruction)},
-			},
-		}
-	}
+	// Build config with system instruction and generation options
+	config := c.buildConfig(systemInstruction)
 
 	// Stream content
 	var fullContent string
@@ -184,3 +193,19 @@ func (c *GeminiClient) CheckConnection() error {
 func (c *GeminiClient) GetModel() string {
 	return c.model
 }
+
+// SetModel switches the model used for subsequent requests.
+func (c *GeminiClient) SetModel(model string) {
+	c.model = model
+}
+
+// SetOptions sets the generation options sent with each request.
+// NumCtx is ignored; Gemini's context window is fixed per model.
+func (c *GeminiClient) SetOptions(opts GenerationOptions) {
+	c.options = opts
+}
+
+// ModelInfo returns the provider and model this client talks to.
+func (c *GeminiClient) ModelInfo() ModelInfo {
+	return ModelInfo{Provider: ProviderGemini, Model: c.model}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -20,9 +21,10 @@ type Message struct {
 
 // ChatRequest represents an Ollama chat request
 type ChatRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-	Stream   bool      `json:"stream"`
+	Model    string             `json:"model"`
+	Messages []Message          `json:"messages"`
+	Stream   bool               `json:"stream"`
+	Options  *GenerationOptions `json:"options,omitempty"`
 }
 
 // ChatResponse represents an Ollama chat response
@@ -43,6 +45,7 @@ type OllamaClient struct {
 	APIKey          string
 	HTTPClient      *http.Client // Client with timeout for regular requests
 	StreamingClient *http.Client // Client without timeout for streaming
+	Options         GenerationOptions
 }
 
 // NewOllamaClient creates a new Ollama client with proper connection pooling.
@@ -69,6 +72,7 @@ func (c *OllamaClient) Chat(messages []Message) (string, error) {
 		Model:    c.Model,
 		Messages: messages,
 		Stream:   false,
+		Options:  c.options(),
 	}
 
 	jsonData, err := json.Marshal(req)
@@ -95,7 +99,7 @@ func (c *OllamaClient) Chat(messages []Message) (string, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("ollama (url: %s, model: %s) returned status %d: %s", url, c.Model, resp.StatusCode, string(body))
+		return "", newStatusError(resp.StatusCode, "ollama (url: %s, model: %s) returned status %d: %s", url, c.Model, resp.StatusCode, string(body))
 	}
 
 	var chatResp ChatResponse
@@ -114,6 +118,7 @@ func (c *OllamaClient) ChatStream(messages []Message, callback StreamCallback) (
 		Model:    c.Model,
 		Messages: messages,
 		Stream:   true,
+		Options:  c.options(),
 	}
 
 	jsonData, err := json.Marshal(req)
@@ -147,7 +152,7 @@ func (c *OllamaClient) ChatStream(messages []Message, callback StreamCallback) (
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
+		return "", newStatusError(resp.StatusCode, "ollama returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	// Read streaming response line by line
@@ -192,6 +197,143 @@ func (c *OllamaClient) ChatStream(messages []Message, callback StreamCallback) (
 	return fullContent, nil
 }
 
+// ollamaTool is the /api/chat representation of a callable function.
+type ollamaTool struct {
+	Type     string             `json:"type"` // always "function"
+	Function ollamaToolFunction `json:"function"`
+}
+
+// ollamaToolFunction describes a function's name, purpose and arguments schema.
+type ollamaToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ollamaToolChatRequest is a ChatRequest with native tool definitions attached.
+type ollamaToolChatRequest struct {
+	Model    string             `json:"model"`
+	Messages []Message          `json:"messages"`
+	Stream   bool               `json:"stream"`
+	Tools    []ollamaTool       `json:"tools"`
+	Options  *GenerationOptions `json:"options,omitempty"`
+}
+
+// ollamaToolChatResponse is a streaming chunk that may carry tool calls.
+type ollamaToolChatResponse struct {
+	Message struct {
+		Content   string `json:"content"`
+		ToolCalls []struct {
+			Function struct {
+				Name      string          `json:"name"`
+				Arguments json.RawMessage `json:"arguments"`
+			} `json:"function"`
+		} `json:"tool_calls"`
+	} `json:"message"`
+	Done bool `json:"done"`
+}
+
+// ChatStreamWithTools sends a streaming chat request with native tool definitions
+// (the /api/chat "tools" parameter). Text chunks are delivered via callback and
+// structured tool calls are returned once the stream completes.
+// Returns ErrToolsUnsupported if the model rejects the tools parameter.
+func (c *OllamaClient) ChatStreamWithTools(messages []Message, tools []ToolDefinition, callback StreamCallback) (string, []ToolCall, error) {
+	req := ollamaToolChatRequest{
+		Model:    c.Model,
+		Messages: messages,
+		Stream:   true,
+		Options:  c.options(),
+	}
+	for _, t := range tools {
+		req.Tools = append(req.Tools, ollamaTool{
+			Type: "function",
+			Function: ollamaToolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/chat", c.BaseURL)
+	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
+	}
+
+	resp, err := c.StreamingClient.Do(httpReq)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		// Ollama answers 400 "<model> does not support tools" for models
+		// without a tool-calling template
+		if resp.StatusCode == http.StatusBadRequest && strings.Contains(string(body), "does not support tools") {
+			return "", nil, ErrToolsUnsupported
+		}
+		return "", nil, newStatusError(resp.StatusCode, "ollama returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var fullContent string
+	var toolCalls []ToolCall
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		var chunk ollamaToolChatResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			continue
+		}
+
+		if chunk.Message.Content != "" {
+			fullContent += chunk.Message.Content
+			if callback != nil {
+				callback(chunk.Message.Content)
+			}
+		}
+
+		for _, tc := range chunk.Message.ToolCalls {
+			args := string(tc.Function.Arguments)
+			// Some servers encode arguments as a JSON string instead of an object
+			var encoded string
+			if json.Unmarshal(tc.Function.Arguments, &encoded) == nil {
+				args = encoded
+			}
+			if args == "" || args == "null" {
+				args = "{}"
+			}
+			toolCalls = append(toolCalls, ToolCall{Name: tc.Function.Name, Arguments: args})
+		}
+
+		if chunk.Done {
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fullContent, toolCalls, fmt.Errorf("error reading stream: %w", err)
+	}
+
+	return fullContent, toolCalls, nil
+}
+
 // chatWithFallback uses non-streaming mode and delivers the response via callback.
 // This is used as a fallback when streaming is unavailable (e.g., Ollama Cloud 503).
 func (c *OllamaClient) chatWithFallback(messages []Message, callback StreamCallback) (string, error) {
@@ -218,13 +360,75 @@ func (c *OllamaClient) CheckConnection() error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ollama returned status %d", resp.StatusCode)
+		return newStatusError(resp.StatusCode, "ollama returned status %d", resp.StatusCode)
 	}
 
 	return nil
 }
 
+// ollamaTagsResponse is the response of GET /api/tags.
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// ListModels returns the names of the models installed on the Ollama server.
+func (c *OllamaClient) ListModels() ([]string, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/tags", c.BaseURL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp.StatusCode, "ollama returned status %d", resp.StatusCode)
+	}
+
+	var tags ollamaTagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+
+	models := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		models = append(models, m.Name)
+	}
+	return models, nil
+}
+
 // GetModel returns the name of the model being used.
 func (c *OllamaClient) GetModel() string {
 	return c.Model
 }
+
+// SetModel switches the model used for subsequent requests.
+func (c *OllamaClient) SetModel(model string) {
+	c.Model = model
+}
+
+// SetOptions sets the generation options sent with each request.
+func (c *OllamaClient) SetOptions(opts GenerationOptions) {
+	c.Options = opts
+}
+
+// options returns the request options, or nil if none are set.
+func (c *OllamaClient) options() *GenerationOptions {
+	if c.Options.IsZero() {
+		return nil
+	}
+	return &c.Options
+}
+
+// ModelInfo returns the provider, model and endpoint this client talks to.
+func (c *OllamaClient) ModelInfo() ModelInfo {
+	return ModelInfo{Provider: ProviderOllama, Model: c.Model, BaseURL: c.BaseURL}
+}
@@ -0,0 +1,236 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultOpenAIBaseURL is the API root used when no base URL is configured.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// openAIChatRequest is the request body for the /chat/completions endpoint.
+type openAIChatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Stream      bool      `json:"stream"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	TopP        *float64  `json:"top_p,omitempty"`
+}
+
+// openAIChatResponse is the non-streaming response from /chat/completions.
+type openAIChatResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+}
+
+// openAIStreamChunk is a single "data:" event of a streaming response.
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// OpenAIClient talks to any OpenAI-compatible chat completions API.
+// This covers OpenAI itself as well as OpenRouter, LM Studio, vLLM and
+// other servers exposing the same /v1/chat/completions contract.
+type OpenAIClient struct {
+	BaseURL         string
+	Model           string
+	APIKey          string
+	HTTPClient      *http.Client // Client with timeout for regular requests
+	StreamingClient *http.Client // Client without timeout for streaming
+	Options         GenerationOptions
+}
+
+// NewOpenAIClient creates a new OpenAI-compatible client.
+// baseURL should include the version prefix (e.g. "https://api.openai.com/v1").
+// If baseURL is empty, the official OpenAI endpoint is used.
+func NewOpenAIClient(baseURL, model, apiKey string) *OpenAIClient {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	return &OpenAIClient{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Model:   model,
+		APIKey:  apiKey,
+		HTTPClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+		StreamingClient: &http.Client{
+			Timeout: 0, // No timeout for streaming - responses can take a while
+		},
+	}
+}
+
+// newRequest builds an authenticated POST request to the chat completions endpoint.
+func (c *OpenAIClient) newRequest(messages []Message, stream bool) (*http.Request, error) {
+	body := openAIChatRequest{
+		Model:       c.Model,
+		Messages:    messages,
+		Stream:      stream,
+		Temperature: c.Options.Temperature,
+		TopP:        c.Options.TopP,
+	}
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := c.BaseURL + "/chat/completions"
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	return req, nil
+}
+
+// Chat sends a non-streaming chat completion request and returns the response.
+func (c *OpenAIClient) Chat(messages []Message) (string, error) {
+	req, err := c.newRequest(messages, false)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", newStatusError(resp.StatusCode, "openai (url: %s, model: %s) returned status %d: %s", c.BaseURL, c.Model, resp.StatusCode, string(body))
+	}
+
+	var chatResp openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("openai returned no choices")
+	}
+
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+// ChatStream sends a streaming chat completion request and calls callback for each chunk.
+// The response is a server-sent event stream of "data: {...}" lines terminated by "data: [DONE]".
+func (c *OpenAIClient) ChatStream(messages []Message, callback StreamCallback) (string, error) {
+	req, err := c.newRequest(messages, true)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.StreamingClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", newStatusError(resp.StatusCode, "openai returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var fullContent strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			// Skip blank separators, comments (": keep-alive") and event names
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			fullContent.WriteString(choice.Delta.Content)
+			if callback != nil {
+				callback(choice.Delta.Content)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fullContent.String(), fmt.Errorf("error reading stream: %w", err)
+	}
+
+	return fullContent.String(), nil
+}
+
+// CheckConnection verifies that the API is reachable by listing models.
+func (c *OpenAIClient) CheckConnection() error {
+	req, err := http.NewRequest("GET", c.BaseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", c.BaseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp.StatusCode, "openai returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GetModel returns the name of the model being used.
+func (c *OpenAIClient) GetModel() string {
+	return c.Model
+}
+
+// SetModel switches the model used for subsequent requests.
+func (c *OpenAIClient) SetModel(model string) {
+	c.Model = model
+}
+
+// SetOptions sets the generation options sent with each request.
+// NumCtx is ignored; the context window is fixed by the server.
+func (c *OpenAIClient) SetOptions(opts GenerationOptions) {
+	c.Options = opts
+}
+
+// ModelInfo returns the provider, model and endpoint this client talks to.
+func (c *OpenAIClient) ModelInfo() ModelInfo {
+	return ModelInfo{Provider: ProviderOpenAI, Model: c.Model, BaseURL: c.BaseURL}
+}
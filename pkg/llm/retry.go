@@ -0,0 +1,129 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// StatusError is returned when a provider answers with a non-200 status.
+// It keeps the status code so callers can decide whether to retry.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// newStatusError creates a StatusError with a formatted message.
+func newStatusError(statusCode int, format string, args ...interface{}) *StatusError {
+	return &StatusError{StatusCode: statusCode, Message: fmt.Sprintf(format, args...)}
+}
+
+// RetryPolicy controls retries of failed LLM requests.
+// Delays grow exponentially from BaseDelay, are capped at MaxDelay and are
+// jittered so many clients don't retry in lockstep.
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first (1 = no retries)
+	BaseDelay   time.Duration // Delay before the first retry
+	MaxDelay    time.Duration // Upper bound for any single delay
+}
+
+// DefaultRetryPolicy retries twice with 1s/2s base delays.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Second,
+	MaxDelay:    15 * time.Second,
+}
+
+// Backoff returns the jittered delay before retry number attempt (1-based).
+// Uses "equal jitter": half the exponential delay plus a random share of the other half.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << uint(attempt-1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying:
+// timeouts, dropped connections, rate limiting (429) and server errors (5xx).
+// Client errors such as a bad API key or unknown model are not retried.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrToolsUnsupported) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return isRetryableStatus(statusErr.StatusCode)
+	}
+
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return isRetryableStatus(apiErr.Code)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	// Connection resets and EOFs mid-stream surface as wrapped strings
+	msg := strings.ToLower(err.Error())
+	for _, transient := range []string{"connection reset", "connection refused", "eof", "broken pipe", "timeout"} {
+		if strings.Contains(msg, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRetryableStatus reports whether an HTTP status code indicates a transient failure.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || code >= 500
+}
+
+// Retry calls fn until it succeeds, fails with a non-retryable error, or the
+// policy's attempts are exhausted. onRetry (may be nil) is called before each
+// wait with the attempt that just failed, the upcoming delay and the error.
+// Returns the last error, or ctx.Err() if the context is cancelled while waiting.
+func Retry(ctx context.Context, policy RetryPolicy, fn func() error, onRetry func(attempt int, delay time.Duration, err error)) error {
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil || !IsRetryable(err) || attempt == attempts {
+			return err
+		}
+
+		delay := policy.Backoff(attempt)
+		if onRetry != nil {
+			onRetry(attempt, delay, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+package llm
+
+import "errors"
+
+// ToolDefinition describes a tool the model may invoke through native
+// (structured) tool calling.
+type ToolDefinition struct {
+	Name        string                 // Tool name, e.g. "http_request"
+	Description string                 // What the tool does
+	Parameters  map[string]interface{} // JSON Schema describing the arguments object
+}
+
+// ToolCall is a structured tool invocation returned by the model.
+type ToolCall struct {
+	Name      string // Name of the tool to call
+	Arguments string // JSON-encoded arguments object
+}
+
+// ErrToolsUnsupported is returned by ChatStreamWithTools when the model
+// rejects the tools parameter. Callers should fall back to text-based
+// tool calling for the rest of the session.
+var ErrToolsUnsupported = errors.New("model does not support native tool calling")
+
+// ToolCallingClient is implemented by clients whose backend supports
+// native tool calling. The agent checks for it with a type assertion and
+// otherwise parses "ACTION: tool(...)" from the text response.
+type ToolCallingClient interface {
+	// ChatStreamWithTools streams the text response via callback and returns
+	// the full text plus any tool calls the model made.
+	ChatStreamWithTools(messages []Message, tools []ToolDefinition, callback StreamCallback) (string, []ToolCall, error)
+}
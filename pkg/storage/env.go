@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -13,26 +14,94 @@ import (
 // varPattern matches {{VAR_NAME}} or {{env:VAR_NAME}}
 var varPattern = regexp.MustCompile(`\{\{([^}]+)\}\}`)
 
+// Reserved environment keys holding settings instead of variables
+const (
+	EnvironmentTLSKey  = "tls"  // TLS settings
+	EnvironmentAuthKey = "auth" // Named auth profiles
+)
+
 // LoadEnvironment loads environment variables from a YAML file
 func LoadEnvironment(filePath string) (map[string]string, error) {
-	data, err := os.ReadFile(filePath)
+	nodes, err := readEnvironmentFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read environment file: %w", err)
+		return nil, err
 	}
 
-	var env map[string]string
-	if err := yaml.Unmarshal(data, &env); err != nil {
-		return nil, fmt.Errorf("failed to parse environment YAML: %w", err)
-	}
-
-	// Resolve any {{env:VAR}} references to actual environment variables
-	for key, value := range env {
+	env := make(map[string]string, len(nodes))
+	for key, node := range nodes {
+		if key == EnvironmentTLSKey || key == EnvironmentAuthKey {
+			continue
+		}
+		var value string
+		if err := node.Decode(&value); err != nil {
+			return nil, fmt.Errorf("failed to parse environment variable '%s': %w", key, err)
+		}
+		// Resolve any {{env:VAR}} references to actual environment variables
 		env[key] = resolveEnvRefs(value)
 	}
 
 	return env, nil
 }
 
+// LoadEnvironmentTLS loads the optional tls block of an environment file.
+// It returns nil if the environment has no TLS settings.
+func LoadEnvironmentTLS(filePath string) (*TLSConfig, error) {
+	nodes, err := readEnvironmentFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	node, ok := nodes[EnvironmentTLSKey]
+	if !ok {
+		return nil, nil
+	}
+	var tls TLSConfig
+	if err := node.Decode(&tls); err != nil {
+		return nil, fmt.Errorf("failed to parse environment tls settings: %w", err)
+	}
+	tls.CAFile = resolveEnvRefs(tls.CAFile)
+	return &tls, nil
+}
+
+// LoadEnvironmentAuth loads the optional auth block of an environment file:
+// auth profiles by name. It returns nil if the environment defines none.
+func LoadEnvironmentAuth(filePath string) (map[string]AuthProfile, error) {
+	nodes, err := readEnvironmentFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	node, ok := nodes[EnvironmentAuthKey]
+	if !ok {
+		return nil, nil
+	}
+	var profiles map[string]AuthProfile
+	if err := node.Decode(&profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse environment auth profiles: %w", err)
+	}
+	for name, profile := range profiles {
+		profile.TokenURL = resolveEnvRefs(profile.TokenURL)
+		profile.ClientID = resolveEnvRefs(profile.ClientID)
+		profile.Username = resolveEnvRefs(profile.Username)
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// readEnvironmentFile parses an environment file into its top-level nodes
+func readEnvironmentFile(filePath string) (map[string]yaml.Node, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environment file: %w", err)
+	}
+
+	var nodes map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to parse environment YAML: %w", err)
+	}
+	return nodes, nil
+}
+
 // SaveEnvironment saves environment variables to a YAML file
 func SaveEnvironment(env map[string]string, filePath string) error {
 	dir := filepath.Dir(filePath)
@@ -110,6 +179,8 @@ func ApplyEnvironment(req *Request, env map[string]string) *Request {
 		Headers: make(map[string]string),
 		Query:   make(map[string]string),
 		Body:    req.Body,
+		UseAuth: req.UseAuth,
+		Tags:    req.Tags,
 	}
 
 	// Apply to headers
@@ -145,3 +216,73 @@ func resolveEnvRefs(text string) string {
 		return match
 	})
 }
+
+// SetEnvironmentVariables adds vars to an environment file, creating it if
+// needed. Comments, TLS settings and auth profiles are kept. Existing
+// variables are only replaced when overwrite is set; the names that were
+// written are returned.
+func SetEnvironmentVariables(filePath string, vars map[string]string, overwrite bool) ([]string, error) {
+	var doc yaml.Node
+	data, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read environment file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse environment YAML: %w", err)
+	}
+
+	var mapping *yaml.Node
+	var header []byte
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		mapping = doc.Content[0]
+		if mapping.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("environment file is not a YAML mapping")
+		}
+	} else {
+		// Empty file, or one with only comments (like the default dev.yaml)
+		if trimmed := strings.TrimSpace(string(data)); trimmed != "" {
+			header = []byte(trimmed + "\n")
+		}
+		mapping = &yaml.Node{Kind: yaml.MappingNode}
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{mapping}}
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var written []string
+	for _, name := range names {
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: vars[name]}
+		found := false
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value != name {
+				continue
+			}
+			found = true
+			if overwrite {
+				mapping.Content[i+1] = value
+				written = append(written, name)
+			}
+			break
+		}
+		if !found {
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+			written = append(written, name)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal environment: %w", err)
+	}
+	if err := os.WriteFile(filePath, append(header, out...), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write environment file: %w", err)
+	}
+	return written, nil
+}
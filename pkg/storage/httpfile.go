@@ -0,0 +1,367 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HTTPFile is a VS Code REST Client style .http file: file variables and
+// requests separated by ### lines.
+type HTTPFile struct {
+	Variables map[string]string // @name = value lines
+	Requests  []Request
+}
+
+// httpFileVarPattern matches a file variable line: @baseUrl = https://api.example.com
+var httpFileVarPattern = regexp.MustCompile(`^@([A-Za-z0-9_\-]+)\s*=\s*(.*)$`)
+
+// httpRequestLinePattern matches a request line: GET https://... HTTP/1.1
+var httpRequestLinePattern = regexp.MustCompile(`^(GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|TRACE|CONNECT)\s+(\S.*?)(?:\s+HTTP/[0-9.]+)?$`)
+
+// httpEnvVarPattern matches REST Client's {{$dotenv NAME}} and {{$processEnv NAME}}
+var httpEnvVarPattern = regexp.MustCompile(`\{\{\s*\$(?:dotenv|processEnv)\s+%?([A-Za-z0-9_]+)\s*\}\}`)
+
+// httpEnvRefPattern matches ZAP's {{env:NAME}}, written back as {{$processEnv NAME}}
+var httpEnvRefPattern = regexp.MustCompile(`\{\{env:([A-Za-z0-9_]+)\}\}`)
+
+// IsHTTPFile reports whether path is a .http or .rest file
+func IsHTTPFile(path string) bool {
+	return strings.HasSuffix(path, ".http") || strings.HasSuffix(path, ".rest")
+}
+
+// ParseHTTPFile parses a .http file. A request is named by a "# @name"
+// comment, or else by the text after its ### separator. {{$dotenv X}} and
+// {{$processEnv X}} become {{env:X}}; other variables are left in place.
+func ParseHTTPFile(data []byte) (*HTTPFile, error) {
+	file := &HTTPFile{Variables: make(map[string]string)}
+	text := httpEnvVarPattern.ReplaceAllString(strings.ReplaceAll(string(data), "\r\n", "\n"), "{{env:$1}}")
+
+	var blocks [][]string
+	var titles []string
+	current, title := []string{}, ""
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "###") {
+			blocks, titles = append(blocks, current), append(titles, title)
+			current, title = []string{}, strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+		current = append(current, line)
+	}
+	blocks, titles = append(blocks, current), append(titles, title)
+
+	for i, block := range blocks {
+		req, err := parseHTTPBlock(block, file.Variables)
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %w", len(file.Requests)+1, err)
+		}
+		if req == nil {
+			continue
+		}
+		if req.Name == "" {
+			req.Name = titles[i]
+		}
+		if req.Name == "" {
+			req.Name = fmt.Sprintf("request-%d", len(file.Requests)+1)
+		}
+		file.Requests = append(file.Requests, *req)
+	}
+	return file, nil
+}
+
+// parseHTTPBlock parses the lines between two ### separators. File
+// variables found before the request line are added to vars. It returns
+// nil for a block without a request.
+func parseHTTPBlock(lines []string, vars map[string]string) (*Request, error) {
+	req := &Request{}
+	i := 0
+
+	// Comments, @name annotations and file variables before the request line
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		if comment, ok := httpComment(line); ok {
+			if name, ok := strings.CutPrefix(comment, "@name "); ok {
+				req.Name = strings.TrimSpace(name)
+			} else if profile, ok := strings.CutPrefix(comment, "@use_auth "); ok {
+				req.UseAuth = strings.TrimSpace(profile)
+			} else if tags, ok := strings.CutPrefix(comment, "@tags "); ok {
+				for _, tag := range strings.Split(tags, ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						req.Tags = append(req.Tags, tag)
+					}
+				}
+			}
+			continue
+		}
+		if m := httpFileVarPattern.FindStringSubmatch(line); m != nil {
+			vars[m[1]] = strings.TrimSpace(m[2])
+			continue
+		}
+		break
+	}
+	if i == len(lines) {
+		return nil, nil
+	}
+
+	line := strings.TrimSpace(lines[i])
+	if m := httpRequestLinePattern.FindStringSubmatch(line); m != nil {
+		req.Method, req.URL = m[1], strings.TrimSpace(m[2])
+	} else if strings.Contains(line, "://") || strings.HasPrefix(line, "{{") {
+		req.Method, req.URL = "GET", strings.TrimSuffix(strings.TrimSpace(strings.Split(line, " HTTP/")[0]), " ")
+	} else {
+		return nil, fmt.Errorf("expected a request line like 'GET https://...', got %q", line)
+	}
+	i++
+
+	// Query continuation lines: ?page=1 and &limit=10
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&") {
+			break
+		}
+		req.URL += line
+	}
+
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			break
+		}
+		if _, ok := httpComment(line); ok {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header line %q", line)
+		}
+		if req.Headers == nil {
+			req.Headers = make(map[string]string)
+		}
+		req.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	if i < len(lines) {
+		body := strings.TrimSpace(strings.Join(lines[i:], "\n"))
+		if body != "" {
+			req.Body = httpFileBody(body)
+		}
+	}
+	return req, nil
+}
+
+// httpComment returns the text of a # or // comment line
+func httpComment(line string) (string, bool) {
+	if comment, ok := strings.CutPrefix(line, "#"); ok {
+		return strings.TrimSpace(comment), true
+	}
+	if comment, ok := strings.CutPrefix(line, "//"); ok {
+		return strings.TrimSpace(comment), true
+	}
+	return "", false
+}
+
+// httpFileBody returns a JSON object or array body as structured data, so it
+// matches requests saved as YAML, and anything else as a string
+func httpFileBody(body string) interface{} {
+	if strings.HasPrefix(body, "{") || strings.HasPrefix(body, "[") {
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(body), &parsed); err == nil {
+			return parsed
+		}
+	}
+	return body
+}
+
+// Format renders the file in REST Client syntax
+func (f *HTTPFile) Format() []byte {
+	var buf bytes.Buffer
+
+	names := make([]string, 0, len(f.Variables))
+	for name := range f.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&buf, "@%s = %s\n", name, f.Variables[name])
+	}
+
+	for i, req := range f.Requests {
+		if i > 0 || len(names) > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "### %s\n", req.Name)
+		if !strings.ContainsAny(req.Name, " \t") {
+			fmt.Fprintf(&buf, "# @name %s\n", req.Name)
+		}
+		if req.UseAuth != "" {
+			fmt.Fprintf(&buf, "# @use_auth %s\n", req.UseAuth)
+		}
+		if len(req.Tags) > 0 {
+			fmt.Fprintf(&buf, "# @tags %s\n", strings.Join(req.Tags, ", "))
+		}
+		fmt.Fprintf(&buf, "%s %s\n", req.Method, httpFileURL(req))
+
+		headers := make([]string, 0, len(req.Headers))
+		for name := range req.Headers {
+			headers = append(headers, name)
+		}
+		sort.Strings(headers)
+		for _, name := range headers {
+			fmt.Fprintf(&buf, "%s: %s\n", name, req.Headers[name])
+		}
+
+		switch body := req.Body.(type) {
+		case nil:
+		case string:
+			if body != "" {
+				fmt.Fprintf(&buf, "\n%s\n", body)
+			}
+		default:
+			data, err := json.MarshalIndent(body, "", "  ")
+			if err == nil {
+				fmt.Fprintf(&buf, "\n%s\n", data)
+			}
+		}
+	}
+	return httpEnvRefPattern.ReplaceAll(buf.Bytes(), []byte("{{$$processEnv $1}}"))
+}
+
+// httpFileURL returns the request URL with its query parameters appended
+func httpFileURL(req Request) string {
+	if len(req.Query) == 0 {
+		return req.URL
+	}
+	keys := make([]string, 0, len(req.Query))
+	for key := range req.Query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(req.URL)
+	sep := "?"
+	if strings.Contains(req.URL, "?") {
+		sep = "&"
+	}
+	for _, key := range keys {
+		sb.WriteString(sep + key + "=" + req.Query[key])
+		sep = "&"
+	}
+	return sb.String()
+}
+
+// Request returns the request with the given name, with the file's
+// variables substituted. Names match case-insensitively, with spaces and
+// dashes treated alike.
+func (f *HTTPFile) Request(name string) (*Request, bool) {
+	// File variables may refer to each other: @url = {{host}}/api
+	vars := make(map[string]string, len(f.Variables))
+	for key, value := range f.Variables {
+		vars[key] = value
+	}
+	for i := 0; i < 3; i++ {
+		for key, value := range vars {
+			vars[key] = SubstituteVariables(value, vars)
+		}
+	}
+
+	for _, req := range f.Requests {
+		if httpRequestKey(req.Name) != httpRequestKey(name) {
+			continue
+		}
+		applied := ApplyEnvironment(&req, vars)
+		if _, ok := req.Body.(string); !ok && req.Body != nil {
+			// Structured bodies are substituted through their JSON text
+			if data, err := json.Marshal(req.Body); err == nil {
+				var body interface{}
+				if json.Unmarshal([]byte(SubstituteVariables(string(data), vars)), &body) == nil {
+					applied.Body = body
+				}
+			}
+		}
+		return applied, true
+	}
+	return nil, false
+}
+
+// httpRequestKey normalizes a request name for lookups
+func httpRequestKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "-"))
+}
+
+// LoadHTTPFile reads a .http file
+func LoadHTTPFile(filePath string) (*HTTPFile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	file, err := ParseHTTPFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(filePath), err)
+	}
+	return file, nil
+}
+
+// SaveHTTPRequest adds req to a .http file, replacing a request with the
+// same name and keeping the others and the file variables
+func SaveHTTPRequest(req Request, filePath string) error {
+	file := &HTTPFile{Variables: make(map[string]string)}
+	if _, err := os.Stat(filePath); err == nil {
+		if file, err = LoadHTTPFile(filePath); err != nil {
+			return err
+		}
+	}
+
+	replaced := false
+	for i := range file.Requests {
+		if httpRequestKey(file.Requests[i].Name) == httpRequestKey(req.Name) {
+			file.Requests[i] = req
+			replaced = true
+		}
+	}
+	if !replaced {
+		file.Requests = append(file.Requests, req)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(filePath, file.Format(), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// FindHTTPRequest looks for a named request in the .http files of dir
+func FindHTTPRequest(dir, name string) (*Request, error) {
+	var found *Request
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !IsHTTPFile(path) || found != nil {
+			return err
+		}
+		file, err := LoadHTTPFile(path)
+		if err != nil {
+			return err
+		}
+		if req, ok := file.Request(name); ok {
+			found = req
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("request '%s' not found", name)
+	}
+	return found, nil
+}
@@ -2,12 +2,14 @@ package storage
 
 // Request represents a saved API request in YAML format.
 type Request struct {
-	Name    string            `yaml:"name"`              // Unique name for the request
-	Method  string            `yaml:"method"`            // HTTP method (GET, POST, etc.)
-	URL     string            `yaml:"url"`               // Request URL (can contain variables)
-	Headers map[string]string `yaml:"headers,omitempty"` // HTTP headers
-	Query   map[string]string `yaml:"query,omitempty"`   // Query parameters
-	Body    interface{}       `yaml:"body,omitempty"`    // Request body (JSON or string)
+	Name    string            `yaml:"name"`               // Unique name for the request
+	Method  string            `yaml:"method"`             // HTTP method (GET, POST, etc.)
+	URL     string            `yaml:"url"`                // Request URL (can contain variables)
+	Headers map[string]string `yaml:"headers,omitempty"`  // HTTP headers
+	Query   map[string]string `yaml:"query,omitempty"`    // Query parameters
+	Body    interface{}       `yaml:"body,omitempty"`     // Request body (JSON or string)
+	UseAuth string            `yaml:"use_auth,omitempty"` // Auth profile of the active environment
+	Tags    []string          `yaml:"tags,omitempty"`     // Labels for search_requests, e.g. [users, smoke]
 }
 
 // Environment represents a set of environment variables.
@@ -16,6 +18,30 @@ type Environment struct {
 	Variables map[string]string `yaml:",inline"` // Key-value pairs for variables
 }
 
+// TLSConfig holds an environment's TLS settings, e.g. for self-signed dev servers.
+type TLSConfig struct {
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"` // Skip certificate verification
+	CAFile             string `yaml:"ca_file,omitempty"`              // Extra CA bundle (PEM) to trust
+}
+
+// AuthProfile holds an environment's credentials for one identity, so
+// switching environments switches credentials. The *_var fields name
+// variables holding secrets rather than the secrets themselves.
+type AuthProfile struct {
+	Type            string   `yaml:"type"`                        // bearer, basic, api_key or oauth2
+	TokenVar        string   `yaml:"token_var,omitempty"`         // bearer: variable holding the token
+	Username        string   `yaml:"username,omitempty"`          // basic, oauth2 password flow
+	UsernameVar     string   `yaml:"username_var,omitempty"`      // Or the variable holding it
+	PasswordVar     string   `yaml:"password_var,omitempty"`      // basic, oauth2 password flow
+	Header          string   `yaml:"header,omitempty"`            // api_key: header name (default X-API-Key)
+	ValueVar        string   `yaml:"value_var,omitempty"`         // api_key: variable holding the key
+	Flow            string   `yaml:"flow,omitempty"`              // oauth2: client_credentials (default) or password
+	TokenURL        string   `yaml:"token_url,omitempty"`         // oauth2
+	ClientID        string   `yaml:"client_id,omitempty"`         // oauth2
+	ClientSecretVar string   `yaml:"client_secret_var,omitempty"` // oauth2
+	Scopes          []string `yaml:"scopes,omitempty"`            // oauth2
+}
+
 // Collection represents a folder of related requests.
 type Collection struct {
 	Name        string    `yaml:"name"`                  // Collection name
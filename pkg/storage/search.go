@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// RequestMatch is a saved request found by SearchRequests
+type RequestMatch struct {
+	Path    string   // Relative to the requests directory; file.http#name for .http requests
+	Request Request  // The request as saved, without variables substituted
+	Fields  []string // Fields the query matched: name, url, tags, body
+}
+
+// SearchRequests finds saved requests, in every collection and .http file,
+// whose name, URL, tags or body contain all words of query
+// (case-insensitive) and that carry all of tags. An empty query matches
+// every request with the tags.
+func SearchRequests(baseDir, query string, tags []string) ([]RequestMatch, error) {
+	requestsDir := GetRequestsDir(baseDir)
+	if _, err := os.Stat(requestsDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	terms := strings.Fields(strings.ToLower(query))
+
+	var matches []RequestMatch
+	consider := func(path string, req Request) {
+		if !hasTags(req, tags) {
+			return
+		}
+		if fields, ok := matchRequest(req, terms); ok {
+			matches = append(matches, RequestMatch{Path: filepath.ToSlash(path), Request: req, Fields: fields})
+		}
+	}
+
+	err := filepath.Walk(requestsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relPath, _ := filepath.Rel(requestsDir, path)
+		switch {
+		case strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml"):
+			req, err := LoadRequest(path)
+			if err != nil {
+				return nil // Not a request; list_requests shows it anyway
+			}
+			consider(relPath, *req)
+		case IsHTTPFile(path):
+			file, err := LoadHTTPFile(path)
+			if err != nil {
+				return nil
+			}
+			for _, req := range file.Requests {
+				consider(relPath+"#"+req.Name, req)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search requests: %w", err)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matchRank(matches[i]) < matchRank(matches[j])
+	})
+	return matches, nil
+}
+
+// matchRequest reports whether every term occurs in one of the request's
+// searchable fields, and which fields matched
+func matchRequest(req Request, terms []string) ([]string, bool) {
+	fields := []struct {
+		name string
+		text string
+	}{
+		{"name", req.Name},
+		{"url", httpFileURL(req)},
+		{"tags", strings.Join(req.Tags, " ")},
+		{"body", searchableBody(req.Body)},
+	}
+
+	var matched []string
+	for _, term := range terms {
+		found := false
+		for _, field := range fields {
+			if strings.Contains(strings.ToLower(field.text), term) {
+				found = true
+				if !slices.Contains(matched, field.name) {
+					matched = append(matched, field.name)
+				}
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return matched, true
+}
+
+// matchRank orders name matches before URL and tag matches, and those
+// before matches only in the body
+func matchRank(m RequestMatch) int {
+	switch {
+	case slices.Contains(m.Fields, "name"):
+		return 0
+	case slices.Contains(m.Fields, "url"), slices.Contains(m.Fields, "tags"):
+		return 1
+	}
+	return 2
+}
+
+// hasTags reports whether req carries all of tags (case-insensitive)
+func hasTags(req Request, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, have := range req.Tags {
+			if strings.EqualFold(strings.TrimSpace(tag), have) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// searchableBody returns a request body as text
+func searchableBody(body interface{}) string {
+	switch b := body.(type) {
+	case nil:
+		return ""
+	case string:
+		return b
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Sprint(body)
+	}
+	return string(data)
+}
@@ -62,9 +62,22 @@ func ListRequests(baseDir string) ([]string, error) {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && (strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
-			relPath, _ := filepath.Rel(requestsDir, path)
+		if info.IsDir() {
+			return nil
+		}
+		relPath, _ := filepath.Rel(requestsDir, path)
+		if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
 			files = append(files, relPath)
+		} else if IsHTTPFile(path) {
+			// Each request in a .http file is listed as file.http#name
+			file, err := LoadHTTPFile(path)
+			if err != nil {
+				files = append(files, relPath+" (invalid: "+err.Error()+")")
+				return nil
+			}
+			for _, req := range file.Requests {
+				files = append(files, relPath+"#"+req.Name)
+			}
 		}
 		return nil
 	})
@@ -76,6 +89,80 @@ func ListRequests(baseDir string) ([]string, error) {
 	return files, nil
 }
 
+// CleanCollection normalizes a collection (a subfolder of requests/, nested
+// with slashes like "users/admin") the way request names are: lowercase,
+// spaces as dashes. It rejects paths leaving the requests directory.
+func CleanCollection(collection string) (string, error) {
+	collection = strings.Trim(filepath.ToSlash(strings.TrimSpace(collection)), "/")
+	if collection == "" {
+		return "", nil
+	}
+	cleaned := strings.ToLower(strings.ReplaceAll(collection, " ", "-"))
+	if filepath.IsAbs(collection) || strings.Contains(cleaned, "..") {
+		return "", fmt.Errorf("invalid collection '%s': use a relative folder name like users/admin", collection)
+	}
+	return cleaned, nil
+}
+
+// FindRequestFile looks for a request file named filename in every
+// collection of requestsDir. It returns "" when there is none, and an error
+// when several collections hold one.
+func FindRequestFile(requestsDir, filename string) (string, error) {
+	var matches []string
+	err := filepath.Walk(requestsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && info.Name() == filename {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to search requests: %w", err)
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return matches[0], nil
+	}
+	var names []string
+	for _, match := range matches {
+		relPath, _ := filepath.Rel(requestsDir, match)
+		names = append(names, filepath.ToSlash(relPath))
+	}
+	return "", fmt.Errorf("'%s' is in more than one collection (%s); include the collection in the name", filename, strings.Join(names, ", "))
+}
+
+// MoveRequest moves a request file into collection ("" for the top level of
+// requestsDir), removing folders it leaves empty, and returns the new path.
+func MoveRequest(requestsDir, filePath, collection string) (string, error) {
+	newPath := filepath.Join(requestsDir, collection, filepath.Base(filePath))
+	if newPath == filePath {
+		return newPath, nil
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return "", fmt.Errorf("%s already exists", newPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.Rename(filePath, newPath); err != nil {
+		return "", fmt.Errorf("failed to move request: %w", err)
+	}
+
+	// os.Remove only removes empty folders, so this stops at the first one in use
+	for dir := filepath.Dir(filePath); dir != filepath.Clean(requestsDir) && strings.HasPrefix(dir, filepath.Clean(requestsDir)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return newPath, nil
+}
+
 // GetRequestsDir returns the requests directory path
 func GetRequestsDir(baseDir string) string {
 	return filepath.Join(baseDir, "requests")
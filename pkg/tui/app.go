@@ -30,5 +30,10 @@ func Run() error {
 	// Clear program reference after run completes
 	globalProgram.Set(nil)
 
+	// Stop the servers and background commands tools left running
+	if m.agent != nil {
+		m.agent.Shutdown()
+	}
+
 	return err
 }
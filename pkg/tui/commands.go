@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/llm"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// slashCommandHelp lists the available slash commands.
+const slashCommandHelp = `Commands:
+  /model                     Show the current provider and model
+  /model <name>              Switch model (same provider)
+  /model <provider> <name>   Switch provider and model (ollama, gemini, openai, anthropic)
+  /models                    Pick from the models installed on the server (Ollama)
+  /curl <command>            Import a pasted curl command and run it
+  /curl save <name>          Save the last imported curl command as a request
+  /help                      Show this help`
+
+// handleSlashCommand runs a "/command args" entered in the input box.
+// Slash commands are handled locally and never sent to the agent.
+func (m Model) handleSlashCommand(input string) (Model, tea.Cmd) {
+	fields := strings.Fields(input)
+	command, args := fields[0], fields[1:]
+
+	if len(m.logs) > 0 {
+		m.logs = append(m.logs, logEntry{Type: "separator", Content: ""})
+	}
+	m.logs = append(m.logs, logEntry{Type: "user", Content: input})
+
+	switch command {
+	case "/model":
+		m = m.handleModelCommand(args)
+	case "/models":
+		m.textinput.SetValue("")
+		m.updateViewportContent()
+		return m, checkModelAsync(m.agent, true)
+	case "/curl":
+		// Take the raw remainder so quoting in the pasted command survives
+		rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(input), command))
+		m.textinput.SetValue("")
+		m.updateViewportContent()
+		return m, runCurlCommandAsync(m, rest)
+	case "/help":
+		m.logs = append(m.logs, logEntry{Type: "system", Content: slashCommandHelp})
+	default:
+		m.logs = append(m.logs, logEntry{Type: "error", Content: fmt.Sprintf("Unknown command %s (try /help)", command)})
+	}
+
+	m.textinput.SetValue("")
+	m.updateViewportContent()
+	return m, nil
+}
+
+// commandResultMsg carries the output of a slash command that ran a tool.
+type commandResultMsg struct {
+	output string
+	err    error
+}
+
+// runCurlCommandAsync runs the import_curl tool in the background for
+// "/curl <command>" and "/curl save <name>".
+func runCurlCommandAsync(m Model, rest string) tea.Cmd {
+	params := map[string]interface{}{"command": rest}
+	if name, ok := strings.CutPrefix(rest, "save "); ok {
+		params = map[string]interface{}{"save_as": strings.TrimSpace(name)}
+	}
+	if rest == "" {
+		return func() tea.Msg {
+			return commandResultMsg{err: fmt.Errorf("usage: /curl <curl command> or /curl save <name>")}
+		}
+	}
+	args, _ := json.Marshal(params)
+	agent := m.agent
+	return func() tea.Msg {
+		output, err := agent.ExecuteTool("import_curl", string(args))
+		return commandResultMsg{output: output, err: err}
+	}
+}
+
+// handleCommandResult shows the output of a slash command that ran a tool.
+func (m Model) handleCommandResult(msg commandResultMsg) Model {
+	if msg.output != "" {
+		m.logs = append(m.logs, logEntry{Type: "system", Content: strings.TrimSpace(msg.output)})
+	}
+	if msg.err != nil {
+		m.logs = append(m.logs, logEntry{Type: "error", Content: msg.err.Error()})
+	}
+	m.updateViewportContent()
+	return m
+}
+
+// handleModelCommand shows or switches the active LLM model.
+// With one argument the model of the current provider is changed in place;
+// with two arguments a new client is built for the given provider.
+func (m Model) handleModelCommand(args []string) Model {
+	switch len(args) {
+	case 0:
+		info := m.agent.GetModelInfo()
+		m.logs = append(m.logs, logEntry{Type: "system", Content: fmt.Sprintf("Provider: %s\nModel:    %s", info.Provider, info.Model)})
+		return m
+
+	case 1:
+		m.agent.SetModel(args[0])
+
+	case 2:
+		cfg := providerConfigFromViper(args[0])
+		cfg.Model = args[1]
+		client, err := llm.NewClient(cfg)
+		if err != nil {
+			m.logs = append(m.logs, logEntry{Type: "error", Content: fmt.Sprintf("Failed to switch provider: %v", err)})
+			return m
+		}
+		m.agent.SetLLMClient(client)
+
+	default:
+		m.logs = append(m.logs, logEntry{Type: "error", Content: "Usage: /model [provider] <name>"})
+		return m
+	}
+
+	info := m.agent.GetModelInfo()
+	m.modelName = info.Model
+	m.logs = append(m.logs, logEntry{Type: "system", Content: fmt.Sprintf("Switched to %s (%s)", info.Model, info.Provider)})
+
+	// The model badge width changed, so resize the input next to it
+	if m.width > 0 {
+		badgeWidth := lipgloss.Width(ModelBadgeStyle.Render(m.modelName))
+		m.textinput.Width = m.width - badgeWidth - 10
+	}
+	return m
+}
@@ -1,7 +1,9 @@
 package tui
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/blackcoderx/zap/pkg/core"
@@ -25,17 +27,42 @@ func configureToolLimits(agent *core.Agent) {
 	// Default limits (used if config doesn't specify)
 	defaultLimits := map[string]int{
 		// High-risk tools (external I/O, side effects)
-		"http_request":     25,
-		"performance_test": 5,
-		"webhook_listener": 10,
-		"auth_oauth2":      10,
-		"write_file":       10, // File writes require confirmation
+		"http_request":       25,
+		"performance_test":   5,
+		"fuzz_endpoint":      5,
+		"security_scan":      5,
+		"webhook_listener":   10,
+		"mock_server":        10,
+		"chaos_proxy":        10,
+		"graphql_introspect": 10,
+		"grpc_request":       25,
+		"sse_listen":         10,
+		"jsonrpc_request":    25,
+		"tls_inspect":        10,
+		"import_curl":        20,
+		"auth_oauth2":        10,
+		"login_flow":         10,
+		"db_query":           20,
+		"run_command":        10, // Every run requires confirmation
+		"write_file":         10, // File writes require confirmation
+		"apply_patch":        10, // File writes require confirmation
 		// Medium-risk tools (file system I/O)
-		"read_file":    50,
-		"list_files":   50,
-		"search_code":  30,
-		"save_request": 20,
-		"load_request": 30,
+		"read_file":        50,
+		"list_files":       50,
+		"search_code":      30,
+		"list_routes":      10,
+		"repo_map":         20,
+		"generate_openapi": 5,
+		"generate_tests":   5,
+		"save_request":     20,
+		"load_request":     30,
+		"move_request":     20,
+		"search_requests":  30,
+		"tail_logs":        30,
+		"docker":           20,
+		"git":              20,
+		"env_inspect":      10,
+		"export_curl":      30,
 		// Low-risk tools (in-memory, fast)
 		"variable":             100,
 		"assert_response":      100,
@@ -43,12 +70,19 @@ func configureToolLimits(agent *core.Agent) {
 		"auth_bearer":          50,
 		"auth_basic":           50,
 		"auth_helper":          50,
+		"auth_aws_sigv4":       20,
+		"auth_hmac":            20,
 		"validate_json_schema": 50,
+		"validate_openapi":     50,
 		"compare_responses":    30,
+		"history":              30,
+		"snapshot":             30,
 		// Special tools (prevent infinite loops)
-		"retry":      15,
-		"wait":       20,
-		"test_suite": 10,
+		"retry":            15,
+		"wait":             20,
+		"wait_for_service": 10,
+		"test_suite":       10,
+		"iterate":          10,
 		// Memory tool
 		"memory": 50,
 	}
@@ -101,19 +135,57 @@ func registerTools(agent *core.Agent, zapDir, workDir string, confirmManager *to
 
 	// Register codebase tools
 	httpTool := tools.NewHTTPTool(responseManager, varStore)
+	httpTool.SetRequestIDs(tools.RequestIDConfig{
+		Enabled:        viper.GetBool("request_ids.enabled"),
+		IdempotencyKey: viper.GetString("request_ids.idempotency_key"),
+		RequestID:      viper.GetString("request_ids.request_id"),
+	})
+	history := tools.NewResponseHistory(zapDir)
+	httpTool.SetHistory(history)
 	agent.RegisterTool(httpTool)
 	agent.RegisterTool(tools.NewReadFileTool(workDir))
 	agent.RegisterTool(tools.NewWriteFileTool(workDir, confirmManager))
+	agent.RegisterTool(tools.NewApplyPatchTool(workDir, confirmManager))
 	agent.RegisterTool(tools.NewListFilesTool(workDir))
 	agent.RegisterTool(tools.NewSearchCodeTool(workDir))
+	agent.RegisterTool(tools.NewListRoutesTool(workDir, agent.GetFramework()))
+	// Index the project's symbols in the background so repo_map answers at once
+	repoIndex := tools.NewRepoIndex(workDir, agent.GetFramework())
+	go repoIndex.Build()
+	agent.RegisterTool(tools.NewRepoMapTool(repoIndex))
+	agent.RegisterTool(tools.NewTailLogsTool(workDir, tools.LogSource{
+		File:      viper.GetString("logs.file"),
+		Container: viper.GetString("logs.container"),
+	}, varStore))
+	agent.RegisterTool(tools.NewDockerTool())
+	agent.RegisterTool(tools.NewGitTool(workDir))
+	agent.RegisterTool(tools.NewEnvInspectTool(workDir))
+	agent.RegisterTool(tools.NewRunCommandTool(workDir, zapDir, tools.CommandConfig{
+		Allow:   viper.GetStringSlice("commands.allow"),
+		Timeout: viper.GetInt("commands.timeout"),
+	}, confirmManager))
+
+	// Register protocol tools
+	agent.RegisterTool(tools.NewGraphQLIntrospectTool(httpTool, responseManager, varStore))
+	agent.RegisterTool(tools.NewGRPCTool(responseManager, varStore))
+	agent.RegisterTool(tools.NewSSETool(responseManager, varStore))
+	agent.RegisterTool(tools.NewJSONRPCTool(httpTool, responseManager, varStore))
+	agent.RegisterTool(tools.NewTLSInspectTool(varStore))
 
 	// Register persistence tools
 	persistence := tools.NewPersistenceTool(zapDir)
+	persistence.SetHTTPTool(httpTool)
+	persistence.SetLibraryDir(core.UserZapDir())
 	agent.RegisterTool(tools.NewSaveRequestTool(persistence))
 	agent.RegisterTool(tools.NewLoadRequestTool(persistence))
 	agent.RegisterTool(tools.NewListRequestsTool(persistence))
+	agent.RegisterTool(tools.NewSearchRequestsTool(persistence))
+	agent.RegisterTool(tools.NewMoveRequestTool(persistence))
 	agent.RegisterTool(tools.NewListEnvironmentsTool(persistence))
 	agent.RegisterTool(tools.NewSetEnvironmentTool(persistence))
+	agent.RegisterTool(tools.NewCurlImportTool(httpTool, responseManager, persistence, varStore))
+	agent.RegisterTool(tools.NewCurlExportTool(httpTool, persistence, varStore))
+	agent.RegisterTool(tools.NewDBQueryTool(persistence))
 
 	// Register Sprint 1 testing tools
 	assertTool := tools.NewAssertTool(responseManager)
@@ -123,82 +195,176 @@ func registerTools(agent *core.Agent, zapDir, workDir string, confirmManager *to
 	agent.RegisterTool(tools.NewVariableTool(varStore))
 	agent.RegisterTool(tools.NewWaitTool())
 	agent.RegisterTool(tools.NewRetryTool(agent))
+	agent.RegisterTool(tools.NewWaitForServiceTool(httpTool, responseManager, varStore))
 
 	// Register Sprint 2 tools
-	agent.RegisterTool(tools.NewSchemaValidationTool(responseManager))
+	agent.RegisterTool(tools.NewSchemaValidationTool(responseManager, workDir))
+	agent.RegisterTool(tools.NewOpenAPIValidationTool(httpTool, responseManager, varStore))
 	agent.RegisterTool(auth.NewBearerTool(varStore))
 	agent.RegisterTool(auth.NewBasicTool(varStore))
 	agent.RegisterTool(auth.NewHelperTool(responseManager, varStore))
-	agent.RegisterTool(tools.NewTestSuiteTool(httpTool, assertTool, extractTool, responseManager, varStore, zapDir))
+	suiteTool := tools.NewTestSuiteTool(httpTool, assertTool, extractTool, responseManager, varStore, zapDir)
+	suiteTool.SetPersistence(persistence)
+	suiteTool.SetToolExecutor(agent)
+	agent.RegisterTool(suiteTool)
+	agent.RegisterTool(tools.NewIterateTool(suiteTool))
+	agent.RegisterTool(tools.NewGenerateTestsTool(zapDir))
 	agent.RegisterTool(tools.NewCompareResponsesTool(responseManager, zapDir))
+	agent.RegisterTool(tools.NewHistoryTool(history, httpTool))
+	agent.RegisterTool(tools.NewGenerateOpenAPITool(history, workDir, agent.GetFramework()))
+	agent.RegisterTool(tools.NewSnapshotTool(responseManager, zapDir))
 
 	// Register Sprint 3 tools (MVP)
-	agent.RegisterTool(tools.NewPerformanceTool(httpTool, varStore))
+	agent.RegisterTool(tools.NewPerformanceTool(httpTool, varStore, zapDir))
+	agent.RegisterTool(tools.NewFuzzTool(httpTool, varStore))
+	agent.RegisterTool(tools.NewSecurityScanTool(httpTool, varStore))
 	agent.RegisterTool(tools.NewWebhookListenerTool(varStore))
+	agent.RegisterTool(tools.NewMockServerTool(varStore))
+	agent.RegisterTool(tools.NewChaosProxyTool(varStore))
 	agent.RegisterTool(auth.NewOAuth2Tool(varStore))
+	agent.RegisterTool(auth.NewAWSSigV4Tool(httpTool, varStore))
+	agent.RegisterTool(auth.NewHMACTool(httpTool, varStore))
+	agent.RegisterTool(auth.NewLoginFlowTool(httpTool, varStore, zapDir))
 
 	// Register memory tool
 	agent.RegisterTool(tools.NewMemoryTool(memStore))
 }
 
-// newLLMClient creates and configures the LLM client from Viper config.
-// Supports multiple providers: ollama (local/cloud) and gemini.
+// NewLLMClient creates and configures the LLM client from Viper config.
+// Used by the TUI and by `zap doctor` to check connectivity.
+// The provider-specific settings are mapped onto an llm.ProviderConfig and
+// the llm package factory builds the matching client.
 // Falls back to legacy config format for backward compatibility.
-func newLLMClient() llm.LLMClient {
+// An error is returned when the configured provider cannot be initialized.
+func NewLLMClient() (llm.LLMClient, error) {
 	provider := viper.GetString("provider")
+	if provider == "" {
+		// Legacy config format (backward compatibility)
+		return newOllamaClientFallback(viper.GetString("default_model")), nil
+	}
 
-	// Default model from config
-	defaultModel := viper.GetString("default_model")
-
-	switch provider {
-	case "gemini":
-		// Gemini configuration
-		apiKey := viper.GetString("gemini.api_key")
-		if apiKey == "" {
-			apiKey = os.Getenv("GEMINI_API_KEY")
-		}
+	return llm.NewClient(providerConfigFromViper(provider))
+}
 
-		if defaultModel == "" {
-			defaultModel = "gemini-2.5-flash-lite"
-		}
+// newFallbackClients builds the clients listed under "fallback_providers".
+// Entries that can't be initialized are skipped and reported as errors.
+func newFallbackClients() ([]llm.LLMClient, []error) {
+	var entries []core.FallbackProviderConfig
+	if err := viper.UnmarshalKey("fallback_providers", &entries); err != nil {
+		return nil, []error{fmt.Errorf("invalid fallback_providers config: %w", err)}
+	}
 
-		client, err := llm.NewGeminiClient(apiKey, defaultModel)
+	var clients []llm.LLMClient
+	var errs []error
+	for _, entry := range entries {
+		cfg := providerConfigFromViper(entry.Provider)
+		cfg.Model = entry.Model // default_model belongs to the primary provider
+		client, err := llm.NewClient(cfg)
 		if err != nil {
-			// Fall back to Ollama if Gemini client creation fails
-			return newOllamaClientFallback(defaultModel)
-		}
-		return client
-
-	case "ollama":
-		// New Ollama config format
-		ollamaURL := viper.GetString("ollama.url")
-		ollamaAPIKey := viper.GetString("ollama.api_key")
-
-		if ollamaURL == "" {
-			// Check mode for defaults
-			mode := viper.GetString("ollama.mode")
-			if mode == "local" {
-				ollamaURL = "http://localhost:11434"
-			} else {
-				ollamaURL = "https://ollama.com"
-			}
+			errs = append(errs, fmt.Errorf("fallback provider %s skipped: %w", entry.Provider, err))
+			continue
 		}
+		clients = append(clients, client)
+	}
+	return clients, errs
+}
+
+// retryPolicyFromViper reads "llm_retry" from config, using
+// llm.DefaultRetryPolicy for any value that isn't set.
+func retryPolicyFromViper() llm.RetryPolicy {
+	policy := llm.DefaultRetryPolicy
+	if v := viper.GetInt("llm_retry.max_attempts"); v > 0 {
+		policy.MaxAttempts = v
+	}
+	if v := viper.GetInt("llm_retry.base_delay_ms"); v > 0 {
+		policy.BaseDelay = time.Duration(v) * time.Millisecond
+	}
+	if v := viper.GetInt("llm_retry.max_delay_ms"); v > 0 {
+		policy.MaxDelay = time.Duration(v) * time.Millisecond
+	}
+	return policy
+}
 
-		if defaultModel == "" {
-			mode := viper.GetString("ollama.mode")
-			if mode == "local" {
-				defaultModel = "llama3"
-			} else {
-				defaultModel = "qwen3-coder:480b-cloud"
+// providerConfigFromViper reads the settings for the given provider from
+// config.json (via Viper) into an llm.ProviderConfig.
+func providerConfigFromViper(provider string) llm.ProviderConfig {
+	cfg := llm.ProviderConfig{
+		Provider: provider,
+		Model:    viper.GetString("default_model"),
+		Options:  generationOptionsFromViper(),
+	}
+
+	switch provider {
+	case llm.ProviderOllama:
+		cfg.BaseURL = viper.GetString("ollama.url")
+		cfg.APIKey = configSecret("ollama.api_key")
+
+		// Cloud mode has different defaults than a local server
+		if viper.GetString("ollama.mode") != "local" {
+			if cfg.BaseURL == "" {
+				cfg.BaseURL = "https://ollama.com"
+			}
+			if cfg.Model == "" {
+				cfg.Model = "qwen3-coder:480b-cloud"
 			}
 		}
 
-		return llm.NewOllamaClient(ollamaURL, defaultModel, ollamaAPIKey)
+	case llm.ProviderGemini:
+		cfg.APIKey = configSecret("gemini.api_key")
 
-	default:
-		// Legacy config format (backward compatibility)
-		return newOllamaClientFallback(defaultModel)
+	case llm.ProviderOpenAI:
+		cfg.BaseURL = viper.GetString("openai.base_url")
+		cfg.APIKey = configSecret("openai.api_key")
+
+	case llm.ProviderAnthropic:
+		cfg.APIKey = configSecret("anthropic.api_key")
+	}
+
+	// Keep API keys out of displayed output and logs
+	core.RegisterSecretValue(cfg.APIKey)
+	core.RegisterSecretValue(os.Getenv(strings.ToUpper(provider) + "_API_KEY"))
+
+	return cfg
+}
+
+// configSecret reads a secret setting, resolving "keyring:" references from
+// the OS keyring. A key that can't be read is treated as unset (`zap doctor`
+// reports why).
+func configSecret(key string) string {
+	value, err := core.ResolveSecret(viper.GetString(key))
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// newEmbedder creates the embedding client for semantic memory recall.
+// It uses memory.embedding_provider (default: the chat provider) with that
+// provider's credentials, and memory.embedding_model.
+func newEmbedder() (llm.Embedder, error) {
+	provider := viper.GetString("memory.embedding_provider")
+	if provider == "" {
+		provider = viper.GetString("provider")
+	}
+
+	cfg := providerConfigFromViper(provider)
+	cfg.Model = viper.GetString("memory.embedding_model")
+	return llm.NewEmbedder(cfg)
+}
+
+// generationOptionsFromViper reads the "generation" config block.
+func generationOptionsFromViper() llm.GenerationOptions {
+	var opts llm.GenerationOptions
+	if viper.IsSet("generation.temperature") {
+		temperature := viper.GetFloat64("generation.temperature")
+		opts.Temperature = &temperature
+	}
+	if viper.IsSet("generation.top_p") {
+		topP := viper.GetFloat64("generation.top_p")
+		opts.TopP = &topP
 	}
+	opts.NumCtx = viper.GetInt("generation.num_ctx")
+	return opts
 }
 
 // newOllamaClientFallback creates an Ollama client using legacy config fields.
@@ -209,7 +375,7 @@ func newOllamaClientFallback(defaultModel string) *llm.OllamaClient {
 		ollamaURL = "https://ollama.com"
 	}
 
-	ollamaAPIKey := viper.GetString("ollama_api_key")
+	ollamaAPIKey := configSecret("ollama_api_key")
 	if ollamaAPIKey == "" {
 		ollamaAPIKey = os.Getenv("OLLAMA_API_KEY")
 	}
@@ -218,7 +384,9 @@ func newOllamaClientFallback(defaultModel string) *llm.OllamaClient {
 		defaultModel = "llama3"
 	}
 
-	return llm.NewOllamaClient(ollamaURL, defaultModel, ollamaAPIKey)
+	client := llm.NewOllamaClient(ollamaURL, defaultModel, ollamaAPIKey)
+	client.SetOptions(generationOptionsFromViper())
+	return client
 }
 
 // newSpinner creates a spinner with the ZAP style (dots animation).
@@ -312,14 +480,29 @@ func InitialModel() Model {
 	// Get .zap directory path
 	zapDir := core.ZapFolderName
 
-	// Get model name for display
-	modelName := viper.GetString("default_model")
-	if modelName == "" {
-		modelName = "llama3"
+	// Build the LLM client for the configured provider. If it can't be
+	// initialized, fall back to Ollama and tell the user why.
+	var startupLogs []logEntry
+	client, err := NewLLMClient()
+	if err != nil {
+		client = newOllamaClientFallback("")
+		startupLogs = append(startupLogs, logEntry{
+			Type:    "error",
+			Content: fmt.Sprintf("Failed to initialize %s provider: %v (falling back to Ollama)", viper.GetString("provider"), err),
+		})
+	}
+
+	// Fallback providers are tried in order when the primary fails
+	fallbacks, fallbackErrs := newFallbackClients()
+	for _, fbErr := range fallbackErrs {
+		startupLogs = append(startupLogs, logEntry{Type: "error", Content: fbErr.Error()})
 	}
 
-	client := newLLMClient()
+	// Model name for the footer badge comes from the client actually in use
+	modelName := client.GetModel()
+
 	agent := core.NewAgent(client)
+	agent.SetFallbackClients(fallbacks)
 
 	// Set framework from config for context-aware assistance
 	framework := viper.GetString("framework")
@@ -329,6 +512,47 @@ func InitialModel() Model {
 	}
 	agent.SetFramework(framework)
 
+	// Retry transient LLM failures with jittered exponential backoff
+	agent.SetRetryPolicy(retryPolicyFromViper())
+
+	// Stop before burning through the user's cloud quota
+	agent.SetBudget(core.Budget{
+		MaxTokens:          viper.GetInt("budget.max_tokens"),
+		MaxCostUSD:         viper.GetFloat64("budget.max_cost_usd"),
+		InputPricePerMTok:  viper.GetFloat64("budget.input_price_per_mtok"),
+		OutputPricePerMTok: viper.GetFloat64("budget.output_price_per_mtok"),
+	})
+
+	// Summarize older turns once history exceeds this many (estimated) tokens
+	if budget := viper.GetInt("history_token_budget"); budget > 0 {
+		agent.SetHistoryTokenBudget(budget)
+	}
+
+	// Native tool calling is on by default; it can be forced off for models
+	// that accept the tools parameter but use it poorly
+	if viper.IsSet("native_tools") {
+		agent.SetNativeToolCalling(viper.GetBool("native_tools"))
+	}
+
+	// Opt-in audit log of every prompt and completion (secrets masked)
+	if viper.GetBool("llm_log") {
+		agent.SetLLMLog(core.NewLLMLog(zapDir))
+	}
+
+	// Project instructions (ZAP.md in the project root)
+	if instructions, err := core.LoadProjectInstructions(workDir); err != nil {
+		startupLogs = append(startupLogs, logEntry{Type: "error", Content: err.Error()})
+	} else {
+		agent.SetProjectInstructions(instructions)
+	}
+
+	// Project-specific system prompt overrides (.zap/prompts/*.md)
+	if overrides, err := core.LoadPromptOverrides(zapDir); err != nil {
+		startupLogs = append(startupLogs, logEntry{Type: "error", Content: err.Error()})
+	} else {
+		agent.SetPromptOverrides(overrides)
+	}
+
 	// Configure per-tool call limits before registering tools
 	configureToolLimits(agent)
 
@@ -344,12 +568,21 @@ func InitialModel() Model {
 	memStore := core.NewMemoryStore(zapDir)
 	agent.SetMemoryStore(memStore)
 
+	// Semantic memory recall (opt-in; needs an embedding model)
+	if viper.GetBool("memory.embeddings") {
+		if embedder, err := newEmbedder(); err != nil {
+			startupLogs = append(startupLogs, logEntry{Type: "error", Content: fmt.Sprintf("Semantic memory disabled: %v", err)})
+		} else {
+			memStore.SetEmbedder(embedder)
+		}
+	}
+
 	registerTools(agent, zapDir, workDir, confirmManager, memStore)
 
 	return Model{
 		textinput:        newTextInput(),
 		spinner:          newSpinner(),
-		logs:             []logEntry{},
+		logs:             startupLogs,
 		thinking:         false,
 		agent:            agent,
 		ready:            false,
@@ -382,5 +615,6 @@ func (m Model) Init() tea.Cmd {
 		textinput.Blink,
 		m.spinner.Tick,
 		animTick(), // Start harmonica spring animation loop
+		checkModelAsync(m.agent, false),
 	)
 }
@@ -14,6 +14,9 @@ func (m Model) handleKeyMsg(msg tea.KeyMsg) (Model, tea.Cmd) {
 	if m.confirmationMode {
 		return m.handleConfirmationKeys(msg)
 	}
+	if m.modelPickerMode {
+		return m.handleModelPickerKeys(msg)
+	}
 
 	switch msg.String() {
 	case "ctrl+c":
@@ -157,6 +160,14 @@ func (m Model) handleEnter() (Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Slash commands are handled by the TUI, not the agent
+	if strings.HasPrefix(userInput, "/") {
+		m.inputHistory = append(m.inputHistory, userInput)
+		m.historyIdx = -1
+		m.savedInput = ""
+		return m.handleSlashCommand(userInput)
+	}
+
 	// Add separator if there are previous logs
 	if len(m.logs) > 0 {
 		m.logs = append(m.logs, logEntry{Type: "separator", Content: ""})
@@ -188,7 +199,7 @@ func (m Model) handleViewportScroll(msg tea.KeyMsg) (Model, tea.Cmd) {
 	return m, cmd
 }
 
-// handleConfirmationKeys processes keyboard input during file write confirmation.
+// handleConfirmationKeys processes keyboard input during file write and command confirmation.
 func (m Model) handleConfirmationKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
 	switch msg.String() {
 	case "y", "Y":
@@ -197,8 +208,13 @@ func (m Model) handleConfirmationKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
 			m.confirmManager.SendResponse(true)
 		}
 		m.confirmationMode = false
-		m.logs = append(m.logs, logEntry{Type: "user", Content: "Approved file change"})
+		if m.pendingCommand != nil {
+			m.logs = append(m.logs, logEntry{Type: "user", Content: "Approved command: " + m.pendingCommand.Command})
+		} else {
+			m.logs = append(m.logs, logEntry{Type: "user", Content: "Approved file change"})
+		}
 		m.pendingConfirmation = nil
+		m.pendingCommand = nil
 		m.updateViewportContent()
 		return m, nil
 
@@ -208,8 +224,13 @@ func (m Model) handleConfirmationKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
 			m.confirmManager.SendResponse(false)
 		}
 		m.confirmationMode = false
-		m.logs = append(m.logs, logEntry{Type: "error", Content: "Rejected file change"})
+		if m.pendingCommand != nil {
+			m.logs = append(m.logs, logEntry{Type: "error", Content: "Rejected command: " + m.pendingCommand.Command})
+		} else {
+			m.logs = append(m.logs, logEntry{Type: "error", Content: "Rejected file change"})
+		}
 		m.pendingConfirmation = nil
+		m.pendingCommand = nil
 		m.updateViewportContent()
 		return m, nil
 
@@ -220,6 +241,7 @@ func (m Model) handleConfirmationKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
 		}
 		m.confirmationMode = false
 		m.pendingConfirmation = nil
+		m.pendingCommand = nil
 		if msg.String() == "ctrl+c" {
 			// Save session summary before quitting
 			if m.memoryStore != nil {
@@ -17,7 +17,7 @@ import (
 
 // logEntry represents a single log line in the UI
 type logEntry struct {
-	Type      string        // "user", "thinking", "tool", "observation", "response", "error", "separator", "streaming"
+	Type      string        // "user", "thinking", "tool", "observation", "response", "error", "separator", "streaming", "system"
 	Content   string
 	ToolArgs  string        // Tool arguments (for "tool" entries)
 	ToolUsed  int           // Current usage count (for "tool" entries)
@@ -67,11 +67,20 @@ type Model struct {
 	lastToolLimit  int                // Last tool's limit
 	toolStartTime  time.Time          // When the current tool call started
 
+	// Progress of a long-running tool, e.g. performance_test (nil when none)
+	toolProgress *core.ProgressEvent
+
 	// Confirmation state for file write approval
 	confirmationMode    bool                      // True when awaiting user confirmation
 	pendingConfirmation *core.FileConfirmation    // Details of the pending file change
+	pendingCommand      *core.CommandConfirmation // Details of the pending command
 	confirmManager      *tools.ConfirmationManager // Shared confirmation manager
 
+	// Model picker state (shown when the configured model isn't installed, or via /models)
+	modelPickerMode bool     // True while the picker is open
+	modelChoices    []string // Models available from the provider
+	modelPickerIdx  int      // Index of the highlighted model
+
 	// Persistent memory store
 	memoryStore *core.MemoryStore
 
@@ -103,7 +112,7 @@ type agentCancelMsg struct {
 	cancel context.CancelFunc
 }
 
-// confirmationTimeoutMsg signals that a file confirmation has timed out
+// confirmationTimeoutMsg signals that a file or command confirmation has timed out
 type confirmationTimeoutMsg struct{}
 
 // programRef holds the program reference for sending messages from goroutines.
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackcoderx/zap/pkg/core"
+	"github.com/blackcoderx/zap/pkg/llm"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// modelListMsg carries the result of listing the provider's models.
+type modelListMsg struct {
+	models []string
+	err    error
+	manual bool // true when opened with /models rather than the startup check
+}
+
+// checkModelAsync lists the provider's models in the background.
+// The startup check (manual=false) silently ignores providers that can't
+// list models; /models reports the error instead.
+func checkModelAsync(agent *core.Agent, manual bool) tea.Cmd {
+	return func() tea.Msg {
+		models, err := agent.ListModels()
+		return modelListMsg{models: models, err: err, manual: manual}
+	}
+}
+
+// handleModelList validates the configured model against the installed
+// models and opens the picker if it is missing (or if the user asked).
+func (m Model) handleModelList(msg modelListMsg) Model {
+	if msg.err != nil {
+		if msg.manual {
+			m.logs = append(m.logs, logEntry{Type: "error", Content: fmt.Sprintf("Failed to list models: %v", msg.err)})
+			m.updateViewportContent()
+		}
+		return m
+	}
+
+	current := m.agent.GetModelInfo().Model
+	if !msg.manual {
+		if llm.HasModel(msg.models, current) {
+			return m
+		}
+		if len(msg.models) == 0 {
+			m.logs = append(m.logs, logEntry{
+				Type:    "error",
+				Content: fmt.Sprintf("Model %q is not installed and no models were found. Run: ollama pull %s", current, current),
+			})
+			m.updateViewportContent()
+			return m
+		}
+		m.logs = append(m.logs, logEntry{
+			Type:    "error",
+			Content: fmt.Sprintf("Model %q is not installed. Pick one of the available models below, or run: ollama pull %s", current, current),
+		})
+	}
+
+	if len(msg.models) == 0 {
+		m.logs = append(m.logs, logEntry{Type: "system", Content: "No models available"})
+		m.updateViewportContent()
+		return m
+	}
+
+	m.modelPickerMode = true
+	m.modelChoices = msg.models
+	m.modelPickerIdx = 0
+	for i, name := range msg.models {
+		if name == current {
+			m.modelPickerIdx = i
+		}
+	}
+	m.updateViewportContent()
+	return m
+}
+
+// handleModelPickerKeys processes keyboard input while the model picker is open.
+func (m Model) handleModelPickerKeys(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if m.modelPickerIdx > 0 {
+			m.modelPickerIdx--
+		}
+
+	case "down", "j":
+		if m.modelPickerIdx < len(m.modelChoices)-1 {
+			m.modelPickerIdx++
+		}
+
+	case "enter":
+		m.modelPickerMode = false
+		m = m.handleModelCommand([]string{m.modelChoices[m.modelPickerIdx]})
+		m.modelChoices = nil
+
+	case "esc":
+		m.modelPickerMode = false
+		m.modelChoices = nil
+
+	case "ctrl+c":
+		if m.memoryStore != nil {
+			m.memoryStore.SaveSessionSummary(m.agent.GetHistory())
+		}
+		return m, tea.Quit
+	}
+
+	m.updateViewportContent()
+	// Non-nil so the key isn't passed on to the text input
+	return m, func() tea.Msg { return nil }
+}
+
+// renderModelPicker renders the list of models with the cursor on the selection.
+func (m Model) renderModelPicker() string {
+	pad := strings.Repeat(" ", ContentPadLeft)
+
+	var b strings.Builder
+	b.WriteString(pad + ConfirmHeaderStyle.Render("Select a model") + "\n\n")
+	for i, name := range m.modelChoices {
+		if i == m.modelPickerIdx {
+			b.WriteString(pad + ConfirmApproveStyle.Render("> "+name) + "\n")
+		} else {
+			b.WriteString(pad + ObservationStyle.Render("  "+name) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// renderModelPickerFooter renders the footer while the model picker is open.
+func (m Model) renderModelPickerFooter() string {
+	left := ConfirmHeaderStyle.Render("Switch model")
+
+	right := ShortcutKeyStyle.Render("↑↓") + ShortcutDescStyle.Render(" select") +
+		"    " +
+		ShortcutKeyStyle.Render("enter") + ShortcutDescStyle.Render(" use") +
+		"    " +
+		ShortcutKeyStyle.Render("esc") + ShortcutDescStyle.Render(" keep current")
+
+	gap := m.width - lipglossWidth(left) - lipglossWidth(right) - 4
+	if gap < 2 {
+		gap = 2
+	}
+
+	return FooterStyle.Width(m.width).Render(left + strings.Repeat(" ", gap) + right)
+}
@@ -226,6 +226,13 @@ var (
 	// Total usage style
 	TotalUsageStyle = lipgloss.NewStyle().
 			Foreground(AccentColor)
+
+	// Progress bar of a long-running tool
+	ProgressFillStyle = lipgloss.NewStyle().
+				Foreground(AccentColor)
+
+	ProgressEmptyStyle = lipgloss.NewStyle().
+				Foreground(MutedColor)
 )
 
 // Diff colors for file write confirmation
@@ -66,6 +66,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m = m.handleAgentEvent(msg)
 		cmds = append(cmds, m.spinner.Tick)
 
+	case modelListMsg:
+		m = m.handleModelList(msg)
+
+	case commandResultMsg:
+		m = m.handleCommandResult(msg)
+
 	case agentCancelMsg:
 		m.cancelAgent = msg.cancel
 
@@ -87,10 +93,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Handle confirmation timeout - exit confirmation mode and show error
 		if m.confirmationMode {
 			m.confirmationMode = false
+			content := "File confirmation timed out (5 minutes). The file was not modified."
+			if m.pendingCommand != nil {
+				content = "Command confirmation timed out (5 minutes). The command was not run."
+			}
 			m.pendingConfirmation = nil
+			m.pendingCommand = nil
 			m.logs = append(m.logs, logEntry{
 				Type:    "error",
-				Content: "File confirmation timed out (5 minutes). The file was not modified.",
+				Content: content,
 			})
 			m.updateViewportContent()
 		}
@@ -224,6 +235,11 @@ func (m Model) handleAgentEvent(msg agentEventMsg) Model {
 		}
 		m.status = "thinking"
 		m.currentTool = ""
+		m.toolProgress = nil
+
+	case "progress":
+		// A long-running tool reporting how far it has got
+		m.toolProgress = msg.event.Progress
 
 	case "answer":
 		// Replace streaming entry with final response if exists
@@ -235,11 +251,24 @@ func (m Model) handleAgentEvent(msg agentEventMsg) Model {
 		m.streamingBuffer = ""
 		m.status = "idle"
 
-	case "error":
+	case "error", "budget_exceeded":
 		m.logs = append(m.logs, logEntry{Type: "error", Content: msg.event.Content})
 		m.streamingBuffer = ""
 		m.status = "idle"
 
+	case "fallback", "retry":
+		// Discard any partial output from the failed attempt
+		m.streamingBuffer = ""
+		if len(m.logs) > 0 && m.logs[len(m.logs)-1].Type == "streaming" {
+			m.logs = m.logs[:len(m.logs)-1]
+		}
+		m.logs = append(m.logs, logEntry{Type: "system", Content: msg.event.Content})
+		m.status = "thinking"
+
+	case "notice":
+		// Messages from a running tool, e.g. a URL the user has to open
+		m.logs = append(m.logs, logEntry{Type: "system", Content: msg.event.Content})
+
 	case "tool_usage":
 		if msg.event.ToolUsage != nil {
 			usage := msg.event.ToolUsage
@@ -276,6 +305,9 @@ func (m Model) handleAgentEvent(msg agentEventMsg) Model {
 		if msg.event.FileConfirmation != nil {
 			m.confirmationMode = true
 			m.pendingConfirmation = msg.event.FileConfirmation
+		} else if msg.event.CommandConfirmation != nil {
+			m.confirmationMode = true
+			m.pendingCommand = msg.event.CommandConfirmation
 		}
 	}
 
@@ -291,6 +323,7 @@ func (m Model) handleAgentDone(msg agentDoneMsg) Model {
 	m.thinking = false
 	m.status = "idle"
 	m.currentTool = ""
+	m.toolProgress = nil
 	m.cancelAgent = nil // Clear the cancel function
 
 	// Reset tool usage display
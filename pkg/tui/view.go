@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/blackcoderx/zap/pkg/core"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -40,7 +41,9 @@ func (m *Model) updateViewportContent() {
 	content.WriteString("\n")
 
 	// In confirmation mode, show the diff view
-	if m.confirmationMode && m.pendingConfirmation != nil {
+	if m.confirmationMode && m.pendingCommand != nil {
+		content.WriteString(m.renderCommandConfirmationView())
+	} else if m.confirmationMode && m.pendingConfirmation != nil {
 		content.WriteString(m.renderConfirmationView())
 	} else {
 		for _, entry := range m.logs {
@@ -51,6 +54,10 @@ func (m *Model) updateViewportContent() {
 			content.WriteString(line)
 			content.WriteString("\n")
 		}
+		if m.modelPickerMode {
+			content.WriteString("\n")
+			content.WriteString(m.renderModelPicker())
+		}
 	}
 
 	// Check if we were at the bottom before updating
@@ -60,7 +67,7 @@ func (m *Model) updateViewportContent() {
 
 	// Only auto-scroll to bottom if we were already at the bottom
 	// This allows users to scroll up and read history
-	if atBottom || m.thinking || m.confirmationMode {
+	if atBottom || m.thinking || m.confirmationMode || m.modelPickerMode {
 		m.viewport.GotoBottom()
 	}
 }
@@ -118,6 +125,14 @@ func (m *Model) formatLogEntry(entry logEntry) string {
 	case "interrupted":
 		return pad + InterruptedStyle.Render("  interrupted")
 
+	case "system":
+		// Output of slash commands
+		lines := strings.Split(entry.Content, "\n")
+		for i, line := range lines {
+			lines[i] = pad + ObservationStyle.Render("  "+line)
+		}
+		return strings.Join(lines, "\n")
+
 	case "separator":
 		return ""
 
@@ -199,12 +214,49 @@ func (m Model) renderStatusText() string {
 	case "streaming":
 		return StatusLabelStyle.Render("streaming")
 	case "tool":
+		if m.toolProgress != nil {
+			return m.renderProgress(*m.toolProgress)
+		}
 		return StatusLabelStyle.Render("tool calling")
 	default:
 		return StatusIdleStyle.Render("ready")
 	}
 }
 
+// renderProgress renders a running tool's progress: a bar when the share done
+// is known, then the count, rate and time left.
+func (m Model) renderProgress(p core.ProgressEvent) string {
+	const barWidth = 20
+
+	bar := ""
+	var parts []string
+	if fraction := p.Fraction(); fraction >= 0 {
+		filled := int(fraction * barWidth)
+		bar = ProgressFillStyle.Render(strings.Repeat("█", filled)) +
+			ProgressEmptyStyle.Render(strings.Repeat("░", barWidth-filled)) + " "
+		parts = append(parts, fmt.Sprintf("%d%%", int(fraction*100)))
+	}
+
+	count := fmt.Sprintf("%d", p.Completed)
+	if p.Total > 0 {
+		count += fmt.Sprintf("/%d", p.Total)
+	}
+	if p.Unit != "" {
+		count += " " + p.Unit
+	}
+	parts = append(parts, count)
+	if p.RPS > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f/s", p.RPS))
+	}
+	if p.Remaining > 0 {
+		parts = append(parts, p.Remaining.Round(time.Second).String()+" left")
+	} else {
+		parts = append(parts, p.Elapsed.Round(time.Second).String())
+	}
+
+	return StatusLabelStyle.Render(p.Tool+" ") + bar + StatusLabelStyle.Render(strings.Join(parts, " · "))
+}
+
 // renderAnimatedCircle renders the pulsing status circle using harmonica spring values.
 func (m Model) renderAnimatedCircle() string {
 	if !m.thinking {
@@ -236,6 +288,9 @@ func (m Model) renderFooter() string {
 	if m.confirmationMode {
 		return m.renderConfirmationFooter()
 	}
+	if m.modelPickerMode {
+		return m.renderModelPickerFooter()
+	}
 
 	// Left side: animated circle + status + model name
 	circle := m.renderAnimatedCircle()
@@ -343,6 +398,30 @@ func (m Model) renderConfirmationView() string {
 	return sb.String()
 }
 
+// renderCommandConfirmationView renders the run_command confirmation dialog.
+func (m Model) renderCommandConfirmationView() string {
+	c := m.pendingCommand
+	pad := strings.Repeat(" ", ContentPadLeft)
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(pad + ConfirmHeaderStyle.Render("  Command Confirmation"))
+	sb.WriteString("\n\n")
+
+	sb.WriteString(pad + ConfirmPathStyle.Render("  $ "+c.Command))
+	sb.WriteString("\n")
+	if c.Dir != "" {
+		sb.WriteString(pad + DiffContextStyle.Render("  in "+c.Dir))
+		sb.WriteString("\n")
+	}
+	if c.Background {
+		sb.WriteString(pad + DiffContextStyle.Render("  left running in the background, output in .zap/logs/"))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
 // renderColoredDiff applies syntax highlighting to a unified diff.
 func (m Model) renderColoredDiff(diff string) string {
 	if diff == "" {
@@ -379,6 +458,9 @@ func (m Model) renderColoredDiff(diff string) string {
 // renderConfirmationFooter renders the footer with confirmation prompt.
 func (m Model) renderConfirmationFooter() string {
 	left := ConfirmHeaderStyle.Render("Apply changes?")
+	if m.pendingCommand != nil {
+		left = ConfirmHeaderStyle.Render("Run command?")
+	}
 
 	right := ShortcutKeyStyle.Render("y") + ShortcutDescStyle.Render(" approve") +
 		"    " +